@@ -13,6 +13,9 @@ var (
   # View cached configurations 
   %[1]s config view
 
+  # View cached configurations and the effective scan config of a config file
+  %[1]s config view --config ci/kubescape.yaml
+
   # Delete cached configurations
   %[1]s config delete
 
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/kubescape/go-logger"
+	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/meta"
 	v1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
 	"github.com/spf13/cobra"
@@ -11,15 +13,20 @@ import (
 
 func getViewCmd(ks meta.IKubescape) *cobra.Command {
 
+	var viewConfig = v1.ViewConfig{Writer: os.Stdout}
+
 	// configCmd represents the config command
-	return &cobra.Command{
+	viewCmd := &cobra.Command{
 		Use:   "view",
 		Short: "View cached configurations",
 		Long:  ``,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := ks.ViewCachedConfig(&v1.ViewConfig{Writer: os.Stdout}); err != nil {
+			if err := ks.ViewCachedConfig(&viewConfig); err != nil {
 				logger.L().Fatal(err.Error())
 			}
 		},
 	}
+	viewCmd.Flags().StringVar(&viewConfig.ScanConfigFile, "config", "", fmt.Sprintf("Path to the scan config file. If not set will load %s from the repository root [$%s]", cautils.ScanConfigFilename, cautils.ScanConfigEnvVar))
+
+	return viewCmd
 }
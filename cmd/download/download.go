@@ -8,6 +8,7 @@ import (
 
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
 	"github.com/kubescape/kubescape/v3/core/core"
 	"github.com/kubescape/kubescape/v3/core/meta"
 	v1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
@@ -36,6 +37,13 @@ var (
 
   # Download the configured controls-inputs 
   %[1]s download controls-inputs 
+
+  # Download all artifacts and sign their checksum manifest, to scan with '--use-artifacts-from /tmp --policies-public-key cosign.pub --require-signed-policies'
+  %[1]s download artifacts --output /tmp
+  cosign sign-blob --key cosign.key --output-signature /tmp/checksums.txt.sig /tmp/checksums.txt
+
+  # Download all artifacts and push them as a policy bundle to an OCI registry, to scan with '--use-artifacts-from oci://registry.example.com/kubescape/policies:v1'
+  %[1]s download artifacts --push oci://registry.example.com/kubescape/policies:v1
 `, cautils.ExecName())
 )
 
@@ -71,6 +79,10 @@ func GetDownloadCmd(ks meta.IKubescape) *cobra.Command {
 				return fmt.Errorf("no arguements provided")
 			}
 
+			if downloadInfo.PushReference != "" && !getter.IsOCIReference(downloadInfo.PushReference) {
+				return fmt.Errorf("invalid push reference '%s', the reference must start with %s", downloadInfo.PushReference, getter.OCIScheme)
+			}
+
 			downloadInfo.Target = args[0]
 			if len(args) >= 2 {
 				downloadInfo.Identifier = args[1]
@@ -85,6 +97,7 @@ func GetDownloadCmd(ks meta.IKubescape) *cobra.Command {
 	downloadCmd.PersistentFlags().StringVarP(&downloadInfo.AccountID, "account", "", "", "Kubescape SaaS account ID. Default will load account ID from cache")
 	downloadCmd.PersistentFlags().StringVarP(&downloadInfo.AccessKey, "access-key", "", "", "Kubescape SaaS access key. Default will load access key from cache")
 	downloadCmd.Flags().StringVarP(&downloadInfo.Path, "output", "o", "", "Output file. If not specified, will save in `~/.kubescape/<policy name>.json`")
+	downloadCmd.Flags().StringVar(&downloadInfo.PushReference, "push", "", "Push the downloaded artifacts as a policy bundle to an OCI registry, e.g. oci://registry.example.com/kubescape/policies:v1")
 
 	return downloadCmd
 }
@@ -0,0 +1,60 @@
+package explore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kubescape/kubescape/v3/cmd/shared"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/meta"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/spf13/cobra"
+)
+
+var exploreCmdExamples = fmt.Sprintf(`
+  Explore command browses the failed controls of a scan report in an interactive terminal UI:
+  the controls, the failing resources, their failed paths, remediation and YAML.
+  Mark findings with space to write exceptions for them (e) or to fix them (f).
+
+  # Explore the results of a scan
+  1) %[1]s scan . --format json --output output.json
+  2) %[1]s explore output.json
+
+  # Explore the findings of high severity controls in the default namespace
+  %[1]s explore output.json --severity High --namespace default
+
+  # Write the exceptions of the marked findings to a custom exceptions file
+  %[1]s explore output.json --exceptions-file my-exceptions.json
+
+`, cautils.ExecName())
+
+func GetExploreCmd(ks meta.IKubescape) *cobra.Command {
+	var exploreInfo metav1.ExploreInfo
+
+	exploreCmd := &cobra.Command{
+		Use:     "explore <report output file>",
+		Short:   "Browse and triage the results of a scan in an interactive terminal UI",
+		Long:    ``,
+		Example: exploreCmdExamples,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("report output file is required")
+			}
+			exploreInfo.ReportFile = args[0]
+			if exploreInfo.Severity != "" {
+				if err := shared.ValidateSeverity(exploreInfo.Severity); err != nil {
+					return err
+				}
+			}
+
+			return ks.Explore(&exploreInfo)
+		},
+	}
+
+	exploreCmd.Flags().StringVar(&exploreInfo.ExceptionsFile, "exceptions-file", "exceptions.json", "Exceptions file the exceptions of the marked findings are written to, existing exceptions are kept")
+	exploreCmd.Flags().StringVar(&exploreInfo.Severity, "severity", "", "Show the controls of a severity only (Critical, High, Medium or Low)")
+	exploreCmd.Flags().StringVar(&exploreInfo.Namespace, "namespace", "", "Show the resources of a namespace only")
+	exploreCmd.Flags().StringVar(&exploreInfo.Kind, "kind", "", "Show the resources of a kind only")
+
+	return exploreCmd
+}
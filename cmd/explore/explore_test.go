@@ -0,0 +1,32 @@
+package explore
+
+import (
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/cmd/shared"
+	"github.com/kubescape/kubescape/v3/core/mocks"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetExploreCmd(t *testing.T) {
+	exploreCmd := GetExploreCmd(&mocks.MockIKubescape{})
+
+	assert.Equal(t, "explore <report output file>", exploreCmd.Use)
+	assert.Equal(t, exploreCmdExamples, exploreCmd.Example)
+
+	err := exploreCmd.RunE(&cobra.Command{}, []string{})
+	assert.EqualError(t, err, "report output file is required")
+
+	assert.NoError(t, exploreCmd.RunE(&cobra.Command{}, []string{"output.json"}))
+
+	require.NoError(t, exploreCmd.Flags().Set("severity", "high"))
+	assert.NoError(t, exploreCmd.RunE(&cobra.Command{}, []string{"output.json"}))
+	require.NoError(t, exploreCmd.Flags().Set("severity", "severe"))
+	assert.ErrorIs(t, exploreCmd.RunE(&cobra.Command{}, []string{"output.json"}), shared.ErrUnknownSeverity)
+
+	exceptionsFile := exploreCmd.Flags().Lookup("exceptions-file")
+	assert.NotNil(t, exceptionsFile)
+	assert.Equal(t, "exceptions.json", exceptionsFile.DefValue)
+}
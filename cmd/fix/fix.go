@@ -7,6 +7,7 @@ import (
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/meta"
 	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/pkg/fixhandler"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +19,24 @@ var fixCmdExamples = fmt.Sprintf(`
   1) %[1]s scan . --format json --output output.json
   2) %[1]s fix output.json
 
+  # Commit the fixes of each control to a new git branch and push it to a remote
+  %[1]s fix output.json --no-confirm --git-branch kubescape-fixes --push origin
+
+  # Commit the fixes of each control to a new git branch and write the commits to a patch file
+  %[1]s fix output.json --no-confirm --git-branch kubescape-fixes --patch-file fixes.patch
+
+  # Fix the Helm charts of a scan in a values file of the environment, instead of the values.yaml of the charts
+  %[1]s fix output.json --helm-values values-production.yaml
+
+  # Fix the resources of a Kustomize overlay with JSON6902 patches of the overlay, instead of strategic merge patches
+  %[1]s fix output.json --kustomize-patch json6902
+
+  # Fix the live resources of a cluster based on a cluster scan output, with server-side apply. The fields managed by
+  # other tools, e.g. Helm or a GitOps controller, are not taken over and their resources fail to be fixed
+  1) %[1]s scan --format json --output output.json
+  2) %[1]s fix output.json --cluster
+  3) %[1]s fix rollback <fix ID>
+
 `, cautils.ExecName())
 
 func GetFixCmd(ks meta.IKubescape) *cobra.Command {
@@ -34,13 +53,31 @@ func GetFixCmd(ks meta.IKubescape) *cobra.Command {
 			}
 			fixInfo.ReportFile = args[0]
 
+			if fixInfo.GitBranch == "" && (fixInfo.PatchFile != "" || fixInfo.PushRemote != "") {
+				return errors.New("--patch-file and --push require --git-branch")
+			}
+			if fixInfo.Cluster && (fixInfo.GitBranch != "" || fixInfo.HelmValuesFile != "") {
+				return errors.New("--cluster cannot be used with --git-branch or --helm-values")
+			}
+			if fixInfo.KustomizePatch != fixhandler.KustomizePatchStrategicMerge && fixInfo.KustomizePatch != fixhandler.KustomizePatchJSON6902 {
+				return fmt.Errorf("--kustomize-patch must be %s or %s", fixhandler.KustomizePatchStrategicMerge, fixhandler.KustomizePatchJSON6902)
+			}
+
 			return ks.Fix(&fixInfo)
 		},
 	}
 
 	fixCmd.PersistentFlags().BoolVar(&fixInfo.NoConfirm, "no-confirm", false, "No confirmation will be given to the user before applying the fix (default false)")
 	fixCmd.PersistentFlags().BoolVar(&fixInfo.DryRun, "dry-run", false, "No changes will be applied (default false)")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.GitBranch, "git-branch", "", "Commit the fixes to a new git branch, a commit per control, instead of only editing the files")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.PatchFile, "patch-file", "", "Write the commits of the git branch to a patch file")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.PushRemote, "push", "", "Push the git branch to a remote, the name of a configured remote or a git URL")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.HelmValuesFile, "helm-values", "", "Values file the fixes of Helm charts are written to, relative to the chart directory (default values.yaml)")
+	fixCmd.PersistentFlags().StringVar(&fixInfo.KustomizePatch, "kustomize-patch", fixhandler.KustomizePatchStrategicMerge, "Type of the patches the fixes of Kustomize overlays are written to, strategic-merge or json6902")
+	fixCmd.PersistentFlags().BoolVar(&fixInfo.Cluster, "cluster", false, "Fix the live resources of the cluster of a cluster scan with server-side apply, instead of manifest files")
 	fixCmd.PersistentFlags().BoolVar(&fixInfo.SkipUserValues, "skip-user-values", true, "Changes which involve user-defined values will be skipped")
 
+	fixCmd.AddCommand(getRollbackCmd(ks))
+
 	return fixCmd
 }
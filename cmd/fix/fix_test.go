@@ -28,3 +28,38 @@ func TestGetFixCmd(t *testing.T) {
 	err = fixCmd.RunE(&cobra.Command{}, []string{"random-file.json"})
 	assert.Nil(t, err)
 }
+
+func TestGetFixCmdGitFlags(t *testing.T) {
+	fixCmd := GetFixCmd(&mocks.MockIKubescape{})
+
+	assert.NoError(t, fixCmd.PersistentFlags().Set("patch-file", "fixes.patch"))
+	err := fixCmd.RunE(&cobra.Command{}, []string{"output.json"})
+	assert.EqualError(t, err, "--patch-file and --push require --git-branch")
+
+	assert.NoError(t, fixCmd.PersistentFlags().Set("git-branch", "kubescape-fixes"))
+	assert.NoError(t, fixCmd.RunE(&cobra.Command{}, []string{"output.json"}))
+}
+
+func TestGetFixCmdClusterFlag(t *testing.T) {
+	fixCmd := GetFixCmd(&mocks.MockIKubescape{})
+
+	assert.NoError(t, fixCmd.PersistentFlags().Set("cluster", "true"))
+	assert.NoError(t, fixCmd.RunE(&cobra.Command{}, []string{"output.json"}))
+
+	assert.NoError(t, fixCmd.PersistentFlags().Set("git-branch", "kubescape-fixes"))
+	err := fixCmd.RunE(&cobra.Command{}, []string{"output.json"})
+	assert.EqualError(t, err, "--cluster cannot be used with --git-branch or --helm-values")
+}
+
+func TestGetRollbackCmd(t *testing.T) {
+	fixCmd := GetFixCmd(&mocks.MockIKubescape{})
+
+	rollbackCmd, _, err := fixCmd.Find([]string{"rollback"})
+	assert.NoError(t, err)
+	assert.Equal(t, "rollback [fix ID]", rollbackCmd.Use)
+	assert.Equal(t, rollbackCmdExamples, rollbackCmd.Example)
+
+	assert.NoError(t, rollbackCmd.RunE(&cobra.Command{}, []string{}))
+	assert.NoError(t, rollbackCmd.RunE(&cobra.Command{}, []string{"20261019-123000"}))
+	assert.EqualError(t, rollbackCmd.RunE(&cobra.Command{}, []string{"a", "b"}), "a single fix ID is expected")
+}
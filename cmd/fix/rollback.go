@@ -0,0 +1,47 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/meta"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmdExamples = fmt.Sprintf(`
+  Rollback command restores the fields of the resources of a cluster changed by "%[1]s fix --cluster" to their values before the fix.
+
+  # List the fixes of clusters
+  %[1]s fix rollback
+
+  # Roll back a fix
+  %[1]s fix rollback 20261019-123000
+
+`, cautils.ExecName())
+
+func getRollbackCmd(ks meta.IKubescape) *cobra.Command {
+	var rollbackInfo metav1.FixRollbackInfo
+
+	rollbackCmd := &cobra.Command{
+		Use:     "rollback [fix ID]",
+		Short:   "Restore the resources of a cluster changed by a fix",
+		Long:    ``,
+		Example: rollbackCmdExamples,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("a single fix ID is expected")
+			}
+			if len(args) == 1 {
+				rollbackInfo.ID = args[0]
+			}
+
+			return ks.FixRollback(&rollbackInfo)
+		},
+	}
+
+	rollbackCmd.Flags().BoolVar(&rollbackInfo.NoConfirm, "no-confirm", false, "No confirmation will be given to the user before rolling back the fix (default false)")
+
+	return rollbackCmd
+}
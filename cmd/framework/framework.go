@@ -0,0 +1,137 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/meta"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/spf13/cobra"
+)
+
+var frameworkCmdExamples = fmt.Sprintf(`
+  Framework commands compose custom frameworks from the controls of existing frameworks.
+  The framework definition references the controls by ID and is resolved when scanning.
+
+  # Create a framework with the NSA controls that are not part of MITRE, and the "C-0053" control
+  %[1]s framework create my-framework --inherit nsa --exclude-frameworks mitre --controls C-0053
+
+  # Create a framework with the NSA controls, raising the severity of the "C-0017" control
+  %[1]s framework create my-framework --inherit nsa --severity C-0017=critical
+
+  # Create a framework with the NSA controls, with the score factor and the sensitive key names of the "C-0012" control
+  %[1]s framework create my-framework --inherit nsa --score-factor C-0012=9 --controls-input C-0012.sensitiveKeyNames=aws_access_key_id,password
+
+  # Create a framework from the artifacts downloaded to /tmp
+  %[1]s framework create my-framework --inherit nsa,mitre --exclude-controls C-0002 --use-artifacts-from /tmp
+
+  # Scan with the framework
+  %[1]s download artifacts
+  %[1]s scan framework my-framework --use-from my-framework.yaml
+`, cautils.ExecName())
+
+func GetFrameworkCmd(ks meta.IKubescape) *cobra.Command {
+	frameworkCmd := &cobra.Command{
+		Use:     "framework",
+		Short:   "Compose custom frameworks from existing controls",
+		Long:    ``,
+		Example: frameworkCmdExamples,
+	}
+
+	frameworkCmd.AddCommand(getCreateCmd(ks))
+
+	return frameworkCmd
+}
+
+func getCreateCmd(ks meta.IKubescape) *cobra.Command {
+	var createInfo metav1.FrameworkCreateInfo
+	var scoreFactors map[string]string
+	var controlsInputs []string
+
+	createCmd := &cobra.Command{
+		Use:   "create <framework name>",
+		Short: "Create a framework definition referencing existing controls",
+		Long:  ``,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 || args[0] == "" {
+				return errors.New("framework name is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cautils.ValidateAccountID(createInfo.AccountID); err != nil {
+				return err
+			}
+			if len(createInfo.Inherit) == 0 && len(createInfo.Controls) == 0 {
+				return errors.New("at least one of --inherit or --controls is required")
+			}
+			var err error
+			if createInfo.ScoreFactors, err = parseScoreFactors(scoreFactors); err != nil {
+				return err
+			}
+			if createInfo.ControlsInputs, err = parseControlsInputs(controlsInputs); err != nil {
+				return err
+			}
+			createInfo.Name = args[0]
+
+			return ks.CreateFramework(&createInfo)
+		},
+	}
+
+	createCmd.Flags().StringVar(&createInfo.Description, "description", "", "Description of the framework")
+	createCmd.Flags().StringSliceVar(&createInfo.Inherit, "inherit", nil, "Frameworks all controls are included from. e.g: --inherit nsa,mitre")
+	createCmd.Flags().StringSliceVar(&createInfo.Controls, "controls", nil, "IDs of the controls added to the framework. e.g: --controls C-0002,C-0005")
+	createCmd.Flags().StringSliceVar(&createInfo.ExcludeFrameworks, "exclude-frameworks", nil, "Frameworks whose controls are removed from the framework")
+	createCmd.Flags().StringSliceVar(&createInfo.ExcludeControls, "exclude-controls", nil, "IDs of the controls removed from the framework")
+	createCmd.Flags().StringToStringVar(&createInfo.Severities, "severity", nil, "Severity overrides by control ID. e.g: --severity C-0017=critical,C-0002=low")
+	createCmd.Flags().StringToStringVar(&scoreFactors, "score-factor", nil, "Score factor overrides by control ID, between 0 and 10. e.g: --score-factor C-0017=8,C-0002=2.5")
+	createCmd.Flags().StringArrayVar(&controlsInputs, "controls-input", nil, "Controls input overrides, as <control ID>.<input name>=<comma separated values>. e.g: --controls-input C-0012.sensitiveKeyNames=aws_access_key_id,password")
+	createCmd.Flags().StringVarP(&createInfo.Output, "output", "o", "", "Output file. If not specified, will save in `<framework name>.yaml`")
+	createCmd.Flags().StringVar(&createInfo.UseArtifactsFrom, "use-artifacts-from", "", "Resolve the controls from the artifacts of this directory instead of downloading them")
+	createCmd.Flags().StringVarP(&createInfo.AccountID, "account", "", "", "Kubescape SaaS account ID. Default will load account ID from cache")
+	createCmd.Flags().StringVarP(&createInfo.AccessKey, "access-key", "", "", "Kubescape SaaS access key. Default will load access key from cache")
+
+	return createCmd
+}
+
+// parseScoreFactors parses the score factors of the --score-factor flag
+func parseScoreFactors(scoreFactors map[string]string) (map[string]float32, error) {
+	if len(scoreFactors) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]float32, len(scoreFactors))
+	for controlID, value := range scoreFactors {
+		scoreFactor, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid score factor '%s' of control %s", value, controlID)
+		}
+		parsed[controlID] = float32(scoreFactor)
+	}
+	return parsed, nil
+}
+
+// parseControlsInputs parses the <control ID>.<input name>=<values> controls inputs of the --controls-input flag
+func parseControlsInputs(controlsInputs []string) (map[string]map[string][]string, error) {
+	if len(controlsInputs) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string]map[string][]string)
+	for _, controlsInput := range controlsInputs {
+		key, values, ok := strings.Cut(controlsInput, "=")
+		controlID, name, found := strings.Cut(key, ".")
+		if !ok || !found || controlID == "" || name == "" {
+			return nil, fmt.Errorf("invalid controls input '%s', expected <control ID>.<input name>=<values>", controlsInput)
+		}
+		if parsed[controlID] == nil {
+			parsed[controlID] = make(map[string][]string)
+		}
+		parsed[controlID][name] = []string{}
+		if values != "" {
+			parsed[controlID][name] = strings.Split(values, ",")
+		}
+	}
+	return parsed, nil
+}
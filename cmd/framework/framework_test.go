@@ -0,0 +1,88 @@
+package framework
+
+import (
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/mocks"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFrameworkCmd(t *testing.T) {
+	frameworkCmd := GetFrameworkCmd(&mocks.MockIKubescape{})
+	assert.Equal(t, "framework", frameworkCmd.Use)
+	assert.Equal(t, frameworkCmdExamples, frameworkCmd.Example)
+
+	createCmd, _, err := frameworkCmd.Find([]string{"create"})
+	require.NoError(t, err)
+	assert.Equal(t, "create <framework name>", createCmd.Use)
+
+	assert.EqualError(t, createCmd.Args(&cobra.Command{}, []string{}), "framework name is required")
+	assert.NoError(t, createCmd.Args(&cobra.Command{}, []string{"custom"}))
+
+	assert.EqualError(t, createCmd.RunE(createCmd, []string{"custom"}), "at least one of --inherit or --controls is required")
+	require.NoError(t, createCmd.Flags().Set("inherit", "nsa"))
+	require.NoError(t, createCmd.Flags().Set("severity", "C-0017=critical"))
+	require.NoError(t, createCmd.Flags().Set("score-factor", "C-0017=8"))
+	require.NoError(t, createCmd.Flags().Set("controls-input", "C-0012.sensitiveKeyNames=aws_access_key_id,password"))
+	assert.NoError(t, createCmd.RunE(createCmd, []string{"custom"}))
+
+	require.NoError(t, createCmd.Flags().Set("score-factor", "C-0017=high"))
+	assert.EqualError(t, createCmd.RunE(createCmd, []string{"custom"}), "invalid score factor 'high' of control C-0017")
+}
+
+func TestParseScoreFactors(t *testing.T) {
+	scoreFactors, err := parseScoreFactors(map[string]string{"C-0017": "8", "C-0002": "2.5"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float32{"C-0017": 8, "C-0002": 2.5}, scoreFactors)
+
+	scoreFactors, err = parseScoreFactors(nil)
+	require.NoError(t, err)
+	assert.Nil(t, scoreFactors)
+
+	_, err = parseScoreFactors(map[string]string{"C-0017": "high"})
+	assert.EqualError(t, err, "invalid score factor 'high' of control C-0017")
+}
+
+func TestParseControlsInputs(t *testing.T) {
+	tests := []struct {
+		name           string
+		controlsInputs []string
+		want           map[string]map[string][]string
+		wantErr        string
+	}{
+		{
+			name: "no inputs",
+		},
+		{
+			name:           "inputs of several controls",
+			controlsInputs: []string{"C-0012.sensitiveKeyNames=aws_access_key_id,password", "C-0012.sensitiveValuesAllowed=", "C-0078.imageRepositoryAllowList=quay.io"},
+			want: map[string]map[string][]string{
+				"C-0012": {"sensitiveKeyNames": {"aws_access_key_id", "password"}, "sensitiveValuesAllowed": {}},
+				"C-0078": {"imageRepositoryAllowList": {"quay.io"}},
+			},
+		},
+		{
+			name:           "missing values",
+			controlsInputs: []string{"C-0012.sensitiveKeyNames"},
+			wantErr:        "invalid controls input 'C-0012.sensitiveKeyNames', expected <control ID>.<input name>=<values>",
+		},
+		{
+			name:           "missing input name",
+			controlsInputs: []string{"C-0012=password"},
+			wantErr:        "invalid controls input 'C-0012=password', expected <control ID>.<input name>=<values>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlsInputs, err := parseControlsInputs(tt.controlsInputs)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, controlsInputs)
+		})
+	}
+}
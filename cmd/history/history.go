@@ -0,0 +1,56 @@
+package history
+
+import (
+	"fmt"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/meta"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/spf13/cobra"
+)
+
+var historyCmdExamples = fmt.Sprintf(`
+  History command shows the compliance score trends and the control regressions of the scans recorded
+  in the local history store. Scans are recorded with the --keep-history flag of the scan command.
+
+  # Record the scans of the current cluster
+  %[1]s scan --keep-history
+
+  # Show the trends and the regressions of all the scanned targets
+  %[1]s history
+
+  # Show the NSA scores of the last 10 scans of a cluster
+  %[1]s history my-cluster --framework nsa --limit 10
+
+  # Export the history of the last 30 days as json
+  %[1]s history --since 720h --format json --output history.json
+
+`, cautils.ExecName())
+
+func GetHistoryCmd(ks meta.IKubescape) *cobra.Command {
+	var historyInfo metav1.HistoryInfo
+
+	historyCmd := &cobra.Command{
+		Use:     "history [cluster name, repository URL or path]",
+		Short:   "Show the score trends and the control regressions of the recorded scans",
+		Long:    ``,
+		Example: historyCmdExamples,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				historyInfo.Target = args[0]
+			}
+
+			return ks.History(&historyInfo)
+		},
+	}
+
+	historyCmd.Flags().StringVar(&historyInfo.HistoryFile, "history-file", "", "Path to the local history store. Default is $HOME/.kubescape/history.db")
+	historyCmd.Flags().StringVar(&historyInfo.Framework, "framework", "", "Show the scores of a framework. Default is the compliance score of the scans")
+	historyCmd.Flags().DurationVar(&historyInfo.Since, "since", 0, "Show the scans of the last period only, e.g. 168h. Default is all the scans")
+	historyCmd.Flags().IntVar(&historyInfo.Limit, "limit", 10, "Show the latest scans of each target only, 0 for all the scans")
+	historyCmd.Flags().StringVarP(&historyInfo.Format, "format", "f", "pretty-printer", `Output format. Supported formats: "pretty-printer", "json"`)
+	historyCmd.Flags().StringVarP(&historyInfo.Output, "output", "o", "", "Output file. Print output to file and not stdout")
+
+	return historyCmd
+}
@@ -0,0 +1,24 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/mocks"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHistoryCmd(t *testing.T) {
+	historyCmd := GetHistoryCmd(&mocks.MockIKubescape{})
+
+	assert.Equal(t, "history [cluster name, repository URL or path]", historyCmd.Use)
+	assert.Equal(t, historyCmdExamples, historyCmd.Example)
+
+	assert.NoError(t, historyCmd.RunE(&cobra.Command{}, []string{}))
+	assert.NoError(t, historyCmd.RunE(&cobra.Command{}, []string{"my-cluster"}))
+	assert.Error(t, historyCmd.Args(historyCmd, []string{"a", "b"}))
+
+	limit := historyCmd.Flags().Lookup("limit")
+	assert.NotNil(t, limit)
+	assert.Equal(t, "10", limit.DefValue)
+}
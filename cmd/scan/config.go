@@ -0,0 +1,67 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/spf13/cobra"
+)
+
+// applyScanConfig loads the scan config and merges it into the scan info. The flags set on the command line take precedence
+// over the env vars, which take precedence over the config file. The config is loaded once per scan
+func applyScanConfig(cmd *cobra.Command, inputPatterns []string, scanInfo *cautils.ScanInfo) error {
+	if scanInfo.ScanConfig != nil {
+		return nil
+	}
+
+	config, err := cautils.GetScanConfig(scanInfo.ConfigFile, scanConfigDir(inputPatterns))
+	if err != nil {
+		return err
+	}
+	if config.Path() != "" {
+		logger.L().Debug("Loaded scan config", helpers.String("path", config.Path()))
+	}
+
+	flags := cmd.Flags()
+	if config.ControlsConfig != "" && !flags.Changed("controls-config") {
+		scanInfo.ControlsInputs = config.ControlsConfig
+	}
+	if config.Exceptions != "" && !flags.Changed("exceptions") {
+		scanInfo.UseExceptions = config.Exceptions
+	}
+	if config.Thresholds.Compliance != nil && !flags.Changed("compliance-threshold") {
+		scanInfo.ComplianceThreshold = *config.Thresholds.Compliance
+	}
+	if config.Thresholds.Severity != "" && !flags.Changed("severity-threshold") {
+		scanInfo.FailThresholdSeverity = config.Thresholds.Severity
+	}
+	if len(config.ExcludeNamespaces) > 0 && !flags.Changed("exclude-namespaces") && !flags.Changed("include-namespaces") {
+		scanInfo.ExcludedNamespaces = strings.Join(config.ExcludeNamespaces, ",")
+	}
+
+	scanInfo.ScanConfig = config
+	return nil
+}
+
+// scanConfigDir returns the directory the scan config is discovered from: the directory of the first local input, or the
+// working directory
+func scanConfigDir(inputPatterns []string) string {
+	for _, input := range inputPatterns {
+		info, err := os.Stat(input)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			input = filepath.Dir(input)
+		}
+		if dir, err := filepath.Abs(input); err == nil {
+			return dir
+		}
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
@@ -0,0 +1,88 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScanConfigTestCmd builds a command with the flags of the scan command the scan config applies to
+func newScanConfigTestCmd(scanInfo *cautils.ScanInfo) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&scanInfo.ControlsInputs, "controls-config", "", "")
+	cmd.Flags().StringVar(&scanInfo.UseExceptions, "exceptions", "", "")
+	cmd.Flags().Float32Var(&scanInfo.ComplianceThreshold, "compliance-threshold", 0, "")
+	cmd.Flags().StringVar(&scanInfo.FailThresholdSeverity, "severity-threshold", "", "")
+	cmd.Flags().StringVar(&scanInfo.ExcludedNamespaces, "exclude-namespaces", "", "")
+	cmd.Flags().StringVar(&scanInfo.IncludeNamespaces, "include-namespaces", "", "")
+	return cmd
+}
+
+func TestApplyScanConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := `kind: ScanConfig
+controlsConfig: inputs.json
+exceptions: exceptions.json
+thresholds:
+  compliance: 80
+  severity: high
+excludeNamespaces:
+- kube-system
+- kube-public
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, cautils.ScanConfigFilename), []byte(config), 0600))
+	t.Setenv(cautils.ScanConfigEnvVar, "")
+
+	t.Run("config file values", func(t *testing.T) {
+		scanInfo := &cautils.ScanInfo{}
+		cmd := newScanConfigTestCmd(scanInfo)
+
+		require.NoError(t, applyScanConfig(cmd, []string{dir}, scanInfo))
+		assert.Equal(t, filepath.Join(dir, "inputs.json"), scanInfo.ControlsInputs)
+		assert.Equal(t, filepath.Join(dir, "exceptions.json"), scanInfo.UseExceptions)
+		assert.Equal(t, float32(80), scanInfo.ComplianceThreshold)
+		assert.Equal(t, "high", scanInfo.FailThresholdSeverity)
+		assert.Equal(t, "kube-system,kube-public", scanInfo.ExcludedNamespaces)
+		require.NotNil(t, scanInfo.ScanConfig)
+		assert.Equal(t, filepath.Join(dir, cautils.ScanConfigFilename), scanInfo.ScanConfig.Path())
+	})
+
+	t.Run("flags take precedence", func(t *testing.T) {
+		t.Setenv("KS_SEVERITY_THRESHOLD", "medium")
+		scanInfo := &cautils.ScanInfo{}
+		cmd := newScanConfigTestCmd(scanInfo)
+		require.NoError(t, cmd.Flags().Set("compliance-threshold", "95"))
+		require.NoError(t, cmd.Flags().Set("include-namespaces", "default"))
+
+		require.NoError(t, applyScanConfig(cmd, []string{dir}, scanInfo))
+		assert.Equal(t, float32(95), scanInfo.ComplianceThreshold)
+		assert.Equal(t, "medium", scanInfo.FailThresholdSeverity)
+		assert.Empty(t, scanInfo.ExcludedNamespaces)
+		assert.Equal(t, "default", scanInfo.IncludeNamespaces)
+	})
+
+	t.Run("explicit config file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kubescape.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("thresholds:\n  severity: critical\n"), 0600))
+		scanInfo := &cautils.ScanInfo{ConfigFile: path}
+		cmd := newScanConfigTestCmd(scanInfo)
+
+		require.NoError(t, applyScanConfig(cmd, []string{dir}, scanInfo))
+		assert.Equal(t, "critical", scanInfo.FailThresholdSeverity)
+		assert.Empty(t, scanInfo.UseExceptions)
+	})
+
+	t.Run("invalid config file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "kubescape.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("unknown: true\n"), 0600))
+		scanInfo := &cautils.ScanInfo{ConfigFile: path}
+		cmd := newScanConfigTestCmd(scanInfo)
+
+		assert.Error(t, applyScanConfig(cmd, nil, scanInfo))
+	})
+}
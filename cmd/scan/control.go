@@ -55,11 +55,18 @@ func getControlCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Comman
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyScanConfig(cmd, policyInputPatterns(args), scanInfo); err != nil {
+				return err
+			}
 
 			if err := validateFrameworkScanInfo(scanInfo); err != nil {
 				return err
 			}
 
+			if ok, err := scanMultipleClusters(cmd, args, policyInputPatterns(args), ks, scanInfo); ok {
+				return err
+			}
+
 			// flagValidationControl(scanInfo)
 			scanInfo.PolicyIdentifier = []cautils.PolicyIdentifier{}
 
@@ -99,6 +106,7 @@ func getControlCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Comman
 			if err != nil {
 				logger.L().Fatal(err.Error())
 			}
+			defer results.Close()
 			if err := results.HandleResults(ks.Context()); err != nil {
 				logger.L().Fatal(err.Error())
 			}
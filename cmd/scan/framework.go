@@ -14,6 +14,7 @@ import (
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/cautils/getter"
 	"github.com/kubescape/kubescape/v3/core/meta"
+	"github.com/kubescape/kubescape/v3/core/pkg/resourcehandler"
 	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
 	reporthandlingapis "github.com/kubescape/opa-utils/reporthandling/apis"
 	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
@@ -44,6 +45,7 @@ var (
 	ErrBadThreshold             = errors.New("bad argument: out of range threshold")
 	ErrKeepLocalOrSubmit        = errors.New("you can use `keep-local` or `submit`, but not both")
 	ErrOmitRawResourcesOrSubmit = errors.New("you can use `omit-raw-resources` or `submit`, but not both")
+	ErrBadPageSize              = errors.New("bad argument: page-size must not be negative")
 )
 
 func getFrameworkCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Command {
@@ -69,10 +71,18 @@ func getFrameworkCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Comm
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyScanConfig(cmd, policyInputPatterns(args), scanInfo); err != nil {
+				return err
+			}
 
 			if err := validateFrameworkScanInfo(scanInfo); err != nil {
 				return err
 			}
+
+			if ok, err := scanMultipleClusters(cmd, args, policyInputPatterns(args), ks, scanInfo); ok {
+				return err
+			}
+
 			scanInfo.FrameworkScan = true
 
 			// We do not scan all frameworks by default when triggering scan from the CLI
@@ -116,6 +126,7 @@ func getFrameworkCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Comm
 			if err != nil {
 				logger.L().Fatal(err.Error())
 			}
+			defer results.Close()
 
 			if err = results.HandleResults(ks.Context()); err != nil {
 				logger.L().Fatal(err.Error())
@@ -210,10 +221,16 @@ func validateFrameworkScanInfo(scanInfo *cautils.ScanInfo) error {
 	if scanInfo.Submit && scanInfo.OmitRawResources {
 		return ErrOmitRawResourcesOrSubmit
 	}
+	if scanInfo.PageSize < 0 {
+		return ErrBadPageSize
+	}
 	severity := scanInfo.FailThresholdSeverity
 	if err := shared.ValidateSeverity(severity); severity != "" && err != nil {
 		return err
 	}
+	if _, err := resourcehandler.NewResourceSelector(scanInfo.LabelSelector, scanInfo.AnnotationSelector); err != nil {
+		return err
+	}
 
 	// Validate the user's credentials
 	return cautils.ValidateAccountID(scanInfo.AccountID)
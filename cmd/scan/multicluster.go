@@ -0,0 +1,127 @@
+package scan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/meta"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// multiClusterFlags are set by the multi-cluster scan itself and are not forwarded to the scan of each cluster
+var multiClusterFlags = []string{"kube-contexts", "all-kube-contexts", "parallel-clusters", "kube-context", "format", "output"}
+
+func isMultiClusterScan(scanInfo *cautils.ScanInfo) bool {
+	return len(scanInfo.KubeContexts) > 0 || scanInfo.AllKubeContexts
+}
+
+func validateMultiClusterScanInfo(scanInfo *cautils.ScanInfo, inputPatterns []string) error {
+	if len(scanInfo.KubeContexts) > 0 && scanInfo.AllKubeContexts {
+		return fmt.Errorf("you can use either '--kube-contexts' or '--all-kube-contexts', but not both")
+	}
+	if len(inputPatterns) > 0 || scanInfo.SnapshotPath != "" {
+		return fmt.Errorf("multi-cluster scanning is only supported for live clusters")
+	}
+	if scanInfo.ParallelClusters < 1 {
+		return fmt.Errorf("bad argument: parallel-clusters must be a positive number")
+	}
+	return nil
+}
+
+// scanMultipleClusters scans the clusters selected by '--kube-contexts' or '--all-kube-contexts' and prints the combined report.
+// It returns false when the scan is not a multi-cluster scan
+func scanMultipleClusters(cmd *cobra.Command, args []string, inputPatterns []string, ks meta.IKubescape, scanInfo *cautils.ScanInfo) (bool, error) {
+	if !isMultiClusterScan(scanInfo) {
+		return false, nil
+	}
+	if err := validateMultiClusterScanInfo(scanInfo, inputPatterns); err != nil {
+		return true, err
+	}
+
+	multiClusterInfo := &metav1.MultiClusterScanInfo{
+		KubeContexts: scanInfo.KubeContexts,
+		ScanArgs:     getClusterScanArgs(cmd, args),
+		Parallelism:  scanInfo.ParallelClusters,
+	}
+	report, err := ks.ScanClusters(multiClusterInfo)
+	if err != nil {
+		return true, err
+	}
+
+	if err := resultshandling.HandleMultiClusterResults(ks.Context(), report, scanInfo); err != nil {
+		return true, err
+	}
+
+	for _, cluster := range report.Clusters {
+		if cluster.Report == nil {
+			return true, fmt.Errorf("failed to scan cluster '%s'", cluster.KubeContext)
+		}
+	}
+	if report.ComplianceScore < scanInfo.ComplianceThreshold {
+		logger.L().Fatal("fleet compliance-score is below permitted threshold", helpers.String("compliance-score", fmt.Sprintf("%.2f", report.ComplianceScore)), helpers.String("compliance-threshold", fmt.Sprintf("%.2f", scanInfo.ComplianceThreshold)))
+	}
+	if clusters := clustersExceedingThresholds(report, scanInfo); len(clusters) > 0 {
+		logger.L().Fatal("cluster results exceed the permitted thresholds", helpers.String("clusters", strings.Join(clusters, ",")), helpers.String("fail-threshold", fmt.Sprintf("%.2f", scanInfo.FailThreshold)), helpers.String("set severity threshold", scanInfo.FailThresholdSeverity))
+	}
+	return true, nil
+}
+
+// clustersExceedingThresholds returns the kube contexts of the clusters whose risk-score is above the fail threshold or which
+// have failed controls of the severity threshold. The scan of each cluster exits with a non-zero code in that case, but its
+// exit code is not kept once its report is read
+func clustersExceedingThresholds(report *cautils.MultiClusterReport, scanInfo *cautils.ScanInfo) []string {
+	var clusters []string
+	for _, cluster := range report.Clusters {
+		if cluster.Report == nil {
+			continue
+		}
+		summaryDetails := &cluster.Report.SummaryDetails
+		exceeded := summaryDetails.Score > scanInfo.FailThreshold
+		if scanInfo.FailThresholdSeverity != "" {
+			if exceedsSeverity, err := countersExceedSeverityThreshold(summaryDetails.GetResourcesSeverityCounters(), scanInfo); err == nil && exceedsSeverity {
+				exceeded = true
+			}
+		}
+		if exceeded {
+			clusters = append(clusters, cluster.KubeContext)
+		}
+	}
+	return clusters
+}
+
+// policyInputPatterns returns the input files of a framework or control scan, the first argument being the policies
+func policyInputPatterns(args []string) []string {
+	if len(args) > 1 {
+		return args[1:]
+	}
+	return nil
+}
+
+// getClusterScanArgs rebuilds the command line of the scan of a single cluster from the current command, its arguments and the flags set by the user
+func getClusterScanArgs(cmd *cobra.Command, args []string) []string {
+	// the first element of the command path is the executable name
+	scanArgs := strings.Fields(cmd.CommandPath())[1:]
+	scanArgs = append(scanArgs, args...)
+
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		for _, name := range multiClusterFlags {
+			if f.Name == name {
+				return
+			}
+		}
+		if sliceValue, ok := f.Value.(pflag.SliceValue); ok {
+			for _, value := range sliceValue.GetSlice() {
+				scanArgs = append(scanArgs, fmt.Sprintf("--%s=%s", f.Name, value))
+			}
+			return
+		}
+		scanArgs = append(scanArgs, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+	})
+	return scanArgs
+}
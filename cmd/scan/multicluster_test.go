@@ -0,0 +1,94 @@
+package scan
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/mocks"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetClusterScanArgs(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "kubescape"}
+	rootCmd.PersistentFlags().String("kube-context", "", "")
+	rootCmd.AddCommand(GetScanCommand(&mocks.MockIKubescape{}))
+
+	frameworkCmd, _, err := rootCmd.Find([]string{"scan", "framework"})
+	require.NoError(t, err)
+	require.NoError(t, frameworkCmd.ParseFlags([]string{
+		"--kube-contexts", "prod,staging",
+		"--kube-context", "prod",
+		"--format", "pretty-printer",
+		"--exclude-namespaces", "kube-system",
+		"--use-from", "nsa.json",
+		"--use-from", "mitre.json",
+		"--verbose",
+	}))
+
+	assert.Equal(t, []string{
+		"scan", "framework", "nsa",
+		"--exclude-namespaces=kube-system",
+		"--use-from=nsa.json",
+		"--use-from=mitre.json",
+		"--verbose=true",
+	}, getClusterScanArgs(frameworkCmd, []string{"nsa"}))
+}
+
+func TestValidateMultiClusterScanInfo(t *testing.T) {
+	assert.NoError(t, validateMultiClusterScanInfo(&cautils.ScanInfo{KubeContexts: []string{"prod"}, ParallelClusters: 4}, nil))
+	assert.Error(t, validateMultiClusterScanInfo(&cautils.ScanInfo{KubeContexts: []string{"prod"}, AllKubeContexts: true, ParallelClusters: 4}, nil))
+	assert.Error(t, validateMultiClusterScanInfo(&cautils.ScanInfo{AllKubeContexts: true, ParallelClusters: 4}, []string{"."}))
+	assert.Error(t, validateMultiClusterScanInfo(&cautils.ScanInfo{AllKubeContexts: true, ParallelClusters: 4, SnapshotPath: "dump.tar.gz"}, nil))
+	assert.Error(t, validateMultiClusterScanInfo(&cautils.ScanInfo{AllKubeContexts: true}, nil))
+}
+
+func TestScanMultipleClusters(t *testing.T) {
+	cmd := &cobra.Command{Use: "kubescape"}
+
+	ok, err := scanMultipleClusters(cmd, nil, nil, &mocks.MockIKubescape{}, &cautils.ScanInfo{})
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	scanInfo := &cautils.ScanInfo{
+		AllKubeContexts:  true,
+		ParallelClusters: 4,
+		Format:           "json",
+		Output:           filepath.Join(t.TempDir(), "fleet.json"),
+	}
+	ok, err = scanMultipleClusters(cmd, nil, nil, &mocks.MockIKubescape{}, scanInfo)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.FileExists(t, scanInfo.Output)
+}
+
+func TestClustersExceedingThresholds(t *testing.T) {
+	newClusterReport := func(kubeContext string, score float32, severityCounters reportsummary.SeverityCounters) cautils.ClusterReport {
+		report := &reporthandlingv2.PostureReport{}
+		report.SummaryDetails.Score = score
+		report.SummaryDetails.ResourcesSeverityCounters = severityCounters
+		return cautils.NewClusterReport(kubeContext, report, nil)
+	}
+	report := cautils.NewMultiClusterReport([]cautils.ClusterReport{
+		newClusterReport("prod", 10, reportsummary.SeverityCounters{MediumSeverityCounter: 2}),
+		newClusterReport("staging", 40, reportsummary.SeverityCounters{HighSeverityCounter: 1}),
+		newClusterReport("dev", 5, reportsummary.SeverityCounters{}),
+		cautils.NewClusterReport("unreachable", nil, errors.New("no cluster")),
+	})
+
+	assert.Empty(t, clustersExceedingThresholds(report, &cautils.ScanInfo{FailThreshold: 100}))
+	assert.Equal(t, []string{"staging"}, clustersExceedingThresholds(report, &cautils.ScanInfo{FailThreshold: 30}))
+	assert.Equal(t, []string{"staging"}, clustersExceedingThresholds(report, &cautils.ScanInfo{FailThreshold: 100, FailThresholdSeverity: "high"}))
+	assert.Equal(t, []string{"prod", "staging"}, clustersExceedingThresholds(report, &cautils.ScanInfo{FailThreshold: 100, FailThresholdSeverity: "medium"}))
+}
+
+func TestPolicyInputPatterns(t *testing.T) {
+	assert.Nil(t, policyInputPatterns(nil))
+	assert.Nil(t, policyInputPatterns([]string{"nsa"}))
+	assert.Equal(t, []string{"."}, policyInputPatterns([]string{"nsa", "."}))
+}
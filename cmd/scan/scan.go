@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/cautils/getter"
 	"github.com/kubescape/kubescape/v3/core/meta"
@@ -22,6 +23,18 @@ var scanCmdExamples = fmt.Sprintf(`
   # Scan kubernetes manifest files
   %[1]s scan .
 
+  # Scan a remote git repository pinned to a branch, tag or commit (any git server, ssh:// and file:// are supported)
+  %[1]s scan https://gitea.example.com/org/repo.git#v1.2.0
+
+  # Scan an offline cluster snapshot, e.g. the output of 'kubectl get all -A -o yaml'
+  %[1]s scan --snapshot cluster-dump.tar.gz
+
+  # Scan the workloads owned by a team
+  %[1]s scan --selector team=x
+
+  # Scan with the settings of a scan config file, .kubescape.yaml of the repository root is loaded by default
+  %[1]s scan . --config ci/kubescape.yaml
+
   # Scan and save the results in the JSON format
   %[1]s scan --format json --output results.json
 
@@ -30,6 +43,12 @@ var scanCmdExamples = fmt.Sprintf(`
 
   # Scan different clusters from the kubectl context
   %[1]s scan --kube-context <kubernetes context>
+
+  # Scan several clusters concurrently and compare their compliance scores
+  %[1]s scan --kube-contexts prod,staging
+
+  # Scan all the clusters of the kubeconfig and save the combined report
+  %[1]s scan --all-kube-contexts --format json --output fleet.json
 `, cautils.ExecName())
 
 func GetScanCommand(ks meta.IKubescape) *cobra.Command {
@@ -42,14 +61,34 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 		Long:    `The action you want to perform`,
 		Example: scanCmdExamples,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyScanConfig(cmd, args, &scanInfo); err != nil {
+				return err
+			}
+
+			if ok, err := scanMultipleClusters(cmd, args, args, ks, &scanInfo); ok {
+				if err != nil {
+					logger.L().Fatal(err.Error())
+				}
+				return nil
+			}
+
+			// the frameworks of the scan config replace the native frameworks of the resource and control views
+			frameworks := getter.NativeFrameworks
+			if configFrameworks := scanInfo.ScanConfig.Frameworks; len(configFrameworks) > 0 {
+				frameworks = configFrameworks
+			}
+
 			if scanInfo.View == string(cautils.SecurityViewType) {
+				if configFrameworks := scanInfo.ScanConfig.Frameworks; len(configFrameworks) > 0 {
+					logger.L().Warning("the frameworks of the scan config are not scanned in the security view, use '--view resource' or '--view control' to scan them", helpers.String("frameworks", strings.Join(configFrameworks, ",")))
+				}
 				setSecurityViewScanInfo(args, &scanInfo)
 
 				if err := securityScan(scanInfo, ks); err != nil {
 					logger.L().Fatal(err.Error())
 				}
 			} else if len(args) == 0 || (args[0] != "framework" && args[0] != "control") {
-				if err := getFrameworkCmd(ks, &scanInfo).RunE(cmd, append([]string{strings.Join(getter.NativeFrameworks, ",")}, args...)); err != nil {
+				if err := getFrameworkCmd(ks, &scanInfo).RunE(cmd, append([]string{strings.Join(frameworks, ",")}, args...)); err != nil {
 					logger.L().Fatal(err.Error())
 				}
 			} else {
@@ -67,17 +106,32 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 
 	scanCmd.PersistentFlags().StringVarP(&scanInfo.AccountID, "account", "", "", "Kubescape SaaS account ID. Default will load account ID from cache")
 	scanCmd.PersistentFlags().StringVarP(&scanInfo.AccessKey, "access-key", "", "", "Kubescape SaaS access key. Default will load access key from cache")
-	scanCmd.PersistentFlags().StringVar(&scanInfo.ControlsInputs, "controls-config", "", "Path to an controls-config obj. If not set will download controls-config from ARMO management portal")
-	scanCmd.PersistentFlags().StringVar(&scanInfo.UseExceptions, "exceptions", "", "Path to an exceptions obj. If not set will download exceptions from ARMO management portal")
-	scanCmd.PersistentFlags().StringVar(&scanInfo.UseArtifactsFrom, "use-artifacts-from", "", "Load artifacts from local directory. If not used will download them")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.ConfigFile, "config", "", fmt.Sprintf("Path to the scan config file. If not set will load %s from the repository root [$%s]", cautils.ScanConfigFilename, cautils.ScanConfigEnvVar))
+	scanCmd.PersistentFlags().StringVar(&scanInfo.ControlsInputs, "controls-config", "", "Path to an controls-config obj. If not set will download controls-config from ARMO management portal [$KS_CONTROLS_CONFIG]")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.UseExceptions, "exceptions", "", "Path to an exceptions obj. If not set will download exceptions from ARMO management portal [$KS_EXCEPTIONS]")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.UseArtifactsFrom, "use-artifacts-from", "", "Load artifacts from local directory, or from a policy bundle of an OCI registry, e.g. oci://registry.example.com/kubescape/policies:v1. If not used will download them")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.PoliciesPublicKey, "policies-public-key", "", "Path to a cosign public key. The checksum manifest of the local policies must be signed with the matching private key")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.RequireSignedPolicies, "require-signed-policies", false, "Refuse to scan with policies that are not loaded from local files verified against their checksum manifest signed with --policies-public-key")
 	scanCmd.PersistentFlags().StringVarP(&scanInfo.ExcludedNamespaces, "exclude-namespaces", "e", "", "Namespaces to exclude from scanning. e.g: --exclude-namespaces ns-a,ns-b. Notice, when running with `exclude-namespace` kubescape does not scan cluster-scoped objects.")
 
 	scanCmd.PersistentFlags().Float32VarP(&scanInfo.FailThreshold, "fail-threshold", "t", 100, "Failure threshold is the percent above which the command fails and returns exit code 1")
-	scanCmd.PersistentFlags().Float32VarP(&scanInfo.ComplianceThreshold, "compliance-threshold", "", 0, "Compliance threshold is the percent below which the command fails and returns exit code 1")
+	scanCmd.PersistentFlags().Float32VarP(&scanInfo.ComplianceThreshold, "compliance-threshold", "", 0, "Compliance threshold is the percent below which the command fails and returns exit code 1 [$KS_COMPLIANCE_THRESHOLD]")
 
-	scanCmd.PersistentFlags().StringVar(&scanInfo.FailThresholdSeverity, "severity-threshold", "", "Severity threshold is the severity of failed controls at which the command fails and returns exit code 1")
-	scanCmd.PersistentFlags().StringVarP(&scanInfo.Format, "format", "f", "pretty-printer", `Output file format. Supported formats: "pretty-printer", "json", "junit", "prometheus", "pdf", "html", "sarif"`)
+	scanCmd.PersistentFlags().StringVar(&scanInfo.FailThresholdSeverity, "severity-threshold", "", "Severity threshold is the severity of failed controls at which the command fails and returns exit code 1 [$KS_SEVERITY_THRESHOLD]")
+	scanCmd.PersistentFlags().StringVarP(&scanInfo.Format, "format", "f", "pretty-printer", `Output file format. Supported formats: "pretty-printer", "json", "junit", "prometheus", "pdf", "html", "sarif", "markdown", "csv", "xlsx", "otlp", "gitlab-codequality", "gitlab-sast"`)
 	scanCmd.PersistentFlags().StringVar(&scanInfo.IncludeNamespaces, "include-namespaces", "", "scan specific namespaces. e.g: --include-namespaces ns-a,ns-b")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.LabelSelector, "selector", "", "Scan only the workloads matching the label selector. Related objects such as namespaces, RBAC and services are always scanned. e.g: --selector team=x,tier!=db")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.AnnotationSelector, "annotation-selector", "", "Scan only the workloads whose annotations match the selector, using the label selector syntax. e.g: --annotation-selector owner=team-x")
+	scanCmd.PersistentFlags().Int64Var(&scanInfo.PageSize, "page-size", 500, "Number of objects requested from the API server in each list call")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.StoreResourcesOnDisk, "store-resources-on-disk", false, "Keep the cluster objects in a temporary file instead of memory while scanning. Reduces the memory used when scanning very large clusters, at the cost of a slower scan")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.OTLPEndpoint, "otlp-endpoint", "", "Base URL of the OpenTelemetry collector the otlp format exports to over OTLP/HTTP, e.g. http://localhost:4318. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.PrometheusNamespaces, "prometheus-namespace-metrics", false, "Add the compliance score and counters of each namespace to the prometheus format")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.PrometheusWorkloads, "prometheus-workload-metrics", false, "Add the counters of the controls of each workload, by severity of the failed ones, to the prometheus format")
+	scanCmd.PersistentFlags().IntVar(&scanInfo.PrometheusMaxSeries, "prometheus-max-series", 10000, "Maximum number of series of the namespace and workload metrics of the prometheus format. The workloads with the least severe failures are dropped first, 0 for no limit")
+	scanCmd.PersistentFlags().StringSliceVar(&scanInfo.PrometheusAllowList, "prometheus-namespaces", nil, "Namespaces, or glob patterns of namespaces, of the namespace and workload metrics of the prometheus format. e.g: --prometheus-namespaces prod-*,payments. Default is all namespaces")
+	scanCmd.PersistentFlags().IntVar(&scanInfo.MarkdownMaxSize, "markdown-max-size", 65536, "Maximum size in characters of the markdown output, the sections exceeding it are dropped. The default fits GitHub comments, 0 for no limit")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.KeepHistory, "keep-history", false, "Record the scan in the local history store, to follow the score trends and control regressions with 'kubescape history' and in the html/pdf reports")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.HistoryFile, "history-file", "", "Path to the local history store, implies --keep-history. Default is $HOME/.kubescape/history.db")
 	scanCmd.PersistentFlags().BoolVarP(&scanInfo.Local, "keep-local", "", false, "If you do not want your Kubescape results reported to configured backend.")
 	scanCmd.PersistentFlags().StringVarP(&scanInfo.Output, "output", "o", "", "Output file. Print output to file and not stdout")
 	scanCmd.PersistentFlags().BoolVarP(&scanInfo.VerboseMode, "verbose", "v", false, "Display all of the input resources and not only failed resources")
@@ -92,6 +146,10 @@ func GetScanCommand(ks meta.IKubescape) *cobra.Command {
 	scanCmd.PersistentFlags().BoolVarP(&scanInfo.PrintAttackTree, "print-attack-tree", "", false, "Print attack tree")
 	scanCmd.PersistentFlags().BoolVarP(&scanInfo.EnableRegoPrint, "enable-rego-prints", "", false, "Enable sending to rego prints to the logs (use with debug log level: -l debug)")
 	scanCmd.PersistentFlags().BoolVarP(&scanInfo.ScanImages, "scan-images", "", false, "Scan resources images")
+	scanCmd.PersistentFlags().StringVar(&scanInfo.SnapshotPath, "snapshot", "", "Scan an offline cluster snapshot (a resources dump directory, yaml/json file or tar.gz archive) instead of the current cluster")
+	scanCmd.PersistentFlags().StringSliceVar(&scanInfo.KubeContexts, "kube-contexts", nil, "Scan several clusters, identified by their kube contexts, and print a combined report. e.g: --kube-contexts prod,staging")
+	scanCmd.PersistentFlags().BoolVar(&scanInfo.AllKubeContexts, "all-kube-contexts", false, "Scan all the clusters of the kubeconfig and print a combined report")
+	scanCmd.PersistentFlags().IntVar(&scanInfo.ParallelClusters, "parallel-clusters", 4, "Maximum number of clusters scanned concurrently when scanning several clusters")
 
 	scanCmd.PersistentFlags().MarkDeprecated("fail-threshold", "use '--compliance-threshold' flag instead. Flag will be removed at 1.Dec.2023")
 	scanCmd.PersistentFlags().MarkDeprecated("create-account", "Create account is no longer supported. In case of a missing Account ID and a configured backend server, a new account id will be generated automatically by Kubescape. Feel free to contact the Kubescape maintainers for more information.")
@@ -138,11 +196,15 @@ func securityScan(scanInfo cautils.ScanInfo, ks meta.IKubescape) error {
 	if err != nil {
 		return err
 	}
+	defer results.Close()
 
 	if err = results.HandleResults(ks.Context()); err != nil {
 		return err
 	}
 
+	if results.GetComplianceScore() < float32(scanInfo.ComplianceThreshold) {
+		logger.L().Fatal("scan compliance-score is below permitted threshold", helpers.String("compliance-score", fmt.Sprintf("%.2f", results.GetComplianceScore())), helpers.String("compliance-threshold", fmt.Sprintf("%.2f", scanInfo.ComplianceThreshold)))
+	}
 	enforceSeverityThresholds(results.GetData().Report.SummaryDetails.GetResourcesSeverityCounters(), &scanInfo, terminateOnExceedingSeverity)
 
 	return nil
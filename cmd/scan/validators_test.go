@@ -79,6 +79,11 @@ func Test_validateFrameworkScanInfo(t *testing.T) {
 			&cautils.ScanInfo{},
 			nil,
 		},
+		{
+			"Negative page size should be invalid for scan info",
+			&cautils.ScanInfo{PageSize: -1},
+			ErrBadPageSize,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -97,6 +102,19 @@ func Test_validateFrameworkScanInfo(t *testing.T) {
 	}
 }
 
+// Test_validateFrameworkScanInfoSelectors tests how the label and annotation selectors are validated
+func Test_validateFrameworkScanInfoSelectors(t *testing.T) {
+	if got := validateFrameworkScanInfo(&cautils.ScanInfo{LabelSelector: "team=x", AnnotationSelector: "owner=platform"}); got != nil {
+		t.Errorf("got: %v, want: nil", got)
+	}
+	if got := validateFrameworkScanInfo(&cautils.ScanInfo{LabelSelector: "team==x==y"}); got == nil {
+		t.Errorf("got: nil, want: invalid label selector error")
+	}
+	if got := validateFrameworkScanInfo(&cautils.ScanInfo{AnnotationSelector: "owner in team-x"}); got == nil {
+		t.Errorf("got: nil, want: invalid annotation selector error")
+	}
+}
+
 func Test_validateWorkloadIdentifier(t *testing.T) {
 	testCases := []struct {
 		Description string
@@ -56,6 +56,9 @@ func getWorkloadCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Comma
 			return validateWorkloadIdentifier(args[0])
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyScanConfig(cmd, []string{scanInfo.FilePath}, scanInfo); err != nil {
+				return err
+			}
 
 			kind, name, err := parseWorkloadIdentifierString(args[0])
 			if err != nil {
@@ -69,6 +72,7 @@ func getWorkloadCmd(ks meta.IKubescape, scanInfo *cautils.ScanInfo) *cobra.Comma
 			if err != nil {
 				logger.L().Fatal(err.Error())
 			}
+			defer results.Close()
 
 			if err = results.HandleResults(ks.Context()); err != nil {
 				logger.L().Fatal(err.Error())
@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	"github.com/kubescape/kubescape/v3/core/meta"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmdExamples = fmt.Sprintf(`
+  Snapshot command exports the resources of the current cluster into an archive, to be scanned later without cluster access.
+  Only the resources required by the selected frameworks are exported and the data of Secrets is redacted.
+
+  # Export the resources required by the default frameworks
+  %[1]s snapshot
+
+  # Export the resources required by the NSA and MITRE frameworks
+  %[1]s snapshot nsa,mitre --output cluster-snapshot.tar.gz
+
+  # Scan the snapshot offline
+  %[1]s scan framework nsa --snapshot cluster-snapshot.tar.gz
+`, cautils.ExecName())
+
+func GetSnapshotCmd(ks meta.IKubescape) *cobra.Command {
+	var snapshotInfo metav1.SnapshotInfo
+	var scanInfo cautils.ScanInfo
+
+	snapshotCmd := &cobra.Command{
+		Use:     "snapshot [<framework names list>]",
+		Short:   "Export a snapshot of the cluster resources for offline scanning",
+		Long:    ``,
+		Example: snapshotCmdExamples,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("usage: <framework-0>,<framework-1>")
+			}
+			if len(args) == 1 && slices.Contains(strings.Split(args[0], ","), "") {
+				return fmt.Errorf("usage: <framework-0>,<framework-1>")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			frameworks := getter.NativeFrameworks
+			if len(args) > 0 && args[0] != "all" {
+				frameworks = strings.Split(args[0], ",")
+			}
+
+			scanInfo.FrameworkScan = true
+			scanInfo.SetScanType(cautils.ScanTypeCluster)
+			scanInfo.SetPolicyIdentifiers(frameworks, apisv1.KindFramework)
+
+			if snapshotInfo.Output == "" {
+				snapshotInfo.Output = fmt.Sprintf("cluster-snapshot-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+			}
+			if !cautils.IsSnapshotArchive(snapshotInfo.Output) {
+				return fmt.Errorf("output file '%s' must be a .tar.gz archive", snapshotInfo.Output)
+			}
+
+			return ks.Snapshot(&snapshotInfo, &scanInfo)
+		},
+	}
+
+	snapshotCmd.PersistentFlags().StringVarP(&snapshotInfo.Output, "output", "o", "", "Output archive. Default is cluster-snapshot-<timestamp>.tar.gz")
+	snapshotCmd.PersistentFlags().StringVarP(&scanInfo.AccountID, "account", "", "", "Kubescape SaaS account ID. Default will load account ID from cache")
+	snapshotCmd.PersistentFlags().StringVarP(&scanInfo.AccessKey, "access-key", "", "", "Kubescape SaaS access key. Default will load access key from cache")
+	snapshotCmd.PersistentFlags().StringVarP(&scanInfo.ExcludedNamespaces, "exclude-namespaces", "e", "", "Namespaces to exclude from the snapshot. e.g: --exclude-namespaces ns-a,ns-b")
+	snapshotCmd.PersistentFlags().StringVar(&scanInfo.IncludeNamespaces, "include-namespaces", "", "Export specific namespaces. e.g: --include-namespaces ns-a,ns-b")
+	snapshotCmd.PersistentFlags().StringVar(&scanInfo.LabelSelector, "selector", "", "Export only the workloads matching the label selector. Related objects such as namespaces, RBAC and services are always exported. e.g: --selector team=x")
+	snapshotCmd.PersistentFlags().StringVar(&scanInfo.AnnotationSelector, "annotation-selector", "", "Export only the workloads whose annotations match the selector, using the label selector syntax. e.g: --annotation-selector owner=team-x")
+	snapshotCmd.PersistentFlags().Int64Var(&scanInfo.PageSize, "page-size", 500, "Number of objects requested from the API server in each list call")
+	snapshotCmd.PersistentFlags().StringSliceVar(&scanInfo.UseFrom, "use-from", nil, "Load local policy object from specified path. If not used will download latest")
+	snapshotCmd.PersistentFlags().StringVar(&scanInfo.CustomClusterName, "cluster-name", "", "Set the custom name of the cluster recorded in the snapshot")
+	snapshotCmd.PersistentFlags().StringVar(&scanInfo.HostSensorYamlPath, "host-scan-yaml", "", "Override default host scanner DaemonSet. Use this flag cautiously")
+
+	hostF := snapshotCmd.PersistentFlags().VarPF(&scanInfo.HostSensorEnabled, "enable-host-scan", "", "Deploy Kubescape host-sensor daemonset in the cluster and include the collected host data in the snapshot")
+	hostF.NoOptDefVal = "true"
+	hostF.DefValue = "false"
+
+	snapshotCmd.PersistentFlags().MarkHidden("host-scan-yaml")
+
+	// Retrieve --kubeconfig flag from https://github.com/kubernetes/kubectl/blob/master/pkg/cmd/cmd.go
+	snapshotCmd.PersistentFlags().AddGoFlag(flag.Lookup("kubeconfig"))
+
+	return snapshotCmd
+}
@@ -0,0 +1,31 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/mocks"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSnapshotCmd(t *testing.T) {
+	// Create a mock Kubescape interface
+	mockKubescape := &mocks.MockIKubescape{}
+
+	snapshotCmd := GetSnapshotCmd(mockKubescape)
+
+	// Verify the command name and short description
+	assert.Equal(t, "snapshot [<framework names list>]", snapshotCmd.Use)
+	assert.Equal(t, "Export a snapshot of the cluster resources for offline scanning", snapshotCmd.Short)
+	assert.Equal(t, snapshotCmdExamples, snapshotCmd.Example)
+
+	assert.NoError(t, snapshotCmd.Args(&cobra.Command{}, []string{}))
+	assert.NoError(t, snapshotCmd.Args(&cobra.Command{}, []string{"nsa,mitre"}))
+	assert.Error(t, snapshotCmd.Args(&cobra.Command{}, []string{"nsa,"}))
+	assert.Error(t, snapshotCmd.Args(&cobra.Command{}, []string{"nsa", "mitre"}))
+
+	assert.NoError(t, snapshotCmd.RunE(&cobra.Command{}, []string{"nsa"}))
+
+	assert.NoError(t, snapshotCmd.PersistentFlags().Set("output", "snapshot.yaml"))
+	assert.Error(t, snapshotCmd.RunE(&cobra.Command{}, []string{"nsa"}))
+}
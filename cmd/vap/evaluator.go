@@ -0,0 +1,316 @@
+package vap
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// policyEvaluator evaluates the CEL expressions of a Validating Admission Policy locally, the way the API server does on object creation
+type policyEvaluator struct {
+	policy          *admissionv1.ValidatingAdmissionPolicy
+	matchConditions []compiledExpression
+	variables       []compiledExpression
+	validations     []compiledValidation
+}
+
+type compiledExpression struct {
+	name       string
+	expression string
+	program    cel.Program
+}
+
+type compiledValidation struct {
+	compiledExpression
+	message           string
+	messageExpression *compiledExpression
+}
+
+// evaluation is the result of the evaluation of a policy on an object
+type evaluation struct {
+	Allowed    bool   `json:"allowed"`
+	Expression string `json:"expression,omitempty"` // the failing expression
+	Message    string `json:"message,omitempty"`
+}
+
+func newCELEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("oldObject", cel.DynType),
+		cel.Variable("params", cel.DynType),
+		cel.Variable("request", cel.DynType),
+		cel.Variable("namespaceObject", cel.DynType),
+		cel.Variable("variables", cel.MapType(cel.StringType, cel.DynType)),
+		cel.OptionalTypes(),
+		ext.Strings(),
+		ext.Lists(),
+		ext.Sets(),
+		ext.Math(),
+		ext.Encoders(),
+	)
+}
+
+// newPolicyEvaluator compiles the expressions of the policy
+func newPolicyEvaluator(env *cel.Env, policy *admissionv1.ValidatingAdmissionPolicy) (*policyEvaluator, error) {
+	evaluator := &policyEvaluator{policy: policy}
+	for _, matchCondition := range policy.Spec.MatchConditions {
+		compiled, err := compileExpression(env, matchCondition.Name, matchCondition.Expression)
+		if err != nil {
+			return nil, err
+		}
+		evaluator.matchConditions = append(evaluator.matchConditions, *compiled)
+	}
+	for _, variable := range policy.Spec.Variables {
+		compiled, err := compileExpression(env, variable.Name, variable.Expression)
+		if err != nil {
+			return nil, err
+		}
+		evaluator.variables = append(evaluator.variables, *compiled)
+	}
+	for _, validation := range policy.Spec.Validations {
+		compiled, err := compileExpression(env, "", validation.Expression)
+		if err != nil {
+			return nil, err
+		}
+		compiledValidation := compiledValidation{compiledExpression: *compiled, message: validation.Message}
+		if validation.MessageExpression != "" {
+			if compiledValidation.messageExpression, err = compileExpression(env, "", validation.MessageExpression); err != nil {
+				return nil, err
+			}
+		}
+		evaluator.validations = append(evaluator.validations, compiledValidation)
+	}
+	return evaluator, nil
+}
+
+func compileExpression(env *cel.Env, name, expression string) (*compiledExpression, error) {
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile expression %q: %w", expression, issues.Err())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile expression %q: %w", expression, err)
+	}
+	return &compiledExpression{name: name, expression: expression, program: program}, nil
+}
+
+// evaluate evaluates the policy on the creation of the object. It returns nil when the match conditions exclude the object
+func (evaluator *policyEvaluator) evaluate(object workloadinterface.IMetadata, params map[string]interface{}, namespaceObject map[string]interface{}) *evaluation {
+	activation := map[string]interface{}{
+		"object":          normalizeNumbers(object.GetObject()),
+		"oldObject":       nil,
+		"params":          nil,
+		"request":         newAdmissionRequest(object),
+		"namespaceObject": nil,
+	}
+	if params != nil {
+		activation["params"] = normalizeNumbers(params)
+	}
+	if namespaceObject != nil {
+		activation["namespaceObject"] = normalizeNumbers(namespaceObject)
+	}
+
+	// the variables are evaluated in order, each variable can reference the previous ones
+	variables := make(map[string]interface{}, len(evaluator.variables))
+	activation["variables"] = variables
+
+	for _, matchCondition := range evaluator.matchConditions {
+		matches, err := evalBool(&matchCondition, activation)
+		if err != nil {
+			return evaluator.onError(&matchCondition, err)
+		}
+		if !matches {
+			return nil
+		}
+	}
+	for _, variable := range evaluator.variables {
+		val, _, err := variable.program.Eval(activation)
+		if err != nil {
+			return evaluator.onError(&variable, err)
+		}
+		variables[variable.name] = val
+	}
+	for i := range evaluator.validations {
+		validation := &evaluator.validations[i]
+		allowed, err := evalBool(&validation.compiledExpression, activation)
+		if err != nil {
+			return evaluator.onError(&validation.compiledExpression, err)
+		}
+		if !allowed {
+			return &evaluation{Allowed: false, Expression: validation.expression, Message: validation.getMessage(activation)}
+		}
+	}
+	return &evaluation{Allowed: true}
+}
+
+// onError applies the failure policy of the policy when an expression cannot be evaluated
+func (evaluator *policyEvaluator) onError(expression *compiledExpression, err error) *evaluation {
+	if evaluator.policy.Spec.FailurePolicy != nil && *evaluator.policy.Spec.FailurePolicy == admissionv1.Ignore {
+		return &evaluation{Allowed: true}
+	}
+	return &evaluation{Allowed: false, Expression: expression.expression, Message: fmt.Sprintf("failed to evaluate expression: %s", err.Error())}
+}
+
+func (validation *compiledValidation) getMessage(activation map[string]interface{}) string {
+	if validation.messageExpression != nil {
+		if val, _, err := validation.messageExpression.program.Eval(activation); err == nil {
+			if message, ok := val.Value().(string); ok && message != "" {
+				return message
+			}
+		}
+	}
+	if validation.message != "" {
+		return validation.message
+	}
+	return fmt.Sprintf("failed expression: %s", validation.expression)
+}
+
+func evalBool(expression *compiledExpression, activation map[string]interface{}) (bool, error) {
+	val, _, err := expression.program.Eval(activation)
+	if err != nil {
+		return false, err
+	}
+	if val.Type() != types.BoolType {
+		return false, fmt.Errorf("expression %q does not return a boolean", expression.expression)
+	}
+	return val.Value().(bool), nil
+}
+
+// newAdmissionRequest returns the request attributes of the creation of the object
+func newAdmissionRequest(object workloadinterface.IMetadata) map[string]interface{} {
+	group, version := splitAPIVersion(object.GetApiVersion())
+	return map[string]interface{}{
+		"kind":      map[string]interface{}{"group": group, "version": version, "kind": object.GetKind()},
+		"resource":  map[string]interface{}{"group": group, "version": version, "resource": getResource(object.GetKind())},
+		"name":      object.GetName(),
+		"namespace": object.GetNamespace(),
+		"operation": string(admissionv1.Create),
+		"dryRun":    true,
+	}
+}
+
+// normalizeNumbers converts the whole numbers decoded as floats to integers, so they compare to the CEL integers the way the API server objects do
+func normalizeNumbers(object interface{}) interface{} {
+	switch v := object.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if key == "sourcePath" {
+				continue // added when the object is loaded from a file
+			}
+			normalized[key] = normalizeNumbers(value)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i := range v {
+			normalized[i] = normalizeNumbers(v[i])
+		}
+		return normalized
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+			return int64(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// matchesResources returns true when the creation of the object matches the resources. The namespace selector is evaluated against
+// the labels of the namespace of the object
+func matchesResources(match *admissionv1.MatchResources, object workloadinterface.IMetadata, namespaceLabels map[string]string) bool {
+	if match == nil {
+		return true
+	}
+	if len(match.ResourceRules) > 0 && !matchesAnyRule(match.ResourceRules, object) {
+		return false
+	}
+	if matchesAnyRule(match.ExcludeResourceRules, object) {
+		return false
+	}
+	if match.NamespaceSelector != nil && object.GetNamespace() != "" {
+		if !matchesSelector(match.NamespaceSelector, namespaceLabels) {
+			return false
+		}
+	}
+	if match.ObjectSelector != nil {
+		if !matchesSelector(match.ObjectSelector, workloadinterface.NewWorkloadObj(object.GetObject()).GetLabels()) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesSelector(selector *metav1.LabelSelector, objectLabels map[string]string) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(labels.Set(objectLabels))
+}
+
+func matchesAnyRule(rules []admissionv1.NamedRuleWithOperations, object workloadinterface.IMetadata) bool {
+	group, version := splitAPIVersion(object.GetApiVersion())
+	resource := getResource(object.GetKind())
+	for _, rule := range rules {
+		if len(rule.ResourceNames) > 0 && !slices.Contains(rule.ResourceNames, object.GetName()) {
+			continue
+		}
+		if !matchesValue(operationsToStrings(rule.Operations), string(admissionv1.Create)) ||
+			!matchesValue(rule.APIGroups, group) ||
+			!matchesValue(rule.APIVersions, version) ||
+			!matchesValue(rule.Resources, resource) {
+			continue
+		}
+		if rule.Scope != nil {
+			if *rule.Scope == admissionv1.NamespacedScope && object.GetNamespace() == "" {
+				continue
+			}
+			if *rule.Scope == admissionv1.ClusterScope && object.GetNamespace() != "" {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func matchesValue(values []string, value string) bool {
+	return slices.Contains(values, "*") || slices.Contains(values, value)
+}
+
+func operationsToStrings(operations []admissionv1.OperationType) []string {
+	s := make([]string, len(operations))
+	for i := range operations {
+		s[i] = string(operations[i])
+	}
+	return s
+}
+
+// splitAPIVersion returns the group and version of an apiVersion, e.g. "apps/v1"
+func splitAPIVersion(apiVersion string) (string, string) {
+	if group, version, ok := strings.Cut(apiVersion, "/"); ok {
+		return group, version
+	}
+	return "", apiVersion
+}
+
+// getResource returns the resource name of a kind, e.g. "deployments"
+func getResource(kind string) string {
+	if groupVersionResource, err := k8sinterface.GetGroupVersionResource(kind); err == nil {
+		return groupVersionResource.Resource
+	}
+	return strings.ToLower(kind) + "s"
+}
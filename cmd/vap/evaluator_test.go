@@ -0,0 +1,100 @@
+package vap
+
+import (
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestDeployment(replicas interface{}) workloadinterface.IMetadata {
+	return workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default", "labels": map[string]interface{}{"app": "nginx"}},
+		"spec":       map[string]interface{}{"replicas": replicas},
+	})
+}
+
+func TestPolicyEvaluator(t *testing.T) {
+	env, err := newCELEnv()
+	require.NoError(t, err)
+
+	policy := &admissionv1.ValidatingAdmissionPolicy{
+		Spec: admissionv1.ValidatingAdmissionPolicySpec{
+			MatchConditions: []admissionv1.MatchCondition{{Name: "not-excluded", Expression: `!has(object.metadata.labels) || !("excluded" in object.metadata.labels)`}},
+			Variables: []admissionv1.Variable{
+				{Name: "replicas", Expression: "object.spec.replicas"},
+				{Name: "maxReplicas", Expression: "params == null ? 3 : params.maxReplicas"},
+			},
+			Validations: []admissionv1.Validation{
+				{Expression: "request.operation == 'CREATE' && request.resource.resource == 'deployments'"},
+				{Expression: "variables.replicas <= variables.maxReplicas", MessageExpression: "'too many replicas: ' + string(variables.replicas)"},
+			},
+		},
+	}
+	evaluator, err := newPolicyEvaluator(env, policy)
+	require.NoError(t, err)
+
+	// the numbers decoded from JSON are compared as integers
+	assert.Equal(t, &evaluation{Allowed: true}, evaluator.evaluate(newTestDeployment(float64(2)), nil, nil))
+	assert.Equal(t, &evaluation{Allowed: false, Expression: "variables.replicas <= variables.maxReplicas", Message: "too many replicas: 5"},
+		evaluator.evaluate(newTestDeployment(float64(5)), nil, nil))
+	assert.True(t, evaluator.evaluate(newTestDeployment(5), map[string]interface{}{"maxReplicas": float64(10)}, nil).Allowed)
+
+	excluded := newTestDeployment(5)
+	excluded.(*workloadinterface.Workload).SetLabel("excluded", "true")
+	assert.Nil(t, evaluator.evaluate(excluded, nil, nil))
+
+	t.Run("failure policy", func(t *testing.T) {
+		policy := &admissionv1.ValidatingAdmissionPolicy{
+			Spec: admissionv1.ValidatingAdmissionPolicySpec{
+				Validations: []admissionv1.Validation{{Expression: "object.spec.missing == 1", Message: "missing"}},
+			},
+		}
+		evaluator, err := newPolicyEvaluator(env, policy)
+		require.NoError(t, err)
+		evaluated := evaluator.evaluate(newTestDeployment(1), nil, nil)
+		assert.False(t, evaluated.Allowed)
+		assert.Contains(t, evaluated.Message, "failed to evaluate expression")
+
+		ignore := admissionv1.Ignore
+		policy.Spec.FailurePolicy = &ignore
+		assert.True(t, evaluator.evaluate(newTestDeployment(1), nil, nil).Allowed)
+	})
+
+	t.Run("compilation error", func(t *testing.T) {
+		_, err := newPolicyEvaluator(env, &admissionv1.ValidatingAdmissionPolicy{
+			Spec: admissionv1.ValidatingAdmissionPolicySpec{Validations: []admissionv1.Validation{{Expression: "object.spec."}}},
+		})
+		assert.ErrorContains(t, err, "failed to compile expression")
+	})
+}
+
+func TestMatchesResources(t *testing.T) {
+	deployment := newTestDeployment(1)
+	rule := func(groups, resources []string) admissionv1.NamedRuleWithOperations {
+		return admissionv1.NamedRuleWithOperations{RuleWithOperations: admissionv1.RuleWithOperations{
+			Operations: []admissionv1.OperationType{admissionv1.Create, admissionv1.Update},
+			Rule:       admissionv1.Rule{APIGroups: groups, APIVersions: []string{"*"}, Resources: resources},
+		}}
+	}
+
+	assert.True(t, matchesResources(nil, deployment, nil))
+	assert.True(t, matchesResources(&admissionv1.MatchResources{ResourceRules: []admissionv1.NamedRuleWithOperations{rule([]string{"apps"}, []string{"deployments"})}}, deployment, nil))
+	assert.False(t, matchesResources(&admissionv1.MatchResources{ResourceRules: []admissionv1.NamedRuleWithOperations{rule([]string{""}, []string{"pods"})}}, deployment, nil))
+	assert.False(t, matchesResources(&admissionv1.MatchResources{
+		ResourceRules:        []admissionv1.NamedRuleWithOperations{rule([]string{"*"}, []string{"*"})},
+		ExcludeResourceRules: []admissionv1.NamedRuleWithOperations{rule([]string{"apps"}, []string{"deployments"})},
+	}, deployment, nil))
+
+	namespaceSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "prod"}}
+	assert.False(t, matchesResources(&admissionv1.MatchResources{NamespaceSelector: namespaceSelector}, deployment, map[string]string{"kubernetes.io/metadata.name": "default"}))
+	assert.True(t, matchesResources(&admissionv1.MatchResources{NamespaceSelector: namespaceSelector}, deployment, map[string]string{"kubernetes.io/metadata.name": "prod"}))
+
+	assert.True(t, matchesResources(&admissionv1.MatchResources{ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}}}, deployment, nil))
+	assert.False(t, matchesResources(&admissionv1.MatchResources{ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}}}, deployment, nil))
+}
@@ -0,0 +1,320 @@
+package vap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	"github.com/kubescape/opa-utils/objectsenvelopes"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/spf13/cobra"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	controlIDRegex = regexp.MustCompile(`(?i)\bc-\d{4}\b`)
+	labelRegex     = regexp.MustCompile(`^[a-zA-Z0-9]+=[a-zA-Z0-9]+$`)
+)
+
+// controlIDLabel is the label of the library policies holding the ID of the control they implement
+const controlIDLabel = "controlId"
+
+type generateOptions struct {
+	reportPath         string
+	libraryPath        string
+	exceptionsPath     string
+	namespaces         []string
+	labels             []string
+	action             string
+	parameterReference string
+}
+
+func getGenerateCmd() *cobra.Command {
+	var options generateOptions
+
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate policies and bindings enforcing the controls that pass in a scan report",
+		Long: `Pick the controls that pass on all the resources of a namespace in a scan report and generate the Validating Admission Policies
+of the library implementing them, with bindings scoped to these namespaces. Exceptions become match conditions of the policies.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, namespace := range options.namespaces {
+				if err := isValidK8sObjectName(namespace); err != nil {
+					return fmt.Errorf("invalid namespace %s: %w", namespace, err)
+				}
+			}
+			for _, label := range options.labels {
+				// Label selector must be in the format key=value
+				if !labelRegex.MatchString(label) {
+					return fmt.Errorf("invalid label selector: %s", label)
+				}
+			}
+			if options.action != "Deny" && options.action != "Audit" && options.action != "Warn" {
+				return fmt.Errorf("invalid action: %s", options.action)
+			}
+			if err := isValidK8sObjectName(options.parameterReference); err != nil {
+				return fmt.Errorf("invalid parameter reference %s: %w", options.parameterReference, err)
+			}
+
+			return generatePolicies(&options, os.Stdout)
+		},
+	}
+	generateCmd.Flags().StringVar(&options.reportPath, "report", "", "Path to a JSON scan report, e.g. the output of 'scan --format json'")
+	generateCmd.MarkFlagRequired("report")
+	generateCmd.Flags().StringVar(&options.libraryPath, "library", "", "Path to a copy of the admission policy library saved beforehand with \"vap deploy-library > library.yaml\", the latest release is downloaded from GitHub when not set. No library is bundled, so it is required offline")
+	generateCmd.Flags().StringVar(&options.exceptionsPath, "exceptions", "", "Path to an exceptions file, the excepted resources are excluded from the policies")
+	generateCmd.Flags().StringSliceVar(&options.namespaces, "namespace", []string{}, "Namespaces to enforce, all the namespaces of the report when not set")
+	generateCmd.Flags().StringSliceVar(&options.labels, "label", []string{}, "Resource label selector of the bindings")
+	generateCmd.Flags().StringVarP(&options.action, "action", "a", "Deny", "Action to take when policy fails")
+	generateCmd.Flags().StringVarP(&options.parameterReference, "parameter-reference", "r", "basic-control-configuration", "Parameter reference object name of the policies with parameters")
+
+	return generateCmd
+}
+
+// generate
+func generatePolicies(options *generateOptions, out io.Writer) error {
+	report, err := loadReport(options.reportPath)
+	if err != nil {
+		return err
+	}
+	var exceptions []armotypes.PostureExceptionPolicy
+	if options.exceptionsPath != "" {
+		if exceptions, err = getter.NewLoadPolicy([]string{options.exceptionsPath}).GetExceptions(""); err != nil {
+			return fmt.Errorf("failed to load exceptions: %w", err)
+		}
+	}
+	library, err := loadLibrary(options.libraryPath)
+	if err != nil {
+		return err
+	}
+	policies, err := getLibraryPolicies(library)
+	if err != nil {
+		return err
+	}
+
+	passingControls := getPassingControls(report, options.namespaces)
+
+	var documents [][]byte
+	var bindings []*admissionv1.ValidatingAdmissionPolicyBinding
+	for _, controlID := range sortedKeys(passingControls) {
+		policy, ok := policies[controlID]
+		if !ok {
+			logger.L().Debug("no admission policy implements the control", helpers.String("controlID", controlID))
+			continue
+		}
+		policy.Spec.MatchConditions = append(policy.Spec.MatchConditions, exceptionsToMatchConditions(controlID, exceptions)...)
+
+		paramRef := ""
+		if policy.Spec.ParamKind != nil {
+			paramRef = options.parameterReference
+		}
+		bindings = append(bindings, newPolicyBinding(policy.Name+"-binding", policy.Name, options.action, paramRef, sortedKeys(passingControls[controlID]), options.labels))
+
+		b, err := yaml.Marshal(policy)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, b)
+	}
+	for _, binding := range bindings {
+		b, err := yaml.Marshal(binding)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, b)
+	}
+	if len(documents) == 0 {
+		return errors.New("no admission policy implements the passing controls of the report")
+	}
+	logger.L().Info("Generated admission policies", helpers.Int("policies", len(bindings)))
+
+	_, err = out.Write(bytes.Join(documents, []byte("---\n")))
+	return err
+}
+
+func loadReport(reportPath string) (*reporthandlingv2.PostureReport, error) {
+	b, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	report := &reporthandlingv2.PostureReport{}
+	if err := json.Unmarshal(b, report); err != nil {
+		return nil, fmt.Errorf("failed to decode report %s, the report must be in JSON format: %w", reportPath, err)
+	}
+	return report, nil
+}
+
+// getLibraryPolicies returns the admission policies of the library per control ID
+func getLibraryPolicies(library string) (map[string]*admissionv1.ValidatingAdmissionPolicy, error) {
+	policies := make(map[string]*admissionv1.ValidatingAdmissionPolicy)
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(library)))
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read admission policy library: %w", err)
+		}
+
+		policy := &admissionv1.ValidatingAdmissionPolicy{}
+		if err := yaml.Unmarshal(document, policy); err != nil || policy.Kind != "ValidatingAdmissionPolicy" {
+			continue
+		}
+		controlID := policy.Labels[controlIDLabel]
+		if controlID == "" {
+			controlID = controlIDRegex.FindString(policy.Name)
+		}
+		if controlID == "" {
+			continue
+		}
+		policy.APIVersion = "admissionregistration.k8s.io/v1"
+		policies[strings.ToUpper(controlID)] = policy
+	}
+	return policies, nil
+}
+
+// getPassingControls returns the namespaces of each control which has resources in the namespace and passes on all of them,
+// map[<control ID>]map[<namespace>]. Only the given namespaces are kept when set
+func getPassingControls(report *reporthandlingv2.PostureReport, namespaces []string) map[string]map[string]bool {
+	resourceNamespaces := make(map[string]string, len(report.Resources))
+	for i := range report.Resources {
+		if object, ok := report.Resources[i].Object.(map[string]interface{}); ok {
+			if resource := objectsenvelopes.NewObject(object); resource != nil {
+				resourceNamespaces[report.Resources[i].ResourceID] = resource.GetNamespace()
+			}
+		}
+	}
+
+	passed := make(map[string]map[string]bool) // map[<control ID>]map[<namespace>]<passed on all the resources>
+	for i := range report.Results {
+		namespace, ok := resourceNamespaces[report.Results[i].ResourceID]
+		if !ok {
+			namespace = namespaceFromResourceID(report.Results[i].ResourceID)
+		}
+		// cluster scoped resources are not bound by namespace
+		if namespace == "" || (len(namespaces) > 0 && !slices.Contains(namespaces, namespace)) {
+			continue
+		}
+		for _, control := range report.Results[i].ListControls() {
+			status := control.GetStatus(nil)
+			if !status.IsPassed() && !status.IsFailed() {
+				continue
+			}
+			if _, ok := passed[control.GetID()]; !ok {
+				passed[control.GetID()] = make(map[string]bool)
+			}
+			if p, ok := passed[control.GetID()][namespace]; !ok || p {
+				passed[control.GetID()][namespace] = status.IsPassed()
+			}
+		}
+	}
+
+	passingControls := make(map[string]map[string]bool)
+	for controlID, namespacesStatus := range passed {
+		for namespace, p := range namespacesStatus {
+			if !p {
+				continue
+			}
+			if _, ok := passingControls[controlID]; !ok {
+				passingControls[controlID] = make(map[string]bool)
+			}
+			passingControls[controlID][namespace] = true
+		}
+	}
+	return passingControls
+}
+
+// namespaceFromResourceID returns the namespace of a resource ID, e.g. "apps/v1/default/Deployment/nginx"
+func namespaceFromResourceID(resourceID string) string {
+	parts := strings.Split(resourceID, "/")
+	if len(parts) != 5 {
+		return ""
+	}
+	return parts[2]
+}
+
+// exceptionsToMatchConditions returns match conditions excluding the resources excepted from the control. The exceptions designating
+// resources by ID or path cannot be expressed in CEL and are ignored
+func exceptionsToMatchConditions(controlID string, exceptions []armotypes.PostureExceptionPolicy) []admissionv1.MatchCondition {
+	var matchConditions []admissionv1.MatchCondition
+	for i := range exceptions {
+		if !exceptionAppliesToControl(&exceptions[i], controlID) {
+			continue
+		}
+		for j := range exceptions[i].Resources {
+			attributes := exceptions[i].Resources[j].DigestPortalDesignator()
+			if attributes.GetResourceID() != "" || attributes.GetPath() != "" {
+				logger.L().Warning("exceptions by resource ID or path are not supported by admission policies", helpers.String("exception", exceptions[i].Name))
+				continue
+			}
+
+			var conditions []string
+			if kind := attributes.GetKind(); kind != "" {
+				conditions = append(conditions, fmt.Sprintf("object.kind.matches(%s)", celRegex(kind)))
+			}
+			if namespace := attributes.GetNamespace(); namespace != "" {
+				conditions = append(conditions, fmt.Sprintf("has(object.metadata.namespace) && object.metadata.namespace.matches(%s)", celRegex(namespace)))
+			}
+			if name := attributes.GetName(); name != "" {
+				conditions = append(conditions, fmt.Sprintf("has(object.metadata.name) && object.metadata.name.matches(%s)", celRegex(name)))
+			}
+			labels := attributes.GetLabels()
+			for _, key := range sortedKeys(labels) {
+				conditions = append(conditions, fmt.Sprintf("has(object.metadata.labels) && %s in object.metadata.labels && object.metadata.labels[%s].matches(%s)",
+					strconv.Quote(key), strconv.Quote(key), celRegex(labels[key])))
+			}
+			if len(conditions) == 0 {
+				continue
+			}
+
+			matchConditions = append(matchConditions, admissionv1.MatchCondition{
+				Name:       fmt.Sprintf("exception-%d-%d", i, j),
+				Expression: fmt.Sprintf("object == null || !(%s)", strings.Join(conditions, " && ")),
+			})
+		}
+	}
+	return matchConditions
+}
+
+// exceptionAppliesToControl returns true when the exception covers the control, or all the controls
+func exceptionAppliesToControl(exception *armotypes.PostureExceptionPolicy, controlID string) bool {
+	if len(exception.PosturePolicies) == 0 {
+		return true
+	}
+	for _, posturePolicy := range exception.PosturePolicies {
+		if posturePolicy.ControlID == "" || strings.EqualFold(posturePolicy.ControlID, controlID) {
+			return true
+		}
+	}
+	return false
+}
+
+// celRegex returns a CEL string of the regex matching the whole value, the same way exceptions are matched by Kubescape
+func celRegex(value string) string {
+	return strconv.Quote("^" + value + "$")
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
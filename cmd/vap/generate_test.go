@@ -0,0 +1,144 @@
+package vap
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/armosec/armoapi-go/identifiers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+func readDocuments(t *testing.T, b []byte) (policies []admissionv1.ValidatingAdmissionPolicy, bindings []admissionv1.ValidatingAdmissionPolicyBinding) {
+	t.Helper()
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return policies, bindings
+		}
+		require.NoError(t, err)
+
+		binding := admissionv1.ValidatingAdmissionPolicyBinding{}
+		require.NoError(t, yaml.Unmarshal(document, &binding))
+		switch binding.Kind {
+		case "ValidatingAdmissionPolicy":
+			policy := admissionv1.ValidatingAdmissionPolicy{}
+			require.NoError(t, yaml.Unmarshal(document, &policy))
+			policies = append(policies, policy)
+		case "ValidatingAdmissionPolicyBinding":
+			bindings = append(bindings, binding)
+		default:
+			t.Fatalf("unexpected kind %s", binding.Kind)
+		}
+	}
+}
+
+func TestGeneratePolicies(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, generatePolicies(&generateOptions{
+		reportPath:         "testdata/report.json",
+		libraryPath:        "testdata/library.yaml",
+		exceptionsPath:     "testdata/exceptions.json",
+		action:             "Deny",
+		parameterReference: "basic-control-configuration",
+	}, &out))
+
+	policies, bindings := readDocuments(t, out.Bytes())
+	require.Len(t, policies, 3)
+	require.Len(t, bindings, 3)
+
+	// C-0012 passes in default, C-0016 passes in all the namespaces, C-0017 passes in prod
+	assert.Equal(t, "kubescape-c-0012-credentials-in-env-var", policies[0].Name)
+	assert.Equal(t, "kubescape-c-0016-allow-privilege-escalation", policies[1].Name)
+	assert.Equal(t, "kubescape-c-0017-immutable-container-filesystem", policies[2].Name)
+
+	assert.Equal(t, "kubescape-c-0012-credentials-in-env-var", bindings[0].Spec.PolicyName)
+	assert.Equal(t, []string{"default"}, bindings[0].Spec.MatchResources.NamespaceSelector.MatchExpressions[0].Values)
+	require.NotNil(t, bindings[0].Spec.ParamRef)
+	assert.Equal(t, "basic-control-configuration", bindings[0].Spec.ParamRef.Name)
+
+	assert.Equal(t, "kubescape-c-0016-allow-privilege-escalation-binding", bindings[1].Name)
+	assert.Equal(t, []string{"default", "prod"}, bindings[1].Spec.MatchResources.NamespaceSelector.MatchExpressions[0].Values)
+	assert.Nil(t, bindings[1].Spec.ParamRef)
+	assert.Equal(t, []admissionv1.ValidationAction{admissionv1.Deny}, bindings[1].Spec.ValidationActions)
+
+	assert.Equal(t, []string{"prod"}, bindings[2].Spec.MatchResources.NamespaceSelector.MatchExpressions[0].Values)
+
+	// only the exception of C-0016 becomes a match condition
+	assert.Empty(t, policies[0].Spec.MatchConditions)
+	require.Len(t, policies[1].Spec.MatchConditions, 1)
+	assert.Equal(t, `object == null || !(object.kind.matches("^Pod$") && has(object.metadata.namespace) && object.metadata.namespace.matches("^default$") && has(object.metadata.name) && object.metadata.name.matches("^redis-.*$") && has(object.metadata.labels) && "app" in object.metadata.labels && object.metadata.labels["app"].matches("^redis$"))`,
+		policies[1].Spec.MatchConditions[0].Expression)
+}
+
+func TestGeneratePoliciesNamespacesAndLabels(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, generatePolicies(&generateOptions{
+		reportPath:  "testdata/report.json",
+		libraryPath: "testdata/library.yaml",
+		namespaces:  []string{"prod"},
+		labels:      []string{"tier=backend"},
+		action:      "Warn",
+	}, &out))
+
+	policies, bindings := readDocuments(t, out.Bytes())
+	require.Len(t, policies, 2)
+	assert.Equal(t, "kubescape-c-0016-allow-privilege-escalation", policies[0].Name)
+	assert.Equal(t, "kubescape-c-0017-immutable-container-filesystem", policies[1].Name)
+
+	require.Len(t, bindings, 2)
+	assert.Equal(t, []string{"prod"}, bindings[0].Spec.MatchResources.NamespaceSelector.MatchExpressions[0].Values)
+	assert.Equal(t, map[string]string{"tier": "backend"}, bindings[0].Spec.MatchResources.ObjectSelector.MatchLabels)
+	assert.Equal(t, []admissionv1.ValidationAction{admissionv1.Warn}, bindings[0].Spec.ValidationActions)
+}
+
+func TestGeneratePoliciesErrors(t *testing.T) {
+	assert.ErrorContains(t, generatePolicies(&generateOptions{reportPath: "testdata/missing.json", libraryPath: "testdata/library.yaml"}, io.Discard), "failed to read report")
+	assert.ErrorContains(t, generatePolicies(&generateOptions{reportPath: "testdata/library.yaml", libraryPath: "testdata/library.yaml"}, io.Discard), "JSON format")
+	assert.ErrorContains(t, generatePolicies(&generateOptions{reportPath: "testdata/report.json", libraryPath: "testdata/library.yaml", namespaces: []string{"staging"}}, io.Discard), "no admission policy")
+}
+
+func TestGetLibraryPolicies(t *testing.T) {
+	library, err := os.ReadFile("testdata/library.yaml")
+	require.NoError(t, err)
+
+	policies, err := getLibraryPolicies(string(library))
+	require.NoError(t, err)
+	assert.Len(t, policies, 3)
+	assert.Equal(t, "kubescape-c-0016-allow-privilege-escalation", policies["C-0016"].Name)
+	assert.Equal(t, "kubescape-c-0017-immutable-container-filesystem", policies["C-0017"].Name)
+}
+
+func TestNamespaceFromResourceID(t *testing.T) {
+	assert.Equal(t, "default", namespaceFromResourceID("apps/v1/default/Deployment/nginx"))
+	assert.Equal(t, "kube-system", namespaceFromResourceID("/v1/kube-system/Pod/coredns"))
+	assert.Equal(t, "", namespaceFromResourceID("rbac.authorization.k8s.io/v1//ClusterRole/admin"))
+	assert.Equal(t, "", namespaceFromResourceID("path=123/api=/v1//Pod/nginx"))
+}
+
+func TestExceptionsToMatchConditions(t *testing.T) {
+	exceptions := []armotypes.PostureExceptionPolicy{
+		{
+			PortalBase: armotypes.PortalBase{Name: "all-controls"},
+			Resources:  []identifiers.PortalDesignator{{DesignatorType: identifiers.DesignatorAttributes, Attributes: map[string]string{identifiers.AttributeNamespace: "kube-system"}}},
+		},
+		{
+			PortalBase: armotypes.PortalBase{Name: "by-id"},
+			Resources:  []identifiers.PortalDesignator{{DesignatorType: identifiers.DesignatorAttributes, Attributes: map[string]string{identifiers.AttributeResourceID: "/v1/default/Pod/nginx"}}},
+		},
+	}
+
+	matchConditions := exceptionsToMatchConditions("C-0016", exceptions)
+	require.Len(t, matchConditions, 1)
+	assert.Equal(t, "exception-0-0", matchConditions[0].Name)
+	assert.Equal(t, `object == null || !(has(object.metadata.namespace) && object.metadata.namespace.matches("^kube-system$"))`, matchConditions[0].Expression)
+}
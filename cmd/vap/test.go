@@ -0,0 +1,450 @@
+package vap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/spf13/cobra"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	testFormatPretty = "pretty"
+	testFormatJSON   = "json"
+)
+
+type testOptions struct {
+	policiesPaths      []string
+	namespace          string
+	format             string
+	parameterReference string
+}
+
+// testResult is the result of the evaluation of the policies on an object
+type testResult struct {
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace,omitempty"`
+	Name      string        `json:"name"`
+	Path      string        `json:"path,omitempty"`
+	Allowed   bool          `json:"allowed"`
+	Failures  []testFailure `json:"failures,omitempty"`
+}
+
+// testFailure is a failed validation of a policy binding
+type testFailure struct {
+	Policy     string `json:"policy"`
+	Binding    string `json:"binding,omitempty"`
+	Action     string `json:"action"`
+	Expression string `json:"expression,omitempty"`
+	Message    string `json:"message"`
+}
+
+// policyTest holds the policies, bindings and parameters the objects are tested against
+type policyTest struct {
+	evaluators map[string]*policyEvaluator // map[<policy name>]
+	bindings   []*admissionv1.ValidatingAdmissionPolicyBinding
+	params     []workloadinterface.IMetadata
+	namespaces map[string]map[string]interface{} // map[<namespace name>]<namespace object>
+}
+
+func getTestCmd() *cobra.Command {
+	var options testOptions
+
+	testCmd := &cobra.Command{
+		Use:   "test [manifests path]",
+		Short: "Test which objects the admission policies would reject",
+		Long: `Evaluate the CEL expressions of Validating Admission Policies against the manifests of a directory or the objects of a live namespace,
+as if the objects were created. Policies without bindings are evaluated as if they were bound to all the objects.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("accepts at most one manifests path")
+			}
+			if len(args) == 0 && options.namespace == "" {
+				return errors.New("a manifests path or a namespace is required")
+			}
+			if len(args) == 1 && options.namespace != "" {
+				return errors.New("a manifests path and a namespace cannot be tested together")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.format != testFormatPretty && options.format != testFormatJSON {
+				return fmt.Errorf("invalid format: %s", options.format)
+			}
+			if options.parameterReference != "" {
+				if err := isValidK8sObjectName(options.parameterReference); err != nil {
+					return fmt.Errorf("invalid parameter reference %s: %w", options.parameterReference, err)
+				}
+			}
+
+			var objects []workloadinterface.IMetadata
+			if len(args) == 1 {
+				objects = loadObjects(cmd.Context(), args[0])
+			} else {
+				if err := isValidK8sObjectName(options.namespace); err != nil {
+					return fmt.Errorf("invalid namespace %s: %w", options.namespace, err)
+				}
+				var err error
+				if objects, err = listNamespaceObjects(cmd.Context(), options.policiesPaths, options.namespace); err != nil {
+					return err
+				}
+			}
+
+			results, err := testPolicies(cmd.Context(), &options, objects)
+			if err != nil {
+				return err
+			}
+			return printTestResults(os.Stdout, options.format, results)
+		},
+	}
+	testCmd.Flags().StringSliceVarP(&options.policiesPaths, "policies", "p", []string{}, "Paths of the policies, bindings and parameters to test, e.g. the output of 'vap deploy-library'")
+	testCmd.MarkFlagRequired("policies")
+	testCmd.Flags().StringVarP(&options.namespace, "namespace", "n", "", "Test the objects of a namespace of the cluster instead of manifests")
+	testCmd.Flags().StringVarP(&options.format, "format", "f", testFormatPretty, "Output format. Supported formats: pretty, json")
+	testCmd.Flags().StringVarP(&options.parameterReference, "parameter-reference", "r", "basic-control-configuration", "Parameter reference object name of the policies with parameters and without bindings")
+
+	return testCmd
+}
+
+// loadObjects loads the objects of the files of a path
+func loadObjects(ctx context.Context, path string) []workloadinterface.IMetadata {
+	// the paths of the objects are relative to the loaded directory
+	rootPath, err := filepath.Abs(path)
+	if err != nil {
+		rootPath = path
+	}
+	if info, err := os.Stat(rootPath); err == nil && !info.IsDir() {
+		rootPath = filepath.Dir(rootPath)
+	}
+
+	var objects []workloadinterface.IMetadata
+	workloads := cautils.LoadResourcesFromFiles(ctx, path, rootPath)
+	for _, file := range sortedKeys(workloads) {
+		objects = append(objects, workloads[file]...)
+	}
+	return objects
+}
+
+// testPolicies evaluates the policies on the creation of each object
+func testPolicies(ctx context.Context, options *testOptions, objects []workloadinterface.IMetadata) ([]testResult, error) {
+	var policyObjects []workloadinterface.IMetadata
+	for _, path := range options.policiesPaths {
+		policyObjects = append(policyObjects, loadObjects(ctx, path)...)
+	}
+	test, err := newPolicyTest(policyObjects, options.parameterReference)
+	if err != nil {
+		return nil, err
+	}
+	if len(test.evaluators) == 0 {
+		return nil, errors.New("no admission policy found")
+	}
+	for _, object := range objects {
+		if object.GetKind() == "Namespace" {
+			test.namespaces[object.GetName()] = object.GetObject()
+		}
+	}
+
+	results := make([]testResult, 0, len(objects))
+	for _, object := range objects {
+		results = append(results, test.test(object))
+	}
+	return results, nil
+}
+
+// newPolicyTest compiles the policies and adds the default bindings of the policies without bindings
+func newPolicyTest(objects []workloadinterface.IMetadata, parameterReference string) (*policyTest, error) {
+	env, err := newCELEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	test := &policyTest{
+		evaluators: make(map[string]*policyEvaluator),
+		namespaces: make(map[string]map[string]interface{}),
+	}
+	for _, object := range objects {
+		switch object.GetKind() {
+		case "ValidatingAdmissionPolicy":
+			policy := &admissionv1.ValidatingAdmissionPolicy{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.GetObject(), policy); err != nil {
+				return nil, fmt.Errorf("failed to decode policy %s: %w", object.GetName(), err)
+			}
+			evaluator, err := newPolicyEvaluator(env, policy)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compile policy %s: %w", policy.Name, err)
+			}
+			test.evaluators[policy.Name] = evaluator
+		case "ValidatingAdmissionPolicyBinding":
+			binding := &admissionv1.ValidatingAdmissionPolicyBinding{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.GetObject(), binding); err != nil {
+				return nil, fmt.Errorf("failed to decode policy binding %s: %w", object.GetName(), err)
+			}
+			test.bindings = append(test.bindings, binding)
+		default:
+			test.params = append(test.params, object)
+		}
+	}
+
+	bound := make(map[string]bool, len(test.bindings))
+	for _, binding := range test.bindings {
+		bound[binding.Spec.PolicyName] = true
+	}
+	for _, policyName := range sortedKeys(test.evaluators) {
+		if bound[policyName] {
+			continue
+		}
+		paramRef := ""
+		if test.evaluators[policyName].policy.Spec.ParamKind != nil {
+			paramRef = parameterReference
+		}
+		binding := newPolicyBinding("", policyName, string(admissionv1.Deny), paramRef, nil, nil)
+		test.bindings = append(test.bindings, binding)
+	}
+	return test, nil
+}
+
+// test evaluates the bindings on the object. The object is denied when a binding with the Deny action fails
+func (test *policyTest) test(object workloadinterface.IMetadata) testResult {
+	result := testResult{
+		Kind:      object.GetKind(),
+		Namespace: object.GetNamespace(),
+		Name:      object.GetName(),
+		Allowed:   true,
+	}
+	if localWorkload, ok := object.(interface{ GetPath() string }); ok {
+		result.Path = localWorkload.GetPath()
+	}
+
+	namespaceObject, namespaceLabels := test.getNamespace(object.GetNamespace())
+	for _, binding := range test.bindings {
+		evaluator, ok := test.evaluators[binding.Spec.PolicyName]
+		if !ok {
+			logger.L().Debug("policy of the binding not found", helpers.String("binding", binding.Name), helpers.String("policy", binding.Spec.PolicyName))
+			continue
+		}
+		if !matchesResources(evaluator.policy.Spec.MatchConstraints, object, namespaceLabels) ||
+			!matchesResources(binding.Spec.MatchResources, object, namespaceLabels) {
+			continue
+		}
+
+		for _, evaluated := range test.evaluateBinding(evaluator, binding, object, namespaceObject) {
+			if evaluated.Allowed {
+				continue
+			}
+			actions := make([]string, len(binding.Spec.ValidationActions))
+			for i := range binding.Spec.ValidationActions {
+				actions[i] = string(binding.Spec.ValidationActions[i])
+				if binding.Spec.ValidationActions[i] == admissionv1.Deny {
+					result.Allowed = false
+				}
+			}
+			result.Failures = append(result.Failures, testFailure{
+				Policy:     evaluator.policy.Name,
+				Binding:    binding.Name,
+				Action:     strings.Join(actions, ","),
+				Expression: evaluated.Expression,
+				Message:    evaluated.Message,
+			})
+		}
+	}
+	return result
+}
+
+// evaluateBinding evaluates the policy of the binding once per parameter of the binding
+func (test *policyTest) evaluateBinding(evaluator *policyEvaluator, binding *admissionv1.ValidatingAdmissionPolicyBinding, object workloadinterface.IMetadata, namespaceObject map[string]interface{}) []*evaluation {
+	if evaluator.policy.Spec.ParamKind == nil || binding.Spec.ParamRef == nil {
+		if result := evaluator.evaluate(object, nil, namespaceObject); result != nil {
+			return []*evaluation{result}
+		}
+		return nil
+	}
+
+	params := test.getParams(evaluator.policy.Spec.ParamKind, binding.Spec.ParamRef, object.GetNamespace())
+	if len(params) == 0 {
+		if binding.Spec.ParamRef.ParameterNotFoundAction != nil && *binding.Spec.ParamRef.ParameterNotFoundAction == admissionv1.AllowAction {
+			return nil
+		}
+		return []*evaluation{{Allowed: false, Message: fmt.Sprintf("no params found for policy binding with Deny parameterNotFoundAction, paramKind %s", evaluator.policy.Spec.ParamKind.Kind)}}
+	}
+
+	var evaluations []*evaluation
+	for _, param := range params {
+		if result := evaluator.evaluate(object, param.GetObject(), namespaceObject); result != nil {
+			evaluations = append(evaluations, result)
+		}
+	}
+	return evaluations
+}
+
+// getParams returns the parameters of the kind referenced by the binding. Parameters without a namespace in the reference are
+// looked up in the namespace of the object, or are cluster scoped
+func (test *policyTest) getParams(paramKind *admissionv1.ParamKind, paramRef *admissionv1.ParamRef, objectNamespace string) []workloadinterface.IMetadata {
+	var params []workloadinterface.IMetadata
+	for _, param := range test.params {
+		if param.GetKind() != paramKind.Kind || param.GetApiVersion() != paramKind.APIVersion {
+			continue
+		}
+		if paramRef.Namespace != "" {
+			if param.GetNamespace() != paramRef.Namespace {
+				continue
+			}
+		} else if param.GetNamespace() != "" && param.GetNamespace() != objectNamespace {
+			continue
+		}
+		if paramRef.Name != "" && param.GetName() != paramRef.Name {
+			continue
+		}
+		if paramRef.Selector != nil && !matchesSelector(paramRef.Selector, workloadinterface.NewWorkloadObj(param.GetObject()).GetLabels()) {
+			continue
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// getNamespace returns the namespace object and labels of a namespace. Namespaces which are not part of the tested objects only
+// have the name label, the same way the API server labels all the namespaces
+func (test *policyTest) getNamespace(namespace string) (map[string]interface{}, map[string]string) {
+	if namespace == "" {
+		return nil, nil
+	}
+	namespaceObject, ok := test.namespaces[namespace]
+	if !ok {
+		namespaceObject = map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata":   map[string]interface{}{"name": namespace},
+		}
+	}
+
+	namespaceLabels := map[string]string{}
+	for key, value := range workloadinterface.NewWorkloadObj(namespaceObject).GetLabels() {
+		namespaceLabels[key] = value
+	}
+	namespaceLabels["kubernetes.io/metadata.name"] = namespace
+	return namespaceObject, namespaceLabels
+}
+
+// listNamespaceObjects lists the objects of a namespace of the resources the policies match
+func listNamespaceObjects(ctx context.Context, policiesPaths []string, namespace string) ([]workloadinterface.IMetadata, error) {
+	resources := make(map[schema.GroupVersionResource]bool)
+	for _, path := range policiesPaths {
+		for _, object := range loadObjects(ctx, path) {
+			if object.GetKind() != "ValidatingAdmissionPolicy" {
+				continue
+			}
+			policy := &admissionv1.ValidatingAdmissionPolicy{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.GetObject(), policy); err != nil || policy.Spec.MatchConstraints == nil {
+				continue
+			}
+			for _, rule := range policy.Spec.MatchConstraints.ResourceRules {
+				for _, resource := range getRuleResources(&rule) {
+					resources[resource] = true
+				}
+			}
+		}
+	}
+
+	if !k8sinterface.IsConnectedToCluster() {
+		return nil, errors.New("failed to connect to the cluster, try setting the KUBECONFIG environment variable")
+	}
+	k8sAPI := k8sinterface.NewKubernetesApi()
+	objects := []workloadinterface.IMetadata{}
+	namespaceObject, err := k8sAPI.DynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	objects = append(objects, workloadinterface.NewWorkloadObj(namespaceObject.Object))
+
+	gvrs := make([]schema.GroupVersionResource, 0, len(resources))
+	for resource := range resources {
+		gvrs = append(gvrs, resource)
+	}
+	sort.Slice(gvrs, func(i, j int) bool { return gvrs[i].String() < gvrs[j].String() })
+	for _, gvr := range gvrs {
+		list, err := k8sAPI.DynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			logger.L().Ctx(ctx).Warning("failed to list resources", helpers.String("resource", gvr.String()), helpers.Error(err))
+			continue
+		}
+		for i := range list.Items {
+			// the list items have no kind and apiVersion
+			list.Items[i].SetAPIVersion(gvr.GroupVersion().String())
+			if list.Items[i].GetKind() == "" {
+				list.Items[i].SetKind(strings.TrimSuffix(list.GetKind(), "List"))
+			}
+			objects = append(objects, workloadinterface.NewWorkloadObj(list.Items[i].Object))
+		}
+	}
+	return objects, nil
+}
+
+// getRuleResources returns the resources of a rule, rules with wildcard groups, versions or resources are not listed
+func getRuleResources(rule *admissionv1.NamedRuleWithOperations) []schema.GroupVersionResource {
+	if rule.Scope != nil && *rule.Scope == admissionv1.ClusterScope {
+		return nil
+	}
+	var resources []schema.GroupVersionResource
+	for _, group := range rule.APIGroups {
+		for _, version := range rule.APIVersions {
+			for _, resource := range rule.Resources {
+				if group == "*" || version == "*" || strings.Contains(resource, "*") || strings.Contains(resource, "/") {
+					continue
+				}
+				resources = append(resources, schema.GroupVersionResource{Group: group, Version: version, Resource: resource})
+			}
+		}
+	}
+	return resources
+}
+
+func printTestResults(out io.Writer, format string, results []testResult) error {
+	if format == testFormatJSON {
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+
+	denied := 0
+	for _, result := range results {
+		decision := "ALLOW"
+		if !result.Allowed {
+			decision = "DENY"
+			denied++
+		}
+		object := result.Kind + " " + result.Name
+		if result.Namespace != "" {
+			object = fmt.Sprintf("%s %s/%s", result.Kind, result.Namespace, result.Name)
+		}
+		if result.Path != "" {
+			object = fmt.Sprintf("%s (%s)", object, result.Path)
+		}
+		fmt.Fprintf(out, "%-5s %s\n", decision, object)
+		for _, failure := range result.Failures {
+			fmt.Fprintf(out, "      [%s] %s: %s\n", failure.Action, failure.Policy, failure.Message)
+			if failure.Expression != "" {
+				fmt.Fprintf(out, "        expression: %s\n", failure.Expression)
+			}
+		}
+	}
+	_, err := fmt.Fprintf(out, "\n%d objects tested, %d denied\n", len(results), denied)
+	return err
+}
@@ -0,0 +1,97 @@
+package vap
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testManifests(t *testing.T, policiesPaths ...string) map[string]testResult {
+	t.Helper()
+	objects := loadObjects(context.Background(), "testdata/manifests")
+	require.Len(t, objects, 3)
+
+	results, err := testPolicies(context.Background(), &testOptions{policiesPaths: policiesPaths, parameterReference: "basic-control-configuration"}, objects)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	resultsByName := make(map[string]testResult, len(results))
+	for _, result := range results {
+		resultsByName[result.Name] = result
+	}
+	return resultsByName
+}
+
+func TestTestPolicies(t *testing.T) {
+	results := testManifests(t, "testdata/library.yaml", "testdata/control-configuration.yaml")
+
+	assert.True(t, results["nginx"].Allowed)
+	assert.Empty(t, results["nginx"].Failures)
+	assert.True(t, results["settings"].Allowed)
+	assert.Equal(t, "pods.yaml:1", results["redis"].Path)
+
+	redis := results["redis"]
+	assert.False(t, redis.Allowed)
+	require.Len(t, redis.Failures, 2)
+	assert.Equal(t, "kubescape-c-0012-credentials-in-env-var", redis.Failures[0].Policy)
+	assert.Equal(t, "Deny", redis.Failures[0].Action)
+	assert.Contains(t, redis.Failures[0].Expression, "params.settings.sensitiveKeyNames")
+	assert.Equal(t, "kubescape-c-0016-allow-privilege-escalation", redis.Failures[1].Policy)
+	assert.Contains(t, redis.Failures[1].Message, "allows privilege escalation")
+}
+
+func TestTestPoliciesBindings(t *testing.T) {
+	results := testManifests(t, "testdata/library.yaml", "testdata/control-configuration.yaml", "testdata/bindings.yaml")
+
+	// C-0016 only warns in prod, C-0012 and C-0017 are not bound and deny everywhere
+	redis := results["redis"]
+	assert.False(t, redis.Allowed)
+	require.Len(t, redis.Failures, 2)
+	assert.Equal(t, "kubescape-c-0016-allow-privilege-escalation", redis.Failures[0].Policy)
+	assert.Equal(t, "c-0016-prod", redis.Failures[0].Binding)
+	assert.Equal(t, "Warn", redis.Failures[0].Action)
+	assert.Equal(t, "kubescape-c-0012-credentials-in-env-var", redis.Failures[1].Policy)
+}
+
+func TestTestPoliciesParamsNotFound(t *testing.T) {
+	results := testManifests(t, "testdata/library.yaml")
+
+	for _, name := range []string{"nginx", "redis"} {
+		assert.False(t, results[name].Allowed)
+		assert.Equal(t, "kubescape-c-0012-credentials-in-env-var", results[name].Failures[0].Policy)
+		assert.Contains(t, results[name].Failures[0].Message, "no params found")
+	}
+	assert.True(t, results["settings"].Allowed)
+}
+
+func TestTestPoliciesNoPolicies(t *testing.T) {
+	_, err := testPolicies(context.Background(), &testOptions{policiesPaths: []string{"testdata/control-configuration.yaml"}}, nil)
+	assert.ErrorContains(t, err, "no admission policy found")
+}
+
+func TestPrintTestResults(t *testing.T) {
+	results := []testResult{
+		{Kind: "ConfigMap", Namespace: "default", Name: "settings", Allowed: true},
+		{Kind: "Pod", Namespace: "prod", Name: "redis", Path: "pods.yaml:1", Failures: []testFailure{
+			{Policy: "kubescape-c-0016-allow-privilege-escalation", Action: "Deny", Expression: "object.spec.hostPID != true", Message: "privilege escalation"},
+		}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, printTestResults(&out, testFormatPretty, results))
+	assert.Equal(t, `ALLOW ConfigMap default/settings
+DENY  Pod prod/redis (pods.yaml:1)
+      [Deny] kubescape-c-0016-allow-privilege-escalation: privilege escalation
+        expression: object.spec.hostPID != true
+
+2 objects tested, 1 denied
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, printTestResults(&out, testFormatJSON, results))
+	assert.Contains(t, out.String(), `"allowed": false`)
+	assert.Contains(t, out.String(), `"path": "pods.yaml:1"`)
+}
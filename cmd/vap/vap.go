@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"regexp"
+	"strings"
 
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/kubescape/v3/core/cautils"
@@ -25,6 +27,10 @@ var vapHelperCmdExamples = fmt.Sprintf(`
   %[1]s vap deploy-library | kubectl apply -f -
   # Create a policy binding
   %[1]s vap create-policy-binding --name my-policy-binding --policy c-0016 --namespace=my-namespace | kubectl apply -f -
+  # Test which manifests the policy library would reject
+  %[1]s vap deploy-library > library.yaml && %[1]s vap test --policies library.yaml path/to/manifests
+  # Use a copy of the policy library saved on a connected machine, the library is downloaded from GitHub otherwise
+  %[1]s vap deploy-library --library library.yaml | kubectl apply -f -
 `, cautils.ExecName())
 
 func GetVapHelperCmd() *cobra.Command {
@@ -39,19 +45,26 @@ func GetVapHelperCmd() *cobra.Command {
 	// Create subcommands
 	vapHelperCmd.AddCommand(getDeployLibraryCmd())
 	vapHelperCmd.AddCommand(getCreatePolicyBindingCmd())
+	vapHelperCmd.AddCommand(getGenerateCmd())
+	vapHelperCmd.AddCommand(getTestCmd())
 
 	return vapHelperCmd
 }
 
 func getDeployLibraryCmd() *cobra.Command {
-	return &cobra.Command{
+	var libraryPath string
+
+	deployLibraryCmd := &cobra.Command{
 		Use:   "deploy-library",
 		Short: "Install Kubescape CEL admission policy library",
 		Long:  ``,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return deployLibrary()
+			return deployLibrary(libraryPath)
 		},
 	}
+	deployLibraryCmd.Flags().StringVar(&libraryPath, "library", "", "Path to a copy of the admission policy library saved beforehand with \"vap deploy-library > library.yaml\", the latest release is downloaded from GitHub when not set. No library is bundled, so it is required offline")
+
+	return deployLibraryCmd
 }
 
 func getCreatePolicyBindingCmd() *cobra.Command {
@@ -81,7 +94,7 @@ func getCreatePolicyBindingCmd() *cobra.Command {
 			}
 			for _, label := range labelArr {
 				// Label selector must be in the format key=value
-				if !regexp.MustCompile(`^[a-zA-Z0-9]+=[a-zA-Z0-9]+$`).MatchString(label) {
+				if !labelRegex.MatchString(label) {
 					return fmt.Errorf("invalid label selector: %s", label)
 				}
 			}
@@ -112,39 +125,65 @@ func getCreatePolicyBindingCmd() *cobra.Command {
 
 // Implementation of the VAP helper commands
 // deploy-library
-func deployLibrary() error {
+func deployLibrary(libraryPath string) error {
+	library, err := loadLibrary(libraryPath)
+	if err != nil {
+		return err
+	}
+
+	// Print the library to the STDOUT for the user to apply
+	fmt.Println(library)
+
+	return nil
+}
+
+// loadLibrary returns the Kubescape CEL admission policy library as a single YAML. The library is not bundled with Kubescape: it is
+// downloaded from the latest release, or read from libraryPath when set, e.g. a copy saved with "vap deploy-library > library.yaml"
+// on a connected machine
+func loadLibrary(libraryPath string) (string, error) {
+	if libraryPath != "" {
+		library, err := os.ReadFile(libraryPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read admission policy library: %w", err)
+		}
+		return string(library), nil
+	}
+
+	library, err := downloadLibrary()
+	if err != nil {
+		return "", fmt.Errorf("failed to download admission policy library: %w. To run offline, save the library beforehand on a connected machine with \"%s vap deploy-library > library.yaml\" and pass it with --library", err, cautils.ExecName())
+	}
+	return library, nil
+}
+
+// downloadLibrary downloads the Kubescape CEL admission policy library from the latest release
+func downloadLibrary() (string, error) {
 	logger.L().Info("Downloading the Kubescape CEL admission policy library")
 	// Download the policy-configuration-definition.yaml from the latest release URL
 	policyConfigurationDefinitionURL := "https://github.com/kubescape/cel-admission-library/releases/latest/download/policy-configuration-definition.yaml"
 	policyConfigurationDefinition, err := downloadFileToString(policyConfigurationDefinitionURL)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Download the basic-control-configuration.yaml from the latest release URL
 	basicControlConfigurationURL := "https://github.com/kubescape/cel-admission-library/releases/latest/download/basic-control-configuration.yaml"
 	basicControlConfiguration, err := downloadFileToString(basicControlConfigurationURL)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Download the kubescape-validating-admission-policies.yaml from the latest release URL
 	kubescapeValidatingAdmissionPoliciesURL := "https://github.com/kubescape/cel-admission-library/releases/latest/download/kubescape-validating-admission-policies.yaml"
 	kubescapeValidatingAdmissionPolicies, err := downloadFileToString(kubescapeValidatingAdmissionPoliciesURL)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	logger.L().Info("Successfully downloaded admission policy library")
 
-	// Print the downloaded files to the STDOUT for the user to apply connecting them to a single YAML with ---
-	fmt.Println(policyConfigurationDefinition)
-	fmt.Println("---")
-	fmt.Println(basicControlConfiguration)
-	fmt.Println("---")
-	fmt.Println(kubescapeValidatingAdmissionPolicies)
-
-	return nil
+	// Connect the downloaded files to a single YAML with ---
+	return strings.Join([]string{policyConfigurationDefinition, basicControlConfiguration, kubescapeValidatingAdmissionPolicies}, "\n---\n"), nil
 }
 
 func downloadFileToString(url string) (string, error) {
@@ -189,10 +228,21 @@ func isValidK8sObjectName(name string) error {
 
 // Create a policy binding
 func createPolicyBinding(bindingName string, policyName string, action string, paramRefName string, namespaceArr []string, labelMatch []string) error {
+	policyBinding := newPolicyBinding(bindingName, policyName, action, paramRefName, namespaceArr, labelMatch)
+	// Marshal the policy binding to YAML
+	out, err := yaml.Marshal(policyBinding)
+	if err != nil {
+		return err
+	}
+	// Print the policy binding to the STDOUT
+	// The user can apply the output to the cluster
+	fmt.Println(string(out))
+	return nil
+}
+
+func newPolicyBinding(bindingName string, policyName string, action string, paramRefName string, namespaceArr []string, labelMatch []string) *admissionv1.ValidatingAdmissionPolicyBinding {
 	// Create a policy binding struct
 	policyBinding := &admissionv1.ValidatingAdmissionPolicyBinding{}
-	// Print the policy binding after marshalling it to YAML to the STDOUT
-	// The user can apply the output to the cluster
 	policyBinding.APIVersion = "admissionregistration.k8s.io/v1"
 	policyBinding.Name = bindingName
 	policyBinding.Kind = "ValidatingAdmissionPolicyBinding"
@@ -225,11 +275,5 @@ func createPolicyBinding(bindingName string, policyName string, action string, p
 			Name: paramRefName,
 		}
 	}
-	// Marshal the policy binding to YAML
-	out, err := yaml.Marshal(policyBinding)
-	if err != nil {
-		return err
-	}
-	fmt.Println(string(out))
-	return nil
+	return policyBinding
 }
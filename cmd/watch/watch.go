@@ -0,0 +1,103 @@
+package watch
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	"github.com/kubescape/kubescape/v3/core/meta"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrBadInterval   = errors.New("bad argument: interval must be positive")
+	ErrBadWebhookURL = errors.New("bad argument: webhook-url must be an http or https URL")
+)
+
+var watchCmdExamples = fmt.Sprintf(`
+  Watch command scans the current cluster and keeps watching the resources required by the selected frameworks.
+  When resources change, the controls covering them are evaluated again and every status change is printed to stdout as a JSON line.
+
+  # Watch the cluster with the default frameworks
+  %[1]s watch
+
+  # Watch the cluster with the NSA framework and post the status changes to a webhook
+  %[1]s watch nsa --webhook-url https://example.com/kubescape-events
+
+  # Evaluate the changes once per minute
+  %[1]s watch --interval 1m
+`, cautils.ExecName())
+
+func GetWatchCmd(ks meta.IKubescape) *cobra.Command {
+	var watchInfo metav1.WatchInfo
+	var scanInfo cautils.ScanInfo
+
+	watchCmd := &cobra.Command{
+		Use:     "watch [<framework names list>]",
+		Short:   "Watch the cluster resources and report control status changes",
+		Long:    ``,
+		Example: watchCmdExamples,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("usage: <framework-0>,<framework-1>")
+			}
+			if len(args) == 1 && slices.Contains(strings.Split(args[0], ","), "") {
+				return fmt.Errorf("usage: <framework-0>,<framework-1>")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateWatchInfo(&watchInfo); err != nil {
+				return err
+			}
+
+			frameworks := getter.NativeFrameworks
+			if len(args) > 0 && args[0] != "all" {
+				frameworks = strings.Split(args[0], ",")
+			}
+
+			scanInfo.FrameworkScan = true
+			scanInfo.SetScanType(cautils.ScanTypeCluster)
+			scanInfo.SetPolicyIdentifiers(frameworks, apisv1.KindFramework)
+
+			return ks.Watch(&watchInfo, &scanInfo)
+		},
+	}
+
+	watchCmd.PersistentFlags().StringVar(&watchInfo.WebhookURL, "webhook-url", "", "Webhook the status change events are posted to, as a JSON array")
+	watchCmd.PersistentFlags().DurationVar(&watchInfo.Interval, "interval", 10*time.Second, "Interval at which the changed resources are evaluated")
+	watchCmd.PersistentFlags().StringVarP(&scanInfo.AccountID, "account", "", "", "Kubescape SaaS account ID. Default will load account ID from cache")
+	watchCmd.PersistentFlags().StringVarP(&scanInfo.AccessKey, "access-key", "", "", "Kubescape SaaS access key. Default will load access key from cache")
+	watchCmd.PersistentFlags().StringVarP(&scanInfo.ExcludedNamespaces, "exclude-namespaces", "e", "", "Namespaces to exclude from watching. e.g: --exclude-namespaces ns-a,ns-b")
+	watchCmd.PersistentFlags().StringVar(&scanInfo.IncludeNamespaces, "include-namespaces", "", "Watch specific namespaces. e.g: --include-namespaces ns-a,ns-b")
+	watchCmd.PersistentFlags().StringSliceVar(&scanInfo.UseFrom, "use-from", nil, "Load local policy object from specified path. If not used will download latest")
+	watchCmd.PersistentFlags().StringVar(&scanInfo.ControlsInputs, "controls-config", "", "Path to an controls-config obj. If not set will download controls-config from ARMO management portal")
+	watchCmd.PersistentFlags().StringVar(&scanInfo.UseExceptions, "exceptions", "", "Path to an exceptions obj. If not set will download exceptions from ARMO management portal")
+	watchCmd.PersistentFlags().StringVar(&scanInfo.CustomClusterName, "cluster-name", "", "Set the custom name of the cluster reported in the events")
+
+	// Retrieve --kubeconfig flag from https://github.com/kubernetes/kubectl/blob/master/pkg/cmd/cmd.go
+	watchCmd.PersistentFlags().AddGoFlag(flag.Lookup("kubeconfig"))
+
+	return watchCmd
+}
+
+func validateWatchInfo(watchInfo *metav1.WatchInfo) error {
+	if watchInfo.Interval <= 0 {
+		return ErrBadInterval
+	}
+	if watchInfo.WebhookURL != "" {
+		u, err := url.Parse(watchInfo.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrBadWebhookURL
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package watch
+
+import (
+	"testing"
+	"time"
+
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/mocks"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWatchCmd(t *testing.T) {
+	// Create a mock Kubescape interface
+	mockKubescape := &mocks.MockIKubescape{}
+
+	watchCmd := GetWatchCmd(mockKubescape)
+
+	// Verify the command name and short description
+	assert.Equal(t, "watch [<framework names list>]", watchCmd.Use)
+	assert.Equal(t, "Watch the cluster resources and report control status changes", watchCmd.Short)
+	assert.Equal(t, watchCmdExamples, watchCmd.Example)
+
+	assert.NoError(t, watchCmd.Args(&cobra.Command{}, []string{}))
+	assert.NoError(t, watchCmd.Args(&cobra.Command{}, []string{"nsa,mitre"}))
+	assert.Error(t, watchCmd.Args(&cobra.Command{}, []string{"nsa,"}))
+	assert.Error(t, watchCmd.Args(&cobra.Command{}, []string{"nsa", "mitre"}))
+
+	assert.NoError(t, watchCmd.RunE(&cobra.Command{}, []string{"nsa"}))
+
+	assert.NoError(t, watchCmd.PersistentFlags().Set("webhook-url", "ftp://example.com"))
+	assert.ErrorIs(t, watchCmd.RunE(&cobra.Command{}, []string{"nsa"}), ErrBadWebhookURL)
+}
+
+func TestValidateWatchInfo(t *testing.T) {
+	testCases := []struct {
+		name      string
+		watchInfo metav1.WatchInfo
+		want      error
+	}{
+		{"Valid interval", metav1.WatchInfo{Interval: time.Second}, nil},
+		{"Zero interval", metav1.WatchInfo{}, ErrBadInterval},
+		{"Valid webhook", metav1.WatchInfo{Interval: time.Second, WebhookURL: "https://example.com/events"}, nil},
+		{"Webhook without host", metav1.WatchInfo{Interval: time.Second, WebhookURL: "https://"}, ErrBadWebhookURL},
+		{"Webhook without scheme", metav1.WatchInfo{Interval: time.Second, WebhookURL: "example.com/events"}, ErrBadWebhookURL},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, validateWatchInfo(&tc.watchInfo))
+		})
+	}
+}
@@ -81,6 +81,42 @@ func NewOPASessionObj(ctx context.Context, frameworks []reporthandling.Framework
 	}
 }
 
+// NewOPASessionObjFromReport returns the session of a scan from its report, to print or browse the results of a report file
+func NewOPASessionObjFromReport(report *reporthandlingv2.PostureReport) *OPASessionObj {
+	sessionObj := &OPASessionObj{
+		Report:               report,
+		Metadata:             &report.Metadata,
+		AllResources:         make(map[string]workloadinterface.IMetadata, len(report.Resources)),
+		ResourcesResult:      make(map[string]resourcesresults.Result, len(report.Results)),
+		ResourcesPrioritized: make(map[string]prioritization.PrioritizedResource),
+		InfoMap:              make(map[string]apis.StatusInfo),
+		ResourceSource:       make(map[string]reporthandling.Source),
+	}
+
+	for i := range report.Resources {
+		resource := &report.Resources[i]
+		sessionObj.AllResources[resource.GetID()] = resource
+		if resource.Source != nil {
+			sessionObj.ResourceSource[resource.GetID()] = *resource.Source
+		}
+	}
+	for i := range report.Results {
+		result := report.Results[i]
+		sessionObj.ResourcesResult[result.ResourceID] = result
+		if result.RawResource != nil {
+			sessionObj.AllResources[result.ResourceID] = result.RawResource
+			if result.RawResource.Source != nil {
+				sessionObj.ResourceSource[result.ResourceID] = *result.RawResource.Source
+			}
+		}
+		if result.PrioritizedResource != nil {
+			sessionObj.ResourcesPrioritized[result.ResourceID] = *result.PrioritizedResource
+		}
+	}
+
+	return sessionObj
+}
+
 // SetTopWorkloads sets the top workloads by score
 func (sessionObj *OPASessionObj) SetTopWorkloads() {
 	count := 0
@@ -0,0 +1,40 @@
+package cautils
+
+import (
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOPASessionObjFromReport(t *testing.T) {
+	newResource := func(name string) *reporthandling.Resource {
+		return reporthandling.NewResourceIMetadata(workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}))
+	}
+	pod := newResource("nginx")
+	pod.Source = &reporthandling.Source{RelativePath: "pod.yaml"}
+	rawPod := newResource("busybox")
+
+	report := &reporthandlingv2.PostureReport{
+		Resources: []reporthandling.Resource{*pod},
+		Results: []resourcesresults.Result{
+			{ResourceID: pod.GetID()},
+			{ResourceID: rawPod.GetID(), RawResource: rawPod},
+		},
+	}
+
+	sessionObj := NewOPASessionObjFromReport(report)
+
+	assert.Equal(t, report, sessionObj.Report)
+	assert.Len(t, sessionObj.AllResources, 2)
+	assert.Equal(t, "busybox", sessionObj.AllResources[rawPod.GetID()].GetName())
+	assert.Len(t, sessionObj.ResourcesResult, 2)
+	assert.Equal(t, "pod.yaml", sessionObj.ResourceSource[pod.GetID()].RelativePath)
+}
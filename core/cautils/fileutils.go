@@ -263,7 +263,14 @@ func convertJsonToWorkload(jsonObj interface{}, workloads *[]workloadinterface.I
 	switch x := jsonObj.(type) {
 	case map[string]interface{}:
 		if o := objectsenvelopes.NewObject(x); o != nil {
-			(*workloads) = append(*workloads, o)
+			if o.GetObjectType() == workloadinterface.TypeListWorkloads {
+				// e.g. kubectl get -o json. Items are converted one by one to keep their envelope type
+				if items, ok := x["items"]; ok {
+					convertJsonToWorkload(items, workloads)
+				}
+			} else {
+				(*workloads) = append(*workloads, o)
+			}
 		}
 	case []interface{}:
 		for i := range x {
@@ -0,0 +1,264 @@
+package getter
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	FrameworkDefinitionAPIVersion = "kubescape.io/v1"
+	FrameworkDefinitionKind       = "FrameworkDefinition"
+
+	// AllControlsFramework is the framework the controls of a definition are taken from when no framework is set
+	AllControlsFramework = "allcontrols"
+)
+
+var ErrNotFrameworkDefinition = errors.New("not a framework definition")
+
+// severityScores are the score factors of the severities a control can be overridden with, the lowest score of each severity
+var severityScores = map[string]float32{
+	strings.ToLower(apis.SeverityLowString):      1,
+	strings.ToLower(apis.SeverityMediumString):   4,
+	strings.ToLower(apis.SeverityHighString):     7,
+	strings.ToLower(apis.SeverityCriticalString): 9,
+}
+
+// FrameworkDefinition composes a custom framework from the controls of existing frameworks.
+//
+// The controls of the inherited frameworks are added first, the excluded frameworks and controls are then removed. The listed
+// controls are added last, or override the inherited ones: a listed control is always part of the framework.
+type FrameworkDefinition struct {
+	APIVersion  string              `json:"apiVersion"`
+	Kind        string              `json:"kind"`
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Inherit     []string            `json:"inherit,omitempty"`
+	Exclude     FrameworkExclusions `json:"exclude,omitempty"`
+	Controls    []ControlReference  `json:"controls,omitempty"`
+}
+
+// FrameworkExclusions lists the frameworks whose controls are removed and the removed controls
+type FrameworkExclusions struct {
+	Frameworks []string `json:"frameworks,omitempty"`
+	Controls   []string `json:"controls,omitempty"`
+}
+
+// ControlReference references a control by ID, with overrides of its severity, score factor and controls inputs. Controls
+// without a framework are taken from the inherited or excluded frameworks, or from all controls
+type ControlReference struct {
+	ID             string              `json:"id"`
+	Framework      string              `json:"framework,omitempty"`
+	Severity       string              `json:"severity,omitempty"`
+	ScoreFactor    *float32            `json:"scoreFactor,omitempty"`
+	ControlsInputs map[string][]string `json:"controlsInputs,omitempty"`
+}
+
+// NewFrameworkDefinition builds an empty framework definition
+func NewFrameworkDefinition(name string) *FrameworkDefinition {
+	return &FrameworkDefinition{
+		APIVersion: FrameworkDefinitionAPIVersion,
+		Kind:       FrameworkDefinitionKind,
+		Name:       name,
+	}
+}
+
+// IsFrameworkDefinition returns true if the YAML or JSON document is a framework definition
+func IsFrameworkDefinition(buf []byte) bool {
+	var typeMeta struct {
+		Kind string `json:"kind"`
+	}
+	return yaml.Unmarshal(buf, &typeMeta) == nil && typeMeta.Kind == FrameworkDefinitionKind
+}
+
+// ParseFrameworkDefinition parses and validates a YAML or JSON framework definition
+func ParseFrameworkDefinition(buf []byte) (*FrameworkDefinition, error) {
+	if !IsFrameworkDefinition(buf) {
+		return nil, ErrNotFrameworkDefinition
+	}
+	var definition FrameworkDefinition
+	if err := yaml.UnmarshalStrict(buf, &definition); err != nil {
+		return nil, fmt.Errorf("failed to parse framework definition: %w", err)
+	}
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// Validate checks the definition has a name, controls to resolve and valid overrides
+func (d *FrameworkDefinition) Validate() error {
+	if d.Name == "" {
+		return ErrNameRequired
+	}
+	if len(d.Inherit) == 0 && len(d.Controls) == 0 {
+		return fmt.Errorf("framework definition %s: no inherited frameworks nor controls", d.Name)
+	}
+	for _, ref := range d.Controls {
+		if err := ref.Validate(); err != nil {
+			return fmt.Errorf("framework definition %s: %w", d.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the reference has an ID and valid overrides
+func (ref *ControlReference) Validate() error {
+	if ref.ID == "" {
+		return ErrIDRequired
+	}
+	if _, ok := severityScores[strings.ToLower(ref.Severity)]; ref.Severity != "" && !ok {
+		return fmt.Errorf("control %s: invalid severity '%s', supported: %s", ref.ID, ref.Severity, strings.Join(apis.GetSupportedSeverities(), ","))
+	}
+	if ref.ScoreFactor != nil && (*ref.ScoreFactor < 0 || *ref.ScoreFactor > 10) {
+		return fmt.Errorf("control %s: score factor must be between 0 and 10", ref.ID)
+	}
+	return nil
+}
+
+// Resolve builds the framework of the definition, getFramework returns the frameworks the controls are taken from
+func (d *FrameworkDefinition) Resolve(getFramework func(name string) (*reporthandling.Framework, error)) (*reporthandling.Framework, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	frameworks := make(map[string]*reporthandling.Framework)
+	cachedFramework := func(name string) (*reporthandling.Framework, error) {
+		key := strings.ToLower(name)
+		if framework, ok := frameworks[key]; ok {
+			return framework, nil
+		}
+		framework, err := getFramework(name)
+		if err != nil {
+			return nil, fmt.Errorf("framework definition %s: %w", d.Name, err)
+		}
+		frameworks[key] = framework
+		return framework, nil
+	}
+
+	controls := newOrderedControls()
+	for _, name := range d.Inherit {
+		framework, err := cachedFramework(name)
+		if err != nil {
+			return nil, err
+		}
+		for i := range framework.Controls {
+			controls.add(framework.Controls[i])
+		}
+	}
+
+	for _, name := range d.Exclude.Frameworks {
+		framework, err := cachedFramework(name)
+		if err != nil {
+			return nil, err
+		}
+		for i := range framework.Controls {
+			controls.remove(framework.Controls[i].ControlID)
+		}
+	}
+	for _, controlID := range d.Exclude.Controls {
+		controls.remove(controlID)
+	}
+
+	for _, ref := range d.Controls {
+		control, ok := controls.get(ref.ID)
+		if !ok && ref.Framework == "" {
+			// controls of the inherited and excluded frameworks are resolved without loading all controls
+			for _, name := range append(append([]string{}, d.Inherit...), d.Exclude.Frameworks...) {
+				if control, ok = findControl(frameworks[strings.ToLower(name)], ref.ID); ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			source := ref.Framework
+			if source == "" {
+				source = AllControlsFramework
+			}
+			framework, err := cachedFramework(source)
+			if err != nil {
+				return nil, err
+			}
+			if control, ok = findControl(framework, ref.ID); !ok {
+				return nil, fmt.Errorf("framework definition %s: controlID: %s in framework %s: %w", d.Name, ref.ID, source, ErrControlNotMatching)
+			}
+		}
+		controls.add(ref.Apply(control))
+	}
+
+	return &reporthandling.Framework{
+		PortalBase:  armotypes.PortalBase{Name: d.Name},
+		Description: d.Description,
+		Controls:    controls.list(),
+	}, nil
+}
+
+// Apply returns the control with the overrides of the reference
+func (ref *ControlReference) Apply(control reporthandling.Control) reporthandling.Control {
+	if score, ok := severityScores[strings.ToLower(ref.Severity)]; ok {
+		control.BaseScore = score
+	}
+	if ref.ScoreFactor != nil {
+		control.BaseScore = *ref.ScoreFactor
+	}
+	if len(ref.ControlsInputs) > 0 {
+		fixedInput := make(map[string][]string, len(control.FixedInput)+len(ref.ControlsInputs))
+		maps.Copy(fixedInput, control.FixedInput)
+		maps.Copy(fixedInput, ref.ControlsInputs)
+		control.FixedInput = fixedInput
+	}
+	return control
+}
+
+func findControl(framework *reporthandling.Framework, controlID string) (reporthandling.Control, bool) {
+	for i := range framework.Controls {
+		if strings.EqualFold(framework.Controls[i].ControlID, controlID) {
+			return framework.Controls[i], true
+		}
+	}
+	return reporthandling.Control{}, false
+}
+
+// orderedControls keeps the controls of a framework in the order they are added
+type orderedControls struct {
+	ids      []string
+	controls map[string]reporthandling.Control
+}
+
+func newOrderedControls() *orderedControls {
+	return &orderedControls{controls: make(map[string]reporthandling.Control)}
+}
+
+func (c *orderedControls) add(control reporthandling.Control) {
+	id := strings.ToUpper(control.ControlID)
+	if _, ok := c.controls[id]; !ok {
+		c.ids = append(c.ids, id)
+	}
+	c.controls[id] = control
+}
+
+func (c *orderedControls) get(controlID string) (reporthandling.Control, bool) {
+	control, ok := c.controls[strings.ToUpper(controlID)]
+	return control, ok
+}
+
+func (c *orderedControls) remove(controlID string) {
+	id := strings.ToUpper(controlID)
+	delete(c.controls, id)
+	c.ids = slices.DeleteFunc(c.ids, func(listed string) bool { return listed == id })
+}
+
+func (c *orderedControls) list() []reporthandling.Control {
+	controls := make([]reporthandling.Control, 0, len(c.controls))
+	for _, id := range c.ids {
+		controls = append(controls, c.controls[id])
+	}
+	return controls
+}
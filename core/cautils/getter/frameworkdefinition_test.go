@@ -0,0 +1,170 @@
+package getter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/kubescape/v3/internal/testutils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFrameworkDefinitionFile() string {
+	return filepath.Join(testutils.CurrentDir(), "testdata", "custom-framework.yaml")
+}
+
+func controlIDs(framework *reporthandling.Framework) []string {
+	ids := make([]string, 0, len(framework.Controls))
+	for _, control := range framework.Controls {
+		ids = append(ids, control.ControlID)
+	}
+	return ids
+}
+
+func TestParseFrameworkDefinition(t *testing.T) {
+	buf, err := os.ReadFile(testFrameworkDefinitionFile())
+	require.NoError(t, err)
+	assert.True(t, IsFrameworkDefinition(buf))
+
+	definition, err := ParseFrameworkDefinition(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "Custom", definition.Name)
+	assert.Equal(t, []string{"NSA"}, definition.Inherit)
+	assert.Equal(t, FrameworkExclusions{Frameworks: []string{"MITRE"}, Controls: []string{"C-0005"}}, definition.Exclude)
+	require.Len(t, definition.Controls, 3)
+	assert.Equal(t, "critical", definition.Controls[0].Severity)
+
+	framework, err := os.ReadFile(testFrameworkFile("NSA"))
+	require.NoError(t, err)
+	assert.False(t, IsFrameworkDefinition(framework))
+	_, err = ParseFrameworkDefinition(framework)
+	assert.ErrorIs(t, err, ErrNotFrameworkDefinition)
+
+	for name, invalid := range map[string]string{
+		"missing name":     "kind: FrameworkDefinition\ninherit: [NSA]",
+		"no controls":      "kind: FrameworkDefinition\nname: empty",
+		"missing ID":       "kind: FrameworkDefinition\nname: custom\ncontrols:\n- severity: high",
+		"invalid severity": "kind: FrameworkDefinition\nname: custom\ncontrols:\n- id: C-0001\n  severity: urgent",
+		"invalid score":    "kind: FrameworkDefinition\nname: custom\ncontrols:\n- id: C-0001\n  scoreFactor: 11",
+		"unknown field":    "kind: FrameworkDefinition\nname: custom\ninherits: [NSA]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseFrameworkDefinition([]byte(invalid))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFrameworkDefinitionResolve(t *testing.T) {
+	frameworks := map[string]*reporthandling.Framework{
+		"base": {
+			PortalBase: armotypes.PortalBase{Name: "base"},
+			Controls: []reporthandling.Control{
+				{ControlID: "C-0001", BaseScore: 3},
+				{ControlID: "C-0002", BaseScore: 5, FixedInput: map[string][]string{"trustedRegistries": {"quay.io"}}},
+				{ControlID: "C-0003", BaseScore: 7},
+			},
+		},
+		"other": {
+			PortalBase: armotypes.PortalBase{Name: "other"},
+			Controls:   []reporthandling.Control{{ControlID: "C-0003"}},
+		},
+		AllControlsFramework: {
+			PortalBase: armotypes.PortalBase{Name: "AllControls"},
+			Controls:   []reporthandling.Control{{ControlID: "C-0004", BaseScore: 2}},
+		},
+	}
+	getFramework := func(name string) (*reporthandling.Framework, error) {
+		if framework, ok := frameworks[name]; ok {
+			return framework, nil
+		}
+		return nil, ErrFrameworkNotMatching
+	}
+	scoreFactor := float32(9)
+
+	definition := NewFrameworkDefinition("custom")
+	definition.Description = "custom framework"
+	definition.Inherit = []string{"base"}
+	definition.Exclude = FrameworkExclusions{Frameworks: []string{"other"}, Controls: []string{"c-0001"}}
+	definition.Controls = []ControlReference{
+		{ID: "C-0004", Severity: "High"},
+		{ID: "C-0002", ScoreFactor: &scoreFactor, ControlsInputs: map[string][]string{"imageRepositoryAllowList": {"docker.io"}}},
+	}
+
+	framework, err := definition.Resolve(getFramework)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", framework.Name)
+	assert.Equal(t, "custom framework", framework.Description)
+	assert.Equal(t, []string{"C-0002", "C-0004"}, controlIDs(framework))
+	assert.Equal(t, float32(9), framework.Controls[0].BaseScore)
+	assert.Equal(t, map[string][]string{"trustedRegistries": {"quay.io"}, "imageRepositoryAllowList": {"docker.io"}}, framework.Controls[0].FixedInput)
+	assert.Equal(t, float32(7), framework.Controls[1].BaseScore)
+
+	// the source frameworks are not modified
+	assert.Equal(t, float32(5), frameworks["base"].Controls[1].BaseScore)
+	assert.Len(t, frameworks["base"].Controls[1].FixedInput, 1)
+
+	t.Run("listed controls are added back", func(t *testing.T) {
+		definition := NewFrameworkDefinition("custom")
+		definition.Inherit = []string{"base"}
+		definition.Exclude.Frameworks = []string{"base"}
+		definition.Controls = []ControlReference{{ID: "C-0003", Framework: "other"}}
+
+		framework, err := definition.Resolve(getFramework)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"C-0003"}, controlIDs(framework))
+	})
+
+	t.Run("missing control", func(t *testing.T) {
+		definition := NewFrameworkDefinition("custom")
+		definition.Controls = []ControlReference{{ID: "C-0001", Framework: "other"}}
+
+		_, err := definition.Resolve(getFramework)
+		assert.ErrorIs(t, err, ErrControlNotMatching)
+	})
+
+	t.Run("missing framework", func(t *testing.T) {
+		definition := NewFrameworkDefinition("custom")
+		definition.Inherit = []string{"missing"}
+
+		_, err := definition.Resolve(getFramework)
+		assert.ErrorIs(t, err, ErrFrameworkNotMatching)
+	})
+}
+
+func TestLoadPolicyFrameworkDefinition(t *testing.T) {
+	p := NewLoadPolicy([]string{testFrameworkDefinitionFile(), testFrameworkFile("NSA"), testFrameworkFile("MITRE")})
+
+	framework, err := p.GetFramework("custom")
+	require.NoError(t, err)
+	assert.Equal(t, "Custom", framework.Name)
+	assert.Equal(t, []string{"C-0038", "C-0017", "C-0013", "C-0034", "C-0041", "C-0009", "C-0016", "C-0046", "C-0055", "C-0030", "C-0044", "C-0053", "C-0002"}, controlIDs(framework))
+
+	control, err := p.GetControl("C-0053")
+	require.NoError(t, err)
+	assert.Equal(t, float32(9), control.BaseScore)
+	control, err = p.GetControl("C-0017")
+	require.NoError(t, err)
+	assert.Equal(t, float32(8), control.BaseScore)
+	assert.Equal(t, []string{"aws_access_key_id"}, control.FixedInput["sensitiveKeyNames"])
+
+	names, err := p.ListFrameworks()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Custom", "NSA", "MITRE"}, names)
+
+	frameworks, err := p.GetFrameworks()
+	require.NoError(t, err)
+	require.Len(t, frameworks, 3)
+	assert.Len(t, frameworks[0].Controls, 13)
+
+	t.Run("missing source framework", func(t *testing.T) {
+		p := NewLoadPolicy([]string{testFrameworkDefinitionFile(), testFrameworkFile("NSA")})
+		_, err := p.GetFramework("custom")
+		assert.ErrorIs(t, err, ErrFrameworkNotMatching)
+		_, err = p.GetFrameworks()
+		assert.Error(t, err)
+	})
+}
@@ -78,8 +78,8 @@ func (lp *LoadPolicy) GetControl(controlID string) (*reporthandling.Control, err
 	}
 
 	// check if the file is a framework descriptor
-	var framework reporthandling.Framework
-	if err = json.Unmarshal(buf, &framework); err != nil {
+	framework, err := lp.loadFramework(buf)
+	if err != nil {
 		return nil, err
 	}
 
@@ -106,13 +106,13 @@ func (lp *LoadPolicy) GetFramework(frameworkName string) (*reporthandling.Framew
 			return nil, err
 		}
 
-		var framework reporthandling.Framework
-		if err = json.Unmarshal(buf, &framework); err != nil {
+		framework, err := lp.loadFramework(buf)
+		if err != nil {
 			return nil, err
 		}
 
 		if strings.EqualFold(frameworkName, framework.Name) {
-			return &framework, nil
+			return framework, nil
 		}
 	}
 
@@ -130,8 +130,11 @@ func (lp *LoadPolicy) GetFrameworks() ([]reporthandling.Framework, error) {
 			return nil, err
 		}
 
-		var framework reporthandling.Framework
-		if err = json.Unmarshal(buf, &framework); err != nil {
+		framework, err := lp.loadFramework(buf)
+		if err != nil {
+			if IsFrameworkDefinition(buf) {
+				return nil, err
+			}
 			// ignore invalid framework files
 			continue
 		}
@@ -143,7 +146,7 @@ func (lp *LoadPolicy) GetFrameworks() ([]reporthandling.Framework, error) {
 		}
 
 		seenFws[framework.Name] = struct{}{}
-		frameworks = append(frameworks, framework)
+		frameworks = append(frameworks, *framework)
 	}
 
 	return frameworks, nil
@@ -160,7 +163,13 @@ func (lp *LoadPolicy) ListFrameworks() ([]string, error) {
 		}
 
 		var framework reporthandling.Framework
-		if err := json.Unmarshal(buf, &framework); err != nil {
+		if IsFrameworkDefinition(buf) {
+			definition, err := ParseFrameworkDefinition(buf)
+			if err != nil {
+				continue
+			}
+			framework.Name = definition.Name
+		} else if err := json.Unmarshal(buf, &framework); err != nil {
 			continue
 		}
 
@@ -185,8 +194,8 @@ func (lp *LoadPolicy) ListControls() ([]string, error) {
 		return nil, err
 	}
 
-	var framework reporthandling.Framework
-	if err = json.Unmarshal(buf, &framework); err != nil {
+	framework, err := lp.loadFramework(buf)
+	if err != nil {
 		return nil, err
 	}
 
@@ -262,6 +271,43 @@ func (lp *LoadPolicy) GetAttackTracks() ([]v1alpha1.AttackTrack, error) {
 	return attackTracks, nil
 }
 
+// loadFramework decodes a framework descriptor. Framework definitions are resolved against the frameworks they reference
+func (lp *LoadPolicy) loadFramework(buf []byte) (*reporthandling.Framework, error) {
+	if IsFrameworkDefinition(buf) {
+		definition, err := ParseFrameworkDefinition(buf)
+		if err != nil {
+			return nil, err
+		}
+		return definition.Resolve(lp.getSourceFramework)
+	}
+
+	var framework reporthandling.Framework
+	if err := json.Unmarshal(buf, &framework); err != nil {
+		return nil, err
+	}
+	return &framework, nil
+}
+
+// getSourceFramework returns a framework referenced by a framework definition, from the policy paths or from the local store.
+//
+// NOTE: framework definitions cannot reference other definitions
+func (lp *LoadPolicy) getSourceFramework(frameworkName string) (*reporthandling.Framework, error) {
+	filePaths := append(append(make([]string, 0, len(lp.filePaths)+1), lp.filePaths...), GetDefaultPath(strings.ToLower(frameworkName)+".json"))
+	for _, filePath := range filePaths {
+		buf, err := os.ReadFile(filePath)
+		if err != nil || IsFrameworkDefinition(buf) {
+			continue
+		}
+
+		var framework reporthandling.Framework
+		if err := json.Unmarshal(buf, &framework); err == nil && strings.EqualFold(frameworkName, framework.Name) {
+			return &framework, nil
+		}
+	}
+
+	return nil, fmt.Errorf("framework: %s: %w", frameworkName, ErrFrameworkNotMatching)
+}
+
 // temporary support for a list of files
 func (lp *LoadPolicy) filePath() string {
 	if len(lp.filePaths) > 0 {
@@ -0,0 +1,347 @@
+package getter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/attacktrack/v1alpha1"
+)
+
+// =======================================================================================================================
+// ============================================== OCIPolicy ==============================================================
+// =======================================================================================================================
+var (
+	_ IPolicyGetter         = &OCIPolicy{}
+	_ IExceptionsGetter     = &OCIPolicy{}
+	_ IAttackTracksGetter   = &OCIPolicy{}
+	_ IControlsInputsGetter = &OCIPolicy{}
+)
+
+const (
+	// OCIScheme prefixes the policy bundle references, e.g. oci://registry.example.com/kubescape/policies:v1
+	OCIScheme = "oci://"
+
+	policyBundleConfigMediaType types.MediaType = "application/vnd.kubescape.policy.bundle.config.v1+json"
+	policyBundleLayerMediaType  types.MediaType = "application/vnd.kubescape.policy.bundle.layer.v1+json"
+	policyBundleTitleAnnotation                 = "org.opencontainers.image.title"
+
+	// the artifacts of the bundle have the names of the files of "download artifacts", the other files are frameworks
+	bundleControlsInputsFilename = "controls-inputs.json"
+	bundleExceptionsFilename     = "exceptions.json"
+	bundleAttackTracksFilename   = "attack-tracks.json"
+
+	ociIndexFilename = "index.json"
+)
+
+// OCIPolicy loads the policies of a policy bundle pulled from an OCI registry. The bundles are cached per digest, and the
+// digest of each reference is pinned so the cached bundle is used when the registry cannot be reached.
+type OCIPolicy struct {
+	digest string
+	dir    string
+}
+
+// NewOCIPolicy pulls the policy bundle of the reference, unless the bundle of its digest is already cached in cacheDir.
+func NewOCIPolicy(ctx context.Context, reference string, cacheDir string) (*OCIPolicy, error) {
+	ref, err := name.ParseReference(strings.TrimPrefix(reference, OCIScheme))
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy bundle reference %s: %w", reference, err)
+	}
+	options := []remote.Option{remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+
+	digest, err := resolveDigest(ref, cacheDir, options)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(cacheDir, strings.ReplaceAll(digest, ":", "-"))
+	if _, err := os.Stat(dir); err != nil {
+		if err := pullPolicyBundle(ref.Context().Digest(digest), dir, options); err != nil {
+			return nil, err
+		}
+		logger.L().Ctx(ctx).Info("Pulled policy bundle", helpers.String("reference", ref.String()), helpers.String("digest", digest))
+	}
+	if err := pinDigest(cacheDir, ref.String(), digest); err != nil {
+		logger.L().Ctx(ctx).Warning("failed to pin the digest of the policy bundle", helpers.Error(err))
+	}
+
+	return &OCIPolicy{
+		digest: digest,
+		dir:    dir,
+	}, nil
+}
+
+// IsOCIReference returns true when the artifacts are loaded from a policy bundle of an OCI registry.
+func IsOCIReference(artifactsFrom string) bool {
+	return strings.HasPrefix(artifactsFrom, OCIScheme)
+}
+
+// Digest returns the digest of the policy bundle.
+func (op *OCIPolicy) Digest() string {
+	return op.digest
+}
+
+// Files returns the paths of the cached files of the policy bundle.
+func (op *OCIPolicy) Files() ([]string, error) {
+	entries, err := os.ReadDir(op.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files = append(files, filepath.Join(op.dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// GetFramework returns a framework of the bundle.
+func (op *OCIPolicy) GetFramework(name string) (*reporthandling.Framework, error) {
+	lp, err := op.frameworks()
+	if err != nil {
+		return nil, err
+	}
+	return lp.GetFramework(name)
+}
+
+// GetFrameworks returns the frameworks of the bundle.
+func (op *OCIPolicy) GetFrameworks() ([]reporthandling.Framework, error) {
+	lp, err := op.frameworks()
+	if err != nil {
+		return nil, err
+	}
+	return lp.GetFrameworks()
+}
+
+// GetControl returns a control of any framework of the bundle.
+func (op *OCIPolicy) GetControl(ID string) (*reporthandling.Control, error) {
+	frameworks, err := op.GetFrameworks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range frameworks {
+		for j := range frameworks[i].Controls {
+			if strings.EqualFold(frameworks[i].Controls[j].ControlID, ID) {
+				return &frameworks[i].Controls[j], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("controlID: %s: %w", ID, ErrControlNotMatching)
+}
+
+// ListFrameworks lists the names of the frameworks of the bundle.
+func (op *OCIPolicy) ListFrameworks() ([]string, error) {
+	lp, err := op.frameworks()
+	if err != nil {
+		return nil, err
+	}
+	return lp.ListFrameworks()
+}
+
+// ListControls lists the IDs of the controls of all the frameworks of the bundle.
+func (op *OCIPolicy) ListControls() ([]string, error) {
+	frameworks, err := op.GetFrameworks()
+	if err != nil {
+		return nil, err
+	}
+	controlIDs := make([]string, 0, 100)
+	for i := range frameworks {
+		for j := range frameworks[i].Controls {
+			if !contains(controlIDs, frameworks[i].Controls[j].ControlID) {
+				controlIDs = append(controlIDs, frameworks[i].Controls[j].ControlID)
+			}
+		}
+	}
+	sort.Strings(controlIDs)
+	return controlIDs, nil
+}
+
+// GetExceptions returns the exceptions of the bundle, a bundle without exceptions has none.
+func (op *OCIPolicy) GetExceptions(clusterName string) ([]armotypes.PostureExceptionPolicy, error) {
+	path := filepath.Join(op.dir, bundleExceptionsFilename)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return []armotypes.PostureExceptionPolicy{}, nil
+	}
+	return NewLoadPolicy([]string{path}).GetExceptions(clusterName)
+}
+
+// GetControlsInputs returns the controls inputs of the bundle.
+func (op *OCIPolicy) GetControlsInputs(clusterName string) (map[string][]string, error) {
+	return NewLoadPolicy([]string{filepath.Join(op.dir, bundleControlsInputsFilename)}).GetControlsInputs(clusterName)
+}
+
+// GetAttackTracks returns the attack tracks of the bundle.
+func (op *OCIPolicy) GetAttackTracks() ([]v1alpha1.AttackTrack, error) {
+	return NewLoadPolicy([]string{filepath.Join(op.dir, bundleAttackTracksFilename)}).GetAttackTracks()
+}
+
+// frameworks returns a loader of the framework files of the bundle
+func (op *OCIPolicy) frameworks() (*LoadPolicy, error) {
+	files, err := op.Files()
+	if err != nil {
+		return nil, err
+	}
+	frameworkFiles := make([]string, 0, len(files))
+	for _, file := range files {
+		switch filepath.Base(file) {
+		case bundleControlsInputsFilename, bundleExceptionsFilename, bundleAttackTracksFilename, ChecksumsFilename, ChecksumsSignatureFilename:
+			continue
+		}
+		frameworkFiles = append(frameworkFiles, file)
+	}
+	return NewLoadPolicy(frameworkFiles), nil
+}
+
+// PushPolicyBundle pushes the files as a policy bundle, one layer per file, and returns the digest of the bundle.
+func PushPolicyBundle(ctx context.Context, reference string, files []string) (string, error) {
+	ref, err := name.ParseReference(strings.TrimPrefix(reference, OCIScheme))
+	if err != nil {
+		return "", fmt.Errorf("invalid policy bundle reference %s: %w", reference, err)
+	}
+
+	img := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), policyBundleConfigMediaType)
+	sort.Strings(files)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		img, err = mutate.Append(img, mutate.Addendum{
+			Layer:       static.NewLayer(b, policyBundleLayerMediaType),
+			Annotations: map[string]string{policyBundleTitleAnnotation: filepath.Base(file)},
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := remote.Write(ref, img, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain)); err != nil {
+		return "", fmt.Errorf("failed to push policy bundle %s: %w", ref.String(), err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return "", err
+	}
+	return digest.String(), nil
+}
+
+// resolveDigest returns the digest of the reference. The digest of a tag is resolved by the registry, or read from the pinned
+// digests when the registry cannot be reached
+func resolveDigest(ref name.Reference, cacheDir string, options []remote.Option) (string, error) {
+	if digest, ok := ref.(name.Digest); ok {
+		return digest.DigestStr(), nil
+	}
+
+	descriptor, err := remote.Head(ref, options...)
+	if err == nil {
+		return descriptor.Digest.String(), nil
+	}
+	if digest, ok := readPinnedDigests(cacheDir)[ref.String()]; ok {
+		if _, statErr := os.Stat(filepath.Join(cacheDir, strings.ReplaceAll(digest, ":", "-"))); statErr == nil {
+			logger.L().Warning("failed to resolve the policy bundle, using the cached bundle", helpers.String("reference", ref.String()), helpers.String("digest", digest), helpers.Error(err))
+			return digest, nil
+		}
+	}
+	return "", fmt.Errorf("failed to resolve policy bundle %s: %w", ref.String(), err)
+}
+
+// pullPolicyBundle writes the files of the bundle to dir. The layers are verified against their digests while they are read
+func pullPolicyBundle(ref name.Digest, dir string, options []remote.Option) error {
+	img, err := remote.Image(ref, options...)
+	if err != nil {
+		return fmt.Errorf("failed to pull policy bundle %s: %w", ref.String(), err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+	if manifest.Config.MediaType != policyBundleConfigMediaType {
+		return fmt.Errorf("%s is not a policy bundle, config media type: %s", ref.String(), manifest.Config.MediaType)
+	}
+
+	// the files are written to a temporary directory first, so an interrupted pull is not cached
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), ".pull-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, descriptor := range manifest.Layers {
+		fileName := filepath.Base(descriptor.Annotations[policyBundleTitleAnnotation])
+		if fileName == "" || fileName == "." || fileName == string(filepath.Separator) {
+			return fmt.Errorf("layer %s of policy bundle %s has no title", descriptor.Digest.String(), ref.String())
+		}
+		if err := writeLayer(img, descriptor.Digest, filepath.Join(tmpDir, fileName)); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmpDir, dir)
+}
+
+func writeLayer(img v1.Image, digest v1.Hash, path string) error {
+	layer, err := img.LayerByDigest(digest)
+	if err != nil {
+		return err
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, rc); err != nil {
+		return fmt.Errorf("failed to read layer %s: %w", digest.String(), err)
+	}
+	return nil
+}
+
+var ociIndexMu sync.Mutex
+
+// readPinnedDigests returns the digests of the pulled references, map[<reference>]<digest>
+func readPinnedDigests(cacheDir string) map[string]string {
+	digests := make(map[string]string)
+	b, err := os.ReadFile(filepath.Join(cacheDir, ociIndexFilename))
+	if err != nil {
+		return digests
+	}
+	_ = json.Unmarshal(b, &digests)
+	return digests
+}
+
+func pinDigest(cacheDir, reference, digest string) error {
+	ociIndexMu.Lock()
+	defer ociIndexMu.Unlock()
+
+	digests := readPinnedDigests(cacheDir)
+	if digests[reference] == digest {
+		return nil
+	}
+	digests[reference] = digest
+	return SaveInFile(digests, filepath.Join(cacheDir, ociIndexFilename))
+}
@@ -0,0 +1,124 @@
+package getter
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBundleFiles writes the artifacts of a policy bundle
+func writeBundleFiles(t *testing.T) []string {
+	t.Helper()
+	dir := t.TempDir()
+	artifacts := map[string]string{
+		"nsa.json":             `{"name":"NSA","controls":[{"controlID":"C-0002","name":"Exec into container"},{"controlID":"C-0005","name":"API server insecure port"}]}`,
+		"mitre.json":           `{"name":"MITRE","controls":[{"controlID":"C-0002","name":"Exec into container"}]}`,
+		"controls-inputs.json": `{"sensitiveKeyNames":["aws_access_key_id"]}`,
+		"exceptions.json":      `[{"name":"kube-system"}]`,
+		"attack-tracks.json":   `[{"metadata":{"name":"workload-external-track"}}]`,
+	}
+	files := make([]string, 0, len(artifacts))
+	for name, content := range artifacts {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		files = append(files, path)
+	}
+	return files
+}
+
+func newTestRegistry(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewServer(registry.New())
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return server, u.Host
+}
+
+func TestOCIPolicy(t *testing.T) {
+	ctx := context.Background()
+	server, host := newTestRegistry(t)
+	reference := OCIScheme + host + "/kubescape/policies:v1"
+
+	digest, err := PushPolicyBundle(ctx, reference, writeBundleFiles(t))
+	require.NoError(t, err)
+	assert.Contains(t, digest, "sha256:")
+
+	cacheDir := t.TempDir()
+	ociPolicy, err := NewOCIPolicy(ctx, reference, cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, digest, ociPolicy.Digest())
+	assert.DirExists(t, filepath.Join(cacheDir, "sha256-"+digest[len("sha256:"):]))
+	assert.Equal(t, map[string]string{host + "/kubescape/policies:v1": digest}, readPinnedDigests(cacheDir))
+
+	frameworks, err := ociPolicy.ListFrameworks()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"NSA", "MITRE"}, frameworks)
+
+	framework, err := ociPolicy.GetFramework("nsa")
+	require.NoError(t, err)
+	assert.Len(t, framework.Controls, 2)
+
+	control, err := ociPolicy.GetControl("c-0005")
+	require.NoError(t, err)
+	assert.Equal(t, "API server insecure port", control.Name)
+	_, err = ociPolicy.GetControl("C-0999")
+	assert.ErrorIs(t, err, ErrControlNotMatching)
+
+	controls, err := ociPolicy.ListControls()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"C-0002", "C-0005"}, controls)
+
+	controlsInputs, err := ociPolicy.GetControlsInputs("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws_access_key_id"}, controlsInputs["sensitiveKeyNames"])
+
+	exceptions, err := ociPolicy.GetExceptions("")
+	require.NoError(t, err)
+	require.Len(t, exceptions, 1)
+	assert.Equal(t, "kube-system", exceptions[0].Name)
+
+	attackTracks, err := ociPolicy.GetAttackTracks()
+	require.NoError(t, err)
+	require.Len(t, attackTracks, 1)
+	assert.Equal(t, "workload-external-track", attackTracks[0].GetName())
+
+	// the pinned digest and the cached bundle are used when the registry is down
+	server.Close()
+	cached, err := NewOCIPolicy(ctx, reference, cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, digest, cached.Digest())
+	cached, err = NewOCIPolicy(ctx, OCIScheme+host+"/kubescape/policies@"+digest, cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, digest, cached.Digest())
+
+	_, err = NewOCIPolicy(ctx, reference, t.TempDir())
+	assert.ErrorContains(t, err, "failed to resolve policy bundle")
+}
+
+func TestOCIPolicyNotABundle(t *testing.T) {
+	_, host := newTestRegistry(t)
+	ref, err := name.ParseReference(host + "/kubescape/image:latest")
+	require.NoError(t, err)
+	img, err := random.Image(16, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, img))
+
+	_, err = NewOCIPolicy(context.Background(), OCIScheme+ref.String(), t.TempDir())
+	assert.ErrorContains(t, err, "is not a policy bundle")
+}
+
+func TestIsOCIReference(t *testing.T) {
+	assert.True(t, IsOCIReference("oci://registry.example.com/kubescape/policies:v1"))
+	assert.False(t, IsOCIReference("/home/user/.kubescape"))
+}
@@ -0,0 +1,183 @@
+package getter
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+const (
+	// ChecksumsFilename is the checksum manifest of a policy bundle, in the format of sha256sum
+	ChecksumsFilename = "checksums.txt"
+	// ChecksumsSignatureFilename is the signature of the checksum manifest, e.g. the output of "cosign sign-blob --output-signature"
+	ChecksumsSignatureFilename = "checksums.txt.sig"
+)
+
+// ErrUnverifiedPolicies is returned when the policy files are not covered by a checksum manifest, or the manifest is not signed
+var ErrUnverifiedPolicies = errors.New("unverified policies")
+
+// UpdateChecksums adds the SHA-256 checksums of the files to the checksum manifest of the directory. The manifest and its
+// signature are left untouched when no checksum changed, otherwise the signature no longer matches the manifest and is
+// removed, it returns true when a signature was removed
+func UpdateChecksums(dir string, files []string) (bool, error) {
+	manifestPath := filepath.Join(dir, ChecksumsFilename)
+	previous, err := os.ReadFile(manifestPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	checksums, err := parseChecksums(previous)
+	if err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		checksum, err := fileChecksum(file)
+		if err != nil {
+			return false, err
+		}
+		checksums[filepath.Base(file)] = checksum
+	}
+
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var manifest bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&manifest, "%s  %s\n", checksums[name], name)
+	}
+	if bytes.Equal(manifest.Bytes(), previous) {
+		return false, nil
+	}
+	if err := os.WriteFile(manifestPath, manifest.Bytes(), 0644); err != nil { //nolint:gosec
+		return false, err
+	}
+
+	signaturePath := filepath.Join(dir, ChecksumsSignatureFilename)
+	if _, err := os.Stat(signaturePath); err != nil {
+		return false, nil
+	}
+	return true, os.Remove(signaturePath)
+}
+
+// VerifyPolicyFiles verifies the files against the checksum manifests of their directories. When publicKeyPath is set, the
+// manifests must be signed by the matching private key, e.g. with "cosign sign-blob". Files which are not covered by a
+// manifest wrap ErrUnverifiedPolicies, the other errors mean the files or the manifests were modified
+func VerifyPolicyFiles(files []string, publicKeyPath string) error {
+	var verifier signature.Verifier
+	if publicKeyPath != "" {
+		var err error
+		if verifier, err = loadVerifier(publicKeyPath); err != nil {
+			return err
+		}
+	}
+
+	manifests := make(map[string]map[string]string) // map[<directory>]map[<file name>]<checksum>
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		checksums, ok := manifests[dir]
+		if !ok {
+			var err error
+			if checksums, err = loadManifest(dir, verifier); err != nil {
+				return err
+			}
+			manifests[dir] = checksums
+		}
+
+		expected, ok := checksums[filepath.Base(file)]
+		if !ok {
+			return fmt.Errorf("%w: %s is not listed in %s", ErrUnverifiedPolicies, file, filepath.Join(dir, ChecksumsFilename))
+		}
+		checksum, err := fileChecksum(file)
+		if err != nil {
+			return err
+		}
+		if checksum != expected {
+			return fmt.Errorf("checksum mismatch of %s, the file was modified after it was downloaded", file)
+		}
+	}
+	return nil
+}
+
+// loadManifest reads the checksum manifest of a directory and verifies its signature when a verifier is set
+func loadManifest(dir string, verifier signature.Verifier) (map[string]string, error) {
+	manifestPath := filepath.Join(dir, ChecksumsFilename)
+	manifest, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: no checksum manifest in %s", ErrUnverifiedPolicies, dir)
+		}
+		return nil, err
+	}
+
+	if verifier != nil {
+		signaturePath := filepath.Join(dir, ChecksumsSignatureFilename)
+		sig, err := os.ReadFile(signaturePath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("%w: %s is not signed", ErrUnverifiedPolicies, manifestPath)
+			}
+			return nil, err
+		}
+		// cosign writes base64 encoded signatures
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+			sig = decoded
+		}
+		if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(manifest)); err != nil {
+			return nil, fmt.Errorf("invalid signature of %s: %w", manifestPath, err)
+		}
+	}
+	return parseChecksums(manifest)
+}
+
+// parseChecksums parses a manifest in the format of sha256sum, "<checksum>  <file name>" lines
+func parseChecksums(manifest []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		checksum, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid checksum manifest line: %s", line)
+		}
+		// sha256sum marks binary files with a '*'
+		checksums[strings.TrimPrefix(strings.TrimSpace(name), "*")] = strings.ToLower(checksum)
+	}
+	return checksums, scanner.Err()
+}
+
+func fileChecksum(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func loadVerifier(publicKeyPath string) (signature.Verifier, error) {
+	b, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	publicKey, err := cryptoutils.UnmarshalPEMToPublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key %s: %w", publicKeyPath, err)
+	}
+	return signature.LoadVerifier(publicKey, crypto.SHA256)
+}
@@ -0,0 +1,135 @@
+package getter
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePolicyBundle(t *testing.T) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "nsa.json"), filepath.Join(dir, "controls-inputs.json")}
+	require.NoError(t, os.WriteFile(files[0], []byte(`{"name":"NSA"}`), 0600))
+	require.NoError(t, os.WriteFile(files[1], []byte(`{}`), 0600))
+	return dir, files
+}
+
+// signManifest signs the checksum manifest of the directory like "cosign sign-blob" and returns the path of the public key
+func signManifest(t *testing.T, dir string) string {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := signature.LoadSigner(privateKey, crypto.SHA256)
+	require.NoError(t, err)
+
+	manifest, err := os.Open(filepath.Join(dir, ChecksumsFilename))
+	require.NoError(t, err)
+	defer manifest.Close()
+	sig, err := signer.SignMessage(manifest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ChecksumsSignatureFilename), []byte(base64.StdEncoding.EncodeToString(sig)), 0600))
+
+	publicKey, err := cryptoutils.MarshalPublicKeyToPEM(privateKey.Public())
+	require.NoError(t, err)
+	publicKeyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(publicKeyPath, publicKey, 0600))
+	return publicKeyPath
+}
+
+func TestUpdateChecksums(t *testing.T) {
+	dir, files := writePolicyBundle(t)
+
+	signatureRemoved, err := UpdateChecksums(dir, files[:1])
+	require.NoError(t, err)
+	assert.False(t, signatureRemoved)
+	signatureRemoved, err = UpdateChecksums(dir, files[1:])
+	require.NoError(t, err)
+	assert.False(t, signatureRemoved)
+
+	manifest, err := os.ReadFile(filepath.Join(dir, ChecksumsFilename))
+	require.NoError(t, err)
+	checksums, err := parseChecksums(manifest)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"controls-inputs.json": "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a",
+		"nsa.json":             "1018c96fd1b2374b61bd5b57d664b021dafd5555878a916a4c8668cc1fc1a1cd",
+	}, checksums)
+
+	// the signature is kept when no checksum changed
+	signManifest(t, dir)
+	signatureRemoved, err = UpdateChecksums(dir, files)
+	require.NoError(t, err)
+	assert.False(t, signatureRemoved)
+	assert.FileExists(t, filepath.Join(dir, ChecksumsSignatureFilename))
+
+	require.NoError(t, os.WriteFile(files[0], []byte(`{"name":"NSA","controls":[]}`), 0600))
+	signatureRemoved, err = UpdateChecksums(dir, files[:1])
+	require.NoError(t, err)
+	assert.True(t, signatureRemoved)
+	assert.NoFileExists(t, filepath.Join(dir, ChecksumsSignatureFilename))
+}
+
+func TestVerifyPolicyFiles(t *testing.T) {
+	t.Run("no manifest", func(t *testing.T) {
+		_, files := writePolicyBundle(t)
+		assert.ErrorIs(t, VerifyPolicyFiles(files, ""), ErrUnverifiedPolicies)
+	})
+
+	t.Run("checksums", func(t *testing.T) {
+		dir, files := writePolicyBundle(t)
+		_, err := UpdateChecksums(dir, files[:1])
+		require.NoError(t, err)
+
+		assert.NoError(t, VerifyPolicyFiles(files[:1], ""))
+		assert.ErrorIs(t, VerifyPolicyFiles(files, ""), ErrUnverifiedPolicies)
+
+		require.NoError(t, os.WriteFile(files[0], []byte(`{"name":"tampered"}`), 0600))
+		err = VerifyPolicyFiles(files[:1], "")
+		assert.ErrorContains(t, err, "checksum mismatch")
+		assert.NotErrorIs(t, err, ErrUnverifiedPolicies)
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		dir, files := writePolicyBundle(t)
+		_, err := UpdateChecksums(dir, files)
+		require.NoError(t, err)
+		publicKeyPath := signManifest(t, dir)
+
+		assert.NoError(t, VerifyPolicyFiles(files, publicKeyPath))
+
+		// a manifest updated after signing does not match the signature
+		manifestPath := filepath.Join(dir, ChecksumsFilename)
+		manifest, err := os.ReadFile(manifestPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(manifestPath, append(manifest, []byte("0000  extra.json\n")...), 0600))
+		assert.ErrorContains(t, VerifyPolicyFiles(files, publicKeyPath), "invalid signature")
+
+		require.NoError(t, os.Remove(filepath.Join(dir, ChecksumsSignatureFilename)))
+		assert.ErrorIs(t, VerifyPolicyFiles(files, publicKeyPath), ErrUnverifiedPolicies)
+	})
+
+	t.Run("invalid public key", func(t *testing.T) {
+		_, files := writePolicyBundle(t)
+		assert.ErrorContains(t, VerifyPolicyFiles(files, "testdata/missing.pub"), "failed to read public key")
+	})
+}
+
+func TestParseChecksums(t *testing.T) {
+	checksums, err := parseChecksums([]byte("ABCD  nsa.json\n\nef01 *mitre.json\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"nsa.json": "abcd", "mitre.json": "ef01"}, checksums)
+
+	_, err = parseChecksums([]byte("abcd"))
+	assert.ErrorContains(t, err, "invalid checksum manifest line")
+}
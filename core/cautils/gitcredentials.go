@@ -0,0 +1,161 @@
+package cautils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/bgentry/go-netrc/netrc"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+const (
+	// gitTokenEnvPrefix is the prefix of the per-host token environment variable, e.g. KS_GIT_TOKEN_GITEA_EXAMPLE_COM
+	gitTokenEnvPrefix = "KS_GIT_TOKEN_"
+	// gitUsernameEnvPrefix is the prefix of the optional per-host username environment variable, e.g. KS_GIT_USERNAME_GITEA_EXAMPLE_COM
+	gitUsernameEnvPrefix = "KS_GIT_USERNAME_"
+
+	defaultTokenUsername = "x-token-auth"
+)
+
+// IGitCredentialSource provides credentials for cloning a remote git repository
+type IGitCredentialSource interface {
+	// Name of the credential source, used for logging
+	Name() string
+	// AuthMethod returns the authentication method for the remote, or nil if the source has no credentials for it
+	AuthMethod(remote *GitRemote) (transport.AuthMethod, error)
+}
+
+// GitCredentialSources is the ordered list of credential sources used when cloning remote repositories.
+// The first source that returns an authentication method wins
+var GitCredentialSources = []IGitCredentialSource{
+	&EnvTokenCredentials{},
+	&NetrcCredentials{},
+	&SSHAgentCredentials{},
+}
+
+// resolveGitAuth walks the credential sources and returns the first matching authentication method
+func resolveGitAuth(remote *GitRemote, sources []IGitCredentialSource) (transport.AuthMethod, error) {
+	for _, source := range sources {
+		auth, err := source.AuthMethod(remote)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get git credentials from %s: %w", source.Name(), err)
+		}
+		if auth != nil {
+			return auth, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetGitAuth returns the authentication method of a git URL from the credential sources. Local repositories and paths
+// need no authentication
+func GetGitAuth(rawURL string) (transport.AuthMethod, error) {
+	remote, err := ParseGitRemote(rawURL)
+	if err != nil || remote.Scheme == "file" {
+		return nil, nil
+	}
+	return resolveGitAuth(remote, GitCredentialSources)
+}
+
+// EnvTokenCredentials reads a token from the environment.
+// The per-host variable KS_GIT_TOKEN_<HOST> takes precedence over the provider specific variables (GITHUB_TOKEN, GITLAB_TOKEN...)
+type EnvTokenCredentials struct{}
+
+func (*EnvTokenCredentials) Name() string { return "environment" }
+
+func (*EnvTokenCredentials) AuthMethod(remote *GitRemote) (transport.AuthMethod, error) {
+	if !isHTTPScheme(remote.Scheme) {
+		return nil, nil
+	}
+	token := os.Getenv(gitHostEnvName(gitTokenEnvPrefix, remote.Host))
+	username := os.Getenv(gitHostEnvName(gitUsernameEnvPrefix, remote.Host))
+	if token == "" {
+		token = remote.GetToken()
+	}
+	if token == "" {
+		return nil, nil
+	}
+	if username == "" {
+		username = defaultTokenUsername
+	}
+	return &http.BasicAuth{Username: username, Password: token}, nil
+}
+
+// gitHostEnvName builds the environment variable name for a host, e.g. gitea.example.com:3000 -> KS_GIT_TOKEN_GITEA_EXAMPLE_COM_3000
+func gitHostEnvName(prefix, host string) string {
+	return prefix + strings.ToUpper(strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, host))
+}
+
+// NetrcCredentials reads credentials from the netrc file ($NETRC or ~/.netrc, ~/_netrc on Windows)
+type NetrcCredentials struct {
+	Path string // overrides the netrc file location
+}
+
+func (*NetrcCredentials) Name() string { return "netrc" }
+
+func (n *NetrcCredentials) AuthMethod(remote *GitRemote) (transport.AuthMethod, error) {
+	if !isHTTPScheme(remote.Scheme) || remote.Host == "" {
+		return nil, nil
+	}
+	path := n.netrcPath()
+	if path == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	machine, err := netrc.FindMachine(path, remote.Host)
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil || machine.Password == "" {
+		return nil, nil
+	}
+	return &http.BasicAuth{Username: machine.Login, Password: machine.Password}, nil
+}
+
+func (n *NetrcCredentials) netrcPath() string {
+	if n.Path != "" {
+		return n.Path
+	}
+	if p := os.Getenv("NETRC"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// SSHAgentCredentials authenticates ssh remotes using the keys loaded in the running ssh-agent
+type SSHAgentCredentials struct{}
+
+func (*SSHAgentCredentials) Name() string { return "ssh-agent" }
+
+func (*SSHAgentCredentials) AuthMethod(remote *GitRemote) (transport.AuthMethod, error) {
+	if remote.Scheme != "ssh" && remote.Scheme != "git+ssh" {
+		return nil, nil
+	}
+	if os.Getenv("SSH_AUTH_SOCK") == "" {
+		return nil, nil
+	}
+	user := ssh.DefaultUsername
+	if u, err := parseGitTransportURL(remote.CloneURL); err == nil && u.User != nil && u.User.Username() != "" {
+		user = u.User.Username()
+	}
+	return ssh.NewSSHAgentAuth(user)
+}
@@ -0,0 +1,117 @@
+package cautils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHostEnvName(t *testing.T) {
+	assert.Equal(t, "KS_GIT_TOKEN_GITEA_EXAMPLE_COM", gitHostEnvName(gitTokenEnvPrefix, "gitea.example.com"))
+	assert.Equal(t, "KS_GIT_TOKEN_GIT_EXAMPLE_COM_3000", gitHostEnvName(gitTokenEnvPrefix, "git.example.com:3000"))
+}
+
+func TestEnvTokenCredentials(t *testing.T) {
+	remote, err := ParseGitRemote("https://gitea.example.com/org/repo.git")
+	require.NoError(t, err)
+
+	source := &EnvTokenCredentials{}
+
+	auth, err := source.AuthMethod(remote)
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	t.Setenv("KS_GIT_TOKEN_GITEA_EXAMPLE_COM", "secret")
+	auth, err = source.AuthMethod(remote)
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: defaultTokenUsername, Password: "secret"}, auth)
+
+	t.Setenv("KS_GIT_USERNAME_GITEA_EXAMPLE_COM", "bot")
+	auth, err = source.AuthMethod(remote)
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "bot", Password: "secret"}, auth)
+
+	// tokens are never sent over ssh
+	sshRemote, err := ParseGitRemote("ssh://git@gitea.example.com/org/repo.git")
+	require.NoError(t, err)
+	auth, err = source.AuthMethod(sshRemote)
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+}
+
+func TestEnvTokenCredentialsProviderToken(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "gitlab-secret")
+	remote, err := ParseGitRemote("https://gitlab.com/kubescape/kubescape")
+	require.NoError(t, err)
+
+	auth, err := (&EnvTokenCredentials{}).AuthMethod(remote)
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: defaultTokenUsername, Password: "gitlab-secret"}, auth)
+}
+
+func TestNetrcCredentials(t *testing.T) {
+	netrcPath := filepath.Join(t.TempDir(), ".netrc")
+	require.NoError(t, os.WriteFile(netrcPath, []byte("machine gitea.example.com login user password pass\n"), 0600))
+
+	source := &NetrcCredentials{Path: netrcPath}
+
+	remote, err := ParseGitRemote("https://gitea.example.com/org/repo.git")
+	require.NoError(t, err)
+	auth, err := source.AuthMethod(remote)
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "user", Password: "pass"}, auth)
+
+	other, err := ParseGitRemote("https://git.example.com/org/repo.git")
+	require.NoError(t, err)
+	auth, err = source.AuthMethod(other)
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	missing := &NetrcCredentials{Path: filepath.Join(t.TempDir(), "missing")}
+	auth, err = missing.AuthMethod(remote)
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+}
+
+func TestSSHAgentCredentialsWithoutAgent(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	remote, err := ParseGitRemote("git@git.example.com:org/repo.git")
+	require.NoError(t, err)
+
+	auth, err := (&SSHAgentCredentials{}).AuthMethod(remote)
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+}
+
+type mockCredentialSource struct {
+	auth transport.AuthMethod
+	err  error
+}
+
+func (m *mockCredentialSource) Name() string { return "mock" }
+func (m *mockCredentialSource) AuthMethod(*GitRemote) (transport.AuthMethod, error) {
+	return m.auth, m.err
+}
+
+func TestResolveGitAuth(t *testing.T) {
+	remote := &GitRemote{Scheme: "https", Host: "git.example.com"}
+	first := &http.BasicAuth{Username: "first"}
+	second := &http.BasicAuth{Username: "second"}
+
+	auth, err := resolveGitAuth(remote, []IGitCredentialSource{&mockCredentialSource{}, &mockCredentialSource{auth: first}, &mockCredentialSource{auth: second}})
+	assert.NoError(t, err)
+	assert.Equal(t, first, auth)
+
+	auth, err = resolveGitAuth(remote, []IGitCredentialSource{&mockCredentialSource{}})
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	_, err = resolveGitAuth(remote, []IGitCredentialSource{&mockCredentialSource{err: errors.New("boom")}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package cautils
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	giturls "github.com/chainguard-dev/git-urls"
+	giturl "github.com/kubescape/go-git-url"
+)
+
+// GitRemote describes a remote git repository to be cloned and scanned.
+// Well-known providers (GitHub, GitLab, Azure, Bitbucket) are parsed with go-git-url,
+// any other git URL (self-hosted servers, ssh://, file://, scp-like) is handled generically
+type GitRemote struct {
+	CloneURL string // URL passed to git clone
+	Host     string // host name, empty for file:// remotes
+	Scheme   string // transport scheme (https, ssh, file...)
+	Provider string // provider name, used for metadata and error messages
+	Owner    string // owner/group part of the repository path
+	Repo     string // repository name without the .git suffix
+	Ref      string // branch, tag or commit to pin the clone to
+
+	api giturl.IGitAPI // set for well-known providers only
+}
+
+const gitRefSeparator = "#"
+
+// ParseGitRemote parses a remote git repository URL.
+// A ref (branch, tag or commit) can be requested by appending it to the URL after a '#', e.g. https://gitea.example.com/org/repo.git#v1.2.0
+func ParseGitRemote(input string) (*GitRemote, error) {
+	rawURL, ref, _ := strings.Cut(input, gitRefSeparator)
+
+	u, err := parseGitTransportURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := &GitRemote{
+		Scheme: u.Scheme,
+		Host:   u.Hostname(),
+		Ref:    ref,
+	}
+
+	if api, err := giturl.NewGitAPI(rawURL); err == nil && isHTTPScheme(u.Scheme) {
+		remote.api = api
+		remote.CloneURL = api.GetHttpCloneURL()
+		remote.Provider = api.GetProvider()
+		remote.Owner = api.GetOwnerName()
+		remote.Repo = api.GetRepoName()
+		if remote.Ref == "" {
+			remote.Ref = api.GetBranchName()
+		}
+		return remote, nil
+	}
+
+	remote.CloneURL = rawURL
+	remote.Provider = guessGitProvider(remote.Host)
+	remote.Owner, remote.Repo = splitRepoPath(u.Path)
+	if remote.Repo == "" {
+		return nil, fmt.Errorf("expecting a repository path in git url '%s'", rawURL)
+	}
+
+	return remote, nil
+}
+
+// IsGitRemoteURL returns true if the input looks like a remote git repository URL rather than a local path
+func IsGitRemoteURL(input string) bool {
+	_, err := ParseGitRemote(input)
+	return err == nil
+}
+
+// GetToken returns the provider token configured in the environment for well-known providers
+func (r *GitRemote) GetToken() string {
+	if r.api == nil {
+		return ""
+	}
+	return r.api.GetToken()
+}
+
+// IsWellKnownProvider returns true if the remote is hosted by one of the providers supported by go-git-url
+func (r *GitRemote) IsWellKnownProvider() bool {
+	return r.api != nil
+}
+
+// URL returns the clone URL including the requested ref, used as a key for the cloned repositories cache
+func (r *GitRemote) URL() string {
+	if r.Ref == "" {
+		return r.CloneURL
+	}
+	return r.CloneURL + gitRefSeparator + r.Ref
+}
+
+// parseGitTransportURL accepts only URLs with an explicit git transport or the scp-like syntax.
+// Local paths are deliberately rejected so they are not mistaken for remotes
+func parseGitTransportURL(rawURL string) (*url.URL, error) {
+	if rawURL == "" {
+		return nil, fmt.Errorf("empty git url")
+	}
+	if u, err := giturls.ParseTransport(rawURL); err == nil {
+		if u.Scheme != "file" && u.Host == "" {
+			return nil, fmt.Errorf("missing host in git url '%s'", rawURL)
+		}
+		return u, nil
+	}
+	if strings.Contains(rawURL, "://") {
+		return nil, fmt.Errorf("unsupported git transport in url '%s'", rawURL)
+	}
+	u, err := giturls.ParseScp(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	// "C:/path" or "dir:file" are local paths, scp-like urls must have a user or a dotted host name
+	if u.User == nil && !strings.Contains(u.Host, ".") {
+		return nil, fmt.Errorf("'%s' is not a git url", rawURL)
+	}
+	return u, nil
+}
+
+func isHTTPScheme(scheme string) bool {
+	return scheme == "http" || scheme == "https"
+}
+
+// splitRepoPath splits a repository path such as /group/subgroup/repo.git into owner and repo
+func splitRepoPath(p string) (string, string) {
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if p == "" {
+		return "", ""
+	}
+	owner, repo := path.Split(p)
+	return strings.TrimSuffix(owner, "/"), strings.TrimSuffix(repo, ".git")
+}
+
+// guessGitProvider returns the provider name based on the host, falling back to a generic "git" provider
+func guessGitProvider(host string) string {
+	for _, provider := range []string{"gitlab", "gitea", "bitbucket", "github", "azure"} {
+		if strings.Contains(host, provider) {
+			return provider
+		}
+	}
+	return "git"
+}
@@ -0,0 +1,146 @@
+package cautils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitRemote(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    GitRemote
+		wantErr bool
+	}{
+		{
+			name:  "github",
+			input: "https://github.com/kubescape/kubescape",
+			want: GitRemote{
+				CloneURL: "https://github.com/kubescape/kubescape.git",
+				Host:     "github.com",
+				Scheme:   "https",
+				Provider: "github",
+				Owner:    "kubescape",
+				Repo:     "kubescape",
+			},
+		},
+		{
+			name:  "github with ref",
+			input: "https://github.com/kubescape/kubescape#v3.0.0",
+			want: GitRemote{
+				CloneURL: "https://github.com/kubescape/kubescape.git",
+				Host:     "github.com",
+				Scheme:   "https",
+				Provider: "github",
+				Owner:    "kubescape",
+				Repo:     "kubescape",
+				Ref:      "v3.0.0",
+			},
+		},
+		{
+			name:  "gitea",
+			input: "https://gitea.example.com/org/repo.git",
+			want: GitRemote{
+				CloneURL: "https://gitea.example.com/org/repo.git",
+				Host:     "gitea.example.com",
+				Scheme:   "https",
+				Provider: "gitea",
+				Owner:    "org",
+				Repo:     "repo",
+			},
+		},
+		{
+			name:  "bitbucket server with commit",
+			input: "https://git.example.com/scm/proj/repo.git#0123456789abcdef0123456789abcdef01234567",
+			want: GitRemote{
+				CloneURL: "https://git.example.com/scm/proj/repo.git",
+				Host:     "git.example.com",
+				Scheme:   "https",
+				Provider: "git",
+				Owner:    "scm/proj",
+				Repo:     "repo",
+				Ref:      "0123456789abcdef0123456789abcdef01234567",
+			},
+		},
+		{
+			name:  "ssh",
+			input: "ssh://git@git.example.com:2222/group/sub/repo.git",
+			want: GitRemote{
+				CloneURL: "ssh://git@git.example.com:2222/group/sub/repo.git",
+				Host:     "git.example.com",
+				Scheme:   "ssh",
+				Provider: "git",
+				Owner:    "group/sub",
+				Repo:     "repo",
+			},
+		},
+		{
+			name:  "scp-like",
+			input: "git@github.com:kubescape/kubescape.git",
+			want: GitRemote{
+				CloneURL: "git@github.com:kubescape/kubescape.git",
+				Host:     "github.com",
+				Scheme:   "ssh",
+				Provider: "github",
+				Owner:    "kubescape",
+				Repo:     "kubescape",
+			},
+		},
+		{
+			name:  "file",
+			input: "file:///srv/git/repo.git#main",
+			want: GitRemote{
+				CloneURL: "file:///srv/git/repo.git",
+				Scheme:   "file",
+				Provider: "git",
+				Owner:    "srv/git",
+				Repo:     "repo",
+				Ref:      "main",
+			},
+		},
+		{
+			name:    "local path",
+			input:   "examples/online-boutique",
+			wantErr: true,
+		},
+		{
+			name:    "absolute local path",
+			input:   "/tmp/repo",
+			wantErr: true,
+		},
+		{
+			name:    "windows path",
+			input:   "C:/repo",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGitRemote(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.False(t, IsGitRemoteURL(tt.input))
+				return
+			}
+			require.NoError(t, err)
+			got.api = nil
+			assert.Equal(t, tt.want, *got)
+			assert.True(t, IsGitRemoteURL(tt.input))
+		})
+	}
+}
+
+func TestGitRemoteURL(t *testing.T) {
+	remote := &GitRemote{CloneURL: "https://gitea.example.com/org/repo.git"}
+	assert.Equal(t, "https://gitea.example.com/org/repo.git", remote.URL())
+
+	remote.Ref = "main"
+	assert.Equal(t, "https://gitea.example.com/org/repo.git#main", remote.URL())
+}
@@ -1,7 +1,9 @@
 package cautils
 
 import (
+	"fmt"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -15,6 +17,9 @@ import (
 	helmengine "helm.sh/helm/v3/pkg/engine"
 )
 
+// templateLineCommentRegexp matches the comments added by AddCommentToTemplate
+var templateLineCommentRegexp = regexp.MustCompile(` ?#This is the (\d+) line`)
+
 type HelmChart struct {
 	chart *helmchart.Chart
 	path  string
@@ -49,13 +54,22 @@ func (hc *HelmChart) GetWorkloadsWithDefaultValues() (map[string][]workloadinter
 	return hc.GetWorkloads(hc.GetDefaultValues())
 }
 
+// GetValues returns the default values of the chart merged with the values files, the last file taking precedence
+func (hc *HelmChart) GetValues(valuesFiles ...string) (map[string]interface{}, error) {
+	values := helmchartutil.Values{}
+	for _, valuesFile := range valuesFiles {
+		fileValues, err := helmchartutil.ReadValuesFile(valuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s: %w", valuesFile, err)
+		}
+		values = helmchartutil.CoalesceTables(fileValues, values)
+	}
+	return helmchartutil.CoalesceTables(values, hc.chart.Values), nil
+}
+
 // GetWorkloads renders chart template using the provided values and returns a map of source (absolute) file path to its workloads
 func (hc *HelmChart) GetWorkloads(values map[string]interface{}) (map[string][]workloadinterface.IMetadata, []error) {
-	vals, err := helmchartutil.ToRenderValues(hc.chart, values, helmchartutil.ReleaseOptions{}, nil)
-	if err != nil {
-		return nil, []error{err}
-	}
-	sourceToFile, err := helmengine.Render(hc.chart, vals)
+	sourceToFile, err := hc.RenderTemplates(values)
 	if err != nil {
 		return nil, []error{err}
 	}
@@ -63,32 +77,77 @@ func (hc *HelmChart) GetWorkloads(values map[string]interface{}) (map[string][]w
 	workloads := make(map[string][]workloadinterface.IMetadata)
 	var errs []error
 
-	for path, renderedYaml := range sourceToFile {
-		if !IsYaml(strings.ToLower(path)) {
-			continue
-		}
-
+	for absPath, renderedYaml := range sourceToFile {
 		wls, e := ReadFile([]byte(renderedYaml), YAML_FILE_FORMAT)
 		if e != nil {
-			logger.L().Debug("failed to read rendered yaml file", helpers.String("file", path), helpers.Error(e))
+			logger.L().Debug("failed to read rendered yaml file", helpers.String("file", absPath), helpers.Error(e))
 		}
 		if len(wls) == 0 {
 			continue
 		}
-		if firstPathSeparatorIndex := strings.Index(path, "/"); firstPathSeparatorIndex != -1 {
-			absPath := filepath.Join(hc.path, path[firstPathSeparatorIndex:])
 
-			workloads[absPath] = []workloadinterface.IMetadata{}
-			for i := range wls {
-				lw := localworkload.NewLocalWorkload(wls[i].GetObject())
-				lw.SetPath(absPath)
-				workloads[absPath] = append(workloads[absPath], lw)
-			}
+		workloads[absPath] = []workloadinterface.IMetadata{}
+		for i := range wls {
+			lw := localworkload.NewLocalWorkload(wls[i].GetObject())
+			lw.SetPath(absPath)
+			workloads[absPath] = append(workloads[absPath], lw)
 		}
 	}
 	return workloads, errs
 }
 
+// RenderTemplates renders the YAML templates of the chart using the provided values and returns a map of template (absolute)
+// file path to its rendered content
+func (hc *HelmChart) RenderTemplates(values map[string]interface{}) (map[string]string, error) {
+	vals, err := helmchartutil.ToRenderValues(hc.chart, values, helmchartutil.ReleaseOptions{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	sourceToFile, err := helmengine.Render(hc.chart, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := make(map[string]string, len(sourceToFile))
+	for path, renderedYaml := range sourceToFile {
+		if !IsYaml(strings.ToLower(path)) {
+			continue
+		}
+		if firstPathSeparatorIndex := strings.Index(path, "/"); firstPathSeparatorIndex != -1 {
+			rendered[filepath.Join(hc.path, path[firstPathSeparatorIndex:])] = renderedYaml
+		}
+	}
+	return rendered, nil
+}
+
+// ReplaceTemplate replaces the content of a template of the chart, the path is the absolute file path of the template. It
+// returns false if the chart has no such template
+func (hc *HelmChart) ReplaceTemplate(path string, data []byte) bool {
+	for _, t := range hc.chart.Templates {
+		if filepath.Join(hc.path, t.Name) == path {
+			t.Data = data
+			return true
+		}
+	}
+	return false
+}
+
+// TemplateLineOfComment returns the line of the template a rendered line comes from, using the comments added by
+// AddCommentToTemplate. Lines joined by whitespace control carry several comments, the first one is the origin of the line
+func TemplateLineOfComment(renderedLine string) (int, bool) {
+	match := templateLineCommentRegexp.FindStringSubmatch(renderedLine)
+	if match == nil {
+		return 0, false
+	}
+	line, err := strconv.Atoi(match[1])
+	return line, err == nil
+}
+
+// RemoveTemplateLineComments removes the comments added by AddCommentToTemplate from a rendered line
+func RemoveTemplateLineComments(renderedLine string) string {
+	return templateLineCommentRegexp.ReplaceAllString(renderedLine, "")
+}
+
 func (hc *HelmChart) AddCommentToTemplate() {
 	for index, t := range hc.chart.Templates {
 		if IsYaml(strings.ToLower(t.Name)) {
@@ -56,6 +56,17 @@ func IsKustomizeFile(path string) bool {
 	return false
 }
 
+// FindKustomizationFile returns the path of the Kustomization file of the directory, or an empty string if there is none
+func FindKustomizationFile(dir string) string {
+	for _, kustomizationFileMatcher := range kustomizationFileMatchers {
+		checkPath := filepath.Join(dir, kustomizationFileMatcher)
+		if _, err := os.Stat(checkPath); err == nil {
+			return checkPath
+		}
+	}
+	return ""
+}
+
 func NewKustomizeDirectory(path string) *KustomizeDirectory {
 	return &KustomizeDirectory{
 		path: path,
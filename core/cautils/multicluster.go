@@ -0,0 +1,115 @@
+package cautils
+
+import (
+	"sort"
+
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+)
+
+// ClusterReport is the result of the scan of a single cluster of a multi-cluster scan
+type ClusterReport struct {
+	KubeContext     string                          `json:"kubeContext"`
+	ComplianceScore float32                         `json:"complianceScore"`
+	Frameworks      map[string]float32              `json:"frameworks,omitempty"` // framework name -> compliance score
+	FailedControls  int                             `json:"failedControls"`
+	FailedResources int                             `json:"failedResources"`
+	AllResources    int                             `json:"allResources"`
+	Error           string                          `json:"error,omitempty"`
+	Report          *reporthandlingv2.PostureReport `json:"report,omitempty"`
+}
+
+// MultiClusterReport is the combined report of a multi-cluster scan.
+// The fleet-level compliance scores are the per-cluster scores weighted by the number of scanned resources
+type MultiClusterReport struct {
+	ComplianceScore float32            `json:"complianceScore"`
+	Frameworks      map[string]float32 `json:"frameworks,omitempty"` // framework name -> fleet compliance score
+	Clusters        []ClusterReport    `json:"clusters"`
+}
+
+// NewClusterReport summarizes the posture report of a single cluster. A nil report records a failed scan
+func NewClusterReport(kubeContext string, report *reporthandlingv2.PostureReport, err error) ClusterReport {
+	clusterReport := ClusterReport{
+		KubeContext: kubeContext,
+		Report:      report,
+	}
+	if err != nil {
+		clusterReport.Error = err.Error()
+	}
+	if report == nil {
+		return clusterReport
+	}
+
+	clusterReport.ComplianceScore = report.SummaryDetails.ComplianceScore
+	clusterReport.FailedControls = report.SummaryDetails.NumberOfControls().Failed()
+	clusterReport.FailedResources = report.SummaryDetails.NumberOfResources().Failed()
+	clusterReport.AllResources = report.SummaryDetails.NumberOfResources().All()
+	clusterReport.Frameworks = make(map[string]float32, len(report.SummaryDetails.Frameworks))
+	for _, framework := range report.SummaryDetails.Frameworks {
+		clusterReport.Frameworks[framework.Name] = framework.ComplianceScore
+	}
+	return clusterReport
+}
+
+// NewMultiClusterReport combines the reports of the scanned clusters and computes the fleet-level scores
+func NewMultiClusterReport(clusters []ClusterReport) *MultiClusterReport {
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].KubeContext < clusters[j].KubeContext
+	})
+
+	multiClusterReport := &MultiClusterReport{
+		Frameworks: map[string]float32{},
+		Clusters:   clusters,
+	}
+
+	scores := []weightedScore{}
+	frameworkScores := map[string][]weightedScore{}
+	for _, cluster := range clusters {
+		if cluster.Report == nil {
+			continue
+		}
+		scores = append(scores, weightedScore{score: cluster.ComplianceScore, weight: cluster.AllResources})
+		for name, score := range cluster.Frameworks {
+			frameworkScores[name] = append(frameworkScores[name], weightedScore{score: score, weight: cluster.AllResources})
+		}
+	}
+
+	multiClusterReport.ComplianceScore = weightedAverage(scores)
+	for name := range frameworkScores {
+		multiClusterReport.Frameworks[name] = weightedAverage(frameworkScores[name])
+	}
+	return multiClusterReport
+}
+
+// ListFrameworks returns the sorted names of the frameworks scanned in the fleet
+func (multiClusterReport *MultiClusterReport) ListFrameworks() []string {
+	frameworks := make([]string, 0, len(multiClusterReport.Frameworks))
+	for name := range multiClusterReport.Frameworks {
+		frameworks = append(frameworks, name)
+	}
+	sort.Strings(frameworks)
+	return frameworks
+}
+
+type weightedScore struct {
+	score  float32
+	weight int
+}
+
+// weightedAverage falls back to the plain average when no resources were scanned
+func weightedAverage(scores []weightedScore) float32 {
+	if len(scores) == 0 {
+		return 0
+	}
+	var sum, weights float32
+	for _, s := range scores {
+		sum += s.score * float32(s.weight)
+		weights += float32(s.weight)
+	}
+	if weights == 0 {
+		for _, s := range scores {
+			sum += s.score
+		}
+		return sum / float32(len(scores))
+	}
+	return sum / weights
+}
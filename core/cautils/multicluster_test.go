@@ -0,0 +1,71 @@
+package cautils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockClusterPostureReport(complianceScore float32, passed, failed int, frameworks map[string]float32) *reporthandlingv2.PostureReport {
+	report := &reporthandlingv2.PostureReport{}
+	report.SummaryDetails.ComplianceScore = complianceScore
+	report.SummaryDetails.StatusCounters = reportsummary.StatusCounters{PassedResources: passed, FailedResources: failed}
+	for name, score := range frameworks {
+		report.SummaryDetails.Frameworks = append(report.SummaryDetails.Frameworks, reportsummary.FrameworkSummary{Name: name, ComplianceScore: score})
+	}
+	return report
+}
+
+func TestNewClusterReport(t *testing.T) {
+	t.Run("scanned cluster", func(t *testing.T) {
+		report := NewClusterReport("prod", mockClusterPostureReport(80, 6, 4, map[string]float32{"nsa": 75}), nil)
+		assert.Equal(t, "prod", report.KubeContext)
+		assert.Equal(t, float32(80), report.ComplianceScore)
+		assert.Equal(t, 4, report.FailedResources)
+		assert.Equal(t, 10, report.AllResources)
+		assert.Equal(t, map[string]float32{"nsa": 75}, report.Frameworks)
+		assert.Empty(t, report.Error)
+	})
+
+	t.Run("failed cluster", func(t *testing.T) {
+		report := NewClusterReport("staging", nil, fmt.Errorf("connection refused"))
+		assert.Equal(t, "staging", report.KubeContext)
+		assert.Nil(t, report.Report)
+		assert.Equal(t, "connection refused", report.Error)
+	})
+}
+
+func TestNewMultiClusterReport(t *testing.T) {
+	t.Run("fleet scores are weighted by resources", func(t *testing.T) {
+		report := NewMultiClusterReport([]ClusterReport{
+			NewClusterReport("staging", mockClusterPostureReport(50, 5, 5, map[string]float32{"nsa": 40, "mitre": 60}), nil),
+			NewClusterReport("prod", mockClusterPostureReport(80, 24, 6, map[string]float32{"nsa": 80}), nil),
+			NewClusterReport("dev", nil, fmt.Errorf("unreachable")),
+		})
+
+		require.Len(t, report.Clusters, 3)
+		assert.Equal(t, "dev", report.Clusters[0].KubeContext) // sorted by context
+		assert.InDelta(t, 72.5, report.ComplianceScore, 0.001) // (50*10 + 80*30) / 40
+		assert.InDelta(t, 70, report.Frameworks["nsa"], 0.001) // (40*10 + 80*30) / 40
+		assert.InDelta(t, 60, report.Frameworks["mitre"], 0.001)
+		assert.Equal(t, []string{"mitre", "nsa"}, report.ListFrameworks())
+	})
+
+	t.Run("no scanned resources", func(t *testing.T) {
+		report := NewMultiClusterReport([]ClusterReport{
+			NewClusterReport("a", mockClusterPostureReport(100, 0, 0, nil), nil),
+			NewClusterReport("b", mockClusterPostureReport(50, 0, 0, nil), nil),
+		})
+		assert.InDelta(t, 75, report.ComplianceScore, 0.001)
+	})
+
+	t.Run("all clusters failed", func(t *testing.T) {
+		report := NewMultiClusterReport([]ClusterReport{NewClusterReport("a", nil, fmt.Errorf("unreachable"))})
+		assert.Equal(t, float32(0), report.ComplianceScore)
+		assert.Empty(t, report.ListFrameworks())
+	})
+}
@@ -2,6 +2,9 @@ package cautils
 
 import (
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,26 +12,43 @@ import (
 	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
 	"github.com/kubescape/rbac-utils/rbacscanner"
 	"github.com/kubescape/rbac-utils/rbacutils"
+	rbac "k8s.io/api/rbac/v1"
 )
 
+// rbacLister lists the RBAC objects of a cluster, either from the API server or from a snapshot
+type rbacLister interface {
+	ListResources() (*rbacutils.RbacObjects, error)
+}
+
 type RBACObjects struct {
-	scanner *rbacscanner.RbacScannerFromK8sAPI
+	scanner      rbacLister
+	customerGUID string
+	clusterName  string
 }
 
 func NewRBACObjects(scanner *rbacscanner.RbacScannerFromK8sAPI) *RBACObjects {
-	return &RBACObjects{scanner: scanner}
+	return &RBACObjects{scanner: scanner, customerGUID: scanner.CustomerGUID, clusterName: scanner.ClusterName}
+}
+
+// NewRBACObjectsFromResources builds the RBAC objects from already loaded resources (e.g. an offline cluster snapshot)
+func NewRBACObjectsFromResources(resources []workloadinterface.IMetadata, customerGUID, clusterName string) *RBACObjects {
+	return &RBACObjects{
+		scanner:      &rbacResourcesLister{resources: resources, clusterName: clusterName},
+		customerGUID: customerGUID,
+		clusterName:  clusterName,
+	}
 }
 
 func (rbacObjects *RBACObjects) SetResourcesReport() (*reporthandlingv2.PostureReport, error) {
 	return &reporthandlingv2.PostureReport{
 		ReportID:             uuid.NewString(),
 		ReportGenerationTime: time.Now().UTC(),
-		CustomerGUID:         rbacObjects.scanner.CustomerGUID,
-		ClusterName:          rbacObjects.scanner.ClusterName,
+		CustomerGUID:         rbacObjects.customerGUID,
+		ClusterName:          rbacObjects.clusterName,
 		Metadata: reporthandlingv2.Metadata{
 			ContextMetadata: reporthandlingv2.ContextMetadata{
 				ClusterContextMetadata: &reporthandlingv2.ClusterMetadata{
-					ContextName: rbacObjects.scanner.ClusterName,
+					ContextName: rbacObjects.clusterName,
 				},
 			},
 		},
@@ -134,3 +154,75 @@ func convertToMap(obj interface{}) (map[string]interface{}, error) {
 	}
 	return inInterface, nil
 }
+
+// rbacResourcesLister builds the same RBAC objects as rbacscanner.RbacScannerFromK8sAPI from loaded resources
+type rbacResourcesLister struct {
+	resources   []workloadinterface.IMetadata
+	clusterName string
+}
+
+func (r *rbacResourcesLister) ListResources() (*rbacutils.RbacObjects, error) {
+	rbacObjects := &rbacutils.RbacObjects{
+		ClusterRoles:        &rbac.ClusterRoleList{},
+		Roles:               &rbac.RoleList{},
+		ClusterRoleBindings: &rbac.ClusterRoleBindingList{},
+		RoleBindings:        &rbac.RoleBindingList{},
+		SA2WLIDmap:          map[string][]string{},
+		SAID2WLIDmap:        map[string][]string{},
+	}
+
+	var workloads []workloadinterface.IWorkload
+	for _, resource := range r.resources {
+		var err error
+		switch resource.GetKind() {
+		case "ClusterRole":
+			err = appendRbacItem(resource, &rbacObjects.ClusterRoles.Items)
+		case "Role":
+			err = appendRbacItem(resource, &rbacObjects.Roles.Items)
+		case "ClusterRoleBinding":
+			err = appendRbacItem(resource, &rbacObjects.ClusterRoleBindings.Items)
+		case "RoleBinding":
+			err = appendRbacItem(resource, &rbacObjects.RoleBindings.Items)
+		case "ServiceAccount":
+			rbacObjects.SA2WLIDmap[resource.GetName()] = []string{}
+			rbacObjects.SAID2WLIDmap[resource.GetID()] = []string{}
+		default:
+			if slices.Contains(rbacutils.ResourceGroupMapping, strings.ToLower(resource.GetKind())+"s") {
+				workloads = append(workloads, workloadinterface.NewWorkloadObj(resource.GetObject()))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// same logic as rbacutils.InitSA2WLIDmap and rbacutils.InitSAID2WLIDmap
+	for _, wl := range workloads {
+		if ref, err := wl.GetOwnerReferences(); len(ref) == 0 && err == nil {
+			rbacObjects.SA2WLIDmap[wl.GetServiceAccountName()] = append(rbacObjects.SA2WLIDmap[wl.GetServiceAccountName()], wl.GenerateWlid(r.clusterName))
+		}
+		if !rbacutils.WorkloadHasParent(wl) {
+			connectedSA := wl.GetServiceAccountName()
+			if connectedSA == "" {
+				connectedSA = "default"
+			}
+			saID := fmt.Sprintf("/v1/%s/ServiceAccount/%s", wl.GetNamespace(), connectedSA)
+			rbacObjects.SAID2WLIDmap[saID] = append(rbacObjects.SAID2WLIDmap[saID], wl.GenerateWlid(r.clusterName))
+		}
+	}
+
+	return rbacObjects, nil
+}
+
+func appendRbacItem[T any](resource workloadinterface.IMetadata, items *[]T) error {
+	var item T
+	b, err := json.Marshal(resource.GetObject())
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &item); err != nil {
+		return fmt.Errorf("failed to convert %s: %w", resource.GetID(), err)
+	}
+	*items = append(*items, item)
+	return nil
+}
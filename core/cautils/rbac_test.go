@@ -0,0 +1,64 @@
+package cautils
+
+import (
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRBACObjectsFromResources(t *testing.T) {
+	resources := []workloadinterface.IMetadata{
+		workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "ClusterRole",
+			"metadata":   map[string]interface{}{"name": "admin"},
+			"rules": []interface{}{
+				map[string]interface{}{"apiGroups": []interface{}{"*"}, "resources": []interface{}{"*"}, "verbs": []interface{}{"*"}},
+			},
+		}),
+		workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "rbac.authorization.k8s.io/v1",
+			"kind":       "RoleBinding",
+			"metadata":   map[string]interface{}{"name": "admin-binding", "namespace": "default"},
+			"roleRef":    map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "ClusterRole", "name": "admin"},
+			"subjects": []interface{}{
+				map[string]interface{}{"kind": "ServiceAccount", "name": "builder", "namespace": "default"},
+			},
+		}),
+		workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata":   map[string]interface{}{"name": "builder", "namespace": "default"},
+		}),
+		workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "builder", "namespace": "default"},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{"serviceAccountName": "builder"},
+				},
+			},
+		}),
+	}
+
+	rbacObjects := NewRBACObjectsFromResources(resources, "", "prod")
+
+	report, err := rbacObjects.SetResourcesReport()
+	require.NoError(t, err)
+	assert.Equal(t, "prod", report.ClusterName)
+
+	objs, err := rbacObjects.scanner.ListResources()
+	require.NoError(t, err)
+	assert.Len(t, objs.ClusterRoles.Items, 1)
+	assert.Len(t, objs.RoleBindings.Items, 1)
+	assert.Equal(t, "builder", objs.RoleBindings.Items[0].Subjects[0].Name)
+	assert.Len(t, objs.SA2WLIDmap["builder"], 1)
+	assert.Len(t, objs.SAID2WLIDmap["/v1/default/ServiceAccount/builder"], 1)
+
+	allResources, err := rbacObjects.ListAllResources()
+	require.NoError(t, err)
+	assert.NotEmpty(t, allResources)
+}
@@ -8,10 +8,10 @@ import (
 	"os"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	giturl "github.com/kubescape/go-git-url"
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
@@ -67,14 +67,6 @@ func isGitRepoPublic(u string) bool {
 	return resp.StatusCode == nethttp.StatusOK
 }
 
-// Check if the GITHUB_TOKEN is present
-func isGitTokenPresent(gitURL giturl.IGitAPI) bool {
-	if token := gitURL.GetToken(); token == "" {
-		return false
-	}
-	return true
-}
-
 // Get the error message according to the provider
 func getProviderError(gitURL giturl.IGitAPI) error {
 	switch gitURL.GetProvider() {
@@ -90,40 +82,40 @@ func getProviderError(gitURL giturl.IGitAPI) error {
 	return fmt.Errorf("%w", errors.New("unable to find the host name"))
 }
 
-// cloneRepo clones a repository to a local temporary directory and returns the directory
-func cloneRepo(gitURL giturl.IGitAPI) (string, error) {
-	cloneURL := gitURL.GetHttpCloneURL()
+// getCredentialsError returns the error message for a remote that requires authentication
+func getCredentialsError(remote *GitRemote) error {
+	if remote.api != nil {
+		return getProviderError(remote.api)
+	}
+	if remote.Scheme == "ssh" || remote.Scheme == "git+ssh" {
+		return fmt.Errorf("no ssh credentials found for '%s', make sure the ssh-agent is running (SSH_AUTH_SOCK)", remote.Host)
+	}
+	return fmt.Errorf("no credentials found for '%s', set %s or add the host to your netrc file", remote.Host, gitHostEnvName(gitTokenEnvPrefix, remote.Host))
+}
 
+// cloneRepo clones a repository to a local temporary directory and returns the directory
+func cloneRepo(remote *GitRemote) (string, error) {
 	// Check if directory exists
-	if p := getDirPath(cloneURL); p != "" {
+	if p := getDirPath(remote.URL()); p != "" {
 		// directory exists, meaning this repo was cloned
 		return p, nil
 	}
-	// Get the URL to clone
 
 	// Create temp directory
-	tmpDir, err := createTempDir(cloneURL)
+	tmpDir, err := createTempDir(remote.URL())
 	if err != nil {
 		return "", err
 	}
 
-	isGitTokenPresent := isGitTokenPresent(gitURL)
-
-	// Declare the authentication variable required for cloneOptions
-	var auth transport.AuthMethod
+	auth, err := resolveGitAuth(remote, GitCredentialSources)
+	if err != nil {
+		return "", err
+	}
 
-	if isGitTokenPresent {
-		auth = &http.BasicAuth{
-			Username: "x-token-auth",
-			Password: gitURL.GetToken(),
-		}
-	} else {
-		// If the repository is public, no authentication is needed
-		if isGitRepoPublic(cloneURL) {
-			auth = nil
-		} else {
-			return "", getProviderError(gitURL)
-		}
+	// Well-known providers answer a plain GET on public repositories, so we can fail early with a meaningful error.
+	// Other servers are cloned anonymously and the error is reported if authentication turns out to be required
+	if auth == nil && remote.IsWellKnownProvider() && !isGitRepoPublic(remote.CloneURL) {
+		return "", getCredentialsError(remote)
 	}
 
 	// For Azure repo cloning
@@ -131,40 +123,146 @@ func cloneRepo(gitURL giturl.IGitAPI) (string, error) {
 		capability.ThinPack,
 	}
 
-	// Clone option
-	cloneOpts := git.CloneOptions{URL: cloneURL, Auth: auth}
-	if gitURL.GetBranchName() != "" {
-		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(gitURL.GetBranchName())
-		cloneOpts.SingleBranch = true
+	// Actual clone
+	if err := cloneAtRef(tmpDir, remote, auth); err != nil {
+		if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+			return "", fmt.Errorf("failed to clone %s. %w", remote.Repo, getCredentialsError(remote))
+		}
+		return "", fmt.Errorf("failed to clone %s. %w", remote.Repo, err)
 	}
+	tmpDirPaths[hashRepoURL(remote.URL())] = tmpDir
 
-	// Actual clone
-	_, err = git.PlainClone(tmpDir, false, &cloneOpts)
+	return tmpDir, nil
+}
+
+// cloneAtRef clones the remote into dir. When a ref is requested the clone is shallow and pinned to it:
+// the ref is looked up as a branch, then as a tag, and finally as a commit
+func cloneAtRef(dir string, remote *GitRemote, auth transport.AuthMethod) error {
+	if remote.Ref == "" {
+		_, err := git.PlainClone(dir, false, &git.CloneOptions{URL: remote.CloneURL, Auth: auth})
+		return err
+	}
+
+	for _, refName := range []plumbing.ReferenceName{plumbing.NewBranchReferenceName(remote.Ref), plumbing.NewTagReferenceName(remote.Ref)} {
+		repo, err := git.PlainClone(dir, false, &git.CloneOptions{
+			URL:           remote.CloneURL,
+			Auth:          auth,
+			ReferenceName: refName,
+			SingleBranch:  true,
+			Depth:         1,
+			Tags:          git.NoTags,
+		})
+		if err == nil {
+			if refName.IsBranch() {
+				return nil
+			}
+			// tags are checked out detached, scanning requires HEAD to be a branch
+			return checkoutPinnedBranch(repo, remote.Ref, plumbing.ZeroHash)
+		}
+		if !isRefNotFound(err) {
+			return err
+		}
+		if err := resetDir(dir); err != nil {
+			return err
+		}
+	}
+
+	return cloneAtCommit(dir, remote, auth)
+}
+
+// cloneAtCommit fetches a single commit. Servers that do not allow fetching a commit by its hash fall back to a full fetch
+func cloneAtCommit(dir string, remote *GitRemote, auth transport.AuthMethod) error {
+	repo, err := git.PlainInit(dir, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to clone %s. %w", gitURL.GetRepoName(), err)
+		return err
+	}
+	origin, err := repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{remote.CloneURL}})
+	if err != nil {
+		return err
 	}
-	// tmpDir = filepath.Join(tmpDir, gitURL.GetRepoName())
-	tmpDirPaths[hashRepoURL(cloneURL)] = tmpDir
 
-	return tmpDir, nil
+	fetched := false
+	if plumbing.IsHash(remote.Ref) {
+		err = origin.Fetch(&git.FetchOptions{
+			RefSpecs: []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:refs/heads/%s", remote.Ref, remote.Ref))},
+			Auth:     auth,
+			Depth:    1,
+			Tags:     git.NoTags,
+		})
+		switch {
+		case err == nil:
+			fetched = true
+		case !errors.Is(err, git.ErrExactSHA1NotSupported):
+			return err
+		}
+	}
+	if !fetched {
+		err = origin.Fetch(&git.FetchOptions{
+			RefSpecs: []config.RefSpec{config.RefSpec("+refs/heads/*:refs/remotes/origin/*")},
+			Auth:     auth,
+			Tags:     git.NoTags,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return err
+		}
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(remote.Ref))
+	if err != nil {
+		return fmt.Errorf("ref '%s' not found: %w", remote.Ref, err)
+	}
+	return checkoutPinnedBranch(repo, remote.Ref, *hash)
+}
+
+// checkoutPinnedBranch checks out a local branch named after the requested ref
+func checkoutPinnedBranch(repo *git.Repository, ref string, hash plumbing.Hash) error {
+	if hash.IsZero() {
+		head, err := repo.Head()
+		if err != nil {
+			return err
+		}
+		hash = head.Hash()
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	branch := plumbing.NewBranchReferenceName(ref)
+	if _, err := repo.Reference(branch, false); err == nil {
+		return wt.Checkout(&git.CheckoutOptions{Branch: branch, Force: true})
+	}
+	return wt.Checkout(&git.CheckoutOptions{Hash: hash, Branch: branch, Create: true, Force: true})
+}
+
+func isRefNotFound(err error) bool {
+	var noMatchingRefSpec git.NoMatchingRefSpecError
+	return errors.As(err, &noMatchingRefSpec) || errors.Is(err, plumbing.ErrReferenceNotFound)
+}
+
+// resetDir empties a directory left behind by a failed clone attempt
+func resetDir(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, 0750)
 }
 
 // CloneGitRepo clone git repository
 func CloneGitRepo(path *string) (string, error) {
 	var clonedDir string
 
-	gitURL, err := giturl.NewGitAPI(*path)
+	remote, err := ParseGitRemote(*path)
 	if err != nil {
 		return "", nil
 	}
 
 	// Clone git repository if needed
-	logger.L().Start("cloning", helpers.String("repository url", gitURL.GetURL().String()))
+	logger.L().Start("cloning", helpers.String("repository url", remote.CloneURL), helpers.String("ref", remote.Ref))
 
-	clonedDir, err = cloneRepo(gitURL)
+	clonedDir, err = cloneRepo(remote)
 	if err != nil {
-		logger.L().StopError("failed to clone git repo", helpers.String("url", gitURL.GetURL().String()), helpers.Error(err))
-		return "", fmt.Errorf("failed to clone git repo '%s',  %w", gitURL.GetURL().String(), err)
+		logger.L().StopError("failed to clone git repo", helpers.String("url", remote.CloneURL), helpers.Error(err))
+		return "", fmt.Errorf("failed to clone git repo '%s',  %w", remote.CloneURL, err)
 	}
 	*path = clonedDir
 
@@ -175,10 +273,10 @@ func CloneGitRepo(path *string) (string, error) {
 
 func GetClonedPath(path string) string {
 
-	gitURL, err := giturl.NewGitAPI(path)
+	remote, err := ParseGitRemote(path)
 	if err != nil {
 		return ""
 	}
 
-	return getDirPath(gitURL.GetHttpCloneURL())
+	return getDirPath(remote.URL())
 }
@@ -4,10 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	giturl "github.com/kubescape/go-git-url"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsGitRepoPublic(t *testing.T) {
@@ -86,8 +92,8 @@ func TestCloneRepo(t *testing.T) {
 				t.Fatalf("failed to create temporary directory: %v", err)
 			}
 
-			gitURL, _ := giturl.NewGitAPI(tt.url)
-			tempDir, err := cloneRepo(gitURL)
+			remote, _ := ParseGitRemote(tt.url)
+			tempDir, err := cloneRepo(remote)
 			assert.NotEqual(t, tmpDir, tempDir)
 			assert.Equal(t, tt.err, err)
 		})
@@ -153,3 +159,99 @@ func TestGetDirPath(t *testing.T) {
 		})
 	}
 }
+
+// newTestRemoteRepository creates a local repository with a branch, a tag and two commits, to be cloned through file://
+func newTestRemoteRepository(t *testing.T) (string, []plumbing.Hash) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	var commits []plumbing.Hash
+	for _, name := range []string{"first.yaml", "second.yaml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("kind: ConfigMap\n"), 0600))
+		_, err = wt.Add(name)
+		require.NoError(t, err)
+		hash, err := wt.Commit("add "+name, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+		require.NoError(t, err)
+		commits = append(commits, hash)
+	}
+	_, err = repo.CreateTag("v1", commits[0], nil)
+	require.NoError(t, err)
+
+	return dir, commits
+}
+
+func TestCloneAtRef(t *testing.T) {
+	repoDir, commits := newTestRemoteRepository(t)
+
+	tests := []struct {
+		name          string
+		ref           string
+		wantCommit    plumbing.Hash
+		wantSecondYml bool
+	}{
+		{
+			name:          "default branch",
+			wantCommit:    commits[1],
+			wantSecondYml: true,
+		},
+		{
+			name:          "branch",
+			ref:           "master",
+			wantCommit:    commits[1],
+			wantSecondYml: true,
+		},
+		{
+			name:       "tag",
+			ref:        "v1",
+			wantCommit: commits[0],
+		},
+		{
+			name:       "commit",
+			ref:        commits[0].String(),
+			wantCommit: commits[0],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "file://" + filepath.ToSlash(repoDir)
+			if tt.ref != "" {
+				input += "#" + tt.ref
+			}
+			remote, err := ParseGitRemote(input)
+			require.NoError(t, err)
+
+			dir := t.TempDir()
+			require.NoError(t, cloneAtRef(dir, remote, nil))
+
+			localRepo, err := NewLocalGitRepository(dir)
+			require.NoError(t, err)
+			commit, err := localRepo.GetLastCommit()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCommit.String(), commit.SHA)
+
+			_, err = os.Stat(filepath.Join(dir, "second.yaml"))
+			assert.Equal(t, tt.wantSecondYml, err == nil)
+		})
+	}
+}
+
+func TestCloneAtRefNotFound(t *testing.T) {
+	repoDir, _ := newTestRemoteRepository(t)
+
+	remote, err := ParseGitRemote("file://" + filepath.ToSlash(repoDir) + "#missing")
+	require.NoError(t, err)
+	assert.Error(t, cloneAtRef(t.TempDir(), remote, nil))
+}
+
+func TestGetCredentialsError(t *testing.T) {
+	remote, err := ParseGitRemote("https://gitea.example.com/org/repo.git")
+	require.NoError(t, err)
+	assert.ErrorContains(t, getCredentialsError(remote), "KS_GIT_TOKEN_GITEA_EXAMPLE_COM")
+
+	remote, err = ParseGitRemote("https://github.com/kubescape/kubescape")
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Errorf("%w", errors.New("GITHUB_TOKEN is not present")), getCredentialsError(remote))
+}
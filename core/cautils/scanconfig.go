@@ -0,0 +1,259 @@
+package cautils
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ScanConfigFilename   = ".kubescape.yaml"
+	ScanConfigAPIVersion = "kubescape.io/v1"
+	ScanConfigKind       = "ScanConfig"
+
+	ScanConfigEnvVar          = "KS_CONFIG"
+	frameworksEnvVar          = "KS_FRAMEWORKS"
+	controlsConfigEnvVar      = "KS_CONTROLS_CONFIG"
+	exceptionsEnvVar          = "KS_EXCEPTIONS"
+	complianceThresholdEnvVar = "KS_COMPLIANCE_THRESHOLD"
+	severityThresholdEnvVar   = "KS_SEVERITY_THRESHOLD"
+)
+
+// ScanConfig declares the scan settings of a repository in a single file. The env vars override the values of the file, and
+// the command line flags override both
+type ScanConfig struct {
+	APIVersion        string                    `json:"apiVersion,omitempty"`
+	Kind              string                    `json:"kind,omitempty"`
+	Frameworks        []string                  `json:"frameworks,omitempty"`        // frameworks scanned when none are given
+	ControlsConfig    string                    `json:"controlsConfig,omitempty"`    // path to the controls inputs file
+	ControlsInputs    map[string][]string       `json:"controlsInputs,omitempty"`    // controls inputs overriding the ones of the controls inputs file
+	Exceptions        string                    `json:"exceptions,omitempty"`        // path to the exceptions file
+	Thresholds        ScanThresholds            `json:"thresholds,omitempty"`        // thresholds the scan fails at
+	ExcludeNamespaces []string                  `json:"excludeNamespaces,omitempty"` // namespaces excluded from the scan
+	Controls          []getter.ControlReference `json:"controls,omitempty"`          // per-control severity, score factor and controls inputs overrides
+	Paths             []PathRule                `json:"paths,omitempty"`             // rules of the scanned files, by path
+
+	path string
+}
+
+type ScanThresholds struct {
+	Compliance *float32 `json:"compliance,omitempty"` // compliance score below which the scan fails
+	Severity   string   `json:"severity,omitempty"`   // severity of failed controls at which the scan fails
+}
+
+// PathRule applies to the scanned files matching the path, relative to the directory of the config file. Patterns ending with
+// a "/" or "/**" match directories, the other patterns are matched as globs against the path and the file name
+type PathRule struct {
+	Path   string `json:"path"`
+	Ignore bool   `json:"ignore,omitempty"` // the files are not scanned
+}
+
+// LoadScanConfig loads the scan config file. The relative paths of the config are resolved from the directory of the file
+func LoadScanConfig(path string) (*ScanConfig, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config := &ScanConfig{}
+	if err := yaml.UnmarshalStrict(buf, config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if config.Kind != "" && config.Kind != ScanConfigKind {
+		return nil, fmt.Errorf("config file %s: unsupported kind '%s', expected %s", path, config.Kind, ScanConfigKind)
+	}
+	for _, ref := range config.Controls {
+		if err := ref.Validate(); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	for _, rule := range config.Paths {
+		if rule.Path == "" {
+			return nil, fmt.Errorf("config file %s: path rule without path", path)
+		}
+	}
+
+	config.path = path
+	dir := filepath.Dir(path)
+	config.ControlsConfig = resolvePath(dir, config.ControlsConfig)
+	config.Exceptions = resolvePath(dir, config.Exceptions)
+	return config, nil
+}
+
+// FindScanConfig returns the path of the scan config file: the path of the KS_CONFIG env var, or the config file at the root
+// of the git repository of the directory, or in the directory. It returns an empty path when there is no config file
+func FindScanConfig(dir string) string {
+	if path := os.Getenv(ScanConfigEnvVar); path != "" {
+		return path
+	}
+
+	dirs := []string{dir}
+	if repo, err := NewLocalGitRepository(dir); err == nil {
+		if root, err := repo.GetRootDir(); err == nil {
+			dirs = append([]string{root}, dirs...)
+		}
+	}
+	for _, d := range dirs {
+		if path := filepath.Join(d, ScanConfigFilename); isFile(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// GetScanConfig returns the scan config of the file, or of the config file discovered from the directory when the path is
+// empty, with the values of the env vars applied. The config is empty when there is no config file
+func GetScanConfig(path, dir string) (*ScanConfig, error) {
+	if path == "" {
+		path = FindScanConfig(dir)
+	}
+
+	config := &ScanConfig{}
+	if path != "" {
+		var err error
+		if config, err = LoadScanConfig(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Path returns the path of the config file
+func (c *ScanConfig) Path() string {
+	return c.path
+}
+
+// ApplyEnv overrides the values of the config with the values of the env vars
+func (c *ScanConfig) ApplyEnv() error {
+	if frameworks := os.Getenv(frameworksEnvVar); frameworks != "" {
+		c.Frameworks = strings.Split(frameworks, ",")
+	}
+	if controlsConfig := os.Getenv(controlsConfigEnvVar); controlsConfig != "" {
+		c.ControlsConfig = controlsConfig
+	}
+	if exceptions := os.Getenv(exceptionsEnvVar); exceptions != "" {
+		c.Exceptions = exceptions
+	}
+	if compliance := os.Getenv(complianceThresholdEnvVar); compliance != "" {
+		threshold, err := strconv.ParseFloat(compliance, 32)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s env var as float: %w", complianceThresholdEnvVar, err)
+		}
+		c.Thresholds.Compliance = ptrFloat32(float32(threshold))
+	}
+	if severity := os.Getenv(severityThresholdEnvVar); severity != "" {
+		c.Thresholds.Severity = severity
+	}
+	return nil
+}
+
+// IsIgnoredPath returns true if a path rule ignores the file, the path is relative to the directory of the config file
+func (c *ScanConfig) IsIgnoredPath(path string) bool {
+	for _, rule := range c.Paths {
+		if rule.Ignore && MatchPathPattern(rule.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIgnoredFile returns true if a path rule ignores the file. The files outside the directory of the config file are not ignored
+func (c *ScanConfig) IsIgnoredFile(file string) bool {
+	dir, err := filepath.Abs(filepath.Dir(c.path))
+	if err != nil {
+		return false
+	}
+	path, err := filepath.Rel(dir, file)
+	if err != nil || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return c.IsIgnoredPath(path)
+}
+
+// OverridePolicies returns the frameworks with the per-control overrides applied to their controls
+func (c *ScanConfig) OverridePolicies(frameworks []reporthandling.Framework) []reporthandling.Framework {
+	if len(c.Controls) == 0 {
+		return frameworks
+	}
+	overrides := make(map[string]getter.ControlReference, len(c.Controls))
+	for _, ref := range c.Controls {
+		overrides[strings.ToUpper(ref.ID)] = ref
+	}
+
+	overridden := make([]reporthandling.Framework, 0, len(frameworks))
+	for _, framework := range frameworks {
+		controls := make([]reporthandling.Control, 0, len(framework.Controls))
+		for _, control := range framework.Controls {
+			if ref, ok := overrides[strings.ToUpper(control.ControlID)]; ok {
+				control = ref.Apply(control)
+			}
+			controls = append(controls, control)
+		}
+		framework.Controls = controls
+		overridden = append(overridden, framework)
+	}
+	return overridden
+}
+
+// OverrideControlsInputs returns the controls inputs merged with the controls inputs of the config
+func (c *ScanConfig) OverrideControlsInputs(controlsInputs map[string][]string) map[string][]string {
+	if len(c.ControlsInputs) == 0 {
+		return controlsInputs
+	}
+	merged := make(map[string][]string, len(controlsInputs)+len(c.ControlsInputs))
+	maps.Copy(merged, controlsInputs)
+	maps.Copy(merged, c.ControlsInputs)
+	return merged
+}
+
+// String returns the YAML representation of the config
+func (c *ScanConfig) String() string {
+	buf, err := yaml.Marshal(c)
+	if err != nil {
+		return err.Error()
+	}
+	return string(buf)
+}
+
+// MatchPathPattern returns true if the slash separated path matches the pattern. A pattern ending with a "/" or "/**" matches
+// the files of a directory, anywhere in the tree unless the pattern starts with a "/". The other patterns are globs matched
+// against the path and the file name
+func MatchPathPattern(pattern, path string) bool {
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok || strings.HasSuffix(pattern, "/") {
+		dir = strings.TrimSuffix(dir, "/")
+		if anchored, ok := strings.CutPrefix(dir, "/"); ok {
+			return strings.HasPrefix(path, anchored+"/")
+		}
+		return strings.HasPrefix(path, dir+"/") || strings.Contains(path, "/"+dir+"/")
+	}
+
+	pattern = strings.TrimPrefix(pattern, "/")
+	if matched, _ := filepath.Match(pattern, path); matched {
+		return true
+	}
+	matched, _ := filepath.Match(pattern, filepath.Base(path))
+	return matched
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func ptrFloat32(f float32) *float32 {
+	return &f
+}
@@ -0,0 +1,210 @@
+package cautils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScanConfig = `apiVersion: kubescape.io/v1
+kind: ScanConfig
+frameworks:
+- nsa
+- mitre
+controlsConfig: controls-inputs.json
+exceptions: /etc/kubescape/exceptions.json
+controlsInputs:
+  trustedCosignPublicKeys:
+  - key
+thresholds:
+  compliance: 80
+  severity: high
+excludeNamespaces:
+- kube-system
+controls:
+- id: C-0017
+  severity: critical
+- id: c-0002
+  scoreFactor: 2
+  controlsInputs:
+    sensitiveKeyNames:
+    - token
+paths:
+- path: vendor/
+- path: tests/**
+  ignore: true
+- path: "*_test.yaml"
+  ignore: true
+`
+
+func writeScanConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, ScanConfigFilename)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadScanConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := writeScanConfig(t, dir, testScanConfig)
+
+	config, err := LoadScanConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, path, config.Path())
+	assert.Equal(t, []string{"nsa", "mitre"}, config.Frameworks)
+	assert.Equal(t, filepath.Join(dir, "controls-inputs.json"), config.ControlsConfig)
+	assert.Equal(t, "/etc/kubescape/exceptions.json", config.Exceptions)
+	require.NotNil(t, config.Thresholds.Compliance)
+	assert.Equal(t, float32(80), *config.Thresholds.Compliance)
+	assert.Equal(t, "high", config.Thresholds.Severity)
+	assert.Equal(t, []string{"kube-system"}, config.ExcludeNamespaces)
+	assert.Len(t, config.Controls, 2)
+	assert.Equal(t, []PathRule{{Path: "vendor/"}, {Path: "tests/**", Ignore: true}, {Path: "*_test.yaml", Ignore: true}}, config.Paths)
+
+	t.Run("invalid config", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"unknown field":    "framework: nsa",
+			"wrong kind":       "kind: FrameworkDefinition",
+			"path without pat": "paths:\n- ignore: true",
+			"invalid severity": "controls:\n- id: C-0017\n  severity: urgent",
+			"control without":  "controls:\n- severity: high",
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := LoadScanConfig(writeScanConfig(t, t.TempDir(), content))
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadScanConfig(filepath.Join(dir, "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestGetScanConfig(t *testing.T) {
+	t.Run("no config file", func(t *testing.T) {
+		t.Setenv(ScanConfigEnvVar, "")
+		config, err := GetScanConfig("", t.TempDir())
+		require.NoError(t, err)
+		assert.Empty(t, config.Path())
+		assert.Empty(t, config.Frameworks)
+	})
+
+	t.Run("config file in the directory", func(t *testing.T) {
+		t.Setenv(ScanConfigEnvVar, "")
+		dir := t.TempDir()
+		path := writeScanConfig(t, dir, testScanConfig)
+		config, err := GetScanConfig("", dir)
+		require.NoError(t, err)
+		assert.Equal(t, path, config.Path())
+	})
+
+	t.Run("config file of the env var", func(t *testing.T) {
+		path := writeScanConfig(t, t.TempDir(), testScanConfig)
+		t.Setenv(ScanConfigEnvVar, path)
+		config, err := GetScanConfig("", t.TempDir())
+		require.NoError(t, err)
+		assert.Equal(t, path, config.Path())
+	})
+
+	t.Run("env vars override the config file", func(t *testing.T) {
+		t.Setenv(ScanConfigEnvVar, "")
+		t.Setenv(frameworksEnvVar, "cis-v1.23-t1.0.1")
+		t.Setenv(controlsConfigEnvVar, "inputs.json")
+		t.Setenv(exceptionsEnvVar, "exceptions.json")
+		t.Setenv(complianceThresholdEnvVar, "95.5")
+		t.Setenv(severityThresholdEnvVar, "critical")
+
+		config, err := GetScanConfig(writeScanConfig(t, t.TempDir(), testScanConfig), "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cis-v1.23-t1.0.1"}, config.Frameworks)
+		assert.Equal(t, "inputs.json", config.ControlsConfig)
+		assert.Equal(t, "exceptions.json", config.Exceptions)
+		assert.Equal(t, float32(95.5), *config.Thresholds.Compliance)
+		assert.Equal(t, "critical", config.Thresholds.Severity)
+	})
+
+	t.Run("invalid env var", func(t *testing.T) {
+		t.Setenv(ScanConfigEnvVar, "")
+		t.Setenv(complianceThresholdEnvVar, "high")
+		_, err := GetScanConfig("", t.TempDir())
+		assert.Error(t, err)
+	})
+}
+
+func TestMatchPathPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"vendor/", "vendor/deployment.yaml", true},
+		{"vendor/", "charts/vendor/deployment.yaml", true},
+		{"vendor/", "vendored/deployment.yaml", false},
+		{"tests/**", "./tests/fixtures/pod.yaml", true},
+		{"/tests/**", "tests/pod.yaml", true},
+		{"/tests/**", "app/tests/pod.yaml", false},
+		{"*_test.yaml", "app/pod_test.yaml", true},
+		{"*_test.yaml", "app/pod.yaml", false},
+		{"app/*.yaml", "app/pod.yaml", true},
+		{"app/*.yaml", "app/sub/pod.yaml", false},
+		{"/app/pod.yaml", "app/pod.yaml", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchPathPattern(tt.pattern, tt.path))
+		})
+	}
+}
+
+func TestScanConfigOverrides(t *testing.T) {
+	config, err := LoadScanConfig(writeScanConfig(t, t.TempDir(), testScanConfig))
+	require.NoError(t, err)
+
+	t.Run("policies", func(t *testing.T) {
+		frameworks := []reporthandling.Framework{{
+			Controls: []reporthandling.Control{
+				{ControlID: "C-0002", BaseScore: 8, FixedInput: map[string][]string{"sensitiveValues": {"secret"}}},
+				{ControlID: "C-0017", BaseScore: 4},
+				{ControlID: "C-0034", BaseScore: 6},
+			},
+		}}
+
+		overridden := config.OverridePolicies(frameworks)
+		require.Len(t, overridden, 1)
+		assert.Equal(t, float32(2), overridden[0].Controls[0].BaseScore)
+		assert.Equal(t, map[string][]string{"sensitiveValues": {"secret"}, "sensitiveKeyNames": {"token"}}, overridden[0].Controls[0].FixedInput)
+		assert.Equal(t, float32(9), overridden[0].Controls[1].BaseScore)
+		assert.Equal(t, float32(6), overridden[0].Controls[2].BaseScore)
+
+		// the frameworks of the policy handler are not modified
+		assert.Equal(t, float32(8), frameworks[0].Controls[0].BaseScore)
+		assert.Equal(t, float32(4), frameworks[0].Controls[1].BaseScore)
+	})
+
+	t.Run("controls inputs", func(t *testing.T) {
+		controlsInputs := map[string][]string{"trustedCosignPublicKeys": {}, "imageRepositoryAllowList": {"quay.io"}}
+		merged := config.OverrideControlsInputs(controlsInputs)
+		assert.Equal(t, []string{"key"}, merged["trustedCosignPublicKeys"])
+		assert.Equal(t, []string{"quay.io"}, merged["imageRepositoryAllowList"])
+		assert.Empty(t, controlsInputs["trustedCosignPublicKeys"])
+	})
+
+	t.Run("ignored paths", func(t *testing.T) {
+		assert.True(t, config.IsIgnoredPath("tests/pod.yaml"))
+		assert.True(t, config.IsIgnoredPath("app/pod_test.yaml"))
+		assert.False(t, config.IsIgnoredPath("vendor/pod.yaml"))
+		assert.False(t, config.IsIgnoredPath("app/pod.yaml"))
+
+		dir := filepath.Dir(config.Path())
+		assert.True(t, config.IsIgnoredFile(filepath.Join(dir, "tests", "pod.yaml")))
+		assert.False(t, config.IsIgnoredFile(filepath.Join(dir, "app", "pod.yaml")))
+		assert.False(t, config.IsIgnoredFile(filepath.Join(filepath.Dir(dir), "tests", "pod.yaml")))
+	})
+}
@@ -29,6 +29,7 @@ const (
 	ContextDir       ScanningContext = "local-dir"
 	ContextGitLocal  ScanningContext = "git-local"
 	ContextGitRemote ScanningContext = "git-remote"
+	ContextSnapshot  ScanningContext = "cluster-snapshot"
 )
 
 const ( // deprecated
@@ -107,6 +108,8 @@ type ScanInfo struct {
 	UseFrom               []string                     // Load framework from local file (instead of download). Use when running offline
 	UseDefault            bool                         // Load framework from cached file (instead of download). Use when running offline
 	UseArtifactsFrom      string                       // Load artifacts from local path. Use when running offline
+	PoliciesPublicKey     string                       // Cosign public key verifying the signature of the checksum manifest of the policies
+	RequireSignedPolicies bool                         // Refuse policies which are not verified against a checksum manifest
 	VerboseMode           bool                         // Display all the input resources and not only failed resources
 	View                  string                       //
 	Format                string                       // Format results (table, json, junit ...)
@@ -139,7 +142,26 @@ type ScanInfo struct {
 	ScanImages            bool
 	ChartPath             string
 	FilePath              string
+	SnapshotPath          string      // Scan an offline cluster snapshot (resources dump directory or archive) instead of a live cluster
+	KubeContexts          []string    // Scan several clusters, identified by their kube contexts, in one run
+	AllKubeContexts       bool        // Scan all the clusters of the kubeconfig
+	ParallelClusters      int         // Maximum number of clusters scanned concurrently
+	LabelSelector         string      // Scan only the workloads matching the Kubernetes label selector
+	AnnotationSelector    string      // Scan only the workloads whose annotations match the selector, uses the label selector syntax
+	PageSize              int64       // Number of objects of each list call to the API server
+	StoreResourcesOnDisk  bool        // Keep the pulled objects in a disk-backed store instead of memory, for very large clusters
+	ConfigFile            string      // Path to the scan config file, discovered in the repository root when not set
+	ScanConfig            *ScanConfig // Scan config, merged into the scan info with a lower precedence than the flags
+	MarkdownMaxSize       int         // Maximum size of the markdown output, to fit in the comments of pull requests. No limit when 0
+	OTLPEndpoint          string      // Base URL of the OpenTelemetry collector of the otlp format. The OTEL_EXPORTER_OTLP_* environment variables are used when empty
+	PrometheusNamespaces  bool        // Add the metrics of each namespace to the prometheus format
+	PrometheusWorkloads   bool        // Add the metrics of each workload to the prometheus format
+	PrometheusMaxSeries   int         // Maximum number of series of the namespaces and workloads metrics of the prometheus format. No limit when 0
+	PrometheusAllowList   []string    // Names or glob patterns of the namespaces of the namespaces and workloads metrics of the prometheus format. All when empty
+	KeepHistory           bool        // Record the scan in the local history store
+	HistoryFile           string      // Path to the history store, implies KeepHistory. $HOME/.kubescape/history.db when empty
 	scanningContext       *ScanningContext
+	snapshot              *ClusterSnapshot
 	cleanups              []func()
 }
 
@@ -162,10 +184,16 @@ func (scanInfo *ScanInfo) Cleanup() {
 	for _, cleanup := range scanInfo.cleanups {
 		cleanup()
 	}
+	scanInfo.cleanups = nil
+}
+
+// AddCleanup registers a function releasing a resource of the scan, called by Cleanup
+func (scanInfo *ScanInfo) AddCleanup(cleanup func()) {
+	scanInfo.cleanups = append(scanInfo.cleanups, cleanup)
 }
 
 func (scanInfo *ScanInfo) setUseArtifactsFrom(ctx context.Context) {
-	if scanInfo.UseArtifactsFrom == "" {
+	if scanInfo.UseArtifactsFrom == "" || getter.IsOCIReference(scanInfo.UseArtifactsFrom) {
 		return
 	}
 	// UseArtifactsFrom must be a path without a filename
@@ -185,7 +213,7 @@ func (scanInfo *ScanInfo) setUseArtifactsFrom(ctx context.Context) {
 		filePath := filepath.Join(scanInfo.UseArtifactsFrom, f.Name())
 		file, err := os.ReadFile(filePath)
 		if err == nil {
-			if err := json.Unmarshal(file, framework); err == nil {
+			if err := json.Unmarshal(file, framework); err == nil || getter.IsFrameworkDefinition(file) {
 				scanInfo.UseFrom = append(scanInfo.UseFrom, filepath.Join(scanInfo.UseArtifactsFrom, f.Name()))
 			}
 		}
@@ -274,7 +302,7 @@ func scanInfoToScanMetadata(ctx context.Context, scanInfo *ScanInfo) *reporthand
 	metadata.ScanMetadata.ControlsInputs = scanInfo.ControlsInputs
 
 	switch scanInfo.GetScanningContext() {
-	case ContextCluster:
+	case ContextCluster, ContextSnapshot:
 		// cluster
 		metadata.ScanMetadata.ScanningTarget = reporthandlingv2.Cluster
 	case ContextFile:
@@ -304,6 +332,18 @@ func (scanInfo *ScanInfo) GetInputFiles() string {
 	return ""
 }
 
+// GetClusterSnapshot loads the cluster snapshot to be scanned. The snapshot is read only once
+func (scanInfo *ScanInfo) GetClusterSnapshot() (*ClusterSnapshot, error) {
+	if scanInfo.snapshot == nil {
+		snapshot, err := ReadClusterSnapshot(scanInfo.SnapshotPath)
+		if err != nil {
+			return nil, err
+		}
+		scanInfo.snapshot = snapshot
+	}
+	return scanInfo.snapshot, nil
+}
+
 func (scanInfo *ScanInfo) GetScanningContext() ScanningContext {
 	if scanInfo.scanningContext == nil {
 		scanningContext := scanInfo.getScanningContext(scanInfo.GetInputFiles())
@@ -315,13 +355,18 @@ func (scanInfo *ScanInfo) GetScanningContext() ScanningContext {
 // getScanningContext get scanning context from the input param
 // this function should be called only once. Call GetScanningContext() to get the scanning context
 func (scanInfo *ScanInfo) getScanningContext(input string) ScanningContext {
+	// offline cluster snapshot
+	if scanInfo.SnapshotPath != "" {
+		return ContextSnapshot
+	}
+
 	//  cluster
 	if input == "" {
 		return ContextCluster
 	}
 
 	// git url
-	if _, err := giturl.NewGitURL(input); err == nil {
+	if IsGitRemoteURL(input) {
 		if repo, err := CloneGitRepo(&input); err == nil {
 			if _, err := NewLocalGitRepository(repo); err == nil {
 				scanInfo.cleanups = append(scanInfo.cleanups, func() {
@@ -359,6 +404,35 @@ func (scanInfo *ScanInfo) setContextMetadata(ctx context.Context, contextMetadat
 		contextMetadata.ClusterContextMetadata = &reporthandlingv2.ClusterMetadata{
 			ContextName: k8sinterface.GetContextName(),
 		}
+	case ContextSnapshot:
+		// scanned as a cluster, the repo metadata marks the source as a snapshot and not a live cluster
+		snapshotPath := getAbsPath(scanInfo.SnapshotPath)
+		contextName := scanInfo.CustomClusterName
+		if snapshot, err := scanInfo.GetClusterSnapshot(); err == nil && contextName == "" {
+			contextName = snapshot.GetClusterName()
+		}
+		contextMetadata.ClusterContextMetadata = &reporthandlingv2.ClusterMetadata{
+			ContextName: contextName,
+		}
+		if isDir(snapshotPath) {
+			contextMetadata.DirectoryContextMetadata = &reporthandlingv2.DirectoryContextMetadata{
+				BasePath: snapshotPath,
+				HostName: getHostname(),
+			}
+		} else {
+			contextMetadata.FileContextMetadata = &reporthandlingv2.FileContextMetadata{
+				FilePath: snapshotPath,
+				HostName: getHostname(),
+			}
+		}
+		contextMetadata.RepoContextMetadata = &reporthandlingv2.RepoContextMetadata{
+			Provider:      string(ContextSnapshot),
+			Repo:          fmt.Sprintf("%s@%s", ContextSnapshot, snapshotPath),
+			Owner:         getHostname(),
+			Branch:        "none",
+			DefaultBranch: "none",
+			LocalRootPath: snapshotPath,
+		}
 	case ContextDir:
 		contextMetadata.DirectoryContextMetadata = &reporthandlingv2.DirectoryContextMetadata{
 			BasePath: getAbsPath(input),
@@ -415,17 +489,24 @@ func metadataGitLocal(input string) (*reporthandlingv2.RepoContextMetadata, erro
 		return nil, fmt.Errorf("%w", err)
 	}
 	repoContext := &reporthandlingv2.RepoContextMetadata{}
-	gitParserURL, err := giturl.NewGitURL(remoteURL)
-	if err != nil {
+	if gitParserURL, err := giturl.NewGitURL(remoteURL); err == nil {
+		gitParserURL.SetBranchName(gitParser.GetBranchName())
+
+		repoContext.Provider = gitParserURL.GetProvider()
+		repoContext.Repo = gitParserURL.GetRepoName()
+		repoContext.Owner = gitParserURL.GetOwnerName()
+		repoContext.Branch = gitParserURL.GetBranchName()
+		repoContext.RemoteURL = gitParserURL.GetURL().String()
+	} else if remote, err := ParseGitRemote(remoteURL); err == nil {
+		// self-hosted or generic git server
+		repoContext.Provider = remote.Provider
+		repoContext.Repo = remote.Repo
+		repoContext.Owner = remote.Owner
+		repoContext.Branch = gitParser.GetBranchName()
+		repoContext.RemoteURL = remote.CloneURL
+	} else {
 		return repoContext, fmt.Errorf("%w", err)
 	}
-	gitParserURL.SetBranchName(gitParser.GetBranchName())
-
-	repoContext.Provider = gitParserURL.GetProvider()
-	repoContext.Repo = gitParserURL.GetRepoName()
-	repoContext.Owner = gitParserURL.GetOwnerName()
-	repoContext.Branch = gitParserURL.GetBranchName()
-	repoContext.RemoteURL = gitParserURL.GetURL().String()
 
 	commit, err := gitParser.GetLastCommit()
 	if err != nil {
@@ -23,6 +23,21 @@ func TestSetContextMetadata(t *testing.T) {
 		assert.Nil(t, ctx.HelmContextMetadata)
 		assert.Nil(t, ctx.RepoContextMetadata)
 	}
+	{
+		ctx := reporthandlingv2.ContextMetadata{}
+		scanInfo := &ScanInfo{SnapshotPath: writeSnapshotDir(t)}
+		require.Equal(t, ContextSnapshot, scanInfo.GetScanningContext())
+		scanInfo.setContextMetadata(context.TODO(), &ctx)
+
+		require.NotNil(t, ctx.ClusterContextMetadata)
+		assert.Equal(t, "prod", ctx.ClusterContextMetadata.ContextName)
+		assert.NotNil(t, ctx.DirectoryContextMetadata)
+		assert.Nil(t, ctx.FileContextMetadata)
+		require.NotNil(t, ctx.RepoContextMetadata)
+		assert.Equal(t, "cluster-snapshot", ctx.RepoContextMetadata.Provider)
+		assert.True(t, IsClusterSnapshot(&ctx))
+		assert.False(t, IsClusterSnapshot(&reporthandlingv2.ContextMetadata{ClusterContextMetadata: ctx.ClusterContextMetadata}))
+	}
 	// TODO: tests were commented out due to actual http calls ; http calls should be mocked.
 	/*{
 		ctx := reporthandlingv2.ContextMetadata{}
@@ -0,0 +1,278 @@
+package cautils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// SnapshotMetadataFilename is the name of the metadata file written at the root of a cluster snapshot
+const SnapshotMetadataFilename = "snapshot.json"
+
+// SnapshotMetadata describes the cluster a snapshot was taken from.
+// Snapshots produced by other tools (kubectl dumps, must-gather archives) have no metadata file
+type SnapshotMetadata struct {
+	ClusterName      string        `json:"clusterName,omitempty"`
+	CreationTime     time.Time     `json:"creationTime,omitempty"`
+	KubescapeVersion string        `json:"kubescapeVersion,omitempty"`
+	APIServerInfo    *version.Info `json:"apiServerInfo,omitempty"`
+	Frameworks       []string      `json:"frameworks,omitempty"`
+}
+
+// ClusterSnapshot is an offline copy of the resources of a cluster: a single yaml/json dump,
+// a directory tree or a tar/tar.gz archive of yaml/json files
+type ClusterSnapshot struct {
+	Path      string
+	Metadata  SnapshotMetadata
+	Resources map[string][]workloadinterface.IMetadata // source file in the snapshot -> objects
+}
+
+// ReadClusterSnapshot loads all the objects of a cluster snapshot
+func ReadClusterSnapshot(path string) (*ClusterSnapshot, error) {
+	snapshot := &ClusterSnapshot{
+		Path:      getAbsPath(path),
+		Resources: map[string][]workloadinterface.IMetadata{},
+	}
+
+	var err error
+	switch {
+	case isDir(path):
+		err = snapshot.readDir(path)
+	case IsSnapshotArchive(path):
+		err = snapshot.readArchive(path)
+	case isFile(path):
+		var content []byte
+		if content, err = os.ReadFile(path); err == nil {
+			err = snapshot.addFile(filepath.Base(path), content)
+		}
+	default:
+		err = fmt.Errorf("snapshot '%s' not found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster snapshot: %w", err)
+	}
+
+	if len(snapshot.Resources) == 0 {
+		return nil, fmt.Errorf("no Kubernetes objects found in snapshot '%s'", path)
+	}
+	return snapshot, nil
+}
+
+// NewClusterSnapshot groups resources into the files of a snapshot: one JSON List per namespace and kind
+func NewClusterSnapshot(metadata SnapshotMetadata, resources []workloadinterface.IMetadata) *ClusterSnapshot {
+	snapshot := &ClusterSnapshot{
+		Metadata:  metadata,
+		Resources: map[string][]workloadinterface.IMetadata{},
+	}
+	for _, resource := range resources {
+		name := snapshotFileName(resource)
+		snapshot.Resources[name] = append(snapshot.Resources[name], resource)
+	}
+	return snapshot
+}
+
+// WriteArchive writes the snapshot as a tar.gz archive that can be scanned with ReadClusterSnapshot
+func (s *ClusterSnapshot) WriteArchive(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	metadata, err := json.MarshalIndent(s.Metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, SnapshotMetadataFilename, metadata); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(s.Resources))
+	for name := range s.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		items := make([]map[string]interface{}, 0, len(s.Resources[name]))
+		for _, resource := range s.Resources[name] {
+			items = append(items, resource.GetObject())
+		}
+		content, err := json.Marshal(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      items,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+		if err := writeTarFile(tw, name, content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// IsSnapshotArchive returns true if the path is a tar or tar.gz archive
+func IsSnapshotArchive(path string) bool {
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClusterName returns the name of the snapshotted cluster, falling back to the snapshot file name
+func (s *ClusterSnapshot) GetClusterName() string {
+	if s.Metadata.ClusterName != "" {
+		return s.Metadata.ClusterName
+	}
+	name := filepath.Base(s.Path)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".yaml", ".yml", ".json"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// IsClusterSnapshot returns true if the scan metadata is of a cluster snapshot and not of a live cluster
+func IsClusterSnapshot(contextMetadata *reporthandlingv2.ContextMetadata) bool {
+	return contextMetadata.RepoContextMetadata != nil && contextMetadata.RepoContextMetadata.Provider == string(ContextSnapshot)
+}
+
+// ListResources returns all the objects of the snapshot
+func (s *ClusterSnapshot) ListResources() []workloadinterface.IMetadata {
+	var resources []workloadinterface.IMetadata
+	for _, objs := range s.Resources {
+		resources = append(resources, objs...)
+	}
+	return resources
+}
+
+func (s *ClusterSnapshot) readDir(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if !isSnapshotFile(relPath) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return s.addFile(filepath.ToSlash(relPath), content)
+	})
+}
+
+func (s *ClusterSnapshot) readArchive(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if !strings.HasSuffix(path, ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || !isSnapshotFile(header.Name) {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := s.addFile(header.Name, content); err != nil {
+			return err
+		}
+	}
+}
+
+// addFile parses a file of the snapshot. The metadata file is only recognized at the root of the snapshot
+func (s *ClusterSnapshot) addFile(name string, content []byte) error {
+	name = strings.TrimPrefix(filepath.ToSlash(name), "./")
+	if name == SnapshotMetadataFilename {
+		if err := json.Unmarshal(content, &s.Metadata); err != nil {
+			return fmt.Errorf("failed to parse snapshot metadata: %w", err)
+		}
+		return nil
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	objs, err := ReadFile(content, getFileFormat(name))
+	if err != nil || len(objs) == 0 {
+		// dumps often contain files that are not Kubernetes objects (logs, events...), skip them silently
+		return nil
+	}
+	s.Resources[name] = append(s.Resources[name], objs...)
+	return nil
+}
+
+// snapshotFileName returns the file of the snapshot an object is stored in, e.g. namespaces/default/Deployment.json
+func snapshotFileName(obj workloadinterface.IMetadata) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return fmt.Sprintf("namespaces/%s/%s.json", ns, obj.GetKind())
+	}
+	return fmt.Sprintf("cluster/%s.json", obj.GetKind())
+}
+
+func writeTarFile(tw *tar.Writer, name string, content []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+func isSnapshotFile(name string) bool {
+	return IsYaml(name) || IsJson(name)
+}
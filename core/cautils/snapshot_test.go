@@ -0,0 +1,139 @@
+package cautils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	snapshotDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+`
+	snapshotNodesList = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"apiVersion": "v1", "kind": "Node", "metadata": {"name": "node-1"}},
+    {"apiVersion": "v1", "kind": "Node", "metadata": {"name": "node-2"}}
+  ]
+}`
+	snapshotMetadata = `{"clusterName": "prod", "apiServerInfo": {"gitVersion": "v1.29.0"}}`
+)
+
+func writeSnapshotDir(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "default"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "default", "deployments.yaml"), []byte(snapshotDeployment), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nodes.json"), []byte(snapshotNodesList), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "events.log"), []byte("not a k8s object"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SnapshotMetadataFilename), []byte(snapshotMetadata), 0644))
+	return dir
+}
+
+func writeSnapshotArchive(t *testing.T, files map[string]string) string {
+	path := filepath.Join(t.TempDir(), "cluster-dump.tar.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return path
+}
+
+func TestReadClusterSnapshot(t *testing.T) {
+	t.Run("directory", func(t *testing.T) {
+		snapshot, err := ReadClusterSnapshot(writeSnapshotDir(t))
+		require.NoError(t, err)
+
+		assert.Len(t, snapshot.Resources, 2)
+		assert.Len(t, snapshot.Resources["default/deployments.yaml"], 1)
+		assert.Len(t, snapshot.Resources["nodes.json"], 2) // List items are expanded
+		assert.Len(t, snapshot.ListResources(), 3)
+		assert.Equal(t, "prod", snapshot.GetClusterName())
+		require.NotNil(t, snapshot.Metadata.APIServerInfo)
+		assert.Equal(t, "v1.29.0", snapshot.Metadata.APIServerInfo.GitVersion)
+	})
+
+	t.Run("tar.gz archive", func(t *testing.T) {
+		path := writeSnapshotArchive(t, map[string]string{
+			"./default/deployments.yaml": snapshotDeployment,
+			"./nodes.json":               snapshotNodesList,
+		})
+		snapshot, err := ReadClusterSnapshot(path)
+		require.NoError(t, err)
+
+		assert.Len(t, snapshot.ListResources(), 3)
+		assert.Equal(t, "cluster-dump", snapshot.GetClusterName())
+		assert.Nil(t, snapshot.Metadata.APIServerInfo)
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "all.json")
+		require.NoError(t, os.WriteFile(path, []byte(snapshotNodesList), 0644))
+
+		snapshot, err := ReadClusterSnapshot(path)
+		require.NoError(t, err)
+		assert.Len(t, snapshot.ListResources(), 2)
+		assert.Equal(t, "all", snapshot.GetClusterName())
+	})
+
+	t.Run("no objects", func(t *testing.T) {
+		_, err := ReadClusterSnapshot(t.TempDir())
+		assert.Error(t, err)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := ReadClusterSnapshot(filepath.Join(t.TempDir(), "missing"))
+		assert.Error(t, err)
+	})
+}
+
+func TestIsSnapshotArchive(t *testing.T) {
+	assert.True(t, IsSnapshotArchive("dump.tar.gz"))
+	assert.True(t, IsSnapshotArchive("dump.tgz"))
+	assert.True(t, IsSnapshotArchive("dump.tar"))
+	assert.False(t, IsSnapshotArchive("dump.yaml"))
+}
+
+func TestClusterSnapshotWriteArchive(t *testing.T) {
+	source, err := ReadClusterSnapshot(writeSnapshotDir(t))
+	require.NoError(t, err)
+
+	snapshot := NewClusterSnapshot(source.Metadata, source.ListResources())
+	assert.Len(t, snapshot.Resources["namespaces/default/Deployment.json"], 1)
+	assert.Len(t, snapshot.Resources["cluster/Node.json"], 2)
+
+	path := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	require.NoError(t, snapshot.WriteArchive(path))
+
+	// the archive can be scanned as a snapshot
+	restored, err := ReadClusterSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", restored.GetClusterName())
+	assert.Equal(t, "v1.29.0", restored.Metadata.APIServerInfo.GitVersion)
+	assert.Len(t, restored.ListResources(), 3)
+	assert.Len(t, restored.Resources["namespaces/default/Deployment.json"], 1)
+}
@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/pkg/admissionhandler"
+	"github.com/kubescape/kubescape/v3/core/pkg/policyhandler"
+	"github.com/kubescape/opa-utils/resources"
+)
+
+// NewAdmissionValidator returns a validator evaluating the resources of admission requests against the policies of the scan.
+// The policies, exceptions and controls inputs are collected on the first request and cached
+func (ks *Kubescape) NewAdmissionValidator(admissionInfo *metav1.AdmissionInfo, scanInfo *cautils.ScanInfo) (*admissionhandler.Validator, error) {
+	ctx := ks.Context()
+
+	severityThreshold, err := admissionhandler.ParseSeverity(admissionInfo.SeverityThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	scanInfo.Init(ctx)
+
+	k8s := getKubernetesApi()
+	tenantConfig := cautils.GetTenantConfig(scanInfo.AccountID, scanInfo.AccessKey, k8sinterface.GetContextName(), scanInfo.CustomClusterName, k8s)
+
+	downloadReleasedPolicy := getter.NewDownloadReleasedPolicy() // download config inputs from github release
+	scanInfo.Getters.PolicyGetter = getPolicyGetter(ctx, scanInfo.UseFrom, tenantConfig.GetAccountID(), scanInfo.FrameworkScan, downloadReleasedPolicy)
+	scanInfo.Getters.ControlsInputsGetter = getConfigInputsGetter(ctx, scanInfo.ControlsInputs, tenantConfig.GetAccountID(), downloadReleasedPolicy)
+	scanInfo.Getters.ExceptionsGetter = getExceptionsGetter(ctx, scanInfo.UseExceptions, tenantConfig.GetAccountID(), downloadReleasedPolicy)
+
+	collectPolicies := func(ctx context.Context) (*cautils.OPASessionObj, error) {
+		policyHandler := policyhandler.NewPolicyHandler(tenantConfig.GetContextName())
+		return policyHandler.CollectPolicies(ctx, scanInfo.PolicyIdentifier, scanInfo)
+	}
+	deps := resources.NewRegoDependenciesData(k8sinterface.GetK8sConfig(), tenantConfig.GetContextName())
+
+	return admissionhandler.NewValidator(collectPolicies, deps, tenantConfig.GetContextName(), severityThreshold, admissionInfo.WarnOnly, admissionInfo.PoliciesCacheTTL), nil
+}
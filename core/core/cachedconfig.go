@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
@@ -31,6 +32,25 @@ func (ks *Kubescape) SetCachedConfig(setConfig *metav1.SetConfig) error {
 func (ks *Kubescape) ViewCachedConfig(viewConfig *metav1.ViewConfig) error {
 	tenant := cautils.GetTenantConfig("", "", "", "", getKubernetesApi()) // change k8sinterface
 	fmt.Fprintf(viewConfig.Writer, "%s\n", tenant.GetConfigObj().Config())
+	return viewScanConfig(viewConfig)
+}
+
+// viewScanConfig prints the effective scan config: the config file merged with the env vars
+func viewScanConfig(viewConfig *metav1.ViewConfig) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	scanConfig, err := cautils.GetScanConfig(viewConfig.ScanConfigFile, dir)
+	if err != nil {
+		return err
+	}
+
+	source := "no config file found"
+	if scanConfig.Path() != "" {
+		source = scanConfig.Path()
+	}
+	fmt.Fprintf(viewConfig.Writer, "\nScan config (%s, flags take precedence):\n%s", source, scanConfig.String())
 	return nil
 }
 
@@ -52,9 +52,62 @@ func (ks *Kubescape) Download(downloadInfo *metav1.DownloadInfo) error {
 	if err := downloadArtifact(ks.Context(), downloadInfo, downloadFunc); err != nil {
 		return err
 	}
+	signatureRemoved, err := updateChecksums(ks.Context(), downloadInfo)
+	if err != nil {
+		return err
+	}
+	if downloadInfo.PushReference != "" {
+		if signatureRemoved {
+			// pushing would publish the bundle without the signature the manifest had
+			return fmt.Errorf("the downloaded artifacts changed since %s was signed, sign it again and push the bundle", filepath.Join(downloadInfo.Path, getter.ChecksumsFilename))
+		}
+		return pushPolicyBundle(ks.Context(), downloadInfo)
+	}
 	return nil
 }
 
+// pushPolicyBundle pushes the downloaded files, their checksum manifest and its signature to an OCI registry. Scans pulling
+// the bundle by digest get exactly the pushed files
+func pushPolicyBundle(ctx context.Context, downloadInfo *metav1.DownloadInfo) error {
+	if len(downloadInfo.DownloadedFiles) == 0 {
+		return fmt.Errorf("no artifact was downloaded, nothing to push")
+	}
+	files := append([]string{}, downloadInfo.DownloadedFiles...)
+	for _, name := range []string{getter.ChecksumsFilename, getter.ChecksumsSignatureFilename} {
+		if path := filepath.Join(downloadInfo.Path, name); fileExists(path) {
+			files = append(files, path)
+		}
+	}
+
+	digest, err := getter.PushPolicyBundle(ctx, downloadInfo.PushReference, files)
+	if err != nil {
+		return err
+	}
+	logger.L().Ctx(ctx).Success("Pushed policy bundle", helpers.String("reference", downloadInfo.PushReference), helpers.String("digest", digest))
+	return nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// updateChecksums adds the downloaded files to the checksum manifest of the download directory, scans verify the policies
+// against it before using them. It returns true when the manifest changed and its signature was removed
+func updateChecksums(ctx context.Context, downloadInfo *metav1.DownloadInfo) (bool, error) {
+	if len(downloadInfo.DownloadedFiles) == 0 {
+		return false, nil
+	}
+	signatureRemoved, err := getter.UpdateChecksums(downloadInfo.Path, downloadInfo.DownloadedFiles)
+	if err != nil {
+		return false, fmt.Errorf("failed to update checksum manifest: %w", err)
+	}
+	if signatureRemoved {
+		logger.L().Ctx(ctx).Warning("the checksum manifest changed and its signature was removed, sign it again", helpers.String("path", filepath.Join(downloadInfo.Path, getter.ChecksumsFilename)))
+	}
+	return signatureRemoved, nil
+}
+
 func downloadArtifact(ctx context.Context, downloadInfo *metav1.DownloadInfo, downloadArtifactFunc map[string]func(context.Context, *metav1.DownloadInfo) error) error {
 	if f, ok := downloadArtifactFunc[downloadInfo.Target]; ok {
 		if err := f(ctx, downloadInfo); err != nil {
@@ -90,9 +143,11 @@ func downloadArtifacts(ctx context.Context, downloadInfo *metav1.DownloadInfo) e
 		"attack-tracks":   downloadAttackTracks,
 	}
 	for artifact := range artifacts {
-		if err := downloadArtifact(ctx, &metav1.DownloadInfo{Target: artifact, Path: downloadInfo.Path, FileName: fmt.Sprintf("%s.json", artifact)}, artifacts); err != nil {
+		artifactInfo := &metav1.DownloadInfo{Target: artifact, Path: downloadInfo.Path, FileName: fmt.Sprintf("%s.json", artifact)}
+		if err := downloadArtifact(ctx, artifactInfo, artifacts); err != nil {
 			logger.L().Ctx(ctx).Warning("error downloading", helpers.String("artifact", artifact), helpers.Error(err))
 		}
+		downloadInfo.DownloadedFiles = append(downloadInfo.DownloadedFiles, artifactInfo.DownloadedFiles...)
 	}
 	return nil
 }
@@ -116,6 +171,7 @@ func downloadConfigInputs(ctx context.Context, downloadInfo *metav1.DownloadInfo
 	if err != nil {
 		return err
 	}
+	downloadInfo.DownloadedFiles = append(downloadInfo.DownloadedFiles, filepath.Join(downloadInfo.Path, downloadInfo.FileName))
 	logger.L().Success("Downloaded", helpers.String("artifact", downloadInfo.Target), helpers.String("path", filepath.Join(downloadInfo.Path, downloadInfo.FileName)))
 	return nil
 }
@@ -137,6 +193,7 @@ func downloadExceptions(ctx context.Context, downloadInfo *metav1.DownloadInfo)
 	if err != nil {
 		return err
 	}
+	downloadInfo.DownloadedFiles = append(downloadInfo.DownloadedFiles, filepath.Join(downloadInfo.Path, downloadInfo.FileName))
 	logger.L().Ctx(ctx).Success("Downloaded", helpers.String("artifact", downloadInfo.Target), helpers.String("path", filepath.Join(downloadInfo.Path, downloadInfo.FileName)))
 	return nil
 }
@@ -160,6 +217,7 @@ func downloadAttackTracks(ctx context.Context, downloadInfo *metav1.DownloadInfo
 	if err != nil {
 		return err
 	}
+	downloadInfo.DownloadedFiles = append(downloadInfo.DownloadedFiles, filepath.Join(downloadInfo.Path, downloadInfo.FileName))
 	logger.L().Success("Downloaded", helpers.String("attack tracks", downloadInfo.Target), helpers.String("path", filepath.Join(downloadInfo.Path, downloadInfo.FileName)))
 	return nil
 
@@ -183,6 +241,7 @@ func downloadFramework(ctx context.Context, downloadInfo *metav1.DownloadInfo) e
 			if err != nil {
 				return err
 			}
+			downloadInfo.DownloadedFiles = append(downloadInfo.DownloadedFiles, downloadTo)
 			logger.L().Success("Downloaded", helpers.String("artifact", downloadInfo.Target), helpers.String("name", fw.Name), helpers.String("path", downloadTo))
 		}
 		// return fmt.Errorf("missing framework name")
@@ -202,6 +261,7 @@ func downloadFramework(ctx context.Context, downloadInfo *metav1.DownloadInfo) e
 		if err != nil {
 			return err
 		}
+		downloadInfo.DownloadedFiles = append(downloadInfo.DownloadedFiles, downloadTo)
 		logger.L().Success("Downloaded", helpers.String("artifact", downloadInfo.Target), helpers.String("name", framework.Name), helpers.String("path", downloadTo))
 	}
 	return nil
@@ -232,6 +292,7 @@ func downloadControl(ctx context.Context, downloadInfo *metav1.DownloadInfo) err
 	if err != nil {
 		return err
 	}
+	downloadInfo.DownloadedFiles = append(downloadInfo.DownloadedFiles, downloadTo)
 	logger.L().Success("Downloaded", helpers.String("artifact", downloadInfo.Target), helpers.String("ID", downloadInfo.Identifier), helpers.String("path", downloadTo))
 	return nil
 }
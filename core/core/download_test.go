@@ -3,12 +3,17 @@ package core
 import (
 	"context"
 	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/registry"
 	"github.com/kubescape/kubescape/v3/core/cautils/getter"
 	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Returns a list of all available download commands when 'DownloadSupportCommands' is called.
@@ -111,6 +116,50 @@ func TestDownloadArtifact(t *testing.T) {
 	}
 }
 
+func TestPushPolicyBundle(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	framework := `{"name":"NSA"}`
+	defer func(o map[string]func(context.Context, *metav1.DownloadInfo) error) { downloadFunc = o }(downloadFunc)
+	downloadFunc = map[string]func(context.Context, *metav1.DownloadInfo) error{
+		TargetFramework: func(_ context.Context, downloadInfo *metav1.DownloadInfo) error {
+			path := filepath.Join(downloadInfo.Path, "nsa.json")
+			downloadInfo.DownloadedFiles = append(downloadInfo.DownloadedFiles, path)
+			return os.WriteFile(path, []byte(framework), 0600)
+		},
+	}
+
+	dir := t.TempDir()
+	ks := NewKubescape(ctx)
+	pushReference := getter.OCIScheme + u.Host + "/kubescape/policies:v1"
+	signaturePath := filepath.Join(dir, getter.ChecksumsSignatureFilename)
+
+	// download, sign the manifest, then download again and push
+	require.NoError(t, ks.Download(&metav1.DownloadInfo{Target: TargetFramework, Path: dir}))
+	require.NoError(t, os.WriteFile(signaturePath, []byte("signature"), 0600))
+	require.NoError(t, ks.Download(&metav1.DownloadInfo{Target: TargetFramework, Path: dir, PushReference: pushReference}))
+
+	ociPolicy, err := getter.NewOCIPolicy(ctx, pushReference, t.TempDir())
+	require.NoError(t, err)
+	files, err := ociPolicy.Files()
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	assert.ElementsMatch(t, []string{"nsa.json", getter.ChecksumsFilename, getter.ChecksumsSignatureFilename}, names)
+
+	// the bundle is not pushed without the signature of the changed manifest
+	framework = `{"name":"NSA","controls":[]}`
+	err = ks.Download(&metav1.DownloadInfo{Target: TargetFramework, Path: dir, PushReference: pushReference})
+	assert.ErrorContains(t, err, "sign it again")
+	assert.NoFileExists(t, signaturePath)
+}
+
 func TestSetPathAndFilename(t *testing.T) {
 	tests := []struct {
 		downloadInfo     *metav1.DownloadInfo
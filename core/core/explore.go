@@ -0,0 +1,95 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/pkg/explorehandler"
+	"github.com/kubescape/kubescape/v3/core/pkg/fixhandler"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+)
+
+// Explore browses the results of a scan report in an interactive terminal UI, and fixes the findings marked by the user
+// on request
+func (ks *Kubescape) Explore(exploreInfo *metav1.ExploreInfo) error {
+	content, err := os.ReadFile(exploreInfo.ReportFile)
+	if err != nil {
+		return err
+	}
+	var report reporthandlingv2.PostureReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		return fmt.Errorf("failed to read report file %s: %w", exploreInfo.ReportFile, err)
+	}
+	setControlsDetails(&report)
+
+	clusterName := ""
+	if report.Metadata.ScanMetadata.ScanningTarget == reporthandlingv2.Cluster {
+		clusterName = report.ClusterName
+	}
+	filter := explorehandler.Filter{Severity: exploreInfo.Severity, Namespace: exploreInfo.Namespace, Kind: exploreInfo.Kind}
+
+	result, err := explorehandler.Run(cautils.NewOPASessionObjFromReport(&report), filter, exploreInfo.ExceptionsFile, clusterName)
+	if err != nil {
+		return err
+	}
+	if result.Action != explorehandler.ActionFix {
+		return nil
+	}
+
+	return ks.fixFindings(&report, result.Findings)
+}
+
+// fixFindings fixes the findings with a report of their results only
+func (ks *Kubescape) fixFindings(report *reporthandlingv2.PostureReport, findings []explorehandler.Finding) error {
+	reportFile, err := os.CreateTemp("", "kubescape-explore-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(reportFile.Name())
+
+	err = json.NewEncoder(reportFile).Encode(explorehandler.FilterReport(report, findings))
+	if closeErr := reportFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return ks.Fix(&metav1.FixInfo{
+		ReportFile:     reportFile.Name(),
+		SkipUserValues: true,
+		KustomizePatch: fixhandler.KustomizePatchStrategicMerge,
+		Cluster:        report.Metadata.ScanMetadata.ScanningTarget == reporthandlingv2.Cluster,
+	})
+}
+
+// setControlsDetails sets the description and remediation of the controls of the report, which are not part of the
+// JSON output, from the frameworks in the local store. The controls of the frameworks not downloaded have no details,
+// which is warned about
+func setControlsDetails(report *reporthandlingv2.PostureReport) {
+	controls := report.SummaryDetails.Controls
+	for _, frameworkSummary := range report.SummaryDetails.Frameworks {
+		frameworkFile := getter.GetDefaultPath(strings.ToLower(frameworkSummary.Name) + ".json")
+		framework, err := getter.NewLoadPolicy([]string{frameworkFile}).GetFramework(frameworkSummary.Name)
+		if err != nil {
+			logger.L().Warning(fmt.Sprintf("framework not found in the local cache, its controls have no description and remediation. Download it with '%s download framework %s'", cautils.ExecName(), strings.ToLower(frameworkSummary.Name)), helpers.String("framework", frameworkSummary.Name), helpers.Error(err))
+			continue
+		}
+		for _, control := range framework.Controls {
+			summary, ok := controls[control.ControlID]
+			if !ok || summary.Description != "" {
+				continue
+			}
+			summary.Description = control.Description
+			summary.Remediation = control.Remediation
+			controls[control.ControlID] = summary
+		}
+	}
+}
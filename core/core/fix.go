@@ -3,8 +3,12 @@ package core
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
 	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
 	"github.com/kubescape/kubescape/v3/core/pkg/fixhandler"
 )
@@ -13,9 +17,16 @@ const (
 	noChangesApplied     = "No changes were applied."
 	noResourcesToFix     = "No issues to fix."
 	confirmationQuestion = "Would you like to apply the changes to the files above? [y|n]: "
+
+	clusterConfirmationQuestion  = "Would you like to apply the changes to the resources of the cluster above? [y|n]: "
+	rollbackConfirmationQuestion = "Would you like to roll back the changes to the resources above? [y|n]: "
 )
 
 func (ks *Kubescape) Fix(fixInfo *metav1.FixInfo) error {
+	if fixInfo.Cluster {
+		return ks.fixCluster(fixInfo)
+	}
+
 	logger.L().Info("Reading report file...")
 	handler, err := fixhandler.NewFixHandler(fixInfo)
 	if err != nil {
@@ -41,6 +52,10 @@ func (ks *Kubescape) Fix(fixInfo *metav1.FixInfo) error {
 		return nil
 	}
 
+	if fixInfo.GitBranch != "" {
+		return ks.commitFixes(handler, resourcesToFix, fixInfo)
+	}
+
 	updatedFilesCount, errors := handler.ApplyChanges(ks.Context(), resourcesToFix)
 	logger.L().Info(fmt.Sprintf("Fixed resources in %d files.", updatedFilesCount))
 
@@ -54,11 +69,159 @@ func (ks *Kubescape) Fix(fixInfo *metav1.FixInfo) error {
 	return nil
 }
 
+// commitFixes commits the fixes of each control to a new git branch and publishes the branch with the change providers
+func (ks *Kubescape) commitFixes(handler *fixhandler.FixHandler, resourcesToFix []fixhandler.ResourceFixInfo, fixInfo *metav1.FixInfo) error {
+	change, fixErrors, err := handler.CommitChanges(ks.Context(), resourcesToFix, fixInfo.GitBranch)
+	if err != nil {
+		return err
+	}
+	for _, fixErr := range fixErrors {
+		logger.L().Ctx(ks.Context()).Warning(fixErr.Error())
+	}
+
+	if len(change.Commits) == 0 {
+		logger.L().Info(noChangesApplied)
+		return nil
+	}
+	logger.L().Info(fmt.Sprintf("Committed the fixes of %d controls to branch %s.", len(change.Commits), change.Branch))
+
+	for _, provider := range getChangeProviders(fixInfo) {
+		if err := provider.Publish(ks.Context(), change); err != nil {
+			return err
+		}
+		logger.L().Success("Published fixes", helpers.String("provider", provider.Name()), helpers.String("branch", change.Branch))
+	}
+
+	if len(fixErrors) > 0 {
+		return fmt.Errorf("Failed to fix some resources, check the logs for more details")
+	}
+	return nil
+}
+
+// fixCluster applies the fixes to the live resources of the cluster and saves their prior versions to roll them back
+func (ks *Kubescape) fixCluster(fixInfo *metav1.FixInfo) error {
+	k8s := getKubernetesApi()
+	if k8s == nil {
+		return fmt.Errorf("failed to connect to the cluster")
+	}
+
+	logger.L().Info("Reading report file...")
+	handler, err := fixhandler.NewClusterFixHandler(fixInfo, k8s.DynamicClient, k8sinterface.GetContextName(), fixhandler.DefaultRollbackStore())
+	if err != nil {
+		return err
+	}
+
+	resourcesToFix := handler.PrepareResourcesToFix(ks.Context())
+
+	if len(resourcesToFix) == 0 {
+		logger.L().Info(noResourcesToFix)
+		return nil
+	}
+
+	handler.PrintExpectedChanges(resourcesToFix)
+
+	if fixInfo.DryRun {
+		logger.L().Info(noChangesApplied)
+		return nil
+	}
+
+	if !fixInfo.NoConfirm && !askConfirmation(clusterConfirmationQuestion) {
+		logger.L().Info(noChangesApplied)
+		return nil
+	}
+
+	record, errors := handler.ApplyChanges(ks.Context(), resourcesToFix)
+	if record != nil {
+		logger.L().Info(fmt.Sprintf("Fixed %d resources. Run \"%s fix rollback %s\" to restore them.", len(record.Resources), cautils.ExecName(), record.ID))
+	}
+
+	if len(errors) > 0 {
+		for _, err := range errors {
+			logger.L().Ctx(ks.Context()).Warning(err.Error())
+		}
+		return fmt.Errorf("Failed to fix some resources, check the logs for more details")
+	}
+
+	return nil
+}
+
+// FixRollback restores the resources of the cluster changed by a fix, or lists the fixes when no ID is given
+func (ks *Kubescape) FixRollback(rollbackInfo *metav1.FixRollbackInfo) error {
+	store := fixhandler.DefaultRollbackStore()
+
+	if rollbackInfo.ID == "" {
+		records, err := store.List()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			logger.L().Info("No fixes to roll back.")
+			return nil
+		}
+		var sb strings.Builder
+		sb.WriteString("Fixes of clusters:\n")
+		for _, record := range records {
+			sb.WriteString(fmt.Sprintf("%s\tcontext: %s\tresources: %d\n", record.ID, record.Context, len(record.Resources)))
+		}
+		logger.L().Info(sb.String())
+		return nil
+	}
+
+	record, err := store.Load(rollbackInfo.ID)
+	if err != nil {
+		return err
+	}
+	if contextName := k8sinterface.GetContextName(); contextName != record.Context {
+		return fmt.Errorf("fix %s was applied to context %s, but the current context is %s", record.ID, record.Context, contextName)
+	}
+	k8s := getKubernetesApi()
+	if k8s == nil {
+		return fmt.Errorf("failed to connect to the cluster")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("The following resources of context %s will be restored to their versions of %s:\n", record.Context, record.CreatedAt.Local().Format(time.DateTime)))
+	for _, resource := range record.Resources {
+		sb.WriteString(fmt.Sprintf("\t%s %s/%s\n", resource.Kind, resource.Namespace, resource.Name))
+	}
+	logger.L().Info(sb.String())
+
+	if !rollbackInfo.NoConfirm && !askConfirmation(rollbackConfirmationQuestion) {
+		logger.L().Info(noChangesApplied)
+		return nil
+	}
+
+	if errors := fixhandler.Rollback(ks.Context(), k8s.DynamicClient, record); len(errors) > 0 {
+		for _, err := range errors {
+			logger.L().Ctx(ks.Context()).Warning(err.Error())
+		}
+		return fmt.Errorf("Failed to roll back some resources, check the logs for more details")
+	}
+
+	logger.L().Success("Rolled back fix", helpers.String("id", record.ID))
+	return store.Delete(record.ID)
+}
+
+func getChangeProviders(fixInfo *metav1.FixInfo) []fixhandler.IChangeProvider {
+	var providers []fixhandler.IChangeProvider
+	if fixInfo.PatchFile != "" {
+		providers = append(providers, fixhandler.NewPatchProvider(fixInfo.PatchFile))
+	}
+	if fixInfo.PushRemote != "" {
+		providers = append(providers, fixhandler.NewPushProvider(fixInfo.PushRemote))
+	}
+	return providers
+}
+
 func userConfirmed() bool {
+	return askConfirmation(confirmationQuestion)
+}
+
+func askConfirmation(question string) bool {
 	var input string
 
 	for {
-		fmt.Println(confirmationQuestion)
+		fmt.Println(question)
 		if _, err := fmt.Scanln(&input); err != nil {
 			continue
 		}
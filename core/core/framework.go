@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// CreateFramework writes the definition of a custom framework, after checking all its controls are resolved
+func (ks *Kubescape) CreateFramework(createInfo *metav1.FrameworkCreateInfo) error {
+	ctx := ks.Context()
+
+	definition := newFrameworkDefinition(createInfo)
+	if err := definition.Validate(); err != nil {
+		return err
+	}
+
+	policyGetter, err := getFrameworkSourceGetter(ctx, createInfo)
+	if err != nil {
+		return err
+	}
+	framework, err := definition.Resolve(policyGetter.GetFramework)
+	if err != nil {
+		return err
+	}
+
+	buf, err := yaml.Marshal(definition)
+	if err != nil {
+		return err
+	}
+	output := createInfo.Output
+	if output == "" {
+		output = strings.ToLower(definition.Name) + ".yaml"
+	}
+	if err := os.WriteFile(output, buf, 0644); err != nil { //nolint:gosec
+		return fmt.Errorf("failed to write framework definition: %w", err)
+	}
+
+	logger.L().Success("Created framework definition", helpers.String("name", definition.Name), helpers.String("path", output), helpers.Int("controls", len(framework.Controls)))
+	logger.L().Info(fmt.Sprintf("Run '%s download artifacts' and '%s scan framework %s --use-from %s' to scan with the framework", cautils.ExecName(), cautils.ExecName(), definition.Name, output))
+	return nil
+}
+
+// newFrameworkDefinition builds the framework definition of the command line inputs
+func newFrameworkDefinition(createInfo *metav1.FrameworkCreateInfo) *getter.FrameworkDefinition {
+	definition := getter.NewFrameworkDefinition(createInfo.Name)
+	definition.Description = createInfo.Description
+	definition.Inherit = createInfo.Inherit
+	definition.Exclude = getter.FrameworkExclusions{
+		Frameworks: createInfo.ExcludeFrameworks,
+		Controls:   createInfo.ExcludeControls,
+	}
+
+	for _, controlID := range createInfo.Controls {
+		definition.Controls = append(definition.Controls, getter.ControlReference{ID: controlID})
+	}
+
+	// overrides of controls that are not listed reference inherited controls
+	controlIDs := slices.Concat(slices.Collect(maps.Keys(createInfo.Severities)), slices.Collect(maps.Keys(createInfo.ScoreFactors)), slices.Collect(maps.Keys(createInfo.ControlsInputs)))
+	slices.Sort(controlIDs)
+	for _, controlID := range slices.Compact(controlIDs) {
+		ref := controlReference(definition, controlID)
+		if severity, ok := createInfo.Severities[controlID]; ok {
+			ref.Severity = severity
+		}
+		if scoreFactor, ok := createInfo.ScoreFactors[controlID]; ok {
+			ref.ScoreFactor = &scoreFactor
+		}
+		if controlsInputs, ok := createInfo.ControlsInputs[controlID]; ok {
+			ref.ControlsInputs = controlsInputs
+		}
+	}
+
+	return definition
+}
+
+// controlReference returns the reference of the control in the definition, the control is added when it is not listed
+func controlReference(definition *getter.FrameworkDefinition, controlID string) *getter.ControlReference {
+	i := slices.IndexFunc(definition.Controls, func(ref getter.ControlReference) bool {
+		return strings.EqualFold(ref.ID, controlID)
+	})
+	if i < 0 {
+		definition.Controls = append(definition.Controls, getter.ControlReference{ID: controlID})
+		i = len(definition.Controls) - 1
+	}
+	return &definition.Controls[i]
+}
+
+// getFrameworkSourceGetter returns the getter of the frameworks the controls of a definition are taken from
+func getFrameworkSourceGetter(ctx context.Context, createInfo *metav1.FrameworkCreateInfo) (getter.IPolicyGetter, error) {
+	if createInfo.UseArtifactsFrom == "" {
+		tenant := cautils.GetTenantConfig(createInfo.AccountID, createInfo.AccessKey, "", "", nil)
+		return getPolicyGetter(ctx, nil, tenant.GetAccountID(), true, nil), nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(createInfo.UseArtifactsFrom, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no artifacts found in %s, download them with '%s download artifacts --output %s'", createInfo.UseArtifactsFrom, cautils.ExecName(), createInfo.UseArtifactsFrom)
+	}
+	return getter.NewLoadPolicy(files), nil
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFramework(t *testing.T) {
+	artifacts := t.TempDir()
+	for _, name := range []string{"NSA.json", "MITRE.json"} {
+		buf, err := os.ReadFile(filepath.Join("..", "cautils", "getter", "testdata", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(artifacts, name), buf, 0600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(artifacts, "allcontrols.json"), []byte(`{"name":"AllControls","controls":[]}`), 0600))
+	output := filepath.Join(t.TempDir(), "custom.yaml")
+	ks := NewKubescape(context.Background())
+
+	createInfo := &metav1.FrameworkCreateInfo{
+		Name:              "custom",
+		Inherit:           []string{"nsa"},
+		ExcludeFrameworks: []string{"mitre"},
+		Controls:          []string{"C-0053"},
+		Severities:        map[string]string{"C-0053": "critical", "C-0017": "high"},
+		ScoreFactors:      map[string]float32{"C-0017": 8},
+		ControlsInputs:    map[string]map[string][]string{"C-0012": {"sensitiveKeyNames": {"aws_access_key_id"}}},
+		Output:            output,
+		UseArtifactsFrom:  artifacts,
+	}
+	require.NoError(t, ks.CreateFramework(createInfo))
+
+	buf, err := os.ReadFile(output)
+	require.NoError(t, err)
+	definition, err := getter.ParseFrameworkDefinition(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", definition.Name)
+	scoreFactor := float32(8)
+	assert.Equal(t, []getter.ControlReference{
+		{ID: "C-0053", Severity: "critical"},
+		{ID: "C-0012", ControlsInputs: map[string][]string{"sensitiveKeyNames": {"aws_access_key_id"}}},
+		{ID: "C-0017", Severity: "high", ScoreFactor: &scoreFactor},
+	}, definition.Controls)
+
+	// the definition is resolved when scanning with the downloaded artifacts
+	framework, err := getter.NewLoadPolicy([]string{output, filepath.Join(artifacts, "NSA.json"), filepath.Join(artifacts, "MITRE.json")}).GetFramework("custom")
+	require.NoError(t, err)
+	// C-0012 is part of MITRE and added back by its override
+	require.Len(t, framework.Controls, 14)
+	for _, control := range framework.Controls {
+		switch control.ControlID {
+		case "C-0012":
+			assert.Equal(t, []string{"aws_access_key_id"}, control.FixedInput["sensitiveKeyNames"])
+		case "C-0017":
+			assert.Equal(t, float32(8), control.BaseScore)
+		}
+	}
+
+	t.Run("unknown control", func(t *testing.T) {
+		createInfo := *createInfo
+		createInfo.Controls = []string{"C-9999"}
+		createInfo.Severities = nil
+		createInfo.ScoreFactors = nil
+		createInfo.ControlsInputs = nil
+		assert.ErrorIs(t, ks.CreateFramework(&createInfo), getter.ErrControlNotMatching)
+	})
+
+	t.Run("invalid severity", func(t *testing.T) {
+		createInfo := *createInfo
+		createInfo.Severities = map[string]string{"C-0053": "urgent"}
+		assert.ErrorContains(t, ks.CreateFramework(&createInfo), "invalid severity")
+	})
+
+	t.Run("invalid score factor", func(t *testing.T) {
+		createInfo := *createInfo
+		createInfo.ScoreFactors = map[string]float32{"C-0017": 11}
+		assert.ErrorContains(t, ks.CreateFramework(&createInfo), "score factor must be between 0 and 10")
+	})
+
+	t.Run("missing artifacts", func(t *testing.T) {
+		createInfo := *createInfo
+		createInfo.UseArtifactsFrom = t.TempDir()
+		assert.ErrorContains(t, ks.CreateFramework(&createInfo), "no artifacts found")
+	})
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
+	printerv2 "github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer/v2"
+)
+
+// History prints the score trends and the control regressions of the scans recorded in the history store
+func (ks *Kubescape) History(historyInfo *metav1.HistoryInfo) error {
+	file := historyInfo.HistoryFile
+	if file == "" {
+		file = history.DefaultFile()
+	}
+
+	report, err := history.LoadReport(ks.Context(), file, newHistoryFilter(historyInfo, time.Now()))
+	if errors.Is(err, history.ErrNoHistory) {
+		return fmt.Errorf("%w, record scans with the --keep-history flag of the scan command", err)
+	}
+	if err != nil {
+		return err
+	}
+	return printerv2.PrintHistoryReport(ks.Context(), historyInfo.Format, historyInfo.Output, report)
+}
+
+func newHistoryFilter(historyInfo *metav1.HistoryInfo, now time.Time) history.Filter {
+	filter := history.Filter{
+		Target:    history.Target{Name: historyInfo.Target},
+		Framework: historyInfo.Framework,
+		Limit:     historyInfo.Limit,
+	}
+	if historyInfo.Since > 0 {
+		filter.Since = now.Add(-historyInfo.Since)
+	}
+	return filter
+}
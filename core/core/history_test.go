@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHistoryFilter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, history.Filter{
+		Target:    history.Target{Name: "prod"},
+		Framework: "nsa",
+		Since:     now.Add(-24 * time.Hour),
+		Limit:     5,
+	}, newHistoryFilter(&metav1.HistoryInfo{Target: "prod", Framework: "nsa", Since: 24 * time.Hour, Limit: 5}, now))
+	assert.Equal(t, history.Filter{}, newHistoryFilter(&metav1.HistoryInfo{}, now))
+}
+
+func TestHistory(t *testing.T) {
+	ks := &Kubescape{Ctx: context.TODO()}
+	historyFile := filepath.Join(t.TempDir(), history.DefaultFileName)
+
+	err := ks.History(&metav1.HistoryInfo{HistoryFile: historyFile, Format: "json"})
+	assert.ErrorIs(t, err, history.ErrNoHistory)
+
+	store, err := history.Open(context.TODO(), historyFile)
+	require.NoError(t, err)
+	require.NoError(t, store.Add(context.TODO(), &history.Scan{ReportID: "report", Target: history.Target{Type: history.TargetCluster, Name: "prod"}, ScannedAt: time.Now()}))
+	require.NoError(t, store.Close())
+
+	output := filepath.Join(t.TempDir(), "history.json")
+	assert.NoError(t, ks.History(&metav1.HistoryInfo{HistoryFile: historyFile, Format: "json", Output: output}))
+	assert.FileExists(t, output)
+}
@@ -29,6 +29,15 @@ func getKubernetesApi() *k8sinterface.KubernetesApi {
 	return k8sinterface.NewKubernetesApi()
 }
 
+// getSnapshotClusterName returns the name of the cluster the scanned snapshot was taken from
+func getSnapshotClusterName(scanInfo *cautils.ScanInfo) string {
+	snapshot, err := scanInfo.GetClusterSnapshot()
+	if err != nil {
+		return ""
+	}
+	return snapshot.GetClusterName()
+}
+
 func getExceptionsGetter(ctx context.Context, useExceptions string, accountID string, downloadReleasedPolicy *getter.DownloadReleasedPolicy) getter.IExceptionsGetter {
 	if useExceptions != "" {
 		// load exceptions from file
@@ -63,7 +72,7 @@ func getReporter(ctx context.Context, tenantConfig cautils.ITenantConfig, report
 
 	if submit {
 		submitData := reporterv2.SubmitContextScan
-		if scanInfo.GetScanningContext() != cautils.ContextCluster {
+		if scanningContext := scanInfo.GetScanningContext(); scanningContext != cautils.ContextCluster && scanningContext != cautils.ContextSnapshot {
 			submitData = reporterv2.SubmitContextRepository
 		}
 		return reporterv2.NewReportEventReceiver(tenantConfig, reportID, submitData, getter.GetKSCloudAPIConnector())
@@ -85,6 +94,14 @@ func getResourceHandler(ctx context.Context, scanInfo *cautils.ScanInfo, tenantC
 	ctx, span := otel.Tracer("").Start(ctx, "getResourceHandler")
 	defer span.End()
 
+	if scanInfo.GetScanningContext() == cautils.ContextSnapshot {
+		snapshot, err := scanInfo.GetClusterSnapshot()
+		if err != nil {
+			logger.L().Ctx(ctx).Fatal(err.Error())
+		}
+		return resourcehandler.NewSnapshotResourceHandler(snapshot, tenantConfig.GetContextName())
+	}
+
 	if len(scanInfo.InputPatterns) > 0 || k8s == nil {
 		// scanInfo.HostSensor.SetBool(false)
 		return resourcehandler.NewFileResourceHandler()
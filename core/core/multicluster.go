@@ -0,0 +1,144 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const defaultClusterScanParallelism = 4
+
+// clusterScanner scans a single kube context and returns its posture report
+type clusterScanner func(ctx context.Context, kubeContext string, scanArgs []string) (*reporthandlingv2.PostureReport, error)
+
+// ScanClusters scans several kube contexts concurrently and combines the results.
+// The Kubernetes configuration of k8sinterface is global to the process, so each cluster is scanned
+// by a child kubescape process pinned to its kube context
+func (ks *Kubescape) ScanClusters(multiClusterInfo *metav1.MultiClusterScanInfo) (*cautils.MultiClusterReport, error) {
+	return scanClusters(ks.Context(), multiClusterInfo, execClusterScan)
+}
+
+func scanClusters(ctx context.Context, multiClusterInfo *metav1.MultiClusterScanInfo, scan clusterScanner) (*cautils.MultiClusterReport, error) {
+	kubeContexts := multiClusterInfo.KubeContexts
+	if len(kubeContexts) == 0 {
+		kubeContexts = listKubeContexts()
+	}
+	if len(kubeContexts) == 0 {
+		return nil, fmt.Errorf("no kube contexts found, make sure the kubeconfig is set")
+	}
+
+	parallelism := multiClusterInfo.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultClusterScanParallelism
+	}
+
+	logger.L().Start(fmt.Sprintf("Scanning %d clusters...", len(kubeContexts)))
+
+	clusterReports := make([]cautils.ClusterReport, len(kubeContexts))
+	limit := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i := range kubeContexts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			report, err := scan(ctx, kubeContexts[i], multiClusterInfo.ScanArgs)
+			if err != nil {
+				logger.L().Ctx(ctx).Warning("failed to scan cluster", helpers.String("context", kubeContexts[i]), helpers.Error(err))
+			}
+			clusterReports[i] = cautils.NewClusterReport(kubeContexts[i], report, err)
+		}(i)
+	}
+	wg.Wait()
+
+	logger.L().StopSuccess(fmt.Sprintf("Scanned %d clusters", len(kubeContexts)))
+
+	return cautils.NewMultiClusterReport(clusterReports), nil
+}
+
+// listKubeContexts returns all the contexts of the kubeconfig. The kubeconfig is loaded directly since
+// k8sinterface only exposes it once connected to the current cluster, which may be unreachable
+func listKubeContexts() []string {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig := flag.Lookup("kubeconfig"); kubeconfig != nil {
+		loadingRules.ExplicitPath = kubeconfig.Value.String()
+	}
+	config, err := loadingRules.Load()
+	if err != nil {
+		return nil
+	}
+	kubeContexts := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		kubeContexts = append(kubeContexts, name)
+	}
+	sort.Strings(kubeContexts)
+	return kubeContexts
+}
+
+// execClusterScan runs the scan of a single cluster in a child process and reads its JSON report
+func execClusterScan(ctx context.Context, kubeContext string, scanArgs []string) (*reporthandlingv2.PostureReport, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	outputFile, err := os.CreateTemp("", "kubescape-cluster-*.json")
+	if err != nil {
+		return nil, err
+	}
+	outputFile.Close()
+	defer os.Remove(outputFile.Name())
+
+	args := append(slices.Clone(scanArgs), "--kube-context", kubeContext, "--format", "json", "--output", outputFile.Name())
+	cmd := exec.CommandContext(ctx, executable, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	// the scan exits with a non-zero code when a threshold is exceeded, the report is written anyway
+	report, err := readPostureReport(outputFile.Name())
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("%w: %s", runErr, lastLine(stderr.String()))
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+func readPostureReport(path string) (*reporthandlingv2.PostureReport, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("empty scan report")
+	}
+	report := &reporthandlingv2.PostureReport{}
+	if err := json.Unmarshal(content, report); err != nil {
+		return nil, fmt.Errorf("failed to parse scan report: %w", err)
+	}
+	return report, nil
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanClusters(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	var receivedArgs [][]string
+
+	scanner := func(ctx context.Context, kubeContext string, scanArgs []string) (*reporthandlingv2.PostureReport, error) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		receivedArgs = append(receivedArgs, scanArgs)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		if kubeContext == "broken" {
+			return nil, fmt.Errorf("connection refused")
+		}
+		report := &reporthandlingv2.PostureReport{}
+		report.SummaryDetails.ComplianceScore = 90
+		return report, nil
+	}
+
+	report, err := scanClusters(context.TODO(), &metav1.MultiClusterScanInfo{
+		KubeContexts: []string{"prod", "broken", "staging"},
+		ScanArgs:     []string{"scan", "framework", "nsa"},
+		Parallelism:  2,
+	}, scanner)
+	require.NoError(t, err)
+
+	require.Len(t, report.Clusters, 3)
+	assert.Equal(t, "broken", report.Clusters[0].KubeContext)
+	assert.Equal(t, "connection refused", report.Clusters[0].Error)
+	assert.Nil(t, report.Clusters[0].Report)
+	assert.Equal(t, float32(90), report.Clusters[1].ComplianceScore)
+	assert.Equal(t, float32(90), report.ComplianceScore)
+	assert.LessOrEqual(t, maxRunning, 2)
+	for _, args := range receivedArgs {
+		assert.Equal(t, []string{"scan", "framework", "nsa"}, args)
+	}
+}
+
+func TestListKubeContexts(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+contexts:
+- name: staging
+  context: {cluster: staging}
+- name: prod
+  context: {cluster: prod}
+`), 0644))
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	assert.Equal(t, []string{"prod", "staging"}, listKubeContexts())
+}
+
+func TestReadPostureReport(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "report.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"summaryDetails": {"complianceScore": 42}}`), 0644))
+	report, err := readPostureReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, float32(42), report.SummaryDetails.ComplianceScore)
+
+	empty := filepath.Join(dir, "empty.json")
+	require.NoError(t, os.WriteFile(empty, nil, 0644))
+	_, err = readPostureReport(empty)
+	assert.Error(t, err)
+}
+
+func TestLastLine(t *testing.T) {
+	assert.Equal(t, "fatal error", lastLine("info\nfatal error\n"))
+	assert.Equal(t, "", lastLine(""))
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+)
+
+// ociCacheDirName is the directory of ~/.kubescape caching the policy bundles pulled from OCI registries
+const ociCacheDirName = "oci"
+
+// verifyPolicies verifies the local policy files of the scan against the checksum manifests written by "download". The
+// verification only fails the scan when a public key is set, otherwise modified files are logged as a warning. The policy
+// bundles of OCI registries are verified once they are pulled, the files set explicitly along with a bundle are verified here
+func verifyPolicies(ctx context.Context, scanInfo *cautils.ScanInfo) error {
+	// an unsigned manifest can be regenerated along with the modified policies
+	if scanInfo.RequireSignedPolicies && scanInfo.PoliciesPublicKey == "" {
+		return fmt.Errorf("%w: --require-signed-policies requires --policies-public-key", getter.ErrUnverifiedPolicies)
+	}
+
+	// the policies downloaded during the scan are not covered by a manifest
+	if scanInfo.RequireSignedPolicies && !getter.IsOCIReference(scanInfo.UseArtifactsFrom) && (len(scanInfo.UseFrom) == 0 || scanInfo.ControlsInputs == "" || scanInfo.UseExceptions == "" || scanInfo.AttackTracks == "") {
+		return fmt.Errorf("%w: signed policies are required, download them with '%s download artifacts' and scan with --use-artifacts-from", getter.ErrUnverifiedPolicies, cautils.ExecName())
+	}
+
+	files := getPolicyFiles(scanInfo)
+	if len(files) == 0 {
+		return nil
+	}
+	return verifyPolicyFiles(ctx, files, scanInfo.PoliciesPublicKey)
+}
+
+// getOCIPolicy pulls the policy bundle of the OCI reference of the scan and verifies its files against the checksum manifest of
+// the bundle
+func getOCIPolicy(ctx context.Context, scanInfo *cautils.ScanInfo) (*getter.OCIPolicy, error) {
+	ociPolicy, err := getter.NewOCIPolicy(ctx, scanInfo.UseArtifactsFrom, getter.GetDefaultPath(ociCacheDirName))
+	if err != nil {
+		return nil, err
+	}
+
+	bundleFiles, err := ociPolicy.Files()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range bundleFiles {
+		if name := filepath.Base(file); name != getter.ChecksumsFilename && name != getter.ChecksumsSignatureFilename {
+			files = append(files, file)
+		}
+	}
+	if err := verifyPolicyFiles(ctx, files, scanInfo.PoliciesPublicKey); err != nil {
+		return nil, err
+	}
+	return ociPolicy, nil
+}
+
+// getOCIPolicyGetters returns the getters of the artifacts of the policy bundle. The artifacts set explicitly in the scan,
+// e.g. with --controls-config or --exceptions, are loaded from their files instead
+func getOCIPolicyGetters(scanInfo *cautils.ScanInfo, ociPolicy *getter.OCIPolicy) cautils.Getters {
+	getters := cautils.Getters{
+		PolicyGetter:         ociPolicy,
+		ControlsInputsGetter: ociPolicy,
+		ExceptionsGetter:     ociPolicy,
+		AttackTracksGetter:   ociPolicy,
+	}
+	if len(scanInfo.UseFrom) > 0 {
+		getters.PolicyGetter = getter.NewLoadPolicy(scanInfo.UseFrom)
+	}
+	if scanInfo.ControlsInputs != "" {
+		getters.ControlsInputsGetter = getter.NewLoadPolicy([]string{scanInfo.ControlsInputs})
+	}
+	if scanInfo.UseExceptions != "" {
+		getters.ExceptionsGetter = getter.NewLoadPolicy([]string{scanInfo.UseExceptions})
+	}
+	if scanInfo.AttackTracks != "" {
+		getters.AttackTracksGetter = getter.NewLoadPolicy([]string{scanInfo.AttackTracks})
+	}
+	return getters
+}
+
+// verifyPolicyFiles verifies the files against their checksum manifests. The verification is only required when the manifests
+// must be signed by the public key, an unsigned manifest can be regenerated locally
+func verifyPolicyFiles(ctx context.Context, files []string, publicKeyPath string) error {
+	if err := getter.VerifyPolicyFiles(files, publicKeyPath); err != nil {
+		switch {
+		case publicKeyPath != "":
+			return fmt.Errorf("failed to verify policies: %w", err)
+		case errors.Is(err, getter.ErrUnverifiedPolicies):
+			logger.L().Ctx(ctx).Debug("policies are not verified", helpers.Error(err))
+		default:
+			logger.L().Ctx(ctx).Warning("policies were modified after they were downloaded", helpers.Error(err))
+		}
+		return nil
+	}
+	logger.L().Ctx(ctx).Info("Verified policies", helpers.Int("files", len(files)), helpers.String("signed", fmt.Sprintf("%t", publicKeyPath != "")))
+	return nil
+}
+
+// getPolicyFiles returns the existing local files the policies, controls inputs, exceptions and attack tracks are loaded from
+func getPolicyFiles(scanInfo *cautils.ScanInfo) []string {
+	var files []string
+	for _, file := range append(append([]string{}, scanInfo.UseFrom...), scanInfo.ControlsInputs, scanInfo.UseExceptions, scanInfo.AttackTracks) {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
+}
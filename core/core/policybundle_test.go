@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPolicies(t *testing.T) {
+	dir := t.TempDir()
+	scanInfo := &cautils.ScanInfo{
+		UseFrom:        []string{filepath.Join(dir, "nsa.json")},
+		ControlsInputs: filepath.Join(dir, "controls-inputs.json"),
+		UseExceptions:  filepath.Join(dir, "exceptions.json"),
+		AttackTracks:   filepath.Join(dir, "attack-tracks.json"), // missing files are not verified
+	}
+	for _, file := range []string{"nsa.json", "controls-inputs.json", "exceptions.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(`{}`), 0600))
+	}
+	ctx := context.Background()
+
+	// no manifest, the policies are only refused when the manifest must be signed
+	assert.NoError(t, verifyPolicies(ctx, scanInfo))
+	scanInfo.PoliciesPublicKey = writePublicKey(t)
+	assert.ErrorIs(t, verifyPolicies(ctx, scanInfo), getter.ErrUnverifiedPolicies)
+
+	// modified policies are refused when the manifest must be signed, and logged otherwise
+	_, err := getter.UpdateChecksums(dir, getPolicyFiles(scanInfo))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "exceptions.json"), []byte(`[]`), 0600))
+	assert.Error(t, verifyPolicies(ctx, scanInfo))
+	scanInfo.PoliciesPublicKey = ""
+	assert.NoError(t, verifyPolicies(ctx, scanInfo))
+
+	t.Run("signed policies without public key", func(t *testing.T) {
+		err := verifyPolicies(ctx, &cautils.ScanInfo{RequireSignedPolicies: true, UseArtifactsFrom: "oci://registry.example.com/kubescape/policies:v1"})
+		assert.ErrorIs(t, err, getter.ErrUnverifiedPolicies)
+		assert.ErrorContains(t, err, "--policies-public-key")
+	})
+
+	t.Run("downloaded policies", func(t *testing.T) {
+		err := verifyPolicies(ctx, &cautils.ScanInfo{RequireSignedPolicies: true, PoliciesPublicKey: writePublicKey(t)})
+		assert.ErrorIs(t, err, getter.ErrUnverifiedPolicies)
+		assert.ErrorContains(t, err, "--use-artifacts-from")
+	})
+}
+
+func TestGetOCIPolicyGetters(t *testing.T) {
+	ociPolicy := &getter.OCIPolicy{}
+	getters := getOCIPolicyGetters(&cautils.ScanInfo{}, ociPolicy)
+	assert.Equal(t, ociPolicy, getters.PolicyGetter)
+	assert.Equal(t, ociPolicy, getters.ControlsInputsGetter)
+	assert.Equal(t, ociPolicy, getters.ExceptionsGetter)
+	assert.Equal(t, ociPolicy, getters.AttackTracksGetter)
+
+	// the artifacts set explicitly are not replaced by the bundle
+	getters = getOCIPolicyGetters(&cautils.ScanInfo{ControlsInputs: "controls-inputs.json", UseExceptions: "exceptions.json"}, ociPolicy)
+	assert.Equal(t, ociPolicy, getters.PolicyGetter)
+	assert.Equal(t, getter.NewLoadPolicy([]string{"controls-inputs.json"}), getters.ControlsInputsGetter)
+	assert.Equal(t, getter.NewLoadPolicy([]string{"exceptions.json"}), getters.ExceptionsGetter)
+	assert.Equal(t, ociPolicy, getters.AttackTracksGetter)
+}
+
+func writePublicKey(t *testing.T) string {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKey, err := cryptoutils.MarshalPublicKeyToPEM(privateKey.Public())
+	require.NoError(t, err)
+	publicKeyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(publicKeyPath, publicKey, 0600))
+	return publicKeyPath
+}
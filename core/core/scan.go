@@ -12,6 +12,7 @@ import (
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
 	"github.com/kubescape/kubescape/v3/core/pkg/hostsensorutils"
 	"github.com/kubescape/kubescape/v3/core/pkg/opaprocessor"
 	"github.com/kubescape/kubescape/v3/core/pkg/policyhandler"
@@ -53,7 +54,13 @@ func getInterfaces(ctx context.Context, scanInfo *cautils.ScanInfo) componentInt
 	}
 
 	// ================== setup tenant object ======================================
-	tenantConfig := cautils.GetTenantConfig(scanInfo.AccountID, scanInfo.AccessKey, k8sinterface.GetContextName(), scanInfo.CustomClusterName, getKubernetesApi())
+	var tenantConfig cautils.ITenantConfig
+	if scanInfo.GetScanningContext() == cautils.ContextSnapshot {
+		// the snapshot is scanned offline, the current kubectl context is not used
+		tenantConfig = cautils.NewLocalConfig(scanInfo.AccountID, scanInfo.AccessKey, getSnapshotClusterName(scanInfo), scanInfo.CustomClusterName)
+	} else {
+		tenantConfig = cautils.GetTenantConfig(scanInfo.AccountID, scanInfo.AccessKey, k8sinterface.GetContextName(), scanInfo.CustomClusterName, getKubernetesApi())
+	}
 
 	// Set submit behavior AFTER loading tenant config
 	setSubmitBehavior(scanInfo, tenantConfig)
@@ -121,24 +128,47 @@ func GetOutputPrinters(scanInfo *cautils.ScanInfo, ctx context.Context, clusterN
 	return outputPrinters
 }
 
-func (ks *Kubescape) Scan(scanInfo *cautils.ScanInfo) (*resultshandling.ResultsHandler, error) {
+// Scan scans the target of the scan info. The resources of the scan are released by the Close method of the results
+// handler, or before returning when the scan fails
+func (ks *Kubescape) Scan(scanInfo *cautils.ScanInfo) (results *resultshandling.ResultsHandler, err error) {
 	ctxInit, spanInit := otel.Tracer("").Start(ks.Context(), "initialization")
 	logger.L().Start("Kubescape scanner initializing...")
 
 	// ===================== Initialization =====================
 	scanInfo.Init(ctxInit) // initialize scan info
-	defer scanInfo.Cleanup()
+	defer func() {
+		if err != nil {
+			scanInfo.Cleanup()
+		}
+	}()
+
+	if err := verifyPolicies(ctxInit, scanInfo); err != nil {
+		spanInit.End()
+		return nil, err
+	}
+	var ociPolicy *getter.OCIPolicy
+	if getter.IsOCIReference(scanInfo.UseArtifactsFrom) {
+		var err error
+		if ociPolicy, err = getOCIPolicy(ctxInit, scanInfo); err != nil {
+			spanInit.End()
+			return nil, err
+		}
+	}
 
 	interfaces := getInterfaces(ctxInit, scanInfo)
 	interfaces.report.SetTenantConfig(interfaces.tenantConfig)
 
 	downloadReleasedPolicy := getter.NewDownloadReleasedPolicy() // download config inputs from github release
 
-	// set policy getter only after setting the customerGUID
-	scanInfo.Getters.PolicyGetter = getPolicyGetter(ctxInit, scanInfo.UseFrom, interfaces.tenantConfig.GetAccountID(), scanInfo.FrameworkScan, downloadReleasedPolicy)
-	scanInfo.Getters.ControlsInputsGetter = getConfigInputsGetter(ctxInit, scanInfo.ControlsInputs, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy)
-	scanInfo.Getters.ExceptionsGetter = getExceptionsGetter(ctxInit, scanInfo.UseExceptions, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy)
-	scanInfo.Getters.AttackTracksGetter = getAttackTracksGetter(ctxInit, scanInfo.AttackTracks, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy)
+	if ociPolicy != nil {
+		scanInfo.Getters = getOCIPolicyGetters(scanInfo, ociPolicy)
+	} else {
+		// set policy getter only after setting the customerGUID
+		scanInfo.Getters.PolicyGetter = getPolicyGetter(ctxInit, scanInfo.UseFrom, interfaces.tenantConfig.GetAccountID(), scanInfo.FrameworkScan, downloadReleasedPolicy)
+		scanInfo.Getters.ControlsInputsGetter = getConfigInputsGetter(ctxInit, scanInfo.ControlsInputs, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy)
+		scanInfo.Getters.ExceptionsGetter = getExceptionsGetter(ctxInit, scanInfo.UseExceptions, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy)
+		scanInfo.Getters.AttackTracksGetter = getAttackTracksGetter(ctxInit, scanInfo.AttackTracks, interfaces.tenantConfig.GetAccountID(), downloadReleasedPolicy)
+	}
 
 	// TODO - list supported frameworks/controls
 	if scanInfo.ScanAll {
@@ -155,6 +185,8 @@ func (ks *Kubescape) Scan(scanInfo *cautils.ScanInfo) (*resultshandling.ResultsH
 	logger.L().StopSuccess("Initialized scanner")
 
 	resultsHandling := resultshandling.NewResultsHandler(interfaces.report, interfaces.outputPrinters, interfaces.uiPrinter)
+	resultsHandling.HistoryFile = history.GetFile(scanInfo)
+	resultsHandling.Cleanup = scanInfo.Cleanup // the resources of the session are read until the results are handled
 
 	// ===================== policies =====================
 	ctxPolicies, spanPolicies := otel.Tracer("").Start(ctxInit, "policies")
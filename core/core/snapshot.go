@@ -0,0 +1,78 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/kubescape/backend/pkg/versioncheck"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/pkg/hostsensorutils"
+	"github.com/kubescape/kubescape/v3/core/pkg/opaprocessor"
+	"github.com/kubescape/kubescape/v3/core/pkg/policyhandler"
+	"github.com/kubescape/kubescape/v3/core/pkg/resourcehandler"
+)
+
+// Snapshot exports the resources of the current cluster required by the selected frameworks into an archive
+// that can be scanned offline with 'kubescape scan --snapshot'
+func (ks *Kubescape) Snapshot(snapshotInfo *metav1.SnapshotInfo, scanInfo *cautils.ScanInfo) error {
+	ctx := ks.Context()
+
+	k8s := getKubernetesApi()
+	if k8s == nil {
+		return fmt.Errorf("failed connecting to Kubernetes cluster")
+	}
+
+	tenantConfig := cautils.GetTenantConfig(scanInfo.AccountID, scanInfo.AccessKey, k8sinterface.GetContextName(), scanInfo.CustomClusterName, k8s)
+
+	downloadReleasedPolicy := getter.NewDownloadReleasedPolicy() // download config inputs from github release
+	scanInfo.Getters.PolicyGetter = getPolicyGetter(ctx, scanInfo.UseFrom, tenantConfig.GetAccountID(), scanInfo.FrameworkScan, downloadReleasedPolicy)
+	scanInfo.Getters.ControlsInputsGetter = getConfigInputsGetter(ctx, scanInfo.ControlsInputs, tenantConfig.GetAccountID(), downloadReleasedPolicy)
+	scanInfo.Getters.ExceptionsGetter = getExceptionsGetter(ctx, scanInfo.UseExceptions, tenantConfig.GetAccountID(), downloadReleasedPolicy)
+
+	// ===================== policies =====================
+	policyHandler := policyhandler.NewPolicyHandler(tenantConfig.GetContextName())
+	scanData, err := policyHandler.CollectPolicies(ctx, scanInfo.PolicyIdentifier, scanInfo)
+	if err != nil {
+		return err
+	}
+
+	// ===================== host scanner =====================
+	hostSensorHandler := getHostSensorHandler(ctx, scanInfo, k8s)
+	if err := hostSensorHandler.Init(ctx); err != nil {
+		logger.L().Ctx(ctx).Error("failed to init host scanner", helpers.Error(err))
+		hostSensorHandler = hostsensorutils.NewHostSensorHandlerMock()
+	}
+	defer func() {
+		if err := hostSensorHandler.TearDown(); err != nil {
+			logger.L().Ctx(ctx).StopError("Failed to tear down host scanner", helpers.Error(err))
+		}
+	}()
+
+	// ===================== resources =====================
+	resourceHandler := resourcehandler.NewK8sResourceHandler(k8s, hostSensorHandler, nil, tenantConfig.GetContextName())
+	snapshot, err := resourceHandler.PullSnapshot(ctx, scanData.Policies, scanInfo, scanInfo.HostSensorEnabled.GetBool())
+	if err != nil {
+		return err
+	}
+
+	snapshot.Metadata.KubescapeVersion = versioncheck.BuildNumber
+	for _, policy := range scanData.Policies {
+		snapshot.Metadata.Frameworks = append(snapshot.Metadata.Frameworks, policy.Name)
+	}
+
+	// the snapshot leaves the cluster, secrets are redacted the same way they are in the scan results
+	for _, resource := range snapshot.ListResources() {
+		opaprocessor.RedactSecret(resource)
+	}
+
+	if err := snapshot.WriteArchive(snapshotInfo.Output); err != nil {
+		return fmt.Errorf("failed to write cluster snapshot: %w", err)
+	}
+
+	logger.L().Success("Cluster snapshot saved", helpers.String("path", snapshotInfo.Output), helpers.Int("resources", len(snapshot.ListResources())))
+	return nil
+}
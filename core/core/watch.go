@@ -0,0 +1,68 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/kubescape/v3/core/pkg/hostsensorutils"
+	"github.com/kubescape/kubescape/v3/core/pkg/opaprocessor"
+	"github.com/kubescape/kubescape/v3/core/pkg/policyhandler"
+	"github.com/kubescape/kubescape/v3/core/pkg/resourcehandler"
+	"github.com/kubescape/kubescape/v3/core/pkg/watchhandler"
+	"github.com/kubescape/opa-utils/resources"
+)
+
+const webhookTimeout = 30 * time.Second
+
+// Watch scans the current cluster once and then keeps watching the resources required by the selected frameworks.
+// The controls are evaluated again when the resources change, and the status changes are printed to stdout as JSON lines
+// and posted to the webhook
+func (ks *Kubescape) Watch(watchInfo *metav1.WatchInfo, scanInfo *cautils.ScanInfo) error {
+	ctx := ks.Context()
+
+	scanInfo.Init(ctx)
+	defer scanInfo.Cleanup()
+
+	k8s := getKubernetesApi()
+	if k8s == nil {
+		return fmt.Errorf("failed connecting to Kubernetes cluster")
+	}
+
+	tenantConfig := cautils.GetTenantConfig(scanInfo.AccountID, scanInfo.AccessKey, k8sinterface.GetContextName(), scanInfo.CustomClusterName, k8s)
+
+	downloadReleasedPolicy := getter.NewDownloadReleasedPolicy() // download config inputs from github release
+	scanInfo.Getters.PolicyGetter = getPolicyGetter(ctx, scanInfo.UseFrom, tenantConfig.GetAccountID(), scanInfo.FrameworkScan, downloadReleasedPolicy)
+	scanInfo.Getters.ControlsInputsGetter = getConfigInputsGetter(ctx, scanInfo.ControlsInputs, tenantConfig.GetAccountID(), downloadReleasedPolicy)
+	scanInfo.Getters.ExceptionsGetter = getExceptionsGetter(ctx, scanInfo.UseExceptions, tenantConfig.GetAccountID(), downloadReleasedPolicy)
+
+	// ===================== policies =====================
+	policyHandler := policyhandler.NewPolicyHandler(tenantConfig.GetContextName())
+	scanData, err := policyHandler.CollectPolicies(ctx, scanInfo.PolicyIdentifier, scanInfo)
+	if err != nil {
+		return err
+	}
+
+	// ===================== resources =====================
+	// only the Kubernetes resources are watched, the host scanner is not deployed
+	resourceHandler := resourcehandler.NewK8sResourceHandler(k8s, hostsensorutils.NewHostSensorHandlerMock(), nil, tenantConfig.GetContextName())
+	if err := resourcehandler.CollectResources(ctx, resourceHandler, scanData, scanInfo); err != nil {
+		return err
+	}
+
+	// ===================== watch =====================
+	deps := resources.NewRegoDependenciesData(k8sinterface.GetK8sConfig(), tenantConfig.GetContextName())
+	processor := opaprocessor.NewOPAProcessor(scanData, deps, tenantConfig.GetContextName(), scanInfo.ExcludedNamespaces, scanInfo.IncludeNamespaces, scanInfo.EnableRegoPrint)
+
+	senders := []watchhandler.IEventSender{watchhandler.NewJSONLinesSender(os.Stdout)}
+	if watchInfo.WebhookURL != "" {
+		senders = append(senders, watchhandler.NewWebhookSender(watchInfo.WebhookURL, webhookTimeout))
+	}
+
+	watcher := watchhandler.NewWatcher(k8s.DynamicClient, scanData, processor, tenantConfig.GetContextName(), watchInfo.Interval, senders...)
+	return watcher.Run(ctx)
+}
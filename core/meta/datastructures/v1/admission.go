@@ -0,0 +1,9 @@
+package v1
+
+import "time"
+
+type AdmissionInfo struct {
+	SeverityThreshold string        // failed controls at or above this severity deny the request, e.g. "high"
+	WarnOnly          bool          // never deny, the failed controls are only returned as warnings
+	PoliciesCacheTTL  time.Duration // the policies and exceptions are collected again once expired
+}
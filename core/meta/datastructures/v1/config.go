@@ -9,7 +9,8 @@ type SetConfig struct {
 	CloudAPIURL    string
 }
 type ViewConfig struct {
-	Writer io.Writer
+	Writer         io.Writer
+	ScanConfigFile string // path to the scan config file, discovered from the working directory when not set
 }
 type DeleteConfig struct {
 }
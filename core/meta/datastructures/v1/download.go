@@ -1,10 +1,12 @@
 package v1
 
 type DownloadInfo struct {
-	Path       string // directory to save artifact. Default is "~/.kubescape/"
-	FileName   string // can be empty
-	Target     string // type of artifact to download
-	Identifier string // identifier of artifact to download
-	AccountID  string
-	AccessKey  string
+	Path            string // directory to save artifact. Default is "~/.kubescape/"
+	FileName        string // can be empty
+	Target          string // type of artifact to download
+	Identifier      string // identifier of artifact to download
+	AccountID       string
+	AccessKey       string
+	PushReference   string   // OCI reference the downloaded files are pushed to as a policy bundle, e.g. oci://registry.example.com/kubescape/policies:v1
+	DownloadedFiles []string // paths of the saved files, added to the checksum manifest of the directory
 }
@@ -5,4 +5,23 @@ type FixInfo struct {
 	NoConfirm      bool   // if true, no confirmation will be given to the user before applying the fix
 	SkipUserValues bool   // if true, user values will not be changed
 	DryRun         bool   // if true, no changes will be applied
+	GitBranch      string // if set, the fixes are committed to a new git branch, a commit per control
+	PatchFile      string // path of the patch file the commits of the git branch are written to
+	PushRemote     string // name or URL of the remote the git branch is pushed to
+	HelmValuesFile string // values file the fixes of Helm charts are written to, instead of the values.yaml of the chart
+	KustomizePatch string // type of the patches written to Kustomize overlays, "strategic-merge" or "json6902"
+	Cluster        bool   // if true, the resources of a cluster scan are fixed in the cluster with server-side apply
+}
+
+type FixRollbackInfo struct {
+	ID        string // ID of the cluster fix to roll back, the fixes are listed when empty
+	NoConfirm bool   // if true, no confirmation will be given to the user before rolling back the fix
+}
+
+type ExploreInfo struct {
+	ReportFile     string // path to report file (mandatory)
+	ExceptionsFile string // path of the exceptions file the exceptions of the marked findings are written to
+	Severity       string // initial severity filter, all the severities when empty
+	Namespace      string // initial namespace filter, all the namespaces when empty
+	Kind           string // initial kind filter, all the kinds when empty
 }
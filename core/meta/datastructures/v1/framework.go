@@ -0,0 +1,17 @@
+package v1
+
+type FrameworkCreateInfo struct {
+	Name              string                         // name of the framework
+	Description       string                         // description of the framework
+	Inherit           []string                       // frameworks all controls are included from
+	Controls          []string                       // IDs of the controls added to the framework
+	ExcludeFrameworks []string                       // frameworks whose controls are removed
+	ExcludeControls   []string                       // IDs of the controls removed from the framework
+	Severities        map[string]string              // severity overrides by control ID
+	ScoreFactors      map[string]float32             // score factor overrides by control ID
+	ControlsInputs    map[string]map[string][]string // controls inputs overrides by control ID, map[<control ID>]map[<input name>][]<value>
+	Output            string                         // path of the framework definition, <name>.yaml by default
+	UseArtifactsFrom  string                         // resolve the controls from the artifacts of this directory instead of downloading them
+	AccountID         string
+	AccessKey         string
+}
@@ -0,0 +1,13 @@
+package v1
+
+import "time"
+
+type HistoryInfo struct {
+	HistoryFile string        // path of the history store, the default store in the cache directory when empty
+	Target      string        // cluster name, repository URL or path of the scans. All the targets when empty
+	Framework   string        // framework of the scores. The compliance scores of the scans when empty
+	Since       time.Duration // scans of the last period only. All the scans when 0
+	Limit       int           // latest scans of each target. All the scans when 0
+	Format      string        // output format, pretty-printer or json
+	Output      string        // output file, stdout when empty
+}
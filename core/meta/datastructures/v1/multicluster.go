@@ -0,0 +1,7 @@
+package v1
+
+type MultiClusterScanInfo struct {
+	KubeContexts []string // kube contexts to scan. Empty for all the contexts of the kubeconfig
+	ScanArgs     []string // arguments of the scan of a single cluster, e.g. ["scan", "framework", "nsa"]
+	Parallelism  int      // number of clusters scanned concurrently
+}
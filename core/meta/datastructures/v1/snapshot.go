@@ -0,0 +1,5 @@
+package v1
+
+type SnapshotInfo struct {
+	Output string // path of the snapshot archive, e.g. "cluster-snapshot.tar.gz"
+}
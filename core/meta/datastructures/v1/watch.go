@@ -0,0 +1,8 @@
+package v1
+
+import "time"
+
+type WatchInfo struct {
+	WebhookURL string        // webhook the status change events are posted to, in addition to stdout
+	Interval   time.Duration // changes are batched and evaluated once per interval
+}
@@ -13,10 +13,12 @@ type IKubescape interface {
 	Context() context.Context
 
 	Scan(scanInfo *cautils.ScanInfo) (*resultshandling.ResultsHandler, error) // TODO - use scanInfo from v1
+	ScanClusters(multiClusterInfo *metav1.MultiClusterScanInfo) (*cautils.MultiClusterReport, error)
 
 	// policies
 	List(listPolicies *metav1.ListPolicies) error     // TODO - return list response
 	Download(downloadInfo *metav1.DownloadInfo) error // TODO - return downloaded policies
+	CreateFramework(createInfo *metav1.FrameworkCreateInfo) error
 
 	// config
 	SetCachedConfig(setConfig *metav1.SetConfig) error
@@ -25,10 +27,23 @@ type IKubescape interface {
 
 	// fix
 	Fix(fixInfo *metav1.FixInfo) error
+	FixRollback(rollbackInfo *metav1.FixRollbackInfo) error
+
+	// history
+	History(historyInfo *metav1.HistoryInfo) error
+
+	// explore
+	Explore(exploreInfo *metav1.ExploreInfo) error
 
 	// patch
 	Patch(patchInfo *metav1.PatchInfo, scanInfo *cautils.ScanInfo) (*models.PresenterConfig, error)
 
 	// scan image
 	ScanImage(imgScanInfo *metav1.ImageScanInfo, scanInfo *cautils.ScanInfo) (*models.PresenterConfig, error)
+
+	// snapshot
+	Snapshot(snapshotInfo *metav1.SnapshotInfo, scanInfo *cautils.ScanInfo) error
+
+	// watch
+	Watch(watchInfo *metav1.WatchInfo, scanInfo *cautils.ScanInfo) error
 }
@@ -19,6 +19,10 @@ func (m *MockIKubescape) Scan(scanInfo *cautils.ScanInfo) (*resultshandling.Resu
 	return nil, nil
 }
 
+func (m *MockIKubescape) ScanClusters(multiClusterInfo *metav1.MultiClusterScanInfo) (*cautils.MultiClusterReport, error) {
+	return cautils.NewMultiClusterReport(nil), nil
+}
+
 func (m *MockIKubescape) List(listPolicies *metav1.ListPolicies) error {
 	return nil
 }
@@ -27,6 +31,10 @@ func (m *MockIKubescape) Download(downloadInfo *metav1.DownloadInfo) error {
 	return nil
 }
 
+func (m *MockIKubescape) CreateFramework(createInfo *metav1.FrameworkCreateInfo) error {
+	return nil
+}
+
 func (m *MockIKubescape) SetCachedConfig(setConfig *metav1.SetConfig) error {
 	return nil
 }
@@ -43,6 +51,18 @@ func (m *MockIKubescape) Fix(fixInfo *metav1.FixInfo) error {
 	return nil
 }
 
+func (m *MockIKubescape) FixRollback(rollbackInfo *metav1.FixRollbackInfo) error {
+	return nil
+}
+
+func (m *MockIKubescape) History(historyInfo *metav1.HistoryInfo) error {
+	return nil
+}
+
+func (m *MockIKubescape) Explore(exploreInfo *metav1.ExploreInfo) error {
+	return nil
+}
+
 func (m *MockIKubescape) Patch(patchInfo *metav1.PatchInfo, scanInfo *cautils.ScanInfo) (*models.PresenterConfig, error) {
 	return nil, nil
 }
@@ -50,3 +70,11 @@ func (m *MockIKubescape) Patch(patchInfo *metav1.PatchInfo, scanInfo *cautils.Sc
 func (m *MockIKubescape) ScanImage(imgScanInfo *metav1.ImageScanInfo, scanInfo *cautils.ScanInfo) (*models.PresenterConfig, error) {
 	return nil, nil
 }
+
+func (m *MockIKubescape) Snapshot(snapshotInfo *metav1.SnapshotInfo, scanInfo *cautils.ScanInfo) error {
+	return nil
+}
+
+func (m *MockIKubescape) Watch(watchInfo *metav1.WatchInfo, scanInfo *cautils.ScanInfo) error {
+	return nil
+}
@@ -0,0 +1,233 @@
+package admissionhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/opaprocessor"
+	"github.com/kubescape/opa-utils/objectsenvelopes"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/kubescape/opa-utils/resources"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PoliciesCollector collects the policies, exceptions and controls inputs the admitted resources are evaluated against
+type PoliciesCollector func(ctx context.Context) (*cautils.OPASessionObj, error)
+
+// FailedControl is a control the admitted resource fails
+type FailedControl struct {
+	ControlID   string
+	Name        string
+	Severity    int
+	Remediation string
+}
+
+// String returns a readable description of the failed control, e.g. "C-0057 Privileged container (High): Remove privileged capabilities"
+func (control *FailedControl) String() string {
+	s := fmt.Sprintf("%s %s (%s)", control.ControlID, control.Name, apis.SeverityNumberToString(control.Severity))
+	if control.Remediation != "" {
+		s += ": " + control.Remediation
+	}
+	return s
+}
+
+// Validator evaluates the resources of admission requests against cached policies. The resources failing controls with a
+// severity at or above the threshold are denied, the other failed controls are returned as warnings.
+// Only the admitted resource is evaluated, the related resources of the cluster are not pulled
+type Validator struct {
+	collectPolicies      PoliciesCollector
+	regoDependenciesData *resources.RegoDependenciesData
+	clusterName          string
+	severityThreshold    int
+	warnOnly             bool // never deny, the failed controls are only returned as warnings
+	cacheTtl             time.Duration
+
+	mu         sync.Mutex // guards the cached policies
+	policies   *cautils.OPASessionObj
+	expiration time.Time
+}
+
+func NewValidator(collectPolicies PoliciesCollector, regoDependenciesData *resources.RegoDependenciesData, clusterName string, severityThreshold int, warnOnly bool, cacheTtl time.Duration) *Validator {
+	return &Validator{
+		collectPolicies:      collectPolicies,
+		regoDependenciesData: regoDependenciesData,
+		clusterName:          clusterName,
+		severityThreshold:    severityThreshold,
+		warnOnly:             warnOnly,
+		cacheTtl:             cacheTtl,
+	}
+}
+
+// ParseSeverity returns the severity number of a severity name, e.g. "high"
+func ParseSeverity(severity string) (int, error) {
+	for i, supported := range apis.GetSupportedSeverities() {
+		if strings.EqualFold(severity, supported) {
+			return apis.SeverityLow + i, nil
+		}
+	}
+	return apis.SeverityUnknown, fmt.Errorf("unknown severity: %q, supported severities: %s", severity, strings.Join(apis.GetSupportedSeverities(), ", "))
+}
+
+// Validate evaluates the resource of the admission request and returns the admission response
+func (v *Validator) Validate(ctx context.Context, request *admissionv1.AdmissionRequest) (*admissionv1.AdmissionResponse, error) {
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+
+	// deleted resources are not evaluated
+	if request.Operation != admissionv1.Create && request.Operation != admissionv1.Update {
+		return response, nil
+	}
+
+	resource, err := getAdmittedResource(request)
+	if err != nil {
+		return nil, err
+	}
+	// resources with a parent are evaluated through their parent, the same way they are when the cluster is scanned
+	if k8sinterface.IsTypeWorkload(resource.GetObject()) && k8sinterface.WorkloadHasParent(workloadinterface.NewWorkloadObj(resource.GetObject())) {
+		return response, nil
+	}
+	groupVersionResource, err := k8sinterface.GetGroupVersionResource(resource.GetKind())
+	if err != nil {
+		// kinds unknown to Kubescape are not covered by any control
+		return response, nil
+	}
+
+	policies, err := v.getPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	triplet := k8sinterface.JoinResourceTriplets(groupVersionResource.Group, groupVersionResource.Version, groupVersionResource.Resource)
+	failedControls := v.evaluate(ctx, policies, triplet, resource)
+
+	var denied []string
+	for i := range failedControls {
+		if !v.warnOnly && failedControls[i].Severity >= v.severityThreshold {
+			denied = append(denied, failedControls[i].String())
+		} else {
+			response.Warnings = append(response.Warnings, failedControls[i].String())
+		}
+	}
+	if len(denied) > 0 {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonForbidden,
+			Code:    http.StatusForbidden,
+			Message: fmt.Sprintf("%s %s is denied by Kubescape, failed controls: %s", strings.ToLower(resource.GetKind()), getReadableName(resource), strings.Join(denied, "; ")),
+		}
+	}
+	logger.L().Ctx(ctx).Debug("admission request evaluated",
+		helpers.String("resource", resource.GetID()),
+		helpers.String("operation", string(request.Operation)),
+		helpers.Interface("allowed", response.Allowed),
+		helpers.Int("failedControls", len(failedControls)))
+
+	return response, nil
+}
+
+// evaluate evaluates the controls on the resource and returns the failed controls sorted by severity
+func (v *Validator) evaluate(ctx context.Context, policies *cautils.OPASessionObj, triplet string, resource workloadinterface.IMetadata) []FailedControl {
+	sessionObj := &cautils.OPASessionObj{
+		Policies:      policies.Policies,
+		Exceptions:    policies.Exceptions,
+		RegoInputData: policies.RegoInputData,
+		Metadata:      policies.Metadata,
+		Report:        &reporthandlingv2.PostureReport{},
+		K8SResources:  cautils.K8SResources{triplet: {resource.GetID()}},
+		AllResources:  map[string]workloadinterface.IMetadata{resource.GetID(): resource},
+	}
+
+	// the processor updates the controls inputs of the dependencies data, each request uses its own copy
+	regoDependenciesData := *v.regoDependenciesData
+	processor := opaprocessor.NewOPAProcessor(sessionObj, &regoDependenciesData, v.clusterName, "", "", false)
+	result, ok := processor.EvaluateControls(ctx, nil)[resource.GetID()]
+	if !ok {
+		return nil
+	}
+
+	var failedControls []FailedControl
+	for _, control := range result.ListControls() {
+		if !control.GetStatus(nil).IsFailed() {
+			continue
+		}
+		failedControl := FailedControl{ControlID: control.GetID(), Name: control.GetName()}
+		if policy, ok := sessionObj.AllPolicies.Controls[control.GetID()]; ok {
+			failedControl.Severity = apis.ControlSeverityToInt(policy.BaseScore)
+			failedControl.Remediation = policy.Remediation
+		}
+		failedControls = append(failedControls, failedControl)
+	}
+	sort.Slice(failedControls, func(i, j int) bool {
+		if failedControls[i].Severity == failedControls[j].Severity {
+			return failedControls[i].ControlID < failedControls[j].ControlID
+		}
+		return failedControls[i].Severity > failedControls[j].Severity
+	})
+	return failedControls
+}
+
+// getPolicies returns the cached policies, the policies are collected again once the cache expires.
+// The expired policies are kept when they cannot be collected
+func (v *Validator) getPolicies(ctx context.Context) (*cautils.OPASessionObj, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.policies != nil && time.Now().Before(v.expiration) {
+		return v.policies, nil
+	}
+	policies, err := v.collectPolicies(ctx)
+	if err != nil {
+		if v.policies != nil {
+			logger.L().Ctx(ctx).Warning("failed to collect policies, using the expired policies", helpers.Error(err))
+			return v.policies, nil
+		}
+		return nil, fmt.Errorf("failed to collect policies: %w", err)
+	}
+	v.policies = policies
+	v.expiration = time.Now().Add(v.cacheTtl)
+	return v.policies, nil
+}
+
+// getAdmittedResource returns the resource of the admission request
+func getAdmittedResource(request *admissionv1.AdmissionRequest) (workloadinterface.IMetadata, error) {
+	object := make(map[string]interface{})
+	if err := json.Unmarshal(request.Object.Raw, &object); err != nil {
+		return nil, fmt.Errorf("failed to decode the object of the admission request: %w", err)
+	}
+	resource := objectsenvelopes.NewObject(object)
+	if resource == nil {
+		return nil, fmt.Errorf("unsupported object of kind %q", request.Kind.Kind)
+	}
+
+	// the name and namespace are not set yet when they are generated by the api server
+	if resource.GetNamespace() == "" && request.Namespace != "" {
+		resource.SetNamespace(request.Namespace)
+	}
+	if resource.GetName() == "" {
+		if request.Name != "" {
+			resource.SetName(request.Name)
+		} else if k8sinterface.IsTypeWorkload(object) {
+			resource.SetName(workloadinterface.NewWorkloadObj(object).GetGenerateName())
+		}
+	}
+	return resource, nil
+}
+
+func getReadableName(resource workloadinterface.IMetadata) string {
+	if resource.GetNamespace() != "" {
+		return resource.GetNamespace() + "/" + resource.GetName()
+	}
+	return resource.GetName()
+}
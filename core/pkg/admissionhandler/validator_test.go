@@ -0,0 +1,201 @@
+package admissionhandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/armosec/armoapi-go/identifiers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	privilegedRule  = "package armo_builtins\n\ndeny[msga] {\n    pod := input[_]\n    pod.kind == \"Pod\"\n    pod.spec.containers[_].securityContext.privileged == true\n    msga := {\n        \"alertMessage\": \"privileged container\",\n        \"packagename\": \"armo_builtins\",\n        \"alertScore\": 7,\n        \"failedPaths\": [],\n        \"fixPaths\": [],\n        \"alertObject\": {\"k8sApiObjects\": [pod]}\n    }\n}\n"
+	hostNetworkRule = "package armo_builtins\n\ndeny[msga] {\n    pod := input[_]\n    pod.kind == \"Pod\"\n    pod.spec.hostNetwork == true\n    msga := {\n        \"alertMessage\": \"host network\",\n        \"packagename\": \"armo_builtins\",\n        \"alertScore\": 7,\n        \"failedPaths\": [],\n        \"fixPaths\": [],\n        \"alertObject\": {\"k8sApiObjects\": [pod]}\n    }\n}\n"
+)
+
+func mockPolicies() *cautils.OPASessionObj {
+	newControl := func(controlID, name, remediation, rule string, baseScore float32) reporthandling.Control {
+		return reporthandling.Control{
+			ControlID:   controlID,
+			PortalBase:  armotypes.PortalBase{Name: name},
+			Remediation: remediation,
+			BaseScore:   baseScore,
+			Rules: []reporthandling.PolicyRule{{
+				PortalBase:   armotypes.PortalBase{Name: controlID + "-rule"},
+				Rule:         rule,
+				Match:        []reporthandling.RuleMatchObjects{{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"Pod"}}},
+				RuleLanguage: reporthandling.RegoLanguage,
+			}},
+		}
+	}
+	sessionObj := cautils.NewOPASessionObjMock()
+	sessionObj.Policies = []reporthandling.Framework{{
+		PortalBase: armotypes.PortalBase{Name: "test"},
+		Controls: []reporthandling.Control{
+			newControl("C-0057", "Privileged container", "Remove privileged capabilities", privilegedRule, 8),
+			newControl("C-0041", "HostNetwork access", "Do not use the host network", hostNetworkRule, 4),
+		},
+	}}
+	return sessionObj
+}
+
+func newAdmissionRequest(t *testing.T, operation admissionv1.Operation, pod map[string]interface{}) *admissionv1.AdmissionRequest {
+	raw, err := json.Marshal(pod)
+	require.NoError(t, err)
+	return &admissionv1.AdmissionRequest{
+		UID:       "uid",
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Namespace: "default",
+		Operation: operation,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func mockPod(name string, privileged, hostNetwork bool) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"hostNetwork": hostNetwork,
+			"containers": []interface{}{
+				map[string]interface{}{"name": "nginx", "image": "nginx", "securityContext": map[string]interface{}{"privileged": privileged}},
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	policies := mockPolicies()
+	validator := NewValidator(func(context.Context) (*cautils.OPASessionObj, error) { return policies, nil }, resources.NewRegoDependenciesData(nil, ""), "", apis.SeverityHigh, false, time.Minute)
+
+	t.Run("compliant resource is allowed", func(t *testing.T) {
+		response, err := validator.Validate(context.Background(), newAdmissionRequest(t, admissionv1.Create, mockPod("nginx", false, false)))
+		require.NoError(t, err)
+		assert.True(t, response.Allowed)
+		assert.Equal(t, "uid", string(response.UID))
+		assert.Empty(t, response.Warnings)
+	})
+
+	t.Run("failed controls below the threshold are warnings", func(t *testing.T) {
+		response, err := validator.Validate(context.Background(), newAdmissionRequest(t, admissionv1.Update, mockPod("nginx", false, true)))
+		require.NoError(t, err)
+		assert.True(t, response.Allowed)
+		assert.Equal(t, []string{"C-0041 HostNetwork access (Medium): Do not use the host network"}, response.Warnings)
+	})
+
+	t.Run("failed controls at the threshold are denied", func(t *testing.T) {
+		response, err := validator.Validate(context.Background(), newAdmissionRequest(t, admissionv1.Create, mockPod("nginx", true, true)))
+		require.NoError(t, err)
+		assert.False(t, response.Allowed)
+		require.NotNil(t, response.Result)
+		assert.Equal(t, int32(403), response.Result.Code)
+		assert.Equal(t, "pod default/nginx is denied by Kubescape, failed controls: C-0057 Privileged container (High): Remove privileged capabilities", response.Result.Message)
+		assert.Equal(t, []string{"C-0041 HostNetwork access (Medium): Do not use the host network"}, response.Warnings)
+	})
+
+	t.Run("deleted resource is allowed", func(t *testing.T) {
+		response, err := validator.Validate(context.Background(), newAdmissionRequest(t, admissionv1.Delete, mockPod("nginx", true, false)))
+		require.NoError(t, err)
+		assert.True(t, response.Allowed)
+	})
+
+	t.Run("resource with a parent is allowed", func(t *testing.T) {
+		pod := mockPod("nginx-abc", true, false)
+		pod["metadata"].(map[string]interface{})["ownerReferences"] = []interface{}{
+			map[string]interface{}{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "nginx", "uid": "1"},
+		}
+		response, err := validator.Validate(context.Background(), newAdmissionRequest(t, admissionv1.Create, pod))
+		require.NoError(t, err)
+		assert.True(t, response.Allowed)
+	})
+
+	t.Run("invalid object", func(t *testing.T) {
+		request := newAdmissionRequest(t, admissionv1.Create, nil)
+		request.Object.Raw = []byte("{")
+		_, err := validator.Validate(context.Background(), request)
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateWarnOnly(t *testing.T) {
+	validator := NewValidator(func(context.Context) (*cautils.OPASessionObj, error) { return mockPolicies(), nil }, resources.NewRegoDependenciesData(nil, ""), "", apis.SeverityLow, true, time.Minute)
+
+	response, err := validator.Validate(context.Background(), newAdmissionRequest(t, admissionv1.Create, mockPod("nginx", true, true)))
+	require.NoError(t, err)
+	assert.True(t, response.Allowed)
+	assert.Equal(t, []string{
+		"C-0057 Privileged container (High): Remove privileged capabilities",
+		"C-0041 HostNetwork access (Medium): Do not use the host network",
+	}, response.Warnings)
+}
+
+func TestValidateExceptions(t *testing.T) {
+	policies := mockPolicies()
+	policies.Exceptions = []armotypes.PostureExceptionPolicy{{
+		PortalBase:      armotypes.PortalBase{Name: "allow-privileged-nginx"},
+		PolicyType:      "postureExceptionPolicy",
+		Actions:         []armotypes.PostureExceptionPolicyActions{armotypes.AlertOnly},
+		Resources:       []identifiers.PortalDesignator{{DesignatorType: identifiers.DesignatorAttributes, Attributes: map[string]string{identifiers.AttributeKind: "Pod", identifiers.AttributeName: "nginx"}}},
+		PosturePolicies: []armotypes.PosturePolicy{{ControlID: "C-0057"}},
+	}}
+	validator := NewValidator(func(context.Context) (*cautils.OPASessionObj, error) { return policies, nil }, resources.NewRegoDependenciesData(nil, ""), "", apis.SeverityHigh, false, time.Minute)
+
+	response, err := validator.Validate(context.Background(), newAdmissionRequest(t, admissionv1.Create, mockPod("nginx", true, false)))
+	require.NoError(t, err)
+	assert.True(t, response.Allowed)
+}
+
+func TestGetPolicies(t *testing.T) {
+	var calls int
+	var collectErr error
+	validator := NewValidator(func(context.Context) (*cautils.OPASessionObj, error) {
+		calls++
+		if collectErr != nil {
+			return nil, collectErr
+		}
+		return mockPolicies(), nil
+	}, nil, "", apis.SeverityHigh, false, time.Hour)
+
+	_, err := validator.getPolicies(context.Background())
+	require.NoError(t, err)
+	_, err = validator.getPolicies(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	// the expired policies are kept when they cannot be collected
+	validator.expiration = time.Now().Add(-time.Second)
+	collectErr = errors.New("network error")
+	policies, err := validator.getPolicies(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, policies)
+	assert.Equal(t, 2, calls)
+
+	_, err = NewValidator(func(context.Context) (*cautils.OPASessionObj, error) { return nil, collectErr }, nil, "", apis.SeverityHigh, false, time.Hour).getPolicies(context.Background())
+	assert.ErrorContains(t, err, "network error")
+}
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := ParseSeverity("high")
+	require.NoError(t, err)
+	assert.Equal(t, apis.SeverityHigh, severity)
+
+	severity, err = ParseSeverity("Low")
+	require.NoError(t, err)
+	assert.Equal(t, apis.SeverityLow, severity)
+
+	_, err = ParseSeverity("urgent")
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package explorehandler
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+)
+
+// ExploreResult is the outcome of browsing the results: the action the user quit with and the findings marked
+type ExploreResult struct {
+	Action   Action
+	Findings []Finding
+}
+
+// Run browses the failed controls of the session in an interactive terminal UI, until the user quits. The exceptions
+// of the marked findings are written to the exceptions file on request
+func Run(sessionObj *cautils.OPASessionObj, filter Filter, exceptionsFile, clusterName string) (*ExploreResult, error) {
+	controls := NewControls(sessionObj)
+	if len(controls) == 0 {
+		return nil, errors.New("no failed controls to explore")
+	}
+
+	finalModel, err := tea.NewProgram(newModel(controls, filter, exceptionsFile, clusterName), tea.WithAltScreen()).Run()
+	if err != nil {
+		return nil, err
+	}
+	m := finalModel.(*model)
+	return &ExploreResult{Action: m.action, Findings: m.marks.Findings()}, nil
+}
@@ -0,0 +1,192 @@
+package explorehandler
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	printerv2 "github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer/v2"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+// allValues is the value of a filter matching all the findings
+const allValues = ""
+
+// Control holds a control failed by some resources and the findings of the resources
+type Control struct {
+	ID          string
+	Name        string
+	Severity    string
+	ScoreFactor float32
+	Description string
+	Remediation string
+	Findings    []Finding
+}
+
+// Finding is a resource failing a control
+type Finding struct {
+	ControlID   string
+	ControlName string
+	ResourceID  string
+	APIVersion  string
+	Kind        string
+	Namespace   string
+	Name        string
+	Source      string   // file the resource was read from, empty for the resources of a cluster
+	Paths       []string // failed paths and assisted remediation of the resource
+	Object      map[string]interface{}
+}
+
+// Key identifies a finding among the findings of a scan
+func (f *Finding) Key() string {
+	return f.ControlID + "/" + f.ResourceID
+}
+
+// DisplayName returns the namespaced name of the resource
+func (f *Finding) DisplayName() string {
+	if f.Namespace == "" {
+		return f.Name
+	}
+	return f.Namespace + "/" + f.Name
+}
+
+// NewControls returns the controls failed by the resources of the session, sorted from the most severe. The findings
+// of each control are sorted by kind, namespace and name
+func NewControls(sessionObj *cautils.OPASessionObj) []Control {
+	if sessionObj.Report == nil {
+		return nil
+	}
+
+	var controls []Control
+	for _, summary := range printerv2.ListFailedControls(sessionObj.Report.SummaryDetails.Controls) {
+		control := Control{
+			ID:          summary.GetID(),
+			Name:        summary.GetName(),
+			ScoreFactor: summary.GetScoreFactor(),
+			Severity:    apis.ControlSeverityToString(summary.GetScoreFactor()),
+			Description: summary.GetDescription(),
+			Remediation: summary.GetRemediation(),
+		}
+		for _, resource := range printerv2.ListFailedResources(summary, sessionObj.AllResources) {
+			finding := Finding{
+				ControlID:   control.ID,
+				ControlName: control.Name,
+				ResourceID:  resource.GetID(),
+				APIVersion:  resource.GetApiVersion(),
+				Kind:        resource.GetKind(),
+				Namespace:   resource.GetNamespace(),
+				Name:        resource.GetName(),
+				Object:      resource.GetObject(),
+			}
+			if result, ok := sessionObj.ResourcesResult[resource.GetID()]; ok {
+				for i := range result.AssociatedControls {
+					if result.AssociatedControls[i].GetID() == control.ID {
+						finding.Paths = printerv2.AssistedRemediationPathsToString(&result.AssociatedControls[i])
+						break
+					}
+				}
+			}
+			if source, ok := sessionObj.ResourceSource[resource.GetID()]; ok {
+				finding.Source = source.RelativePath
+			}
+			control.Findings = append(control.Findings, finding)
+		}
+		if len(control.Findings) == 0 {
+			continue
+		}
+
+		sort.Slice(control.Findings, func(i, j int) bool {
+			a, b := control.Findings[i], control.Findings[j]
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+		controls = append(controls, control)
+	}
+	return controls
+}
+
+// Filter selects the findings by the severity of their control, case insensitive, and by the namespace and kind of their
+// resource
+type Filter struct {
+	Severity  string
+	Namespace string
+	Kind      string
+}
+
+// Apply returns the controls with the findings matching the filter, the controls without such findings are dropped
+func (f Filter) Apply(controls []Control) []Control {
+	filtered := make([]Control, 0, len(controls))
+	for _, control := range controls {
+		if f.Severity != allValues && !strings.EqualFold(control.Severity, f.Severity) {
+			continue
+		}
+		findings := make([]Finding, 0, len(control.Findings))
+		for _, finding := range control.Findings {
+			if f.Namespace != allValues && finding.Namespace != f.Namespace {
+				continue
+			}
+			if f.Kind != allValues && finding.Kind != f.Kind {
+				continue
+			}
+			findings = append(findings, finding)
+		}
+		if len(findings) > 0 {
+			control.Findings = findings
+			filtered = append(filtered, control)
+		}
+	}
+	return filtered
+}
+
+// filterValues returns the values a filter can take for the controls: the severities from the most severe, and the
+// sorted namespaces and kinds of the findings. The first value matches all the findings
+func filterValues(controls []Control) (severities, namespaces, kinds []string) {
+	severityFactors := make(map[string]float32)
+	namespaceSet := make(map[string]bool)
+	kindSet := make(map[string]bool)
+	for _, control := range controls {
+		if factor, ok := severityFactors[control.Severity]; !ok || control.ScoreFactor > factor {
+			severityFactors[control.Severity] = control.ScoreFactor
+		}
+		for _, finding := range control.Findings {
+			namespaceSet[finding.Namespace] = true
+			kindSet[finding.Kind] = true
+		}
+	}
+
+	severities = []string{allValues}
+	for severity := range severityFactors {
+		severities = append(severities, severity)
+	}
+	sort.Slice(severities[1:], func(i, j int) bool {
+		return severityFactors[severities[i+1]] > severityFactors[severities[j+1]]
+	})
+
+	return severities, sortedKeys(namespaceSet), sortedKeys(kindSet)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		if key != allValues {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return append([]string{allValues}, keys...)
+}
+
+// nextValue returns the value following the current one, back to the first one after the last
+func nextValue(values []string, current string) string {
+	for i, value := range values {
+		if value == current {
+			return values[(i+1)%len(values)]
+		}
+	}
+	return allValues
+}
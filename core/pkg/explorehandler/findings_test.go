@@ -0,0 +1,173 @@
+package explorehandler
+
+import (
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResource(kind, namespace, name string) *reporthandling.Resource {
+	metadata := map[string]interface{}{"name": name}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	return reporthandling.NewResourceIMetadata(workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   metadata,
+	}))
+}
+
+func newTestControl(controlID string, status apis.ScanningStatus, fixPaths ...armotypes.FixPath) resourcesresults.ResourceAssociatedControl {
+	rule := resourcesresults.ResourceAssociatedRule{Name: "rule", Status: status}
+	for _, fixPath := range fixPaths {
+		rule.Paths = append(rule.Paths, armotypes.PosturePaths{FixPath: fixPath})
+	}
+	control := resourcesresults.ResourceAssociatedControl{ControlID: controlID, Name: "control " + controlID, ResourceAssociatedRules: []resourcesresults.ResourceAssociatedRule{rule}}
+	control.SetStatus(reporthandling.Control{})
+	return control
+}
+
+func newTestControlSummary(controlID string, scoreFactor float32, resourceIDs map[apis.ScanningStatus][]string) reportsummary.ControlSummary {
+	summary := reportsummary.ControlSummary{ControlID: controlID, Name: "control " + controlID, ScoreFactor: scoreFactor}
+	for status, ids := range resourceIDs {
+		summary.ResourceIDs.Append(status, ids...)
+	}
+	if len(resourceIDs[apis.StatusFailed]) > 0 {
+		summary.SetStatus(&apis.StatusInfo{InnerStatus: apis.StatusFailed})
+	} else {
+		summary.SetStatus(&apis.StatusInfo{InnerStatus: apis.StatusPassed})
+	}
+	return summary
+}
+
+// newTestReport returns a report in which a pod and a service account fail a high severity control, and the pod fails
+// a medium severity control as well. The pod passes a high severity control
+func newTestReport() *reporthandlingv2.PostureReport {
+	pod := newTestResource("Pod", "default", "nginx")
+	serviceAccount := newTestResource("ServiceAccount", "kube-system", "default")
+	configMap := newTestResource("ConfigMap", "default", "settings")
+
+	report := &reporthandlingv2.PostureReport{
+		Resources: []reporthandling.Resource{*pod, *serviceAccount, *configMap},
+		Results: []resourcesresults.Result{
+			{
+				ResourceID: pod.GetID(),
+				AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+					newTestControl("C-0016", apis.StatusFailed, armotypes.FixPath{Path: "spec.containers[0].securityContext.allowPrivilegeEscalation", Value: "false"}),
+					newTestControl("C-0017", apis.StatusFailed),
+				},
+			},
+			{
+				ResourceID:         serviceAccount.GetID(),
+				AssociatedControls: []resourcesresults.ResourceAssociatedControl{newTestControl("C-0016", apis.StatusFailed)},
+			},
+			{
+				ResourceID:         configMap.GetID(),
+				AssociatedControls: []resourcesresults.ResourceAssociatedControl{newTestControl("C-0016", apis.StatusPassed)},
+			},
+		},
+	}
+	report.SummaryDetails.Controls = reportsummary.ControlSummaries{
+		"C-0016": newTestControlSummary("C-0016", 7, map[apis.ScanningStatus][]string{
+			apis.StatusFailed: {pod.GetID(), serviceAccount.GetID()},
+			apis.StatusPassed: {configMap.GetID()},
+		}),
+		"C-0017": newTestControlSummary("C-0017", 4, map[apis.ScanningStatus][]string{apis.StatusFailed: {pod.GetID()}}),
+		"C-0018": newTestControlSummary("C-0018", 8, map[apis.ScanningStatus][]string{apis.StatusPassed: {pod.GetID()}}),
+	}
+	c := report.SummaryDetails.Controls["C-0016"]
+	c.Remediation = "Set allowPrivilegeEscalation to false"
+	report.SummaryDetails.Controls["C-0016"] = c
+	return report
+}
+
+func TestNewControls(t *testing.T) {
+	controls := NewControls(cautils.NewOPASessionObjFromReport(newTestReport()))
+	require.Len(t, controls, 2)
+
+	assert.Equal(t, "C-0016", controls[0].ID)
+	assert.Equal(t, apis.SeverityHighString, controls[0].Severity)
+	assert.Equal(t, "Set allowPrivilegeEscalation to false", controls[0].Remediation)
+	require.Len(t, controls[0].Findings, 2)
+	assert.Equal(t, "Pod", controls[0].Findings[0].Kind)
+	assert.Equal(t, "default/nginx", controls[0].Findings[0].DisplayName())
+	assert.Equal(t, []string{"spec.containers[0].securityContext.allowPrivilegeEscalation=false"}, controls[0].Findings[0].Paths)
+	assert.Equal(t, "ServiceAccount", controls[0].Findings[1].Kind)
+
+	assert.Equal(t, "C-0017", controls[1].ID)
+	assert.Equal(t, apis.SeverityMediumString, controls[1].Severity)
+	require.Len(t, controls[1].Findings, 1)
+}
+
+func TestFilterApply(t *testing.T) {
+	controls := NewControls(cautils.NewOPASessionObjFromReport(newTestReport()))
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected map[string]int // number of findings by control ID
+	}{
+		{
+			name:     "no filter",
+			filter:   Filter{},
+			expected: map[string]int{"C-0016": 2, "C-0017": 1},
+		},
+		{
+			name:     "severity",
+			filter:   Filter{Severity: apis.SeverityMediumString},
+			expected: map[string]int{"C-0017": 1},
+		},
+		{
+			name:     "namespace",
+			filter:   Filter{Namespace: "kube-system"},
+			expected: map[string]int{"C-0016": 1},
+		},
+		{
+			name:     "kind",
+			filter:   Filter{Kind: "Pod"},
+			expected: map[string]int{"C-0016": 1, "C-0017": 1},
+		},
+		{
+			name:     "severity case insensitive",
+			filter:   Filter{Severity: "medium"},
+			expected: map[string]int{"C-0017": 1},
+		},
+		{
+			name:     "no match",
+			filter:   Filter{Severity: apis.SeverityMediumString, Kind: "ServiceAccount"},
+			expected: map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := tt.filter.Apply(controls)
+			findings := make(map[string]int, len(filtered))
+			for _, control := range filtered {
+				findings[control.ID] = len(control.Findings)
+			}
+			assert.Equal(t, tt.expected, findings)
+		})
+	}
+}
+
+func TestFilterValues(t *testing.T) {
+	severities, namespaces, kinds := filterValues(NewControls(cautils.NewOPASessionObjFromReport(newTestReport())))
+
+	assert.Equal(t, []string{allValues, apis.SeverityHighString, apis.SeverityMediumString}, severities)
+	assert.Equal(t, []string{allValues, "default", "kube-system"}, namespaces)
+	assert.Equal(t, []string{allValues, "Pod", "ServiceAccount"}, kinds)
+
+	assert.Equal(t, "Pod", nextValue(kinds, allValues))
+	assert.Equal(t, allValues, nextValue(kinds, "ServiceAccount"))
+	assert.Equal(t, allValues, nextValue(kinds, "Deployment"))
+}
@@ -0,0 +1,150 @@
+package explorehandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/armosec/armoapi-go/identifiers"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+)
+
+// Marks holds the findings marked while browsing the results, by finding key
+type Marks map[string]Finding
+
+// Toggle marks the finding, or unmarks it when it is marked
+func (m Marks) Toggle(finding Finding) {
+	if _, ok := m[finding.Key()]; ok {
+		delete(m, finding.Key())
+		return
+	}
+	m[finding.Key()] = finding
+}
+
+func (m Marks) IsMarked(finding Finding) bool {
+	_, ok := m[finding.Key()]
+	return ok
+}
+
+// Findings returns the marked findings sorted by key
+func (m Marks) Findings() []Finding {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	findings := make([]Finding, 0, len(keys))
+	for _, key := range keys {
+		findings = append(findings, m[key])
+	}
+	return findings
+}
+
+// NewExceptions returns an exception of each finding, excluding the resource from the results of the control
+func NewExceptions(findings []Finding, clusterName string) []armotypes.PostureExceptionPolicy {
+	exceptions := make([]armotypes.PostureExceptionPolicy, 0, len(findings))
+	for _, finding := range findings {
+		attributes := map[string]string{
+			identifiers.AttributeKind: regexp.QuoteMeta(finding.Kind),
+			identifiers.AttributeName: regexp.QuoteMeta(finding.Name),
+		}
+		if finding.Namespace != "" {
+			attributes[identifiers.AttributeNamespace] = regexp.QuoteMeta(finding.Namespace)
+		}
+		if clusterName != "" {
+			attributes[identifiers.AttributeCluster] = regexp.QuoteMeta(clusterName)
+		}
+
+		exceptions = append(exceptions, armotypes.PostureExceptionPolicy{
+			PortalBase: armotypes.PortalBase{Name: exceptionName(finding)},
+			PolicyType: string(armotypes.PostureExceptionPolicyType),
+			Actions:    []armotypes.PostureExceptionPolicyActions{armotypes.AlertOnly},
+			Resources: []identifiers.PortalDesignator{{
+				DesignatorType: identifiers.DesignatorAttributes,
+				Attributes:     attributes,
+			}},
+			PosturePolicies: []armotypes.PosturePolicy{{ControlID: finding.ControlID}},
+		})
+	}
+	return exceptions
+}
+
+// exceptionName returns the name of the exception of a finding, e.g. "exclude-c-0016-deployment-default-nginx"
+func exceptionName(finding Finding) string {
+	parts := []string{"exclude", finding.ControlID, finding.Kind}
+	if finding.Namespace != "" {
+		parts = append(parts, finding.Namespace)
+	}
+	parts = append(parts, finding.Name)
+	return strings.ToLower(strings.Join(parts, "-"))
+}
+
+// WriteExceptions adds the exceptions to the exceptions file, the exceptions of the file with the same names are replaced
+func WriteExceptions(path string, exceptions []armotypes.PostureExceptionPolicy) error {
+	var existing []armotypes.PostureExceptionPolicy
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case len(strings.TrimSpace(string(content))) > 0:
+		if err := json.Unmarshal(content, &existing); err != nil {
+			return fmt.Errorf("failed to read exceptions file %s: %w", path, err)
+		}
+	}
+
+	names := make(map[string]bool, len(exceptions))
+	for _, exception := range exceptions {
+		names[exception.Name] = true
+	}
+	merged := make([]armotypes.PostureExceptionPolicy, 0, len(existing)+len(exceptions))
+	for _, exception := range existing {
+		if !names[exception.Name] {
+			merged = append(merged, exception)
+		}
+	}
+	merged = append(merged, exceptions...)
+
+	content, err = json.MarshalIndent(merged, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644) //nolint:gosec
+}
+
+// FilterReport returns a copy of the report with the results of the findings only, to fix them
+func FilterReport(report *reporthandlingv2.PostureReport, findings []Finding) *reporthandlingv2.PostureReport {
+	controlsByResource := make(map[string]map[string]bool)
+	for _, finding := range findings {
+		if controlsByResource[finding.ResourceID] == nil {
+			controlsByResource[finding.ResourceID] = make(map[string]bool)
+		}
+		controlsByResource[finding.ResourceID][finding.ControlID] = true
+	}
+
+	filtered := *report
+	filtered.Results = make([]resourcesresults.Result, 0, len(controlsByResource))
+	for _, result := range report.Results {
+		controls, ok := controlsByResource[result.ResourceID]
+		if !ok {
+			continue
+		}
+		associatedControls := make([]resourcesresults.ResourceAssociatedControl, 0, len(controls))
+		for _, associatedControl := range result.AssociatedControls {
+			if controls[associatedControl.GetID()] {
+				associatedControls = append(associatedControls, associatedControl)
+			}
+		}
+		result.AssociatedControls = associatedControls
+		filtered.Results = append(filtered.Results, result)
+	}
+	return &filtered
+}
@@ -0,0 +1,101 @@
+package explorehandler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/armosec/armoapi-go/identifiers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarks(t *testing.T) {
+	pod := Finding{ControlID: "C-0016", ResourceID: "/v1/default/Pod/nginx"}
+	serviceAccount := Finding{ControlID: "C-0016", ResourceID: "/v1/kube-system/ServiceAccount/default"}
+
+	marks := make(Marks)
+	marks.Toggle(serviceAccount)
+	marks.Toggle(pod)
+	assert.True(t, marks.IsMarked(pod))
+	assert.Equal(t, []Finding{pod, serviceAccount}, marks.Findings())
+
+	marks.Toggle(pod)
+	assert.False(t, marks.IsMarked(pod))
+	assert.Equal(t, []Finding{serviceAccount}, marks.Findings())
+}
+
+func TestNewExceptions(t *testing.T) {
+	findings := []Finding{
+		{ControlID: "C-0016", Kind: "Deployment", Namespace: "default", Name: "nginx.v1"},
+		{ControlID: "C-0035", Kind: "ClusterRole", Name: "admin"},
+	}
+
+	exceptions := NewExceptions(findings, "minikube")
+	require.Len(t, exceptions, 2)
+
+	assert.Equal(t, "exclude-c-0016-deployment-default-nginx.v1", exceptions[0].Name)
+	assert.Equal(t, string(armotypes.PostureExceptionPolicyType), exceptions[0].PolicyType)
+	assert.Equal(t, []armotypes.PostureExceptionPolicyActions{armotypes.AlertOnly}, exceptions[0].Actions)
+	assert.Equal(t, []armotypes.PosturePolicy{{ControlID: "C-0016"}}, exceptions[0].PosturePolicies)
+	assert.Equal(t, map[string]string{
+		identifiers.AttributeKind:      "Deployment",
+		identifiers.AttributeNamespace: "default",
+		identifiers.AttributeName:      `nginx\.v1`,
+		identifiers.AttributeCluster:   "minikube",
+	}, exceptions[0].Resources[0].Attributes)
+
+	assert.Equal(t, "exclude-c-0035-clusterrole-admin", exceptions[1].Name)
+	assert.NotContains(t, exceptions[1].Resources[0].Attributes, identifiers.AttributeNamespace)
+}
+
+func TestWriteExceptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exceptions.json")
+	existing := []armotypes.PostureExceptionPolicy{
+		{PortalBase: armotypes.PortalBase{Name: "exclude-kube-system"}},
+		{PortalBase: armotypes.PortalBase{Name: "exclude-c-0016-pod-default-nginx"}, Actions: []armotypes.PostureExceptionPolicyActions{armotypes.Disable}},
+	}
+	content, err := json.Marshal(existing)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, content, 0600))
+
+	exceptions := NewExceptions([]Finding{{ControlID: "C-0016", Kind: "Pod", Namespace: "default", Name: "nginx"}}, "")
+	require.NoError(t, WriteExceptions(path, exceptions))
+
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	var written []armotypes.PostureExceptionPolicy
+	require.NoError(t, json.Unmarshal(content, &written))
+	require.Len(t, written, 2)
+	assert.Equal(t, "exclude-kube-system", written[0].Name)
+	assert.Equal(t, "exclude-c-0016-pod-default-nginx", written[1].Name)
+	assert.Equal(t, []armotypes.PostureExceptionPolicyActions{armotypes.AlertOnly}, written[1].Actions)
+
+	newPath := filepath.Join(t.TempDir(), "new-exceptions.json")
+	require.NoError(t, WriteExceptions(newPath, exceptions))
+	assert.FileExists(t, newPath)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	assert.Error(t, WriteExceptions(path, exceptions))
+}
+
+func TestFilterReport(t *testing.T) {
+	report := newTestReport()
+	controls := NewControls(cautils.NewOPASessionObjFromReport(report))
+	pod := controls[1].Findings[0] // C-0017 of the pod
+
+	filtered := FilterReport(report, []Finding{pod})
+
+	assert.Len(t, filtered.Resources, len(report.Resources))
+	require.Len(t, filtered.Results, 1)
+	assert.Equal(t, pod.ResourceID, filtered.Results[0].ResourceID)
+	require.Len(t, filtered.Results[0].AssociatedControls, 1)
+	assert.Equal(t, "C-0017", filtered.Results[0].AssociatedControls[0].ControlID)
+
+	// the report is not changed
+	assert.Len(t, report.Results, 3)
+	assert.Len(t, report.Results[0].AssociatedControls, 2)
+}
@@ -0,0 +1,396 @@
+package explorehandler
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"sigs.k8s.io/yaml"
+)
+
+// Action is the action the user quit the explorer with
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionFix         // fix the marked findings
+)
+
+type view int
+
+const (
+	viewControls view = iota
+	viewFindings
+	viewFinding
+)
+
+const (
+	defaultHeight = 24
+	// reservedLines are the lines of the header and the footer of the views
+	reservedLines = 5
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+	statusStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+
+	severityStyles = map[string]lipgloss.Style{
+		apis.SeverityCriticalString: lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true),
+		apis.SeverityHighString:     lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+		apis.SeverityMediumString:   lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+		apis.SeverityLowString:      lipgloss.NewStyle().Foreground(lipgloss.Color("4")),
+	}
+)
+
+// model is the state of the explorer: the controls browsed, the filter and the marked findings
+type model struct {
+	controls   []Control
+	filtered   []Control
+	filter     Filter
+	severities []string
+	namespaces []string
+	kinds      []string
+	marks      Marks
+
+	view          view
+	controlCursor int
+	findingCursor int
+	showYAML      bool
+	scroll        int
+	height        int
+
+	exceptionsFile string
+	clusterName    string
+	status         string
+	action         Action
+}
+
+func newModel(controls []Control, filter Filter, exceptionsFile, clusterName string) *model {
+	m := &model{
+		controls:       controls,
+		filter:         filter,
+		marks:          make(Marks),
+		height:         defaultHeight,
+		exceptionsFile: exceptionsFile,
+		clusterName:    clusterName,
+	}
+	m.severities, m.namespaces, m.kinds = filterValues(controls)
+	m.applyFilter()
+	return m
+}
+
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		return m, m.handleKey(msg.String())
+	}
+	return m, nil
+}
+
+func (m *model) handleKey(key string) tea.Cmd {
+	m.status = ""
+	switch key {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "enter", "right", "l":
+		m.enter()
+	case "esc", "left", "h", "backspace":
+		m.back()
+	case "s":
+		m.filter.Severity = nextValue(m.severities, m.filter.Severity)
+		m.applyFilter()
+	case "n":
+		m.filter.Namespace = nextValue(m.namespaces, m.filter.Namespace)
+		m.applyFilter()
+	case "t":
+		m.filter.Kind = nextValue(m.kinds, m.filter.Kind)
+		m.applyFilter()
+	case "c":
+		m.filter = Filter{}
+		m.applyFilter()
+	case "y":
+		if m.view == viewFinding {
+			m.showYAML = !m.showYAML
+			m.scroll = 0
+		}
+	case " ":
+		m.toggleMark()
+	case "e":
+		m.writeExceptions()
+	case "f":
+		if len(m.marks) == 0 {
+			m.status = "Mark the findings to fix first"
+			return nil
+		}
+		m.action = ActionFix
+		return tea.Quit
+	}
+	return nil
+}
+
+func (m *model) move(delta int) {
+	switch m.view {
+	case viewControls:
+		m.controlCursor = clamp(m.controlCursor+delta, len(m.filtered))
+	case viewFindings:
+		m.findingCursor = clamp(m.findingCursor+delta, len(m.currentControl().Findings))
+	case viewFinding:
+		m.scroll = max(m.scroll+delta, 0)
+	}
+}
+
+func (m *model) enter() {
+	switch m.view {
+	case viewControls:
+		if len(m.filtered) > 0 {
+			m.view = viewFindings
+			m.findingCursor = 0
+		}
+	case viewFindings:
+		m.view = viewFinding
+		m.showYAML = false
+		m.scroll = 0
+	}
+}
+
+func (m *model) back() {
+	if m.view > viewControls {
+		m.view--
+	}
+}
+
+// applyFilter filters the controls and goes back to the controls, the cursors would not point to the same findings
+func (m *model) applyFilter() {
+	m.filtered = m.filter.Apply(m.controls)
+	m.view = viewControls
+	m.controlCursor = clamp(m.controlCursor, len(m.filtered))
+	m.findingCursor = 0
+}
+
+func (m *model) currentControl() *Control {
+	return &m.filtered[m.controlCursor]
+}
+
+func (m *model) currentFinding() *Finding {
+	return &m.currentControl().Findings[m.findingCursor]
+}
+
+// toggleMark marks the selected finding, or all the findings of the selected control. A control is unmarked when all
+// its findings are marked
+func (m *model) toggleMark() {
+	if len(m.filtered) == 0 {
+		return
+	}
+	if m.view != viewControls {
+		m.marks.Toggle(*m.currentFinding())
+		return
+	}
+
+	findings := m.currentControl().Findings
+	allMarked := true
+	for i := range findings {
+		allMarked = allMarked && m.marks.IsMarked(findings[i])
+	}
+	for i := range findings {
+		if m.marks.IsMarked(findings[i]) == allMarked {
+			m.marks.Toggle(findings[i])
+		}
+	}
+}
+
+func (m *model) writeExceptions() {
+	if len(m.marks) == 0 {
+		m.status = "Mark the findings to except first"
+		return
+	}
+	exceptions := NewExceptions(m.marks.Findings(), m.clusterName)
+	if err := WriteExceptions(m.exceptionsFile, exceptions); err != nil {
+		m.status = fmt.Sprintf("Failed to write exceptions: %s", err.Error())
+		return
+	}
+	m.status = fmt.Sprintf("Wrote %d exceptions to %s", len(exceptions), m.exceptionsFile)
+}
+
+func (m *model) View() string {
+	var body []string
+	var help string
+	switch m.view {
+	case viewControls:
+		body = m.controlsView()
+		help = "enter: resources • space: mark all • s/n/t: filter severity/namespace/kind • c: clear filter • e: write exceptions • f: fix marked • q: quit"
+	case viewFindings:
+		body = m.findingsView()
+		help = "enter: details • esc: back • space: mark • e: write exceptions • f: fix marked • q: quit"
+	case viewFinding:
+		body = m.findingView()
+		help = "y: toggle YAML • up/down: scroll • esc: back • space: mark • e: write exceptions • f: fix marked • q: quit"
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(m.header() + "\n\n")
+	sb.WriteString(strings.Join(body, "\n") + "\n\n")
+	if m.status != "" {
+		sb.WriteString(statusStyle.Render(m.status) + "\n")
+	}
+	sb.WriteString(helpStyle.Render(help) + "\n")
+	return sb.String()
+}
+
+func (m *model) header() string {
+	filter := fmt.Sprintf("severity: %s  namespace: %s  kind: %s", valueOrAll(m.filter.Severity), valueOrAll(m.filter.Namespace), valueOrAll(m.filter.Kind))
+	title := "Failed controls"
+	switch m.view {
+	case viewFindings:
+		title = fmt.Sprintf("%s - %s", m.currentControl().ID, m.currentControl().Name)
+	case viewFinding:
+		title = fmt.Sprintf("%s - %s › %s/%s", m.currentControl().ID, m.currentControl().Name, m.currentFinding().Kind, m.currentFinding().DisplayName())
+	}
+	return fmt.Sprintf("%s\n%s  marked: %d", titleStyle.Render(title), helpStyle.Render(filter), len(m.marks))
+}
+
+func (m *model) controlsView() []string {
+	if len(m.filtered) == 0 {
+		return []string{"No failed controls match the filter"}
+	}
+	lines := make([]string, 0, len(m.filtered))
+	for i := range m.filtered {
+		control := &m.filtered[i]
+		marked := 0
+		for j := range control.Findings {
+			if m.marks.IsMarked(control.Findings[j]) {
+				marked++
+			}
+		}
+		line := fmt.Sprintf("%s %-8s %-7s %s (%d failed)", markSymbol(marked, len(control.Findings)),
+			severityStyle(control.Severity).Render(fmt.Sprintf("%-8s", control.Severity)), control.ID, control.Name, len(control.Findings))
+		lines = append(lines, m.renderLine(line, i == m.controlCursor))
+	}
+	return window(lines, m.controlCursor, m.listHeight())
+}
+
+func (m *model) findingsView() []string {
+	findings := m.currentControl().Findings
+	lines := make([]string, 0, len(findings))
+	for i := range findings {
+		marked := 0
+		if m.marks.IsMarked(findings[i]) {
+			marked = 1
+		}
+		line := fmt.Sprintf("%s %-24s %s", markSymbol(marked, 1), findings[i].Kind, findings[i].DisplayName())
+		if findings[i].Source != "" {
+			line += helpStyle.Render("  " + findings[i].Source)
+		}
+		lines = append(lines, m.renderLine(line, i == m.findingCursor))
+	}
+	return window(lines, m.findingCursor, m.listHeight())
+}
+
+func (m *model) findingView() []string {
+	control := m.currentControl()
+	finding := m.currentFinding()
+
+	var lines []string
+	if m.showYAML {
+		content, err := yaml.Marshal(finding.Object)
+		if err != nil {
+			content = []byte(err.Error())
+		}
+		lines = strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	} else {
+		marked := "no"
+		if m.marks.IsMarked(*finding) {
+			marked = "yes"
+		}
+		lines = []string{
+			fmt.Sprintf("Severity:    %s", severityStyle(control.Severity).Render(control.Severity)),
+			fmt.Sprintf("Resource:    %s %s %s", finding.APIVersion, finding.Kind, finding.DisplayName()),
+		}
+		if finding.Source != "" {
+			lines = append(lines, fmt.Sprintf("Source:      %s", finding.Source))
+		}
+		lines = append(lines, fmt.Sprintf("Marked:      %s", marked), "")
+		if control.Description != "" {
+			lines = append(lines, titleStyle.Render("Description"), control.Description, "")
+		}
+		lines = append(lines, titleStyle.Render("Failed paths"))
+		if len(finding.Paths) == 0 {
+			lines = append(lines, "  (none)")
+		}
+		for _, path := range finding.Paths {
+			lines = append(lines, "  "+path)
+		}
+		lines = append(lines, "")
+		if control.Remediation != "" {
+			lines = append(lines, titleStyle.Render("Remediation"), control.Remediation, "")
+		}
+		lines = append(lines, fmt.Sprintf("More details: %s", cautils.GetControlLink(control.ID)))
+	}
+
+	m.scroll = min(m.scroll, max(len(lines)-m.listHeight(), 0))
+	end := min(m.scroll+m.listHeight(), len(lines))
+	return lines[m.scroll:end]
+}
+
+func (m *model) renderLine(line string, selected bool) string {
+	if selected {
+		return selectedStyle.Render("> " + line)
+	}
+	return "  " + line
+}
+
+func (m *model) listHeight() int {
+	return max(m.height-reservedLines-2, 1)
+}
+
+// window returns the lines of a list fitting the height, around the cursor
+func window(lines []string, cursor, height int) []string {
+	if len(lines) <= height {
+		return lines
+	}
+	start := min(max(cursor-height/2, 0), len(lines)-height)
+	return lines[start : start+height]
+}
+
+func markSymbol(marked, total int) string {
+	switch {
+	case marked == 0:
+		return "[ ]"
+	case marked == total:
+		return "[x]"
+	default:
+		return "[-]"
+	}
+}
+
+func severityStyle(severity string) lipgloss.Style {
+	if style, ok := severityStyles[severity]; ok {
+		return style
+	}
+	return lipgloss.NewStyle()
+}
+
+func valueOrAll(value string) string {
+	if value == allValues {
+		return "all"
+	}
+	return value
+}
+
+func clamp(i, length int) int {
+	return min(max(i, 0), max(length-1, 0))
+}
@@ -0,0 +1,108 @@
+package explorehandler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestModel(t *testing.T) *model {
+	t.Helper()
+	controls := NewControls(cautils.NewOPASessionObjFromReport(newTestReport()))
+	return newModel(controls, Filter{}, filepath.Join(t.TempDir(), "exceptions.json"), "")
+}
+
+// pressKeys sends the keys to the model and returns the command of the last key
+func pressKeys(m *model, keys ...tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	for _, key := range keys {
+		_, cmd = m.Update(key)
+	}
+	return cmd
+}
+
+func runeKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func TestModelNavigation(t *testing.T) {
+	m := newTestModel(t)
+	assert.Contains(t, m.View(), "C-0016")
+	assert.Contains(t, m.View(), "C-0017")
+
+	pressKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, viewFindings, m.view)
+	assert.Contains(t, m.View(), "default/nginx")
+	assert.Contains(t, m.View(), "kube-system/default")
+
+	pressKeys(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, viewFinding, m.view)
+	assert.Contains(t, m.View(), "spec.containers[0].securityContext.allowPrivilegeEscalation=false")
+	assert.Contains(t, m.View(), "Set allowPrivilegeEscalation to false")
+
+	pressKeys(m, runeKey('y'))
+	assert.Contains(t, m.View(), "kind: Pod")
+
+	pressKeys(m, tea.KeyMsg{Type: tea.KeyEsc}, tea.KeyMsg{Type: tea.KeyEsc}, tea.KeyMsg{Type: tea.KeyEsc}, tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, viewControls, m.view)
+	assert.Equal(t, 1, m.controlCursor)
+
+	pressKeys(m, tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, 1, m.controlCursor)
+}
+
+func TestModelFilter(t *testing.T) {
+	m := newTestModel(t)
+
+	pressKeys(m, runeKey('s'))
+	assert.Equal(t, apis.SeverityHighString, m.filter.Severity)
+	require.Len(t, m.filtered, 1)
+
+	pressKeys(m, runeKey('n'), runeKey('n'))
+	assert.Equal(t, "kube-system", m.filter.Namespace)
+	require.Len(t, m.filtered, 1)
+	assert.Len(t, m.filtered[0].Findings, 1)
+
+	pressKeys(m, runeKey('t'))
+	assert.Equal(t, "Pod", m.filter.Kind)
+	assert.Empty(t, m.filtered)
+	assert.Contains(t, m.View(), "No failed controls match the filter")
+
+	pressKeys(m, runeKey('c'))
+	assert.Equal(t, Filter{}, m.filter)
+	assert.Len(t, m.filtered, 2)
+}
+
+func TestModelMarks(t *testing.T) {
+	m := newTestModel(t)
+
+	// marking a control marks its findings, and marking it again unmarks them
+	pressKeys(m, tea.KeyMsg{Type: tea.KeySpace})
+	assert.Len(t, m.marks, 2)
+	pressKeys(m, tea.KeyMsg{Type: tea.KeySpace})
+	assert.Empty(t, m.marks)
+
+	cmd := pressKeys(m, runeKey('f'))
+	assert.Nil(t, cmd)
+	assert.Equal(t, ActionNone, m.action)
+
+	pressKeys(m, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeySpace})
+	assert.Len(t, m.marks, 1)
+	assert.Contains(t, m.View(), "[x]")
+
+	pressKeys(m, runeKey('e'))
+	assert.Contains(t, m.status, "Wrote 1 exceptions")
+	content, err := os.ReadFile(m.exceptionsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "exclude-c-0016-pod-default-nginx")
+
+	cmd = pressKeys(m, runeKey('f'))
+	assert.NotNil(t, cmd)
+	assert.Equal(t, ActionFix, m.action)
+}
@@ -0,0 +1,122 @@
+package fixhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+)
+
+// anonymousRemoteName is the name of the remote built when pushing to a URL instead of a configured remote
+const anonymousRemoteName = "kubescape"
+
+// IChangeProvider publishes the git branch of the fixes, e.g. as a patch file or by pushing it to a remote
+type IChangeProvider interface {
+	// Name of the provider, used for logging
+	Name() string
+	// Publish publishes the commits of the change
+	Publish(ctx context.Context, change *GitChange) error
+}
+
+var _ IChangeProvider = &PatchProvider{}
+var _ IChangeProvider = &PushProvider{}
+
+// PatchProvider writes the commits of the change to a patch file, in the mailbox format applied by "git am"
+type PatchProvider struct {
+	Path string
+}
+
+func NewPatchProvider(path string) *PatchProvider {
+	return &PatchProvider{Path: path}
+}
+
+func (p *PatchProvider) Name() string { return "patch" }
+
+func (p *PatchProvider) Publish(_ context.Context, change *GitChange) error {
+	f, err := os.Create(p.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create patch file: %w", err)
+	}
+	defer f.Close()
+
+	if err := WritePatch(f, change); err != nil {
+		return fmt.Errorf("failed to write patch file %s: %w", p.Path, err)
+	}
+	return nil
+}
+
+// WritePatch writes the commits of the change in the mailbox format of "git format-patch"
+func WritePatch(w io.Writer, change *GitChange) error {
+	for i, hash := range change.Commits {
+		commit, err := change.Repository.CommitObject(hash)
+		if err != nil {
+			return err
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return err
+		}
+		patch, err := parent.Patch(commit)
+		if err != nil {
+			return err
+		}
+
+		subject, body, _ := strings.Cut(commit.Message, "\n")
+		if _, err := fmt.Fprintf(w, "From %s Mon Sep 17 00:00:00 2001\nFrom: %s <%s>\nDate: %s\nSubject: [PATCH %d/%d] %s\n\n%s\n---\n",
+			commit.Hash, commit.Author.Name, commit.Author.Email, commit.Author.When.Format(time.RFC1123Z), i+1, len(change.Commits), subject, strings.TrimSpace(body)); err != nil {
+			return err
+		}
+		if err := patch.Encode(w); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(w, "--\n\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PushProvider pushes the branch of the change to a remote, the remote is the name of a configured remote or a git URL
+type PushProvider struct {
+	Remote string
+}
+
+func NewPushProvider(remote string) *PushProvider {
+	return &PushProvider{Remote: remote}
+}
+
+func (p *PushProvider) Name() string { return "push" }
+
+func (p *PushProvider) Publish(ctx context.Context, change *GitChange) error {
+	remote, err := change.Repository.Remote(p.Remote)
+	if errors.Is(err, git.ErrRemoteNotFound) {
+		remote = git.NewRemote(change.Repository.Storer, &config.RemoteConfig{Name: anonymousRemoteName, URLs: []string{p.Remote}})
+	} else if err != nil {
+		return err
+	}
+	if len(remote.Config().URLs) == 0 {
+		return fmt.Errorf("remote %s has no URL", p.Remote)
+	}
+
+	auth, err := cautils.GetGitAuth(remote.Config().URLs[0])
+	if err != nil {
+		return err
+	}
+
+	refSpec := config.RefSpec(fmt.Sprintf("refs/heads/%[1]s:refs/heads/%[1]s", change.Branch))
+	if err := remote.PushContext(ctx, &git.PushOptions{
+		RemoteName: remote.Config().Name,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       auth,
+	}); err != nil {
+		return fmt.Errorf("failed to push branch %s to %s: %w", change.Branch, p.Remote, err)
+	}
+	return nil
+}
@@ -0,0 +1,498 @@
+package fixhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/opa-utils/objectsenvelopes"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// ClusterFixFieldManager is the field manager owning the fields fixed in the cluster by server-side apply
+const ClusterFixFieldManager = "kubescape-fix"
+
+// diffContextLines is the number of unchanged lines printed around the changes of a resource
+const diffContextLines = 3
+
+// ClusterFixHandler fixes the resources of a cluster scan report in the live cluster
+type ClusterFixHandler struct {
+	*FixHandler
+	client      dynamic.Interface
+	contextName string
+	store       *RollbackStore
+}
+
+// ClusterResourceFixInfo holds the live object of a resource of the cluster and the object with the fixes applied
+type ClusterResourceFixInfo struct {
+	ResourceFixInfo
+	GroupVersionResource schema.GroupVersionResource
+	Live                 *unstructured.Unstructured
+	Original             map[string]interface{} // live object without the fields set by the API server
+	Fixed                map[string]interface{}
+	dataStruct           runtime.Object
+}
+
+func NewClusterFixHandler(fixInfo *metav1.FixInfo, client dynamic.Interface, contextName string, store *RollbackStore) (*ClusterFixHandler, error) {
+	reportObj, err := readReport(fixInfo.ReportFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if reportObj.Metadata.ScanMetadata.ScanningTarget != reporthandlingv2.Cluster {
+		return nil, fmt.Errorf("unsupported scanning target. Fixing resources in the cluster requires the report of a cluster scan")
+	}
+	if clusterMetadata := reportObj.Metadata.ContextMetadata.ClusterContextMetadata; clusterMetadata != nil && clusterMetadata.ContextName != "" && clusterMetadata.ContextName != contextName {
+		return nil, fmt.Errorf("the report was created for context %s, but the current context is %s", clusterMetadata.ContextName, contextName)
+	}
+
+	setYqLogger()
+
+	return &ClusterFixHandler{
+		FixHandler: &FixHandler{
+			fixInfo:   fixInfo,
+			reportObj: reportObj,
+		},
+		client:      client,
+		contextName: contextName,
+		store:       store,
+	}, nil
+}
+
+// PrepareResourcesToFix reads the failed resources of the report from the cluster and applies their fixes to the live objects
+func (h *ClusterFixHandler) PrepareResourcesToFix(ctx context.Context) []ClusterResourceFixInfo {
+	resourceIdToResource := h.buildResourcesMap()
+
+	resourcesToFix := make([]ClusterResourceFixInfo, 0)
+	for _, result := range h.reportObj.Results {
+		if !result.GetStatus(nil).IsFailed() {
+			continue
+		}
+
+		resourceObj := resourceIdToResource[result.ResourceID]
+		if resourceObj == nil || objectsenvelopes.IsTypeRegoResponseVector(resourceObj.GetObject()) {
+			continue
+		}
+
+		rfi := ResourceFixInfo{
+			Resource:        resourceObj,
+			YamlExpressions: make(map[string]armotypes.FixPath, 0),
+			Controls:        make(map[string]FixControl, 0),
+		}
+		for i := range result.AssociatedControls {
+			if result.AssociatedControls[i].GetStatus(nil).IsFailed() {
+				rfi.addYamlExpressionsFromResourceAssociatedControl(0, &result.AssociatedControls[i], h.fixInfo.SkipUserValues)
+			}
+		}
+		if len(rfi.YamlExpressions) == 0 {
+			continue
+		}
+
+		resourceToFix, err := h.prepareClusterResourceToFix(ctx, rfi)
+		if err != nil {
+			logger.L().Ctx(ctx).Warning("Skipping resource", helpers.String("kind", resourceObj.GetKind()),
+				helpers.String("namespace", resourceObj.GetNamespace()), helpers.String("name", resourceObj.GetName()), helpers.Error(err))
+			continue
+		}
+		if resourceToFix != nil {
+			resourcesToFix = append(resourcesToFix, *resourceToFix)
+		}
+	}
+
+	return resourcesToFix
+}
+
+// prepareClusterResourceToFix returns the live object of the resource with the fixes applied, or nil when the live
+// object is already fixed
+func (h *ClusterFixHandler) prepareClusterResourceToFix(ctx context.Context, rfi ResourceFixInfo) (*ClusterResourceFixInfo, error) {
+	gvk := schema.FromAPIVersionAndKind(rfi.Resource.GetApiVersion(), rfi.Resource.GetKind())
+	// the merge keys of the lists of custom resources are unknown, the fixed fields cannot be applied on their own
+	dataStruct, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, fmt.Errorf("resources with an unknown schema are not supported: %w", err)
+	}
+	gvr, err := groupVersionResource(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := h.client.Resource(gvr).Namespace(rfi.Resource.GetNamespace()).Get(ctx, rfi.Resource.GetName(), k8smetav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	original := sanitizedObject(live.Object)
+	originalYaml, err := sigsyaml.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	fixedYaml, err := ApplyFixToContent(ctx, string(originalYaml), reduceYamlExpressions(&rfi))
+	if err != nil {
+		return nil, err
+	}
+	var fixed map[string]interface{}
+	if err := sigsyaml.Unmarshal([]byte(fixedYaml), &fixed); err != nil {
+		return nil, err
+	}
+	if jsonEqual(original, fixed) {
+		return nil, nil
+	}
+
+	return &ClusterResourceFixInfo{
+		ResourceFixInfo:      rfi,
+		GroupVersionResource: gvr,
+		Live:                 live,
+		Original:             original,
+		Fixed:                fixed,
+		dataStruct:           dataStruct,
+	}, nil
+}
+
+// groupVersionResource returns the resource of the API serving the kind
+func groupVersionResource(gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+	gvr, err := k8sinterface.GetGroupVersionResource(gvk.Kind)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	return gvk.GroupVersion().WithResource(gvr.Resource), nil
+}
+
+// sanitizedObject returns a copy of the object without the fields set by the API server
+func sanitizedObject(object map[string]interface{}) map[string]interface{} {
+	sanitized := deepCopyValue(object).(map[string]interface{})
+	delete(sanitized, "status")
+	if metadata, ok := sanitized["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp", "selfLink"} {
+			delete(metadata, field)
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+	return sanitized
+}
+
+func (h *ClusterFixHandler) PrintExpectedChanges(resourcesToFix []ClusterResourceFixInfo) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("The following changes will be applied to the resources of context %s:\n", h.contextName))
+
+	for _, resourceToFix := range resourcesToFix {
+		sb.WriteString(fmt.Sprintf("Resource: %s\n", resourceToFix.Resource.GetName()))
+		sb.WriteString(fmt.Sprintf("Kind: %s\n", resourceToFix.Resource.GetKind()))
+		if namespace := resourceToFix.Resource.GetNamespace(); namespace != "" {
+			sb.WriteString(fmt.Sprintf("Namespace: %s\n", namespace))
+		}
+		sb.WriteString("Changes:\n")
+
+		originalYaml, _ := sigsyaml.Marshal(resourceToFix.Original)
+		fixedYaml, _ := sigsyaml.Marshal(resourceToFix.Fixed)
+		sb.WriteString(resourceDiff(string(originalYaml), string(fixedYaml)))
+		sb.WriteString("\n------\n")
+	}
+
+	logger.L().Info(sb.String())
+}
+
+// resourceDiff returns the lines changed between the original and the fixed object, with a few unchanged lines around them
+func resourceDiff(original, fixed string) string {
+	originalLines := strings.Split(strings.TrimSuffix(original, "\n"), "\n")
+	fixedLines := strings.Split(strings.TrimSuffix(fixed, "\n"), "\n")
+	ops := diffLines(originalLines, fixedLines)
+
+	lines := make([]string, 0, len(ops))
+	i, j := 0, 0
+	for _, op := range ops {
+		switch op {
+		case diffKeep:
+			lines = append(lines, "  "+originalLines[i])
+			i++
+			j++
+		case diffRemove:
+			lines = append(lines, "- "+originalLines[i])
+			i++
+		case diffInsert:
+			lines = append(lines, "+ "+fixedLines[j])
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	skipped := false
+	for k, line := range lines {
+		if !nearChange(ops, k) {
+			if !skipped {
+				sb.WriteString("  ...\n")
+				skipped = true
+			}
+			continue
+		}
+		skipped = false
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+func nearChange(ops []diffOp, index int) bool {
+	for k := max(0, index-diffContextLines); k <= min(len(ops)-1, index+diffContextLines); k++ {
+		if ops[k] != diffKeep {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyChanges applies the fixed fields of the resources with server-side apply and saves the prior versions of the
+// resources, the returned record is nil when no resource was changed
+func (h *ClusterFixHandler) ApplyChanges(ctx context.Context, resourcesToFix []ClusterResourceFixInfo) (*RollbackRecord, []error) {
+	errs := make([]error, 0)
+	record := &RollbackRecord{
+		Context:   h.contextName,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	for _, resourceToFix := range resourcesToFix {
+		resource, err := h.applyResourceFix(ctx, resourceToFix)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Failed to fix %s %s: %w", resourceToFix.Resource.GetKind(), resourceToFix.Resource.GetName(), err))
+			continue
+		}
+		record.Resources = append(record.Resources, *resource)
+	}
+
+	if len(record.Resources) == 0 {
+		return nil, errs
+	}
+	if err := h.store.Save(record); err != nil {
+		errs = append(errs, fmt.Errorf("failed to save the prior versions of the fixed resources: %w", err))
+	}
+	return record, errs
+}
+
+func (h *ClusterFixHandler) applyResourceFix(ctx context.Context, resourceToFix ClusterResourceFixInfo) (*RollbackResource, error) {
+	configuration, err := applyConfiguration(resourceToFix)
+	if err != nil {
+		return nil, err
+	}
+	revert, err := newTwoWayMergePatch(resourceToFix.Fixed, resourceToFix.Original, resourceToFix.dataStruct)
+	if err != nil {
+		return nil, err
+	}
+	revertJSON, err := json.Marshal(revert)
+	if err != nil {
+		return nil, err
+	}
+
+	live := resourceToFix.Live
+	// the fields managed by other field managers are not taken over, e.g. the fields of a Helm release or of a GitOps
+	// controller would be reverted by their next sync
+	if _, err := h.client.Resource(resourceToFix.GroupVersionResource).Namespace(live.GetNamespace()).Apply(ctx, live.GetName(),
+		&unstructured.Unstructured{Object: configuration}, k8smetav1.ApplyOptions{FieldManager: ClusterFixFieldManager}); err != nil {
+		if apierrors.IsConflict(err) {
+			return nil, fmt.Errorf("the fixed fields are managed by another field manager, fix them with the tool managing the resource: %w", err)
+		}
+		return nil, err
+	}
+
+	return &RollbackResource{
+		APIVersion: live.GetAPIVersion(),
+		Kind:       live.GetKind(),
+		Resource:   resourceToFix.GroupVersionResource.Resource,
+		Namespace:  live.GetNamespace(),
+		Name:       live.GetName(),
+		Prior:      live.Object,
+		Revert:     revertJSON,
+	}, nil
+}
+
+// applyConfiguration returns the configuration applied by the field manager: the fields it fixed before, which are
+// removed by server-side apply when missing from the configuration, and the fields fixed now
+func applyConfiguration(resourceToFix ClusterResourceFixInfo) (map[string]interface{}, error) {
+	patch, err := newTwoWayMergePatch(resourceToFix.Original, resourceToFix.Fixed, resourceToFix.dataStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	configuration := make(map[string]interface{})
+	for _, entry := range resourceToFix.Live.GetManagedFields() {
+		if entry.Manager != ClusterFixFieldManager || entry.Operation != k8smetav1.ManagedFieldsOperationApply || entry.Subresource != "" || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, err
+		}
+		if owned, ok := ownedFields(resourceToFix.Original, fields).(map[string]interface{}); ok {
+			configuration = owned
+		}
+	}
+
+	if len(configuration) > 0 {
+		ownedJSON, err := json.Marshal(configuration)
+		if err != nil {
+			return nil, err
+		}
+		patchJSON, err := json.Marshal(patch)
+		if err != nil {
+			return nil, err
+		}
+		mergedJSON, err := strategicpatch.StrategicMergePatch(ownedJSON, patchJSON, resourceToFix.dataStruct)
+		if err != nil {
+			return nil, err
+		}
+		patch = nil
+		if err := json.Unmarshal(mergedJSON, &patch); err != nil {
+			return nil, err
+		}
+	}
+
+	// server-side apply finds the resource by its identity
+	patch["apiVersion"] = resourceToFix.Live.GetAPIVersion()
+	patch["kind"] = resourceToFix.Live.GetKind()
+	metadata, _ := patch["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["name"] = resourceToFix.Live.GetName()
+	if namespace := resourceToFix.Live.GetNamespace(); namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	patch["metadata"] = metadata
+	return patch, nil
+}
+
+// ownedFields returns the part of the value owned according to the fields of a managed fields entry
+func ownedFields(value interface{}, fields map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		owned := make(map[string]interface{})
+		for key, subFields := range fields {
+			name, ok := strings.CutPrefix(key, "f:")
+			if !ok {
+				continue
+			}
+			if child, exists := v[name]; exists {
+				owned[name] = ownedChild(child, subFields)
+			}
+		}
+		return owned
+	case []interface{}:
+		indexes := make([]int, 0, len(fields))
+		elements := make(map[int]interface{}, len(fields))
+		for key, subFields := range fields {
+			index := listElementIndex(v, key)
+			if index < 0 {
+				continue
+			}
+			element := ownedChild(v[index], subFields)
+			// the elements of a map list are identified by their keys
+			if keyFields, ok := listElementKey(key); ok {
+				if elementMap, ok := element.(map[string]interface{}); ok {
+					for field, fieldValue := range keyFields {
+						elementMap[field] = fieldValue
+					}
+				}
+			}
+			indexes = append(indexes, index)
+			elements[index] = element
+		}
+		sort.Ints(indexes)
+		owned := make([]interface{}, 0, len(indexes))
+		for _, index := range indexes {
+			owned = append(owned, elements[index])
+		}
+		return owned
+	}
+	return value
+}
+
+// ownedChild returns the owned part of a child value, all of it when none of its own fields is listed
+func ownedChild(child interface{}, subFields interface{}) interface{} {
+	fields, _ := subFields.(map[string]interface{})
+	for key := range fields {
+		if key != "." {
+			return ownedFields(child, fields)
+		}
+	}
+	return deepCopyValue(child)
+}
+
+// listElementIndex returns the index of the list element identified by a key of the managed fields, or -1
+func listElementIndex(list []interface{}, key string) int {
+	if keyFields, ok := listElementKey(key); ok {
+		for i, element := range list {
+			elementMap, ok := element.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			matches := true
+			for field, fieldValue := range keyFields {
+				if !jsonEqual(elementMap[field], fieldValue) {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				return i
+			}
+		}
+		return -1
+	}
+	if rawValue, ok := strings.CutPrefix(key, "v:"); ok {
+		var value interface{}
+		if err := json.Unmarshal([]byte(rawValue), &value); err != nil {
+			return -1
+		}
+		for i, element := range list {
+			if jsonEqual(element, value) {
+				return i
+			}
+		}
+		return -1
+	}
+	if rawIndex, ok := strings.CutPrefix(key, "i:"); ok {
+		if index, err := strconv.Atoi(rawIndex); err == nil && index >= 0 && index < len(list) {
+			return index
+		}
+	}
+	return -1
+}
+
+func listElementKey(key string) (map[string]interface{}, bool) {
+	rawKey, ok := strings.CutPrefix(key, "k:")
+	if !ok {
+		return nil, false
+	}
+	var keyFields map[string]interface{}
+	if err := json.Unmarshal([]byte(rawKey), &keyFields); err != nil {
+		return nil, false
+	}
+	return keyFields, true
+}
+
+// jsonEqual compares values by their JSON encoding, the numbers decoded from JSON and from the cluster differ in type
+func jsonEqual(a, b interface{}) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aJSON) == string(bJSON)
+}
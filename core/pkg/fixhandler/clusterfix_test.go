@@ -0,0 +1,343 @@
+package fixhandler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+var deploymentsResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func newTestLiveDeployment() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "nginx",
+			"namespace":       "default",
+			"resourceVersion": "1",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "nginx",
+							"image": "nginx:1.27",
+							"securityContext": map[string]interface{}{
+								"allowPrivilegeEscalation": true,
+							},
+						},
+						map[string]interface{}{
+							"name":  "sidecar",
+							"image": "busybox",
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// writeTestClusterReport writes the report of a cluster scan in which the deployment fails a control
+func writeTestClusterReport(t *testing.T, contextName string, scanningTarget reporthandlingv2.ScanningTarget, fixPaths ...armotypes.FixPath) string {
+	t.Helper()
+	resource := reporthandling.NewResourceIMetadata(workloadinterface.NewWorkloadObj(newTestLiveDeployment().Object))
+
+	reportObj := reporthandlingv2.PostureReport{
+		Resources: []reporthandling.Resource{*resource},
+		Results: []resourcesresults.Result{{
+			ResourceID:         resource.GetID(),
+			AssociatedControls: []resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0016", fixPaths...)},
+		}},
+	}
+	reportObj.Metadata.ScanMetadata.ScanningTarget = scanningTarget
+	reportObj.Metadata.ContextMetadata.ClusterContextMetadata = &reporthandlingv2.ClusterMetadata{ContextName: contextName}
+
+	content, err := json.Marshal(reportObj)
+	require.NoError(t, err)
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, os.WriteFile(reportFile, content, 0600))
+	return reportFile
+}
+
+// deploymentPatchReactor patches the deployments of the fake client with the merge keys of their schema, which the
+// tracker of the client does not know for unstructured objects. Server-side apply is handled as a strategic merge patch
+func deploymentPatchReactor(client *dynamicfake.FakeDynamicClient) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		if patchAction.GetPatchType() != types.ApplyPatchType && patchAction.GetPatchType() != types.StrategicMergePatchType {
+			return false, nil, nil
+		}
+		current, err := client.Tracker().Get(deploymentsResource, patchAction.GetNamespace(), patchAction.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		currentJSON, err := json.Marshal(current)
+		if err != nil {
+			return true, nil, err
+		}
+		patchedJSON, err := strategicpatch.StrategicMergePatch(currentJSON, patchAction.GetPatch(), &appsv1.Deployment{})
+		if err != nil {
+			return true, nil, err
+		}
+		patched := &unstructured.Unstructured{}
+		if err := patched.UnmarshalJSON(patchedJSON); err != nil {
+			return true, nil, err
+		}
+		resourceVersion, _ := strconv.Atoi(patched.GetResourceVersion())
+		patched.SetResourceVersion(strconv.Itoa(resourceVersion + 1))
+		return true, patched, client.Tracker().Update(deploymentsResource, patched, patchAction.GetNamespace())
+	}
+}
+
+func newTestClusterFixHandler(t *testing.T, fixPaths ...armotypes.FixPath) (*ClusterFixHandler, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	k8sinterface.InitializeMapResourcesMock()
+	client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, newTestLiveDeployment())
+	client.PrependReactor("patch", "deployments", deploymentPatchReactor(client))
+	reportFile := writeTestClusterReport(t, "test-cluster", reporthandlingv2.Cluster, fixPaths...)
+
+	handler, err := NewClusterFixHandler(&metav1.FixInfo{ReportFile: reportFile}, client, "test-cluster", NewRollbackStore(t.TempDir()))
+	require.NoError(t, err)
+	return handler, client
+}
+
+var testClusterFixPaths = []armotypes.FixPath{
+	{Path: "spec.template.spec.containers[0].securityContext.allowPrivilegeEscalation", Value: "false"},
+	{Path: "spec.template.spec.securityContext.runAsNonRoot", Value: "true"},
+}
+
+func TestNewClusterFixHandler(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+
+	t.Run("report of a directory scan", func(t *testing.T) {
+		reportFile := writeTestClusterReport(t, "", reporthandlingv2.Directory)
+		_, err := NewClusterFixHandler(&metav1.FixInfo{ReportFile: reportFile}, client, "test-cluster", NewRollbackStore(t.TempDir()))
+		assert.ErrorContains(t, err, "cluster scan")
+	})
+
+	t.Run("report of another context", func(t *testing.T) {
+		reportFile := writeTestClusterReport(t, "other-cluster", reporthandlingv2.Cluster)
+		_, err := NewClusterFixHandler(&metav1.FixInfo{ReportFile: reportFile}, client, "test-cluster", NewRollbackStore(t.TempDir()))
+		assert.ErrorContains(t, err, "other-cluster")
+	})
+}
+
+func TestClusterFixAndRollback(t *testing.T) {
+	handler, client := newTestClusterFixHandler(t, testClusterFixPaths...)
+	ctx := context.TODO()
+
+	resourcesToFix := handler.PrepareResourcesToFix(ctx)
+	require.Len(t, resourcesToFix, 1)
+	assert.Equal(t, deploymentsResource, resourcesToFix[0].GroupVersionResource)
+
+	diff := resourceDiff(toYaml(t, resourcesToFix[0].Original), toYaml(t, resourcesToFix[0].Fixed))
+	assert.Contains(t, diff, "-           allowPrivilegeEscalation: true\n")
+	assert.Contains(t, diff, "+           allowPrivilegeEscalation: false\n")
+	assert.Contains(t, diff, "+         runAsNonRoot: true\n")
+
+	record, errs := handler.ApplyChanges(ctx, resourcesToFix)
+	require.Empty(t, errs)
+	require.NotNil(t, record)
+	assert.NotEmpty(t, record.ID)
+	assert.Equal(t, "test-cluster", record.Context)
+	require.Len(t, record.Resources, 1)
+	assert.Equal(t, "default", record.Resources[0].Namespace)
+
+	fixed, err := client.Resource(deploymentsResource).Namespace("default").Get(ctx, "nginx", k8smetav1.GetOptions{})
+	require.NoError(t, err)
+	value, _, _ := unstructured.NestedBool(fixed.Object, "spec", "template", "spec", "securityContext", "runAsNonRoot")
+	assert.True(t, value)
+	containers, _, _ := unstructured.NestedSlice(fixed.Object, "spec", "template", "spec", "containers")
+	require.Len(t, containers, 2)
+	assert.Equal(t, "nginx:1.27", containers[0].(map[string]interface{})["image"])
+	assert.Equal(t, false, containers[0].(map[string]interface{})["securityContext"].(map[string]interface{})["allowPrivilegeEscalation"])
+
+	// the fixed resource is not fixed again
+	assert.Empty(t, handler.PrepareResourcesToFix(ctx))
+
+	saved, err := handler.store.Load(record.ID)
+	require.NoError(t, err)
+	require.Empty(t, Rollback(ctx, client, saved))
+
+	restored, err := client.Resource(deploymentsResource).Namespace("default").Get(ctx, "nginx", k8smetav1.GetOptions{})
+	require.NoError(t, err)
+	_, found, _ := unstructured.NestedFieldNoCopy(restored.Object, "spec", "template", "spec", "securityContext")
+	assert.False(t, found)
+	containers, _, _ = unstructured.NestedSlice(restored.Object, "spec", "template", "spec", "containers")
+	require.Len(t, containers, 2)
+	assert.Equal(t, "nginx:1.27", containers[0].(map[string]interface{})["image"])
+	assert.Equal(t, true, containers[0].(map[string]interface{})["securityContext"].(map[string]interface{})["allowPrivilegeEscalation"])
+}
+
+func TestRollbackResourceChangedSinceFix(t *testing.T) {
+	handler, client := newTestClusterFixHandler(t, testClusterFixPaths...)
+	ctx := context.TODO()
+
+	record, errs := handler.ApplyChanges(ctx, handler.PrepareResourcesToFix(ctx))
+	require.Empty(t, errs)
+	saved, err := handler.store.Load(record.ID)
+	require.NoError(t, err)
+	resource := &saved.Resources[0]
+
+	fixed, err := client.Resource(deploymentsResource).Namespace("default").Get(ctx, "nginx", k8smetav1.GetOptions{})
+	require.NoError(t, err)
+	changed, err := resource.changedSinceFix(fixed)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// the changes of the fixed fields are rolled back
+	require.NoError(t, unstructured.SetNestedField(fixed.Object, false, "spec", "template", "spec", "securityContext", "runAsNonRoot"))
+	fixed.SetResourceVersion("10")
+	changed, err = resource.changedSinceFix(fixed)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// the changes of the other fields are kept
+	require.NoError(t, unstructured.SetNestedField(fixed.Object, int64(3), "spec", "replicas"))
+	changed, err = resource.changedSinceFix(fixed)
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestClusterFixSkipsMissingResource(t *testing.T) {
+	handler, client := newTestClusterFixHandler(t, testClusterFixPaths...)
+	require.NoError(t, client.Resource(deploymentsResource).Namespace("default").Delete(context.TODO(), "nginx", k8smetav1.DeleteOptions{}))
+
+	assert.Empty(t, handler.PrepareResourcesToFix(context.TODO()))
+}
+
+func TestApplyConfigurationKeepsOwnedFields(t *testing.T) {
+	live := newTestLiveDeployment()
+	// the field manager fixed allowPrivilegeEscalation before
+	live.SetManagedFields([]k8smetav1.ManagedFieldsEntry{
+		{
+			Manager:   "kubectl-client-side-apply",
+			Operation: k8smetav1.ManagedFieldsOperationUpdate,
+			FieldsV1:  &k8smetav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+		},
+		{
+			Manager:   ClusterFixFieldManager,
+			Operation: k8smetav1.ManagedFieldsOperationApply,
+			FieldsV1: &k8smetav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:template":{"f:spec":{"f:containers":{` +
+				`"k:{\"name\":\"nginx\"}":{".":{},"f:name":{},"f:securityContext":{"f:allowPrivilegeEscalation":{}}}}}}}}`)},
+		},
+	})
+	original := sanitizedObject(live.Object)
+	fixed := sanitizedObject(live.Object)
+	require.NoError(t, unstructured.SetNestedField(fixed, true, "spec", "template", "spec", "securityContext", "runAsNonRoot"))
+
+	dataStruct, err := scheme.Scheme.New(live.GroupVersionKind())
+	require.NoError(t, err)
+	configuration, err := applyConfiguration(ClusterResourceFixInfo{Live: live, Original: original, Fixed: fixed, dataStruct: dataStruct})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":            "nginx",
+							"securityContext": map[string]interface{}{"allowPrivilegeEscalation": true},
+						},
+					},
+					"securityContext": map[string]interface{}{"runAsNonRoot": true},
+				},
+			},
+		},
+	}, configuration)
+}
+
+func TestOwnedFields(t *testing.T) {
+	value := map[string]interface{}{
+		"ports": []interface{}{
+			map[string]interface{}{"containerPort": int64(80), "protocol": "TCP", "name": "http"},
+			map[string]interface{}{"containerPort": int64(443), "protocol": "TCP", "name": "https"},
+		},
+		"args":  []interface{}{"--a", "--b"},
+		"image": "nginx",
+	}
+	fields := map[string]interface{}{
+		"f:ports": map[string]interface{}{
+			`k:{"containerPort":443,"protocol":"TCP"}`: map[string]interface{}{".": map[string]interface{}{}, "f:name": map[string]interface{}{}},
+		},
+		"f:args": map[string]interface{}{"v:\"--b\"": map[string]interface{}{}},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"ports": []interface{}{map[string]interface{}{"containerPort": float64(443), "protocol": "TCP", "name": "https"}},
+		"args":  []interface{}{"--b"},
+	}, ownedFields(value, fields))
+}
+
+func TestRollbackStore(t *testing.T) {
+	store := NewRollbackStore(t.TempDir())
+
+	records, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	createdAt := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	first := &RollbackRecord{Context: "test-cluster", CreatedAt: createdAt, Resources: []RollbackResource{{Kind: "Deployment", Name: "nginx"}}}
+	second := &RollbackRecord{Context: "test-cluster", CreatedAt: createdAt}
+	require.NoError(t, store.Save(first))
+	require.NoError(t, store.Save(second))
+	assert.Equal(t, "20261019-123000", first.ID)
+	assert.Equal(t, "20261019-123000-2", second.ID)
+
+	loaded, err := store.Load(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", loaded.Resources[0].Name)
+
+	records, err = store.List()
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	require.NoError(t, store.Delete(first.ID))
+	_, err = store.Load(first.ID)
+	assert.ErrorContains(t, err, "no cluster fix")
+}
+
+func TestResourceDiff(t *testing.T) {
+	original := "a: 1\nb: 2\nc: 3\nd: 4\ne: 5\nf: 6\ng: 7\nh: 8\n"
+	fixed := "a: 1\nb: 2\nc: 3\nd: 4\ne: 5\nf: 6\ng: 7\nh: 9\n"
+	assert.Equal(t, "  ...\n  e: 5\n  f: 6\n  g: 7\n- h: 8\n+ h: 9\n", resourceDiff(original, fixed))
+}
+
+func toYaml(t *testing.T, object map[string]interface{}) string {
+	t.Helper()
+	content, err := sigsyaml.Marshal(object)
+	require.NoError(t, err)
+	return string(content)
+}
@@ -16,6 +16,7 @@ type FixHandler struct {
 	fixInfo       *metav1.FixInfo
 	reportObj     *reporthandlingv2.PostureReport
 	localBasePath string
+	helmCharts    map[string]*helmChartTracer // tracers of the Helm charts by chart path
 }
 
 // ResourceFixInfo is a struct that holds the information about the resource that needs to be fixed
@@ -24,6 +25,22 @@ type ResourceFixInfo struct {
 	Resource        *reporthandling.Resource
 	FilePath        string
 	DocumentIndex   int
+	Controls        map[string]FixControl // control fixed by each yaml expression
+	HelmChartPath   string                // Helm chart the resource is rendered from, the file is a values file or a template of the chart
+	HelmTemplate    bool                  // the file is a template of the Helm chart, fixed through its rendered output
+	KustomizeDir    string                // Kustomize overlay the resource is rendered from, the file is a patch of the overlay
+}
+
+// FixControl identifies the control a fix comes from
+type FixControl struct {
+	ID   string
+	Name string
+}
+
+// ControlFixes holds the fixes of the resources failing a control
+type ControlFixes struct {
+	FixControl
+	Resources []ResourceFixInfo
 }
 
 // NodeInfo holds extra information about the node
@@ -3,8 +3,10 @@ package fixhandler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
@@ -31,38 +33,50 @@ const unixNewline = "\n"
 const oldMacNewline = "\r"
 
 func NewFixHandler(fixInfo *metav1.FixInfo) (*FixHandler, error) {
-	jsonFile, err := os.Open(fixInfo.ReportFile)
+	reportObj, err := readReport(fixInfo.ReportFile)
 	if err != nil {
 		return nil, err
 	}
-	defer jsonFile.Close()
-	byteValue, _ := io.ReadAll(jsonFile)
-
-	var reportObj reporthandlingv2.PostureReport
-	if err = json.Unmarshal(byteValue, &reportObj); err != nil {
-		return nil, err
-	}
 
-	if err = isSupportedScanningTarget(&reportObj); err != nil {
+	if err = isSupportedScanningTarget(reportObj); err != nil {
 		return nil, err
 	}
 
-	localPath := getLocalPath(&reportObj)
+	localPath := getLocalPath(reportObj)
 	if _, err = os.Stat(localPath); err != nil {
 		return nil, err
 	}
 
-	backendLoggerLeveled := logging.AddModuleLevel(logging.NewLogBackend(logger.L().GetWriter(), "", 0))
-	backendLoggerLeveled.SetLevel(logging.ERROR, "")
-	yqlib.GetLogger().SetBackend(backendLoggerLeveled)
+	setYqLogger()
 
 	return &FixHandler{
 		fixInfo:       fixInfo,
-		reportObj:     &reportObj,
+		reportObj:     reportObj,
 		localBasePath: localPath,
 	}, nil
 }
 
+func readReport(reportFile string) (*reporthandlingv2.PostureReport, error) {
+	jsonFile, err := os.Open(reportFile)
+	if err != nil {
+		return nil, err
+	}
+	defer jsonFile.Close()
+	byteValue, _ := io.ReadAll(jsonFile)
+
+	var reportObj reporthandlingv2.PostureReport
+	if err = json.Unmarshal(byteValue, &reportObj); err != nil {
+		return nil, err
+	}
+	return &reportObj, nil
+}
+
+func setYqLogger() {
+	backendLoggerLeveled := logging.AddModuleLevel(logging.NewLogBackend(logger.L().GetWriter(), "", 0))
+	backendLoggerLeveled.SetLevel(logging.ERROR, "")
+	yqlib.GetLogger().SetBackend(backendLoggerLeveled)
+}
+
 func isSupportedScanningTarget(report *reporthandlingv2.PostureReport) error {
 	scanningTarget := report.Metadata.ScanMetadata.ScanningTarget
 	if scanningTarget == reporthandlingv2.GitLocal || scanningTarget == reporthandlingv2.Directory || scanningTarget == reporthandlingv2.File {
@@ -134,6 +148,16 @@ func (h *FixHandler) PrepareResourcesToFix(ctx context.Context) []ResourceFixInf
 			continue
 		}
 
+		if resourceObj.Source != nil && resourceObj.Source.FileType == reporthandling.SourceTypeHelmChart {
+			resourcesToFix = append(resourcesToFix, h.prepareHelmResourceToFix(ctx, resourceObj, resourcePath, result.AssociatedControls)...)
+			continue
+		}
+
+		if resourceObj.Source != nil && resourceObj.Source.FileType == reporthandling.SourceTypeKustomizeDirectory {
+			resourcesToFix = append(resourcesToFix, h.prepareKustomizeResourceToFix(ctx, resourceObj, resourcePath, result.AssociatedControls)...)
+			continue
+		}
+
 		if resourceObj.Source == nil || resourceObj.Source.FileType != reporthandling.SourceTypeYaml {
 			continue
 		}
@@ -155,6 +179,7 @@ func (h *FixHandler) PrepareResourcesToFix(ctx context.Context) []ResourceFixInf
 			Resource:        resourceObj,
 			YamlExpressions: make(map[string]armotypes.FixPath, 0),
 			DocumentIndex:   documentIndex,
+			Controls:        make(map[string]FixControl, 0),
 		}
 
 		for i := range result.AssociatedControls {
@@ -194,22 +219,54 @@ func (h *FixHandler) PrintExpectedChanges(resourcesToFix []ResourceFixInfo) {
 
 func (h *FixHandler) ApplyChanges(ctx context.Context, resourcesToFix []ResourceFixInfo) (int, []error) {
 	updatedFiles := make(map[string]bool)
-	errors := make([]error, 0)
+	errs := make([]error, 0)
 
 	fileYamlExpressions := h.getFileYamlExpressions(resourcesToFix)
 
+	// the templates of Helm charts are fixed through their rendered output, the values files are created when missing
+	helmTemplates := make(map[string]string)
+	helmValuesFiles := make(map[string]bool)
+	kustomizePatches := make(map[string]ResourceFixInfo)
+	for _, resourceToFix := range resourcesToFix {
+		if resourceToFix.KustomizeDir != "" {
+			kustomizePatches[resourceToFix.FilePath] = resourceToFix
+		} else if resourceToFix.HelmTemplate {
+			helmTemplates[resourceToFix.FilePath] = resourceToFix.HelmChartPath
+		} else if resourceToFix.HelmChartPath != "" {
+			helmValuesFiles[resourceToFix.FilePath] = true
+		}
+	}
+
 	for filepath, yamlExpression := range fileYamlExpressions {
+		// the fixes of resources rendered by Kustomize are written to a patch of the overlay
+		if resourceToFix, ok := kustomizePatches[filepath]; ok {
+			if err := h.writeKustomizePatch(ctx, resourceToFix, yamlExpression); err != nil {
+				errs = append(errs, fmt.Errorf("Failed to fix file %s: %w ", filepath, err))
+				continue
+			}
+			updatedFiles[filepath] = true
+			continue
+		}
+
 		fileAsString, err := GetFileString(filepath)
+		if errors.Is(err, fs.ErrNotExist) && helmValuesFiles[filepath] {
+			fileAsString, err = "", nil
+		}
 
 		if err != nil {
-			errors = append(errors, err)
+			errs = append(errs, err)
 			continue
 		}
 
-		fixedYamlString, err := ApplyFixToContent(ctx, fileAsString, yamlExpression)
+		var fixedYamlString string
+		if chartPath, ok := helmTemplates[filepath]; ok {
+			fixedYamlString, err = h.fixHelmTemplate(ctx, chartPath, filepath, fileAsString, yamlExpression)
+		} else {
+			fixedYamlString, err = ApplyFixToContent(ctx, fileAsString, yamlExpression)
+		}
 
 		if err != nil {
-			errors = append(errors, fmt.Errorf("Failed to fix file %s: %w ", filepath, err))
+			errs = append(errs, fmt.Errorf("Failed to fix file %s: %w ", filepath, err))
 			continue
 		} else {
 			updatedFiles[filepath] = true
@@ -219,11 +276,11 @@ func (h *FixHandler) ApplyChanges(ctx context.Context, resourcesToFix []Resource
 
 		if err != nil {
 			logger.L().Ctx(ctx).Warning(fmt.Sprintf("Failed to write fixes to file %s, %v", filepath, err.Error()))
-			errors = append(errors, err)
+			errs = append(errs, err)
 		}
 	}
 
-	return len(updatedFiles), errors
+	return len(updatedFiles), errs
 }
 
 func (h *FixHandler) getFilePathAndIndex(filePathWithIndex string) (filePath string, documentIndex int, err error) {
@@ -241,6 +298,10 @@ func (h *FixHandler) getFilePathAndIndex(filePathWithIndex string) (filePath str
 }
 
 func ApplyFixToContent(ctx context.Context, yamlAsString, yamlExpression string) (fixedString string, err error) {
+	if strings.TrimSpace(yamlAsString) == "" {
+		return newYamlDocument(ctx, yamlExpression)
+	}
+
 	yamlAsString = sanitizeYaml(yamlAsString)
 	newline := determineNewlineSeparator(yamlAsString)
 
@@ -258,6 +319,18 @@ func ApplyFixToContent(ctx context.Context, yamlAsString, yamlExpression string)
 		return "", err
 	}
 
+	// the fixes are inserted as lines, which cannot go in an empty flow mapping such as "securityContext: {}"
+	if expandedLines, expanded := expandFilledFlowMappings(yamlLines, originalRootNodes, fixedRootNodes); expanded {
+		yamlLines = expandedLines
+		yamlAsString = strings.Join(yamlLines, newline)
+		if originalRootNodes, err = decodeDocumentRoots(yamlAsString); err != nil {
+			return "", err
+		}
+		if fixedRootNodes, err = getFixedNodes(ctx, yamlAsString, yamlExpression); err != nil {
+			return "", err
+		}
+	}
+
 	fixInfo := getFixInfo(ctx, originalRootNodes, fixedRootNodes)
 
 	fixedYamlLines := getFixedYamlLines(yamlLines, fixInfo, newline)
@@ -303,6 +376,10 @@ func (rfi *ResourceFixInfo) addYamlExpressionsFromResourceAssociatedControl(docu
 
 			yamlExpression := FixPathToValidYamlExpression(rulePaths.FixPath.Path, rulePaths.FixPath.Value, documentIndex)
 			rfi.YamlExpressions[yamlExpression] = rulePaths.FixPath
+			if _, ok := rfi.Controls[yamlExpression]; !ok && rfi.Controls != nil {
+				// an expression fixing several controls is committed with the first one
+				rfi.Controls[yamlExpression] = FixControl{ID: ac.ControlID, Name: ac.Name}
+			}
 		}
 	}
 }
@@ -344,7 +421,7 @@ func GetFileString(filepath string) (string, error) {
 	bytes, err := os.ReadFile(filepath)
 
 	if err != nil {
-		return "", fmt.Errorf("Error reading file %s", filepath)
+		return "", fmt.Errorf("Error reading file %s: %w", filepath, err)
 	}
 
 	return string(bytes), nil
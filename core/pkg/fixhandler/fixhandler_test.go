@@ -110,6 +110,13 @@ func getTestCases() []indentationTestCase {
 			"inserts/tc-12-01-expected.yaml",
 		},
 
+		// Empty flow mapping
+		{
+			"inserts/tc-13-00-input-empty-flow-mapping.yaml",
+			"select(di==0).spec.containers[0].securityContext.allowPrivilegeEscalation |= false",
+			"inserts/tc-13-01-expected.yaml",
+		},
+
 		// Removal Scenarios
 		{
 			"removals/tc-01-00-input.yaml",
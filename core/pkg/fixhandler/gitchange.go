@@ -0,0 +1,243 @@
+package fixhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+)
+
+const (
+	defaultCommitAuthorName  = "kubescape"
+	defaultCommitAuthorEmail = "kubescape@localhost"
+)
+
+// GitChange is a git branch holding the fixes of a report, with a commit per control
+type GitChange struct {
+	Repository *git.Repository
+	Branch     string
+	Base       plumbing.Hash   // commit the branch was created from
+	Commits    []plumbing.Hash // commits of the branch, oldest first
+}
+
+// GroupByControl splits the fixes of the resources by control, sorted by control ID
+func GroupByControl(resourcesToFix []ResourceFixInfo) []ControlFixes {
+	groups := make(map[string]*ControlFixes)
+	for _, resourceToFix := range resourcesToFix {
+		byControl := make(map[string]*ResourceFixInfo)
+		expressions := make([]string, 0, len(resourceToFix.YamlExpressions))
+		for expression := range resourceToFix.YamlExpressions {
+			expressions = append(expressions, expression)
+		}
+		sort.Strings(expressions)
+
+		for _, expression := range expressions {
+			control := resourceToFix.Controls[expression]
+			if _, ok := groups[control.ID]; !ok {
+				groups[control.ID] = &ControlFixes{FixControl: control}
+			}
+			if _, ok := byControl[control.ID]; !ok {
+				controlResource := resourceToFix
+				controlResource.YamlExpressions = make(map[string]armotypes.FixPath)
+				controlResource.Controls = make(map[string]FixControl)
+				byControl[control.ID] = &controlResource
+			}
+			byControl[control.ID].YamlExpressions[expression] = resourceToFix.YamlExpressions[expression]
+			byControl[control.ID].Controls[expression] = control
+		}
+
+		for controlID, resource := range byControl {
+			groups[controlID].Resources = append(groups[controlID].Resources, *resource)
+		}
+	}
+
+	controlFixes := make([]ControlFixes, 0, len(groups))
+	for _, group := range groups {
+		controlFixes = append(controlFixes, *group)
+	}
+	sort.Slice(controlFixes, func(i, j int) bool {
+		return controlFixes[i].ID < controlFixes[j].ID
+	})
+	return controlFixes
+}
+
+// CommitChanges creates the branch from the HEAD of the git repository of the scanned files, applies the fixes and commits
+// the fixes of each control separately. The errors of the fixes that could not be applied are returned with the change
+func (h *FixHandler) CommitChanges(ctx context.Context, resourcesToFix []ResourceFixInfo, branch string) (*GitChange, []error, error) {
+	repo, err := git.PlainOpenWithOptions(h.localBasePath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open the git repository of %s: %w", h.localBasePath, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, err
+	}
+	root := worktree.Filesystem.Root()
+
+	if err := checkFilesCommitted(worktree, root, resourcesToFix); err != nil {
+		return nil, nil, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the HEAD of the git repository: %w", err)
+	}
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	if _, err := repo.Reference(branchRef, false); err == nil {
+		return nil, nil, fmt.Errorf("branch %s already exists", branch)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Create: true, Keep: true}); err != nil {
+		return nil, nil, fmt.Errorf("failed to create branch %s: %w", branch, err)
+	}
+
+	change := &GitChange{
+		Repository: repo,
+		Branch:     branch,
+		Base:       head.Hash(),
+	}
+	author := commitSignature(repo)
+
+	var fixErrors []error
+	for _, controlFixes := range GroupByControl(resourcesToFix) {
+		_, errs := h.ApplyChanges(ctx, controlFixes.Resources)
+		fixErrors = append(fixErrors, errs...)
+
+		for _, resourceToFix := range controlFixes.Resources {
+			for _, filePath := range changedFiles(resourceToFix) {
+				relativePath, err := relativeToRoot(root, filePath)
+				if err != nil {
+					return change, fixErrors, err
+				}
+				if _, err := worktree.Add(relativePath); err != nil {
+					return change, fixErrors, fmt.Errorf("failed to stage %s: %w", relativePath, err)
+				}
+			}
+		}
+
+		hash, err := worktree.Commit(commitMessage(root, controlFixes), &git.CommitOptions{Author: author})
+		if errors.Is(err, git.ErrEmptyCommit) {
+			logger.L().Ctx(ctx).Debug("no changes to commit", helpers.String("control", controlFixes.ID))
+			continue
+		}
+		if err != nil {
+			return change, fixErrors, fmt.Errorf("failed to commit the fixes of control %s: %w", controlFixes.ID, err)
+		}
+		change.Commits = append(change.Commits, hash)
+	}
+
+	return change, fixErrors, nil
+}
+
+// checkFilesCommitted checks the files to fix have no uncommitted changes, which would be committed with the fixes
+func checkFilesCommitted(worktree *git.Worktree, root string, resourcesToFix []ResourceFixInfo) error {
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get the status of the git repository: %w", err)
+	}
+	for _, resourceToFix := range resourcesToFix {
+		for _, filePath := range changedFiles(resourceToFix) {
+			relativePath, err := relativeToRoot(root, filePath)
+			if err != nil {
+				return err
+			}
+			if fileStatus, ok := status[relativePath]; ok && (fileStatus.Staging != git.Unmodified || fileStatus.Worktree != git.Unmodified) {
+				return fmt.Errorf("file %s has uncommitted changes", relativePath)
+			}
+		}
+	}
+	return nil
+}
+
+// changedFiles returns the files changed by the fixes of a resource, the patches of Kustomize overlays are registered in
+// their Kustomization file
+func changedFiles(resourceToFix ResourceFixInfo) []string {
+	files := []string{resourceToFix.FilePath}
+	if resourceToFix.KustomizeDir != "" {
+		if kustomizationFile := cautils.FindKustomizationFile(resourceToFix.KustomizeDir); kustomizationFile != "" {
+			files = append(files, kustomizationFile)
+		}
+	}
+	return files
+}
+
+// relativeToRoot returns the slash separated path of the file relative to the root of the worktree
+func relativeToRoot(root, path string) (string, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// the worktree root and the report paths can go through different symlinks, e.g. a temporary directory
+	if resolved, err := filepath.EvalSymlinks(absolutePath); err == nil {
+		absolutePath = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	relativePath, err := filepath.Rel(root, absolutePath)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return "", fmt.Errorf("file %s is not in the git repository %s", path, root)
+	}
+	return filepath.ToSlash(relativePath), nil
+}
+
+// commitSignature returns the author of the commits from the git config, or a default author
+func commitSignature(repo *git.Repository) *object.Signature {
+	signature := &object.Signature{
+		Name:  defaultCommitAuthorName,
+		Email: defaultCommitAuthorEmail,
+		When:  time.Now(),
+	}
+	cfg, err := repo.ConfigScoped(config.GlobalScope)
+	if err != nil {
+		return signature
+	}
+	if cfg.User.Name != "" {
+		signature.Name = cfg.User.Name
+	}
+	if cfg.User.Email != "" {
+		signature.Email = cfg.User.Email
+	}
+	return signature
+}
+
+// commitMessage describes the fixes of a control: the control in the subject, the fixed resources and their changes in the body
+func commitMessage(root string, controlFixes ControlFixes) string {
+	var sb strings.Builder
+	if controlFixes.Name != "" {
+		sb.WriteString(fmt.Sprintf("Fix %s: %s\n\n", controlFixes.ID, controlFixes.Name))
+	} else {
+		sb.WriteString(fmt.Sprintf("Fix %s\n\n", controlFixes.ID))
+	}
+	sb.WriteString(fmt.Sprintf("Fix the resources failing control %s, see %s\n", controlFixes.ID, cautils.GetControlLink(controlFixes.ID)))
+
+	for _, resourceToFix := range controlFixes.Resources {
+		filePath, err := relativeToRoot(root, resourceToFix.FilePath)
+		if err != nil {
+			filePath = resourceToFix.FilePath
+		}
+		sb.WriteString(fmt.Sprintf("\n%s/%s in %s:\n", resourceToFix.Resource.GetKind(), resourceToFix.Resource.GetName(), filePath))
+
+		expressions := make([]string, 0, len(resourceToFix.YamlExpressions))
+		for expression := range resourceToFix.YamlExpressions {
+			expressions = append(expressions, expression)
+		}
+		sort.Strings(expressions)
+		for _, expression := range expressions {
+			fixPath := resourceToFix.YamlExpressions[expression]
+			sb.WriteString(fmt.Sprintf("- %s = %s\n", fixPath.Path, fixPath.Value))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,201 @@
+package fixhandler
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx
+spec:
+  containers:
+  - name: nginx
+    image: nginx
+`
+
+// newTestRepository creates a git repository with a committed pod manifest
+func newTestRepository(t *testing.T) (*git.Repository, string) {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pod.yaml"), []byte(testPod), 0600))
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add("pod.yaml")
+	require.NoError(t, err)
+	_, err = worktree.Commit("Add pod", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com"}})
+	require.NoError(t, err)
+	return repo, dir
+}
+
+// newTestResourcesToFix returns the fixes of two controls on the pod
+func newTestResourcesToFix(dir string) []ResourceFixInfo {
+	resource := reporthandling.NewResource(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "nginx"},
+	})
+	fixes := []struct {
+		control FixControl
+		path    string
+		value   string
+	}{
+		{FixControl{ID: "C-0017", Name: "Immutable container filesystem"}, "spec.containers[0].securityContext.readOnlyRootFilesystem", "true"},
+		{FixControl{ID: "C-0016", Name: "Allow privilege escalation"}, "spec.containers[0].securityContext.allowPrivilegeEscalation", "false"},
+	}
+
+	rfi := ResourceFixInfo{
+		YamlExpressions: make(map[string]armotypes.FixPath),
+		Resource:        resource,
+		FilePath:        filepath.Join(dir, "pod.yaml"),
+		Controls:        make(map[string]FixControl),
+	}
+	for _, fix := range fixes {
+		expression := FixPathToValidYamlExpression(fix.path, fix.value, 0)
+		rfi.YamlExpressions[expression] = armotypes.FixPath{Path: fix.path, Value: fix.value}
+		rfi.Controls[expression] = fix.control
+	}
+	return []ResourceFixInfo{rfi}
+}
+
+func TestGroupByControl(t *testing.T) {
+	groups := GroupByControl(newTestResourcesToFix("/repo"))
+	require.Len(t, groups, 2)
+
+	assert.Equal(t, "C-0016", groups[0].ID)
+	assert.Equal(t, "C-0017", groups[1].ID)
+	for _, group := range groups {
+		require.Len(t, group.Resources, 1)
+		require.Len(t, group.Resources[0].YamlExpressions, 1)
+		for expression := range group.Resources[0].YamlExpressions {
+			assert.Equal(t, group.ID, group.Resources[0].Controls[expression].ID)
+		}
+	}
+}
+
+func TestCommitChanges(t *testing.T) {
+	handler, err := NewFixHandlerMock()
+	require.NoError(t, err)
+
+	t.Run("commit per control and push to a remote", func(t *testing.T) {
+		repo, dir := newTestRepository(t)
+		handler.localBasePath = dir
+
+		change, fixErrors, err := handler.CommitChanges(context.TODO(), newTestResourcesToFix(dir), "kubescape-fixes")
+		require.NoError(t, err)
+		assert.Empty(t, fixErrors)
+		require.Len(t, change.Commits, 2)
+
+		head, err := repo.Head()
+		require.NoError(t, err)
+		assert.Equal(t, plumbing.NewBranchReferenceName("kubescape-fixes"), head.Name())
+		assert.Equal(t, change.Commits[1], head.Hash())
+
+		first, err := repo.CommitObject(change.Commits[0])
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(first.Message, "Fix C-0016: Allow privilege escalation\n"))
+		assert.Contains(t, first.Message, "Pod/nginx in pod.yaml:\n- spec.containers[0].securityContext.allowPrivilegeEscalation = false\n")
+		assert.Equal(t, []plumbing.Hash{change.Base}, first.ParentHashes)
+
+		fixed, err := os.ReadFile(filepath.Join(dir, "pod.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(fixed), "allowPrivilegeEscalation: false")
+		assert.Contains(t, string(fixed), "readOnlyRootFilesystem: true")
+
+		// the remote is a bare repository, pushed to by path
+		remoteDir := t.TempDir()
+		remote, err := git.PlainInit(remoteDir, true)
+		require.NoError(t, err)
+
+		require.NoError(t, NewPushProvider(remoteDir).Publish(context.TODO(), change))
+		pushed, err := remote.Reference(plumbing.NewBranchReferenceName("kubescape-fixes"), true)
+		require.NoError(t, err)
+		assert.Equal(t, change.Commits[1], pushed.Hash())
+	})
+
+	t.Run("push to a configured remote", func(t *testing.T) {
+		repo, dir := newTestRepository(t)
+		handler.localBasePath = dir
+
+		remoteDir := t.TempDir()
+		remote, err := git.PlainInit(remoteDir, true)
+		require.NoError(t, err)
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}})
+		require.NoError(t, err)
+
+		change, _, err := handler.CommitChanges(context.TODO(), newTestResourcesToFix(dir), "kubescape-fixes")
+		require.NoError(t, err)
+		require.NoError(t, NewPushProvider("origin").Publish(context.TODO(), change))
+
+		pushed, err := remote.Reference(plumbing.NewBranchReferenceName("kubescape-fixes"), true)
+		require.NoError(t, err)
+		assert.Equal(t, change.Commits[1], pushed.Hash())
+	})
+
+	t.Run("uncommitted changes", func(t *testing.T) {
+		_, dir := newTestRepository(t)
+		handler.localBasePath = dir
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pod.yaml"), []byte(testPod+"# edited\n"), 0600))
+
+		_, _, err := handler.CommitChanges(context.TODO(), newTestResourcesToFix(dir), "kubescape-fixes")
+		assert.ErrorContains(t, err, "uncommitted changes")
+	})
+
+	t.Run("existing branch", func(t *testing.T) {
+		repo, dir := newTestRepository(t)
+		handler.localBasePath = dir
+		head, err := repo.Head()
+		require.NoError(t, err)
+		require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("kubescape-fixes"), head.Hash())))
+
+		_, _, err = handler.CommitChanges(context.TODO(), newTestResourcesToFix(dir), "kubescape-fixes")
+		assert.ErrorContains(t, err, "already exists")
+	})
+
+	t.Run("not a git repository", func(t *testing.T) {
+		handler.localBasePath = t.TempDir()
+		_, _, err := handler.CommitChanges(context.TODO(), newTestResourcesToFix(handler.localBasePath), "kubescape-fixes")
+		assert.Error(t, err)
+	})
+}
+
+func TestWritePatch(t *testing.T) {
+	handler, err := NewFixHandlerMock()
+	require.NoError(t, err)
+	_, dir := newTestRepository(t)
+	handler.localBasePath = dir
+
+	change, _, err := handler.CommitChanges(context.TODO(), newTestResourcesToFix(dir), "kubescape-fixes")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePatch(&buf, change))
+	patch := buf.String()
+	assert.Contains(t, patch, "Subject: [PATCH 1/2] Fix C-0016: Allow privilege escalation\n")
+	assert.Contains(t, patch, "Subject: [PATCH 2/2] Fix C-0017: Immutable container filesystem\n")
+	assert.Contains(t, patch, "diff --git a/pod.yaml b/pod.yaml\n")
+	assert.Contains(t, patch, "+      allowPrivilegeEscalation: false\n")
+
+	patchFile := filepath.Join(t.TempDir(), "fixes.patch")
+	require.NoError(t, NewPatchProvider(patchFile).Publish(context.TODO(), change))
+	written, err := os.ReadFile(patchFile)
+	require.NoError(t, err)
+	assert.Equal(t, patch, string(written))
+}
@@ -0,0 +1,648 @@
+package fixhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultValuesFile = "values.yaml"
+
+	// probeValue and probeKey are set in the chart values to find where a values key is rendered
+	probeValue = "kubescape-values-probe"
+	probeKey   = "kubescapeValuesProbe"
+)
+
+var plainValuesKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// valuesWiring is a values key rendered at a field of a resource
+type valuesWiring struct {
+	valuesKey []string
+	field     []string // path of the field in the rendered resource
+	isMap     bool     // the values key is a map rendered at the field, e.g. with toYaml
+}
+
+// helmChartTracer traces the fields of the resources rendered from a Helm chart to the values keys rendering them, by
+// rendering the chart with a probe in each values key
+type helmChartTracer struct {
+	chart      *cautils.HelmChart
+	chartPath  string
+	valuesFile string                 // values file the fixes are written to
+	values     map[string]interface{} // default values merged with the values file
+
+	resources map[string]renderedResource // rendered resources by resource key
+	wirings   map[string][]valuesWiring   // values keys rendered in the resources by resource key
+}
+
+// renderedResource is a resource rendered from a template of the chart
+type renderedResource struct {
+	object        interface{}
+	documentIndex int
+}
+
+func newHelmChartTracer(chartPath, valuesFile string) (*helmChartTracer, error) {
+	chart, err := cautils.NewHelmChart(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Helm chart %s: %w", chartPath, err)
+	}
+
+	if valuesFile == "" {
+		valuesFile = defaultValuesFile
+	}
+	if !filepath.IsAbs(valuesFile) {
+		valuesFile = filepath.Join(chartPath, valuesFile)
+	}
+	values, err := loadChartValues(chart, valuesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := chart.RenderTemplates(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render Helm chart %s: %w", chartPath, err)
+	}
+
+	t := &helmChartTracer{
+		chart:      chart,
+		chartPath:  chartPath,
+		valuesFile: valuesFile,
+		values:     values,
+		resources:  make(map[string]renderedResource),
+		wirings:    make(map[string][]valuesWiring),
+	}
+	for templatePath, content := range rendered {
+		documents, _ := decodeRenderedDocuments(content)
+		for i, document := range documents {
+			t.resources[resourceKey(templatePath, document)] = renderedResource{object: document, documentIndex: i}
+		}
+	}
+	t.probe()
+	return t, nil
+}
+
+// probe renders the chart once per values key, with a probe value in scalar keys and a probe key in map keys, and records
+// the fields the probes are rendered at
+func (t *helmChartTracer) probe() {
+	walkValues(t.values, nil, func(valuesKey []string, value interface{}) {
+		var probe interface{}
+		var isMap bool
+		switch v := value.(type) {
+		case map[string]interface{}:
+			probeMap := deepCopyValue(v).(map[string]interface{})
+			probeMap[probeKey] = probeValue
+			probe, isMap = probeMap, true
+		case []interface{}:
+			return
+		default:
+			probe = probeValue
+		}
+
+		probeValues := deepCopyValue(t.values).(map[string]interface{})
+		setValue(probeValues, valuesKey, probe)
+		rendered, err := t.chart.RenderTemplates(probeValues)
+		if err != nil {
+			return
+		}
+
+		for templatePath, content := range rendered {
+			needle := probeValue
+			if isMap {
+				needle = probeKey
+			}
+			if !strings.Contains(content, needle) {
+				continue
+			}
+			documents, _ := decodeRenderedDocuments(content)
+			for _, document := range documents {
+				key := resourceKey(templatePath, document)
+				for _, field := range findProbe(document, nil, isMap) {
+					t.wirings[key] = append(t.wirings[key], valuesWiring{valuesKey: valuesKey, field: field, isMap: isMap})
+				}
+			}
+		}
+	})
+}
+
+// documentIndex returns the index of the resource in its rendered template
+func (t *helmChartTracer) documentIndex(templatePath string, resource *reporthandling.Resource) (int, bool) {
+	rendered, ok := t.resources[resourceKeyOf(templatePath, resource.GetKind(), resource.GetName())]
+	return rendered.documentIndex, ok
+}
+
+// traceValue returns the values key setting the fixed field of the resource to the fixed value. A scalar values key
+// rendered at the field is preferred, otherwise the field is added under the closest map values key rendered at a parent
+// of the field. The values key is verified by rendering the chart with the fix
+func (t *helmChartTracer) traceValue(templatePath string, resource *reporthandling.Resource, fixPath armotypes.FixPath) ([]string, bool) {
+	key := resourceKeyOf(templatePath, resource.GetKind(), resource.GetName())
+	field := splitFixPath(fixPath.Path)
+
+	var valuesKey []string
+	var closest *valuesWiring
+	for i, wiring := range t.wirings[key] {
+		if !wiring.isMap && slices.Equal(wiring.field, field) {
+			valuesKey = wiring.valuesKey
+			break
+		}
+		if wiring.isMap && len(wiring.field) < len(field) && slices.Equal(wiring.field, field[:len(wiring.field)]) &&
+			!slices.ContainsFunc(field[len(wiring.field):], isIndexToken) && (closest == nil || len(wiring.field) > len(closest.field)) {
+			closest = &t.wirings[key][i]
+		}
+	}
+	if valuesKey == nil && closest != nil {
+		// a field rendered by the template itself would be duplicated by the map values key
+		if _, exists := lookupField(t.resources[key].object, field); !exists {
+			valuesKey = append(slices.Clone(closest.valuesKey), field[len(closest.field):]...)
+		}
+	}
+	if valuesKey == nil {
+		return nil, false
+	}
+
+	fixedValues := deepCopyValue(t.values).(map[string]interface{})
+	setValue(fixedValues, valuesKey, parseFixValue(fixPath.Value))
+	rendered, err := t.chart.RenderTemplates(fixedValues)
+	if err != nil {
+		return nil, false
+	}
+	documents, _ := decodeRenderedDocuments(rendered[templatePath])
+	for _, document := range documents {
+		if resourceKey(templatePath, document) != key {
+			continue
+		}
+		if value, exists := lookupField(document, field); exists && fmt.Sprint(value) == fixPath.Value {
+			return valuesKey, true
+		}
+	}
+	return nil, false
+}
+
+// fixTemplate applies the yaml expression to the rendered template and maps the changed lines back to the template, using
+// the line comments of the rendered template. The patched template is verified by rendering it. The chart is loaded again
+// from the files since earlier fixes may have changed them
+func (t *helmChartTracer) fixTemplate(ctx context.Context, templatePath, templateContent, yamlExpression string) (string, error) {
+	chart, err := cautils.NewHelmChart(t.chartPath)
+	if err != nil {
+		return "", err
+	}
+	values, err := loadChartValues(chart, t.valuesFile)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := chart.RenderTemplates(values)
+	if err != nil {
+		return "", err
+	}
+	expected, err := ApplyFixToContent(ctx, rendered[templatePath], yamlExpression)
+	if err != nil {
+		return "", err
+	}
+
+	commentedChart, err := cautils.NewHelmChart(t.chartPath)
+	if err != nil {
+		return "", err
+	}
+	commentedChart.AddCommentToTemplate()
+	commentedRendered, err := commentedChart.RenderTemplates(values)
+	if err != nil {
+		return "", err
+	}
+	commented, ok := commentedRendered[templatePath]
+	if !ok {
+		return "", fmt.Errorf("template %s is not rendered by chart %s", templatePath, t.chartPath)
+	}
+	fixedCommented, err := ApplyFixToContent(ctx, commented, yamlExpression)
+	if err != nil {
+		return "", err
+	}
+
+	newline := determineNewlineSeparator(templateContent)
+	patched, err := patchTemplate(strings.Split(templateContent, newline), strings.Split(commented, "\n"), strings.Split(fixedCommented, "\n"))
+	if err != nil {
+		return "", fmt.Errorf("failed to patch template %s: %w", templatePath, err)
+	}
+	patchedContent := strings.Join(patched, newline)
+
+	// the patched template must render the fixed resources
+	chart.ReplaceTemplate(templatePath, []byte(patchedContent))
+	patchedRendered, err := chart.RenderTemplates(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to render patched template %s: %w", templatePath, err)
+	}
+	expectedDocuments, err := decodeRenderedDocuments(expected)
+	if err != nil {
+		return "", err
+	}
+	patchedDocuments, err := decodeRenderedDocuments(patchedRendered[templatePath])
+	if err != nil || !reflect.DeepEqual(expectedDocuments, patchedDocuments) {
+		return "", fmt.Errorf("failed to patch template %s: the fix cannot be mapped to the template lines", templatePath)
+	}
+	return patchedContent, nil
+}
+
+// loadChartValues returns the default values of the chart merged with the values file, when it exists and is not blank
+func loadChartValues(chart *cautils.HelmChart, valuesFile string) (map[string]interface{}, error) {
+	if content, err := os.ReadFile(valuesFile); err == nil && len(strings.TrimSpace(string(content))) > 0 {
+		return chart.GetValues(valuesFile)
+	}
+	return chart.GetValues()
+}
+
+// patchTemplate maps the changes between the rendered lines and the fixed lines to the template lines. Removed lines must
+// come from plain template lines, inserted lines are indented relative to the template line they follow
+func patchTemplate(templateLines, renderedLines, fixedLines []string) ([]string, error) {
+	removed := make(map[int]bool)
+	inserted := make(map[int][]string)
+
+	renderedIdx, fixedIdx := 0, 0
+	lastTemplateLine, lastIndentShift := 0, 0
+	for _, op := range diffLines(renderedLines, fixedLines) {
+		switch op {
+		case diffKeep:
+			// lines are inserted after the last non-blank line, the fix engine inserts the fields of a resource after its trailing blank lines
+			if line, ok := cautils.TemplateLineOfComment(renderedLines[renderedIdx]); ok && line <= len(templateLines) && strings.TrimSpace(templateLines[line-1]) != "" {
+				lastTemplateLine = line
+				lastIndentShift = indentation(templateLines[line-1]) - indentation(renderedLines[renderedIdx])
+			}
+			renderedIdx++
+			fixedIdx++
+		case diffRemove:
+			line, ok := cautils.TemplateLineOfComment(renderedLines[renderedIdx])
+			if !ok || line > len(templateLines) {
+				return nil, fmt.Errorf("line '%s' is not a template line", strings.TrimSpace(renderedLines[renderedIdx]))
+			}
+			if strings.Contains(templateLines[line-1], "{{") {
+				return nil, fmt.Errorf("template line %d renders values", line)
+			}
+			removed[line] = true
+			lastTemplateLine = line
+			lastIndentShift = indentation(templateLines[line-1]) - indentation(renderedLines[renderedIdx])
+			renderedIdx++
+		case diffInsert:
+			if lastTemplateLine == 0 {
+				return nil, errors.New("no template line to insert after")
+			}
+			line := strings.TrimRight(cautils.RemoveTemplateLineComments(fixedLines[fixedIdx]), " ")
+			if _, commented := cautils.TemplateLineOfComment(fixedLines[fixedIdx]); commented && line == "" {
+				// a comment of the rendered template moved by the fix, e.g. the foot comment of a replaced field
+				fixedIdx++
+				continue
+			}
+			if lastIndentShift > 0 {
+				line = strings.Repeat(" ", lastIndentShift) + line
+			} else if lastIndentShift < 0 {
+				if indentation(line) < -lastIndentShift {
+					return nil, errors.New("inserted line is less indented than the template")
+				}
+				line = line[-lastIndentShift:]
+			}
+			inserted[lastTemplateLine] = append(inserted[lastTemplateLine], line)
+			fixedIdx++
+		}
+	}
+
+	patched := make([]string, 0, len(templateLines))
+	for i, line := range templateLines {
+		if !removed[i+1] {
+			patched = append(patched, line)
+		}
+		patched = append(patched, inserted[i+1]...)
+	}
+	return patched, nil
+}
+
+type diffOp int
+
+const (
+	diffKeep diffOp = iota
+	diffRemove
+	diffInsert
+)
+
+// diffLines returns the operations turning a into b, based on their longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffKeep)
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffRemove)
+			i++
+		default:
+			ops = append(ops, diffInsert)
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffRemove)
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffInsert)
+	}
+	return ops
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// decodeRenderedDocuments decodes the YAML documents of a rendered template
+func decodeRenderedDocuments(content string) ([]interface{}, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	var documents []interface{}
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return documents, err
+		}
+		// empty documents are kept, the document indexes of the yaml expressions count them
+		documents = append(documents, document)
+	}
+}
+
+func resourceKey(templatePath string, document interface{}) string {
+	object, _ := document.(map[string]interface{})
+	kind, _ := object["kind"].(string)
+	metadata, _ := object["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return resourceKeyOf(templatePath, kind, name)
+}
+
+func resourceKeyOf(templatePath, kind, name string) string {
+	return fmt.Sprintf("%s/%s/%s", templatePath, kind, name)
+}
+
+// walkValues calls fn on every values key, parents first
+func walkValues(values map[string]interface{}, parent []string, fn func(valuesKey []string, value interface{})) {
+	for key, value := range values {
+		valuesKey := append(slices.Clone(parent), key)
+		fn(valuesKey, value)
+		if child, ok := value.(map[string]interface{}); ok {
+			walkValues(child, valuesKey, fn)
+		}
+	}
+}
+
+// findProbe returns the paths of the fields holding the probe value, or of the maps holding the probe key
+func findProbe(node interface{}, path []string, isMap bool) [][]string {
+	var found [][]string
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, value := range n {
+			if isMap && key == probeKey {
+				found = append(found, slices.Clone(path))
+				continue
+			}
+			found = append(found, findProbe(value, append(slices.Clone(path), key), isMap)...)
+		}
+	case []interface{}:
+		for i, value := range n {
+			found = append(found, findProbe(value, append(slices.Clone(path), fmt.Sprintf("[%d]", i)), isMap)...)
+		}
+	case string:
+		if !isMap && n == probeValue {
+			found = append(found, slices.Clone(path))
+		}
+	}
+	return found
+}
+
+// lookupField returns the value of the field of a rendered resource
+func lookupField(node interface{}, field []string) (interface{}, bool) {
+	for _, token := range field {
+		if isIndexToken(token) {
+			list, ok := node.([]interface{})
+			index, err := strconv.Atoi(strings.Trim(token, "[]"))
+			if !ok || err != nil || index >= len(list) {
+				return nil, false
+			}
+			node = list[index]
+			continue
+		}
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node, ok = object[token]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// splitFixPath splits a fix path such as spec.containers[0].securityContext into its keys and indexes
+func splitFixPath(fixPath string) []string {
+	var tokens []string
+	for _, part := range strings.Split(fixPath, ".") {
+		for {
+			bracket := strings.Index(part, "[")
+			if bracket == -1 {
+				break
+			}
+			if bracket > 0 {
+				tokens = append(tokens, part[:bracket])
+			}
+			end := strings.Index(part, "]")
+			if end < bracket {
+				break
+			}
+			tokens = append(tokens, part[bracket:end+1])
+			part = part[end+1:]
+		}
+		if part != "" {
+			tokens = append(tokens, part)
+		}
+	}
+	return tokens
+}
+
+func isIndexToken(token string) bool {
+	return strings.HasPrefix(token, "[")
+}
+
+// valuesKeyPath returns the yq path of a values key, quoting the keys which are not plain identifiers
+func valuesKeyPath(valuesKey []string) string {
+	keys := make([]string, len(valuesKey))
+	for i, key := range valuesKey {
+		if plainValuesKeyRegexp.MatchString(key) {
+			keys[i] = key
+		} else {
+			keys[i] = strconv.Quote(key)
+		}
+	}
+	return strings.Join(keys, ".")
+}
+
+// parseFixValue returns the typed value of a fix, the way FixPathToValidYamlExpression writes it
+func parseFixValue(value string) interface{} {
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+func setValue(values map[string]interface{}, valuesKey []string, value interface{}) {
+	for _, key := range valuesKey[:len(valuesKey)-1] {
+		child, ok := values[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			values[key] = child
+		}
+		values = child
+	}
+	values[valuesKey[len(valuesKey)-1]] = value
+}
+
+func deepCopyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = deepCopyValue(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = deepCopyValue(child)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// getHelmChartTracer returns the tracer of the chart, the charts are rendered and probed once per fix
+func (h *FixHandler) getHelmChartTracer(chartPath string) (*helmChartTracer, error) {
+	if tracer, ok := h.helmCharts[chartPath]; ok {
+		return tracer, nil
+	}
+	tracer, err := newHelmChartTracer(chartPath, h.fixInfo.HelmValuesFile)
+	if err != nil {
+		return nil, err
+	}
+	if h.helmCharts == nil {
+		h.helmCharts = make(map[string]*helmChartTracer)
+	}
+	h.helmCharts[chartPath] = tracer
+	return tracer, nil
+}
+
+// prepareHelmResourceToFix traces the fixes of a resource rendered from a Helm chart to the values file of the chart. The
+// fixes which are not wired to a values key are applied to the template
+func (h *FixHandler) prepareHelmResourceToFix(ctx context.Context, resourceObj *reporthandling.Resource, templatePath string, associatedControls []resourcesresults.ResourceAssociatedControl) []ResourceFixInfo {
+	chartPath := resourceObj.Source.HelmPath
+	if !filepath.IsAbs(chartPath) {
+		chartPath = filepath.Join(h.localBasePath, chartPath)
+	}
+	tracer, err := h.getHelmChartTracer(chartPath)
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("Skipping Helm chart", helpers.String("path", chartPath), helpers.Error(err))
+		return nil
+	}
+	documentIndex, ok := tracer.documentIndex(templatePath, resourceObj)
+	if !ok {
+		logger.L().Ctx(ctx).Warning("Skipping resource not rendered by the Helm chart", helpers.String("template", templatePath), helpers.String("resource", resourceObj.GetName()))
+		return nil
+	}
+
+	rendered := ResourceFixInfo{
+		YamlExpressions: make(map[string]armotypes.FixPath),
+		Controls:        make(map[string]FixControl),
+	}
+	for i := range associatedControls {
+		if associatedControls[i].GetStatus(nil).IsFailed() {
+			rendered.addYamlExpressionsFromResourceAssociatedControl(documentIndex, &associatedControls[i], h.fixInfo.SkipUserValues)
+		}
+	}
+
+	valuesFix := ResourceFixInfo{
+		YamlExpressions: make(map[string]armotypes.FixPath),
+		Resource:        resourceObj,
+		FilePath:        tracer.valuesFile,
+		Controls:        make(map[string]FixControl),
+		HelmChartPath:   chartPath,
+	}
+	templateFix := ResourceFixInfo{
+		YamlExpressions: make(map[string]armotypes.FixPath),
+		Resource:        resourceObj,
+		FilePath:        templatePath,
+		DocumentIndex:   documentIndex,
+		Controls:        make(map[string]FixControl),
+		HelmChartPath:   chartPath,
+		HelmTemplate:    true,
+	}
+
+	for expression, fixPath := range rendered.YamlExpressions {
+		if valuesKey, ok := tracer.traceValue(templatePath, resourceObj, fixPath); ok {
+			valuesPath := valuesKeyPath(valuesKey)
+			valuesExpression := FixPathToValidYamlExpression(valuesPath, fixPath.Value, 0)
+			valuesFix.YamlExpressions[valuesExpression] = armotypes.FixPath{Path: valuesPath, Value: fixPath.Value}
+			valuesFix.Controls[valuesExpression] = rendered.Controls[expression]
+			continue
+		}
+		templateFix.YamlExpressions[expression] = fixPath
+		templateFix.Controls[expression] = rendered.Controls[expression]
+	}
+
+	var resourcesToFix []ResourceFixInfo
+	for _, fix := range []ResourceFixInfo{valuesFix, templateFix} {
+		if len(fix.YamlExpressions) > 0 {
+			resourcesToFix = append(resourcesToFix, fix)
+		}
+	}
+	return resourcesToFix
+}
+
+// fixHelmTemplate applies the yaml expression to a template of a Helm chart
+func (h *FixHandler) fixHelmTemplate(ctx context.Context, chartPath, templatePath, templateContent, yamlExpression string) (string, error) {
+	tracer, err := h.getHelmChartTracer(chartPath)
+	if err != nil {
+		return "", err
+	}
+	return tracer.fixTemplate(ctx, templatePath, templateContent, yamlExpression)
+}
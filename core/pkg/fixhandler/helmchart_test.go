@@ -0,0 +1,223 @@
+package fixhandler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestHelmChart copies the test chart to a temporary directory and returns the chart path and the deployment template path
+func newTestHelmChart(t *testing.T) (string, string) {
+	t.Helper()
+	chartPath := filepath.Join(t.TempDir(), "nginx")
+	require.NoError(t, os.CopyFS(chartPath, os.DirFS(filepath.Join("testdata", "helm", "nginx"))))
+	return chartPath, filepath.Join(chartPath, "templates", "deployment.yaml")
+}
+
+func newTestHelmResource(chartPath string) *reporthandling.Resource {
+	resource := reporthandling.NewResourceIMetadata(workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx"},
+	}))
+	resource.Source = &reporthandling.Source{
+		FileType: reporthandling.SourceTypeHelmChart,
+		HelmPath: chartPath,
+	}
+	return resource
+}
+
+func newTestFailedControl(controlID string, fixPaths ...armotypes.FixPath) resourcesresults.ResourceAssociatedControl {
+	rule := resourcesresults.ResourceAssociatedRule{Name: "rule", Status: apis.StatusFailed}
+	for _, fixPath := range fixPaths {
+		rule.Paths = append(rule.Paths, armotypes.PosturePaths{FixPath: fixPath})
+	}
+	control := resourcesresults.ResourceAssociatedControl{ControlID: controlID, Name: controlID, ResourceAssociatedRules: []resourcesresults.ResourceAssociatedRule{rule}}
+	control.SetStatus(reporthandling.Control{})
+	return control
+}
+
+func TestHelmChartTracerTraceValue(t *testing.T) {
+	chartPath, templatePath := newTestHelmChart(t)
+	tracer, err := newHelmChartTracer(chartPath, "")
+	require.NoError(t, err)
+	resource := newTestHelmResource(chartPath)
+
+	index, ok := tracer.documentIndex(templatePath, resource)
+	assert.True(t, ok)
+	assert.Equal(t, 0, index)
+
+	tests := []struct {
+		name      string
+		fixPath   armotypes.FixPath
+		valuesKey []string
+		traced    bool
+	}{
+		{
+			name:      "scalar values key",
+			fixPath:   armotypes.FixPath{Path: "spec.template.spec.containers[0].securityContext.readOnlyRootFilesystem", Value: "true"},
+			valuesKey: []string{"securityContext", "readOnlyRootFilesystem"},
+			traced:    true,
+		},
+		{
+			name:      "field added to a map values key",
+			fixPath:   armotypes.FixPath{Path: "spec.template.spec.securityContext.runAsNonRoot", Value: "true"},
+			valuesKey: []string{"podSecurityContext", "runAsNonRoot"},
+			traced:    true,
+		},
+		{
+			name:    "hard-coded field",
+			fixPath: armotypes.FixPath{Path: "spec.template.spec.containers[0].securityContext.allowPrivilegeEscalation", Value: "false"},
+		},
+		{
+			name:    "field not rendered from the values",
+			fixPath: armotypes.FixPath{Path: "spec.template.spec.containers[0].securityContext.runAsUser", Value: "1000"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valuesKey, traced := tracer.traceValue(templatePath, resource, tt.fixPath)
+			assert.Equal(t, tt.traced, traced)
+			assert.Equal(t, tt.valuesKey, valuesKey)
+		})
+	}
+}
+
+func TestFixHelmChart(t *testing.T) {
+	fixPaths := []armotypes.FixPath{
+		{Path: "spec.template.spec.containers[0].securityContext.readOnlyRootFilesystem", Value: "true"},
+		{Path: "spec.template.spec.securityContext.runAsNonRoot", Value: "true"},
+		{Path: "spec.template.spec.containers[0].securityContext.allowPrivilegeEscalation", Value: "false"},
+		{Path: "spec.template.spec.containers[0].securityContext.runAsUser", Value: "1000"},
+	}
+
+	t.Run("fix values.yaml and the template", func(t *testing.T) {
+		handler, err := NewFixHandlerMock()
+		require.NoError(t, err)
+		chartPath, templatePath := newTestHelmChart(t)
+
+		resourcesToFix := handler.prepareHelmResourceToFix(context.TODO(), newTestHelmResource(chartPath), templatePath,
+			[]resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0017", fixPaths...)})
+		require.Len(t, resourcesToFix, 2)
+
+		valuesFix, templateFix := resourcesToFix[0], resourcesToFix[1]
+		assert.Equal(t, filepath.Join(chartPath, "values.yaml"), valuesFix.FilePath)
+		assert.False(t, valuesFix.HelmTemplate)
+		assert.Len(t, valuesFix.YamlExpressions, 2)
+		assert.Equal(t, templatePath, templateFix.FilePath)
+		assert.True(t, templateFix.HelmTemplate)
+		assert.Len(t, templateFix.YamlExpressions, 2)
+		for _, control := range valuesFix.Controls {
+			assert.Equal(t, "C-0017", control.ID)
+		}
+
+		updated, errs := handler.ApplyChanges(context.TODO(), resourcesToFix)
+		require.Empty(t, errs)
+		assert.Equal(t, 2, updated)
+
+		values, err := os.ReadFile(filepath.Join(chartPath, "values.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "image: nginx:1.27\n\npodSecurityContext:\n  runAsNonRoot: true\n\nsecurityContext:\n  readOnlyRootFilesystem: true\n", string(values))
+
+		template, err := os.ReadFile(templatePath)
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(string(template), `        securityContext:
+          readOnlyRootFilesystem: {{ .Values.securityContext.readOnlyRootFilesystem }}
+          allowPrivilegeEscalation: false
+          runAsUser: 1000
+`))
+	})
+
+	t.Run("fix a values file of the environment", func(t *testing.T) {
+		handler, err := NewFixHandlerMock()
+		require.NoError(t, err)
+		handler.fixInfo.HelmValuesFile = "values-production.yaml"
+		chartPath, templatePath := newTestHelmChart(t)
+
+		resourcesToFix := handler.prepareHelmResourceToFix(context.TODO(), newTestHelmResource(chartPath), templatePath,
+			[]resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0017", fixPaths[:2]...)})
+		require.Len(t, resourcesToFix, 1)
+		assert.Equal(t, filepath.Join(chartPath, "values-production.yaml"), resourcesToFix[0].FilePath)
+
+		_, errs := handler.ApplyChanges(context.TODO(), resourcesToFix)
+		require.Empty(t, errs)
+
+		values, err := os.ReadFile(filepath.Join(chartPath, "values-production.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(values), "securityContext:\n  readOnlyRootFilesystem: true\n")
+		assert.Contains(t, string(values), "podSecurityContext:\n  runAsNonRoot: true\n")
+
+		// the default values are left untouched
+		defaultValues, err := os.ReadFile(filepath.Join(chartPath, "values.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(defaultValues), "readOnlyRootFilesystem: false")
+	})
+
+	t.Run("resource not rendered by the chart", func(t *testing.T) {
+		handler, err := NewFixHandlerMock()
+		require.NoError(t, err)
+		chartPath, _ := newTestHelmChart(t)
+
+		resourcesToFix := handler.prepareHelmResourceToFix(context.TODO(), newTestHelmResource(chartPath), filepath.Join(chartPath, "templates", "service.yaml"),
+			[]resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0017", fixPaths...)})
+		assert.Empty(t, resourcesToFix)
+	})
+}
+
+func TestPatchTemplate(t *testing.T) {
+	templateLines := []string{
+		"spec:",
+		"  image: {{ .Values.image }}",
+		"  privileged: true",
+	}
+	renderedLines := []string{
+		"spec: #This is the 1 line",
+		"  image: nginx #This is the 2 line",
+		"  privileged: true #This is the 3 line",
+	}
+
+	t.Run("replace a plain line", func(t *testing.T) {
+		fixedLines := []string{renderedLines[0], renderedLines[1], "  privileged: false", "  runAsNonRoot: true"}
+		patched, err := patchTemplate(templateLines, renderedLines, fixedLines)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"spec:", "  image: {{ .Values.image }}", "  privileged: false", "  runAsNonRoot: true"}, patched)
+	})
+
+	t.Run("line rendering values", func(t *testing.T) {
+		fixedLines := []string{renderedLines[0], "  image: nginx:1.27", renderedLines[2]}
+		_, err := patchTemplate(templateLines, renderedLines, fixedLines)
+		assert.ErrorContains(t, err, "renders values")
+	})
+}
+
+func TestDiffLines(t *testing.T) {
+	ops := diffLines([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})
+	assert.Equal(t, []diffOp{diffKeep, diffRemove, diffInsert, diffKeep, diffInsert}, ops)
+}
+
+func TestSplitFixPath(t *testing.T) {
+	assert.Equal(t, []string{"spec", "containers", "[0]", "securityContext", "capabilities", "drop", "[1]"},
+		splitFixPath("spec.containers[0].securityContext.capabilities.drop[1]"))
+	assert.Equal(t, []string{"metadata", "name"}, splitFixPath("metadata.name"))
+}
+
+func TestValuesKeyPath(t *testing.T) {
+	assert.Equal(t, "securityContext.readOnlyRootFilesystem", valuesKeyPath([]string{"securityContext", "readOnlyRootFilesystem"}))
+	assert.Equal(t, `podAnnotations."app.kubernetes.io/name"`, valuesKeyPath([]string{"podAnnotations", "app.kubernetes.io/name"}))
+}
+
+func TestNewYamlDocument(t *testing.T) {
+	content, err := ApplyFixToContent(context.TODO(), "", FixPathToValidYamlExpression("securityContext.runAsNonRoot", "true", 0))
+	require.NoError(t, err)
+	assert.Equal(t, "securityContext:\n  runAsNonRoot: true\n", content)
+}
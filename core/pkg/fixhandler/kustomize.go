@@ -0,0 +1,441 @@
+package fixhandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/objectsenvelopes/localworkload"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	KustomizePatchStrategicMerge = "strategic-merge"
+	KustomizePatchJSON6902       = "json6902"
+
+	kustomizePatchFilePrefix = "kubescape-fix-"
+	setElementOrderPrefix    = "$setElementOrder/"
+)
+
+// jsonPatchOperation is an operation of a JSON6902 patch
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// kustomization holds the patches registered in a Kustomization file
+type kustomization struct {
+	Namespace string `yaml:"namespace"`
+	Patches   []struct {
+		Path string `yaml:"path"`
+	} `yaml:"patches"`
+	PatchesStrategicMerge []string `yaml:"patchesStrategicMerge"`
+	PatchesJson6902       []struct {
+		Path string `yaml:"path"`
+	} `yaml:"patchesJson6902"`
+}
+
+func (k *kustomization) hasPatch(path string) bool {
+	for _, patch := range k.Patches {
+		if filepath.Clean(patch.Path) == path {
+			return true
+		}
+	}
+	for _, patch := range k.PatchesStrategicMerge {
+		if filepath.Clean(patch) == path {
+			return true
+		}
+	}
+	for _, patch := range k.PatchesJson6902 {
+		if filepath.Clean(patch.Path) == path {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareKustomizeResourceToFix prepares the fixes of a resource rendered from a Kustomize overlay. The fixes are written
+// to a patch of the overlay, the bases shared with other overlays are not changed
+func (h *FixHandler) prepareKustomizeResourceToFix(ctx context.Context, resourceObj *reporthandling.Resource, overlayDir string, associatedControls []resourcesresults.ResourceAssociatedControl) []ResourceFixInfo {
+	if !filepath.IsAbs(overlayDir) {
+		overlayDir = filepath.Join(h.localBasePath, resourceObj.Source.RelativePath)
+	}
+	if cautils.FindKustomizationFile(overlayDir) == "" {
+		logger.L().Ctx(ctx).Warning("Skipping resource of a missing Kustomization", helpers.String("path", overlayDir), helpers.String("resource", resourceObj.GetName()))
+		return nil
+	}
+
+	rfi := ResourceFixInfo{
+		YamlExpressions: make(map[string]armotypes.FixPath),
+		Resource:        resourceObj,
+		FilePath:        filepath.Join(overlayDir, kustomizePatchFileName(resourceObj)),
+		Controls:        make(map[string]FixControl),
+		KustomizeDir:    overlayDir,
+	}
+	for i := range associatedControls {
+		if associatedControls[i].GetStatus(nil).IsFailed() {
+			rfi.addYamlExpressionsFromResourceAssociatedControl(0, &associatedControls[i], h.fixInfo.SkipUserValues)
+		}
+	}
+
+	if len(rfi.YamlExpressions) == 0 {
+		return nil
+	}
+	return []ResourceFixInfo{rfi}
+}
+
+// kustomizePatchFileName returns the patch file of a resource, e.g. kubescape-fix-deployment-prod-nginx.yaml, the
+// namespace keeps apart the patches of resources with the same name
+func kustomizePatchFileName(resource *reporthandling.Resource) string {
+	if resource.GetNamespace() == "" {
+		return fmt.Sprintf("%s%s-%s.yaml", kustomizePatchFilePrefix, strings.ToLower(resource.GetKind()), resource.GetName())
+	}
+	return fmt.Sprintf("%s%s-%s-%s.yaml", kustomizePatchFilePrefix, strings.ToLower(resource.GetKind()), resource.GetNamespace(), resource.GetName())
+}
+
+// writeKustomizePatch applies the yaml expression to the rendered resource, writes the changes to the patch file of the
+// overlay and registers the patch in the Kustomization. The patch is verified by building the overlay, the files are
+// restored when it does not fix the resource
+func (h *FixHandler) writeKustomizePatch(ctx context.Context, resourceToFix ResourceFixInfo, yamlExpression string) error {
+	kustomizationFile := cautils.FindKustomizationFile(resourceToFix.KustomizeDir)
+	if kustomizationFile == "" {
+		return fmt.Errorf("no Kustomization file in %s", resourceToFix.KustomizeDir)
+	}
+
+	original := renderedObject(resourceToFix.Resource)
+	originalYaml, err := sigsyaml.Marshal(original)
+	if err != nil {
+		return err
+	}
+	fixedYaml, err := ApplyFixToContent(ctx, string(originalYaml), yamlExpression)
+	if err != nil {
+		return err
+	}
+	var fixed map[string]interface{}
+	if err := sigsyaml.Unmarshal([]byte(fixedYaml), &fixed); err != nil {
+		return err
+	}
+
+	previousPatch, err := os.ReadFile(resourceToFix.FilePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	previousKustomization, err := GetFileString(kustomizationFile)
+	if err != nil {
+		return err
+	}
+
+	patchType := h.fixInfo.KustomizePatch
+	if patchType == "" {
+		patchType = KustomizePatchStrategicMerge
+	}
+	var dataStruct runtime.Object
+	if patchType == KustomizePatchStrategicMerge {
+		if dataStruct, err = scheme.Scheme.New(schema.FromAPIVersionAndKind(resourceToFix.Resource.GetApiVersion(), resourceToFix.Resource.GetKind())); err != nil {
+			// the merge keys of the lists of custom resources are unknown
+			logger.L().Ctx(ctx).Debug("writing a JSON6902 patch of a resource with an unknown schema", helpers.String("kind", resourceToFix.Resource.GetKind()))
+			patchType = KustomizePatchJSON6902
+		}
+	}
+
+	namespace, err := kustomizePatchNamespace(previousKustomization, resourceToFix.Resource)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", kustomizationFile, err)
+	}
+
+	var patch []byte
+	switch patchType {
+	case KustomizePatchStrategicMerge:
+		patch, err = newStrategicMergePatch(resourceToFix.Resource, namespace, original, fixed, previousPatch, dataStruct)
+	case KustomizePatchJSON6902:
+		patch, err = newJSON6902Patch(original, fixed, resourceToFix.YamlExpressions, previousPatch)
+	default:
+		err = fmt.Errorf("unknown Kustomize patch type %s", patchType)
+	}
+	if err != nil {
+		return err
+	}
+
+	fixedKustomization, err := registerKustomizePatch(ctx, previousKustomization, filepath.Base(resourceToFix.FilePath), patchType, resourceToFix.Resource, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to register the patch in %s: %w", kustomizationFile, err)
+	}
+
+	restore := func() {
+		if previousPatch == nil {
+			_ = os.Remove(resourceToFix.FilePath)
+		} else {
+			_ = writeFixesToFile(resourceToFix.FilePath, string(previousPatch))
+		}
+		_ = writeFixesToFile(kustomizationFile, previousKustomization)
+	}
+	if err := writeFixesToFile(resourceToFix.FilePath, string(patch)); err != nil {
+		return err
+	}
+	if err := writeFixesToFile(kustomizationFile, fixedKustomization); err != nil {
+		restore()
+		return err
+	}
+	if err := verifyKustomizePatch(resourceToFix); err != nil {
+		restore()
+		return err
+	}
+	return nil
+}
+
+// renderedObject returns the object of the resource rendered by Kustomize, without the fields added by the scan
+func renderedObject(resource *reporthandling.Resource) map[string]interface{} {
+	object := deepCopyValue(resource.GetObject()).(map[string]interface{})
+	delete(object, localworkload.PathKey)
+	return object
+}
+
+// kustomizePatchNamespace returns the namespace the patches of the resource target. Kustomize applies the patches before
+// setting the namespace of the Kustomization, the patches of an overlay with a namespace target the resources by name only
+// as all of them end up in that namespace
+func kustomizePatchNamespace(content string, resource *reporthandling.Resource) (string, error) {
+	var k kustomization
+	if err := yaml.Unmarshal([]byte(content), &k); err != nil {
+		return "", err
+	}
+	if k.Namespace != "" {
+		return "", nil
+	}
+	return resource.GetNamespace(), nil
+}
+
+// newStrategicMergePatch returns the strategic merge patch of the fixes, merged into the previous patch of the resource
+func newStrategicMergePatch(resource *reporthandling.Resource, namespace string, original, fixed map[string]interface{}, previousPatch []byte, dataStruct runtime.Object) ([]byte, error) {
+	patch, err := newTwoWayMergePatch(original, fixed, dataStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(previousPatch) > 0 {
+		previousJSON, err := sigsyaml.YAMLToJSON(previousPatch)
+		if err != nil {
+			return nil, err
+		}
+		patchJSON, err := json.Marshal(patch)
+		if err != nil {
+			return nil, err
+		}
+		if patchJSON, err = strategicpatch.StrategicMergePatch(previousJSON, patchJSON, dataStruct); err != nil {
+			return nil, err
+		}
+		patch = nil
+		if err := json.Unmarshal(patchJSON, &patch); err != nil {
+			return nil, err
+		}
+	}
+
+	// Kustomize finds the patched resource by its identity
+	patch["apiVersion"] = resource.GetApiVersion()
+	patch["kind"] = resource.GetKind()
+	metadata, _ := patch["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["name"] = resource.GetName()
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	patch["metadata"] = metadata
+
+	return sigsyaml.Marshal(patch)
+}
+
+// newTwoWayMergePatch returns the strategic merge patch changing the original object to the fixed one, without the
+// directives keeping the order of the list elements
+func newTwoWayMergePatch(original, fixed map[string]interface{}, dataStruct runtime.Object) (map[string]interface{}, error) {
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+	fixedJSON, err := json.Marshal(fixed)
+	if err != nil {
+		return nil, err
+	}
+	patchJSON, err := strategicpatch.CreateTwoWayMergePatch(originalJSON, fixedJSON, dataStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch map[string]interface{}
+	if err := json.Unmarshal(patchJSON, &patch); err != nil {
+		return nil, err
+	}
+	// the order of the list elements is kept by Kustomize and by server-side apply, the directive is not needed
+	removeSetElementOrder(patch)
+	return patch, nil
+}
+
+func removeSetElementOrder(node interface{}) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, value := range n {
+			if strings.HasPrefix(key, setElementOrderPrefix) {
+				delete(n, key)
+				continue
+			}
+			removeSetElementOrder(value)
+		}
+	case []interface{}:
+		for _, value := range n {
+			removeSetElementOrder(value)
+		}
+	}
+}
+
+// newJSON6902Patch returns the JSON6902 patch of the fixes, appended to the operations of the previous patch. The missing
+// parents of a fixed field are added empty first, so the fixes of different controls can share them
+func newJSON6902Patch(original, fixed map[string]interface{}, fixPaths map[string]armotypes.FixPath, previousPatch []byte) ([]byte, error) {
+	var operations []jsonPatchOperation
+	if len(previousPatch) > 0 {
+		if err := sigsyaml.Unmarshal(previousPatch, &operations); err != nil {
+			return nil, err
+		}
+	}
+	indexOf := make(map[string]int, len(operations))
+	for i, operation := range operations {
+		indexOf[operation.Path] = i
+	}
+
+	fields := make([][]string, 0, len(fixPaths))
+	for _, fixPath := range fixPaths {
+		fields = append(fields, splitFixPath(fixPath.Path))
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return compareFields(fields[i], fields[j]) < 0
+	})
+
+	for _, field := range fields {
+		for i := range field {
+			path := jsonPointer(field[:i+1])
+			_, exists := lookupField(original, field[:i+1])
+
+			var operation jsonPatchOperation
+			if i < len(field)-1 {
+				if _, added := indexOf[path]; exists || added {
+					continue
+				}
+				// a missing parent of the field
+				operation = jsonPatchOperation{Op: "add", Path: path, Value: map[string]interface{}{}}
+				if isIndexToken(field[i+1]) {
+					operation.Value = []interface{}{}
+				}
+			} else {
+				value, _ := lookupField(fixed, field)
+				operation = jsonPatchOperation{Op: "add", Path: path, Value: value}
+				if exists {
+					operation.Op = "replace"
+				}
+			}
+
+			if index, ok := indexOf[path]; ok {
+				operations[index] = operation
+				continue
+			}
+			indexOf[path] = len(operations)
+			operations = append(operations, operation)
+		}
+	}
+
+	return sigsyaml.Marshal(operations)
+}
+
+// compareFields orders the fields by their keys, the indexes of lists in numerical order
+func compareFields(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		if isIndexToken(a[i]) && isIndexToken(b[i]) {
+			indexA, _ := strconv.Atoi(strings.Trim(a[i], "[]"))
+			indexB, _ := strconv.Atoi(strings.Trim(b[i], "[]"))
+			return indexA - indexB
+		}
+		return strings.Compare(a[i], b[i])
+	}
+	return len(a) - len(b)
+}
+
+// jsonPointer returns the JSON pointer of a field, as used by the paths of JSON6902 patches
+func jsonPointer(field []string) string {
+	var sb strings.Builder
+	for _, token := range field {
+		sb.WriteString("/")
+		if isIndexToken(token) {
+			sb.WriteString(strings.Trim(token, "[]"))
+			continue
+		}
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+// registerKustomizePatch adds the patch file to the patches of the Kustomization, unless it is already registered
+func registerKustomizePatch(ctx context.Context, content, patchFile, patchType string, resource *reporthandling.Resource, namespace string) (string, error) {
+	var k kustomization
+	if err := yaml.Unmarshal([]byte(content), &k); err != nil {
+		return "", err
+	}
+	if k.hasPatch(patchFile) {
+		return content, nil
+	}
+
+	entry := fmt.Sprintf(`{"path": %q}`, patchFile)
+	if patchType == KustomizePatchJSON6902 {
+		gvk := schema.FromAPIVersionAndKind(resource.GetApiVersion(), resource.GetKind())
+		target := fmt.Sprintf(`"version": %q, "kind": %q, "name": %q`, gvk.Version, gvk.Kind, resource.GetName())
+		if namespace != "" {
+			target = fmt.Sprintf(`%s, "namespace": %q`, target, namespace)
+		}
+		if gvk.Group != "" {
+			target = fmt.Sprintf(`"group": %q, %s`, gvk.Group, target)
+		}
+		entry = fmt.Sprintf(`{"path": %q, "target": {%s}}`, patchFile, target)
+	}
+	return ApplyFixToContent(ctx, content, fmt.Sprintf(".patches += [%s]", entry))
+}
+
+// verifyKustomizePatch builds the overlay and checks the fixed fields of the resource
+func verifyKustomizePatch(resourceToFix ResourceFixInfo) error {
+	workloads, errs := cautils.NewKustomizeDirectory(resourceToFix.KustomizeDir).GetWorkloads(resourceToFix.KustomizeDir)
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to build the Kustomize overlay %s with the patch: %w", resourceToFix.KustomizeDir, errors.Join(errs...))
+	}
+
+	for _, workload := range workloads[resourceToFix.KustomizeDir] {
+		if workload.GetKind() != resourceToFix.Resource.GetKind() || workload.GetName() != resourceToFix.Resource.GetName() ||
+			workload.GetNamespace() != resourceToFix.Resource.GetNamespace() {
+			continue
+		}
+		for _, fixPath := range resourceToFix.YamlExpressions {
+			if value, exists := lookupField(workload.GetObject(), splitFixPath(fixPath.Path)); !exists || fmt.Sprint(value) != fixPath.Value {
+				return fmt.Errorf("the patch of the Kustomize overlay %s does not set %s", resourceToFix.KustomizeDir, fixPath.Path)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("resource %s/%s is not rendered by the Kustomize overlay %s", resourceToFix.Resource.GetKind(), resourceToFix.Resource.GetName(), resourceToFix.KustomizeDir)
+}
@@ -0,0 +1,306 @@
+package fixhandler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestKustomization copies the test base and overlay to a temporary directory and returns the directory and the
+// deployment rendered by the overlay, as found by a scan
+func newTestKustomization(t *testing.T) (string, *reporthandling.Resource) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS(filepath.Join("testdata", "kustomize"))))
+
+	overlayDir := filepath.Join(dir, "overlays", "prod")
+	workloads, errs := cautils.NewKustomizeDirectory(overlayDir).GetWorkloads(overlayDir)
+	require.Empty(t, errs)
+	require.Len(t, workloads[overlayDir], 1)
+
+	resource := reporthandling.NewResourceIMetadata(workloads[overlayDir][0])
+	resource.Source = &reporthandling.Source{
+		RelativePath:           filepath.Join("overlays", "prod"),
+		FileType:               reporthandling.SourceTypeKustomizeDirectory,
+		KustomizeDirectoryName: overlayDir,
+	}
+	return dir, resource
+}
+
+var testKustomizeFixPaths = []armotypes.FixPath{
+	{Path: "spec.template.spec.containers[0].securityContext.allowPrivilegeEscalation", Value: "false"},
+	{Path: "spec.template.spec.containers[0].securityContext.capabilities.drop[0]", Value: "ALL"},
+	{Path: "spec.template.spec.securityContext.runAsNonRoot", Value: "true"},
+}
+
+func TestFixKustomizeOverlay(t *testing.T) {
+	tests := []struct {
+		name          string
+		patchType     string
+		expectedPatch string
+		expectedEntry string
+	}{
+		{
+			name:      "strategic merge patch",
+			patchType: KustomizePatchStrategicMerge,
+			expectedPatch: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+      securityContext:
+        runAsNonRoot: true
+`,
+			expectedEntry: "patches:\n  - path: kubescape-fix-deployment-prod-nginx.yaml\n",
+		},
+		{
+			name:      "JSON6902 patch",
+			patchType: KustomizePatchJSON6902,
+			expectedPatch: `- op: replace
+  path: /spec/template/spec/containers/0/securityContext/allowPrivilegeEscalation
+  value: false
+- op: add
+  path: /spec/template/spec/containers/0/securityContext/capabilities
+  value: {}
+- op: add
+  path: /spec/template/spec/containers/0/securityContext/capabilities/drop
+  value: []
+- op: add
+  path: /spec/template/spec/containers/0/securityContext/capabilities/drop/0
+  value: ALL
+- op: add
+  path: /spec/template/spec/securityContext
+  value: {}
+- op: add
+  path: /spec/template/spec/securityContext/runAsNonRoot
+  value: true
+`,
+			expectedEntry: "patches:\n  - path: kubescape-fix-deployment-prod-nginx.yaml\n    target:\n      group: apps\n      version: v1\n      kind: Deployment\n      name: nginx\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := NewFixHandlerMock()
+			require.NoError(t, err)
+			handler.fixInfo.KustomizePatch = tt.patchType
+			dir, resource := newTestKustomization(t)
+			overlayDir := filepath.Join(dir, "overlays", "prod")
+
+			resourcesToFix := handler.prepareKustomizeResourceToFix(context.TODO(), resource, overlayDir,
+				[]resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0016", testKustomizeFixPaths...)})
+			require.Len(t, resourcesToFix, 1)
+			assert.Equal(t, filepath.Join(overlayDir, "kubescape-fix-deployment-prod-nginx.yaml"), resourcesToFix[0].FilePath)
+
+			updated, errs := handler.ApplyChanges(context.TODO(), resourcesToFix)
+			require.Empty(t, errs)
+			assert.Equal(t, 1, updated)
+
+			patch, err := os.ReadFile(resourcesToFix[0].FilePath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPatch, string(patch))
+
+			kustomization, err := os.ReadFile(filepath.Join(overlayDir, "kustomization.yaml"))
+			require.NoError(t, err)
+			assert.Contains(t, string(kustomization), tt.expectedEntry)
+
+			// the base shared by the overlays is not changed
+			base, err := os.ReadFile(filepath.Join(dir, "base", "deployment.yaml"))
+			require.NoError(t, err)
+			expectedBase, err := os.ReadFile(filepath.Join("testdata", "kustomize", "base", "deployment.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, string(expectedBase), string(base))
+		})
+	}
+}
+
+func TestFixKustomizeOverlayTwice(t *testing.T) {
+	for _, patchType := range []string{KustomizePatchStrategicMerge, KustomizePatchJSON6902} {
+		t.Run(patchType, func(t *testing.T) {
+			handler, err := NewFixHandlerMock()
+			require.NoError(t, err)
+			handler.fixInfo.KustomizePatch = patchType
+			dir, resource := newTestKustomization(t)
+			overlayDir := filepath.Join(dir, "overlays", "prod")
+
+			// the fixes of each control are applied separately, as when committing them to a git branch
+			for _, fixPath := range testKustomizeFixPaths {
+				resourcesToFix := handler.prepareKustomizeResourceToFix(context.TODO(), resource, overlayDir,
+					[]resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0016", fixPath)})
+				_, errs := handler.ApplyChanges(context.TODO(), resourcesToFix)
+				require.Empty(t, errs)
+			}
+
+			workloads, errs := cautils.NewKustomizeDirectory(overlayDir).GetWorkloads(overlayDir)
+			require.Empty(t, errs)
+			for _, fixPath := range testKustomizeFixPaths {
+				value, exists := lookupField(workloads[overlayDir][0].GetObject(), splitFixPath(fixPath.Path))
+				assert.True(t, exists)
+				assert.Equal(t, fixPath.Value, fmt.Sprint(value))
+			}
+
+			kustomization, err := os.ReadFile(filepath.Join(overlayDir, "kustomization.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, 1, strings.Count(string(kustomization), "kubescape-fix-deployment-prod-nginx.yaml"))
+		})
+	}
+}
+
+func TestFixKustomizeOverlaySameNameInNamespaces(t *testing.T) {
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: %s
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.27
+        securityContext:
+          allowPrivilegeEscalation: true
+`
+	for _, patchType := range []string{KustomizePatchStrategicMerge, KustomizePatchJSON6902} {
+		t.Run(patchType, func(t *testing.T) {
+			handler, err := NewFixHandlerMock()
+			require.NoError(t, err)
+			handler.fixInfo.KustomizePatch = patchType
+
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte("resources:\n- prod.yaml\n- staging.yaml\n"), 0600))
+			for _, namespace := range []string{"prod", "staging"} {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, namespace+".yaml"), []byte(fmt.Sprintf(deployment, namespace)), 0600))
+			}
+			workloads, errs := cautils.NewKustomizeDirectory(dir).GetWorkloads(dir)
+			require.Empty(t, errs)
+			var resource *reporthandling.Resource
+			for _, workload := range workloads[dir] {
+				if workload.GetNamespace() == "staging" {
+					resource = reporthandling.NewResourceIMetadata(workload)
+				}
+			}
+			require.NotNil(t, resource)
+
+			resourcesToFix := handler.prepareKustomizeResourceToFix(context.TODO(), resource, dir,
+				[]resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0016", testKustomizeFixPaths[0])})
+			_, errs = handler.ApplyChanges(context.TODO(), resourcesToFix)
+			require.Empty(t, errs)
+
+			// only the deployment of the fixed namespace is patched
+			workloads, errs = cautils.NewKustomizeDirectory(dir).GetWorkloads(dir)
+			require.Empty(t, errs)
+			require.Len(t, workloads[dir], 2)
+			for _, workload := range workloads[dir] {
+				value, _ := lookupField(workload.GetObject(), splitFixPath(testKustomizeFixPaths[0].Path))
+				assert.Equal(t, workload.GetNamespace() == "staging", value == false, workload.GetNamespace())
+			}
+		})
+	}
+}
+
+func TestFixKustomizeOverlayRestoresFiles(t *testing.T) {
+	handler, err := NewFixHandlerMock()
+	require.NoError(t, err)
+	dir, resource := newTestKustomization(t)
+	overlayDir := filepath.Join(dir, "overlays", "prod")
+
+	// the patch cannot be applied to a resource missing from the overlay
+	resource.SetObject(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "missing", "namespace": "prod"},
+	})
+	resourcesToFix := handler.prepareKustomizeResourceToFix(context.TODO(), resource, overlayDir,
+		[]resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0016", testKustomizeFixPaths[0])})
+	_, errs := handler.ApplyChanges(context.TODO(), resourcesToFix)
+	require.Len(t, errs, 1)
+
+	assert.NoFileExists(t, resourcesToFix[0].FilePath)
+	kustomization, err := os.ReadFile(filepath.Join(overlayDir, "kustomization.yaml"))
+	require.NoError(t, err)
+	expectedKustomization, err := os.ReadFile(filepath.Join("testdata", "kustomize", "overlays", "prod", "kustomization.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(expectedKustomization), string(kustomization))
+}
+
+func TestCommitKustomizePatches(t *testing.T) {
+	handler, err := NewFixHandlerMock()
+	require.NoError(t, err)
+	dir, resource := newTestKustomization(t)
+	handler.localBasePath = dir
+	overlayDir := filepath.Join(dir, "overlays", "prod")
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, worktree.AddGlob("."))
+	_, err = worktree.Commit("Add overlay", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com"}})
+	require.NoError(t, err)
+
+	resourcesToFix := handler.prepareKustomizeResourceToFix(context.TODO(), resource, overlayDir, []resourcesresults.ResourceAssociatedControl{
+		newTestFailedControl("C-0016", testKustomizeFixPaths[0]),
+		newTestFailedControl("C-0046", testKustomizeFixPaths[1]),
+	})
+	change, fixErrors, err := handler.CommitChanges(context.TODO(), resourcesToFix, "kubescape-fixes")
+	require.NoError(t, err)
+	assert.Empty(t, fixErrors)
+	require.Len(t, change.Commits, 2)
+
+	// the patch and its registration are committed together
+	status, err := worktree.Status()
+	require.NoError(t, err)
+	assert.True(t, status.IsClean())
+
+	first, err := repo.CommitObject(change.Commits[0])
+	require.NoError(t, err)
+	stats, err := first.Stats()
+	require.NoError(t, err)
+	var files []string
+	for _, stat := range stats {
+		files = append(files, stat.Name)
+	}
+	assert.ElementsMatch(t, []string{"overlays/prod/kubescape-fix-deployment-prod-nginx.yaml", "overlays/prod/kustomization.yaml"}, files)
+}
+
+func TestKustomizePatchFileName(t *testing.T) {
+	newResource := func(kind, namespace, name string) *reporthandling.Resource {
+		metadata := map[string]interface{}{"name": name}
+		if namespace != "" {
+			metadata["namespace"] = namespace
+		}
+		return reporthandling.NewResourceIMetadata(workloadinterface.NewWorkloadObj(map[string]interface{}{"apiVersion": "v1", "kind": kind, "metadata": metadata}))
+	}
+
+	assert.Equal(t, "kubescape-fix-deployment-prod-nginx.yaml", kustomizePatchFileName(newResource("Deployment", "prod", "nginx")))
+	assert.Equal(t, "kubescape-fix-deployment-staging-nginx.yaml", kustomizePatchFileName(newResource("Deployment", "staging", "nginx")))
+	assert.Equal(t, "kubescape-fix-namespace-prod.yaml", kustomizePatchFileName(newResource("Namespace", "", "prod")))
+}
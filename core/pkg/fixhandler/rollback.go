@@ -0,0 +1,202 @@
+package fixhandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	rollbackDirName  = "fix-rollbacks"
+	rollbackIDLayout = "20060102-150405"
+)
+
+// RollbackRecord holds the prior versions of the resources changed by a fix of a cluster
+type RollbackRecord struct {
+	ID        string             `json:"id"`
+	Context   string             `json:"context"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Resources []RollbackResource `json:"resources"`
+}
+
+// RollbackResource holds the prior version of a resource and the patch restoring the fields changed by the fix
+type RollbackResource struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Resource   string                 `json:"resource"`
+	Namespace  string                 `json:"namespace,omitempty"`
+	Name       string                 `json:"name"`
+	Prior      map[string]interface{} `json:"prior"`  // resource before the fix
+	Revert     json.RawMessage        `json:"revert"` // strategic merge patch restoring the fixed fields
+}
+
+func (r *RollbackResource) groupVersionResource() schema.GroupVersionResource {
+	return schema.FromAPIVersionAndKind(r.APIVersion, r.Kind).GroupVersion().WithResource(r.Resource)
+}
+
+// changedSinceFix returns true if fields other than the fixed ones changed since the fix, i.e. the resource with the
+// fixed fields restored differs from its prior version. The metadata and status of the resources are not compared
+func (r *RollbackResource) changedSinceFix(current *unstructured.Unstructured) (bool, error) {
+	dataStruct, err := scheme.Scheme.New(schema.FromAPIVersionAndKind(r.APIVersion, r.Kind))
+	if err != nil {
+		return false, err
+	}
+	currentJSON, err := json.Marshal(current.Object)
+	if err != nil {
+		return false, err
+	}
+	restoredJSON, err := strategicpatch.StrategicMergePatch(currentJSON, r.Revert, dataStruct)
+	if err != nil {
+		return false, err
+	}
+	var restored map[string]interface{}
+	if err := json.Unmarshal(restoredJSON, &restored); err != nil {
+		return false, err
+	}
+
+	restoredSpec, err := json.Marshal(withoutMetadataAndStatus(restored))
+	if err != nil {
+		return false, err
+	}
+	priorSpec, err := json.Marshal(withoutMetadataAndStatus(r.Prior))
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(restoredSpec, priorSpec), nil
+}
+
+func withoutMetadataAndStatus(obj map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		if key != "metadata" && key != "status" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// RollbackStore saves the rollback records of the cluster fixes as JSON files of a directory
+type RollbackStore struct {
+	dir string
+}
+
+func NewRollbackStore(dir string) *RollbackStore {
+	return &RollbackStore{dir: dir}
+}
+
+// DefaultRollbackStore returns the store under the local dot files for kubescape
+func DefaultRollbackStore() *RollbackStore {
+	return NewRollbackStore(getter.GetDefaultPath(rollbackDirName))
+}
+
+// Save sets the ID of the record, from the time it was created, and writes it to the store
+func (s *RollbackStore) Save(record *RollbackRecord) error {
+	id := record.CreatedAt.UTC().Format(rollbackIDLayout)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(s.path(id)); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", record.CreatedAt.UTC().Format(rollbackIDLayout), i)
+	}
+	record.ID = id
+	return getter.SaveInFile(record, s.path(id))
+}
+
+func (s *RollbackStore) Load(id string) (*RollbackRecord, error) {
+	content, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no cluster fix with ID %s", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record RollbackRecord
+	if err := json.Unmarshal(content, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// List returns the records of the store, from the oldest
+func (s *RollbackStore) List() ([]RollbackRecord, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]RollbackRecord, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		record, err := s.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+func (s *RollbackStore) Delete(id string) error {
+	return os.Remove(s.path(id))
+}
+
+func (s *RollbackStore) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+// Rollback restores the fields changed by the fix of the record. The changes made to those fields since the fix are
+// reverted as well
+func Rollback(ctx context.Context, client dynamic.Interface, record *RollbackRecord) []error {
+	errs := make([]error, 0)
+	for _, resource := range record.Resources {
+		resourceClient := client.Resource(resource.groupVersionResource()).Namespace(resource.Namespace)
+
+		current, err := resourceClient.Get(ctx, resource.Name, k8smetav1.GetOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Failed to roll back %s %s: %w", resource.Kind, resource.Name, err))
+			continue
+		}
+		if changed, err := resource.changedSinceFix(current); err != nil {
+			logger.L().Ctx(ctx).Debug("failed to compare the resource with its prior version", helpers.String("kind", resource.Kind),
+				helpers.String("namespace", resource.Namespace), helpers.String("name", resource.Name), helpers.Error(err))
+		} else if changed {
+			logger.L().Ctx(ctx).Warning("The resource changed since it was fixed, only the fixed fields are rolled back", helpers.String("kind", resource.Kind),
+				helpers.String("namespace", resource.Namespace), helpers.String("name", resource.Name))
+		}
+
+		if _, err := resourceClient.Patch(ctx, resource.Name, types.StrategicMergePatchType, resource.Revert,
+			k8smetav1.PatchOptions{FieldManager: ClusterFixFieldManager}); err != nil {
+			errs = append(errs, fmt.Errorf("Failed to roll back %s %s: %w", resource.Kind, resource.Name, err))
+		}
+	}
+	return errs
+}
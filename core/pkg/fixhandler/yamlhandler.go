@@ -284,3 +284,74 @@ func getFixedYamlLines(yamlLines []string, fileFixInfo fileFixInfo, newline stri
 
 	return fixedYamlLines
 }
+
+// expandFilledFlowMappings removes the empty flow mappings of the original YAML the fix adds fields to, e.g. "key: {}"
+// becomes "key:", so the fields can be inserted as lines under the key
+func expandFilledFlowMappings(yamlLines []string, originalRootNodes, fixedRootNodes []yaml.Node) ([]string, bool) {
+	var expanded bool
+	var expandNode func(original, fixed *yaml.Node)
+	expandNode = func(original, fixed *yaml.Node) {
+		if original.Kind != fixed.Kind {
+			return
+		}
+		switch original.Kind {
+		case yaml.DocumentNode:
+			if len(original.Content) > 0 && len(fixed.Content) > 0 {
+				expandNode(original.Content[0], fixed.Content[0])
+			}
+		case yaml.SequenceNode:
+			for i := 0; i < len(original.Content) && i < len(fixed.Content); i++ {
+				expandNode(original.Content[i], fixed.Content[i])
+			}
+		case yaml.MappingNode:
+			if original.Style&yaml.FlowStyle != 0 && len(original.Content) == 0 && len(fixed.Content) > 0 {
+				line, column := original.Line-1, original.Column-1
+				if line < len(yamlLines) && strings.HasPrefix(yamlLines[line][column:], "{}") {
+					yamlLines[line] = strings.TrimRight(yamlLines[line][:column]+yamlLines[line][column+2:], " ")
+					expanded = true
+				}
+				return
+			}
+			for i := 0; i+1 < len(original.Content); i += 2 {
+				for j := 0; j+1 < len(fixed.Content); j += 2 {
+					if original.Content[i].Value == fixed.Content[j].Value {
+						expandNode(original.Content[i+1], fixed.Content[j+1])
+						break
+					}
+				}
+			}
+		}
+	}
+
+	for i := 0; i < len(originalRootNodes) && i < len(fixedRootNodes); i++ {
+		expandNode(&originalRootNodes[i], &fixedRootNodes[i])
+	}
+	return yamlLines, expanded
+}
+
+// newYamlDocument builds the YAML document of a blank file by applying the yaml expression to an empty mapping
+func newYamlDocument(ctx context.Context, yamlExpression string) (string, error) {
+	fixedRootNodes, err := getFixedNodes(ctx, "{}", yamlExpression)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	encoder := yaml.NewEncoder(&sb)
+	encoder.SetIndent(2)
+	for i := range fixedRootNodes {
+		root := &fixedRootNodes[i]
+		if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+			root = root.Content[0]
+		}
+		// the empty mapping is written in block style
+		root.Style = 0
+		if err := encoder.Encode(root); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
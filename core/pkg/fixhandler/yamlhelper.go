@@ -49,6 +49,10 @@ func matchNodes(nodeOne, nodeTwo *yaml.Node) NodeRelation {
 func adjustContentLines(contentToAdd *[]contentToAdd, linesSlice *[]string) {
 	for contentIdx, content := range *contentToAdd {
 		line := content.line
+		// Content replacing removed lines stays in their place
+		if line >= 0 && line < len(*linesSlice) && (*linesSlice)[line] == "*" {
+			continue
+		}
 		// Adjust line numbers such that there are no "empty lines or comment lines of next nodes" before them
 		for idx := line - 1; idx >= 0; idx-- {
 			// If idx is exceeding the length of linesSlice, skip it
@@ -0,0 +1,211 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+)
+
+// DefaultFileName is the name of the history store in the local cache directory
+const DefaultFileName = "history.db"
+
+// Target types of the scans
+const (
+	TargetCluster    = "cluster"
+	TargetRepository = "repository"
+	TargetDirectory  = "directory"
+	TargetFile       = "file"
+)
+
+// DefaultFile returns the path of the history store in the local cache directory, typically $HOME/.kubescape/history.db
+func DefaultFile() string {
+	return getter.GetDefaultPath(DefaultFileName)
+}
+
+// GetFile returns the path of the history store of a scan, empty when the scan is not kept in the history
+func GetFile(scanInfo *cautils.ScanInfo) string {
+	if scanInfo.HistoryFile != "" {
+		return scanInfo.HistoryFile
+	}
+	if scanInfo.KeepHistory {
+		return DefaultFile()
+	}
+	return ""
+}
+
+// Record adds the scan of a session to the history store in the file. Scans of an unknown target are not recorded
+func Record(ctx context.Context, file string, sessionObj *cautils.OPASessionObj) (*Scan, error) {
+	scan := NewScan(sessionObj)
+	if scan == nil {
+		return nil, nil
+	}
+	store, err := Open(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	if err := store.Add(ctx, scan); err != nil {
+		return nil, err
+	}
+	return scan, nil
+}
+
+// Target is what a scan scanned: a cluster, a git repository, a directory or a file
+type Target struct {
+	Type string `json:"type"`
+	Name string `json:"name"` // cluster name, repository URL or path
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s %s", t.Type, t.Name)
+}
+
+// Scan is the summary of a scan kept in the history
+type Scan struct {
+	ID               int64            `json:"id"`
+	ReportID         string           `json:"reportID"`
+	Target           Target           `json:"target"`
+	ScannedAt        time.Time        `json:"scannedAt"`
+	KubescapeVersion string           `json:"kubescapeVersion,omitempty"`
+	ComplianceScore  float32          `json:"complianceScore"`
+	FailedResources  int              `json:"failedResources"`
+	PassedResources  int              `json:"passedResources"`
+	SkippedResources int              `json:"skippedResources"`
+	Frameworks       []FrameworkScore `json:"frameworks,omitempty"`
+	Controls         []ControlResult  `json:"controls,omitempty"`
+}
+
+// FrameworkScore is the compliance score of a framework in a scan
+type FrameworkScore struct {
+	Name            string  `json:"name"`
+	ComplianceScore float32 `json:"complianceScore"`
+}
+
+// ControlResult is the result of a control in a scan
+type ControlResult struct {
+	ControlID       string              `json:"controlID"`
+	Name            string              `json:"name"`
+	Severity        string              `json:"severity"`
+	Status          apis.ScanningStatus `json:"status"`
+	ComplianceScore *float32            `json:"complianceScore,omitempty"` // nil when the control has no compliance score
+	FailedResources int                 `json:"failedResources"`
+	PassedResources int                 `json:"passedResources"`
+}
+
+// NewScan returns the summary of the scan of a session, nil if the target of the scan is unknown
+func NewScan(sessionObj *cautils.OPASessionObj) *Scan {
+	if sessionObj == nil || sessionObj.Report == nil || sessionObj.Metadata == nil {
+		return nil
+	}
+	target, ok := GetTarget(sessionObj.Metadata, sessionObj.Report.ClusterName)
+	if !ok {
+		return nil
+	}
+
+	summaryDetails := &sessionObj.Report.SummaryDetails
+	scan := &Scan{
+		ReportID:         sessionObj.Report.ReportID,
+		Target:           target,
+		ScannedAt:        sessionObj.Report.ReportGenerationTime,
+		KubescapeVersion: sessionObj.Metadata.ScanMetadata.KubescapeVersion,
+		ComplianceScore:  summaryDetails.ComplianceScore,
+		FailedResources:  summaryDetails.NumberOfResources().Failed(),
+		PassedResources:  summaryDetails.NumberOfResources().Passed(),
+		SkippedResources: summaryDetails.NumberOfResources().Skipped(),
+	}
+	if scan.ScannedAt.IsZero() {
+		scan.ScannedAt = time.Now()
+	}
+	if scan.ReportID == "" {
+		scan.ReportID = sessionObj.SessionID
+	}
+
+	for _, framework := range summaryDetails.ListFrameworks() {
+		scan.Frameworks = append(scan.Frameworks, FrameworkScore{Name: framework.GetName(), ComplianceScore: framework.GetComplianceScore()})
+	}
+	for controlID, control := range summaryDetails.Controls {
+		result := ControlResult{
+			ControlID:       controlID,
+			Name:            control.GetName(),
+			Severity:        apis.ControlSeverityToString(control.GetScoreFactor()),
+			Status:          control.GetStatus().Status(),
+			FailedResources: control.NumberOfResources().Failed(),
+			PassedResources: control.NumberOfResources().Passed(),
+		}
+		if complianceScore := control.GetComplianceScore(); complianceScore >= 0 {
+			result.ComplianceScore = &complianceScore
+		}
+		scan.Controls = append(scan.Controls, result)
+	}
+	sort.Slice(scan.Controls, func(i, j int) bool { return scan.Controls[i].ControlID < scan.Controls[j].ControlID })
+	return scan
+}
+
+// GetTarget returns the target of a scan from its metadata. The cluster name is used when the cluster context is unknown
+func GetTarget(metadata *reporthandlingv2.Metadata, clusterName string) (Target, bool) {
+	contextMetadata := &metadata.ContextMetadata
+	switch metadata.ScanMetadata.ScanningTarget {
+	case reporthandlingv2.Cluster:
+		if contextMetadata.ClusterContextMetadata != nil && contextMetadata.ClusterContextMetadata.ContextName != "" {
+			clusterName = contextMetadata.ClusterContextMetadata.ContextName
+		}
+		if clusterName == "" {
+			return Target{}, false
+		}
+		return Target{Type: TargetCluster, Name: clusterName}, true
+	case reporthandlingv2.Repo, reporthandlingv2.GitLocal:
+		if repo := contextMetadata.RepoContextMetadata; repo != nil {
+			if repo.RemoteURL != "" {
+				return Target{Type: TargetRepository, Name: strings.TrimSuffix(repo.RemoteURL, ".git")}, true
+			}
+			if repo.LocalRootPath != "" {
+				return Target{Type: TargetRepository, Name: repo.LocalRootPath}, true
+			}
+		}
+	case reporthandlingv2.Directory:
+		if contextMetadata.DirectoryContextMetadata != nil && contextMetadata.DirectoryContextMetadata.BasePath != "" {
+			return Target{Type: TargetDirectory, Name: contextMetadata.DirectoryContextMetadata.BasePath}, true
+		}
+	case reporthandlingv2.File:
+		if contextMetadata.FileContextMetadata != nil && contextMetadata.FileContextMetadata.FilePath != "" {
+			return Target{Type: TargetFile, Name: contextMetadata.FileContextMetadata.FilePath}, true
+		}
+	}
+	return Target{}, false
+}
+
+// GetFramework returns the compliance score of a framework in the scan
+func (s *Scan) GetFramework(name string) (FrameworkScore, bool) {
+	for _, framework := range s.Frameworks {
+		if strings.EqualFold(framework.Name, name) {
+			return framework, true
+		}
+	}
+	return FrameworkScore{}, false
+}
+
+// GetControl returns the result of a control in the scan
+func (s *Scan) GetControl(controlID string) (ControlResult, bool) {
+	i := sort.Search(len(s.Controls), func(i int) bool { return s.Controls[i].ControlID >= controlID })
+	if i < len(s.Controls) && s.Controls[i].ControlID == controlID {
+		return s.Controls[i], true
+	}
+	return ControlResult{}, false
+}
+
+// Score returns the compliance score of a framework in the scan, or the compliance score of the scan when the
+// framework is empty
+func (s *Scan) Score(framework string) (float32, bool) {
+	if framework == "" {
+		return s.ComplianceScore, true
+	}
+	frameworkScore, ok := s.GetFramework(framework)
+	return frameworkScore.ComplianceScore, ok
+}
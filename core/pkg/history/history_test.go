@@ -0,0 +1,156 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScan(t *testing.T) {
+	scannedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	complianceScore := float32(50)
+	sessionObj := &cautils.OPASessionObj{
+		SessionID: "session",
+		Report: &reporthandlingv2.PostureReport{
+			ReportID:             "report",
+			ReportGenerationTime: scannedAt,
+			SummaryDetails: reportsummary.SummaryDetails{
+				ComplianceScore: 75,
+				StatusCounters:  reportsummary.StatusCounters{FailedResources: 2, PassedResources: 5, SkippedResources: 1},
+				Frameworks:      []reportsummary.FrameworkSummary{{Name: "NSA", ComplianceScore: 80}},
+				Controls: reportsummary.ControlSummaries{
+					"C-0017": {ControlID: "C-0017", Name: "Immutable container filesystem", Status: apis.StatusPassed, ScoreFactor: 3,
+						StatusCounters: reportsummary.StatusCounters{PassedResources: 4}},
+					"C-0002": {ControlID: "C-0002", Name: "Exec into container", Status: apis.StatusFailed, ScoreFactor: 9,
+						ComplianceScore: &complianceScore, StatusCounters: reportsummary.StatusCounters{FailedResources: 2, PassedResources: 2}},
+				},
+			},
+		},
+		Metadata: &reporthandlingv2.Metadata{
+			ScanMetadata: reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.Cluster, KubescapeVersion: "v3.0.0"},
+			ContextMetadata: reporthandlingv2.ContextMetadata{
+				ClusterContextMetadata: &reporthandlingv2.ClusterMetadata{ContextName: "prod"},
+			},
+		},
+	}
+
+	scan := NewScan(sessionObj)
+	require.NotNil(t, scan)
+	assert.Equal(t, "report", scan.ReportID)
+	assert.Equal(t, Target{Type: TargetCluster, Name: "prod"}, scan.Target)
+	assert.Equal(t, scannedAt, scan.ScannedAt)
+	assert.Equal(t, "v3.0.0", scan.KubescapeVersion)
+	assert.Equal(t, float32(75), scan.ComplianceScore)
+	assert.Equal(t, 2, scan.FailedResources)
+	assert.Equal(t, 5, scan.PassedResources)
+	assert.Equal(t, 1, scan.SkippedResources)
+	assert.Equal(t, []FrameworkScore{{Name: "NSA", ComplianceScore: 80}}, scan.Frameworks)
+	assert.Equal(t, []ControlResult{
+		{ControlID: "C-0002", Name: "Exec into container", Severity: apis.SeverityCriticalString, Status: apis.StatusFailed,
+			ComplianceScore: &complianceScore, FailedResources: 2, PassedResources: 2},
+		{ControlID: "C-0017", Name: "Immutable container filesystem", Severity: apis.SeverityLowString, Status: apis.StatusPassed,
+			PassedResources: 4},
+	}, scan.Controls)
+
+	t.Run("report ID defaults to session ID", func(t *testing.T) {
+		sessionObj.Report.ReportID = ""
+		assert.Equal(t, "session", NewScan(sessionObj).ReportID)
+	})
+
+	t.Run("unknown target", func(t *testing.T) {
+		sessionObj.Metadata.ScanMetadata.ScanningTarget = reporthandlingv2.File
+		assert.Nil(t, NewScan(sessionObj))
+	})
+
+	t.Run("no report", func(t *testing.T) {
+		assert.Nil(t, NewScan(&cautils.OPASessionObj{}))
+	})
+}
+
+func TestGetTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		metadata    reporthandlingv2.Metadata
+		clusterName string
+		want        Target
+		wantOK      bool
+	}{
+		{
+			name:        "cluster name when no context",
+			metadata:    reporthandlingv2.Metadata{ScanMetadata: reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.Cluster}},
+			clusterName: "minikube",
+			want:        Target{Type: TargetCluster, Name: "minikube"},
+			wantOK:      true,
+		},
+		{
+			name:     "unknown cluster",
+			metadata: reporthandlingv2.Metadata{ScanMetadata: reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.Cluster}},
+		},
+		{
+			name: "repository URL",
+			metadata: reporthandlingv2.Metadata{
+				ScanMetadata: reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.Repo},
+				ContextMetadata: reporthandlingv2.ContextMetadata{RepoContextMetadata: &reporthandlingv2.RepoContextMetadata{
+					RemoteURL: "https://github.com/kubescape/kubescape.git", LocalRootPath: "/src/kubescape",
+				}},
+			},
+			want:   Target{Type: TargetRepository, Name: "https://github.com/kubescape/kubescape"},
+			wantOK: true,
+		},
+		{
+			name: "local repository without remote",
+			metadata: reporthandlingv2.Metadata{
+				ScanMetadata:    reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.GitLocal},
+				ContextMetadata: reporthandlingv2.ContextMetadata{RepoContextMetadata: &reporthandlingv2.RepoContextMetadata{LocalRootPath: "/src/kubescape"}},
+			},
+			want:   Target{Type: TargetRepository, Name: "/src/kubescape"},
+			wantOK: true,
+		},
+		{
+			name: "directory",
+			metadata: reporthandlingv2.Metadata{
+				ScanMetadata:    reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.Directory},
+				ContextMetadata: reporthandlingv2.ContextMetadata{DirectoryContextMetadata: &reporthandlingv2.DirectoryContextMetadata{BasePath: "/manifests"}},
+			},
+			want:   Target{Type: TargetDirectory, Name: "/manifests"},
+			wantOK: true,
+		},
+		{
+			name: "file",
+			metadata: reporthandlingv2.Metadata{
+				ScanMetadata:    reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.File},
+				ContextMetadata: reporthandlingv2.ContextMetadata{FileContextMetadata: &reporthandlingv2.FileContextMetadata{FilePath: "/manifests/pod.yaml"}},
+			},
+			want:   Target{Type: TargetFile, Name: "/manifests/pod.yaml"},
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GetTarget(&tt.metadata, tt.clusterName)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScan_Score(t *testing.T) {
+	scan := Scan{ComplianceScore: 60, Frameworks: []FrameworkScore{{Name: "NSA", ComplianceScore: 70}}}
+
+	score, ok := scan.Score("")
+	assert.True(t, ok)
+	assert.Equal(t, float32(60), score)
+
+	score, ok = scan.Score("nsa")
+	assert.True(t, ok)
+	assert.Equal(t, float32(70), score)
+
+	_, ok = scan.Score("MITRE")
+	assert.False(t, ok)
+}
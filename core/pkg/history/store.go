@@ -0,0 +1,287 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/glebarez/go-sqlite" // registers the "sqlite" driver
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+// schemaVersion is the version of the schema of the store, kept in the user_version of the database
+const schemaVersion = 1
+
+const schema = `
+CREATE TABLE IF NOT EXISTS scans (
+	id                INTEGER PRIMARY KEY AUTOINCREMENT,
+	report_id         TEXT    NOT NULL,
+	target_type       TEXT    NOT NULL,
+	target            TEXT    NOT NULL,
+	scanned_at        INTEGER NOT NULL,
+	kubescape_version TEXT    NOT NULL,
+	compliance_score  REAL    NOT NULL,
+	failed_resources  INTEGER NOT NULL,
+	passed_resources  INTEGER NOT NULL,
+	skipped_resources INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS scans_target ON scans (target_type, target, scanned_at);
+CREATE INDEX IF NOT EXISTS scans_scanned_at ON scans (scanned_at);
+
+CREATE TABLE IF NOT EXISTS framework_scores (
+	scan_id          INTEGER NOT NULL REFERENCES scans (id),
+	framework        TEXT    NOT NULL COLLATE NOCASE,
+	compliance_score REAL    NOT NULL,
+	PRIMARY KEY (scan_id, framework)
+);
+CREATE INDEX IF NOT EXISTS framework_scores_framework ON framework_scores (framework, scan_id);
+
+CREATE TABLE IF NOT EXISTS control_results (
+	scan_id          INTEGER NOT NULL REFERENCES scans (id),
+	control_id       TEXT    NOT NULL,
+	name             TEXT    NOT NULL,
+	severity         TEXT    NOT NULL,
+	status           TEXT    NOT NULL,
+	compliance_score REAL,
+	failed_resources INTEGER NOT NULL,
+	passed_resources INTEGER NOT NULL,
+	PRIMARY KEY (scan_id, control_id)
+);
+`
+
+// Filter selects the scans of the history
+type Filter struct {
+	Target    Target    // scans of a target, of all the targets when empty. The name alone matches the targets of any type
+	Framework string    // scans of a framework, of all the frameworks when empty
+	Since     time.Time // scans since then, all the scans when zero
+	Limit     int       // the latest scans of each target, all of them when 0
+}
+
+// Store keeps the history of the scans in a SQLite database
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the store in the file, which is created if missing
+func Open(ctx context.Context, file string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory of history store: %w", err)
+	}
+	db, err := sql.Open("sqlite", file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history store %s: %w", file, err)
+	}
+	// a single connection, so that the scans of several processes wait for each other instead of failing
+	db.SetMaxOpenConns(1)
+
+	store := &Store{db: db}
+	if err := store.migrate(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open history store %s: %w", file, err)
+	}
+	return store, nil
+}
+
+func (s *Store) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, "PRAGMA busy_timeout = 10000"); err != nil {
+		return err
+	}
+	var version int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("history store version %d is newer than the supported version %d, upgrade kubescape", version, schemaVersion)
+	}
+	if version == schemaVersion {
+		return nil
+	}
+	if _, err := s.db.ExecContext(ctx, schema); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", schemaVersion))
+	return err
+}
+
+// Close closes the store
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add adds a scan to the history and sets its ID
+func (s *Store) Add(ctx context.Context, scan *Scan) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO scans (report_id, target_type, target, scanned_at, kubescape_version, compliance_score, failed_resources, passed_resources, skipped_resources)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		scan.ReportID, scan.Target.Type, scan.Target.Name, scan.ScannedAt.UnixMilli(), scan.KubescapeVersion, scan.ComplianceScore,
+		scan.FailedResources, scan.PassedResources, scan.SkippedResources)
+	if err != nil {
+		return fmt.Errorf("failed to add scan to history: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, framework := range scan.Frameworks {
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO framework_scores (scan_id, framework, compliance_score) VALUES (?, ?, ?)`,
+			id, framework.Name, framework.ComplianceScore); err != nil {
+			return fmt.Errorf("failed to add framework score to history: %w", err)
+		}
+	}
+	for _, control := range scan.Controls {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO control_results (scan_id, control_id, name, severity, status, compliance_score, failed_resources, passed_resources)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, control.ControlID, control.Name, control.Severity, string(control.Status), control.ComplianceScore,
+			control.FailedResources, control.PassedResources); err != nil {
+			return fmt.Errorf("failed to add control result to history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	scan.ID = id
+	return nil
+}
+
+// List returns the scans matching the filter, with their framework scores and control results, sorted by target and
+// then by time
+func (s *Store) List(ctx context.Context, filter Filter) ([]Scan, error) {
+	// the scans, their framework scores and their control results are read from the same snapshot of the store
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck // the transaction only reads
+
+	scansQuery, args := selectScans(filter)
+	rows, err := tx.QueryContext(ctx, `SELECT id, report_id, target_type, target, scanned_at, kubescape_version, compliance_score, failed_resources, passed_resources, skipped_resources
+		FROM (`+scansQuery+`) ORDER BY target_type, target, scanned_at, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history: %w", err)
+	}
+	defer rows.Close()
+
+	var scans []Scan
+	index := map[int64]int{}
+	for rows.Next() {
+		var scan Scan
+		var scannedAt int64
+		if err := rows.Scan(&scan.ID, &scan.ReportID, &scan.Target.Type, &scan.Target.Name, &scannedAt, &scan.KubescapeVersion,
+			&scan.ComplianceScore, &scan.FailedResources, &scan.PassedResources, &scan.SkippedResources); err != nil {
+			return nil, err
+		}
+		scan.ScannedAt = time.UnixMilli(scannedAt)
+		index[scan.ID] = len(scans)
+		scans = append(scans, scan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(scans) == 0 {
+		return scans, nil
+	}
+
+	if err := listFrameworks(ctx, tx, filter, scans, index); err != nil {
+		return nil, err
+	}
+	if err := listControls(ctx, tx, filter, scans, index); err != nil {
+		return nil, err
+	}
+	return scans, nil
+}
+
+// selectScans returns the query of the scans matching the filter, and its arguments. The rows of the scans are selected
+// with it as a subquery rather than by the IDs of the scans, which would exceed the number of arguments of SQLite for a
+// long history
+func selectScans(filter Filter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if filter.Target.Type != "" {
+		conditions = append(conditions, "s.target_type = ?")
+		args = append(args, filter.Target.Type)
+	}
+	if filter.Target.Name != "" {
+		conditions = append(conditions, "s.target = ?")
+		args = append(args, filter.Target.Name)
+	}
+	if filter.Framework != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM framework_scores f WHERE f.scan_id = s.id AND f.framework = ?)")
+		args = append(args, filter.Framework)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "s.scanned_at >= ?")
+		args = append(args, filter.Since.UnixMilli())
+	}
+
+	query := "SELECT * FROM (SELECT s.*, ROW_NUMBER() OVER (PARTITION BY s.target_type, s.target ORDER BY s.scanned_at DESC, s.id DESC) AS recency FROM scans s"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += ")"
+	if filter.Limit > 0 {
+		query += " WHERE recency <= ?"
+		args = append(args, filter.Limit)
+	}
+	return query, args
+}
+
+func listFrameworks(ctx context.Context, tx *sql.Tx, filter Filter, scans []Scan, index map[int64]int) error {
+	scansQuery, args := selectScans(filter)
+	rows, err := tx.QueryContext(ctx, "SELECT scan_id, framework, compliance_score FROM framework_scores WHERE scan_id IN (SELECT id FROM ("+scansQuery+")) ORDER BY scan_id, framework", args...)
+	if err != nil {
+		return fmt.Errorf("failed to list framework scores: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var scanID int64
+		var framework FrameworkScore
+		if err := rows.Scan(&scanID, &framework.Name, &framework.ComplianceScore); err != nil {
+			return err
+		}
+		scan := &scans[index[scanID]]
+		scan.Frameworks = append(scan.Frameworks, framework)
+	}
+	return rows.Err()
+}
+
+func listControls(ctx context.Context, tx *sql.Tx, filter Filter, scans []Scan, index map[int64]int) error {
+	scansQuery, args := selectScans(filter)
+	rows, err := tx.QueryContext(ctx,
+		"SELECT scan_id, control_id, name, severity, status, compliance_score, failed_resources, passed_resources FROM control_results WHERE scan_id IN (SELECT id FROM ("+scansQuery+")) ORDER BY scan_id, control_id", args...)
+	if err != nil {
+		return fmt.Errorf("failed to list control results: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var scanID int64
+		var control ControlResult
+		var status string
+		var complianceScore sql.NullFloat64
+		if err := rows.Scan(&scanID, &control.ControlID, &control.Name, &control.Severity, &status, &complianceScore,
+			&control.FailedResources, &control.PassedResources); err != nil {
+			return err
+		}
+		control.Status = apis.ScanningStatus(status)
+		if complianceScore.Valid {
+			score := float32(complianceScore.Float64)
+			control.ComplianceScore = &score
+		}
+		scan := &scans[index[scanID]]
+		scan.Controls = append(scan.Controls, control)
+	}
+	// sorted by ID, which GetControl relies on
+	return rows.Err()
+}
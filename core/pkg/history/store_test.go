@@ -0,0 +1,119 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScan(target Target, scannedAt time.Time, score float32, controls ...ControlResult) *Scan {
+	return &Scan{
+		ReportID:        scannedAt.Format(time.RFC3339),
+		Target:          target,
+		ScannedAt:       scannedAt,
+		ComplianceScore: score,
+		Frameworks:      []FrameworkScore{{Name: "NSA", ComplianceScore: score + 1}},
+		Controls:        controls,
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "sub", DefaultFileName)
+
+	store, err := Open(ctx, file)
+	require.NoError(t, err)
+
+	cluster := Target{Type: TargetCluster, Name: "prod"}
+	repo := Target{Type: TargetRepository, Name: "https://github.com/kubescape/kubescape"}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	complianceScore := float32(50)
+
+	first := newTestScan(cluster, start, 60,
+		ControlResult{ControlID: "C-0002", Name: "Exec into container", Severity: "High", Status: apis.StatusFailed, ComplianceScore: &complianceScore, FailedResources: 1, PassedResources: 1},
+		ControlResult{ControlID: "C-0017", Name: "Immutable container filesystem", Severity: "Low", Status: apis.StatusPassed, PassedResources: 2},
+	)
+	require.NoError(t, store.Add(ctx, first))
+	assert.NotZero(t, first.ID)
+	require.NoError(t, store.Add(ctx, newTestScan(cluster, start.Add(time.Hour), 70)))
+	require.NoError(t, store.Add(ctx, newTestScan(cluster, start.Add(2*time.Hour), 80)))
+	require.NoError(t, store.Add(ctx, newTestScan(repo, start.Add(time.Hour), 90)))
+	require.NoError(t, store.Close())
+
+	// reopening keeps the history
+	store, err = Open(ctx, file)
+	require.NoError(t, err)
+	defer store.Close()
+
+	t.Run("all", func(t *testing.T) {
+		scans, err := store.List(ctx, Filter{})
+		require.NoError(t, err)
+		require.Len(t, scans, 4)
+		assert.Equal(t, cluster, scans[0].Target)
+		assert.Equal(t, float32(60), scans[0].ComplianceScore)
+		assert.Equal(t, float32(80), scans[2].ComplianceScore)
+		assert.Equal(t, repo, scans[3].Target)
+
+		assert.True(t, start.Equal(scans[0].ScannedAt))
+		assert.Equal(t, first.Frameworks, scans[0].Frameworks)
+		assert.Equal(t, first.Controls, scans[0].Controls)
+	})
+
+	t.Run("target", func(t *testing.T) {
+		scans, err := store.List(ctx, Filter{Target: Target{Name: repo.Name}})
+		require.NoError(t, err)
+		require.Len(t, scans, 1)
+		assert.Equal(t, repo, scans[0].Target)
+	})
+
+	t.Run("since", func(t *testing.T) {
+		scans, err := store.List(ctx, Filter{Target: cluster, Since: start.Add(30 * time.Minute)})
+		require.NoError(t, err)
+		assert.Len(t, scans, 2)
+	})
+
+	t.Run("latest of each target", func(t *testing.T) {
+		scans, err := store.List(ctx, Filter{Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, scans, 3)
+		assert.Equal(t, float32(70), scans[0].ComplianceScore)
+		assert.Equal(t, float32(80), scans[1].ComplianceScore)
+		assert.Equal(t, repo, scans[2].Target)
+	})
+
+	t.Run("framework", func(t *testing.T) {
+		scans, err := store.List(ctx, Filter{Framework: "nsa"})
+		require.NoError(t, err)
+		assert.Len(t, scans, 4)
+
+		scans, err = store.List(ctx, Filter{Framework: "MITRE"})
+		require.NoError(t, err)
+		assert.Empty(t, scans)
+	})
+}
+
+func TestStoreListLongHistory(t *testing.T) {
+	ctx := context.Background()
+	store, err := Open(ctx, filepath.Join(t.TempDir(), DefaultFileName))
+	require.NoError(t, err)
+	defer store.Close()
+
+	// more scans than the number of arguments of a SQLite query
+	const count = 33000
+	_, err = store.db.ExecContext(ctx, `WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?)
+		INSERT INTO scans (report_id, target_type, target, scanned_at, kubescape_version, compliance_score, failed_resources, passed_resources, skipped_resources)
+		SELECT i, 'cluster', 'prod', i, 'v3', 50, 0, 0, 0 FROM n`, count)
+	require.NoError(t, err)
+	_, err = store.db.ExecContext(ctx, "INSERT INTO framework_scores (scan_id, framework, compliance_score) SELECT id, 'NSA', 50 FROM scans")
+	require.NoError(t, err)
+
+	scans, err := store.List(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, scans, count)
+	assert.Equal(t, []FrameworkScore{{Name: "NSA", ComplianceScore: 50}}, scans[count-1].Frameworks)
+}
@@ -0,0 +1,171 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+// Report is the trend of the scores of the targets in the history, and the controls that regressed
+type Report struct {
+	Framework   string       `json:"framework,omitempty"` // framework of the scores, the compliance scores of the scans when empty
+	Trends      []Trend      `json:"trends"`
+	Regressions []Regression `json:"regressions"`
+}
+
+// Trend is the score of a target over time
+type Trend struct {
+	Target Target  `json:"target"`
+	Points []Point `json:"points"`
+}
+
+// Point is the score of a scan in a trend
+type Point struct {
+	ReportID        string    `json:"reportID"`
+	ScannedAt       time.Time `json:"scannedAt"`
+	Score           float32   `json:"score"`
+	FailedResources int       `json:"failedResources"`
+}
+
+// Regression is a control that got worse on a target between two consecutive scans: it failed after passing, or it
+// failed on more resources. The controls that were not tested in the previous scan are not regressions
+type Regression struct {
+	Target                  Target              `json:"target"`
+	ReportID                string              `json:"reportID"`
+	ScannedAt               time.Time           `json:"scannedAt"`
+	ControlID               string              `json:"controlID"`
+	Name                    string              `json:"name"`
+	Severity                string              `json:"severity"`
+	PreviousStatus          apis.ScanningStatus `json:"previousStatus"`
+	Status                  apis.ScanningStatus `json:"status"`
+	PreviousFailedResources int                 `json:"previousFailedResources"`
+	FailedResources         int                 `json:"failedResources"`
+}
+
+// ErrNoHistory is returned when the history store does not exist, as no scan was recorded yet
+var ErrNoHistory = errors.New("no scan recorded in history")
+
+// LoadReport returns the report of the scans of the history store in the file matching the filter. The scores are the
+// scores of the framework of the filter, if any
+func LoadReport(ctx context.Context, file string, filter Filter) (*Report, error) {
+	if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNoHistory, file)
+	}
+	store, err := Open(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	scans, err := store.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return NewReport(scans, filter.Framework), nil
+}
+
+// NewReport returns the trends and the regressions of the scans, which are sorted by target and then by time as
+// listed by the store. The scores are the scores of the framework, if any
+func NewReport(scans []Scan, framework string) *Report {
+	report := &Report{
+		Framework:   framework,
+		Trends:      []Trend{},
+		Regressions: []Regression{},
+	}
+	for _, targetScans := range groupByTarget(scans) {
+		trend := Trend{Target: targetScans[0].Target}
+		for i := range targetScans {
+			score, ok := targetScans[i].Score(framework)
+			if !ok {
+				continue
+			}
+			trend.Points = append(trend.Points, Point{
+				ReportID:        targetScans[i].ReportID,
+				ScannedAt:       targetScans[i].ScannedAt,
+				Score:           score,
+				FailedResources: targetScans[i].FailedResources,
+			})
+			if i > 0 {
+				report.Regressions = append(report.Regressions, regressions(&targetScans[i-1], &targetScans[i])...)
+			}
+		}
+		report.Trends = append(report.Trends, trend)
+	}
+
+	// latest first, the most severe first
+	sort.SliceStable(report.Regressions, func(i, j int) bool {
+		if !report.Regressions[i].ScannedAt.Equal(report.Regressions[j].ScannedAt) {
+			return report.Regressions[i].ScannedAt.After(report.Regressions[j].ScannedAt)
+		}
+		return severityToInt(report.Regressions[i].Severity) > severityToInt(report.Regressions[j].Severity)
+	})
+	return report
+}
+
+// groupByTarget splits the scans sorted by target into the scans of each target
+func groupByTarget(scans []Scan) [][]Scan {
+	var groups [][]Scan
+	for start := 0; start < len(scans); {
+		end := start + 1
+		for end < len(scans) && scans[end].Target == scans[start].Target {
+			end++
+		}
+		groups = append(groups, scans[start:end])
+		start = end
+	}
+	return groups
+}
+
+// regressions returns the controls that got worse from the previous scan to the current one
+func regressions(previous, current *Scan) []Regression {
+	var list []Regression
+	for _, control := range current.Controls {
+		if control.Status != apis.StatusFailed {
+			continue
+		}
+		previousControl, ok := previous.GetControl(control.ControlID)
+		if !ok {
+			continue
+		}
+		if previousControl.Status == apis.StatusFailed && control.FailedResources <= previousControl.FailedResources {
+			continue
+		}
+		if previousControl.Status != apis.StatusFailed && previousControl.Status != apis.StatusPassed {
+			// skipped or irrelevant before, the control was not tested
+			continue
+		}
+		list = append(list, Regression{
+			Target:                  current.Target,
+			ReportID:                current.ReportID,
+			ScannedAt:               current.ScannedAt,
+			ControlID:               control.ControlID,
+			Name:                    control.Name,
+			Severity:                control.Severity,
+			PreviousStatus:          previousControl.Status,
+			Status:                  control.Status,
+			PreviousFailedResources: previousControl.FailedResources,
+			FailedResources:         control.FailedResources,
+		})
+	}
+	return list
+}
+
+// severityToInt returns the number of a severity, the reverse of apis.SeverityNumberToString
+func severityToInt(severity string) int {
+	switch severity {
+	case apis.SeverityCriticalString:
+		return apis.SeverityCritical
+	case apis.SeverityHighString:
+		return apis.SeverityHigh
+	case apis.SeverityMediumString:
+		return apis.SeverityMedium
+	case apis.SeverityLowString:
+		return apis.SeverityLow
+	}
+	return apis.SeverityUnknown
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReport(t *testing.T) {
+	cluster := Target{Type: TargetCluster, Name: "prod"}
+	repo := Target{Type: TargetRepository, Name: "https://github.com/kubescape/kubescape"}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	scans := []Scan{
+		*newTestScan(cluster, start, 80,
+			ControlResult{ControlID: "C-0001", Severity: "Low", Status: apis.StatusFailed, FailedResources: 2},
+			ControlResult{ControlID: "C-0002", Severity: "High", Status: apis.StatusPassed},
+			ControlResult{ControlID: "C-0003", Severity: "Medium", Status: apis.StatusSkipped},
+			ControlResult{ControlID: "C-0004", Severity: "Critical", Status: apis.StatusFailed, FailedResources: 3},
+		),
+		*newTestScan(cluster, start.Add(time.Hour), 70,
+			ControlResult{ControlID: "C-0001", Severity: "Low", Status: apis.StatusFailed, FailedResources: 3},  // more failures
+			ControlResult{ControlID: "C-0002", Severity: "High", Status: apis.StatusFailed, FailedResources: 1}, // failed after passing
+			ControlResult{ControlID: "C-0003", Severity: "Medium", Status: apis.StatusFailed, FailedResources: 1},
+			ControlResult{ControlID: "C-0004", Severity: "Critical", Status: apis.StatusFailed, FailedResources: 1},
+			ControlResult{ControlID: "C-0005", Severity: "High", Status: apis.StatusFailed, FailedResources: 1},
+		),
+		*newTestScan(repo, start, 90),
+	}
+
+	t.Run("compliance score", func(t *testing.T) {
+		report := NewReport(scans, "")
+		require.Len(t, report.Trends, 2)
+		assert.Equal(t, cluster, report.Trends[0].Target)
+		require.Len(t, report.Trends[0].Points, 2)
+		assert.Equal(t, float32(80), report.Trends[0].Points[0].Score)
+		assert.Equal(t, float32(70), report.Trends[0].Points[1].Score)
+		assert.Equal(t, repo, report.Trends[1].Target)
+		assert.Len(t, report.Trends[1].Points, 1)
+
+		require.Len(t, report.Regressions, 2)
+		assert.Equal(t, "C-0002", report.Regressions[0].ControlID)
+		assert.Equal(t, apis.StatusPassed, report.Regressions[0].PreviousStatus)
+		assert.Equal(t, apis.StatusFailed, report.Regressions[0].Status)
+		assert.Equal(t, "C-0001", report.Regressions[1].ControlID)
+		assert.Equal(t, 2, report.Regressions[1].PreviousFailedResources)
+		assert.Equal(t, 3, report.Regressions[1].FailedResources)
+	})
+
+	t.Run("framework score", func(t *testing.T) {
+		report := NewReport(scans, "NSA")
+		assert.Equal(t, "NSA", report.Framework)
+		assert.Equal(t, float32(81), report.Trends[0].Points[0].Score)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		report := NewReport(nil, "")
+		assert.Empty(t, report.Trends)
+		assert.Empty(t, report.Regressions)
+	})
+}
+
+func TestLoadReport(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), DefaultFileName)
+
+	_, err := LoadReport(ctx, file, Filter{})
+	assert.ErrorIs(t, err, ErrNoHistory)
+
+	cluster := Target{Type: TargetCluster, Name: "prod"}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	store, err := Open(ctx, file)
+	require.NoError(t, err)
+	require.NoError(t, store.Add(ctx, newTestScan(cluster, start, 60)))
+	require.NoError(t, store.Add(ctx, newTestScan(cluster, start.Add(time.Hour), 70)))
+	require.NoError(t, store.Close())
+
+	report, err := LoadReport(ctx, file, Filter{Framework: "NSA"})
+	require.NoError(t, err)
+	assert.Equal(t, "NSA", report.Framework)
+	require.Len(t, report.Trends, 1)
+	require.Len(t, report.Trends[0].Points, 2)
+	assert.Equal(t, float32(71), report.Trends[0].Points[1].Score)
+}
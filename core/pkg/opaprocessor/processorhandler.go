@@ -13,6 +13,7 @@ import (
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/pkg/score"
+	"github.com/kubescape/opa-utils/exceptions"
 	"github.com/kubescape/opa-utils/objectsenvelopes"
 	"github.com/kubescape/opa-utils/reporthandling"
 	"github.com/kubescape/opa-utils/reporthandling/apis"
@@ -125,6 +126,47 @@ func (opap *OPAProcessor) Process(ctx context.Context, policies *cautils.Policie
 	return nil
 }
 
+// EvaluateControls evaluates the given controls, or all the controls when none is given, against the current resources of the session
+// and returns the results per resource with the exceptions applied.
+//
+// Unlike ProcessRulesListener, the results and the report of the session are left untouched and the sensitive data is not removed
+// from the resources, so that the controls can be evaluated again when the resources change.
+func (opap *OPAProcessor) EvaluateControls(ctx context.Context, controlIDs []string) map[string]resourcesresults.Result {
+	if opap.AllPolicies == nil {
+		scanningScope := cautils.GetScanningScope(opap.Metadata.ContextMetadata)
+		opap.AllPolicies = convertFrameworksToPolicies(opap.Policies, opap.ExcludedRules, scanningScope)
+	}
+
+	results := make(map[string]resourcesresults.Result)
+	for controlID, control := range opap.AllPolicies.Controls {
+		if len(controlIDs) > 0 && !slices.Contains(controlIDs, controlID) {
+			continue
+		}
+		resourcesAssociatedControl, err := opap.processControl(ctx, &control)
+		if err != nil {
+			logger.L().Ctx(ctx).Warning(err.Error())
+			continue
+		}
+		for resourceID, controlResult := range resourcesAssociatedControl {
+			result, ok := results[resourceID]
+			if !ok {
+				result = resourcesresults.Result{ResourceID: resourceID}
+			}
+			result.AssociatedControls = append(result.AssociatedControls, controlResult)
+			results[resourceID] = result
+		}
+	}
+
+	processor := exceptions.NewProcessor()
+	for resourceID, result := range results {
+		if resource, ok := opap.AllResources[resourceID]; ok {
+			result.SetExceptions(resource, opap.Exceptions, opap.clusterName, opap.AllPolicies.Controls, resourcesresults.WithExceptionsProcessor(processor))
+			results[resourceID] = result
+		}
+	}
+	return results
+}
+
 func (opap *OPAProcessor) loggerStartScanning() {
 	targetScan := opap.OPASessionObj.Metadata.ScanMetadata.ScanningTarget
 	if reporthandlingv2.Cluster == targetScan {
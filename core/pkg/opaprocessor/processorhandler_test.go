@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/armosec/armoapi-go/identifiers"
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/mocks"
@@ -511,3 +512,102 @@ func TestAppendPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateControls(t *testing.T) {
+	const privilegedRule = "package armo_builtins\n\ndeny[msga] {\n    pod := input[_]\n    pod.kind == \"Pod\"\n    pod.spec.containers[_].securityContext.privileged == true\n    msga := {\n        \"alertMessage\": \"privileged container\",\n        \"packagename\": \"armo_builtins\",\n        \"alertScore\": 7,\n        \"failedPaths\": [],\n        \"fixPaths\": [],\n        \"alertObject\": {\"k8sApiObjects\": [pod]}\n    }\n}\n"
+	const hostNetworkRule = "package armo_builtins\n\ndeny[msga] {\n    pod := input[_]\n    pod.kind == \"Pod\"\n    pod.spec.hostNetwork == true\n    msga := {\n        \"alertMessage\": \"host network\",\n        \"packagename\": \"armo_builtins\",\n        \"alertScore\": 7,\n        \"failedPaths\": [],\n        \"fixPaths\": [],\n        \"alertObject\": {\"k8sApiObjects\": [pod]}\n    }\n}\n"
+
+	podsMatch := []reporthandling.RuleMatchObjects{{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"Pod"}}}
+	newControl := func(controlID, ruleName, rule string) reporthandling.Control {
+		return reporthandling.Control{
+			ControlID: controlID,
+			Rules: []reporthandling.PolicyRule{{
+				PortalBase:   armotypes.PortalBase{Name: ruleName},
+				Rule:         rule,
+				Match:        podsMatch,
+				RuleLanguage: reporthandling.RegoLanguage,
+			}},
+		}
+	}
+	newPod := func(name string, privileged, hostNetwork bool) workloadinterface.IMetadata {
+		return workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec": map[string]interface{}{
+				"hostNetwork": hostNetwork,
+				"containers": []interface{}{
+					map[string]interface{}{"name": "nginx", "image": "nginx", "securityContext": map[string]interface{}{"privileged": privileged}},
+				},
+			},
+		})
+	}
+
+	opap := NewOPAProcessorMock(opaSessionObjMockData1, resourcesMock1)
+	opap.Policies = []reporthandling.Framework{{
+		PortalBase: armotypes.PortalBase{Name: "test"},
+		Controls: []reporthandling.Control{
+			newControl("C-0057", "privileged-container", privilegedRule),
+			newControl("C-0041", "host-network-access", hostNetworkRule),
+		},
+	}}
+	opap.AllPolicies = nil
+	opap.ExternalResources = nil
+	opap.Exceptions = nil
+	opap.AllResources = map[string]workloadinterface.IMetadata{}
+	for _, pod := range []workloadinterface.IMetadata{newPod("pod-a", true, false), newPod("pod-b", false, true)} {
+		opap.AllResources[pod.GetID()] = pod
+	}
+	opap.K8SResources = cautils.K8SResources{"/v1/pods": {"/v1/default/Pod/pod-a", "/v1/default/Pod/pod-b"}}
+	resourcesResultBefore := len(opap.ResourcesResult)
+
+	statuses := func(results map[string]resourcesresults.Result) map[string]map[string]string {
+		m := map[string]map[string]string{}
+		for resourceID, result := range results {
+			m[resourceID] = map[string]string{}
+			for _, control := range result.ListControls() {
+				m[resourceID][control.GetID()] = string(control.GetStatus(nil).Status())
+			}
+		}
+		return m
+	}
+
+	t.Run("all controls", func(t *testing.T) {
+		results := opap.EvaluateControls(context.Background(), nil)
+		assert.Equal(t, map[string]map[string]string{
+			"/v1/default/Pod/pod-a": {"C-0057": "failed", "C-0041": "passed"},
+			"/v1/default/Pod/pod-b": {"C-0057": "passed", "C-0041": "failed"},
+		}, statuses(results))
+	})
+
+	t.Run("selected controls after a change", func(t *testing.T) {
+		pod := newPod("pod-a", false, false)
+		opap.AllResources[pod.GetID()] = pod
+
+		results := opap.EvaluateControls(context.Background(), []string{"C-0057"})
+		assert.Equal(t, map[string]map[string]string{
+			"/v1/default/Pod/pod-a": {"C-0057": "passed"},
+			"/v1/default/Pod/pod-b": {"C-0057": "passed"},
+		}, statuses(results))
+	})
+
+	t.Run("exceptions are applied", func(t *testing.T) {
+		opap.Exceptions = []armotypes.PostureExceptionPolicy{{
+			PortalBase: armotypes.PortalBase{Name: "allow-host-network"},
+			PolicyType: "postureExceptionPolicy",
+			Actions:    []armotypes.PostureExceptionPolicyActions{armotypes.AlertOnly},
+			Resources: []identifiers.PortalDesignator{{
+				DesignatorType: identifiers.DesignatorAttributes,
+				Attributes:     map[string]string{identifiers.AttributeKind: "Pod", identifiers.AttributeName: "pod-b"},
+			}},
+			PosturePolicies: []armotypes.PosturePolicy{{ControlID: "C-0041"}},
+		}}
+		defer func() { opap.Exceptions = nil }()
+
+		results := opap.EvaluateControls(context.Background(), []string{"C-0041"})
+		assert.Equal(t, "passed", statuses(results)["/v1/default/Pod/pod-b"]["C-0041"])
+	})
+
+	// the results of the session are left untouched
+	assert.Len(t, opap.ResourcesResult, resourcesResultBefore)
+}
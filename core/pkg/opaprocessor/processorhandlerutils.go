@@ -186,6 +186,10 @@ func removeData(obj workloadinterface.IMetadata) {
 	default:
 		removePodData(workload)
 	}
+	// objects kept in a disk-backed store return a copy of the object, the changes are written back
+	if obj.GetObjectType() == workloadinterface.TypeWorkloadObject {
+		obj.SetObject(workload.GetObject())
+	}
 }
 
 func removeConfigMapData(workload workloadinterface.IWorkload) {
@@ -206,6 +210,16 @@ func overrideSensitiveData(workload workloadinterface.IWorkload) {
 	}
 }
 
+// RedactSecret removes the sensitive data of a Secret object, the same way it is removed from the scan results
+func RedactSecret(obj workloadinterface.IMetadata) {
+	if obj.GetKind() != "Secret" || !k8sinterface.IsTypeWorkload(obj.GetObject()) {
+		return
+	}
+	workload := workloadinterface.NewWorkloadObj(obj.GetObject())
+	removeSecretData(workload)
+	obj.SetObject(workload.GetObject())
+}
+
 func removeSecretData(workload workloadinterface.IWorkload) {
 	workload.RemoveAnnotation("kubectl.kubernetes.io/last-applied-configuration")
 	workloadinterface.RemoveFromMap(workload.GetObject(), "metadata", "managedFields")
@@ -151,3 +151,16 @@ func TestRemoveEphemeralContainersData(t *testing.T) {
 		}
 	}
 }
+
+func TestRedactSecret(t *testing.T) {
+	secret, _ := workloadinterface.NewWorkload([]byte(`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "example-secret", "namespace": "default"}, "type": "Opaque", "data": {"password": "cGFzc3dvcmQ="}}`))
+	RedactSecret(secret)
+	password, _ := workloadinterface.InspectMap(secret.GetObject(), "data", "password")
+	assert.Equal(t, "XXXXXX", password)
+
+	// other objects are not modified
+	pod, _ := workloadinterface.NewWorkload([]byte(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "example-pod", "namespace": "default"}, "spec": {"containers": [{"name": "nginx", "image": "nginx", "env": [{"name": "ENV", "value": "value"}]}]}}`))
+	RedactSecret(pod)
+	containers, _ := pod.GetContainers()
+	assert.Equal(t, "value", containers[0].Env[0].Value)
+}
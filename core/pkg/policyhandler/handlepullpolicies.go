@@ -59,6 +59,11 @@ func (policyHandler *PolicyHandler) CollectPolicies(ctx context.Context, policyI
 		return opaSessionObj, err
 	}
 
+	if scanInfo.ScanConfig != nil {
+		policies = scanInfo.ScanConfig.OverridePolicies(policies)
+		controlInputs = scanInfo.ScanConfig.OverrideControlsInputs(controlInputs)
+	}
+
 	opaSessionObj.Policies = policies
 	opaSessionObj.Exceptions = exceptions
 	opaSessionObj.RegoInputData.PostureControlInputs = controlInputs
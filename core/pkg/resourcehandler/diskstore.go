@@ -0,0 +1,200 @@
+package resourcehandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/opa-utils/objectsenvelopes"
+)
+
+// DiskResourceStore spools the collected objects to a temporary file. The session holds light references
+// that keep only the object identity in memory and decode the object from the file on access.
+//
+// The file is unlinked as soon as it is created, so it is removed when the process exits even though the
+// scan results are printed after the scan returns
+type DiskResourceStore struct {
+	file   *os.File
+	mu     sync.Mutex
+	offset int64
+}
+
+// NewDiskResourceStore creates a store in dir, the default temporary directory is used when dir is empty
+func NewDiskResourceStore(dir string) (*DiskResourceStore, error) {
+	file, err := os.CreateTemp(dir, "kubescape-resources-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(file.Name()); err != nil {
+		// the file can not be unlinked while open (e.g. on Windows)
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("disk-backed resources store is not supported on this platform: %w", err)
+	}
+	return &DiskResourceStore{file: file}, nil
+}
+
+// Add writes the object to the store and returns a reference to it. It returns nil if the object is not a supported Kubernetes object
+func (store *DiskResourceStore) Add(obj map[string]interface{}) (workloadinterface.IMetadata, error) {
+	metaObj := objectsenvelopes.NewObject(obj)
+	if metaObj == nil {
+		return nil, nil
+	}
+	resource := &storedResource{
+		store:      store,
+		id:         metaObj.GetID(),
+		namespace:  metaObj.GetNamespace(),
+		name:       metaObj.GetName(),
+		kind:       metaObj.GetKind(),
+		apiVersion: metaObj.GetApiVersion(),
+		objectType: metaObj.GetObjectType(),
+	}
+	if err := resource.write(obj); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+func (store *DiskResourceStore) write(obj map[string]interface{}) (int64, int, error) {
+	content, err := json.Marshal(obj)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	offset := store.offset
+	if _, err := store.file.WriteAt(content, offset); err != nil {
+		return 0, 0, fmt.Errorf("failed to write to resources store: %w", err)
+	}
+	store.offset += int64(len(content))
+	return offset, len(content), nil
+}
+
+func (store *DiskResourceStore) read(offset int64, length int) (map[string]interface{}, error) {
+	content := make([]byte, length)
+	// ReadAt is safe for concurrent use, the rules are evaluated concurrently
+	if _, err := store.file.ReadAt(content, offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(content, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Close releases the store file, the references of the store can not be read afterwards
+func (store *DiskResourceStore) Close() error {
+	return store.file.Close()
+}
+
+var _ workloadinterface.IMetadata = &storedResource{}
+
+// storedResource is a reference to an object of a DiskResourceStore.
+// GetObject decodes a new copy of the object on every call, modifications are kept only once written back with SetObject
+type storedResource struct {
+	store      *DiskResourceStore
+	offset     int64
+	length     int
+	id         string
+	namespace  string
+	name       string
+	kind       string
+	apiVersion string
+	objectType workloadinterface.ObjectType
+	object     map[string]interface{} // kept in memory when the store could not be written
+}
+
+func (resource *storedResource) write(obj map[string]interface{}) error {
+	offset, length, err := resource.store.write(obj)
+	if err != nil {
+		return err
+	}
+	resource.offset, resource.length = offset, length
+	return nil
+}
+
+// update applies a change to the stored object
+func (resource *storedResource) update(f func(workload *workloadinterface.Workload)) {
+	workload := workloadinterface.NewWorkloadObj(resource.GetObject())
+	f(workload)
+	resource.SetObject(workload.GetObject())
+}
+
+func (resource *storedResource) SetNamespace(namespace string) {
+	resource.update(func(workload *workloadinterface.Workload) { workload.SetNamespace(namespace) })
+	resource.namespace = namespace
+}
+
+func (resource *storedResource) SetName(name string) {
+	resource.update(func(workload *workloadinterface.Workload) { workload.SetName(name) })
+	resource.name = name
+}
+
+func (resource *storedResource) SetKind(kind string) {
+	resource.update(func(workload *workloadinterface.Workload) { workload.SetKind(kind) })
+	resource.kind = kind
+}
+
+func (resource *storedResource) SetApiVersion(apiVersion string) {
+	resource.update(func(workload *workloadinterface.Workload) { workload.SetApiVersion(apiVersion) })
+	resource.apiVersion = apiVersion
+}
+
+func (resource *storedResource) SetWorkload(obj map[string]interface{}) {
+	resource.SetObject(obj)
+}
+
+// SetObject writes the object back to the store, the previous copy is left unused in the file.
+// The object is kept in memory if it can not be written, so changes such as the removal of sensitive data are never lost
+func (resource *storedResource) SetObject(obj map[string]interface{}) {
+	if obj == nil {
+		return
+	}
+	if err := resource.write(obj); err != nil {
+		logger.L().Warning("failed to write to resources store, keeping object in memory", helpers.String("resource", resource.id), helpers.Error(err))
+		resource.object = obj
+		return
+	}
+	resource.object = nil
+}
+
+func (resource *storedResource) GetNamespace() string  { return resource.namespace }
+func (resource *storedResource) GetName() string       { return resource.name }
+func (resource *storedResource) GetKind() string       { return resource.kind }
+func (resource *storedResource) GetApiVersion() string { return resource.apiVersion }
+func (resource *storedResource) GetID() string         { return resource.id }
+
+func (resource *storedResource) GetObjectType() workloadinterface.ObjectType {
+	return resource.objectType
+}
+
+func (resource *storedResource) GetWorkload() map[string]interface{} {
+	return resource.GetObject()
+}
+
+// GetObject decodes the object from the store. Only the object identity is returned if the store can not be read
+func (resource *storedResource) GetObject() map[string]interface{} {
+	if resource.object != nil {
+		return resource.object
+	}
+	obj, err := resource.store.read(resource.offset, resource.length)
+	if err != nil {
+		logger.L().Error("failed to read from resources store", helpers.String("resource", resource.id), helpers.Error(err))
+		workload := workloadinterface.NewWorkloadObj(map[string]interface{}{})
+		workload.SetApiVersion(resource.apiVersion)
+		workload.SetKind(resource.kind)
+		workload.SetName(resource.name)
+		if resource.namespace != "" {
+			workload.SetNamespace(resource.namespace)
+		}
+		return workload.GetObject()
+	}
+	return obj
+}
@@ -0,0 +1,94 @@
+package resourcehandler
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockStoredSecret() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "credentials", "namespace": "default"},
+		"data":       map[string]interface{}{"password": "c2VjcmV0"},
+	}
+}
+
+func TestDiskResourceStore(t *testing.T) {
+	store, err := NewDiskResourceStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	t.Run("objects are read back from the store", func(t *testing.T) {
+		resource, err := store.Add(mockStoredSecret())
+		require.NoError(t, err)
+		require.NotNil(t, resource)
+
+		assert.Equal(t, "/v1/default/Secret/credentials", resource.GetID())
+		assert.Equal(t, "credentials", resource.GetName())
+		assert.Equal(t, "default", resource.GetNamespace())
+		assert.Equal(t, "Secret", resource.GetKind())
+		assert.Equal(t, "v1", resource.GetApiVersion())
+		assert.Equal(t, workloadinterface.TypeWorkloadObject, resource.GetObjectType())
+		assert.Equal(t, mockStoredSecret(), resource.GetObject())
+	})
+
+	t.Run("changes are kept once written back", func(t *testing.T) {
+		resource, err := store.Add(mockStoredSecret())
+		require.NoError(t, err)
+
+		obj := resource.GetObject()
+		obj["data"] = map[string]interface{}{"password": "XXXXXX"}
+		assert.Equal(t, "c2VjcmV0", resource.GetObject()["data"].(map[string]interface{})["password"], "GetObject returns a copy")
+
+		resource.SetObject(obj)
+		assert.Equal(t, "XXXXXX", resource.GetObject()["data"].(map[string]interface{})["password"])
+
+		resource.SetNamespace("kube-system")
+		assert.Equal(t, "kube-system", resource.GetNamespace())
+		assert.Equal(t, "kube-system", workloadinterface.NewWorkloadObj(resource.GetObject()).GetNamespace())
+		assert.Equal(t, "XXXXXX", resource.GetObject()["data"].(map[string]interface{})["password"])
+	})
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		resource, err := store.Add(mockStoredSecret())
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Equal(t, "credentials", workloadinterface.NewWorkloadObj(resource.GetObject()).GetName())
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("not a kubernetes object", func(t *testing.T) {
+		resource, err := store.Add(map[string]interface{}{"foo": "bar"})
+		assert.NoError(t, err)
+		assert.Nil(t, resource)
+	})
+}
+
+func TestDiskResourceStoreClosed(t *testing.T) {
+	store, err := NewDiskResourceStore(t.TempDir())
+	require.NoError(t, err)
+
+	resource, err := store.Add(mockStoredSecret())
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	// only the object identity is left
+	obj := workloadinterface.NewWorkloadObj(resource.GetObject())
+	assert.Equal(t, "credentials", obj.GetName())
+	assert.Equal(t, "default", obj.GetNamespace())
+	assert.Equal(t, "Secret", obj.GetKind())
+	_, ok := workloadinterface.InspectMap(obj.GetObject(), "data")
+	assert.False(t, ok)
+}
@@ -4,6 +4,8 @@ import (
 	"strings"
 
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -109,3 +111,18 @@ func combineFieldSelectors(selectors ...string) string {
 	}
 	return strings.Join(nonEmptyStrings, FieldSelectorsSeparator)
 }
+
+// matchFieldSelector checks if an already loaded object matches a field selector, the same way the API server would filter it
+func matchFieldSelector(obj workloadinterface.IMetadata, fieldSelector string) bool {
+	if fieldSelector == "" {
+		return true
+	}
+	selector, err := fields.ParseSelector(fieldSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(fields.Set{
+		"metadata.name":      obj.GetName(),
+		"metadata.namespace": obj.GetNamespace(),
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -46,3 +47,17 @@ func TestIncludeNamespacesSelectors(t *testing.T) {
 	assert.Equal(t, "metadata.name==default", selectors2[0])
 	assert.Equal(t, "metadata.name==ingress", selectors2[1])
 }
+
+func TestMatchFieldSelector(t *testing.T) {
+	pod := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+	})
+
+	assert.True(t, matchFieldSelector(pod, ""))
+	assert.True(t, matchFieldSelector(pod, "metadata.namespace==default"))
+	assert.False(t, matchFieldSelector(pod, "metadata.namespace==kube-system"))
+	assert.False(t, matchFieldSelector(pod, "metadata.namespace!=default,metadata.namespace!=ingress"))
+	assert.True(t, matchFieldSelector(pod, "metadata.name==nginx,metadata.namespace!=kube-system"))
+}
@@ -48,6 +48,9 @@ func (fileHandler *FileResourceHandler) GetResources(ctx context.Context, sessio
 				return nil, allResources, nil, nil, err
 			}
 		}
+		if scanInfo.ScanConfig != nil {
+			workloads = filterIgnoredPaths(scanInfo.ScanConfig, getSourcesRoot(scanInfo.InputPatterns[path]), workloadIDToSource, workloads)
+		}
 		if len(workloads) == 0 {
 			continue
 		}
@@ -97,6 +100,41 @@ func (fileHandler *FileResourceHandler) GetResources(ctx context.Context, sessio
 	return k8sResources, allResources, externalResources, excludedRulesMap, nil
 }
 
+// filterIgnoredPaths removes the workloads of the files ignored by the path rules of the scan config. The relative paths of the
+// sources without a path are relative to root
+func filterIgnoredPaths(scanConfig *cautils.ScanConfig, root string, workloadIDToSource map[string]reporthandling.Source, workloads []workloadinterface.IMetadata) []workloadinterface.IMetadata {
+	filtered := make([]workloadinterface.IMetadata, 0, len(workloads))
+	for _, workload := range workloads {
+		if source, ok := workloadIDToSource[workload.GetID()]; ok && scanConfig.IsIgnoredFile(getSourceFile(source, root)) {
+			logger.L().Debug("ignoring file", helpers.String("path", source.RelativePath), helpers.String("resource", workload.GetID()))
+			delete(workloadIDToSource, workload.GetID())
+			continue
+		}
+		filtered = append(filtered, workload)
+	}
+	return filtered
+}
+
+// getSourceFile returns the absolute path of the file of the source
+func getSourceFile(source reporthandling.Source, root string) string {
+	if filepath.IsAbs(source.RelativePath) {
+		return source.RelativePath
+	}
+	if source.Path != "" {
+		root = source.Path
+	}
+	return filepath.Join(root, source.RelativePath)
+}
+
+// getSourcesRoot returns the directory the relative paths of the sources loaded from the path are relative to
+func getSourcesRoot(path string) string {
+	if clonedRepo := cautils.GetClonedPath(path); clonedRepo != "" {
+		path = clonedRepo
+	}
+	repoRoot, _ := getRepoRoot(path)
+	return repoRoot
+}
+
 func (fileHandler *FileResourceHandler) GetCloudProvider() string {
 	return ""
 }
@@ -183,13 +221,7 @@ func getResourcesFromPath(ctx context.Context, path string) (map[string]reportha
 	}
 
 	// Get repo root
-	repoRoot, gitRepo := extractGitRepo(path)
-
-	// when scanning a single file, we consider the repository root to be
-	// the directory of the scanned file
-	if cautils.IsYaml(repoRoot) {
-		repoRoot = filepath.Dir(repoRoot)
-	}
+	repoRoot, gitRepo := getRepoRoot(path)
 
 	// load resource from local file system
 	sourceToWorkloads := cautils.LoadResourcesFromFiles(ctx, path, repoRoot)
@@ -340,6 +372,18 @@ func extractGitRepo(path string) (string, *cautils.LocalGitRepository) {
 	return repoRoot, gitRepo
 }
 
+// getRepoRoot returns the root of the git repository of the path, or the path itself when it is not in a git repository
+func getRepoRoot(path string) (string, *cautils.LocalGitRepository) {
+	repoRoot, gitRepo := extractGitRepo(path)
+
+	// when scanning a single file, we consider the repository root to be
+	// the directory of the scanned file
+	if cautils.IsYaml(repoRoot) {
+		repoRoot = filepath.Dir(repoRoot)
+	}
+	return repoRoot, gitRepo
+}
+
 func (fileHandler *FileResourceHandler) GetClusterAPIServerInfo(_ context.Context) *version.Info {
 	return nil
 }
@@ -1,9 +1,15 @@
 package resourcehandler
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Initializes a new instance of FileResourceHandler.
@@ -11,3 +17,31 @@ func TestNewFileResourceHandler_InitializesNewInstance(t *testing.T) {
 	fileHandler := NewFileResourceHandler()
 	assert.NotNil(t, fileHandler)
 }
+
+func TestFilterIgnoredPaths(t *testing.T) {
+	// the config file is in a sub directory of the repository
+	repoRoot := t.TempDir()
+	configFile := filepath.Join(repoRoot, "deploy", cautils.ScanConfigFilename)
+	require.NoError(t, os.MkdirAll(filepath.Dir(configFile), 0700))
+	require.NoError(t, os.WriteFile(configFile, []byte("paths:\n- path: tests/**\n  ignore: true\n"), 0600))
+	scanConfig, err := cautils.LoadScanConfig(configFile)
+	require.NoError(t, err)
+
+	newPod := func(name string) workloadinterface.IMetadata {
+		pod := workloadinterface.NewWorkloadObj(map[string]interface{}{})
+		pod.SetApiVersion("v1")
+		pod.SetKind("Pod")
+		pod.SetName(name)
+		return pod
+	}
+	ignored, kept, outside := newPod("ignored"), newPod("kept"), newPod("outside")
+	workloadIDToSource := map[string]reporthandling.Source{
+		ignored.GetID(): {Path: repoRoot, RelativePath: "deploy/tests/pod.yaml"},
+		kept.GetID():    {RelativePath: "deploy/pod.yaml"},
+		outside.GetID(): {Path: repoRoot, RelativePath: "tests/pod.yaml"}, // not under the directory of the config file
+	}
+
+	workloads := filterIgnoredPaths(scanConfig, repoRoot, workloadIDToSource, []workloadinterface.IMetadata{ignored, kept, outside})
+	assert.Equal(t, []workloadinterface.IMetadata{kept, outside}, workloads)
+	assert.NotContains(t, workloadIDToSource, ignored.GetID())
+}
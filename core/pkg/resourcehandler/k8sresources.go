@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
@@ -16,11 +17,11 @@ import (
 	"github.com/kubescape/kubescape/v3/core/metrics"
 	"github.com/kubescape/kubescape/v3/core/pkg/hostsensorutils"
 	"github.com/kubescape/opa-utils/objectsenvelopes"
+	"github.com/kubescape/opa-utils/reporthandling"
 	"github.com/kubescape/opa-utils/reporthandling/apis"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	k8slabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/version"
@@ -44,6 +45,8 @@ type K8sResourceHandler struct {
 	k8s               *k8sinterface.KubernetesApi
 	hostSensorHandler hostsensorutils.IHostSensor
 	rbacObjectsAPI    *cautils.RBACObjects
+	pageSize          int64              // number of objects of a list call, the default page size of the pager is used when 0
+	resourceStore     *DiskResourceStore // when set, the pulled objects are kept on disk instead of memory
 }
 
 func NewK8sResourceHandler(k8s *k8sinterface.KubernetesApi, hostSensorHandler hostsensorutils.IHostSensor, rbacObjects *cautils.RBACObjects, clusterName string) *K8sResourceHandler {
@@ -61,9 +64,13 @@ func NewK8sResourceHandler(k8s *k8sinterface.KubernetesApi, hostSensorHandler ho
 
 func (k8sHandler *K8sResourceHandler) GetResources(ctx context.Context, sessionObj *cautils.OPASessionObj, scanInfo *cautils.ScanInfo) (cautils.K8SResources, map[string]workloadinterface.IMetadata, cautils.ExternalResources, map[string]bool, error) {
 	logger.L().Start("Accessing Kubernetes objects...")
-	var err error
 
 	globalFieldSelectors := getFieldSelectorFromScanInfo(scanInfo)
+	resourceSelector, err := getResourceSelectorFromScanInfo(scanInfo)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	k8sHandler.setCollectionOptions(scanInfo)
 
 	if scanInfo.IsDeletedScanObject {
 		sessionObj.SingleResourceScan, err = getWorkloadFromScanObject(scanInfo.ScanObject)
@@ -87,7 +94,7 @@ func (k8sHandler *K8sResourceHandler) GetResources(ctx context.Context, sessionO
 	sessionObj.ResourceToControlsMap = resourceToControl
 
 	// pull k8s resources
-	k8sResourcesMap, allResources, err := k8sHandler.pullResources(queryableResources, globalFieldSelectors)
+	k8sResourcesMap, allResources, err := k8sHandler.pullResources(queryableResources, globalFieldSelectors, resourceSelector)
 	if err != nil {
 		cautils.StopSpinner()
 		return k8sResourcesMap, allResources, ksResourceMap, excludedRulesMap, err
@@ -154,10 +161,94 @@ func (k8sHandler *K8sResourceHandler) GetResources(ctx context.Context, sessionO
 	return k8sResourcesMap, allResources, ksResourceMap, excludedRulesMap, nil
 }
 
+// setCollectionOptions sets how the resources are paginated and stored
+func (k8sHandler *K8sResourceHandler) setCollectionOptions(scanInfo *cautils.ScanInfo) {
+	k8sHandler.pageSize = scanInfo.PageSize
+	if !scanInfo.StoreResourcesOnDisk {
+		return
+	}
+	store, err := NewDiskResourceStore("")
+	if err != nil {
+		logger.L().Warning("failed to create disk-backed resources store, keeping resources in memory", helpers.Error(err))
+		return
+	}
+	k8sHandler.resourceStore = store
+	scanInfo.AddCleanup(func() {
+		if err := store.Close(); err != nil {
+			logger.L().Debug("failed to close resources store", helpers.Error(err))
+		}
+	})
+}
+
 func (k8sHandler *K8sResourceHandler) GetCloudProvider() string {
 	return k8sHandler.cloudProvider
 }
 
+// snapshotResources are always exported in a cluster snapshot: nodes are counted as worker nodes and
+// the RBAC objects are aggregated by the snapshot scan the same way they are collected from the API server
+var snapshotResources = []string{
+	"/v1/nodes",
+	"/v1/serviceaccounts",
+	"rbac.authorization.k8s.io/v1/clusterroles",
+	"rbac.authorization.k8s.io/v1/roles",
+	"rbac.authorization.k8s.io/v1/clusterrolebindings",
+	"rbac.authorization.k8s.io/v1/rolebindings",
+}
+
+// PullSnapshot pulls the resources required by the frameworks into a cluster snapshot, to be scanned offline
+func (k8sHandler *K8sResourceHandler) PullSnapshot(ctx context.Context, frameworks []reporthandling.Framework, scanInfo *cautils.ScanInfo, withHostSensor bool) (*cautils.ClusterSnapshot, error) {
+	logger.L().Start("Accessing Kubernetes objects...")
+
+	globalFieldSelectors := getFieldSelectorFromScanInfo(scanInfo)
+	resourceSelector, err := getResourceSelectorFromScanInfo(scanInfo)
+	if err != nil {
+		return nil, err
+	}
+	// the snapshot is written right after the resources are pulled, a disk-backed store would not save memory
+	k8sHandler.pageSize = scanInfo.PageSize
+
+	queryableResources, _ := getQueryableResourceMapFromPolicies(frameworks, nil, reporthandling.ScopeCluster)
+	for _, triplet := range snapshotResources {
+		queryableResources.Add(QueryableResource{GroupVersionResourceTriplet: triplet})
+	}
+
+	_, allResources, err := k8sHandler.pullResources(queryableResources, globalFieldSelectors, resourceSelector)
+	if err != nil {
+		cautils.StopSpinner()
+		return nil, err
+	}
+
+	resources := make([]workloadinterface.IMetadata, 0, len(allResources))
+	for _, resource := range allResources {
+		resources = append(resources, resource)
+	}
+
+	logger.L().StopSuccess("Accessed Kubernetes objects")
+
+	if withHostSensor && k8sHandler.hostSensorHandler != nil {
+		logger.L().Info("Requesting Host scanner data")
+		hostResources, _, err := k8sHandler.hostSensorHandler.CollectResources(ctx)
+		if err != nil {
+			logger.L().Ctx(ctx).Warning("failed to collect host scanner resources", helpers.Error(err))
+		}
+		for i := range hostResources {
+			resources = append(resources, &hostResources[i])
+		}
+	}
+
+	metadata := cautils.SnapshotMetadata{
+		ClusterName:  k8sHandler.clusterName,
+		CreationTime: time.Now().UTC(),
+	}
+	if apiServerInfo, err := k8sHandler.k8s.DiscoveryClient.ServerVersion(); err != nil {
+		logger.L().Ctx(ctx).Warning("failed to collect API server info", helpers.Error(err))
+	} else {
+		metadata.APIServerInfo = apiServerInfo
+	}
+
+	return cautils.NewClusterSnapshot(metadata, resources), nil
+}
+
 // findScanObjectResource pulls the requested k8s object to be scanned from the api server
 func (k8sHandler *K8sResourceHandler) findScanObjectResource(resource *objectsenvelopes.ScanObject, globalFieldSelector IFieldSelector) (workloadinterface.IWorkload, error) {
 	if resource == nil {
@@ -310,7 +401,7 @@ func setMapNamespaceToNumOfResources(ctx context.Context, allResources map[strin
 	sessionObj.SetMapNamespaceToNumberOfResources(mapNamespaceToNumberOfResources)
 }
 
-func (k8sHandler *K8sResourceHandler) pullResources(queryableResources QueryableResources, globalFieldSelectors IFieldSelector) (cautils.K8SResources, map[string]workloadinterface.IMetadata, error) {
+func (k8sHandler *K8sResourceHandler) pullResources(queryableResources QueryableResources, globalFieldSelectors IFieldSelector, resourceSelector *ResourceSelector) (cautils.K8SResources, map[string]workloadinterface.IMetadata, error) {
 	k8sResources := queryableResources.ToK8sResourceMap()
 	allResources := map[string]workloadinterface.IMetadata{}
 
@@ -318,7 +409,17 @@ func (k8sHandler *K8sResourceHandler) pullResources(queryableResources Queryable
 	for i := range queryableResources {
 		apiGroup, apiVersion, resource := k8sinterface.StringToResourceGroup(queryableResources[i].GroupVersionResourceTriplet)
 		gvr := schema.GroupVersionResource{Group: apiGroup, Version: apiVersion, Resource: resource}
-		result, err := k8sHandler.pullSingleResource(&gvr, nil, queryableResources[i].FieldSelectors, globalFieldSelectors)
+		var metaObjs []workloadinterface.IMetadata
+		err := k8sHandler.listSingleResource(&gvr, resourceSelector, queryableResources[i].FieldSelectors, globalFieldSelectors, func(obj *unstructured.Unstructured) error {
+			metaObj, err := k8sHandler.storeResource(obj.Object)
+			if err != nil {
+				return err
+			}
+			if metaObj != nil {
+				metaObjs = append(metaObjs, metaObj)
+			}
+			return nil
+		})
 		if err != nil {
 			if !strings.Contains(err.Error(), "the server could not find the requested resource") {
 				logger.L().Warning("failed to pull resource", helpers.String("resource", queryableResources[i].GroupVersionResourceTriplet), helpers.Error(err))
@@ -331,8 +432,6 @@ func (k8sHandler *K8sResourceHandler) pullResources(queryableResources Queryable
 			}
 			continue
 		}
-		// store result as []map[string]interface{}
-		metaObjs := ConvertMapListToMeta(k8sinterface.ConvertUnstructuredSliceToMap(result))
 		for i := range metaObjs {
 			allResources[metaObjs[i].GetID()] = metaObjs[i]
 		}
@@ -354,10 +453,21 @@ func (k8sHandler *K8sResourceHandler) pullResources(queryableResources Queryable
 	return k8sResources, allResources, errs
 }
 
-func (k8sHandler *K8sResourceHandler) pullSingleResource(resource *schema.GroupVersionResource, labels map[string]string, fields string, fieldSelector IFieldSelector) ([]unstructured.Unstructured, error) {
+func (k8sHandler *K8sResourceHandler) pullSingleResource(resource *schema.GroupVersionResource, resourceSelector *ResourceSelector, fields string, fieldSelector IFieldSelector) ([]unstructured.Unstructured, error) {
 	var resourceList []unstructured.Unstructured
+	err := k8sHandler.listSingleResource(resource, resourceSelector, fields, fieldSelector, func(obj *unstructured.Unstructured) error {
+		resourceList = append(resourceList, *obj)
+		return nil
+	})
+	return resourceList, err
+}
+
+// listSingleResource lists a resource page by page and streams the objects to the handler, so that a list is never held in memory in full
+func (k8sHandler *K8sResourceHandler) listSingleResource(resource *schema.GroupVersionResource, resourceSelector *ResourceSelector, fields string, fieldSelector IFieldSelector, handler func(obj *unstructured.Unstructured) error) error {
 	// set labels
-	listOptions := metav1.ListOptions{}
+	listOptions := metav1.ListOptions{
+		LabelSelector: resourceSelector.GetLabelSelector(resource),
+	}
 	fieldSelectors := fieldSelector.GetNamespacesSelectors(resource)
 	for i := range fieldSelectors {
 		if fieldSelectors[i] != "" {
@@ -366,35 +476,49 @@ func (k8sHandler *K8sResourceHandler) pullSingleResource(resource *schema.GroupV
 			listOptions.FieldSelector = fields
 		}
 
-		if len(labels) > 0 {
-			set := k8slabels.Set(labels)
-			listOptions.LabelSelector = set.AsSelector().String()
-		}
-
 		// set dynamic object
 		clientResource := k8sHandler.k8s.DynamicClient.Resource(*resource)
 
-		// list resources
-		lenBefore := len(resourceList)
-		if err := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		// list resources, the pager follows the continue token of each page
+		listPager := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
 			return clientResource.List(ctx, opts)
-		}).EachListItem(context.Background(), listOptions, func(obj runtime.Object) error {
+		})
+		if k8sHandler.pageSize > 0 {
+			listPager.PageSize = k8sHandler.pageSize
+		}
+		listPager.PageBufferSize = listPageBufferSize
+
+		count := 0
+		if err := listPager.EachListItem(context.Background(), listOptions, func(obj runtime.Object) error {
 			uObject := obj.(*unstructured.Unstructured)
 			if k8sinterface.IsTypeWorkload(uObject.Object) && k8sinterface.WorkloadHasParent(workloadinterface.NewWorkloadObj(uObject.Object)) {
 				logger.L().Debug("Skipping resource with parent", helpers.String("resource", resource.String()), helpers.String("namespace", uObject.GetNamespace()), helpers.String("name", uObject.GetName()))
 				return nil
 			}
-			resourceList = append(resourceList, *obj.(*unstructured.Unstructured))
-			return nil
+			// the API server does not support annotation selectors
+			if !resourceSelector.MatchesAnnotations(resource, uObject.GetAnnotations()) {
+				return nil
+			}
+			count++
+			return handler(uObject)
 		}); err != nil {
-			return nil, fmt.Errorf("failed to get resource: %v, labelSelector: %v, fieldSelector: %v, reason: %w", resource, listOptions.LabelSelector, listOptions.FieldSelector, err)
+			return fmt.Errorf("failed to get resource: %v, labelSelector: %v, fieldSelector: %v, reason: %w", resource, listOptions.LabelSelector, listOptions.FieldSelector, err)
 		}
-		logger.L().Debug("Pulled resources", helpers.String("resource", resource.String()), helpers.String("fieldSelector", listOptions.FieldSelector), helpers.String("labelSelector", listOptions.LabelSelector), helpers.Int("count", len(resourceList)-lenBefore))
+		logger.L().Debug("Pulled resources", helpers.String("resource", resource.String()), helpers.String("fieldSelector", listOptions.FieldSelector), helpers.String("labelSelector", listOptions.LabelSelector), helpers.Int("count", count))
 	}
 
-	return resourceList, nil
+	return nil
+}
 
+// storeResource strips the object and keeps it in the resources store, or in memory when no store is set
+func (k8sHandler *K8sResourceHandler) storeResource(obj map[string]interface{}) (workloadinterface.IMetadata, error) {
+	stripResource(obj)
+	if k8sHandler.resourceStore != nil {
+		return k8sHandler.resourceStore.Add(obj)
+	}
+	return objectsenvelopes.NewObject(obj), nil
 }
+
 func ConvertMapListToMeta(resourceMap []map[string]interface{}) []workloadinterface.IMetadata {
 	var workloads []workloadinterface.IMetadata
 	for i := range resourceMap {
@@ -448,6 +572,14 @@ func (k8sHandler *K8sResourceHandler) collectRbacResources(allResources map[stri
 
 func (k8sHandler *K8sResourceHandler) pullWorkerNodesNumber() (int, error) {
 	nodesList, err := k8sHandler.k8s.KubernetesClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+	return countWorkerNodes(nodesList), nil
+}
+
+// countWorkerNodes returns the number of schedulable (non control-plane) nodes
+func countWorkerNodes(nodesList *v1.NodeList) int {
 	scheduableNodes := v1.NodeList{}
 	if nodesList != nil {
 		for _, node := range nodesList.Items {
@@ -460,10 +592,7 @@ func (k8sHandler *K8sResourceHandler) pullWorkerNodesNumber() (int, error) {
 			}
 		}
 	}
-	if err != nil {
-		return 0, err
-	}
-	return len(scheduableNodes.Items), nil
+	return len(scheduableNodes.Items)
 }
 
 func (k8sHandler *K8sResourceHandler) setCloudProvider() error {
@@ -4,13 +4,25 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"fmt"
 	"reflect"
+	goruntime "runtime"
+	"strconv"
 	"testing"
 
+	"github.com/kubescape/k8s-interface/k8sinterface"
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
 )
 
 func TestIsMasterNodeTaints(t *testing.T) {
@@ -577,3 +589,170 @@ func TestCloudResourceRequired(t *testing.T) {
 	assert.True(t, cloudResourceRequired(cloudResources, ClusterDescribe))
 	assert.False(t, cloudResourceRequired(cloudResources, "ListRolePolicies"))
 }
+
+var podsGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+// pagingDynamicClient paginates the list calls of the fake dynamic client, which ignores the limit and continue options
+type pagingDynamicClient struct {
+	dynamic.Interface
+	listCalls *[]metav1.ListOptions
+}
+
+func (c pagingDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return pagingResourceClient{NamespaceableResourceInterface: c.Interface.Resource(resource), listCalls: c.listCalls}
+}
+
+type pagingResourceClient struct {
+	dynamic.NamespaceableResourceInterface
+	listCalls *[]metav1.ListOptions
+}
+
+func (c pagingResourceClient) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	*c.listCalls = append(*c.listCalls, opts)
+	list, err := c.NamespaceableResourceInterface.List(ctx, metav1.ListOptions{LabelSelector: opts.LabelSelector, FieldSelector: opts.FieldSelector})
+	if err != nil || opts.Limit == 0 {
+		return list, err
+	}
+	start := 0
+	if opts.Continue != "" {
+		if start, err = strconv.Atoi(opts.Continue); err != nil {
+			return nil, err
+		}
+	}
+	end := min(start+int(opts.Limit), len(list.Items))
+	page := &unstructured.UnstructuredList{Object: list.Object, Items: list.Items[start:end]}
+	if end < len(list.Items) {
+		page.SetContinue(strconv.Itoa(end))
+	}
+	return page, nil
+}
+
+// mockLargeCluster returns a resource handler of a synthetic cluster with the given number of pods
+func mockLargeCluster(numberOfPods int, listCalls *[]metav1.ListOptions) *K8sResourceHandler {
+	objects := make([]runtime.Object, 0, numberOfPods)
+	for i := 0; i < numberOfPods; i++ {
+		pod := &unstructured.Unstructured{}
+		pod.SetAPIVersion("v1")
+		pod.SetKind("Pod")
+		pod.SetName(fmt.Sprintf("pod-%d", i))
+		pod.SetNamespace(fmt.Sprintf("namespace-%d", i%100))
+		pod.SetLabels(map[string]string{"app": fmt.Sprintf("app-%d", i%10)})
+		pod.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply, APIVersion: "v1"}})
+		_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+			map[string]interface{}{"name": "nginx", "image": "nginx:1.25", "securityContext": map[string]interface{}{"privileged": false}},
+		}, "spec", "containers")
+		objects = append(objects, pod)
+	}
+	dynamicClient := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{podsGVR: "PodList"}, objects...)
+
+	return &K8sResourceHandler{
+		k8s: &k8sinterface.KubernetesApi{
+			KubernetesClient: fakeclientset.NewSimpleClientset(),
+			DynamicClient:    pagingDynamicClient{Interface: dynamicClient, listCalls: listCalls},
+			Context:          context.Background(),
+		},
+	}
+}
+
+func TestPullResourcesPaginated(t *testing.T) {
+	queryableResources := QueryableResources{}
+	queryableResources.Add(QueryableResource{GroupVersionResourceTriplet: "/v1/pods"})
+
+	t.Run("in memory", func(t *testing.T) {
+		var listCalls []metav1.ListOptions
+		k8sHandler := mockLargeCluster(25, &listCalls)
+		k8sHandler.pageSize = 10
+
+		k8sResources, allResources, err := k8sHandler.pullResources(queryableResources, &EmptySelector{}, nil)
+		require.NoError(t, err)
+
+		assert.Len(t, k8sResources["/v1/pods"], 25)
+		assert.Len(t, allResources, 25)
+		require.Len(t, listCalls, 3) // 10 + 10 + 5
+		assert.Equal(t, int64(10), listCalls[0].Limit)
+		assert.Equal(t, "", listCalls[0].Continue)
+		assert.Equal(t, "10", listCalls[1].Continue)
+		assert.Equal(t, "20", listCalls[2].Continue)
+
+		pod := allResources["/v1/namespace-3/Pod/pod-3"]
+		require.NotNil(t, pod)
+		_, ok := workloadinterface.InspectMap(pod.GetObject(), "metadata", "managedFields")
+		assert.False(t, ok, "managedFields are stripped")
+	})
+
+	t.Run("disk-backed store", func(t *testing.T) {
+		var listCalls []metav1.ListOptions
+		k8sHandler := mockLargeCluster(25, &listCalls)
+		store, err := NewDiskResourceStore(t.TempDir())
+		require.NoError(t, err)
+		defer store.Close()
+		k8sHandler.resourceStore = store
+
+		_, allResources, err := k8sHandler.pullResources(queryableResources, &EmptySelector{}, nil)
+		require.NoError(t, err)
+
+		require.Len(t, allResources, 25)
+		pod := allResources["/v1/namespace-3/Pod/pod-3"]
+		require.NotNil(t, pod)
+		assert.IsType(t, &storedResource{}, pod)
+		assert.Equal(t, "pod-3", workloadinterface.NewWorkloadObj(pod.GetObject()).GetName())
+		_, ok := workloadinterface.InspectMap(pod.GetObject(), "metadata", "managedFields")
+		assert.False(t, ok, "managedFields are stripped")
+	})
+}
+
+// BenchmarkPullResources pulls the pods of a synthetic large cluster, with the objects kept in memory or in a disk-backed store.
+// The retained heap is the memory held by the scan session once the resources are pulled
+func BenchmarkPullResources(b *testing.B) {
+	const numberOfPods = 10000
+
+	queryableResources := QueryableResources{}
+	queryableResources.Add(QueryableResource{GroupVersionResourceTriplet: "/v1/pods"})
+
+	for _, storeOnDisk := range []bool{false, true} {
+		b.Run(fmt.Sprintf("store_on_disk_%t", storeOnDisk), func(b *testing.B) {
+			var listCalls []metav1.ListOptions
+			k8sHandler := mockLargeCluster(numberOfPods, &listCalls)
+			k8sHandler.pageSize = 500
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if storeOnDisk {
+					store, err := NewDiskResourceStore(b.TempDir())
+					require.NoError(b, err)
+					k8sHandler.resourceStore = store
+				}
+
+				var before, after goruntime.MemStats
+				goruntime.GC()
+				goruntime.ReadMemStats(&before)
+
+				_, allResources, err := k8sHandler.pullResources(queryableResources, &EmptySelector{}, nil)
+				require.NoError(b, err)
+
+				goruntime.GC()
+				goruntime.ReadMemStats(&after)
+				b.ReportMetric(float64(after.HeapAlloc-min(before.HeapAlloc, after.HeapAlloc))/(1024*1024), "retained_heap_mb")
+				require.Len(b, allResources, numberOfPods)
+
+				if k8sHandler.resourceStore != nil {
+					k8sHandler.resourceStore.Close()
+				}
+			}
+		})
+	}
+}
+
+func TestSetCollectionOptions(t *testing.T) {
+	scanInfo := &cautils.ScanInfo{PageSize: 10, StoreResourcesOnDisk: true}
+	k8sHandler := &K8sResourceHandler{}
+	k8sHandler.setCollectionOptions(scanInfo)
+	assert.Equal(t, int64(10), k8sHandler.pageSize)
+	require.NotNil(t, k8sHandler.resourceStore)
+
+	// the store is closed with the scan
+	scanInfo.Cleanup()
+	_, err := k8sHandler.resourceStore.Add(map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "nginx"}})
+	assert.Error(t, err)
+}
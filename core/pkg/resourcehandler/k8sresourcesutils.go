@@ -176,3 +176,26 @@ func getWorkloadFromScanObject(resource *objectsenvelopes.ScanObject) (workloadi
 	}
 	return nil, fmt.Errorf("resource %s is not a valid workload", getReadableID(resource))
 }
+
+// listPageBufferSize is the number of pages the pager prefetches while the objects of the current page are processed
+const listPageBufferSize = 2
+
+// statusStrippedKinds are the controllers whose status (replicas counters and conditions) is not used by any rule.
+// The status of pods and nodes is kept, rules and the image scan use them
+var statusStrippedKinds = map[string]bool{
+	"Deployment":  true,
+	"ReplicaSet":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+	"Job":         true,
+	"CronJob":     true,
+}
+
+// stripResource removes the fields no rule needs from a pulled object, to reduce the memory held by the scan session or
+// the size of the resources store
+func stripResource(obj map[string]interface{}) {
+	workloadinterface.RemoveFromMap(obj, "metadata", "managedFields")
+	if kind, ok := obj["kind"].(string); ok && statusStrippedKinds[kind] {
+		workloadinterface.RemoveFromMap(obj, "status")
+	}
+}
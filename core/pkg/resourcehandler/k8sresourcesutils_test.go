@@ -55,3 +55,33 @@ func Test_getWorkloadFromScanObject(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, workload)
 }
+
+func TestStripResource(t *testing.T) {
+	deployment := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":          "nginx",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"spec":   map[string]interface{}{"replicas": int64(2)},
+		"status": map[string]interface{}{"readyReplicas": int64(2)},
+	}
+	stripResource(deployment)
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx"},
+		"spec":       map[string]interface{}{"replicas": int64(2)},
+	}, deployment)
+
+	// the status of pods is kept
+	pod := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "nginx"},
+		"status":     map[string]interface{}{"phase": "Running"},
+	}
+	stripResource(pod)
+	assert.Contains(t, pod, "status")
+}
@@ -3,12 +3,14 @@ package resourcehandler
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
 	giturls "github.com/chainguard-dev/git-urls"
+	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/cautils/getter"
 	"k8s.io/utils/strings/slices"
 )
@@ -101,7 +103,8 @@ func getRepository(fullURL string) (IRepository, error) {
 		repo = NewGitHubRepository()
 		repo.setIsFile(true)
 	default:
-		return nil, fmt.Errorf("unknown repository host: %s", hostUrl)
+		// any other git server (self-hosted GitLab, Gitea, Bitbucket Server, ssh://, file://...)
+		repo = NewGitRepository()
 	}
 
 	// Returns the host-url, and the part of the user and repository from the url
@@ -253,3 +256,83 @@ func (g *GitHubRepository) rowYamlUrl() string {
 func getFileExtension(path string) string {
 	return strings.TrimPrefix(filepath.Ext(path), ".")
 }
+
+// GitRepository is a repository hosted on a generic git server. Since there is no API to list the files,
+// the repository is cloned and the tree is read from the local clone
+type GitRepository struct {
+	remote    *cautils.GitRemote
+	localPath string
+	isFile    bool
+	tree      tree
+}
+
+func NewGitRepository() *GitRepository {
+	return &GitRepository{}
+}
+
+func (g *GitRepository) parse(fullURL string) error {
+	remote, err := cautils.ParseGitRemote(fullURL)
+	if err != nil {
+		return err
+	}
+	g.remote = remote
+	return nil
+}
+
+func (g *GitRepository) getBranch() string     { return g.remote.Ref }
+func (g *GitRepository) getTree() tree         { return g.tree }
+func (g *GitRepository) setIsFile(isFile bool) { g.isFile = isFile }
+func (g *GitRepository) getIsFile() bool       { return g.isFile }
+
+// setBranch pins the clone to the requested ref, otherwise the default branch of the remote is used
+func (g *GitRepository) setBranch(branchOptional string) error {
+	if branchOptional != "" {
+		g.remote.Ref = branchOptional
+	}
+	return nil
+}
+
+func (g *GitRepository) setTree() error {
+	repoURL := g.remote.URL()
+	localPath, err := cautils.CloneGitRepo(&repoURL)
+	if err != nil {
+		return err
+	}
+	g.localPath = localPath
+
+	var thisTree tree
+	err = filepath.WalkDir(localPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(localPath, path)
+		if err != nil {
+			return err
+		}
+		thisTree.InnerTrees = append(thisTree.InnerTrees, innerTree{Path: filepath.ToSlash(relPath)})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list files of '%s', reason: %s", g.remote.CloneURL, err.Error())
+	}
+	g.tree = thisTree
+
+	return nil
+}
+
+// return a list of local paths in the cloned repository for the given extensions
+func (g *GitRepository) getFilesFromTree(filesExtensions []string) []string {
+	var files []string
+	for _, path := range g.tree.InnerTrees {
+		if slices.Contains(filesExtensions, getFileExtension(path.Path)) {
+			files = append(files, filepath.Join(g.localPath, filepath.FromSlash(path.Path)))
+		}
+	}
+	return files
+}
@@ -1,10 +1,17 @@
 package resourcehandler
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -222,3 +229,36 @@ func TestGetFilesFromTree(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRepositoryGeneric(t *testing.T) {
+	for _, u := range []string{
+		"https://gitea.example.com/org/repo.git",
+		"ssh://git@git.example.com/org/repo.git",
+		"file:///srv/git/repo.git",
+	} {
+		repo, err := getRepository(u)
+		assert.NoError(t, err, u)
+		assert.IsType(t, &GitRepository{}, repo, u)
+	}
+}
+
+func TestScanRepositoryGeneric(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	wt, err := gitRepo.Worktree()
+	require.NoError(t, err)
+	for _, name := range []string{"deploy/app.yaml", "README.md"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("kind: ConfigMap\n"), 0600))
+		_, err = wt.Add(name)
+		require.NoError(t, err)
+	}
+	_, err = wt.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	require.NoError(t, err)
+
+	files, err := ScanRepository("file://"+filepath.ToSlash(dir), "master")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.True(t, strings.HasSuffix(files[0], filepath.Join("deploy", "app.yaml")))
+}
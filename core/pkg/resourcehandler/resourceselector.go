@@ -0,0 +1,73 @@
+package resourcehandler
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// relatedResources are loaded regardless of the label and annotation selectors,
+// the controls of the selected workloads depend on them (e.g. RBAC controls on the workloads' service accounts)
+var relatedResources = map[string]bool{
+	"namespaces":          true,
+	"nodes":               true,
+	"serviceaccounts":     true,
+	"services":            true,
+	"networkpolicies":     true,
+	"roles":               true,
+	"rolebindings":        true,
+	"clusterroles":        true,
+	"clusterrolebindings": true,
+}
+
+// ResourceSelector narrows a cluster scan to the objects matching a label selector and an annotation selector.
+// The label selector is pushed down to the API server list calls, annotations are matched client-side since the API does not support them
+type ResourceSelector struct {
+	labels      k8slabels.Selector
+	annotations k8slabels.Selector
+}
+
+// NewResourceSelector parses the label and annotation selectors, both use the Kubernetes label selector syntax (e.g. "team=x,tier!=db")
+func NewResourceSelector(labelSelector, annotationSelector string) (*ResourceSelector, error) {
+	labels, err := k8slabels.Parse(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector '%s': %w", labelSelector, err)
+	}
+	annotations, err := k8slabels.Parse(annotationSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid annotation selector '%s': %w", annotationSelector, err)
+	}
+	return &ResourceSelector{labels: labels, annotations: annotations}, nil
+}
+
+func getResourceSelectorFromScanInfo(scanInfo *cautils.ScanInfo) (*ResourceSelector, error) {
+	return NewResourceSelector(scanInfo.LabelSelector, scanInfo.AnnotationSelector)
+}
+
+// GetLabelSelector returns the label selector of the list calls of a resource
+func (rs *ResourceSelector) GetLabelSelector(resource *schema.GroupVersionResource) string {
+	if rs == nil || relatedResources[resource.Resource] {
+		return ""
+	}
+	return rs.labels.String()
+}
+
+// MatchesAnnotations checks the annotations of an object listed with the label selector
+func (rs *ResourceSelector) MatchesAnnotations(resource *schema.GroupVersionResource, annotations map[string]string) bool {
+	if rs == nil || relatedResources[resource.Resource] {
+		return true
+	}
+	return rs.annotations.Matches(k8slabels.Set(annotations))
+}
+
+// Matches checks both selectors on an already loaded object
+func (rs *ResourceSelector) Matches(resource *schema.GroupVersionResource, obj workloadinterface.IMetadata) bool {
+	if rs == nil || relatedResources[resource.Resource] {
+		return true
+	}
+	workload := workloadinterface.NewWorkloadObj(obj.GetObject())
+	return rs.labels.Matches(k8slabels.Set(workload.GetLabels())) && rs.annotations.Matches(k8slabels.Set(workload.GetAnnotations()))
+}
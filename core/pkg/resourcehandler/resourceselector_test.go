@@ -0,0 +1,113 @@
+package resourcehandler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+var (
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	servicesGVR    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+)
+
+func mockSelectorObject(apiVersion, kind, name string, labels, annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace("default")
+	obj.SetLabels(labels)
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestNewResourceSelector(t *testing.T) {
+	_, err := NewResourceSelector("team=x,tier!=db", "owner in (team-x)")
+	assert.NoError(t, err)
+
+	_, err = NewResourceSelector("team==x==y", "")
+	assert.Error(t, err)
+
+	_, err = NewResourceSelector("", "owner in team-x")
+	assert.Error(t, err)
+}
+
+func TestResourceSelector(t *testing.T) {
+	rs, err := NewResourceSelector("team=x", "owner=platform")
+	require.NoError(t, err)
+
+	t.Run("label selector is pushed down for workloads only", func(t *testing.T) {
+		assert.Equal(t, "team=x", rs.GetLabelSelector(&deploymentsGVR))
+		assert.Equal(t, "", rs.GetLabelSelector(&servicesGVR))
+		assert.Equal(t, "", (*ResourceSelector)(nil).GetLabelSelector(&deploymentsGVR))
+	})
+
+	t.Run("annotations", func(t *testing.T) {
+		assert.True(t, rs.MatchesAnnotations(&deploymentsGVR, map[string]string{"owner": "platform"}))
+		assert.False(t, rs.MatchesAnnotations(&deploymentsGVR, map[string]string{"owner": "other"}))
+		assert.True(t, rs.MatchesAnnotations(&servicesGVR, nil))
+	})
+
+	t.Run("loaded objects", func(t *testing.T) {
+		matching := workloadinterface.NewWorkloadObj(mockSelectorObject("apps/v1", "Deployment", "a", map[string]string{"team": "x"}, map[string]string{"owner": "platform"}).Object)
+		otherTeam := workloadinterface.NewWorkloadObj(mockSelectorObject("apps/v1", "Deployment", "b", map[string]string{"team": "y"}, map[string]string{"owner": "platform"}).Object)
+		service := workloadinterface.NewWorkloadObj(mockSelectorObject("v1", "Service", "c", nil, nil).Object)
+
+		assert.True(t, rs.Matches(&deploymentsGVR, matching))
+		assert.False(t, rs.Matches(&deploymentsGVR, otherTeam))
+		assert.True(t, rs.Matches(&servicesGVR, service))
+	})
+}
+
+func TestPullSingleResourceWithResourceSelector(t *testing.T) {
+	dynamicClient := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			deploymentsGVR: "DeploymentList",
+			servicesGVR:    "ServiceList",
+		},
+		mockSelectorObject("apps/v1", "Deployment", "team-x", map[string]string{"team": "x"}, map[string]string{"owner": "platform"}),
+		mockSelectorObject("apps/v1", "Deployment", "team-x-unowned", map[string]string{"team": "x"}, nil),
+		mockSelectorObject("apps/v1", "Deployment", "team-y", map[string]string{"team": "y"}, map[string]string{"owner": "platform"}),
+		mockSelectorObject("v1", "Service", "frontend", nil, nil),
+	)
+	k8sHandler := &K8sResourceHandler{
+		k8s: &k8sinterface.KubernetesApi{
+			KubernetesClient: fakeclientset.NewSimpleClientset(),
+			DynamicClient:    dynamicClient,
+			Context:          context.Background(),
+		},
+	}
+
+	rs, err := NewResourceSelector("team=x", "owner=platform")
+	require.NoError(t, err)
+
+	deployments, err := k8sHandler.pullSingleResource(&deploymentsGVR, rs, "", &EmptySelector{})
+	require.NoError(t, err)
+	require.Len(t, deployments, 1)
+	assert.Equal(t, "team-x", deployments[0].GetName())
+
+	// related objects are not filtered
+	services, err := k8sHandler.pullSingleResource(&servicesGVR, rs, "", &EmptySelector{})
+	require.NoError(t, err)
+	assert.Len(t, services, 1)
+}
+
+func TestGetResourceSelectorFromScanInfo(t *testing.T) {
+	rs, err := getResourceSelectorFromScanInfo(&cautils.ScanInfo{LabelSelector: "team=x"})
+	require.NoError(t, err)
+	assert.Equal(t, "team=x", rs.GetLabelSelector(&deploymentsGVR))
+
+	_, err = getResourceSelectorFromScanInfo(&cautils.ScanInfo{AnnotationSelector: "!!"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package resourcehandler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/cloudsupport"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/metrics"
+	"github.com/kubescape/opa-utils/objectsenvelopes/hostsensor"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+var _ IResourceHandler = &SnapshotResourceHandler{}
+
+// SnapshotResourceHandler handles resources from an offline cluster snapshot (resources dump or archive).
+// Unlike FileResourceHandler, the snapshot is scanned as a cluster: the cluster-scope controls are used,
+// RBAC objects are aggregated and nodes are counted as worker nodes
+type SnapshotResourceHandler struct {
+	snapshot    *cautils.ClusterSnapshot
+	clusterName string
+}
+
+func NewSnapshotResourceHandler(snapshot *cautils.ClusterSnapshot, clusterName string) *SnapshotResourceHandler {
+	k8sinterface.InitializeMapResourcesMock() // initialize the resource map
+	return &SnapshotResourceHandler{
+		snapshot:    snapshot,
+		clusterName: clusterName,
+	}
+}
+
+func (snapshotHandler *SnapshotResourceHandler) GetResources(ctx context.Context, sessionObj *cautils.OPASessionObj, scanInfo *cautils.ScanInfo) (cautils.K8SResources, map[string]workloadinterface.IMetadata, cautils.ExternalResources, map[string]bool, error) {
+	logger.L().Start("Accessing cluster snapshot...", helpers.String("snapshot", snapshotHandler.snapshot.Path))
+
+	globalFieldSelectors := getFieldSelectorFromScanInfo(scanInfo)
+	resourceSelector, err := getResourceSelectorFromScanInfo(scanInfo)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// map all resources: map["/group/version/resource"][]<k8s workloads>
+	var hostResources []workloadinterface.IMetadata
+	mappedResources := map[string][]workloadinterface.IMetadata{}
+	for _, obj := range snapshotHandler.snapshot.ListResources() {
+		if hostsensor.IsTypeTypeHostSensor(obj.GetObject()) {
+			hostResources = append(hostResources, obj)
+			continue
+		}
+		addWorkloadsToResourcesMap(mappedResources, []workloadinterface.IMetadata{obj})
+	}
+
+	if sessionObj.SingleResourceScan, err = findScanObjectResource(mappedResources, scanInfo.ScanObject); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	scanningScope := cautils.GetScanningScope(sessionObj.Metadata.ContextMetadata)
+
+	resourceToControl := make(map[string][]string)
+	queryableResources, excludedRulesMap := getQueryableResourceMapFromPolicies(sessionObj.Policies, sessionObj.SingleResourceScan, scanningScope)
+	ksResourceMap := setKSResourceMap(sessionObj.Policies, resourceToControl)
+
+	// map of Kubescape resources to control_ids
+	sessionObj.ResourceToControlsMap = resourceToControl
+
+	// select the snapshot resources the same way they would have been pulled from the API server
+	k8sResourcesMap, allResources := selectResources(queryableResources, mappedResources, globalFieldSelectors, resourceSelector)
+
+	addSingleResourceToResourceMaps(k8sResourcesMap, allResources, sessionObj.SingleResourceScan)
+
+	metrics.UpdateKubernetesResourcesCount(ctx, int64(len(allResources)))
+	numberOfWorkerNodes := countWorkerNodes(snapshotHandler.getNodes(mappedResources))
+	sessionObj.SetNumberOfWorkerNodes(numberOfWorkerNodes)
+	metrics.UpdateWorkerNodesCount(ctx, int64(numberOfWorkerNodes))
+
+	logger.L().StopSuccess("Accessed cluster snapshot")
+
+	if hostResourcesKinds := cautils.MapHostResources(ksResourceMap); len(hostResourcesKinds) > 0 {
+		if len(hostResources) > 0 {
+			addHostResources(hostResources, allResources, ksResourceMap)
+		} else {
+			cautils.SetInfoMapForResources("Host scanner data is not included in the cluster snapshot", hostResourcesKinds, sessionObj.InfoMap)
+		}
+	}
+
+	// RBAC objects are aggregated from the snapshot, the same way they are collected from the API server
+	rbacObjects := cautils.NewRBACObjectsFromResources(snapshotHandler.snapshot.ListResources(), "", snapshotHandler.clusterName)
+	if allRbacResources, err := rbacObjects.ListAllResources(); err != nil {
+		logger.L().Ctx(ctx).Warning("failed to collect rbac resources", helpers.Error(err))
+	} else {
+		for k, v := range allRbacResources {
+			allResources[k] = v
+		}
+	}
+
+	setMapNamespaceToNumOfResources(ctx, allResources, sessionObj)
+
+	if cloudResources := cautils.MapCloudResources(ksResourceMap); len(cloudResources) > 0 {
+		if apiServerInfo := snapshotHandler.snapshot.Metadata.APIServerInfo; apiServerInfo != nil && cloudResourceRequired(cloudResources, string(cloudsupport.TypeApiServerInfo)) {
+			resource := cloudsupport.NewApiServerVersionInfo(apiServerInfo)
+			allResources[resource.GetID()] = resource
+			ksResourceMap[fmt.Sprintf("%s/%s", resource.GetApiVersion(), resource.GetKind())] = []string{resource.GetID()}
+			// the API server info is recorded in the snapshot, only the other cloud resources are skipped
+			cloudResources = slices.DeleteFunc(cloudResources, func(cloudResource string) bool {
+				return strings.Contains(cloudResource, string(cloudsupport.TypeApiServerInfo))
+			})
+		}
+		cautils.SetInfoMapForResources("Cloud provider data is not available when scanning a cluster snapshot", cloudResources, sessionObj.InfoMap)
+	}
+
+	return k8sResourcesMap, allResources, ksResourceMap, excludedRulesMap, nil
+}
+
+// GetCloudProvider returns an empty provider, cloud APIs are not queried when scanning a snapshot
+func (snapshotHandler *SnapshotResourceHandler) GetCloudProvider() string {
+	return ""
+}
+
+// GetClusterAPIServerInfo returns the API server version recorded in the snapshot metadata, if any
+func (snapshotHandler *SnapshotResourceHandler) GetClusterAPIServerInfo(_ context.Context) *version.Info {
+	return snapshotHandler.snapshot.Metadata.APIServerInfo
+}
+
+func (snapshotHandler *SnapshotResourceHandler) getNodes(mappedResources map[string][]workloadinterface.IMetadata) *v1.NodeList {
+	nodesList := &v1.NodeList{}
+	for _, obj := range mappedResources[k8sinterface.JoinResourceTriplets("", "v1", "nodes")] {
+		var node v1.Node
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.GetObject(), &node); err != nil {
+			logger.L().Debug("failed to convert node", helpers.String("name", obj.GetName()), helpers.Error(err))
+			continue
+		}
+		nodesList.Items = append(nodesList.Items, node)
+	}
+	return nodesList
+}
+
+// selectResources applies the queries, the namespaces selectors and the label and annotation selectors on already loaded resources
+func selectResources(queryableResources QueryableResources, mappedResources map[string][]workloadinterface.IMetadata, globalFieldSelectors IFieldSelector, resourceSelector *ResourceSelector) (cautils.K8SResources, map[string]workloadinterface.IMetadata) {
+	k8sResources := queryableResources.ToK8sResourceMap()
+	allResources := map[string]workloadinterface.IMetadata{}
+
+	for _, qr := range queryableResources {
+		apiGroup, apiVersion, resource := k8sinterface.StringToResourceGroup(qr.GroupVersionResourceTriplet)
+		gvr := schema.GroupVersionResource{Group: apiGroup, Version: apiVersion, Resource: resource}
+
+		var ids []string
+		for _, obj := range mappedResources[qr.GroupVersionResourceTriplet] {
+			if k8sinterface.IsTypeWorkload(obj.GetObject()) && k8sinterface.WorkloadHasParent(workloadinterface.NewWorkloadObj(obj.GetObject())) {
+				continue
+			}
+			if _, ok := allResources[obj.GetID()]; ok {
+				continue
+			}
+			if !resourceSelector.Matches(&gvr, obj) {
+				continue
+			}
+			for _, namespaceSelector := range globalFieldSelectors.GetNamespacesSelectors(&gvr) {
+				if matchFieldSelector(obj, combineFieldSelectors(namespaceSelector, qr.FieldSelectors)) {
+					allResources[obj.GetID()] = obj
+					ids = append(ids, obj.GetID())
+					break
+				}
+			}
+		}
+		k8sResources[qr.GroupVersionResourceTriplet] = append(k8sResources[qr.GroupVersionResourceTriplet], ids...)
+	}
+
+	return k8sResources, allResources
+}
+
+// addHostResources adds the host scanner data stored in the snapshot to the external resources
+func addHostResources(hostResources []workloadinterface.IMetadata, allResources map[string]workloadinterface.IMetadata, externalResourceMap cautils.ExternalResources) {
+	for _, hostResource := range hostResources {
+		g, v := getGroupNVersion(hostResource.GetApiVersion())
+		groupResource := k8sinterface.JoinResourceTriplets(g, v, hostResource.GetKind())
+		allResources[hostResource.GetID()] = hostResource
+		externalResourceMap[groupResource] = append(externalResourceMap[groupResource], hostResource.GetID())
+	}
+}
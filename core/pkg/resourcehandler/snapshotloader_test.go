@@ -0,0 +1,163 @@
+package resourcehandler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	reportv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func mockSnapshot() *cautils.ClusterSnapshot {
+	obj := func(apiVersion, kind, namespace, name string, extra map[string]interface{}) workloadinterface.IMetadata {
+		metadata := map[string]interface{}{"name": name}
+		if namespace != "" {
+			metadata["namespace"] = namespace
+		}
+		o := map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "metadata": metadata}
+		for k, v := range extra {
+			o[k] = v
+		}
+		return workloadinterface.NewWorkloadObj(o)
+	}
+	return &cautils.ClusterSnapshot{
+		Path: "/tmp/cluster-dump",
+		Metadata: cautils.SnapshotMetadata{
+			ClusterName:   "prod",
+			APIServerInfo: &version.Info{GitVersion: "v1.29.0"},
+		},
+		Resources: map[string][]workloadinterface.IMetadata{
+			"default.yaml": {
+				obj("apps/v1", "Deployment", "default", "nginx", nil),
+				obj("v1", "Namespace", "", "default", nil),
+			},
+			"kube-system.yaml": {
+				workloadinterface.NewWorkloadObj(map[string]interface{}{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]interface{}{
+						"name":      "coredns",
+						"namespace": "kube-system",
+						"labels":    map[string]interface{}{"k8s-app": "kube-dns"},
+					},
+				}),
+				obj("v1", "Namespace", "", "kube-system", nil),
+			},
+			"nodes.yaml": {
+				obj("v1", "Node", "", "worker", nil),
+				obj("v1", "Node", "", "control-plane", map[string]interface{}{
+					"spec": map[string]interface{}{
+						"taints": []interface{}{
+							map[string]interface{}{"key": "node-role.kubernetes.io/control-plane", "effect": "NoSchedule"},
+						},
+					},
+				}),
+			},
+		},
+	}
+}
+
+func mockSnapshotSession() *cautils.OPASessionObj {
+	sessionObj := cautils.NewOPASessionObjMock()
+	sessionObj.InfoMap = map[string]apis.StatusInfo{}
+	sessionObj.Metadata.ContextMetadata = reportv2.ContextMetadata{ClusterContextMetadata: &reportv2.ClusterMetadata{ContextName: "prod"}}
+	sessionObj.Policies = []reporthandling.Framework{
+		*mockFramework("fw", []reporthandling.Control{
+			mockControl("1", []reporthandling.PolicyRule{
+				mockRule("rule-a", []reporthandling.RuleMatchObjects{mockMatch(2), mockMatch(6), mockMatch(7)}, ""),
+			}),
+		}),
+	}
+	return sessionObj
+}
+
+func TestSnapshotResourceHandler_GetResources(t *testing.T) {
+	handler := NewSnapshotResourceHandler(mockSnapshot(), "prod")
+
+	t.Run("all namespaces", func(t *testing.T) {
+		sessionObj := mockSnapshotSession()
+		k8sResources, allResources, _, _, err := handler.GetResources(context.TODO(), sessionObj, &cautils.ScanInfo{})
+		require.NoError(t, err)
+
+		assert.Len(t, k8sResources["apps/v1/deployments"], 2)
+		assert.Len(t, k8sResources["/v1/namespaces"], 2)
+		assert.Len(t, k8sResources["/v1/nodes"], 2)
+		assert.Contains(t, allResources, "apps/v1/default/Deployment/nginx")
+		assert.Equal(t, 1, sessionObj.Metadata.ContextMetadata.ClusterContextMetadata.NumberOfWorkerNodes)
+	})
+
+	t.Run("excluded namespaces", func(t *testing.T) {
+		sessionObj := mockSnapshotSession()
+		k8sResources, allResources, _, _, err := handler.GetResources(context.TODO(), sessionObj, &cautils.ScanInfo{ExcludedNamespaces: "kube-system"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"apps/v1/default/Deployment/nginx"}, k8sResources["apps/v1/deployments"])
+		assert.Equal(t, []string{"/v1//Namespace/default"}, k8sResources["/v1/namespaces"])
+		assert.NotContains(t, allResources, "apps/v1/kube-system/Deployment/coredns")
+	})
+
+	t.Run("label selector", func(t *testing.T) {
+		sessionObj := mockSnapshotSession()
+		k8sResources, _, _, _, err := handler.GetResources(context.TODO(), sessionObj, &cautils.ScanInfo{LabelSelector: "k8s-app=kube-dns"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"apps/v1/kube-system/Deployment/coredns"}, k8sResources["apps/v1/deployments"])
+		assert.Len(t, k8sResources["/v1/namespaces"], 2) // related objects are not filtered
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		_, _, _, _, err := handler.GetResources(context.TODO(), mockSnapshotSession(), &cautils.ScanInfo{AnnotationSelector: "!!"})
+		assert.Error(t, err)
+	})
+}
+
+func TestSnapshotResourceHandler_GetClusterAPIServerInfo(t *testing.T) {
+	handler := NewSnapshotResourceHandler(mockSnapshot(), "prod")
+	assert.Equal(t, "v1.29.0", handler.GetClusterAPIServerInfo(context.TODO()).GitVersion)
+	assert.Equal(t, "", handler.GetCloudProvider())
+}
+
+func TestSnapshotResourceHandler_GetResourcesCloud(t *testing.T) {
+	rule := mockRule("rule-a", nil, "")
+	rule.DynamicMatch = []reporthandling.RuleMatchObjects{
+		{APIGroups: []string{"apiserverinfo.kubescape.cloud"}, APIVersions: []string{"v1beta0"}, Resources: []string{"APIServerInfo"}},
+		{APIGroups: []string{"container.googleapis.com"}, APIVersions: []string{"v1"}, Resources: []string{"ClusterDescribe"}},
+	}
+	mockSession := func() *cautils.OPASessionObj {
+		sessionObj := mockSnapshotSession()
+		sessionObj.Policies = []reporthandling.Framework{
+			*mockFramework("fw", []reporthandling.Control{mockControl("1", []reporthandling.PolicyRule{rule})}),
+		}
+		return sessionObj
+	}
+
+	t.Run("api server info in the snapshot", func(t *testing.T) {
+		sessionObj := mockSession()
+		handler := NewSnapshotResourceHandler(mockSnapshot(), "prod")
+		_, allResources, ksResources, _, err := handler.GetResources(context.TODO(), sessionObj, &cautils.ScanInfo{})
+		require.NoError(t, err)
+
+		apiServerInfoID := "apiserverinfo.kubescape.cloud/v1beta0/APIServerInfo/version"
+		assert.Contains(t, allResources, apiServerInfoID)
+		assert.Equal(t, []string{apiServerInfoID}, ksResources["apiserverinfo.kubescape.cloud/v1beta0/APIServerInfo"])
+		assert.NotContains(t, sessionObj.InfoMap, "apiserverinfo.kubescape.cloud/v1beta0/APIServerInfo")
+		assert.Equal(t, apis.StatusSkipped, sessionObj.InfoMap["container.googleapis.com/v1/ClusterDescribe"].InnerStatus)
+	})
+
+	t.Run("no api server info in the snapshot", func(t *testing.T) {
+		snapshot := mockSnapshot()
+		snapshot.Metadata.APIServerInfo = nil
+		sessionObj := mockSession()
+		_, _, _, _, err := NewSnapshotResourceHandler(snapshot, "prod").GetResources(context.TODO(), sessionObj, &cautils.ScanInfo{})
+		require.NoError(t, err)
+
+		assert.Equal(t, apis.StatusSkipped, sessionObj.InfoMap["apiserverinfo.kubescape.cloud/v1beta0/APIServerInfo"].InnerStatus)
+		assert.Equal(t, apis.StatusSkipped, sessionObj.InfoMap["container.googleapis.com/v1/ClusterDescribe"].InnerStatus)
+	})
+}
@@ -0,0 +1,18 @@
+package resultshandling
+
+import (
+	"context"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	printerv2 "github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer/v2"
+)
+
+// HandleMultiClusterResults prints the combined report of a multi-cluster scan in all the requested formats
+func HandleMultiClusterResults(ctx context.Context, report *cautils.MultiClusterReport, scanInfo *cautils.ScanInfo) error {
+	for _, format := range scanInfo.Formats() {
+		if err := printerv2.PrintMultiClusterReport(ctx, format, scanInfo.Output, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
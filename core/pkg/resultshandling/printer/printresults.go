@@ -21,6 +21,15 @@ const (
 	PdfFormat         string = "pdf"
 	HtmlFormat        string = "html"
 	SARIFFormat       string = "sarif"
+	MarkdownFormat    string = "markdown"
+	// spreadsheet formats, with a row for each control tested on each resource
+	CSVFormat  string = "csv"
+	XLSXFormat string = "xlsx"
+	// OTLPFormat exports the results to an OpenTelemetry collector
+	OTLPFormat string = "otlp"
+	// GitLab formats, shown in the merge requests of GitLab
+	GitLabCodeQualityFormat string = "gitlab-codequality"
+	GitLabSASTFormat        string = "gitlab-sast"
 )
 
 type IPrinter interface {
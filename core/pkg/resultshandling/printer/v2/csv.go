@@ -0,0 +1,111 @@
+package printer
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
+)
+
+const (
+	csvOutputFile = "report"
+	csvOutputExt  = ".csv"
+
+	// csvControlsFileSuffix is the suffix of the name of the file of the control summaries, next to the output file
+	csvControlsFileSuffix = "-controls"
+)
+
+var _ printer.IPrinter = &CSVPrinter{}
+
+// CSVPrinter prints a row for each control tested on each resource, and the summaries of the controls in a companion
+// file. When printing to the standard output, the summaries follow the rows after an empty line
+type CSVPrinter struct {
+	writer      *os.File
+	viewType    cautils.ViewTypes
+	clusterName string
+}
+
+func NewCSVPrinter(viewType cautils.ViewTypes, clusterName string) *CSVPrinter {
+	return &CSVPrinter{
+		viewType:    viewType,
+		clusterName: clusterName,
+	}
+}
+
+func (cp *CSVPrinter) SetWriter(ctx context.Context, outputFile string) {
+	if outputFile != "" {
+		if strings.TrimSpace(outputFile) == "" {
+			outputFile = csvOutputFile
+		}
+		if filepath.Ext(strings.TrimSpace(outputFile)) != csvOutputExt {
+			outputFile = outputFile + csvOutputExt
+		}
+	}
+	cp.writer = printer.GetWriter(ctx, outputFile)
+}
+
+func (cp *CSVPrinter) Score(score float32) {
+}
+
+func (cp *CSVPrinter) PrintNextSteps() {
+
+}
+
+func (cp *CSVPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OPASessionObj, imageScanData []cautils.ImageScanData) {
+	if opaSessionObj == nil {
+		logger.L().Ctx(ctx).Error("failed to print results, missing data")
+		return
+	}
+
+	withRawResources := !opaSessionObj.OmitRawResources
+	records := [][]string{resourceControlsHeader(withRawResources)}
+	for _, row := range listResourceControlRows(opaSessionObj, cp.viewType, cp.clusterName) {
+		records = append(records, row.values(withRawResources))
+	}
+	if err := writeCSV(cp.writer, records); err != nil {
+		logger.L().Ctx(ctx).Error("failed to write results in csv format", helpers.Error(err))
+		return
+	}
+
+	controlsWriter := cp.writer
+	if cp.writer != os.Stdout {
+		controlsWriter = printer.GetWriter(ctx, csvControlsFileName(cp.writer.Name()))
+		if controlsWriter != os.Stdout {
+			defer controlsWriter.Close()
+		}
+	}
+	if controlsWriter == os.Stdout {
+		// separate the control summaries from the rows of the resources
+		if _, err := io.WriteString(controlsWriter, "\n"); err != nil {
+			logger.L().Ctx(ctx).Error("failed to write results in csv format", helpers.Error(err))
+			return
+		}
+	}
+	if err := writeCSV(controlsWriter, append([][]string{controlSummariesHeader()}, listControlSummaryRows(opaSessionObj)...)); err != nil {
+		logger.L().Ctx(ctx).Error("failed to write control summaries in csv format", helpers.Error(err))
+		return
+	}
+
+	printer.LogOutputFile(cp.writer.Name())
+	printer.LogOutputFile(controlsWriter.Name())
+}
+
+// csvControlsFileName returns the name of the file of the control summaries, e.g. report-controls.csv for report.csv
+func csvControlsFileName(outputFile string) string {
+	return strings.TrimSuffix(outputFile, csvOutputExt) + csvControlsFileSuffix + csvOutputExt
+}
+
+func writeCSV(w io.Writer, records [][]string) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.WriteAll(records); err != nil {
+		return err
+	}
+	return csvWriter.Error()
+}
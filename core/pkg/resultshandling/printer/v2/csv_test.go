@@ -0,0 +1,68 @@
+package printer
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readCSVFile(t *testing.T, name string) [][]string {
+	t.Helper()
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestCSVPrinterSetWriter(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name       string
+		outputFile string
+		want       string
+	}{
+		{name: "adds the extension", outputFile: filepath.Join(dir, "results"), want: filepath.Join(dir, "results.csv")},
+		{name: "keeps the extension", outputFile: filepath.Join(dir, "results.csv"), want: filepath.Join(dir, "results.csv")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := NewCSVPrinter(cautils.SecurityViewType, "")
+			cp.SetWriter(context.Background(), tt.outputFile)
+			defer cp.writer.Close()
+			assert.Equal(t, tt.want, cp.writer.Name())
+		})
+	}
+}
+
+func TestCSVPrinterActionPrint(t *testing.T) {
+	sessionObj := newSpreadsheetTestSession(t)
+	sessionObj.OmitRawResources = true
+	outputFile := filepath.Join(t.TempDir(), "results.csv")
+
+	cp := NewCSVPrinter(cautils.ControlViewType, "my-cluster")
+	cp.SetWriter(context.Background(), outputFile)
+	cp.ActionPrint(context.Background(), sessionObj, nil)
+	require.NoError(t, cp.writer.Close())
+
+	findings := readCSVFile(t, outputFile)
+	require.Len(t, findings, 6)
+	assert.Equal(t, resourceControlsHeader(false), findings[0])
+	assert.Equal(t, []string{"my-cluster", "", "ClusterRole", "admin|all", "C-0035", "Administrative Roles", "Critical", "failed", "", "false", ""}, findings[1])
+
+	controls := readCSVFile(t, csvControlsFileName(outputFile))
+	require.Len(t, controls, 6)
+	assert.Equal(t, controlSummariesHeader(), controls[0])
+	assert.Equal(t, "C-0035", controls[1][0])
+}
+
+func TestCSVControlsFileName(t *testing.T) {
+	assert.Equal(t, "out/results-controls.csv", csvControlsFileName("out/results.csv"))
+}
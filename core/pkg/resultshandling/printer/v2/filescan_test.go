@@ -0,0 +1,107 @@
+package printer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/objectsenvelopes/localworkload"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/require"
+)
+
+const testDeploymentFile = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+        securityContext:
+          allowPrivilegeEscalation: true
+`
+
+func newTestFailedControl(controlID string, fixPaths ...armotypes.FixPath) resourcesresults.ResourceAssociatedControl {
+	rule := resourcesresults.ResourceAssociatedRule{Name: "rule", Status: apis.StatusFailed}
+	for _, fixPath := range fixPaths {
+		rule.Paths = append(rule.Paths, armotypes.PosturePaths{FixPath: fixPath})
+	}
+	control := resourcesresults.ResourceAssociatedControl{ControlID: controlID, Name: controlID, ResourceAssociatedRules: []resourcesresults.ResourceAssociatedRule{rule}}
+	control.SetStatus(reporthandling.Control{})
+	return control
+}
+
+func newTestControlSummary(controlID, name string, scoreFactor float32, resourceIDs ...string) reportsummary.ControlSummary {
+	control := reportsummary.ControlSummary{ControlID: controlID, Name: name, ScoreFactor: scoreFactor}
+	control.Append(&apis.StatusInfo{InnerStatus: apis.StatusFailed}, resourceIDs...)
+	control.SetStatus(&apis.StatusInfo{InnerStatus: apis.StatusFailed})
+	return control
+}
+
+// newFileScanTestSession returns the session of a scan of a directory, in which a deployment of a file fails two
+// controls and a cluster role without file fails another one
+func newFileScanTestSession(t *testing.T) *cautils.OPASessionObj {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(testDeploymentFile), 0600))
+
+	deployment := localworkload.NewLocalWorkload(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+	})
+	deployment.SetPath("deployment.yaml:0")
+	clusterRole := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   map[string]interface{}{"name": "admin|all"},
+	})
+
+	sessionObj := &cautils.OPASessionObj{
+		Report: &reporthandlingv2.PostureReport{},
+		AllResources: map[string]workloadinterface.IMetadata{
+			deployment.GetID():  deployment,
+			clusterRole.GetID(): clusterRole,
+		},
+		ResourceSource: map[string]reporthandling.Source{
+			deployment.GetID(): {RelativePath: "deployment.yaml"},
+		},
+		ResourcesResult: map[string]resourcesresults.Result{
+			deployment.GetID(): {
+				ResourceID: deployment.GetID(),
+				AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+					newTestFailedControl("C-0016", armotypes.FixPath{Path: "spec.template.spec.containers[0].securityContext.allowPrivilegeEscalation", Value: "false"}),
+					newTestFailedControl("C-0017"),
+				},
+			},
+			clusterRole.GetID(): {
+				ResourceID:         clusterRole.GetID(),
+				AssociatedControls: []resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0035")},
+			},
+		},
+		Metadata: &reporthandlingv2.Metadata{},
+	}
+	sessionObj.Metadata.ScanMetadata.ScanningTarget = reporthandlingv2.Directory
+	sessionObj.Metadata.ContextMetadata.DirectoryContextMetadata = &reporthandlingv2.DirectoryContextMetadata{BasePath: dir}
+
+	summaryDetails := &sessionObj.Report.SummaryDetails
+	summaryDetails.ComplianceScore = 78.5
+	summaryDetails.StatusCounters = reportsummary.StatusCounters{FailedResources: 2, PassedResources: 3}
+	summaryDetails.Controls = reportsummary.ControlSummaries{
+		"C-0016": newTestControlSummary("C-0016", "Allow privilege escalation", 7, deployment.GetID()),
+		"C-0017": newTestControlSummary("C-0017", "Immutable container filesystem", 4, deployment.GetID()),
+		"C-0035": newTestControlSummary("C-0035", "Administrative Roles", 9, clusterRole.GetID()),
+	}
+	return sessionObj
+}
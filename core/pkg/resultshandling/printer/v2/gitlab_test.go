@@ -0,0 +1,174 @@
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anchore/grype/grype/presenter/models"
+	"github.com/google/uuid"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/objectsenvelopes/localworkload"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabCodeQualityIssues(t *testing.T) {
+	issues := gitLabCodeQualityIssues(newFileScanTestSession(t))
+
+	// the cluster role is not read from a file
+	require.Len(t, issues, 2)
+	assert.Equal(t, "C-0017", issues[0].CheckName)
+	assert.Equal(t, "major", issues[0].Severity)
+	assert.Equal(t, GitLabCodeQualityLocation{Path: "deployment.yaml", Lines: GitLabCodeQualityLines{Begin: 1}}, issues[0].Location)
+
+	assert.Equal(t, "C-0016", issues[1].CheckName)
+	assert.Equal(t, "Allow privilege escalation: Deployment default/nginx", issues[1].Description)
+	assert.Equal(t, "critical", issues[1].Severity)
+	assert.Equal(t, GitLabCodeQualityLocation{Path: "deployment.yaml", Lines: GitLabCodeQualityLines{Begin: 13}}, issues[1].Location)
+	assert.Contains(t, issues[1].Content.Body, cautils.GetControlLink("C-0016"))
+
+	// the fingerprints are stable across scans, and do not depend on the line of the finding
+	assert.NotEqual(t, issues[0].Fingerprint, issues[1].Fingerprint)
+	assert.Equal(t, issues, gitLabCodeQualityIssues(newFileScanTestSession(t)))
+}
+
+func TestGitLabCodeQualityHelmChart(t *testing.T) {
+	sessionObj := newFileScanTestSession(t)
+	sessionObj.TemplateMapping = make(map[string]cautils.MappingNodes)
+	for resourceID, source := range sessionObj.ResourceSource {
+		source.RelativePath = "chart/Chart.yaml"
+		sessionObj.ResourceSource[resourceID] = source
+		sessionObj.TemplateMapping[resourceID] = cautils.MappingNodes{Nodes: []map[string]cautils.MappingNode{
+			{"spec.template.spec.containers[0].securityContext.allowPrivilegeEscalation": {TemplateLineNumber: 20}},
+			{"spec.template.spec.containers[0].securityContext": {TemplateLineNumber: 7}},
+		}}
+	}
+
+	issues := gitLabCodeQualityIssues(sessionObj)
+
+	// a finding for each template of the resource
+	lines := make(map[string][]int)
+	for _, issue := range issues {
+		assert.Equal(t, "chart/Chart.yaml", issue.Location.Path)
+		lines[issue.CheckName] = append(lines[issue.CheckName], issue.Location.Lines.Begin)
+	}
+	assert.Equal(t, map[string][]int{"C-0016": {7, 20}, "C-0017": {1, 1}}, lines)
+}
+
+func TestGitLabSASTReport(t *testing.T) {
+	endTime := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	sessionObj := newFileScanTestSession(t)
+	sessionObj.Report.ReportGenerationTime = endTime.Add(-time.Minute)
+
+	report := gitLabSASTReport(sessionObj, endTime)
+
+	assert.Equal(t, gitLabSecureReportVersion, report.Version)
+	assert.Equal(t, gitLabScanTypeSAST, report.Scan.Type)
+	assert.Equal(t, "2026-10-19T12:29:00", report.Scan.StartTime)
+	assert.Equal(t, "2026-10-19T12:30:00", report.Scan.EndTime)
+	require.Len(t, report.Vulnerabilities, 2)
+
+	vulnerability := report.Vulnerabilities[1]
+	assert.Equal(t, "High", vulnerability.Severity)
+	assert.Equal(t, GitLabLocation{File: "deployment.yaml", StartLine: 13}, vulnerability.Location)
+	assert.Equal(t, "C-0016", vulnerability.Identifiers[0].Value)
+	assert.Equal(t, cautils.GetControlLink("C-0016"), vulnerability.Identifiers[0].URL)
+	_, err := uuid.Parse(vulnerability.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, vulnerability.ID, gitLabSASTReport(newFileScanTestSession(t), endTime).Vulnerabilities[1].ID)
+}
+
+func TestGitLabContainerScanningReport(t *testing.T) {
+	newDocument := func(version string) models.Document {
+		doc := models.Document{Matches: []models.Match{{
+			Vulnerability: models.Vulnerability{
+				VulnerabilityMetadata: models.VulnerabilityMetadata{ID: "CVE-2024-0001", Severity: "High", URLs: []string{"https://example.com/CVE-2024-0001"}},
+				Fix:                   models.Fix{State: "fixed", Versions: []string{"3.0.14"}},
+			},
+			Artifact: models.Package{Name: "openssl", Version: "3.0.11"},
+		}, {
+			Vulnerability: models.Vulnerability{
+				VulnerabilityMetadata: models.VulnerabilityMetadata{ID: "GHSA-xxxx", Severity: "Negligible"},
+			},
+			Artifact: models.Package{Name: "zlib", Version: "1.2.13"},
+		}}}
+		doc.Distro.Name = "debian"
+		doc.Distro.Version = "12"
+		return doc
+	}
+
+	report := gitLabContainerScanningReport(map[string]models.Document{"nginx:1.27": newDocument("1.27")}, time.Now())
+
+	assert.Equal(t, gitLabScanTypeContainerScanning, report.Scan.Type)
+	require.Len(t, report.Vulnerabilities, 2)
+	vulnerability := report.Vulnerabilities[0]
+	assert.Equal(t, "High", vulnerability.Severity)
+	assert.Equal(t, "Upgrade openssl to version 3.0.14", vulnerability.Solution)
+	assert.Equal(t, GitLabIdentifier{Type: "cve", Name: "CVE-2024-0001", Value: "CVE-2024-0001", URL: "https://example.com/CVE-2024-0001"}, vulnerability.Identifiers[0])
+	assert.Equal(t, GitLabLocation{
+		Dependency:      &GitLabDependency{Package: GitLabPackage{Name: "openssl"}, Version: "3.0.11"},
+		OperatingSystem: "debian:12",
+		Image:           "nginx:1.27",
+	}, vulnerability.Location)
+
+	assert.Equal(t, "Info", report.Vulnerabilities[1].Severity)
+	assert.Equal(t, "vulnerability_id", report.Vulnerabilities[1].Identifiers[0].Type)
+	assert.Empty(t, report.Vulnerabilities[1].Solution)
+
+	// the vulnerabilities of the image are tracked across its versions
+	newReport := gitLabContainerScanningReport(map[string]models.Document{"nginx:1.28": newDocument("1.28")}, time.Now())
+	assert.Equal(t, vulnerability.ID, newReport.Vulnerabilities[0].ID)
+}
+
+func TestImageRepository(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{image: "nginx", expected: "nginx"},
+		{image: "nginx:1.27", expected: "nginx"},
+		{image: "registry.example.com:5000/team/app", expected: "registry.example.com:5000/team/app"},
+		{image: "registry.example.com:5000/team/app:v2", expected: "registry.example.com:5000/team/app"},
+		{image: "nginx@sha256:0123", expected: "nginx"},
+		{image: "nginx:1.27@sha256:0123", expected: "nginx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.expected, imageRepository(tt.image))
+		})
+	}
+}
+
+func TestGitLabSASTPrinterActionPrint(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "gl-sast-report")
+	gp := NewGitLabSASTPrinter()
+	gp.SetWriter(context.TODO(), outputFile)
+	gp.ActionPrint(context.TODO(), newFileScanTestSession(t), nil)
+	require.NoError(t, gp.writer.Close())
+
+	content, err := os.ReadFile(outputFile + gitLabSASTOutputExt)
+	require.NoError(t, err)
+	var report GitLabSecureReport
+	require.NoError(t, json.Unmarshal(content, &report))
+	assert.Len(t, report.Vulnerabilities, 2)
+}
+
+func TestListConfigurationFindingsSkipsResourcesWithoutFile(t *testing.T) {
+	sessionObj := newFileScanTestSession(t)
+	pod := localworkload.NewLocalWorkload(map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "other"}})
+	sessionObj.AllResources[pod.GetID()] = workloadinterface.IMetadata(pod)
+	sessionObj.ResourcesResult[pod.GetID()] = resourcesresults.Result{
+		ResourceID:         pod.GetID(),
+		AssociatedControls: []resourcesresults.ResourceAssociatedControl{newTestFailedControl("C-0016")},
+	}
+	sessionObj.ResourceSource[pod.GetID()] = reporthandling.Source{}
+
+	assert.Len(t, listConfigurationFindings(sessionObj), 2)
+}
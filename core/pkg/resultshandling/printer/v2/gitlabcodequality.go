@@ -0,0 +1,139 @@
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+const (
+	gitLabCodeQualityOutputFile = "gl-code-quality-report"
+	gitLabCodeQualityOutputExt  = ".json"
+)
+
+// https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool
+
+// GitLabCodeQualityIssue is an issue of a GitLab Code Quality report
+type GitLabCodeQualityIssue struct {
+	Description string                    `json:"description"`
+	CheckName   string                    `json:"check_name"`
+	Fingerprint string                    `json:"fingerprint"`
+	Severity    string                    `json:"severity"`
+	Categories  []string                  `json:"categories,omitempty"`
+	Content     *GitLabCodeQualityContent `json:"content,omitempty"`
+	Location    GitLabCodeQualityLocation `json:"location"`
+}
+
+type GitLabCodeQualityContent struct {
+	Body string `json:"body"`
+}
+
+type GitLabCodeQualityLocation struct {
+	Path  string                 `json:"path"`
+	Lines GitLabCodeQualityLines `json:"lines"`
+}
+
+type GitLabCodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+var _ printer.IPrinter = &GitLabCodeQualityPrinter{}
+
+// GitLabCodeQualityPrinter prints the failed controls of the scanned files as a GitLab Code Quality report, which GitLab
+// shows in merge requests
+type GitLabCodeQualityPrinter struct {
+	writer *os.File
+}
+
+func NewGitLabCodeQualityPrinter() *GitLabCodeQualityPrinter {
+	return &GitLabCodeQualityPrinter{}
+}
+
+func (gp *GitLabCodeQualityPrinter) SetWriter(ctx context.Context, outputFile string) {
+	if outputFile != "" {
+		if strings.TrimSpace(outputFile) == "" {
+			outputFile = gitLabCodeQualityOutputFile
+		}
+		if filepath.Ext(strings.TrimSpace(outputFile)) != gitLabCodeQualityOutputExt {
+			outputFile = outputFile + gitLabCodeQualityOutputExt
+		}
+	}
+	gp.writer = printer.GetWriter(ctx, outputFile)
+}
+
+func (gp *GitLabCodeQualityPrinter) Score(score float32) {
+}
+
+func (gp *GitLabCodeQualityPrinter) PrintNextSteps() {
+
+}
+
+func (gp *GitLabCodeQualityPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OPASessionObj, imageScanData []cautils.ImageScanData) {
+	if opaSessionObj == nil {
+		logger.L().Ctx(ctx).Error("failed to write results in gitlab code quality format: no configuration scan data provided")
+		return
+	}
+
+	content, err := json.MarshalIndent(gitLabCodeQualityIssues(opaSessionObj), "", "  ")
+	if err != nil {
+		logger.L().Ctx(ctx).Error("failed to write results in gitlab code quality format", helpers.Error(err))
+		return
+	}
+	if _, err := gp.writer.Write(content); err != nil {
+		logger.L().Ctx(ctx).Error("failed to write results in gitlab code quality format", helpers.Error(err))
+		return
+	}
+	printer.LogOutputFile(gp.writer.Name())
+}
+
+func gitLabCodeQualityIssues(opaSessionObj *cautils.OPASessionObj) []GitLabCodeQualityIssue {
+	findings := listConfigurationFindings(opaSessionObj)
+
+	issues := make([]GitLabCodeQualityIssue, 0, len(findings))
+	for i := range findings {
+		finding := &findings[i]
+		body := fmt.Sprintf("%s\n\nMore details: %s", finding.control.GetDescription(), cautils.GetControlLink(finding.control.GetID()))
+		if remediation := finding.control.GetRemediation(); remediation != "" {
+			body = fmt.Sprintf("%s\n\nRemediation: %s", body, remediation)
+		}
+
+		issues = append(issues, GitLabCodeQualityIssue{
+			Description: finding.description(),
+			CheckName:   finding.control.GetID(),
+			Fingerprint: finding.fingerprint,
+			Severity:    scoreFactorToGitLabCodeQualitySeverity(finding.control.GetScoreFactor()),
+			Categories:  []string{"Security"},
+			Content:     &GitLabCodeQualityContent{Body: strings.TrimSpace(body)},
+			Location: GitLabCodeQualityLocation{
+				Path:  finding.filePath,
+				Lines: GitLabCodeQualityLines{Begin: finding.location.Line},
+			},
+		})
+	}
+	return issues
+}
+
+// scoreFactorToGitLabCodeQualitySeverity returns the Code Quality severity of the severity of a control
+func scoreFactorToGitLabCodeQualitySeverity(scoreFactor float32) string {
+	switch apis.ControlSeverityToString(scoreFactor) {
+	case apis.SeverityCriticalString:
+		return "blocker"
+	case apis.SeverityHighString:
+		return "critical"
+	case apis.SeverityMediumString:
+		return "major"
+	case apis.SeverityLowString:
+		return "minor"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,301 @@
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anchore/grype/grype/presenter/models"
+	"github.com/google/uuid"
+	"github.com/kubescape/backend/pkg/versioncheck"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+const (
+	gitLabSASTOutputFile = "gl-sast-report"
+	gitLabSASTOutputExt  = ".json"
+
+	// gitLabSecureReportVersion is the version of the schemas of the GitLab security reports
+	gitLabSecureReportVersion = "15.0.7"
+	gitLabSecureTimeLayout    = "2006-01-02T15:04:05"
+
+	gitLabScanTypeSAST              = "sast"
+	gitLabScanTypeContainerScanning = "container_scanning"
+)
+
+// https://gitlab.com/gitlab-org/security-products/security-report-schemas
+
+// GitLabSecureReport is a GitLab security report, of the SAST or container scanning schema
+type GitLabSecureReport struct {
+	Version         string                `json:"version"`
+	Scan            GitLabScan            `json:"scan"`
+	Vulnerabilities []GitLabVulnerability `json:"vulnerabilities"`
+}
+
+type GitLabScan struct {
+	Analyzer  GitLabScanner `json:"analyzer"`
+	Scanner   GitLabScanner `json:"scanner"`
+	Type      string        `json:"type"`
+	StartTime string        `json:"start_time"`
+	EndTime   string        `json:"end_time"`
+	Status    string        `json:"status"`
+}
+
+type GitLabScanner struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	URL     string       `json:"url,omitempty"`
+	Version string       `json:"version"`
+	Vendor  GitLabVendor `json:"vendor"`
+}
+
+type GitLabVendor struct {
+	Name string `json:"name"`
+}
+
+type GitLabVulnerability struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Severity    string             `json:"severity"`
+	Solution    string             `json:"solution,omitempty"`
+	Identifiers []GitLabIdentifier `json:"identifiers"`
+	Links       []GitLabLink       `json:"links,omitempty"`
+	Location    GitLabLocation     `json:"location"`
+}
+
+type GitLabIdentifier struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type GitLabLink struct {
+	URL string `json:"url"`
+}
+
+// GitLabLocation is the location of a SAST vulnerability in a file, or of a container scanning vulnerability in an image
+type GitLabLocation struct {
+	File            string            `json:"file,omitempty"`
+	StartLine       int               `json:"start_line,omitempty"`
+	Dependency      *GitLabDependency `json:"dependency,omitempty"`
+	OperatingSystem string            `json:"operating_system,omitempty"`
+	Image           string            `json:"image,omitempty"`
+}
+
+type GitLabDependency struct {
+	Package GitLabPackage `json:"package"`
+	Version string        `json:"version"`
+}
+
+type GitLabPackage struct {
+	Name string `json:"name"`
+}
+
+var _ printer.IPrinter = &GitLabSASTPrinter{}
+
+// GitLabSASTPrinter prints the failed controls of the scanned files as a GitLab SAST report, and the vulnerabilities of
+// scanned images as a GitLab container scanning report
+type GitLabSASTPrinter struct {
+	writer *os.File
+}
+
+func NewGitLabSASTPrinter() *GitLabSASTPrinter {
+	return &GitLabSASTPrinter{}
+}
+
+func (gp *GitLabSASTPrinter) SetWriter(ctx context.Context, outputFile string) {
+	if outputFile != "" {
+		if strings.TrimSpace(outputFile) == "" {
+			outputFile = gitLabSASTOutputFile
+		}
+		if filepath.Ext(strings.TrimSpace(outputFile)) != gitLabSASTOutputExt {
+			outputFile = outputFile + gitLabSASTOutputExt
+		}
+	}
+	gp.writer = printer.GetWriter(ctx, outputFile)
+}
+
+func (gp *GitLabSASTPrinter) Score(score float32) {
+}
+
+func (gp *GitLabSASTPrinter) PrintNextSteps() {
+
+}
+
+func (gp *GitLabSASTPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OPASessionObj, imageScanData []cautils.ImageScanData) {
+	var report *GitLabSecureReport
+	switch {
+	case opaSessionObj != nil:
+		report = gitLabSASTReport(opaSessionObj, time.Now())
+	case len(imageScanData) > 0:
+		report = gitLabContainerScanningReport(imageScanDocuments(imageScanData), time.Now())
+	default:
+		logger.L().Ctx(ctx).Error("failed to write results in gitlab sast format: no data provided")
+		return
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		logger.L().Ctx(ctx).Error("failed to write results in gitlab sast format", helpers.Error(err))
+		return
+	}
+	if _, err := gp.writer.Write(content); err != nil {
+		logger.L().Ctx(ctx).Error("failed to write results in gitlab sast format", helpers.Error(err))
+		return
+	}
+	printer.LogOutputFile(gp.writer.Name())
+}
+
+// gitLabSASTReport returns a SAST report of the failed controls of the scanned files
+func gitLabSASTReport(opaSessionObj *cautils.OPASessionObj, endTime time.Time) *GitLabSecureReport {
+	startTime := endTime
+	if opaSessionObj.Report != nil && !opaSessionObj.Report.ReportGenerationTime.IsZero() {
+		startTime = opaSessionObj.Report.ReportGenerationTime
+	}
+	report := newGitLabSecureReport(gitLabScanTypeSAST, startTime, endTime)
+
+	findings := listConfigurationFindings(opaSessionObj)
+	for i := range findings {
+		finding := &findings[i]
+		controlURL := cautils.GetControlLink(finding.control.GetID())
+		report.Vulnerabilities = append(report.Vulnerabilities, GitLabVulnerability{
+			ID:          gitLabVulnerabilityID(finding.fingerprint),
+			Name:        finding.description(),
+			Description: finding.control.GetDescription(),
+			Severity:    apis.ControlSeverityToString(finding.control.GetScoreFactor()),
+			Solution:    finding.control.GetRemediation(),
+			Identifiers: []GitLabIdentifier{{
+				Type:  "kubescape_control_id",
+				Name:  fmt.Sprintf("%s %s", finding.control.GetID(), finding.control.GetName()),
+				Value: finding.control.GetID(),
+				URL:   controlURL,
+			}},
+			Links: []GitLabLink{{URL: controlURL}},
+			Location: GitLabLocation{
+				File:      finding.filePath,
+				StartLine: finding.location.Line,
+			},
+		})
+	}
+	return report
+}
+
+// gitLabContainerScanningReport returns a container scanning report of the vulnerabilities of the images
+func gitLabContainerScanningReport(documents map[string]models.Document, endTime time.Time) *GitLabSecureReport {
+	report := newGitLabSecureReport(gitLabScanTypeContainerScanning, endTime, endTime)
+
+	images := make([]string, 0, len(documents))
+	for image := range documents {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	for _, image := range images {
+		doc := documents[image]
+		operatingSystem := doc.Distro.Name
+		if doc.Distro.Version != "" {
+			operatingSystem = fmt.Sprintf("%s:%s", doc.Distro.Name, doc.Distro.Version)
+		}
+
+		for _, match := range doc.Matches {
+			vulnerability := match.Vulnerability
+			identifier := GitLabIdentifier{Type: "cve", Name: vulnerability.ID, Value: vulnerability.ID}
+			if !strings.HasPrefix(vulnerability.ID, "CVE-") {
+				identifier.Type = "vulnerability_id"
+			}
+			links := make([]GitLabLink, 0, len(vulnerability.URLs))
+			for _, url := range vulnerability.URLs {
+				links = append(links, GitLabLink{URL: url})
+			}
+			if len(links) > 0 {
+				identifier.URL = links[0].URL
+			}
+
+			report.Vulnerabilities = append(report.Vulnerabilities, GitLabVulnerability{
+				ID:          gitLabVulnerabilityID(findingFingerprint(imageRepository(image), match.Artifact.Name, vulnerability.ID)),
+				Name:        fmt.Sprintf("%s in %s", vulnerability.ID, match.Artifact.Name),
+				Description: vulnerability.Description,
+				Severity:    grypeSeverityToGitLabSeverity(vulnerability.Severity),
+				Solution:    vulnerabilitySolution(match),
+				Identifiers: []GitLabIdentifier{identifier},
+				Links:       links,
+				Location: GitLabLocation{
+					Dependency: &GitLabDependency{
+						Package: GitLabPackage{Name: match.Artifact.Name},
+						Version: match.Artifact.Version,
+					},
+					OperatingSystem: operatingSystem,
+					Image:           image,
+				},
+			})
+		}
+	}
+	return report
+}
+
+func newGitLabSecureReport(scanType string, startTime, endTime time.Time) *GitLabSecureReport {
+	version := versioncheck.BuildNumber
+	if version == "" {
+		version = "unknown"
+	}
+	scanner := GitLabScanner{
+		ID:      toolName,
+		Name:    "Kubescape",
+		URL:     toolInfoURI,
+		Version: version,
+		Vendor:  GitLabVendor{Name: "Kubescape"},
+	}
+	return &GitLabSecureReport{
+		Version: gitLabSecureReportVersion,
+		Scan: GitLabScan{
+			Analyzer:  scanner,
+			Scanner:   scanner,
+			Type:      scanType,
+			StartTime: startTime.UTC().Format(gitLabSecureTimeLayout),
+			EndTime:   endTime.UTC().Format(gitLabSecureTimeLayout),
+			Status:    "success",
+		},
+		Vulnerabilities: []GitLabVulnerability{},
+	}
+}
+
+// gitLabVulnerabilityID returns a UUID derived from the fingerprint of a finding, so that the ID is stable across scans
+func gitLabVulnerabilityID(fingerprint string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(fingerprint)).String()
+}
+
+// grypeSeverityToGitLabSeverity returns the GitLab severity of the severity of a vulnerability
+func grypeSeverityToGitLabSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return "Critical"
+	case "high":
+		return "High"
+	case "medium":
+		return "Medium"
+	case "low":
+		return "Low"
+	case "negligible":
+		return "Info"
+	default:
+		return "Unknown"
+	}
+}
+
+func vulnerabilitySolution(match models.Match) string {
+	if match.Vulnerability.Fix.State != "fixed" || len(match.Vulnerability.Fix.Versions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Upgrade %s to version %s", match.Artifact.Name, strings.Join(match.Vulnerability.Fix.Versions, " or "))
+}
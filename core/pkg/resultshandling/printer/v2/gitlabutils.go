@@ -0,0 +1,147 @@
+package printer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/anchore/clio"
+	"github.com/anchore/grype/grype/presenter/models"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/locationresolver"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+)
+
+// configurationFinding is a control failed by a resource, at a location of the file of the resource
+type configurationFinding struct {
+	control     reportsummary.IControlSummary
+	resource    workloadinterface.IMetadata
+	filePath    string
+	location    locationresolver.Location
+	fingerprint string
+}
+
+// description returns the name of the control and the failing resource
+func (f *configurationFinding) description() string {
+	if f.resource.GetNamespace() == "" {
+		return fmt.Sprintf("%s: %s %s", f.control.GetName(), f.resource.GetKind(), f.resource.GetName())
+	}
+	return fmt.Sprintf("%s: %s %s/%s", f.control.GetName(), f.resource.GetKind(), f.resource.GetNamespace(), f.resource.GetName())
+}
+
+// listConfigurationFindings returns the failed controls of the resources of files, located like the SARIF results: by the
+// mapping nodes of the templates for Helm charts, and by the first fix path in the file for the other files. The
+// findings are sorted by file, line and control
+func listConfigurationFindings(opaSessionObj *cautils.OPASessionObj) []configurationFinding {
+	basePath := getBasePathFromMetadata(*opaSessionObj)
+
+	findings := make([]configurationFinding, 0)
+	for resourceID, result := range opaSessionObj.ResourcesResult {
+		if !result.GetStatus(nil).IsFailed() {
+			continue
+		}
+		resource, ok := opaSessionObj.AllResources[resourceID]
+		filePath := opaSessionObj.ResourceSource[resourceID].RelativePath
+		// findings which are not associated to a file cannot be shown in merge requests
+		if !ok || filePath == "" || basePath == "" {
+			continue
+		}
+
+		templateNodes, helmChartFileType := opaSessionObj.TemplateMapping[resourceID]
+		helmChartFileType = helmChartFileType && len(templateNodes.Nodes) > 0
+		var locationResolver *locationresolver.FixPathLocationResolver
+		if !helmChartFileType {
+			var err error
+			if locationResolver, err = locationresolver.NewFixPathLocationResolver(path.Join(basePath, filePath)); err != nil {
+				logger.L().Debug("failed to create location resolver, will use default location", helpers.Error(err))
+			}
+		}
+
+		for i := range result.AssociatedControls {
+			ac := &result.AssociatedControls[i]
+			if !ac.GetStatus(nil).IsFailed() {
+				continue
+			}
+			control := opaSessionObj.Report.SummaryDetails.Controls.GetControl(reportsummary.EControlCriteriaID, ac.GetID())
+			if control == nil {
+				continue
+			}
+
+			finding := configurationFinding{control: control, resource: resource, filePath: filePath}
+			if !helmChartFileType {
+				location, ok := resolveResourceLocation(opaSessionObj, locationResolver, ac, resourceID)
+				if !ok {
+					location = locationresolver.Location{Line: 1, Column: 1}
+				}
+				finding.location = location
+				finding.fingerprint = findingFingerprint(control.GetID(), filePath, resourceIdentity(resource))
+				findings = append(findings, finding)
+				continue
+			}
+			// a resource rendered from several templates has a finding in each of them
+			for j, subfileNodes := range templateNodes.Nodes {
+				finding.location, _ = resolveFixLocation(subfileNodes, ac)
+				finding.fingerprint = findingFingerprint(control.GetID(), filePath, resourceIdentity(resource), fmt.Sprintf("%d", j))
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].filePath != findings[j].filePath {
+			return findings[i].filePath < findings[j].filePath
+		}
+		if findings[i].location.Line != findings[j].location.Line {
+			return findings[i].location.Line < findings[j].location.Line
+		}
+		return findings[i].fingerprint < findings[j].fingerprint
+	})
+	return findings
+}
+
+// resourceIdentity identifies a resource across scans, unlike its ID which may contain the position of the resource in
+// its file
+func resourceIdentity(resource workloadinterface.IMetadata) string {
+	return strings.Join([]string{resource.GetApiVersion(), resource.GetKind(), resource.GetNamespace(), resource.GetName()}, "/")
+}
+
+// findingFingerprint returns a fingerprint of a finding stable across scans. It does not depend on the line of the
+// finding, so that a finding moved in its file is not reported as a new one
+func findingFingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+// imageRepository returns the image without its tag and digest, so that the vulnerabilities of an image are tracked
+// across its versions
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// imageScanDocuments returns the document of the vulnerabilities of each scanned image, by image
+func imageScanDocuments(imageScanData []cautils.ImageScanData) map[string]models.Document {
+	documents := make(map[string]models.Document, len(imageScanData))
+	for i := range imageScanData {
+		presenterConfig := imageScanData[i].PresenterConfig
+		if presenterConfig == nil {
+			continue
+		}
+		doc, err := models.NewDocument(clio.Identification{}, presenterConfig.Packages, presenterConfig.Context, presenterConfig.Matches, presenterConfig.IgnoredMatches, presenterConfig.MetadataProvider, nil, presenterConfig.DBStatus)
+		if err != nil {
+			logger.L().Error(fmt.Sprintf("failed to create document for image: %v", imageScanData[i].Image), helpers.Error(err))
+			continue
+		}
+		documents[imageScanData[i].Image] = doc
+	}
+	return documents
+}
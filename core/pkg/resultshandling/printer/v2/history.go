@@ -0,0 +1,135 @@
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jwalton/gchalk"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
+	"github.com/olekukonko/tablewriter"
+)
+
+// PrintHistoryReport prints the score trends and the control regressions of the history in the given format
+func PrintHistoryReport(ctx context.Context, format, outputFile string, report *history.Report) error {
+	switch format {
+	case printer.JsonFormat:
+		if outputFile != "" && filepath.Ext(strings.TrimSpace(outputFile)) != jsonOutputExt {
+			outputFile = outputFile + jsonOutputExt
+		}
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to convert history report to JSON: %w", err)
+		}
+		writer := printer.GetWriter(ctx, outputFile)
+		defer closeWriter(writer)
+		if _, err := writer.Write(content); err != nil {
+			return err
+		}
+		printer.LogOutputFile(writer.Name())
+	case printer.PrettyFormat:
+		writer := printer.GetWriter(ctx, outputFile)
+		defer closeWriter(writer)
+		printScoreTrends(writer, report)
+		printControlRegressions(writer, report)
+		printer.LogOutputFile(writer.Name())
+	default:
+		return fmt.Errorf("format \"%s\" is not supported for history", format)
+	}
+	return nil
+}
+
+// printScoreTrends prints a table of the scans of each target, with the change of the score since the previous scan
+func printScoreTrends(writer io.Writer, report *history.Report) {
+	heading := "Score Trends"
+	if report.Framework != "" {
+		heading = fmt.Sprintf("Score Trends of %s", report.Framework)
+	}
+	cautils.SectionHeadingDisplay(writer, heading)
+	if len(report.Trends) == 0 {
+		cautils.SimpleDisplay(writer, "No scan in history\n\n")
+		return
+	}
+	renderHistoryTable(writer, []string{"Target", "Scanned at", "Compliance score", "Change", "Failed resources"}, generateScoreTrendRows(report.Trends))
+}
+
+func generateScoreTrendRows(trends []history.Trend) [][]string {
+	var rows [][]string
+	for _, trend := range trends {
+		for i, point := range trend.Points {
+			change := "-"
+			if i > 0 {
+				change = fmt.Sprintf("%+.2f", point.Score-trend.Points[i-1].Score)
+			}
+			rows = append(rows, []string{
+				trend.Target.String(),
+				point.ScannedAt.Local().Format(time.DateTime),
+				formatScore(point.Score),
+				change,
+				fmt.Sprintf("%d", point.FailedResources),
+			})
+		}
+	}
+	return rows
+}
+
+// printControlRegressions prints a table of the controls that got worse between consecutive scans, latest first
+func printControlRegressions(writer io.Writer, report *history.Report) {
+	cautils.SectionHeadingDisplay(writer, "Control Regressions")
+	if len(report.Regressions) == 0 {
+		cautils.SimpleDisplay(writer, "No control regressions\n\n")
+		return
+	}
+	renderHistoryTable(writer, []string{"Target", "Scanned at", "Severity", "Control", "Before", "After"}, generateControlRegressionRows(report.Regressions))
+}
+
+func generateControlRegressionRows(regressions []history.Regression) [][]string {
+	rows := make([][]string, 0, len(regressions))
+	for _, regression := range regressions {
+		rows = append(rows, []string{
+			regression.Target.String(),
+			regression.ScannedAt.Local().Format(time.DateTime),
+			regression.Severity,
+			fmt.Sprintf("%s %s", regression.ControlID, regression.Name),
+			formatControlHistoryStatus(string(regression.PreviousStatus), regression.PreviousFailedResources),
+			formatControlHistoryStatus(string(regression.Status), regression.FailedResources),
+		})
+	}
+	return rows
+}
+
+func formatControlHistoryStatus(status string, failedResources int) string {
+	if failedResources == 0 {
+		return status
+	}
+	return fmt.Sprintf("%s (%d failed)", status, failedResources)
+}
+
+func renderHistoryTable(writer io.Writer, headers []string, rows [][]string) {
+	table := tablewriter.NewWriter(writer)
+	table.SetHeader(headers)
+	table.SetHeaderLine(true)
+	table.SetRowLine(true)
+	table.SetAutoWrapText(false)
+	table.SetAutoMergeCellsByColumnIndex([]int{0})
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoFormatHeaders(false)
+	table.SetUnicodeHVC(tablewriter.Regular, tablewriter.Regular, gchalk.Ansi256(238))
+
+	var headerColors []tablewriter.Colors
+	for range headers {
+		headerColors = append(headerColors, tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiYellowColor})
+	}
+	table.SetHeaderColor(headerColors...)
+
+	table.AppendBulk(rows)
+	table.Render()
+
+	cautils.SimpleDisplay(writer, "\n")
+}
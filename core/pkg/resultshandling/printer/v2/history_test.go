@@ -0,0 +1,85 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockHistoryReport() *history.Report {
+	cluster := history.Target{Type: history.TargetCluster, Name: "prod"}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	return &history.Report{
+		Trends: []history.Trend{{
+			Target: cluster,
+			Points: []history.Point{
+				{ReportID: "first", ScannedAt: start, Score: 80, FailedResources: 2},
+				{ReportID: "second", ScannedAt: start.Add(time.Hour), Score: 75.5, FailedResources: 3},
+			},
+		}},
+		Regressions: []history.Regression{{
+			Target:                  cluster,
+			ReportID:                "second",
+			ScannedAt:               start.Add(time.Hour),
+			ControlID:               "C-0002",
+			Name:                    "Exec into container",
+			Severity:                "High",
+			PreviousStatus:          apis.StatusPassed,
+			Status:                  apis.StatusFailed,
+			PreviousFailedResources: 0,
+			FailedResources:         1,
+		}},
+	}
+}
+
+func TestGenerateHistoryRows(t *testing.T) {
+	report := mockHistoryReport()
+
+	assert.Equal(t, [][]string{
+		{"cluster prod", "2024-05-01 10:00:00", "80.00%", "-", "2"},
+		{"cluster prod", "2024-05-01 11:00:00", "75.50%", "-4.50", "3"},
+	}, generateScoreTrendRows(report.Trends))
+	assert.Equal(t, [][]string{
+		{"cluster prod", "2024-05-01 11:00:00", "High", "C-0002 Exec into container", "passed", "failed (1 failed)"},
+	}, generateControlRegressionRows(report.Regressions))
+}
+
+func TestPrintHistoryReport(t *testing.T) {
+	t.Run("pretty", func(t *testing.T) {
+		var buf bytes.Buffer
+		printScoreTrends(&buf, mockHistoryReport())
+		printControlRegressions(&buf, mockHistoryReport())
+		assert.Contains(t, buf.String(), "Score Trends")
+		assert.Contains(t, buf.String(), "Control Regressions")
+		assert.Contains(t, buf.String(), "C-0002")
+
+		buf.Reset()
+		printControlRegressions(&buf, &history.Report{})
+		assert.Contains(t, buf.String(), "No control regressions")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "history")
+		require.NoError(t, PrintHistoryReport(context.TODO(), "json", output, mockHistoryReport()))
+
+		content, err := os.ReadFile(output + ".json")
+		require.NoError(t, err)
+		report := history.Report{}
+		require.NoError(t, json.Unmarshal(content, &report))
+		assert.Len(t, report.Trends, 1)
+		assert.Len(t, report.Regressions, 1)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		assert.Error(t, PrintHistoryReport(context.TODO(), "sarif", "", mockHistoryReport()))
+	})
+}
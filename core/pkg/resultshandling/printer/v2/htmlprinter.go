@@ -31,14 +31,17 @@ var _ printer.IPrinter = &HtmlPrinter{}
 type HTMLReportingCtx struct {
 	OPASessionObj     *cautils.OPASessionObj
 	ResourceTableView ResourceTableView
+	TrendChart        *TrendChart
 }
 
 type HtmlPrinter struct {
-	writer *os.File
+	writer      *os.File
+	historyFile string
 }
 
-func NewHtmlPrinter() *HtmlPrinter {
-	return &HtmlPrinter{}
+// NewHtmlPrinter returns the html printer. The report includes the trend chart of the target when the history file is set
+func NewHtmlPrinter(historyFile string) *HtmlPrinter {
+	return &HtmlPrinter{historyFile: historyFile}
 }
 
 func (hp *HtmlPrinter) SetWriter(ctx context.Context, outputFile string) {
@@ -117,7 +120,7 @@ func (hp *HtmlPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.O
 	)
 
 	resourceTableView := buildResourceTableView(opaSessionObj)
-	reportingCtx := HTMLReportingCtx{opaSessionObj, resourceTableView}
+	reportingCtx := HTMLReportingCtx{opaSessionObj, resourceTableView, loadTrendChart(ctx, hp.historyFile, opaSessionObj)}
 	err := tpl.Execute(hp.writer, reportingCtx)
 	if err != nil {
 		logger.L().Ctx(ctx).Error("failed to render template", helpers.Error(err))
@@ -0,0 +1,278 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/locationresolver"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+)
+
+const (
+	markdownOutputFile = "report"
+	markdownOutputExt  = ".md"
+
+	// markdownCollapseThreshold is the number of rows of a table above which the table is collapsed in a details block
+	markdownCollapseThreshold = 10
+)
+
+var _ printer.IPrinter = &MarkdownPrinter{}
+
+// MarkdownPrinter prints a compact summary of the results in GitHub flavored markdown, to post as a pull request comment
+type MarkdownPrinter struct {
+	writer  *os.File
+	maxSize int // maximum size of the output in characters, the sections which do not fit are dropped. No limit when 0
+}
+
+func NewMarkdownPrinter(maxSize int) *MarkdownPrinter {
+	return &MarkdownPrinter{maxSize: maxSize}
+}
+
+func (mp *MarkdownPrinter) SetWriter(ctx context.Context, outputFile string) {
+	if outputFile != "" {
+		if strings.TrimSpace(outputFile) == "" {
+			outputFile = markdownOutputFile
+		}
+		if filepath.Ext(strings.TrimSpace(outputFile)) != markdownOutputExt {
+			outputFile = outputFile + markdownOutputExt
+		}
+	}
+	mp.writer = printer.GetWriter(ctx, outputFile)
+}
+
+func (mp *MarkdownPrinter) Score(score float32) {
+}
+
+func (mp *MarkdownPrinter) PrintNextSteps() {
+
+}
+
+func (mp *MarkdownPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OPASessionObj, imageScanData []cautils.ImageScanData) {
+	if opaSessionObj == nil {
+		logger.L().Ctx(ctx).Error("failed to print results, missing data")
+		return
+	}
+
+	if _, err := mp.writer.WriteString(mp.generateReport(opaSessionObj)); err != nil {
+		logger.L().Ctx(ctx).Error("failed to write results in markdown format", helpers.Error(err))
+		return
+	}
+	printer.LogOutputFile(mp.writer.Name())
+}
+
+// generateReport returns the summary of the scan, followed by the failed controls of each severity and the findings of
+// each file, as long as they fit in the maximum size
+func (mp *MarkdownPrinter) generateReport(opaSessionObj *cautils.OPASessionObj) string {
+	sections := []string{markdownSummary(opaSessionObj)}
+	sections = append(sections, markdownControls(opaSessionObj)...)
+	sections = append(sections, markdownFindings(opaSessionObj)...)
+	return joinSections(sections, mp.maxSize)
+}
+
+// joinSections joins the sections until the next one does not fit in the maximum size, and then notes the results were
+// truncated. The first section is always kept. The size is counted in characters, as the limit of GitHub comments
+func joinSections(sections []string, maxSize int) string {
+	truncatedNote := fmt.Sprintf("\n_The results were truncated to %d characters, use the json or html formats for the full results._\n", maxSize)
+
+	sb := strings.Builder{}
+	size := 0
+	for i, section := range sections {
+		sectionSize := utf8.RuneCountInString(section)
+		if i > 0 && maxSize > 0 && size+sectionSize+utf8.RuneCountInString(truncatedNote) > maxSize {
+			sb.WriteString(truncatedNote)
+			break
+		}
+		sb.WriteString(section)
+		size += sectionSize
+	}
+	return sb.String()
+}
+
+func markdownSummary(opaSessionObj *cautils.OPASessionObj) string {
+	summaryDetails := &opaSessionObj.Report.SummaryDetails
+
+	sb := strings.Builder{}
+	sb.WriteString("## Kubescape scan results\n\n")
+	sb.WriteString(fmt.Sprintf("**Compliance score: %.2f%%**\n\n", summaryDetails.ComplianceScore))
+	sb.WriteString("| Failed controls | Failed resources | Scanned resources |\n")
+	sb.WriteString("|---|---|---|\n")
+	sb.WriteString(fmt.Sprintf("| %d | %d | %d |\n", summaryDetails.NumberOfControls().Failed(),
+		summaryDetails.NumberOfResources().Failed(), summaryDetails.NumberOfResources().All()))
+	return sb.String()
+}
+
+// markdownControls returns a section of the failed controls of each severity, from the most severe
+func markdownControls(opaSessionObj *cautils.OPASessionObj) []string {
+	controlsBySeverity := make(map[string][]reportsummary.ControlSummary)
+	for _, control := range opaSessionObj.Report.SummaryDetails.Controls {
+		if control.GetStatus().IsFailed() {
+			severity := apis.ControlSeverityToString(control.GetScoreFactor())
+			controlsBySeverity[severity] = append(controlsBySeverity[severity], control)
+		}
+	}
+	if len(controlsBySeverity) == 0 {
+		return []string{"\n### Failed controls\n\nNo failed controls :tada:\n"}
+	}
+
+	sections := []string{"\n### Failed controls\n"}
+	severities := apis.GetSupportedSeverities()
+	for i := len(severities) - 1; i >= 0; i-- {
+		controls := controlsBySeverity[severities[i]]
+		if len(controls) == 0 {
+			continue
+		}
+		sort.Slice(controls, func(i, j int) bool {
+			return controls[i].GetID() < controls[j].GetID()
+		})
+
+		table := strings.Builder{}
+		table.WriteString("| Control | Name | Failed resources |\n")
+		table.WriteString("|---|---|---|\n")
+		for j := range controls {
+			table.WriteString(fmt.Sprintf("| [%s](%s) | %s | %d |\n", controls[j].GetID(), cautils.GetControlLink(controls[j].GetID()),
+				escapeMarkdownTable(controls[j].GetName()), controls[j].NumberOfResources().Failed()))
+		}
+		sections = append(sections, collapsible(fmt.Sprintf("**%s** (%d)", severities[i], len(controls)), table.String(), len(controls)))
+	}
+	return sections
+}
+
+// markdownFinding is a control failed by a resource, at a line of the file of the resource when it can be resolved
+type markdownFinding struct {
+	line      int
+	controlID string
+	resource  string
+	path      string
+}
+
+// markdownFindings returns a section of the findings of each file, sorted by file. The resources which are not read from
+// files, such as the resources of a cluster, are grouped by namespace after the files
+func markdownFindings(opaSessionObj *cautils.OPASessionObj) []string {
+	basePath := getBasePathFromMetadata(*opaSessionObj)
+
+	findingsByGroup := make(map[string][]markdownFinding)
+	fileGroups := make(map[string]bool)
+	for resourceID, result := range opaSessionObj.ResourcesResult {
+		if !result.GetStatus(nil).IsFailed() {
+			continue
+		}
+		resource, ok := opaSessionObj.AllResources[resourceID]
+		if !ok {
+			continue
+		}
+
+		group := opaSessionObj.ResourceSource[resourceID].RelativePath
+		var locationResolver *locationresolver.FixPathLocationResolver
+		if group != "" && basePath != "" {
+			locationResolver, _ = locationresolver.NewFixPathLocationResolver(path.Join(basePath, group))
+		}
+		if group == "" {
+			group = "namespace: " + resource.GetNamespace()
+			if resource.GetNamespace() == "" {
+				group = "cluster-scoped resources"
+			}
+		} else {
+			fileGroups[group] = true
+		}
+
+		resourceName := fmt.Sprintf("%s %s", resource.GetKind(), resource.GetName())
+		for i := range result.AssociatedControls {
+			ac := &result.AssociatedControls[i]
+			if !ac.GetStatus(nil).IsFailed() {
+				continue
+			}
+			finding := markdownFinding{controlID: ac.GetID(), resource: resourceName}
+			if location, ok := resolveResourceLocation(opaSessionObj, locationResolver, ac, resourceID); ok {
+				finding.line = location.Line
+			}
+			if paths := AssistedRemediationPathsToString(ac); len(paths) > 0 {
+				finding.path = paths[0]
+				if len(paths) > 1 {
+					finding.path += fmt.Sprintf(" (+%d)", len(paths)-1)
+				}
+			}
+			findingsByGroup[group] = append(findingsByGroup[group], finding)
+		}
+	}
+	if len(findingsByGroup) == 0 {
+		return nil
+	}
+
+	groups := make([]string, 0, len(findingsByGroup))
+	for group := range findingsByGroup {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if fileGroups[groups[i]] != fileGroups[groups[j]] {
+			return fileGroups[groups[i]]
+		}
+		return groups[i] < groups[j]
+	})
+
+	sections := []string{"\n### Findings\n"}
+	for _, group := range groups {
+		findings := findingsByGroup[group]
+		// the findings without line come last
+		sort.Slice(findings, func(i, j int) bool {
+			if findings[i].line != findings[j].line {
+				return findings[j].line == 0 || (findings[i].line != 0 && findings[i].line < findings[j].line)
+			}
+			if findings[i].resource != findings[j].resource {
+				return findings[i].resource < findings[j].resource
+			}
+			return findings[i].controlID < findings[j].controlID
+		})
+
+		table := strings.Builder{}
+		table.WriteString("| Line | Control | Resource | Path |\n")
+		table.WriteString("|---|---|---|---|\n")
+		for _, finding := range findings {
+			line := "-"
+			if finding.line > 0 {
+				line = fmt.Sprintf("%d", finding.line)
+			}
+			findingPath := ""
+			if finding.path != "" {
+				findingPath = fmt.Sprintf("`%s`", escapeMarkdownTable(finding.path))
+			}
+			table.WriteString(fmt.Sprintf("| %s | [%s](%s) | %s | %s |\n", line, finding.controlID, cautils.GetControlLink(finding.controlID),
+				escapeMarkdownTable(finding.resource), findingPath))
+		}
+		sections = append(sections, collapsible(fmt.Sprintf("`%s` (%d)", group, len(findings)), table.String(), len(findings)))
+	}
+	return sections
+}
+
+// collapsible returns the title and the content, in a details block when the content has more rows than the threshold
+func collapsible(title, content string, rows int) string {
+	if rows <= markdownCollapseThreshold {
+		return fmt.Sprintf("\n%s\n\n%s", title, content)
+	}
+	return fmt.Sprintf("\n<details><summary>%s</summary>\n\n%s\n</details>\n", markdownToHTML(title), content)
+}
+
+// markdownToHTML converts the bold and code spans of a title, which are not rendered in the summary of a details block
+func markdownToHTML(title string) string {
+	for _, span := range []struct{ markdown, tag string }{{"**", "b"}, {"`", "code"}} {
+		for strings.Count(title, span.markdown) >= 2 {
+			title = strings.Replace(title, span.markdown, "<"+span.tag+">", 1)
+			title = strings.Replace(title, span.markdown, "</"+span.tag+">", 1)
+		}
+	}
+	return title
+}
+
+func escapeMarkdownTable(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,86 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownPrinterGenerateReport(t *testing.T) {
+	report := NewMarkdownPrinter(0).generateReport(newFileScanTestSession(t))
+
+	assert.Contains(t, report, "**Compliance score: 78.50%**")
+	assert.Contains(t, report, "| 3 | 2 | 5 |")
+
+	// controls are grouped by severity, from the most severe
+	critical := strings.Index(report, "**Critical** (1)")
+	high := strings.Index(report, "**High** (1)")
+	medium := strings.Index(report, "**Medium** (1)")
+	assert.True(t, critical >= 0 && critical < high && high < medium, report)
+	assert.Contains(t, report, fmt.Sprintf("| [C-0016](%s) | Allow privilege escalation | 1 |", cautils.GetControlLink("C-0016")))
+
+	// findings of files have the line of their first path, the resources without file are grouped by namespace
+	assert.Contains(t, report, "`deployment.yaml` (2)")
+	assert.Contains(t, report, fmt.Sprintf("| 13 | [C-0016](%s) | Deployment nginx | `spec.template.spec.containers[0].securityContext.allowPrivilegeEscalation=false` |", cautils.GetControlLink("C-0016")))
+	assert.Contains(t, report, fmt.Sprintf("| - | [C-0017](%s) | Deployment nginx |  |", cautils.GetControlLink("C-0017")))
+	assert.Less(t, strings.Index(report, "`deployment.yaml` (2)"), strings.Index(report, "`cluster-scoped resources` (1)"))
+	assert.Less(t, strings.Index(report, "| 13 | [C-0016]"), strings.Index(report, "| - | [C-0017]"))
+	assert.Contains(t, report, `ClusterRole admin\|all`)
+	assert.NotContains(t, report, "<details>")
+}
+
+func TestMarkdownPrinterMaxSize(t *testing.T) {
+	sessionObj := newFileScanTestSession(t)
+	full := NewMarkdownPrinter(0).generateReport(sessionObj)
+
+	maxSize := utf8.RuneCountInString(full) - 10
+	report := NewMarkdownPrinter(maxSize).generateReport(sessionObj)
+	assert.LessOrEqual(t, utf8.RuneCountInString(report), maxSize)
+	assert.Contains(t, report, "## Kubescape scan results")
+	assert.Contains(t, report, fmt.Sprintf("_The results were truncated to %d characters", maxSize))
+
+	// the summary is kept, whatever the size
+	report = NewMarkdownPrinter(1).generateReport(sessionObj)
+	assert.Contains(t, report, "**Compliance score: 78.50%**")
+	assert.NotContains(t, report, "### Failed controls")
+}
+
+func TestJoinSections(t *testing.T) {
+	summary := "## Kubescape scan results\n"
+	section := strings.Repeat("é", 50) + "\n"
+	truncatedNote := "\n_The results were truncated to 200 characters, use the json or html formats for the full results._\n"
+
+	// the first section and the note fit in 200 characters, although they take more bytes
+	assert.Greater(t, len(summary+section+truncatedNote), 200)
+	assert.Equal(t, summary+section+truncatedNote, joinSections([]string{summary, section, section}, 200))
+	assert.Equal(t, summary+section+section, joinSections([]string{summary, section, section}, 0))
+}
+
+func TestCollapsible(t *testing.T) {
+	rows := strings.Repeat("| row |\n", markdownCollapseThreshold)
+	assert.Equal(t, "\n**High** (10)\n\n"+rows, collapsible("**High** (10)", rows, markdownCollapseThreshold))
+
+	rows += "| row |\n"
+	assert.Equal(t, "\n<details><summary><b>High</b> (11)</summary>\n\n"+rows+"\n</details>\n", collapsible("**High** (11)", rows, markdownCollapseThreshold+1))
+	assert.Equal(t, "<code>deployment.yaml</code> (11)", markdownToHTML("`deployment.yaml` (11)"))
+}
+
+func TestMarkdownPrinterActionPrint(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "results")
+	mp := NewMarkdownPrinter(0)
+	mp.SetWriter(context.TODO(), outputFile)
+	mp.ActionPrint(context.TODO(), newFileScanTestSession(t), nil)
+	require.NoError(t, mp.writer.Close())
+
+	content, err := os.ReadFile(outputFile + markdownOutputExt)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "## Kubescape scan results"))
+}
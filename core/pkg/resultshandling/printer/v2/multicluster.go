@@ -0,0 +1,124 @@
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jwalton/gchalk"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
+	"github.com/olekukonko/tablewriter"
+)
+
+const fleetRowName = "Fleet"
+
+// PrintMultiClusterReport prints the combined report of a multi-cluster scan in the given format
+func PrintMultiClusterReport(ctx context.Context, format, outputFile string, report *cautils.MultiClusterReport) error {
+	switch format {
+	case printer.JsonFormat:
+		if outputFile != "" && filepath.Ext(strings.TrimSpace(outputFile)) != jsonOutputExt {
+			outputFile = outputFile + jsonOutputExt
+		}
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to convert multi-cluster report to JSON: %w", err)
+		}
+		writer := printer.GetWriter(ctx, outputFile)
+		defer closeWriter(writer)
+		if _, err := writer.Write(content); err != nil {
+			return err
+		}
+		printer.LogOutputFile(writer.Name())
+	case printer.PrettyFormat:
+		writer := printer.GetWriter(ctx, outputFile)
+		defer closeWriter(writer)
+		printClusterComparison(writer, report)
+		printer.LogOutputFile(writer.Name())
+	default:
+		return fmt.Errorf("format \"%s\" is not supported for multi-cluster scanning", format)
+	}
+	return nil
+}
+
+func closeWriter(writer *os.File) {
+	if writer != os.Stdout {
+		writer.Close()
+	}
+}
+
+// printClusterComparison prints a table comparing the scanned clusters, with the fleet scores in the last row
+func printClusterComparison(writer io.Writer, report *cautils.MultiClusterReport) {
+	cautils.SectionHeadingDisplay(writer, "Cluster Comparison")
+
+	frameworks := report.ListFrameworks()
+	headers := generateClusterComparisonHeader(frameworks)
+
+	table := tablewriter.NewWriter(writer)
+	table.SetHeader(headers)
+	table.SetHeaderLine(true)
+	table.SetRowLine(true)
+	table.SetAutoWrapText(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoFormatHeaders(false)
+	table.SetUnicodeHVC(tablewriter.Regular, tablewriter.Regular, gchalk.Ansi256(238))
+
+	var headerColors []tablewriter.Colors
+	for range headers {
+		headerColors = append(headerColors, tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiYellowColor})
+	}
+	table.SetHeaderColor(headerColors...)
+
+	table.AppendBulk(generateClusterComparisonRows(report, frameworks))
+	table.Render()
+
+	cautils.SimpleDisplay(writer, "\n")
+}
+
+func generateClusterComparisonHeader(frameworks []string) []string {
+	headers := []string{"Cluster", "Compliance score"}
+	headers = append(headers, frameworks...)
+	return append(headers, "Failed controls", "Failed resources", "Error")
+}
+
+func generateClusterComparisonRows(report *cautils.MultiClusterReport, frameworks []string) [][]string {
+	rows := make([][]string, 0, len(report.Clusters)+1)
+	for _, cluster := range report.Clusters {
+		if cluster.Report == nil {
+			row := []string{cluster.KubeContext, "-"}
+			for range frameworks {
+				row = append(row, "-")
+			}
+			rows = append(rows, append(row, "-", "-", cluster.Error))
+			continue
+		}
+
+		row := []string{cluster.KubeContext, formatScore(cluster.ComplianceScore)}
+		for _, framework := range frameworks {
+			if score, ok := cluster.Frameworks[framework]; ok {
+				row = append(row, formatScore(score))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		rows = append(rows, append(row,
+			fmt.Sprintf("%d", cluster.FailedControls),
+			fmt.Sprintf("%d/%d", cluster.FailedResources, cluster.AllResources),
+			cluster.Error,
+		))
+	}
+
+	fleetRow := []string{fleetRowName, formatScore(report.ComplianceScore)}
+	for _, framework := range frameworks {
+		fleetRow = append(fleetRow, formatScore(report.Frameworks[framework]))
+	}
+	return append(rows, append(fleetRow, "", "", ""))
+}
+
+func formatScore(score float32) string {
+	return fmt.Sprintf("%.2f%%", score)
+}
@@ -0,0 +1,64 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockMultiClusterReport() *cautils.MultiClusterReport {
+	return &cautils.MultiClusterReport{
+		ComplianceScore: 72.5,
+		Frameworks:      map[string]float32{"nsa": 70},
+		Clusters: []cautils.ClusterReport{
+			{KubeContext: "dev", Error: "unreachable"},
+			{KubeContext: "prod", ComplianceScore: 80, Frameworks: map[string]float32{"nsa": 80}, FailedControls: 3, FailedResources: 6, AllResources: 30, Report: &reporthandlingv2.PostureReport{}},
+		},
+	}
+}
+
+func TestGenerateClusterComparisonRows(t *testing.T) {
+	report := mockMultiClusterReport()
+	frameworks := report.ListFrameworks()
+
+	assert.Equal(t, []string{"Cluster", "Compliance score", "nsa", "Failed controls", "Failed resources", "Error"}, generateClusterComparisonHeader(frameworks))
+	assert.Equal(t, [][]string{
+		{"dev", "-", "-", "-", "-", "unreachable"},
+		{"prod", "80.00%", "80.00%", "3", "6/30", ""},
+		{"Fleet", "72.50%", "70.00%", "", "", ""},
+	}, generateClusterComparisonRows(report, frameworks))
+}
+
+func TestPrintClusterComparison(t *testing.T) {
+	var buf bytes.Buffer
+	printClusterComparison(&buf, mockMultiClusterReport())
+	assert.Contains(t, buf.String(), "Cluster Comparison")
+	assert.Contains(t, buf.String(), "prod")
+	assert.Contains(t, buf.String(), "Fleet")
+}
+
+func TestPrintMultiClusterReport(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "fleet")
+		require.NoError(t, PrintMultiClusterReport(context.TODO(), "json", output, mockMultiClusterReport()))
+
+		content, err := os.ReadFile(output + ".json")
+		require.NoError(t, err)
+		report := cautils.MultiClusterReport{}
+		require.NoError(t, json.Unmarshal(content, &report))
+		assert.Equal(t, float32(72.5), report.ComplianceScore)
+		assert.Len(t, report.Clusters, 2)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		assert.Error(t, PrintMultiClusterReport(context.TODO(), "sarif", "", mockMultiClusterReport()))
+	})
+}
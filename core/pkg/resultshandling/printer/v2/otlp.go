@@ -0,0 +1,418 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubescape/backend/pkg/versioncheck"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/metrics"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/metric"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	otlpLogsPath    = "v1/logs"
+	otlpMetricsPath = "v1/metrics"
+	// otlpDefaultEndpoint is the endpoint of the exporters when no endpoint is set
+	otlpDefaultEndpoint       = "https://localhost:4318"
+	otlpEndpointEnvVar        = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otlpLogsEndpointEnvVar    = "OTEL_EXPORTER_OTLP_LOGS_ENDPOINT"
+	otlpMetricsEndpointEnvVar = "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"
+	// otlpExportTimeout is the timeout of each request to the endpoint
+	otlpExportTimeout = 30 * time.Second
+	// otlpMaxBatchSize is the maximum number of log records sent in a request
+	otlpMaxBatchSize = 512
+
+	otlpFindingEventName = "kubescape.finding"
+)
+
+var _ printer.IPrinter = &OTLPPrinter{}
+
+// OTLPPrinter exports each control failed by a resource as an OpenTelemetry log record, and the compliance scores of the
+// frameworks and controls as OpenTelemetry metrics, to an OTLP/HTTP endpoint
+type OTLPPrinter struct {
+	endpoint    string // base URL of the collector, e.g. http://localhost:4318. The OTEL_EXPORTER_OTLP_* environment variables are used when empty
+	clusterName string
+}
+
+func NewOTLPPrinter(endpoint, clusterName string) *OTLPPrinter {
+	return &OTLPPrinter{
+		endpoint:    endpoint,
+		clusterName: clusterName,
+	}
+}
+
+// SetWriter does nothing, the results are sent to the endpoint and not written to a file
+func (op *OTLPPrinter) SetWriter(ctx context.Context, outputFile string) {
+}
+
+func (op *OTLPPrinter) Score(score float32) {
+}
+
+func (op *OTLPPrinter) PrintNextSteps() {
+
+}
+
+func (op *OTLPPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OPASessionObj, imageScanData []cautils.ImageScanData) {
+	if opaSessionObj == nil || opaSessionObj.Report == nil {
+		logger.L().Ctx(ctx).Error("failed to export results, missing data")
+		return
+	}
+
+	endpoint, err := parseOTLPEndpoint(op.endpoint)
+	if err != nil {
+		logger.L().Ctx(ctx).Error("failed to export results over otlp", helpers.Error(err))
+		return
+	}
+
+	if err := op.exportFindings(ctx, endpoint, opaSessionObj); err != nil {
+		logger.L().Ctx(ctx).Error("failed to export findings over otlp", helpers.Error(err))
+		return
+	}
+	if err := op.exportComplianceScores(ctx, endpoint, opaSessionObj); err != nil {
+		logger.L().Ctx(ctx).Error("failed to export compliance scores over otlp", helpers.Error(err))
+		return
+	}
+	logger.L().Success("Scan results exported over otlp", helpers.String("logs", otlpSignalURL(endpoint, otlpLogsEndpointEnvVar, otlpLogsPath)),
+		helpers.String("metrics", otlpSignalURL(endpoint, otlpMetricsEndpointEnvVar, otlpMetricsPath)))
+}
+
+// otlpEndpoint is the collector the results are exported to
+type otlpEndpoint struct {
+	host     string
+	basePath string
+	insecure bool
+}
+
+// parseOTLPEndpoint parses the base URL of a collector. A nil endpoint is returned when the URL is empty, so that the
+// exporters use the OTEL_EXPORTER_OTLP_* environment variables
+func parseOTLPEndpoint(rawURL string) (*otlpEndpoint, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otlp endpoint %q: %w", rawURL, err)
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid otlp endpoint %q, expected a URL such as http://localhost:4318", rawURL)
+	}
+	return &otlpEndpoint{host: u.Host, basePath: u.Path, insecure: u.Scheme == "http"}, nil
+}
+
+func (e *otlpEndpoint) urlPath(signalPath string) string {
+	return path.Join("/", e.basePath, signalPath)
+}
+
+// otlpSignalURL returns the URL a signal is exported to: the URL of the endpoint, or the one the exporters resolve from the
+// OTEL_EXPORTER_OTLP_* environment variables when the endpoint is nil
+func otlpSignalURL(endpoint *otlpEndpoint, signalEndpointEnvVar, signalPath string) string {
+	if endpoint != nil {
+		scheme := "https"
+		if endpoint.insecure {
+			scheme = "http"
+		}
+		return scheme + "://" + endpoint.host + endpoint.urlPath(signalPath)
+	}
+	if signalURL := os.Getenv(signalEndpointEnvVar); signalURL != "" {
+		return signalURL
+	}
+	baseURL := otlpDefaultEndpoint
+	if u := os.Getenv(otlpEndpointEnvVar); u != "" {
+		baseURL = u
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + signalPath
+}
+
+func newOTLPLogExporter(ctx context.Context, endpoint *otlpEndpoint) (*otlploghttp.Exporter, error) {
+	var opts []otlploghttp.Option
+	if endpoint != nil {
+		opts = append(opts, otlploghttp.WithEndpoint(endpoint.host), otlploghttp.WithURLPath(endpoint.urlPath(otlpLogsPath)))
+		if endpoint.insecure {
+			opts = append(opts, otlploghttp.WithInsecure())
+		}
+	}
+	return otlploghttp.New(ctx, opts...)
+}
+
+func newOTLPMetricExporter(ctx context.Context, endpoint *otlpEndpoint) (*otlpmetrichttp.Exporter, error) {
+	var opts []otlpmetrichttp.Option
+	if endpoint != nil {
+		opts = append(opts, otlpmetrichttp.WithEndpoint(endpoint.host), otlpmetrichttp.WithURLPath(endpoint.urlPath(otlpMetricsPath)))
+		if endpoint.insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+	}
+	return otlpmetrichttp.New(ctx, opts...)
+}
+
+// exportFindings exports a log record for each control failed by each resource. The records of a resource share an
+// OpenTelemetry resource, which identifies the Kubernetes resource following the semantic conventions. The records are
+// collected first and sent in batches, the exporter groups the records of a batch by resource
+func (op *OTLPPrinter) exportFindings(ctx context.Context, endpoint *otlpEndpoint, opaSessionObj *cautils.OPASessionObj) error {
+	exporter, err := newOTLPLogExporter(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	defer exporter.Shutdown(context.Background())
+
+	timestamp := time.Now()
+	if !opaSessionObj.Report.ReportGenerationTime.IsZero() {
+		timestamp = opaSessionObj.Report.ReportGenerationTime
+	}
+
+	resourceIDs := make([]string, 0, len(opaSessionObj.ResourcesResult))
+	for resourceID := range opaSessionObj.ResourcesResult {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	sort.Strings(resourceIDs)
+
+	collector := &otlpRecordsCollector{}
+	for _, resourceID := range resourceIDs {
+		result := opaSessionObj.ResourcesResult[resourceID]
+		workload, ok := opaSessionObj.AllResources[resourceID]
+		if !ok || !result.GetStatus(nil).IsFailed() {
+			continue
+		}
+
+		// the resource of the records is the resource of their provider
+		provider := sdklog.NewLoggerProvider(sdklog.WithResource(op.workloadResource(workload)), sdklog.WithProcessor(collector))
+		findingsLogger := provider.Logger(metrics.METER_NAME, log.WithInstrumentationVersion(otlpScannerVersion()))
+		for i := range result.AssociatedControls {
+			ac := &result.AssociatedControls[i]
+			if !ac.GetStatus(nil).IsFailed() {
+				continue
+			}
+			control := opaSessionObj.Report.SummaryDetails.Controls.GetControl(reportsummary.EControlCriteriaID, ac.GetID())
+			if control == nil {
+				continue
+			}
+			findingsLogger.Emit(ctx, findingLogRecord(control, workload, AssistedRemediationPathsToString(ac), opaSessionObj.ResourceSource[resourceID].RelativePath, timestamp))
+		}
+	}
+
+	for start := 0; start < len(collector.records); start += otlpMaxBatchSize {
+		end := min(start+otlpMaxBatchSize, len(collector.records))
+		if err := exportLogRecords(ctx, exporter, collector.records[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportLogRecords(ctx context.Context, exporter sdklog.Exporter, records []sdklog.Record) error {
+	ctx, cancel := context.WithTimeout(ctx, otlpExportTimeout)
+	defer cancel()
+	return exporter.Export(ctx, records)
+}
+
+// findingLogRecord returns the log record of a control failed by a resource, whose severity is the severity of the control
+func findingLogRecord(control reportsummary.IControlSummary, workload workloadinterface.IMetadata, paths []string, sourceFile string, timestamp time.Time) log.Record {
+	severity := apis.ControlSeverityToString(control.GetScoreFactor())
+
+	record := log.Record{}
+	record.SetTimestamp(timestamp)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(controlSeverityToLogSeverity(control.GetScoreFactor()))
+	record.SetSeverityText(severity)
+	record.SetBody(log.StringValue(fmt.Sprintf("%s %s failed on %s %s", control.GetID(), control.GetName(), workload.GetKind(), workloadDisplayName(workload))))
+
+	pathValues := make([]log.Value, 0, len(paths))
+	for _, p := range paths {
+		pathValues = append(pathValues, log.StringValue(p))
+	}
+	record.AddAttributes(
+		log.String("event.name", otlpFindingEventName),
+		log.String("kubescape.control.id", control.GetID()),
+		log.String("kubescape.control.name", control.GetName()),
+		log.String("kubescape.control.severity", severity),
+		log.String("kubescape.control.url", cautils.GetControlLink(control.GetID())),
+		log.String("kubescape.resource.id", workload.GetID()),
+		log.String("kubescape.resource.api_version", workload.GetApiVersion()),
+		log.String("kubescape.resource.kind", workload.GetKind()),
+		log.String("kubescape.resource.name", workload.GetName()),
+		log.Slice("kubescape.finding.paths", pathValues...),
+	)
+	if sourceFile != "" {
+		record.AddAttributes(log.String(string(semconv.CodeFilepathKey), sourceFile))
+	}
+	return record
+}
+
+func workloadDisplayName(workload workloadinterface.IMetadata) string {
+	if workload.GetNamespace() == "" {
+		return workload.GetName()
+	}
+	return workload.GetNamespace() + "/" + workload.GetName()
+}
+
+// controlSeverityToLogSeverity returns the severity of the log records of the findings of a control
+func controlSeverityToLogSeverity(scoreFactor float32) log.Severity {
+	switch apis.ControlSeverityToInt(scoreFactor) {
+	case apis.SeverityCritical:
+		return log.SeverityFatal
+	case apis.SeverityHigh:
+		return log.SeverityError
+	case apis.SeverityMedium:
+		return log.SeverityWarn
+	default:
+		return log.SeverityInfo
+	}
+}
+
+// otlpScannerResourceAttributes are the attributes of all the exported resources, identifying the scanner and the cluster
+func (op *OTLPPrinter) otlpScannerResourceAttributes() []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		semconv.ServiceName("kubescape"),
+		semconv.ServiceVersion(otlpScannerVersion()),
+	}
+	if op.clusterName != "" {
+		attributes = append(attributes, semconv.K8SClusterName(op.clusterName))
+	}
+	return attributes
+}
+
+// workloadResource returns the resource of the findings of a Kubernetes resource, with the attributes of the semantic
+// conventions for its namespace and kind. The kinds without conventions are identified by the attributes of the records
+func (op *OTLPPrinter) workloadResource(workload workloadinterface.IMetadata) *resource.Resource {
+	attributes := op.otlpScannerResourceAttributes()
+	if workload.GetNamespace() != "" {
+		attributes = append(attributes, semconv.K8SNamespaceName(workload.GetNamespace()))
+	}
+	switch workload.GetKind() {
+	case "Pod":
+		attributes = append(attributes, semconv.K8SPodName(workload.GetName()))
+	case "Deployment":
+		attributes = append(attributes, semconv.K8SDeploymentName(workload.GetName()))
+	case "ReplicaSet":
+		attributes = append(attributes, semconv.K8SReplicaSetName(workload.GetName()))
+	case "StatefulSet":
+		attributes = append(attributes, semconv.K8SStatefulSetName(workload.GetName()))
+	case "DaemonSet":
+		attributes = append(attributes, semconv.K8SDaemonSetName(workload.GetName()))
+	case "Job":
+		attributes = append(attributes, semconv.K8SJobName(workload.GetName()))
+	case "CronJob":
+		attributes = append(attributes, semconv.K8SCronJobName(workload.GetName()))
+	case "Node":
+		attributes = append(attributes, semconv.K8SNodeName(workload.GetName()))
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attributes...)
+}
+
+// exportComplianceScores exports gauges of the compliance scores of the scan, of each framework and of each control, and
+// of the number of resources failing each control
+func (op *OTLPPrinter) exportComplianceScores(ctx context.Context, endpoint *otlpEndpoint, opaSessionObj *cautils.OPASessionObj) error {
+	exporter, err := newOTLPMetricExporter(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	defer exporter.Shutdown(context.Background())
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(resource.NewWithAttributes(semconv.SchemaURL, op.otlpScannerResourceAttributes()...)))
+	defer provider.Shutdown(context.Background())
+
+	if err := recordComplianceScores(ctx, provider.Meter(metrics.METER_NAME, metric.WithInstrumentationVersion(otlpScannerVersion())), &opaSessionObj.Report.SummaryDetails); err != nil {
+		return err
+	}
+
+	resourceMetrics := metricdata.ResourceMetrics{}
+	if err := reader.Collect(ctx, &resourceMetrics); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, otlpExportTimeout)
+	defer cancel()
+	return exporter.Export(ctx, &resourceMetrics)
+}
+
+func recordComplianceScores(ctx context.Context, meter metric.Meter, summaryDetails *reportsummary.SummaryDetails) error {
+	complianceScore, err := meter.Float64Gauge(otlpMetricName("compliance_score"), metric.WithUnit("%"), metric.WithDescription("Compliance score of the scan"))
+	if err != nil {
+		return err
+	}
+	frameworkComplianceScore, err := meter.Float64Gauge(otlpMetricName("framework_compliance_score"), metric.WithUnit("%"), metric.WithDescription("Compliance score of a framework"))
+	if err != nil {
+		return err
+	}
+	controlComplianceScore, err := meter.Float64Gauge(otlpMetricName("control_compliance_score"), metric.WithUnit("%"), metric.WithDescription("Compliance score of a control"))
+	if err != nil {
+		return err
+	}
+	controlFailedResources, err := meter.Int64Gauge(otlpMetricName("control_failed_resources"), metric.WithUnit("{resource}"), metric.WithDescription("Number of resources failing a control"))
+	if err != nil {
+		return err
+	}
+
+	complianceScore.Record(ctx, float64(summaryDetails.ComplianceScore))
+	for i := range summaryDetails.Frameworks {
+		framework := &summaryDetails.Frameworks[i]
+		frameworkComplianceScore.Record(ctx, float64(framework.GetComplianceScore()), metric.WithAttributes(attribute.String("kubescape.framework.name", framework.GetName())))
+	}
+	for controlID := range summaryDetails.Controls {
+		control := summaryDetails.Controls[controlID]
+		attributes := metric.WithAttributes(
+			attribute.String("kubescape.control.id", control.GetID()),
+			attribute.String("kubescape.control.name", control.GetName()),
+			attribute.String("kubescape.control.severity", apis.ControlSeverityToString(control.GetScoreFactor())),
+		)
+		// the controls which were not evaluated have no compliance score
+		if control.GetComplianceScore() >= 0 {
+			controlComplianceScore.Record(ctx, float64(control.GetComplianceScore()), attributes)
+		}
+		controlFailedResources.Record(ctx, int64(control.NumberOfResources().Failed()), attributes)
+	}
+	return nil
+}
+
+// otlpMetricName returns the name of a metric, prefixed like the other metrics of kubescape
+func otlpMetricName(name string) string {
+	return metrics.METRIC_NAME_PREFIX + "_" + name
+}
+
+func otlpScannerVersion() string {
+	if versioncheck.BuildNumber == "" {
+		return "unknown"
+	}
+	return versioncheck.BuildNumber
+}
+
+// otlpRecordsCollector is a log processor keeping the emitted records, to export them in batches and get the errors of
+// the export, which the processors of the SDK only report to the global error handler
+type otlpRecordsCollector struct {
+	records []sdklog.Record
+}
+
+func (c *otlpRecordsCollector) OnEmit(_ context.Context, record *sdklog.Record) error {
+	c.records = append(c.records, record.Clone())
+	return nil
+}
+
+func (c *otlpRecordsCollector) Shutdown(context.Context) error {
+	return nil
+}
+
+func (c *otlpRecordsCollector) ForceFlush(context.Context) error {
+	return nil
+}
@@ -0,0 +1,202 @@
+package printer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpTestCollector is a stand-in of an OpenTelemetry collector, keeping the requests it receives over OTLP/HTTP
+type otlpTestCollector struct {
+	mu      sync.Mutex
+	logs    []*collogspb.ExportLogsServiceRequest
+	metrics []*colmetricspb.ExportMetricsServiceRequest
+}
+
+func newOTLPTestCollector(t *testing.T) (*otlpTestCollector, *httptest.Server) {
+	t.Helper()
+	collector := &otlpTestCollector{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/otlp/v1/logs", func(w http.ResponseWriter, r *http.Request) {
+		request := &collogspb.ExportLogsServiceRequest{}
+		collector.handle(t, w, r, request, &collogspb.ExportLogsServiceResponse{})
+		collector.mu.Lock()
+		defer collector.mu.Unlock()
+		collector.logs = append(collector.logs, request)
+	})
+	mux.HandleFunc("/otlp/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		request := &colmetricspb.ExportMetricsServiceRequest{}
+		collector.handle(t, w, r, request, &colmetricspb.ExportMetricsServiceResponse{})
+		collector.mu.Lock()
+		defer collector.mu.Unlock()
+		collector.metrics = append(collector.metrics, request)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return collector, server
+}
+
+func (c *otlpTestCollector) handle(t *testing.T, w http.ResponseWriter, r *http.Request, request, response proto.Message) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(body, request))
+
+	content, err := proto.Marshal(response)
+	require.NoError(t, err)
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(content)
+}
+
+func otlpAttributes(attributes []*commonpb.KeyValue) map[string]string {
+	values := make(map[string]string, len(attributes))
+	for _, attribute := range attributes {
+		values[attribute.GetKey()] = attribute.GetValue().GetStringValue()
+	}
+	return values
+}
+
+func TestOTLPPrinterActionPrint(t *testing.T) {
+	collector, server := newOTLPTestCollector(t)
+
+	sessionObj := newFileScanTestSession(t)
+	summaryDetails := &sessionObj.Report.SummaryDetails
+	summaryDetails.Frameworks = []reportsummary.FrameworkSummary{{Name: "NSA", ComplianceScore: 62.5}}
+	control := summaryDetails.Controls["C-0016"]
+	complianceScore := float32(40)
+	control.ComplianceScore = &complianceScore
+	summaryDetails.Controls["C-0016"] = control
+
+	NewOTLPPrinter(server.URL+"/otlp", "my-cluster").ActionPrint(context.Background(), sessionObj, nil)
+
+	t.Run("findings are exported as log records", func(t *testing.T) {
+		// a single request, grouping the records of the failed resources
+		require.Len(t, collector.logs, 1)
+		records := make(map[string]*logspb.LogRecord)
+		resources := make(map[string]map[string]string)
+		require.Len(t, collector.logs[0].GetResourceLogs(), 2)
+		for _, resourceLogs := range collector.logs[0].GetResourceLogs() {
+			for _, scopeLogs := range resourceLogs.GetScopeLogs() {
+				for _, record := range scopeLogs.GetLogRecords() {
+					controlID := otlpAttributes(record.GetAttributes())["kubescape.control.id"]
+					records[controlID] = record
+					resources[controlID] = otlpAttributes(resourceLogs.GetResource().GetAttributes())
+				}
+			}
+		}
+		require.Len(t, records, 3)
+
+		assert.Equal(t, "my-cluster", resources["C-0016"]["k8s.cluster.name"])
+		assert.Equal(t, "default", resources["C-0016"]["k8s.namespace.name"])
+		assert.Equal(t, "nginx", resources["C-0016"]["k8s.deployment.name"])
+		assert.Equal(t, "kubescape", resources["C-0016"]["service.name"])
+		assert.NotContains(t, resources["C-0035"], "k8s.namespace.name")
+
+		record := records["C-0016"]
+		assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, record.GetSeverityNumber())
+		assert.Equal(t, "High", record.GetSeverityText())
+		assert.Equal(t, "C-0016 Allow privilege escalation failed on Deployment default/nginx", record.GetBody().GetStringValue())
+		attributes := otlpAttributes(record.GetAttributes())
+		assert.Equal(t, otlpFindingEventName, attributes["event.name"])
+		assert.Equal(t, "Deployment", attributes["kubescape.resource.kind"])
+		assert.Equal(t, "deployment.yaml", attributes["code.filepath"])
+		assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL, records["C-0035"].GetSeverityNumber())
+	})
+
+	t.Run("compliance scores are exported as gauges", func(t *testing.T) {
+		require.Len(t, collector.metrics, 1)
+		gauges := make(map[string][]*metricspb.NumberDataPoint)
+		for _, resourceMetrics := range collector.metrics[0].GetResourceMetrics() {
+			assert.Equal(t, "my-cluster", otlpAttributes(resourceMetrics.GetResource().GetAttributes())["k8s.cluster.name"])
+			for _, scopeMetrics := range resourceMetrics.GetScopeMetrics() {
+				for _, m := range scopeMetrics.GetMetrics() {
+					gauges[m.GetName()] = m.GetGauge().GetDataPoints()
+				}
+			}
+		}
+
+		require.Len(t, gauges["kubescape_compliance_score"], 1)
+		assert.Equal(t, 78.5, gauges["kubescape_compliance_score"][0].GetAsDouble())
+		require.Len(t, gauges["kubescape_framework_compliance_score"], 1)
+		assert.Equal(t, 62.5, gauges["kubescape_framework_compliance_score"][0].GetAsDouble())
+
+		// the controls without compliance score have no data point
+		require.Len(t, gauges["kubescape_control_compliance_score"], 1)
+		assert.Equal(t, float64(40), gauges["kubescape_control_compliance_score"][0].GetAsDouble())
+		assert.Equal(t, "C-0016", otlpAttributes(gauges["kubescape_control_compliance_score"][0].GetAttributes())["kubescape.control.id"])
+
+		require.Len(t, gauges["kubescape_control_failed_resources"], 3)
+		for _, dataPoint := range gauges["kubescape_control_failed_resources"] {
+			assert.Equal(t, int64(1), dataPoint.GetAsInt())
+		}
+	})
+}
+
+func TestOTLPPrinterActionPrintWithoutReport(t *testing.T) {
+	collector, server := newOTLPTestCollector(t)
+
+	sessionObj := newFileScanTestSession(t)
+	sessionObj.Report = nil
+	NewOTLPPrinter(server.URL, "my-cluster").ActionPrint(context.Background(), sessionObj, nil)
+
+	assert.Empty(t, collector.logs)
+	assert.Empty(t, collector.metrics)
+}
+
+func TestOTLPSignalURL(t *testing.T) {
+	endpoint, err := parseOTLPEndpoint("http://localhost:4318/otlp")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:4318/otlp/v1/logs", otlpSignalURL(endpoint, otlpLogsEndpointEnvVar, otlpLogsPath))
+
+	t.Setenv(otlpEndpointEnvVar, "")
+	t.Setenv(otlpLogsEndpointEnvVar, "")
+	t.Setenv(otlpMetricsEndpointEnvVar, "")
+	assert.Equal(t, "https://localhost:4318/v1/logs", otlpSignalURL(nil, otlpLogsEndpointEnvVar, otlpLogsPath))
+
+	t.Setenv(otlpEndpointEnvVar, "https://collector.example.com/")
+	t.Setenv(otlpLogsEndpointEnvVar, "https://logs.example.com/ingest")
+	assert.Equal(t, "https://logs.example.com/ingest", otlpSignalURL(nil, otlpLogsEndpointEnvVar, otlpLogsPath))
+	assert.Equal(t, "https://collector.example.com/v1/metrics", otlpSignalURL(nil, otlpMetricsEndpointEnvVar, otlpMetricsPath))
+}
+
+func TestParseOTLPEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawURL   string
+		want     *otlpEndpoint
+		wantPath string
+		wantErr  bool
+	}{
+		{name: "empty", rawURL: ""},
+		{name: "http", rawURL: "http://localhost:4318", want: &otlpEndpoint{host: "localhost:4318", insecure: true}, wantPath: "/v1/logs"},
+		{name: "https with path", rawURL: "https://otlp.example.com/otlp/", want: &otlpEndpoint{host: "otlp.example.com", basePath: "/otlp/"}, wantPath: "/otlp/v1/logs"},
+		{name: "no scheme", rawURL: "localhost:4318", wantErr: true},
+		{name: "invalid", rawURL: "http://[::1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOTLPEndpoint(tt.rawURL)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			if got != nil {
+				assert.Equal(t, tt.wantPath, got.urlPath(otlpLogsPath))
+			}
+		})
+	}
+}
@@ -27,11 +27,13 @@ const (
 var _ printer.IPrinter = &PdfPrinter{}
 
 type PdfPrinter struct {
-	writer *os.File
+	writer      *os.File
+	historyFile string
 }
 
-func NewPdfPrinter() *PdfPrinter {
-	return &PdfPrinter{}
+// NewPdfPrinter returns the pdf printer. The report includes the trend chart of the target when the history file is set
+func NewPdfPrinter(historyFile string) *PdfPrinter {
+	return &PdfPrinter{historyFile: historyFile}
 }
 
 func (pp *PdfPrinter) SetWriter(ctx context.Context, outputFile string) {
@@ -70,7 +72,7 @@ func (pp *PdfPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OP
 		return
 	}
 
-	outBuff, err := pp.generatePdf(&opaSessionObj.Report.SummaryDetails)
+	outBuff, err := pp.generatePdf(&opaSessionObj.Report.SummaryDetails, loadTrendChart(ctx, pp.historyFile, opaSessionObj))
 	if err != nil {
 		logger.L().Ctx(ctx).Error("failed to generate pdf format", helpers.Error(err))
 		return
@@ -83,7 +85,7 @@ func (pp *PdfPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OP
 	printer.LogOutputFile(pp.writer.Name())
 }
 
-func (pp *PdfPrinter) generatePdf(summaryDetails *reportsummary.SummaryDetails) ([]byte, error) {
+func (pp *PdfPrinter) generatePdf(summaryDetails *reportsummary.SummaryDetails, trendChart *TrendChart) ([]byte, error) {
 	sortedControlIDs := getSortedControlsIDs(summaryDetails.Controls)
 	infoToPrintInfo := mapInfoToPrintInfo(summaryDetails.Controls)
 
@@ -95,6 +97,13 @@ func (pp *PdfPrinter) generatePdf(summaryDetails *reportsummary.SummaryDetails)
 	if err != nil {
 		return nil, err
 	}
+	if trendChart != nil {
+		chart, err := trendChart.PNG()
+		if err != nil {
+			return nil, err
+		}
+		template.GenerateTrendChart(trendChart.Summary(), chart)
+	}
 	template.GenerateInfoRows(pp.getFormattedInformation(infoToPrintInfo))
 	return template.GetPdf()
 }
@@ -99,6 +99,27 @@ func (t *Template) GenerateTable(tableRows *[]TableObject, totalFailed, total in
 	return nil
 }
 
+// GenerateTrendChart is responsible for adding the chart of the compliance score trend, a PNG image, in pdf
+func (t *Template) GenerateTrendChart(summary string, chart []byte) *Template {
+	t.maroto.AddRow(8, text.NewCol(12, "Compliance score trend", props.Text{
+		Align:  align.Left,
+		Top:    2.5,
+		Size:   8,
+		Style:  fontstyle.Bold,
+		Family: fontfamily.Arial,
+	}))
+	t.maroto.AddRow(6, text.NewCol(12, summary, props.Text{
+		Align:  align.Left,
+		Size:   6,
+		Family: fontfamily.Arial,
+	}))
+	t.maroto.AddRow(48, image.NewFromBytesCol(12, chart, extension.Png, props.Rect{
+		Center:  true,
+		Percent: 100,
+	}))
+	return t
+}
+
 // GenerateInfoRows is responsible for adding the information in pdf
 func (t *Template) GenerateInfoRows(rows []string) *Template {
 	for _, row := range rows {
@@ -10,7 +10,7 @@ import (
 )
 
 func TestNewPdfPrinter(t *testing.T) {
-	pp := NewPdfPrinter()
+	pp := NewPdfPrinter("")
 	assert.NotNil(t, pp)
 	assert.Empty(t, pp)
 }
@@ -53,7 +53,7 @@ func TestScore_Pdf(t *testing.T) {
 		},
 	}
 
-	pp := NewPdfPrinter()
+	pp := NewPdfPrinter("")
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -108,7 +108,7 @@ func TestSetWriter_Pdf(t *testing.T) {
 		},
 	}
 
-	pp := NewPdfPrinter()
+	pp := NewPdfPrinter("")
 	ctx := context.Background()
 
 	for _, tt := range tests {
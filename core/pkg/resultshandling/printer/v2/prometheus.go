@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 
 	"github.com/anchore/grype/grype/presenter/models"
 	"github.com/kubescape/go-logger"
@@ -20,11 +21,35 @@ var _ printer.IPrinter = &PrometheusPrinter{}
 type PrometheusPrinter struct {
 	writer      *os.File
 	verboseMode bool
+	breakdown   PrometheusBreakdown
 }
 
-func NewPrometheusPrinter(verboseMode bool) *PrometheusPrinter {
+// PrometheusBreakdown configures the optional metrics of the namespaces and of their workloads, i.e. the namespaced
+// resources
+type PrometheusBreakdown struct {
+	Namespaces        bool     // print the metrics of each namespace
+	Workloads         bool     // print the metrics of each workload
+	MaxSeries         int      // maximum number of series of the namespaces and workloads metrics. No limit when 0
+	AllowedNamespaces []string // names or glob patterns of the namespaces to print the metrics of. All when empty
+}
+
+// allowsNamespace returns whether the namespace matches the allow-list
+func (pb *PrometheusBreakdown) allowsNamespace(namespace string) bool {
+	if len(pb.AllowedNamespaces) == 0 {
+		return true
+	}
+	for _, pattern := range pb.AllowedNamespaces {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func NewPrometheusPrinter(verboseMode bool, breakdown PrometheusBreakdown) *PrometheusPrinter {
 	return &PrometheusPrinter{
 		verboseMode: verboseMode,
+		breakdown:   breakdown,
 	}
 }
 
@@ -55,10 +80,16 @@ func (pp *PrometheusPrinter) generatePrometheusFormat(
 	m := &Metrics{}
 	m.setComplianceScores(summaryDetails)
 	// m.setResourcesCounters(resources, results)
+	m.setBreakdown(resources, results, summaryDetails, pp.breakdown)
 
 	return m
 }
 
+// Metrics returns the metrics of the results in the Prometheus text format
+func (pp *PrometheusPrinter) Metrics(opaSessionObj *cautils.OPASessionObj) []byte {
+	return []byte(pp.generatePrometheusFormat(opaSessionObj.AllResources, opaSessionObj.ResourcesResult, &opaSessionObj.Report.SummaryDetails).String())
+}
+
 func (pp *PrometheusPrinter) PrintImageScan(context.Context, *models.PresenterConfig) {
 }
 
@@ -68,9 +99,7 @@ func (pp *PrometheusPrinter) ActionPrint(ctx context.Context, opaSessionObj *cau
 		return
 	}
 
-	metrics := pp.generatePrometheusFormat(opaSessionObj.AllResources, opaSessionObj.ResourcesResult, &opaSessionObj.Report.SummaryDetails)
-
-	if _, err := pp.writer.Write([]byte(metrics.String())); err != nil {
+	if _, err := pp.writer.Write(pp.Metrics(opaSessionObj)); err != nil {
 		logger.L().Ctx(ctx).Error("failed to write results", helpers.Error(err))
 		return
 	}
@@ -13,13 +13,13 @@ import (
 func TestNewPrometheusPrinter(t *testing.T) {
 	// For verbose mode false
 	verboseMode := false
-	promPrinter := NewPrometheusPrinter(verboseMode)
+	promPrinter := NewPrometheusPrinter(verboseMode, PrometheusBreakdown{})
 	assert.NotNil(t, promPrinter)
 	assert.Equal(t, verboseMode, promPrinter.verboseMode)
 
 	// For verbose mode true
 	verboseMode = true
-	promPrinter = NewPrometheusPrinter(verboseMode)
+	promPrinter = NewPrometheusPrinter(verboseMode, PrometheusBreakdown{})
 	assert.NotNil(t, promPrinter)
 	assert.Equal(t, verboseMode, promPrinter.verboseMode)
 }
@@ -74,7 +74,7 @@ func TestScore(t *testing.T) {
 		},
 	}
 
-	promPrinter := NewPrometheusPrinter(false)
+	promPrinter := NewPrometheusPrinter(false, PrometheusBreakdown{})
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
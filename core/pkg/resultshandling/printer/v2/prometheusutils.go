@@ -2,11 +2,14 @@ package printer
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/opa-utils/reporthandling/apis"
 	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
 )
 
 type metricsName string
@@ -24,6 +27,10 @@ const (
 	metricsResource  metricsName = "resource"
 	metricsResources metricsName = "resources"
 	metricsFramework metricsName = "framework"
+	metricsNamespace metricsName = "namespace"
+	metricsWorkload  metricsName = "workload"
+	metricsSeries    metricsName = "series"
+	metricsDropped   metricsName = "dropped"
 )
 
 // ============================================ CLUSTER ============================================================
@@ -172,6 +179,97 @@ func (mrc *mResources) prefix() string {
 	return fmt.Sprintf("%s_%s", ksMetrics, metricsResource)
 }
 
+// ============================================ NAMESPACE ============================================================
+
+func (mns *mNamespaceComplianceScore) metrics() []string {
+	/*
+		#### Namespaces metrics
+		kubescape_namespace_complianceScore{namespace="<namespace>"} <compliance score>
+
+		###### Namespaces resources counters
+		kubescape_namespace_count_resources_failed{namespace="<namespace>"} <counter>
+		kubescape_namespace_count_resources_skipped{namespace="<namespace>"} <counter>
+		kubescape_namespace_count_resources_passed{namespace="<namespace>"} <counter>
+
+		###### Namespaces failed controls counters, only of the severities with failed controls
+		kubescape_namespace_count_controls_failed{namespace="<namespace>",severity="<control severity>"} <counter>
+	*/
+
+	m := []string{}
+	// overall
+	m = append(m, toRowInMetrics(fmt.Sprintf("%s_%s", mns.prefix(), metricsScore), mns.labels(), mns.complianceScore))
+
+	// resources
+	m = append(m, toRowInMetrics(fmt.Sprintf("%s_%s_%s_%s", mns.prefix(), metricsCount, metricsResources, metricsFailed), mns.labels(), mns.resourcesCountFailed))
+	m = append(m, toRowInMetrics(fmt.Sprintf("%s_%s_%s_%s", mns.prefix(), metricsCount, metricsResources, metricsSkipped), mns.labels(), mns.resourcesCountSkipped))
+	m = append(m, toRowInMetrics(fmt.Sprintf("%s_%s_%s_%s", mns.prefix(), metricsCount, metricsResources, metricsPassed), mns.labels(), mns.resourcesCountPassed))
+
+	// controls
+	for _, severity := range prometheusSeverities {
+		if failed := mns.controlsCountFailed[severity]; failed > 0 {
+			m = append(m, toRowInMetrics(fmt.Sprintf("%s_%s_%s_%s", mns.prefix(), metricsCount, metricsControls, metricsFailed), mns.labels()+","+severityLabel(severity), failed))
+		}
+	}
+	return m
+}
+
+func (mns *mNamespaceComplianceScore) labels() string {
+	return fmt.Sprintf("namespace=\"%s\"", mns.namespace)
+}
+
+func (mns *mNamespaceComplianceScore) prefix() string {
+	return fmt.Sprintf("%s_%s", ksMetrics, metricsNamespace)
+}
+
+// ============================================ WORKLOAD ============================================================
+
+func (mw *mWorkload) metrics() []string {
+	/*
+		#### Workloads metrics
+		kubescape_workload_count_controls_passed{apiVersion="<>",kind="<>",namespace="<>",name="<>"} <counter>
+		kubescape_workload_count_controls_skipped{apiVersion="<>",kind="<>",namespace="<>",name="<>"} <counter>
+
+		###### Workloads failed controls counters, only of the severities with failed controls
+		kubescape_workload_count_controls_failed{apiVersion="<>",kind="<>",namespace="<>",name="<>",severity="<control severity>"} <counter>
+	*/
+
+	m := []string{}
+	m = append(m, toRowInMetrics(fmt.Sprintf("%s_%s_%s_%s", mw.prefix(), metricsCount, metricsControls, metricsPassed), mw.labels(), mw.controlsCountPassed))
+	m = append(m, toRowInMetrics(fmt.Sprintf("%s_%s_%s_%s", mw.prefix(), metricsCount, metricsControls, metricsSkipped), mw.labels(), mw.controlsCountSkipped))
+	for _, severity := range prometheusSeverities {
+		if failed := mw.controlsCountFailed[severity]; failed > 0 {
+			m = append(m, toRowInMetrics(fmt.Sprintf("%s_%s_%s_%s", mw.prefix(), metricsCount, metricsControls, metricsFailed), mw.labels()+","+severityLabel(severity), failed))
+		}
+	}
+	return m
+}
+
+func (mw *mWorkload) labels() string {
+	r := fmt.Sprintf("apiVersion=\"%s\"", mw.apiVersion) + ","
+	r += fmt.Sprintf("kind=\"%s\"", mw.kind) + ","
+	r += fmt.Sprintf("namespace=\"%s\"", mw.namespace) + ","
+	r += fmt.Sprintf("name=\"%s\"", mw.name)
+	return r
+}
+
+func (mw *mWorkload) prefix() string {
+	return fmt.Sprintf("%s_%s", ksMetrics, metricsWorkload)
+}
+
+// ============================================ SERIES ============================================================
+
+func (msd *mSeriesDropped) metrics() []string {
+	/*
+		#### Series of the namespaces and workloads metrics dropped by the cardinality cap
+		kubescape_series_count_dropped{} <counter>
+	*/
+	return []string{toRowInMetrics(fmt.Sprintf("%s_%s_%s_%s", ksMetrics, metricsSeries, metricsCount, metricsDropped), "", msd.count)}
+}
+
+func severityLabel(severity string) string {
+	return fmt.Sprintf("severity=\"%s\"", severity)
+}
+
 // ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 
 func toRowInMetrics(name string, row string, value int) string {
@@ -190,6 +288,15 @@ func (m *Metrics) String() string {
 	for i := range m.listResources {
 		r += strings.Join(m.listResources[i].metrics(), "\n") + "\n"
 	}
+	for i := range m.listNamespaces {
+		r += strings.Join(m.listNamespaces[i].metrics(), "\n") + "\n"
+	}
+	for i := range m.listWorkloads {
+		r += strings.Join(m.listWorkloads[i].metrics(), "\n") + "\n"
+	}
+	if m.seriesDropped != nil {
+		r += strings.Join(m.seriesDropped.metrics(), "\n") + "\n"
+	}
 	return r
 }
 
@@ -235,11 +342,38 @@ type mResources struct {
 	controlsCountFailed  int
 	controlsCountSkipped int
 }
+
+type mNamespaceComplianceScore struct {
+	namespace             string
+	resourcesCountPassed  int
+	resourcesCountFailed  int
+	resourcesCountSkipped int
+	controlsCountFailed   map[string]int // by severity
+	complianceScore       int
+}
+
+type mWorkload struct {
+	name                 string
+	namespace            string
+	apiVersion           string
+	kind                 string
+	controlsCountPassed  int
+	controlsCountSkipped int
+	controlsCountFailed  map[string]int // by severity
+}
+
+type mSeriesDropped struct {
+	count int
+}
+
 type Metrics struct {
 	rs             mComplianceScore
 	listFrameworks []mFrameworkComplianceScore
 	listControls   []mControlComplianceScore
 	listResources  []mResources
+	listNamespaces []mNamespaceComplianceScore
+	listWorkloads  []mWorkload
+	seriesDropped  *mSeriesDropped // set when the namespaces or the workloads metrics are enabled
 }
 
 func (mrs *mComplianceScore) set(resources reportsummary.ICounters, controls reportsummary.ICounters) {
@@ -292,6 +426,172 @@ func (m *Metrics) setComplianceScores(summaryDetails *reportsummary.SummaryDetai
 	}
 }
 
+// prometheusSeverities are the severities of the failed controls counters, most severe first
+var prometheusSeverities = []string{apis.SeverityCriticalString, apis.SeverityHighString, apis.SeverityMediumString, apis.SeverityLowString, apis.SeverityUnknownString}
+
+// setBreakdown sets the metrics of the namespaces and of the namespaced resources of the allowed namespaces. The
+// namespaces come first, then the workloads with the most severe failures, until the series reach the cardinality cap
+func (m *Metrics) setBreakdown(
+	resources map[string]workloadinterface.IMetadata,
+	results map[string]resourcesresults.Result,
+	summaryDetails *reportsummary.SummaryDetails,
+	breakdown PrometheusBreakdown) {
+
+	if !breakdown.Namespaces && !breakdown.Workloads {
+		return
+	}
+
+	namespaces := map[string]*namespaceCounters{}
+	workloads := []mWorkload{}
+	for resourceID, result := range results {
+		r, ok := resources[resourceID]
+		if !ok || r.GetNamespace() == "" || !breakdown.allowsNamespace(r.GetNamespace()) {
+			continue
+		}
+		ns, ok := namespaces[r.GetNamespace()]
+		if !ok {
+			ns = newNamespaceCounters(r.GetNamespace())
+			namespaces[r.GetNamespace()] = ns
+		}
+		ns.addResource(result.GetStatus(nil).Status())
+
+		mw := mWorkload{
+			apiVersion:          r.GetApiVersion(),
+			kind:                r.GetKind(),
+			namespace:           r.GetNamespace(),
+			name:                r.GetName(),
+			controlsCountFailed: map[string]int{},
+		}
+		failedControls := map[string]string{} // severity by ID of the failed controls of the resource
+		for _, control := range result.ListControls() {
+			status := control.GetStatus(nil).Status()
+			ns.addControl(control.GetID(), status)
+			switch status {
+			case apis.StatusFailed:
+				controlSummary := summaryDetails.Controls[control.GetID()]
+				failedControls[control.GetID()] = apis.ControlSeverityToString(controlSummary.GetScoreFactor())
+			case apis.StatusSkipped:
+				mw.controlsCountSkipped++
+			case apis.StatusPassed:
+				mw.controlsCountPassed++
+			}
+		}
+		for controlID, severity := range failedControls {
+			mw.controlsCountFailed[severity]++
+			ns.failedControls[controlID] = severity
+		}
+		workloads = append(workloads, mw)
+	}
+
+	m.seriesDropped = &mSeriesDropped{}
+	series := 0
+	// capped returns whether the series of the metrics exceed the cardinality cap, counting them as dropped if so
+	capped := func(metrics []string) bool {
+		if m.seriesDropped.count > 0 || (breakdown.MaxSeries > 0 && series+len(metrics) > breakdown.MaxSeries) {
+			m.seriesDropped.count += len(metrics)
+			return true
+		}
+		series += len(metrics)
+		return false
+	}
+
+	if breakdown.Namespaces {
+		names := make([]string, 0, len(namespaces))
+		for name := range namespaces {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			mns := namespaces[name].toMetrics()
+			if !capped(mns.metrics()) {
+				m.listNamespaces = append(m.listNamespaces, mns)
+			}
+		}
+	}
+
+	if breakdown.Workloads {
+		sort.Slice(workloads, func(i, j int) bool {
+			for _, severity := range prometheusSeverities {
+				if workloads[i].controlsCountFailed[severity] != workloads[j].controlsCountFailed[severity] {
+					return workloads[i].controlsCountFailed[severity] > workloads[j].controlsCountFailed[severity]
+				}
+			}
+			if workloads[i].namespace != workloads[j].namespace {
+				return workloads[i].namespace < workloads[j].namespace
+			}
+			if workloads[i].kind != workloads[j].kind {
+				return workloads[i].kind < workloads[j].kind
+			}
+			return workloads[i].name < workloads[j].name
+		})
+		for i := range workloads {
+			if !capped(workloads[i].metrics()) {
+				m.listWorkloads = append(m.listWorkloads, workloads[i])
+			}
+		}
+	}
+}
+
+// namespaceCounters counts the statuses of the resources of a namespace, and of the controls tested on them
+type namespaceCounters struct {
+	namespace      string
+	resources      map[apis.ScanningStatus]int
+	controls       map[string]map[apis.ScanningStatus]int // statuses of the resources by control ID
+	failedControls map[string]string                      // severity by ID of the failed controls
+}
+
+func newNamespaceCounters(namespace string) *namespaceCounters {
+	return &namespaceCounters{
+		namespace:      namespace,
+		resources:      map[apis.ScanningStatus]int{},
+		controls:       map[string]map[apis.ScanningStatus]int{},
+		failedControls: map[string]string{},
+	}
+}
+
+func (nc *namespaceCounters) addResource(status apis.ScanningStatus) {
+	nc.resources[status]++
+}
+
+func (nc *namespaceCounters) addControl(controlID string, status apis.ScanningStatus) {
+	if _, ok := nc.controls[controlID]; !ok {
+		nc.controls[controlID] = map[apis.ScanningStatus]int{}
+	}
+	nc.controls[controlID][status]++
+}
+
+// complianceScore is the average of the compliance scores of the controls in the namespace, i.e. the percentage of
+// the resources passing each control out of the passed and failed ones. It is 100 when no control was evaluated
+func (nc *namespaceCounters) complianceScore() float32 {
+	var sum float32
+	evaluated := 0
+	for _, statuses := range nc.controls {
+		if total := statuses[apis.StatusPassed] + statuses[apis.StatusFailed]; total > 0 {
+			sum += float32(statuses[apis.StatusPassed]) / float32(total) * 100
+			evaluated++
+		}
+	}
+	if evaluated == 0 {
+		return 100
+	}
+	return sum / float32(evaluated)
+}
+
+func (nc *namespaceCounters) toMetrics() mNamespaceComplianceScore {
+	mns := mNamespaceComplianceScore{
+		namespace:             nc.namespace,
+		resourcesCountPassed:  nc.resources[apis.StatusPassed],
+		resourcesCountFailed:  nc.resources[apis.StatusFailed],
+		resourcesCountSkipped: nc.resources[apis.StatusSkipped],
+		controlsCountFailed:   map[string]int{},
+		complianceScore:       cautils.Float32ToInt(nc.complianceScore()),
+	}
+	for _, severity := range nc.failedControls {
+		mns.controlsCountFailed[severity]++
+	}
+	return mns
+}
+
 /* unused for now
 // return -> (passed, skipped, failed)
 func resourceControlStatusCounters(result *resourcesresults.Result) (int, int, int) {
@@ -3,7 +3,12 @@ package printer
 import (
 	"testing"
 
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestComplianceScore_MetricsLabelsAndPrefix(t *testing.T) {
@@ -320,3 +325,94 @@ func TestMetrics_String(t *testing.T) {
 		})
 	}
 }
+
+// newPrometheusTestSession returns the session of newSpreadsheetTestSession with a pod passing a control in the
+// kube-system namespace
+func newPrometheusTestSession(t *testing.T) *cautils.OPASessionObj {
+	t.Helper()
+	sessionObj := newSpreadsheetTestSession(t)
+	pod := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "coredns", "namespace": "kube-system"},
+	})
+	sessionObj.AllResources[pod.GetID()] = pod
+	sessionObj.ResourcesResult[pod.GetID()] = resourcesresults.Result{
+		ResourceID: pod.GetID(),
+		AssociatedControls: []resourcesresults.ResourceAssociatedControl{
+			{ControlID: "C-0013", Name: "C-0013", Status: apis.StatusInfo{InnerStatus: apis.StatusPassed}},
+		},
+	}
+	return sessionObj
+}
+
+func TestMetrics_SetBreakdown(t *testing.T) {
+	sessionObj := newPrometheusTestSession(t)
+	setBreakdown := func(breakdown PrometheusBreakdown) *Metrics {
+		m := &Metrics{}
+		m.setBreakdown(sessionObj.AllResources, sessionObj.ResourcesResult, &sessionObj.Report.SummaryDetails, breakdown)
+		return m
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		m := setBreakdown(PrometheusBreakdown{MaxSeries: 10})
+		assert.Empty(t, m.listNamespaces)
+		assert.Empty(t, m.listWorkloads)
+		assert.Nil(t, m.seriesDropped)
+	})
+
+	t.Run("namespaces and workloads", func(t *testing.T) {
+		m := setBreakdown(PrometheusBreakdown{Namespaces: true, Workloads: true})
+		require.Len(t, m.listNamespaces, 2)
+		assert.Equal(t, []string{
+			`kubescape_namespace_complianceScore{namespace="default"} 50`,
+			`kubescape_namespace_count_resources_failed{namespace="default"} 1`,
+			`kubescape_namespace_count_resources_skipped{namespace="default"} 0`,
+			`kubescape_namespace_count_resources_passed{namespace="default"} 0`,
+			`kubescape_namespace_count_controls_failed{namespace="default",severity="High"} 1`,
+			`kubescape_namespace_count_controls_failed{namespace="default",severity="Medium"} 1`,
+		}, m.listNamespaces[0].metrics())
+		assert.Equal(t, `kubescape_namespace_complianceScore{namespace="kube-system"} 100`, m.listNamespaces[1].metrics()[0])
+
+		// the cluster-scoped resources have no workload metrics
+		require.Len(t, m.listWorkloads, 2)
+		assert.Equal(t, []string{
+			`kubescape_workload_count_controls_passed{apiVersion="apps/v1",kind="Deployment",namespace="default",name="nginx"} 2`,
+			`kubescape_workload_count_controls_skipped{apiVersion="apps/v1",kind="Deployment",namespace="default",name="nginx"} 0`,
+			`kubescape_workload_count_controls_failed{apiVersion="apps/v1",kind="Deployment",namespace="default",name="nginx",severity="High"} 1`,
+			`kubescape_workload_count_controls_failed{apiVersion="apps/v1",kind="Deployment",namespace="default",name="nginx",severity="Medium"} 1`,
+		}, m.listWorkloads[0].metrics())
+		assert.Equal(t, "coredns", m.listWorkloads[1].name)
+		assert.Equal(t, []string{"kubescape_series_count_dropped{} 0"}, m.seriesDropped.metrics())
+	})
+
+	t.Run("allow-list", func(t *testing.T) {
+		m := setBreakdown(PrometheusBreakdown{Namespaces: true, Workloads: true, AllowedNamespaces: []string{"kube-*"}})
+		require.Len(t, m.listNamespaces, 1)
+		assert.Equal(t, "kube-system", m.listNamespaces[0].namespace)
+		require.Len(t, m.listWorkloads, 1)
+		assert.Equal(t, "coredns", m.listWorkloads[0].name)
+	})
+
+	t.Run("cardinality cap", func(t *testing.T) {
+		m := setBreakdown(PrometheusBreakdown{Namespaces: true, Workloads: true, MaxSeries: 12})
+		assert.Len(t, m.listNamespaces, 2)
+		assert.Empty(t, m.listWorkloads)
+		assert.Equal(t, 6, m.seriesDropped.count)
+
+		// the workloads with the most severe failures are kept first
+		m = setBreakdown(PrometheusBreakdown{Workloads: true, MaxSeries: 5})
+		require.Len(t, m.listWorkloads, 1)
+		assert.Equal(t, "nginx", m.listWorkloads[0].name)
+		assert.Equal(t, 2, m.seriesDropped.count)
+	})
+}
+
+func TestPrometheusBreakdown_AllowsNamespace(t *testing.T) {
+	assert.True(t, (&PrometheusBreakdown{}).allowsNamespace("default"))
+
+	breakdown := &PrometheusBreakdown{AllowedNamespaces: []string{"payments", "prod-*"}}
+	assert.True(t, breakdown.allowsNamespace("payments"))
+	assert.True(t, breakdown.allowsNamespace("prod-eu"))
+	assert.False(t, breakdown.allowsNamespace("default"))
+}
@@ -247,34 +247,31 @@ func (sp *SARIFPrinter) printConfigurationScan(ctx context.Context, opaSessionOb
 }
 
 func (sp *SARIFPrinter) resolveFixLocation(opaSessionObj *cautils.OPASessionObj, locationResolver *locationresolver.FixPathLocationResolver, ac *resourcesresults.ResourceAssociatedControl, resourceID string) locationresolver.Location {
-	defaultLocation := locationresolver.Location{Line: 1, Column: 1}
-	if locationResolver == nil {
-		return defaultLocation
+	if location, ok := resolveResourceLocation(opaSessionObj, locationResolver, ac, resourceID); ok {
+		return location
 	}
+	return locationresolver.Location{Line: 1, Column: 1}
+}
 
-	fixPaths := AssistedRemediationPathsToString(ac)
-	var fixPath string
-	if len(fixPaths) > 0 {
-		fixPath = fixPaths[0]
+// resolveResourceLocation returns the location in the file of the resource of the first assisted remediation path of
+// the control, if it can be resolved
+func resolveResourceLocation(opaSessionObj *cautils.OPASessionObj, locationResolver *locationresolver.FixPathLocationResolver, ac *resourcesresults.ResourceAssociatedControl, resourceID string) (locationresolver.Location, bool) {
+	if locationResolver == nil {
+		return locationresolver.Location{}, false
 	}
 
-	var location locationresolver.Location
-	if fixPath == "" {
-		return defaultLocation
+	fixPaths := AssistedRemediationPathsToString(ac)
+	if len(fixPaths) == 0 || fixPaths[0] == "" {
+		return locationresolver.Location{}, false
 	}
 
 	docIndex, ok := getDocIndex(opaSessionObj, resourceID)
-
 	if !ok {
-		return defaultLocation
-	}
-
-	location, _ = locationResolver.ResolveLocation(fixPath, docIndex)
-	if location.Line == 0 {
-		return defaultLocation
+		return locationresolver.Location{}, false
 	}
 
-	return location
+	location, _ := locationResolver.ResolveLocation(fixPaths[0], docIndex)
+	return location, location.Line != 0
 }
 
 func getFixPath(ac *resourcesresults.ResourceAssociatedControl, onlyPath bool) string {
@@ -0,0 +1,175 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+)
+
+// resourceControlRow is a control tested on a resource, flattened for the spreadsheet formats
+type resourceControlRow struct {
+	cluster          string
+	namespace        string
+	kind             string
+	name             string
+	controlID        string
+	controlName      string
+	severity         int
+	status           apis.ScanningStatus
+	failedPaths      []string
+	exceptionApplied bool
+	sourceFile       string
+	rawResource      string
+}
+
+func resourceControlsHeader(withRawResources bool) []string {
+	header := []string{"Cluster", "Namespace", "Kind", "Name", "Control ID", "Control name", "Severity", "Status", "Failed paths", "Exception applied", "Source file"}
+	if withRawResources {
+		header = append(header, "Raw resource")
+	}
+	return header
+}
+
+func (row *resourceControlRow) values(withRawResources bool) []string {
+	values := []string{
+		row.cluster,
+		row.namespace,
+		row.kind,
+		row.name,
+		row.controlID,
+		row.controlName,
+		apis.SeverityNumberToString(row.severity),
+		string(row.status),
+		strings.Join(row.failedPaths, "; "),
+		strconv.FormatBool(row.exceptionApplied),
+		row.sourceFile,
+	}
+	if withRawResources {
+		values = append(values, row.rawResource)
+	}
+	return values
+}
+
+// listResourceControlRows returns a row for each control tested on each resource. The security view lists only the
+// failed controls and the controls passed thanks to an exception, sorted by control like the control view. The resource
+// view sorts the rows by resource. The raw resources are set unless they are omitted from the output
+func listResourceControlRows(opaSessionObj *cautils.OPASessionObj, viewType cautils.ViewTypes, clusterName string) []resourceControlRow {
+	controls := opaSessionObj.Report.SummaryDetails.Controls
+
+	rows := make([]resourceControlRow, 0)
+	for resourceID, result := range opaSessionObj.ResourcesResult {
+		resourceRow := resourceControlRow{
+			cluster:    clusterName,
+			name:       resourceID,
+			sourceFile: opaSessionObj.ResourceSource[resourceID].RelativePath,
+		}
+		if resource, ok := opaSessionObj.AllResources[resourceID]; ok {
+			resourceRow.namespace = resource.GetNamespace()
+			resourceRow.kind = resource.GetKind()
+			resourceRow.name = resource.GetName()
+			if !opaSessionObj.OmitRawResources {
+				if raw, err := json.Marshal(resource.GetObject()); err == nil {
+					resourceRow.rawResource = string(raw)
+				} else {
+					logger.L().Debug("failed to marshal raw resource", helpers.String("resourceID", resourceID), helpers.Error(err))
+				}
+			}
+		}
+
+		for i := range result.AssociatedControls {
+			ac := &result.AssociatedControls[i]
+			status := ac.GetStatus(nil)
+			exceptionApplied := ac.GetSubStatus() == apis.SubStatusException
+			if viewType == cautils.SecurityViewType && !status.IsFailed() && !exceptionApplied {
+				continue
+			}
+
+			row := resourceRow
+			row.controlID = ac.GetID()
+			row.controlName = ac.GetName()
+			row.status = status.Status()
+			row.failedPaths = AssistedRemediationPathsToString(ac)
+			row.exceptionApplied = exceptionApplied
+			if control := controls.GetControl(reportsummary.EControlCriteriaID, ac.GetID()); control != nil {
+				row.controlName = control.GetName()
+				row.severity = apis.ControlSeverityToInt(control.GetScoreFactor())
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if viewType == cautils.ResourceViewType {
+			return rows[i].lessByResource(&rows[j])
+		}
+		if rows[i].severity != rows[j].severity || rows[i].controlID != rows[j].controlID {
+			return rows[i].lessByControl(&rows[j])
+		}
+		return rows[i].lessByResource(&rows[j])
+	})
+	return rows
+}
+
+func (row *resourceControlRow) lessByResource(other *resourceControlRow) bool {
+	if row.namespace != other.namespace {
+		return row.namespace < other.namespace
+	}
+	if row.kind != other.kind {
+		return row.kind < other.kind
+	}
+	if row.name != other.name {
+		return row.name < other.name
+	}
+	return row.lessByControl(other)
+}
+
+// lessByControl sorts the controls from the most severe
+func (row *resourceControlRow) lessByControl(other *resourceControlRow) bool {
+	if row.severity != other.severity {
+		return row.severity > other.severity
+	}
+	return row.controlID < other.controlID
+}
+
+func controlSummariesHeader() []string {
+	return []string{"Control ID", "Control name", "Severity", "Status", "Compliance score", "Failed resources", "Passed resources", "Skipped resources", "All resources"}
+}
+
+// listControlSummaryRows returns the summary and score of each control, from the most severe
+func listControlSummaryRows(opaSessionObj *cautils.OPASessionObj) [][]string {
+	controls := opaSessionObj.Report.SummaryDetails.Controls
+	sortedControlIDs := getSortedControlsIDs(controls)
+
+	rows := make([][]string, 0, len(controls))
+	for i := len(sortedControlIDs) - 1; i >= 0; i-- {
+		for _, controlID := range sortedControlIDs[i] {
+			control := controls[controlID]
+			resources := control.NumberOfResources()
+			// the controls which were not evaluated have no compliance score
+			complianceScore := ""
+			if control.GetComplianceScore() >= 0 {
+				complianceScore = fmt.Sprintf("%.2f", control.GetComplianceScore())
+			}
+			rows = append(rows, []string{
+				control.GetID(),
+				control.GetName(),
+				apis.ControlSeverityToString(control.GetScoreFactor()),
+				string(control.GetStatus().Status()),
+				complianceScore,
+				strconv.Itoa(resources.Failed()),
+				strconv.Itoa(resources.Passed()),
+				strconv.Itoa(resources.Skipped()),
+				strconv.Itoa(resources.All()),
+			})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,98 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSpreadsheetTestSession returns the session of newFileScanTestSession, in which the deployment also passes a
+// control thanks to an exception and passes another one
+func newSpreadsheetTestSession(t *testing.T) *cautils.OPASessionObj {
+	t.Helper()
+	sessionObj := newFileScanTestSession(t)
+	for resourceID, result := range sessionObj.ResourcesResult {
+		if sessionObj.AllResources[resourceID].GetKind() != "Deployment" {
+			continue
+		}
+		result.AssociatedControls = append(result.AssociatedControls,
+			resourcesresults.ResourceAssociatedControl{ControlID: "C-0012", Name: "C-0012", Status: apis.StatusInfo{InnerStatus: apis.StatusPassed, SubStatus: apis.SubStatusException}},
+			resourcesresults.ResourceAssociatedControl{ControlID: "C-0013", Name: "C-0013", Status: apis.StatusInfo{InnerStatus: apis.StatusPassed}},
+		)
+		sessionObj.ResourcesResult[resourceID] = result
+	}
+	sessionObj.Report.SummaryDetails.Controls["C-0012"] = reportsummary.ControlSummary{ControlID: "C-0012", Name: "Applications credentials in configuration files", ScoreFactor: 8}
+	sessionObj.Report.SummaryDetails.Controls["C-0013"] = reportsummary.ControlSummary{ControlID: "C-0013", Name: "Non-root containers", ScoreFactor: 6}
+	return sessionObj
+}
+
+func rowsControlIDs(rows []resourceControlRow) []string {
+	controlIDs := make([]string, 0, len(rows))
+	for i := range rows {
+		controlIDs = append(controlIDs, rows[i].controlID)
+	}
+	return controlIDs
+}
+
+func TestListResourceControlRows(t *testing.T) {
+	sessionObj := newSpreadsheetTestSession(t)
+
+	t.Run("security view lists failed controls and exceptions by control", func(t *testing.T) {
+		rows := listResourceControlRows(sessionObj, cautils.SecurityViewType, "my-cluster")
+		assert.Equal(t, []string{"C-0035", "C-0012", "C-0016", "C-0017"}, rowsControlIDs(rows))
+
+		assert.Equal(t, []string{"my-cluster", "default", "Deployment", "nginx", "C-0016", "Allow privilege escalation", "High", "failed",
+			"spec.template.spec.containers[0].securityContext.allowPrivilegeEscalation=false", "false", "deployment.yaml"}, rows[2].values(false))
+		assert.True(t, rows[1].exceptionApplied)
+		assert.Equal(t, apis.StatusPassed, rows[1].status)
+		assert.Empty(t, rows[0].sourceFile)
+	})
+
+	t.Run("control view lists all controls by control", func(t *testing.T) {
+		rows := listResourceControlRows(sessionObj, cautils.ControlViewType, "my-cluster")
+		assert.Equal(t, []string{"C-0035", "C-0012", "C-0016", "C-0013", "C-0017"}, rowsControlIDs(rows))
+	})
+
+	t.Run("resource view lists all controls by resource", func(t *testing.T) {
+		rows := listResourceControlRows(sessionObj, cautils.ResourceViewType, "my-cluster")
+		assert.Equal(t, []string{"C-0035", "C-0012", "C-0016", "C-0013", "C-0017"}, rowsControlIDs(rows))
+		assert.Equal(t, "ClusterRole", rows[0].kind)
+		assert.Equal(t, "Deployment", rows[1].kind)
+	})
+
+	t.Run("raw resources", func(t *testing.T) {
+		rows := listResourceControlRows(sessionObj, cautils.SecurityViewType, "")
+		require.NotEmpty(t, rows)
+		assert.JSONEq(t, `{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRole","metadata":{"name":"admin|all"}}`, rows[0].rawResource)
+		assert.Len(t, rows[0].values(true), len(resourceControlsHeader(true)))
+
+		sessionObj.OmitRawResources = true
+		defer func() { sessionObj.OmitRawResources = false }()
+		for _, row := range listResourceControlRows(sessionObj, cautils.SecurityViewType, "") {
+			assert.Empty(t, row.rawResource)
+		}
+	})
+}
+
+func TestListControlSummaryRows(t *testing.T) {
+	sessionObj := newSpreadsheetTestSession(t)
+	control := sessionObj.Report.SummaryDetails.Controls["C-0035"]
+	complianceScore := float32(50)
+	control.ComplianceScore = &complianceScore
+	sessionObj.Report.SummaryDetails.Controls["C-0035"] = control
+	rows := listControlSummaryRows(sessionObj)
+
+	require.Len(t, rows, 5)
+	assert.Equal(t, []string{"C-0035", "Administrative Roles", "Critical", "failed", "50.00", "1", "0", "0", "1"}, rows[0])
+	assert.Empty(t, rows[1][4])
+	assert.Equal(t, "C-0012", rows[1][0])
+	assert.Equal(t, "C-0013", rows[3][0])
+	for _, row := range rows {
+		assert.Len(t, row, len(controlSummariesHeader()))
+	}
+}
@@ -98,3 +98,31 @@ func listResultSummary(controlSummary reportsummary.IControlSummary, allResource
 
 	return workloadsSummary
 }
+
+// ListFailedControls returns the summaries of the failed controls from the most severe, the controls of a severity
+// are sorted by ID
+func ListFailedControls(controls reportsummary.ControlSummaries) []reportsummary.IControlSummary {
+	sortedControlIDs := getSortedControlsIDs(controls)
+	var failedControls []reportsummary.IControlSummary
+	for i := len(sortedControlIDs) - 1; i >= 0; i-- {
+		for _, controlID := range sortedControlIDs[i] {
+			controlSummary := controls.GetControl(reportsummary.EControlCriteriaID, controlID)
+			if controlSummary.GetStatus().IsFailed() {
+				failedControls = append(failedControls, controlSummary)
+			}
+		}
+	}
+	return failedControls
+}
+
+// ListFailedResources returns the resources failing a control
+func ListFailedResources(controlSummary reportsummary.IControlSummary, allResources map[string]workloadinterface.IMetadata) []workloadinterface.IMetadata {
+	workloadsSummary := listResultSummary(controlSummary, allResources)
+	var failedResources []workloadinterface.IMetadata
+	for i := range workloadsSummary {
+		if workloadSummaryFailed(&workloadsSummary[i]) {
+			failedResources = append(failedResources, workloadsSummary[i].resource)
+		}
+	}
+	return failedResources
+}
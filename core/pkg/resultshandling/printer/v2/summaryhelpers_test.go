@@ -3,7 +3,9 @@ package printer
 import (
 	"testing"
 
+	"github.com/kubescape/k8s-interface/workloadinterface"
 	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -182,3 +184,31 @@ func TestIsKindToBeGrouped(t *testing.T) {
 		})
 	}
 }
+
+func TestListFailedControlsAndResources(t *testing.T) {
+	newControlSummary := func(controlID string, scoreFactor float32, status apis.ScanningStatus, resourceIDs ...string) reportsummary.ControlSummary {
+		summary := reportsummary.ControlSummary{ControlID: controlID, ScoreFactor: scoreFactor}
+		summary.ResourceIDs.Append(status, resourceIDs...)
+		summary.SetStatus(&apis.StatusInfo{InnerStatus: status})
+		return summary
+	}
+	pod := workloadinterface.NewWorkloadObj(map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "nginx", "namespace": "default"}})
+	allResources := map[string]workloadinterface.IMetadata{pod.GetID(): pod}
+
+	controls := reportsummary.ControlSummaries{
+		"C-0002": newControlSummary("C-0002", 4, apis.StatusFailed, pod.GetID()),
+		"C-0003": newControlSummary("C-0003", 8, apis.StatusPassed, pod.GetID()),
+		"C-0004": newControlSummary("C-0004", 7, apis.StatusFailed, pod.GetID(), "missing"),
+		"C-0001": newControlSummary("C-0001", 7, apis.StatusFailed, pod.GetID()),
+	}
+
+	var controlIDs []string
+	for _, control := range ListFailedControls(controls) {
+		controlIDs = append(controlIDs, control.GetID())
+	}
+	assert.Equal(t, []string{"C-0001", "C-0004", "C-0002"}, controlIDs)
+
+	// the resources missing from the scanned resources are left out
+	assert.Equal(t, []workloadinterface.IMetadata{pod}, ListFailedResources(controls.GetControl(reportsummary.EControlCriteriaID, "C-0004"), allResources))
+	assert.Empty(t, ListFailedResources(controls.GetControl(reportsummary.EControlCriteriaID, "C-0003"), allResources))
+}
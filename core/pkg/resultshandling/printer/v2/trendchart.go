@@ -0,0 +1,205 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
+)
+
+const (
+	trendChartMaxPoints = 30 // latest scans of the target in the chart, including the current one
+	trendChartWidth     = 600
+	trendChartHeight    = 150
+	trendChartMargin    = 8
+)
+
+var (
+	trendChartLineColor = color.RGBA{R: 33, G: 102, B: 172, A: 255}
+	trendChartGridColor = color.RGBA{R: 224, G: 224, B: 224, A: 255}
+)
+
+// TrendChart is the chart of the compliance score of the scanned target over its latest scans in the history
+type TrendChart struct {
+	Target string
+	Width  int
+	Height int
+	Points []TrendChartPoint
+	GridY  []int // ordinates of the 0, 25, 50, 75 and 100 scores
+}
+
+// TrendChartPoint is a scan of a trend chart, with its coordinates in the chart
+type TrendChartPoint struct {
+	X         int
+	Y         int
+	ScannedAt time.Time
+	Score     float32
+}
+
+// loadTrendChart returns the trend chart of the target of the scan, from the history store and the scan itself. It
+// returns nil when there is no history store or the target has no previous scan
+func loadTrendChart(ctx context.Context, historyFile string, opaSessionObj *cautils.OPASessionObj) *TrendChart {
+	if historyFile == "" {
+		return nil
+	}
+	current := history.NewScan(opaSessionObj)
+	if current == nil {
+		return nil
+	}
+
+	store, err := history.Open(ctx, historyFile)
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("failed to load history, the trend chart is omitted", helpers.Error(err))
+		return nil
+	}
+	defer store.Close()
+	previous, err := store.List(ctx, history.Filter{Target: current.Target, Limit: trendChartMaxPoints})
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("failed to load history, the trend chart is omitted", helpers.Error(err))
+		return nil
+	}
+
+	scans := make([]history.Scan, 0, len(previous)+1)
+	for i := range previous {
+		// the report of a scan may be printed again
+		if previous[i].ReportID != current.ReportID {
+			scans = append(scans, previous[i])
+		}
+	}
+	if len(scans) >= trendChartMaxPoints {
+		scans = scans[len(scans)-trendChartMaxPoints+1:]
+	}
+	scans = append(scans, *current)
+
+	report := history.NewReport(scans, "")
+	if len(report.Trends) == 0 {
+		return nil
+	}
+	return newTrendChart(&report.Trends[0], trendChartWidth, trendChartHeight)
+}
+
+// newTrendChart places the points of the trend in a chart of the size, the scans evenly spaced and the scores from 0 to
+// 100. It returns nil when the trend has less than 2 points
+func newTrendChart(trend *history.Trend, width, height int) *TrendChart {
+	if len(trend.Points) < 2 {
+		return nil
+	}
+	chart := &TrendChart{
+		Target: trend.Target.Name,
+		Width:  width,
+		Height: height,
+	}
+	for score := 0; score <= 100; score += 25 {
+		chart.GridY = append(chart.GridY, chart.y(float32(score)))
+	}
+	step := float32(width-2*trendChartMargin) / float32(len(trend.Points)-1)
+	for i, point := range trend.Points {
+		chart.Points = append(chart.Points, TrendChartPoint{
+			X:         trendChartMargin + int(float32(i)*step),
+			Y:         chart.y(point.Score),
+			ScannedAt: point.ScannedAt,
+			Score:     point.Score,
+		})
+	}
+	return chart
+}
+
+func (c *TrendChart) y(score float32) int {
+	if score < 0 {
+		score = 0
+	} else if score > 100 {
+		score = 100
+	}
+	return c.Height - trendChartMargin - int(score*float32(c.Height-2*trendChartMargin)/100)
+}
+
+// Polyline returns the points of the chart in the format of the points attribute of an SVG polyline
+func (c *TrendChart) Polyline() string {
+	points := make([]string, 0, len(c.Points))
+	for _, point := range c.Points {
+		points = append(points, fmt.Sprintf("%d,%d", point.X, point.Y))
+	}
+	return strings.Join(points, " ")
+}
+
+// Summary returns the scores of the first and the last scans of the chart
+func (c *TrendChart) Summary() string {
+	first, last := c.Points[0], c.Points[len(c.Points)-1]
+	return fmt.Sprintf("%s: %.2f%% on %s, %.2f%% on %s (%+.2f) over %d scans", c.Target,
+		first.Score, first.ScannedAt.Format(time.DateOnly), last.Score, last.ScannedAt.Format(time.DateOnly), last.Score-first.Score, len(c.Points))
+}
+
+// PNG renders the chart as a PNG image
+func (c *TrendChart) PNG() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, y := range c.GridY {
+		drawLine(img, trendChartMargin, y, c.Width-trendChartMargin, y, 0, trendChartGridColor)
+	}
+	for i := 1; i < len(c.Points); i++ {
+		drawLine(img, c.Points[i-1].X, c.Points[i-1].Y, c.Points[i].X, c.Points[i].Y, 1, trendChartLineColor)
+	}
+	for _, point := range c.Points {
+		drawDot(img, point.X, point.Y, 3, trendChartLineColor)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLine draws a line between two points with Bresenham's algorithm, each pixel widened by a dot of the radius
+func drawLine(img *image.RGBA, x0, y0, x1, y1, radius int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	for e := dx + dy; ; {
+		drawDot(img, x0, y0, radius, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func drawDot(img *image.RGBA, x, y, radius int, c color.Color) {
+	for i := -radius; i <= radius; i++ {
+		for j := -radius; j <= radius; j++ {
+			if i*i+j*j <= radius*radius {
+				img.Set(x+i, y+j, c)
+			}
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
+	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrendChart(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	trend := &history.Trend{
+		Target: history.Target{Type: history.TargetCluster, Name: "prod"},
+		Points: []history.Point{
+			{ScannedAt: start, Score: 0},
+			{ScannedAt: start.Add(24 * time.Hour), Score: 50},
+			{ScannedAt: start.Add(48 * time.Hour), Score: 100},
+		},
+	}
+
+	chart := newTrendChart(trend, 116, 116)
+	require.NotNil(t, chart)
+	assert.Equal(t, []int{108, 83, 58, 33, 8}, chart.GridY)
+	assert.Equal(t, "8,108 58,58 108,8", chart.Polyline())
+	assert.Equal(t, "prod: 0.00% on 2024-05-01, 100.00% on 2024-05-03 (+100.00) over 3 scans", chart.Summary())
+
+	image, err := chart.PNG()
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(image))
+	require.NoError(t, err)
+	assert.Equal(t, 116, decoded.Bounds().Dx())
+
+	t.Run("single scan", func(t *testing.T) {
+		assert.Nil(t, newTrendChart(&history.Trend{Points: trend.Points[:1]}, 116, 116))
+	})
+}
+
+func TestLoadTrendChart(t *testing.T) {
+	ctx := context.Background()
+	historyFile := filepath.Join(t.TempDir(), history.DefaultFileName)
+
+	newSession := func(reportID string, score float32) *cautils.OPASessionObj {
+		return &cautils.OPASessionObj{
+			Report: &reporthandlingv2.PostureReport{
+				ReportID: reportID,
+				SummaryDetails: reportsummary.SummaryDetails{
+					ComplianceScore: score,
+					Controls: reportsummary.ControlSummaries{
+						"C-0002": {ControlID: "C-0002", Name: "Exec into container", Status: apis.StatusFailed, ScoreFactor: 5},
+					},
+				},
+			},
+			Metadata: &reporthandlingv2.Metadata{
+				ScanMetadata: reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.Cluster},
+				ContextMetadata: reporthandlingv2.ContextMetadata{
+					ClusterContextMetadata: &reporthandlingv2.ClusterMetadata{ContextName: "prod"},
+				},
+			},
+		}
+	}
+
+	assert.Nil(t, loadTrendChart(ctx, "", newSession("first", 60)))
+
+	_, err := history.Record(ctx, historyFile, newSession("first", 60))
+	require.NoError(t, err)
+	// printed again, the scan is not its own history
+	assert.Nil(t, loadTrendChart(ctx, historyFile, newSession("first", 60)))
+
+	current := newSession("second", 80)
+	chart := loadTrendChart(ctx, historyFile, current)
+	require.NotNil(t, chart)
+	require.Len(t, chart.Points, 2)
+	assert.Equal(t, float32(60), chart.Points[0].Score)
+	assert.Equal(t, float32(80), chart.Points[1].Score)
+
+	t.Run("html", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "report.html")
+		hp := NewHtmlPrinter(historyFile)
+		hp.SetWriter(ctx, output)
+		hp.ActionPrint(ctx, current, nil)
+		hp.writer.Close()
+
+		report, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Contains(t, string(report), "Compliance Score Trend")
+		assert.Contains(t, string(report), `<polyline points="`+chart.Polyline()+`"`)
+	})
+
+	t.Run("pdf", func(t *testing.T) {
+		pp := NewPdfPrinter(historyFile)
+		report, err := pp.generatePdf(&current.Report.SummaryDetails, chart)
+		require.NoError(t, err)
+		assert.NotEmpty(t, report)
+	})
+}
@@ -0,0 +1,216 @@
+package printer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
+)
+
+const (
+	xlsxOutputFile = "report"
+	xlsxOutputExt  = ".xlsx"
+
+	// xlsxMaxCellLength is the maximum number of characters of a cell of a spreadsheet
+	xlsxMaxCellLength = 32767
+)
+
+var _ printer.IPrinter = &XLSXPrinter{}
+
+// XLSXPrinter prints a workbook with a sheet of the controls tested on each resource, and a sheet of the summaries of the
+// controls
+type XLSXPrinter struct {
+	writer      *os.File
+	viewType    cautils.ViewTypes
+	clusterName string
+}
+
+func NewXLSXPrinter(viewType cautils.ViewTypes, clusterName string) *XLSXPrinter {
+	return &XLSXPrinter{
+		viewType:    viewType,
+		clusterName: clusterName,
+	}
+}
+
+func (xp *XLSXPrinter) SetWriter(ctx context.Context, outputFile string) {
+	if outputFile != "" {
+		if strings.TrimSpace(outputFile) == "" {
+			outputFile = xlsxOutputFile
+		}
+		if filepath.Ext(strings.TrimSpace(outputFile)) != xlsxOutputExt {
+			outputFile = outputFile + xlsxOutputExt
+		}
+	}
+	xp.writer = printer.GetWriter(ctx, outputFile)
+}
+
+func (xp *XLSXPrinter) Score(score float32) {
+}
+
+func (xp *XLSXPrinter) PrintNextSteps() {
+
+}
+
+func (xp *XLSXPrinter) ActionPrint(ctx context.Context, opaSessionObj *cautils.OPASessionObj, imageScanData []cautils.ImageScanData) {
+	if opaSessionObj == nil {
+		logger.L().Ctx(ctx).Error("failed to print results, missing data")
+		return
+	}
+
+	withRawResources := !opaSessionObj.OmitRawResources
+	findings := xlsxSheet{name: "Findings", rows: [][]string{resourceControlsHeader(withRawResources)}}
+	for _, row := range listResourceControlRows(opaSessionObj, xp.viewType, xp.clusterName) {
+		findings.rows = append(findings.rows, row.values(withRawResources))
+	}
+	controls := xlsxSheet{
+		name:           "Controls",
+		rows:           append([][]string{controlSummariesHeader()}, listControlSummaryRows(opaSessionObj)...),
+		numericColumns: map[int]bool{4: true, 5: true, 6: true, 7: true, 8: true},
+	}
+
+	if err := writeXLSX(xp.writer, []xlsxSheet{findings, controls}); err != nil {
+		logger.L().Ctx(ctx).Error("failed to write results in xlsx format", helpers.Error(err))
+		return
+	}
+	printer.LogOutputFile(xp.writer.Name())
+}
+
+// xlsxSheet is a sheet of a workbook, whose first row is the header
+type xlsxSheet struct {
+	name           string
+	rows           [][]string
+	numericColumns map[int]bool // indexes of the columns whose cells are numbers, except in the header
+}
+
+// writeXLSX writes a minimal Office Open XML workbook of the sheets, with inline strings and a bold frozen header
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes(len(sheets))},
+		{"_rels/.rels", xlsxRootRelationships},
+		{"xl/workbook.xml", xlsxWorkbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRelationships(len(sheets))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i := range sheets {
+		files = append(files, struct{ name, content string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxWorksheet(&sheets[i])})
+	}
+
+	zipWriter := zip.NewWriter(w)
+	for _, file := range files {
+		fileWriter, err := zipWriter.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fileWriter, file.content); err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
+
+const xlsxRootRelationships = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles has the default cell format and a bold cell format for the headers
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+func xlsxContentTypes(numberOfSheets int) string {
+	sb := strings.Builder{}
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	sb.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	sb.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	sb.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	sb.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= numberOfSheets; i++ {
+		sb.WriteString(fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i))
+	}
+	sb.WriteString(`</Types>`)
+	return sb.String()
+}
+
+func xlsxWorkbook(sheets []xlsxSheet) string {
+	sb := strings.Builder{}
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i := range sheets {
+		sb.WriteString(fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheets[i].name), i+1, i+1))
+	}
+	sb.WriteString(`</sheets></workbook>`)
+	return sb.String()
+}
+
+// xlsxWorkbookRelationships relates the workbook to its sheets, and then to its styles
+func xlsxWorkbookRelationships(numberOfSheets int) string {
+	sb := strings.Builder{}
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= numberOfSheets; i++ {
+		sb.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i))
+	}
+	sb.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, numberOfSheets+1))
+	sb.WriteString(`</Relationships>`)
+	return sb.String()
+}
+
+func xlsxWorksheet(sheet *xlsxSheet) string {
+	sb := strings.Builder{}
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	sb.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	sb.WriteString(`<sheetData>`)
+	for i, row := range sheet.rows {
+		sb.WriteString(fmt.Sprintf(`<row r="%d">`, i+1))
+		for j, value := range row {
+			ref := fmt.Sprintf("%s%d", xlsxColumnName(j), i+1)
+			switch {
+			case i == 0:
+				sb.WriteString(fmt.Sprintf(`<c r="%s" t="inlineStr" s="1"><is><t>%s</t></is></c>`, ref, xmlEscape(value)))
+			case sheet.numericColumns[j] && value == "":
+				continue
+			case sheet.numericColumns[j]:
+				sb.WriteString(fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, xmlEscape(value)))
+			default:
+				if len(value) > xlsxMaxCellLength {
+					value = strings.ToValidUTF8(value[:xlsxMaxCellLength], "")
+				}
+				sb.WriteString(fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(value)))
+			}
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+	return sb.String()
+}
+
+// xlsxColumnName returns the name of a column of a sheet by its index: A, B, ..., Z, AA, AB, ...
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// xmlEscape escapes the text, and replaces the characters not allowed in XML documents
+func xmlEscape(s string) string {
+	sb := strings.Builder{}
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,82 @@
+package printer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readZipFile(t *testing.T, r *zip.ReadCloser, name string) string {
+	t.Helper()
+	f, err := r.Open(name)
+	require.NoError(t, err, name)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(content)
+}
+
+// assertWellFormedXML decodes all the tokens of the document
+func assertWellFormedXML(t *testing.T, name, content string) {
+	t.Helper()
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err, name)
+	}
+}
+
+func TestXLSXPrinterActionPrint(t *testing.T) {
+	outputFile := filepath.Join(t.TempDir(), "results")
+
+	xp := NewXLSXPrinter(cautils.SecurityViewType, "my-cluster")
+	xp.SetWriter(context.Background(), outputFile)
+	xp.ActionPrint(context.Background(), newSpreadsheetTestSession(t), nil)
+	require.NoError(t, xp.writer.Close())
+
+	r, err := zip.OpenReader(outputFile + xlsxOutputExt)
+	require.NoError(t, err)
+	defer r.Close()
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		assertWellFormedXML(t, name, readZipFile(t, r, name))
+	}
+
+	workbook := readZipFile(t, r, "xl/workbook.xml")
+	assert.Contains(t, workbook, `<sheet name="Findings" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, workbook, `<sheet name="Controls" sheetId="2" r:id="rId2"/>`)
+
+	findings := readZipFile(t, r, "xl/worksheets/sheet1.xml")
+	assert.Contains(t, findings, `<c r="A1" t="inlineStr" s="1"><is><t>Cluster</t></is></c>`)
+	assert.Contains(t, findings, `<c r="L1" t="inlineStr" s="1"><is><t>Raw resource</t></is></c>`)
+	assert.Contains(t, findings, `<c r="E2" t="inlineStr"><is><t xml:space="preserve">C-0035</t></is></c>`)
+	assert.Contains(t, findings, `&#34;kind&#34;:&#34;ClusterRole&#34;`)
+
+	controls := readZipFile(t, r, "xl/worksheets/sheet2.xml")
+	assert.Contains(t, controls, `<c r="F2"><v>1</v></c>`)
+	assert.NotContains(t, controls, `<c r="E2">`)
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	assert.Equal(t, "A", xlsxColumnName(0))
+	assert.Equal(t, "Z", xlsxColumnName(25))
+	assert.Equal(t, "AA", xlsxColumnName(26))
+	assert.Equal(t, "AZ", xlsxColumnName(51))
+	assert.Equal(t, "BA", xlsxColumnName(52))
+}
+
+func TestXMLEscape(t *testing.T) {
+	assert.Equal(t, "a &lt;b&gt; &amp; c", xmlEscape("a <b> & c"))
+	assert.Equal(t, "�", xmlEscape("\x00"))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
 	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
 	printerv1 "github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer/v1"
 	printerv2 "github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer/v2"
@@ -21,6 +22,8 @@ type ResultsHandler struct {
 	ScanData      *cautils.OPASessionObj
 	PrinterObjs   []printer.IPrinter
 	ImageScanData []cautils.ImageScanData
+	HistoryFile   string // history store the scan is recorded in, not recorded when empty
+	Cleanup       func() // releases the resources of the scan, called by Close
 }
 
 func NewResultsHandler(reporterObj reporter.IReport, printerObjs []printer.IPrinter, uiPrinter printer.IPrinter) *ResultsHandler {
@@ -74,6 +77,14 @@ func (rh *ResultsHandler) GetResults() *reporthandlingv2.PostureReport {
 	return printerv2.FinalizeResults(rh.ScanData)
 }
 
+// Close releases the resources of the scan, the results can not be read afterwards
+func (rh *ResultsHandler) Close() {
+	if rh.Cleanup != nil {
+		rh.Cleanup()
+		rh.Cleanup = nil
+	}
+}
+
 // HandleResults handles all necessary actions for the scan results
 func (rh *ResultsHandler) HandleResults(ctx context.Context) error {
 	// Display scan results in the UI first to give immediate value.
@@ -90,6 +101,8 @@ func (rh *ResultsHandler) HandleResults(ctx context.Context) error {
 		}
 	}
 
+	rh.recordHistory(ctx)
+
 	// We should submit only after printing results, so a user can see
 	// results at all times, even if submission fails
 	if rh.ReporterObj != nil {
@@ -102,6 +115,21 @@ func (rh *ResultsHandler) HandleResults(ctx context.Context) error {
 	return nil
 }
 
+// recordHistory adds the scan to the history store. The scan does not fail when the history cannot be written
+func (rh *ResultsHandler) recordHistory(ctx context.Context) {
+	if rh.HistoryFile == "" || rh.ScanData == nil {
+		return
+	}
+	scan, err := history.Record(ctx, rh.HistoryFile, rh.ScanData)
+	if err != nil {
+		logger.L().Ctx(ctx).Warning("failed to record scan in history", helpers.String("file", rh.HistoryFile), helpers.Error(err))
+		return
+	}
+	if scan != nil {
+		logger.L().Debug("scan recorded in history", helpers.String("file", rh.HistoryFile), helpers.String("target", scan.Target.String()))
+	}
+}
+
 // NewPrinter returns a new printer for a given format and configuration options
 func NewPrinter(ctx context.Context, printFormat string, scanInfo *cautils.ScanInfo, clusterName string) printer.IPrinter {
 
@@ -117,13 +145,30 @@ func NewPrinter(ctx context.Context, printFormat string, scanInfo *cautils.ScanI
 	case printer.JunitResultFormat:
 		return printerv2.NewJunitPrinter(scanInfo.VerboseMode)
 	case printer.PrometheusFormat:
-		return printerv2.NewPrometheusPrinter(scanInfo.VerboseMode)
+		return printerv2.NewPrometheusPrinter(scanInfo.VerboseMode, printerv2.PrometheusBreakdown{
+			Namespaces:        scanInfo.PrometheusNamespaces,
+			Workloads:         scanInfo.PrometheusWorkloads,
+			MaxSeries:         scanInfo.PrometheusMaxSeries,
+			AllowedNamespaces: scanInfo.PrometheusAllowList,
+		})
 	case printer.PdfFormat:
-		return printerv2.NewPdfPrinter()
+		return printerv2.NewPdfPrinter(history.GetFile(scanInfo))
 	case printer.HtmlFormat:
-		return printerv2.NewHtmlPrinter()
+		return printerv2.NewHtmlPrinter(history.GetFile(scanInfo))
 	case printer.SARIFFormat:
 		return printerv2.NewSARIFPrinter()
+	case printer.MarkdownFormat:
+		return printerv2.NewMarkdownPrinter(scanInfo.MarkdownMaxSize)
+	case printer.CSVFormat:
+		return printerv2.NewCSVPrinter(cautils.ViewTypes(scanInfo.View), clusterName)
+	case printer.XLSXFormat:
+		return printerv2.NewXLSXPrinter(cautils.ViewTypes(scanInfo.View), clusterName)
+	case printer.OTLPFormat:
+		return printerv2.NewOTLPPrinter(scanInfo.OTLPEndpoint, clusterName)
+	case printer.GitLabCodeQualityFormat:
+		return printerv2.NewGitLabCodeQualityPrinter()
+	case printer.GitLabSASTFormat:
+		return printerv2.NewGitLabSASTPrinter()
 	default:
 		if printFormat != printer.PrettyFormat {
 			logger.L().Ctx(ctx).Warning(fmt.Sprintf("Invalid format \"%s\", default format \"pretty-printer\" is applied", printFormat))
@@ -136,15 +181,15 @@ func ValidatePrinter(scanType cautils.ScanTypes, scanContext cautils.ScanningCon
 	if scanType == cautils.ScanTypeImage {
 		// supported types for image scanning
 		switch printFormat {
-		case printer.JsonFormat, printer.PrettyFormat, printer.SARIFFormat:
+		case printer.JsonFormat, printer.PrettyFormat, printer.SARIFFormat, printer.GitLabSASTFormat:
 			return nil
 		default:
 			return fmt.Errorf("format \"%s\"is not supported for image scanning", printFormat)
 		}
 	}
 
-	if printFormat == printer.SARIFFormat {
-		// supported types for SARIF
+	if printFormat == printer.SARIFFormat || printFormat == printer.GitLabCodeQualityFormat || printFormat == printer.GitLabSASTFormat {
+		// supported types for SARIF and GitLab, whose findings are located in files
 		switch scanContext {
 		case cautils.ContextDir, cautils.ContextFile, cautils.ContextGitLocal, cautils.ContextGitRemote:
 			return nil
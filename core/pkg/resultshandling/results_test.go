@@ -3,13 +3,16 @@ package resultshandling
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
 	"github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer"
 	"github.com/kubescape/opa-utils/reporthandling/results/v1/reportsummary"
 	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type DummyReporter struct{}
@@ -60,6 +63,44 @@ func TestResultsHandlerHandleResultsPrintsResultsToUI(t *testing.T) {
 	}
 }
 
+func TestResultsHandlerHandleResultsRecordsHistory(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), history.DefaultFileName)
+	fakeScanData := &cautils.OPASessionObj{
+		Report: &reporthandlingv2.PostureReport{
+			ReportID: "report",
+			SummaryDetails: reportsummary.SummaryDetails{
+				ComplianceScore: 80,
+			},
+		},
+		Metadata: &reporthandlingv2.Metadata{
+			ScanMetadata: reporthandlingv2.ScanMetadata{ScanningTarget: reporthandlingv2.Cluster},
+			ContextMetadata: reporthandlingv2.ContextMetadata{
+				ClusterContextMetadata: &reporthandlingv2.ClusterMetadata{ContextName: "prod"},
+			},
+		},
+	}
+
+	rh := NewResultsHandler(&DummyReporter{}, nil, &SpyPrinter{})
+	rh.HistoryFile = historyFile
+	rh.SetData(fakeScanData)
+	assert.NoError(t, rh.HandleResults(context.TODO()))
+
+	store, err := history.Open(context.TODO(), historyFile)
+	require.NoError(t, err)
+	defer store.Close()
+	scans, err := store.List(context.TODO(), history.Filter{})
+	require.NoError(t, err)
+	require.Len(t, scans, 1)
+	assert.Equal(t, "report", scans[0].ReportID)
+	assert.Equal(t, history.Target{Type: history.TargetCluster, Name: "prod"}, scans[0].Target)
+	assert.Equal(t, float32(80), scans[0].ComplianceScore)
+
+	t.Run("history failure does not fail the scan", func(t *testing.T) {
+		rh.HistoryFile = t.TempDir() // a directory is not a database
+		assert.NoError(t, rh.HandleResults(context.TODO()))
+	})
+}
+
 func TestValidatePrinter(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -166,6 +207,30 @@ func TestValidatePrinter(t *testing.T) {
 			format:      printer.SARIFFormat,
 			expectErr:   nil,
 		},
+		{
+			name:      "gitlab-sast format for image scan should not return error",
+			scanType:  cautils.ScanTypeImage,
+			format:    printer.GitLabSASTFormat,
+			expectErr: nil,
+		},
+		{
+			name:      "gitlab-codequality format for image scan should return error",
+			scanType:  cautils.ScanTypeImage,
+			format:    printer.GitLabCodeQualityFormat,
+			expectErr: errors.New("format \"gitlab-codequality\"is not supported for image scanning"),
+		},
+		{
+			name:        "gitlab-codequality format for cluster context should return error",
+			scanContext: cautils.ContextCluster,
+			format:      printer.GitLabCodeQualityFormat,
+			expectErr:   errors.New("format \"gitlab-codequality\" is only supported when scanning local files"),
+		},
+		{
+			name:        "gitlab-sast format for local dir context should not return error",
+			scanContext: cautils.ContextDir,
+			format:      printer.GitLabSASTFormat,
+			expectErr:   nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -234,6 +299,42 @@ func TestNewPrinter(t *testing.T) {
 			viewType: "resource",
 			version:  defaultVersion,
 		},
+		{
+			name:     "Markdown printer",
+			format:   "markdown",
+			viewType: "control",
+			version:  defaultVersion,
+		},
+		{
+			name:     "CSV printer",
+			format:   "csv",
+			viewType: "resource",
+			version:  defaultVersion,
+		},
+		{
+			name:     "XLSX printer",
+			format:   "xlsx",
+			viewType: "security",
+			version:  defaultVersion,
+		},
+		{
+			name:     "OTLP printer",
+			format:   "otlp",
+			viewType: "security",
+			version:  defaultVersion,
+		},
+		{
+			name:     "GitLab Code Quality printer",
+			format:   "gitlab-codequality",
+			viewType: "control",
+			version:  defaultVersion,
+		},
+		{
+			name:     "GitLab SAST printer",
+			format:   "gitlab-sast",
+			viewType: "control",
+			version:  defaultVersion,
+		},
 		{
 			name:     "Pretty printer",
 			format:   "pretty-printer",
@@ -0,0 +1,115 @@
+package watchhandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+)
+
+// StatusChangeEvent is emitted when the status of a control on a resource changes
+type StatusChangeEvent struct {
+	Time           time.Time           `json:"time"`
+	ClusterName    string              `json:"clusterName,omitempty"`
+	ResourceID     string              `json:"resourceID"`
+	Kind           string              `json:"kind"`
+	Namespace      string              `json:"namespace,omitempty"`
+	Name           string              `json:"name"`
+	ControlID      string              `json:"controlID"`
+	ControlName    string              `json:"controlName,omitempty"`
+	Severity       string              `json:"severity,omitempty"`
+	PreviousStatus apis.ScanningStatus `json:"previousStatus,omitempty"` // empty for new resources
+	Status         apis.ScanningStatus `json:"status"`
+	Message        string              `json:"message"`
+}
+
+// newStatusChangeMessage returns a readable message of the change, e.g. "deployment default/nginx now fails C-0017"
+func newStatusChangeMessage(kind, namespace, name, controlID string, status apis.ScanningStatus) string {
+	if namespace != "" {
+		name = namespace + "/" + name
+	}
+	var verb string
+	switch status {
+	case apis.StatusFailed:
+		verb = "fails"
+	case apis.StatusPassed:
+		verb = "passes"
+	default:
+		verb = fmt.Sprintf("is %s by", status)
+	}
+	return fmt.Sprintf("%s %s now %s %s", strings.ToLower(kind), name, verb, controlID)
+}
+
+// IEventSender sends the status change events
+type IEventSender interface {
+	Send(ctx context.Context, events []StatusChangeEvent) error
+}
+
+var _ IEventSender = &JSONLinesSender{}
+
+// JSONLinesSender writes the events as JSON lines
+type JSONLinesSender struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewJSONLinesSender(writer io.Writer) *JSONLinesSender {
+	return &JSONLinesSender{writer: writer}
+}
+
+func (sender *JSONLinesSender) Send(_ context.Context, events []StatusChangeEvent) error {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	encoder := json.NewEncoder(sender.writer)
+	for i := range events {
+		if err := encoder.Encode(events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ IEventSender = &WebhookSender{}
+
+// WebhookSender posts the events of each evaluation as a JSON array to a webhook
+type WebhookSender struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewWebhookSender(url string, timeout time.Duration) *WebhookSender {
+	return &WebhookSender{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (sender *WebhookSender) Send(ctx context.Context, events []StatusChangeEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sender.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := sender.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send events to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send events to webhook, http-error: %d", resp.StatusCode)
+	}
+	return nil
+}
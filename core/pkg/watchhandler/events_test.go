@@ -0,0 +1,59 @@
+package watchhandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockEvents() []StatusChangeEvent {
+	return []StatusChangeEvent{
+		{ResourceID: "apps/v1/default/Deployment/nginx", ControlID: "C-0017", Status: apis.StatusFailed, Message: "deployment default/nginx now fails C-0017"},
+		{ResourceID: "/v1/default/Pod/nginx", ControlID: "C-0057", Status: apis.StatusPassed, Message: "pod default/nginx now passes C-0057"},
+	}
+}
+
+func TestNewStatusChangeMessage(t *testing.T) {
+	assert.Equal(t, "deployment default/nginx now fails C-0017", newStatusChangeMessage("Deployment", "default", "nginx", "C-0017", apis.StatusFailed))
+	assert.Equal(t, "clusterrole admin now passes C-0035", newStatusChangeMessage("ClusterRole", "", "admin", "C-0035", apis.StatusPassed))
+	assert.Equal(t, "pod default/nginx now is skipped by C-0057", newStatusChangeMessage("Pod", "default", "nginx", "C-0057", apis.StatusSkipped))
+}
+
+func TestJSONLinesSender(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewJSONLinesSender(&buf).Send(context.Background(), mockEvents()))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var event StatusChangeEvent
+	require.NoError(t, json.Unmarshal(lines[0], &event))
+	assert.Equal(t, mockEvents()[0], event)
+}
+
+func TestWebhookSender(t *testing.T) {
+	var received []StatusChangeEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	require.NoError(t, NewWebhookSender(server.URL, time.Second).Send(context.Background(), mockEvents()))
+	assert.Equal(t, mockEvents(), received)
+
+	assert.ErrorContains(t, NewWebhookSender(server.URL+"/fail", time.Second).Send(context.Background(), mockEvents()), "http-error: 500")
+}
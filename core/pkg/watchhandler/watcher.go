@@ -0,0 +1,325 @@
+package watchhandler
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/objectsenvelopes"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// IControlsEvaluator evaluates controls against the current resources of a scan session
+type IControlsEvaluator interface {
+	// EvaluateControls evaluates the given controls, or all the controls when none is given, and returns the results per resource
+	EvaluateControls(ctx context.Context, controlIDs []string) map[string]resourcesresults.Result
+}
+
+// Watcher keeps informers on the resource kinds required by the scanned controls. When resources change, only the
+// controls covering the changed kinds are evaluated again and the status changes are sent as events
+type Watcher struct {
+	dynamicClient      dynamic.Interface
+	sessionObj         *cautils.OPASessionObj
+	evaluator          IControlsEvaluator
+	senders            []IEventSender
+	clusterName        string
+	interval           time.Duration       // changes are batched and evaluated once per interval
+	resourceToControls map[string][]string // controls matching each resource kind, map[<group/version/resource>][]<control ID>
+
+	mu       sync.Mutex                                // guards the resources of the session, the changed kinds and the statuses
+	changed  map[string]bool                           // resource kinds changed since the last evaluation, map[<group/version/resource>]
+	statuses map[string]map[string]apis.ScanningStatus // map[<resource ID>]map[<control ID>]<status>
+}
+
+func NewWatcher(dynamicClient dynamic.Interface, sessionObj *cautils.OPASessionObj, evaluator IControlsEvaluator, clusterName string, interval time.Duration, senders ...IEventSender) *Watcher {
+	return &Watcher{
+		dynamicClient:      dynamicClient,
+		sessionObj:         sessionObj,
+		evaluator:          evaluator,
+		senders:            senders,
+		clusterName:        clusterName,
+		interval:           interval,
+		resourceToControls: mapResourcesToControls(sessionObj.Policies),
+		changed:            make(map[string]bool),
+		statuses:           make(map[string]map[string]apis.ScanningStatus),
+	}
+}
+
+// Run evaluates all the controls once, then watches the resources and evaluates the changes until the context is canceled
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+	w.statuses = getStatuses(w.evaluator.EvaluateControls(ctx, nil), nil)
+	w.mu.Unlock()
+	logger.L().Info("Evaluated the initial posture of the cluster", helpers.Int("resources", len(w.statuses)))
+
+	if err := w.startInformers(ctx); err != nil {
+		return err
+	}
+	logger.L().Success("Watching cluster resources", helpers.Int("kinds", len(w.sessionObj.K8SResources)))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.send(ctx, w.evaluateChanges(ctx))
+		}
+	}
+}
+
+func (w *Watcher) startInformers(ctx context.Context) error {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(w.dynamicClient, 0)
+	var registrations []cache.InformerSynced
+
+	for _, triplet := range w.watchedResources() {
+		group, version, resource := k8sinterface.StringToResourceGroup(triplet)
+		informer := factory.ForResource(schema.GroupVersionResource{Group: group, Version: version, Resource: resource}).Informer()
+
+		registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { w.upsertResource(triplet, obj) },
+			UpdateFunc: func(_, obj interface{}) { w.upsertResource(triplet, obj) },
+			DeleteFunc: func(obj interface{}) { w.deleteResource(triplet, obj) },
+		})
+		if err != nil {
+			return err
+		}
+		registrations = append(registrations, registration.HasSynced)
+	}
+
+	factory.Start(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), registrations...)
+	return nil
+}
+
+// watchedResources returns the Kubernetes resource kinds of the session, the Kubescape resources (host scanner, cloud) are not watched
+func (w *Watcher) watchedResources() []string {
+	triplets := make([]string, 0, len(w.sessionObj.K8SResources))
+	for triplet := range w.sessionObj.K8SResources {
+		triplets = append(triplets, triplet)
+	}
+	sort.Strings(triplets)
+	return triplets
+}
+
+// upsertResource adds or updates a resource of the session. The informers list all the resources when they start,
+// the resources that did not change since they were collected are ignored
+func (w *Watcher) upsertResource(triplet string, obj interface{}) {
+	uObject, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	// resources with a parent are evaluated through their parent, the same way they are when the cluster is scanned
+	if k8sinterface.IsTypeWorkload(uObject.Object) && k8sinterface.WorkloadHasParent(workloadinterface.NewWorkloadObj(uObject.Object)) {
+		return
+	}
+	resource := objectsenvelopes.NewObject(uObject.DeepCopy().Object)
+	if resource == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	resourceID := resource.GetID()
+	current, exists := w.sessionObj.AllResources[resourceID]
+	if exists && current.GetObjectType() == workloadinterface.TypeWorkloadObject &&
+		workloadinterface.NewWorkloadObj(current.GetObject()).GetResourceVersion() == uObject.GetResourceVersion() {
+		return
+	}
+	if !exists {
+		w.sessionObj.K8SResources[triplet] = append(w.sessionObj.K8SResources[triplet], resourceID)
+	}
+	w.sessionObj.AllResources[resourceID] = resource
+	w.changed[triplet] = true
+}
+
+func (w *Watcher) deleteResource(triplet string, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	uObject, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	resource := objectsenvelopes.NewObject(uObject.Object)
+	if resource == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	resourceID := resource.GetID()
+	if _, ok := w.sessionObj.AllResources[resourceID]; !ok {
+		return
+	}
+	delete(w.sessionObj.AllResources, resourceID)
+	w.sessionObj.K8SResources[triplet] = slices.DeleteFunc(w.sessionObj.K8SResources[triplet], func(id string) bool { return id == resourceID })
+	delete(w.statuses, resourceID)
+	w.changed[triplet] = true
+}
+
+// evaluateChanges evaluates the controls covering the resource kinds changed since the last evaluation and returns the status changes
+func (w *Watcher) evaluateChanges(ctx context.Context) []StatusChangeEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.changed) == 0 {
+		return nil
+	}
+	controlIDs := w.controlsOfResources(w.changed)
+	w.changed = make(map[string]bool)
+	if len(controlIDs) == 0 {
+		return nil
+	}
+
+	logger.L().Debug("Evaluating controls of changed resources", helpers.String("controls", strings.Join(controlIDs, ",")))
+	statuses := getStatuses(w.evaluator.EvaluateControls(ctx, controlIDs), controlIDs)
+	return w.updateStatuses(controlIDs, statuses)
+}
+
+// controlsOfResources returns the sorted IDs of the controls covering the given resource kinds
+func (w *Watcher) controlsOfResources(triplets map[string]bool) []string {
+	var controlIDs []string
+	for triplet := range triplets {
+		for _, controlID := range w.resourceToControls[triplet] {
+			if !slices.Contains(controlIDs, controlID) {
+				controlIDs = append(controlIDs, controlID)
+			}
+		}
+	}
+	sort.Strings(controlIDs)
+	return controlIDs
+}
+
+// mapResourcesToControls maps the Kubernetes resource kinds matched by the rules of each control to the control IDs.
+// The session only maps the Kubescape resources (host scanner, cloud) to their controls
+func mapResourcesToControls(frameworks []reporthandling.Framework) map[string][]string {
+	resourceToControls := make(map[string][]string)
+	for _, framework := range frameworks {
+		for _, control := range framework.Controls {
+			for _, rule := range control.Rules {
+				for _, match := range rule.Match {
+					for _, apiGroup := range match.APIGroups {
+						for _, apiVersion := range match.APIVersions {
+							for _, resource := range match.Resources {
+								for _, groupResource := range k8sinterface.ResourceGroupToString(apiGroup, apiVersion, resource) {
+									if !slices.Contains(resourceToControls[groupResource], control.ControlID) {
+										resourceToControls[groupResource] = append(resourceToControls[groupResource], control.ControlID)
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return resourceToControls
+}
+
+// updateStatuses replaces the statuses of the evaluated controls and returns the changes. New resources are reported only when they do not pass
+func (w *Watcher) updateStatuses(controlIDs []string, statuses map[string]map[string]apis.ScanningStatus) []StatusChangeEvent {
+	var events []StatusChangeEvent
+	now := time.Now().UTC()
+
+	for resourceID, controls := range statuses {
+		if _, ok := w.statuses[resourceID]; !ok {
+			w.statuses[resourceID] = make(map[string]apis.ScanningStatus)
+		}
+		for controlID, status := range controls {
+			previousStatus := w.statuses[resourceID][controlID]
+			w.statuses[resourceID][controlID] = status
+			if previousStatus == status || (previousStatus == "" && status == apis.StatusPassed) {
+				continue
+			}
+			events = append(events, w.newStatusChangeEvent(now, resourceID, controlID, previousStatus, status))
+		}
+	}
+
+	// the evaluated controls no longer apply to these resources
+	for resourceID := range w.statuses {
+		for _, controlID := range controlIDs {
+			if _, ok := statuses[resourceID][controlID]; !ok {
+				delete(w.statuses[resourceID], controlID)
+			}
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].ResourceID == events[j].ResourceID {
+			return events[i].ControlID < events[j].ControlID
+		}
+		return events[i].ResourceID < events[j].ResourceID
+	})
+	return events
+}
+
+func (w *Watcher) newStatusChangeEvent(now time.Time, resourceID, controlID string, previousStatus, status apis.ScanningStatus) StatusChangeEvent {
+	event := StatusChangeEvent{
+		Time:           now,
+		ClusterName:    w.clusterName,
+		ResourceID:     resourceID,
+		ControlID:      controlID,
+		PreviousStatus: previousStatus,
+		Status:         status,
+	}
+	if resource, ok := w.sessionObj.AllResources[resourceID]; ok {
+		event.Kind = resource.GetKind()
+		event.Namespace = resource.GetNamespace()
+		event.Name = resource.GetName()
+	}
+	if w.sessionObj.AllPolicies != nil {
+		if control, ok := w.sessionObj.AllPolicies.Controls[controlID]; ok {
+			event.ControlName = control.Name
+			event.Severity = apis.ControlSeverityToString(control.BaseScore)
+		}
+	}
+	event.Message = newStatusChangeMessage(event.Kind, event.Namespace, event.Name, controlID, status)
+	return event
+}
+
+func (w *Watcher) send(ctx context.Context, events []StatusChangeEvent) {
+	if len(events) == 0 {
+		return
+	}
+	for _, sender := range w.senders {
+		if err := sender.Send(ctx, events); err != nil {
+			logger.L().Ctx(ctx).Warning("failed to send status change events", helpers.Error(err))
+		}
+	}
+}
+
+// getStatuses returns the status of each control per resource, only the given controls are kept when controlIDs is not empty
+func getStatuses(results map[string]resourcesresults.Result, controlIDs []string) map[string]map[string]apis.ScanningStatus {
+	statuses := make(map[string]map[string]apis.ScanningStatus, len(results))
+	for resourceID, result := range results {
+		for _, control := range result.ListControls() {
+			if len(controlIDs) > 0 && !slices.Contains(controlIDs, control.GetID()) {
+				continue
+			}
+			if _, ok := statuses[resourceID]; !ok {
+				statuses[resourceID] = make(map[string]apis.ScanningStatus)
+			}
+			statuses[resourceID][control.GetID()] = control.GetStatus(nil).Status()
+		}
+	}
+	return statuses
+}
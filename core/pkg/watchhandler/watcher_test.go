@@ -0,0 +1,244 @@
+package watchhandler
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armosec/armoapi-go/armotypes"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/opa-utils/reporthandling"
+	"github.com/kubescape/opa-utils/reporthandling/apis"
+	"github.com/kubescape/opa-utils/reporthandling/results/v1/resourcesresults"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+var deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func mockDeployment(name, resourceVersion string, privileged bool) *unstructured.Unstructured {
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetName(name)
+	deployment.SetNamespace("default")
+	deployment.SetResourceVersion(resourceVersion)
+	_ = unstructured.SetNestedSlice(deployment.Object, []interface{}{
+		map[string]interface{}{"name": "nginx", "image": "nginx", "securityContext": map[string]interface{}{"privileged": privileged}},
+	}, "spec", "template", "spec", "containers")
+	return deployment
+}
+
+// privilegedEvaluator fails the privileged workloads on C-0057, the other controls always pass
+type privilegedEvaluator struct {
+	sessionObj *cautils.OPASessionObj
+	mu         sync.Mutex
+	calls      [][]string
+}
+
+func (e *privilegedEvaluator) EvaluateControls(_ context.Context, controlIDs []string) map[string]resourcesresults.Result {
+	e.mu.Lock()
+	e.calls = append(e.calls, controlIDs)
+	e.mu.Unlock()
+
+	if len(controlIDs) == 0 {
+		controlIDs = []string{"C-0057", "C-0017"}
+	}
+	results := map[string]resourcesresults.Result{}
+	for resourceID, resource := range e.sessionObj.AllResources {
+		result := resourcesresults.Result{ResourceID: resourceID}
+		for _, controlID := range controlIDs {
+			status := apis.StatusPassed
+			containers, _ := workloadinterface.NewWorkloadObj(resource.GetObject()).GetContainers()
+			if controlID == "C-0057" && len(containers) > 0 && containers[0].SecurityContext != nil && *containers[0].SecurityContext.Privileged {
+				status = apis.StatusFailed
+			}
+			control := resourcesresults.ResourceAssociatedControl{ControlID: controlID}
+			control.ResourceAssociatedRules = []resourcesresults.ResourceAssociatedRule{{Name: "rule", Status: status}}
+			control.SetStatus(reporthandling.Control{})
+			result.AssociatedControls = append(result.AssociatedControls, control)
+		}
+		results[resourceID] = result
+	}
+	return results
+}
+
+func (e *privilegedEvaluator) getCalls() [][]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.calls)
+}
+
+// channelSender forwards the events to a channel
+type channelSender chan StatusChangeEvent
+
+func (sender channelSender) Send(_ context.Context, events []StatusChangeEvent) error {
+	for i := range events {
+		sender <- events[i]
+	}
+	return nil
+}
+
+func receiveEvent(t *testing.T, events channelSender) StatusChangeEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for status change event")
+	}
+	return StatusChangeEvent{}
+}
+
+var (
+	workloadsMatch = reporthandling.RuleMatchObjects{
+		APIGroups:   []string{"apps"},
+		APIVersions: []string{"v1"},
+		Resources:   []string{"Deployment"},
+	}
+	podsMatch = reporthandling.RuleMatchObjects{
+		APIGroups:   []string{""},
+		APIVersions: []string{"v1"},
+		Resources:   []string{"Pod"},
+	}
+)
+
+func TestWatcher(t *testing.T) {
+	nginx := mockDeployment("nginx", "1", false)
+	sessionObj := cautils.NewOPASessionObjMock()
+	sessionObj.AllResources["apps/v1/default/Deployment/nginx"] = workloadinterface.NewWorkloadObj(nginx.DeepCopy().Object)
+	sessionObj.K8SResources = cautils.K8SResources{"apps/v1/deployments": {"apps/v1/default/Deployment/nginx"}}
+	sessionObj.Policies = []reporthandling.Framework{{Controls: []reporthandling.Control{
+		{ControlID: "C-0057", Rules: []reporthandling.PolicyRule{{Match: []reporthandling.RuleMatchObjects{workloadsMatch, podsMatch}}}},
+		{ControlID: "C-0017", Rules: []reporthandling.PolicyRule{{Match: []reporthandling.RuleMatchObjects{podsMatch}}}},
+	}}}
+	sessionObj.AllPolicies = &cautils.Policies{Controls: map[string]reporthandling.Control{
+		"C-0057": {ControlID: "C-0057", PortalBase: armotypes.PortalBase{Name: "Privileged container"}, BaseScore: 8},
+	}}
+
+	dynamicClient := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{deploymentsGVR: "DeploymentList"}, nginx)
+	evaluator := &privilegedEvaluator{sessionObj: sessionObj}
+	events := make(channelSender, 10)
+	watcher := NewWatcher(dynamicClient, sessionObj, evaluator, "test-cluster", 10*time.Millisecond, events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watcher.Run(ctx) }()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	// wait for the informers to list the resources
+	require.Eventually(t, func() bool { return len(evaluator.getCalls()) > 0 }, 10*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	t.Run("updated resource now fails", func(t *testing.T) {
+		_, err := dynamicClient.Resource(deploymentsGVR).Namespace("default").Update(ctx, mockDeployment("nginx", "2", true), metav1.UpdateOptions{})
+		require.NoError(t, err)
+
+		event := receiveEvent(t, events)
+		assert.Equal(t, "deployment default/nginx now fails C-0057", event.Message)
+		assert.Equal(t, "test-cluster", event.ClusterName)
+		assert.Equal(t, "apps/v1/default/Deployment/nginx", event.ResourceID)
+		assert.Equal(t, "Deployment", event.Kind)
+		assert.Equal(t, "Privileged container", event.ControlName)
+		assert.Equal(t, "High", event.Severity)
+		assert.Equal(t, apis.StatusPassed, event.PreviousStatus)
+		assert.Equal(t, apis.StatusFailed, event.Status)
+
+		// only the controls covering deployments are evaluated again
+		calls := evaluator.getCalls()
+		assert.Nil(t, calls[0])
+		assert.Equal(t, []string{"C-0057"}, calls[len(calls)-1])
+	})
+
+	t.Run("new failing resource", func(t *testing.T) {
+		_, err := dynamicClient.Resource(deploymentsGVR).Namespace("default").Create(ctx, mockDeployment("redis", "3", true), metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		event := receiveEvent(t, events)
+		assert.Equal(t, "deployment default/redis now fails C-0057", event.Message)
+		assert.Equal(t, apis.ScanningStatus(""), event.PreviousStatus)
+	})
+
+	t.Run("fixed resource now passes", func(t *testing.T) {
+		_, err := dynamicClient.Resource(deploymentsGVR).Namespace("default").Update(ctx, mockDeployment("nginx", "4", false), metav1.UpdateOptions{})
+		require.NoError(t, err)
+
+		event := receiveEvent(t, events)
+		assert.Equal(t, "deployment default/nginx now passes C-0057", event.Message)
+		assert.Equal(t, apis.StatusFailed, event.PreviousStatus)
+	})
+
+	t.Run("deleted resource", func(t *testing.T) {
+		require.NoError(t, dynamicClient.Resource(deploymentsGVR).Namespace("default").Delete(ctx, "redis", metav1.DeleteOptions{}))
+		require.Eventually(t, func() bool {
+			watcher.mu.Lock()
+			defer watcher.mu.Unlock()
+			_, ok := sessionObj.AllResources["apps/v1/default/Deployment/redis"]
+			return !ok
+		}, 10*time.Second, 10*time.Millisecond)
+
+		watcher.mu.Lock()
+		assert.Equal(t, []string{"apps/v1/default/Deployment/nginx"}, sessionObj.K8SResources["apps/v1/deployments"])
+		watcher.mu.Unlock()
+		select {
+		case event := <-events:
+			t.Errorf("unexpected event: %s", event.Message)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
+
+func TestUpdateStatuses(t *testing.T) {
+	sessionObj := cautils.NewOPASessionObjMock()
+	watcher := NewWatcher(nil, sessionObj, nil, "", time.Second)
+	watcher.statuses = map[string]map[string]apis.ScanningStatus{
+		"a": {"C-0001": apis.StatusPassed, "C-0002": apis.StatusFailed},
+		"b": {"C-0001": apis.StatusFailed},
+	}
+
+	events := watcher.updateStatuses([]string{"C-0001"}, map[string]map[string]apis.ScanningStatus{
+		"a": {"C-0001": apis.StatusFailed},
+		"c": {"C-0001": apis.StatusPassed},
+	})
+	require.Len(t, events, 1)
+	assert.Equal(t, "a", events[0].ResourceID)
+	assert.Equal(t, apis.StatusFailed, events[0].Status)
+
+	assert.Equal(t, map[string]map[string]apis.ScanningStatus{
+		"a": {"C-0001": apis.StatusFailed, "C-0002": apis.StatusFailed},
+		"b": {},
+		"c": {"C-0001": apis.StatusPassed},
+	}, watcher.statuses)
+}
+
+func TestMapResourcesToControls(t *testing.T) {
+	frameworks := []reporthandling.Framework{
+		{
+			Controls: []reporthandling.Control{
+				{ControlID: "C-0017", Rules: []reporthandling.PolicyRule{{Match: []reporthandling.RuleMatchObjects{workloadsMatch, podsMatch}}}},
+				{ControlID: "C-0057", Rules: []reporthandling.PolicyRule{{Match: []reporthandling.RuleMatchObjects{podsMatch}}}},
+			},
+		},
+		{
+			Controls: []reporthandling.Control{
+				{ControlID: "C-0017", Rules: []reporthandling.PolicyRule{{Match: []reporthandling.RuleMatchObjects{workloadsMatch}}}},
+			},
+		},
+	}
+
+	assert.Equal(t, map[string][]string{
+		"apps/v1/deployments": {"C-0017"},
+		"/v1/pods":            {"C-0017", "C-0057"},
+	}, mapResourcesToControls(frameworks))
+}
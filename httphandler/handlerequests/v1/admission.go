@@ -0,0 +1,147 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	"github.com/kubescape/kubescape/v3/core/core"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	utilsmetav1 "github.com/kubescape/opa-utils/httpserver/meta/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+const defaultAdmissionPoliciesCacheTTL = 10 * time.Minute
+
+// IAdmissionValidator evaluates the resources of admission requests
+type IAdmissionValidator interface {
+	Validate(ctx context.Context, request *admissionv1.AdmissionRequest) (*admissionv1.AdmissionResponse, error)
+}
+
+var newAdmissionValidatorImpl = newAdmissionValidator // Override for testing
+
+// ============================================== ADMISSION ========================================================
+// Admission API - ValidatingAdmissionWebhook evaluating the admitted resources against the configured frameworks or controls
+func (handler *HTTPHandler) Admission(w http.ResponseWriter, r *http.Request) {
+	defer handler.recover(r.Context(), w, "")
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+	review := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+		handler.writeError(w, fmt.Errorf("failed to decode admission review, reason: %s", err.Error()), "")
+		return
+	}
+	if review.Request == nil {
+		handler.writeError(w, fmt.Errorf("admission review has no request"), "")
+		return
+	}
+	logger.L().Debug("admission request", helpers.String("UID", string(review.Request.UID)), helpers.String("kind", review.Request.Kind.Kind), helpers.String("operation", string(review.Request.Operation)))
+
+	validator, err := handler.getAdmissionValidator()
+	if err != nil {
+		handler.writeInternalError(r.Context(), w, err)
+		return
+	}
+	response, err := validator.Validate(r.Context(), review.Request)
+	if err != nil {
+		handler.writeInternalError(r.Context(), w, err)
+		return
+	}
+
+	b, err := json.Marshal(admissionv1.AdmissionReview{TypeMeta: review.TypeMeta, Response: response})
+	if err != nil {
+		handler.writeInternalError(r.Context(), w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// getAdmissionValidator returns the admission validator, it is created on the first admission request
+func (handler *HTTPHandler) getAdmissionValidator() (IAdmissionValidator, error) {
+	handler.admissionMu.Lock()
+	defer handler.admissionMu.Unlock()
+
+	if handler.admissionValidator != nil {
+		return handler.admissionValidator, nil
+	}
+	validator, err := newAdmissionValidatorImpl(getAdmissionInfo(), getAdmissionScanInfo(handler.offline))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admission validator: %w", err)
+	}
+	handler.admissionValidator = validator
+	return validator, nil
+}
+
+// writeInternalError fails the admission request, the API server then applies the failure policy of the webhook
+func (handler *HTTPHandler) writeInternalError(ctx context.Context, w http.ResponseWriter, err error) {
+	logger.L().Ctx(ctx).Error("admission request failed", helpers.Error(err))
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(responseToBytes(&utilsmetav1.Response{Type: apisv1.ErrorScanResponseType, Response: err.Error()}))
+}
+
+func newAdmissionValidator(admissionInfo *metav1.AdmissionInfo, scanInfo *cautils.ScanInfo) (IAdmissionValidator, error) {
+	return core.NewKubescape(context.Background()).NewAdmissionValidator(admissionInfo, scanInfo)
+}
+
+func getAdmissionInfo() *metav1.AdmissionInfo {
+	admissionInfo := &metav1.AdmissionInfo{
+		SeverityThreshold: envToString("KS_ADMISSION_SEVERITY_THRESHOLD", "high"), // failed controls at or above this severity deny the request
+		WarnOnly:          envToBool("KS_ADMISSION_WARN_ONLY", false),             // never deny, only return warnings
+		PoliciesCacheTTL:  defaultAdmissionPoliciesCacheTTL,
+	}
+	if ttl, err := time.ParseDuration(envToString("KS_ADMISSION_POLICIES_CACHE_TTL", "")); err == nil {
+		admissionInfo.PoliciesCacheTTL = ttl
+	}
+	return admissionInfo
+}
+
+// getAdmissionScanInfo returns the scan info of the admission requests. The controls are selected by KS_ADMISSION_CONTROLS,
+// otherwise the frameworks are selected by KS_ADMISSION_FRAMEWORKS
+func getAdmissionScanInfo(offline bool) *cautils.ScanInfo {
+	scanRequest := &utilsmetav1.PostScanRequest{
+		TargetType:  apisv1.KindFramework,
+		TargetNames: splitEnv("KS_ADMISSION_FRAMEWORKS", strings.Join(getter.NativeFrameworks, ",")),
+	}
+	if controls := splitEnv("KS_ADMISSION_CONTROLS", ""); len(controls) > 0 {
+		scanRequest.TargetType = apisv1.KindControl
+		scanRequest.TargetNames = controls
+	}
+
+	scanInfo := defaultScanInfo()
+	setTargetInScanInfo(scanRequest, scanInfo)
+	scanInfo.Submit = false
+	scanInfo.Local = true
+	scanInfo.HostSensorEnabled.SetBool(false)
+	scanInfo.UseExceptions = envToString("KS_ADMISSION_EXCEPTIONS", "") // path to the exceptions file
+	if offline {
+		scanInfo.UseDefault = true
+		scanInfo.UseArtifactsFrom = getter.DefaultLocalStore
+	}
+	return scanInfo
+}
+
+// splitEnv returns the comma separated values of an environment variable
+func splitEnv(env string, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(envToString(env, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,134 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v3/core/cautils"
+	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	metav1 "github.com/kubescape/kubescape/v3/core/meta/datastructures/v1"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	k8smetav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// denyingValidator denies the pods and allows the other kinds
+type denyingValidator struct{}
+
+func (denyingValidator) Validate(_ context.Context, request *admissionv1.AdmissionRequest) (*admissionv1.AdmissionResponse, error) {
+	if request.Kind.Kind != "Pod" {
+		return &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}, nil
+	}
+	return &admissionv1.AdmissionResponse{UID: request.UID, Allowed: false, Result: &k8smetav1.Status{Message: "C-0057"}}, nil
+}
+
+func admissionReviewBody(t *testing.T, kind string) *bytes.Reader {
+	t.Helper()
+	b, err := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: k8smetav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  &admissionv1.AdmissionRequest{UID: "uid", Kind: k8smetav1.GroupVersionKind{Version: "v1", Kind: kind}},
+	})
+	require.NoError(t, err)
+	return bytes.NewReader(b)
+}
+
+func TestAdmission(t *testing.T) {
+	defer func(o func(*metav1.AdmissionInfo, *cautils.ScanInfo) (IAdmissionValidator, error)) {
+		newAdmissionValidatorImpl = o
+	}(newAdmissionValidatorImpl)
+	var created int
+	newAdmissionValidatorImpl = func(*metav1.AdmissionInfo, *cautils.ScanInfo) (IAdmissionValidator, error) {
+		created++
+		return denyingValidator{}, nil
+	}
+
+	h := NewHTTPHandler(false)
+	review := func(t *testing.T, kind string) *admissionv1.AdmissionReview {
+		w := httptest.NewRecorder()
+		h.Admission(w, httptest.NewRequest(http.MethodPost, "/v1/admission", admissionReviewBody(t, kind)))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		response := &admissionv1.AdmissionReview{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), response))
+		assert.Equal(t, "AdmissionReview", response.Kind)
+		require.NotNil(t, response.Response)
+		assert.Equal(t, "uid", string(response.Response.UID))
+		return response
+	}
+
+	assert.False(t, review(t, "Pod").Response.Allowed)
+	assert.True(t, review(t, "ConfigMap").Response.Allowed)
+	assert.Equal(t, 1, created)
+
+	t.Run("method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.Admission(w, httptest.NewRequest(http.MethodGet, "/v1/admission", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("missing request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.Admission(w, httptest.NewRequest(http.MethodPost, "/v1/admission", bytes.NewReader([]byte("{}"))))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAdmissionValidatorError(t *testing.T) {
+	defer func(o func(*metav1.AdmissionInfo, *cautils.ScanInfo) (IAdmissionValidator, error)) {
+		newAdmissionValidatorImpl = o
+	}(newAdmissionValidatorImpl)
+	newAdmissionValidatorImpl = func(*metav1.AdmissionInfo, *cautils.ScanInfo) (IAdmissionValidator, error) {
+		return nil, errors.New("unknown severity")
+	}
+
+	w := httptest.NewRecorder()
+	NewHTTPHandler(false).Admission(w, httptest.NewRequest(http.MethodPost, "/v1/admission", admissionReviewBody(t, "Pod")))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown severity")
+}
+
+func TestGetAdmissionInfo(t *testing.T) {
+	admissionInfo := getAdmissionInfo()
+	assert.Equal(t, "high", admissionInfo.SeverityThreshold)
+	assert.False(t, admissionInfo.WarnOnly)
+	assert.Equal(t, defaultAdmissionPoliciesCacheTTL, admissionInfo.PoliciesCacheTTL)
+
+	t.Setenv("KS_ADMISSION_SEVERITY_THRESHOLD", "critical")
+	t.Setenv("KS_ADMISSION_WARN_ONLY", "true")
+	t.Setenv("KS_ADMISSION_POLICIES_CACHE_TTL", "1h")
+	admissionInfo = getAdmissionInfo()
+	assert.Equal(t, "critical", admissionInfo.SeverityThreshold)
+	assert.True(t, admissionInfo.WarnOnly)
+	assert.Equal(t, time.Hour, admissionInfo.PoliciesCacheTTL)
+}
+
+func TestGetAdmissionScanInfo(t *testing.T) {
+	t.Run("default frameworks", func(t *testing.T) {
+		scanInfo := getAdmissionScanInfo(false)
+		assert.True(t, scanInfo.FrameworkScan)
+		assert.False(t, scanInfo.Submit)
+		assert.False(t, scanInfo.HostSensorEnabled.GetBool())
+		require.Len(t, scanInfo.PolicyIdentifier, len(getter.NativeFrameworks))
+		assert.Equal(t, apisv1.KindFramework, scanInfo.PolicyIdentifier[0].Kind)
+	})
+
+	t.Run("controls", func(t *testing.T) {
+		t.Setenv("KS_ADMISSION_CONTROLS", "C-0057, C-0017")
+		scanInfo := getAdmissionScanInfo(true)
+		assert.False(t, scanInfo.FrameworkScan)
+		assert.True(t, scanInfo.UseDefault)
+		assert.Equal(t, []cautils.PolicyIdentifier{
+			{Identifier: "C-0057", Kind: apisv1.KindControl},
+			{Identifier: "C-0017", Kind: apisv1.KindControl},
+		}, scanInfo.PolicyIdentifier)
+	})
+}
@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/schema"
+	"github.com/kubescape/go-logger"
+	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
+	utilsapisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	utilsmetav1 "github.com/kubescape/opa-utils/httpserver/meta/v1"
+)
+
+// History http listener for the score trends and control regressions of the scans recorded in the history store
+func (handler *HTTPHandler) History(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	defer handler.recover(r.Context(), w, "")
+
+	defer r.Body.Close()
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	historyQueryParams := &HistoryQueryParams{}
+	if err := schema.NewDecoder().Decode(historyQueryParams, r.URL.Query()); err != nil {
+		handler.writeError(w, fmt.Errorf("failed to parse query params, reason: %s", err.Error()), "")
+		return
+	}
+	filter, err := newHistoryFilter(historyQueryParams, time.Now())
+	if err != nil {
+		handler.writeError(w, err, "")
+		return
+	}
+	logger.L().Info("requesting history", helpers.String("target", historyQueryParams.Target), helpers.String("api", "v1/history"))
+
+	report, err := history.LoadReport(r.Context(), historyFile(), filter)
+	if errors.Is(err, history.ErrNoHistory) {
+		// no scan recorded yet
+		report, err = history.NewReport(nil, filter.Framework), nil
+	}
+	if err != nil {
+		logger.L().Ctx(r.Context()).Error("failed to load history", helpers.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(responseToBytes(&utilsmetav1.Response{Type: utilsapisv1.ErrorScanResponseType, Response: err.Error()}))
+		return
+	}
+
+	content, err := json.Marshal(report)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+func newHistoryFilter(historyQueryParams *HistoryQueryParams, now time.Time) (history.Filter, error) {
+	filter := history.Filter{
+		Target:    history.Target{Name: historyQueryParams.Target},
+		Framework: historyQueryParams.Framework,
+		Limit:     historyQueryParams.Limit,
+	}
+	if historyQueryParams.Since != "" {
+		since, err := time.ParseDuration(historyQueryParams.Since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid since %q, reason: %s", historyQueryParams.Since, err.Error())
+		}
+		filter.Since = now.Add(-since)
+	}
+	return filter, nil
+}
+
+// historyFile returns the path of the history store the scans are recorded in
+func historyFile() string {
+	return envToString("KS_HISTORY_FILE", history.DefaultFile())
+}
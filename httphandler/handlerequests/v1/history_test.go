@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubescape/kubescape/v3/core/pkg/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHistoryFilter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	filter, err := newHistoryFilter(&HistoryQueryParams{Target: "prod", Framework: "nsa", Since: "24h", Limit: 5}, now)
+	require.NoError(t, err)
+	assert.Equal(t, history.Filter{Target: history.Target{Name: "prod"}, Framework: "nsa", Since: now.Add(-24 * time.Hour), Limit: 5}, filter)
+
+	_, err = newHistoryFilter(&HistoryQueryParams{Since: "a week"}, now)
+	assert.Error(t, err)
+}
+
+func TestHistory(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), history.DefaultFileName)
+	t.Setenv("KS_HISTORY_FILE", historyFile)
+
+	h := &HTTPHandler{state: newServerState()}
+	getHistory := func(query string) (int, history.Report) {
+		w := httptest.NewRecorder()
+		h.History(w, httptest.NewRequest(http.MethodGet, "/v1/history"+query, nil))
+		report := history.Report{}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+		}
+		return w.Code, report
+	}
+
+	// no scan recorded yet
+	code, report := getHistory("")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, report.Trends)
+
+	store, err := history.Open(context.TODO(), historyFile)
+	require.NoError(t, err)
+	start := time.Now().Add(-2 * time.Hour)
+	for i, target := range []string{"prod", "prod", "staging"} {
+		require.NoError(t, store.Add(context.TODO(), &history.Scan{
+			ReportID:        target,
+			Target:          history.Target{Type: history.TargetCluster, Name: target},
+			ScannedAt:       start.Add(time.Duration(i) * time.Hour),
+			ComplianceScore: float32(60 + 10*i),
+		}))
+	}
+	require.NoError(t, store.Close())
+
+	code, report = getHistory("")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, report.Trends, 2)
+
+	code, report = getHistory("?target=prod&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, report.Trends, 1)
+	require.Len(t, report.Trends[0].Points, 1)
+	assert.Equal(t, float32(70), report.Trends[0].Points[0].Score)
+
+	code, _ = getHistory("?since=invalid")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	w := httptest.NewRecorder()
+	h.History(w, httptest.NewRequest(http.MethodPost, "/v1/history", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -6,21 +6,136 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kubescape/go-logger"
 	"github.com/kubescape/go-logger/helpers"
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	printerv2 "github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer/v2"
 	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
 	utilsapisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
 	utilsmetav1 "github.com/kubescape/opa-utils/httpserver/meta/v1"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// Metrics http listener for prometheus support
+const (
+	// defaultMetricsRefreshInterval is the age of the cached metrics after which a request triggers a new scan
+	defaultMetricsRefreshInterval = 10 * time.Minute
+
+	// metricsRetryInterval is the time after a failed scan before a request triggers a new scan
+	metricsRetryInterval = time.Minute
+)
+
+// metricsCache keeps the metrics of the latest scan of the metrics scope in memory
+type metricsCache struct {
+	metrics         []byte
+	scannedAt       time.Time
+	err             error // error of the latest metrics scan, if it failed
+	failedAt        time.Time
+	refreshing      bool
+	refreshInterval time.Duration
+	mtx             sync.RWMutex
+}
+
+func newMetricsCache(refreshInterval time.Duration) *metricsCache {
+	return &metricsCache{
+		refreshInterval: refreshInterval,
+		mtx:             sync.RWMutex{},
+	}
+}
+
+// get returns the cached metrics, nil if no scan succeeded yet, and the error of the latest metrics scan
+func (c *metricsCache) get() ([]byte, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.metrics, c.err
+}
+
+// startRefresh returns whether a scan should be started for the metrics, i.e. they are missing or older than the refresh
+// interval, no metrics scan is in progress and the latest one did not fail recently. If so, the metrics scan is marked
+// as in progress until it ends
+func (c *metricsCache) startRefresh(now time.Time) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.refreshing || (c.err != nil && now.Sub(c.failedAt) < metricsRetryInterval) || (c.metrics != nil && now.Sub(c.scannedAt) < c.refreshInterval) {
+		return false
+	}
+	c.refreshing = true
+	return true
+}
+
+// endRefresh ends the metrics scan, the metrics of a successful scan are set when the scan completes and the previous
+// metrics are kept if it failed
+func (c *metricsCache) endRefresh(err error, failedAt time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.refreshing = false
+	if err != nil {
+		c.err = err
+		c.failedAt = failedAt
+	}
+}
+
+// set sets the metrics of a completed scan of the metrics scope
+func (c *metricsCache) set(metrics []byte, scannedAt time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.metrics = metrics
+	c.scannedAt = scannedAt
+	c.err = nil
+}
+
+// inMetricsScope returns whether a scan has the policies and namespaces of the metrics scan, its results then replace the
+// cached metrics without changing the meaning of the series
+func inMetricsScope(scanInfo *cautils.ScanInfo) bool {
+	metricsScanInfo := getPrometheusDefaultScanCommand("", "")
+	return scanInfo.ScanAll == metricsScanInfo.ScanAll &&
+		scanInfo.IncludeNamespaces == metricsScanInfo.IncludeNamespaces &&
+		scanInfo.ExcludedNamespaces == metricsScanInfo.ExcludedNamespaces &&
+		slices.Equal(scanInfo.PolicyIdentifier, metricsScanInfo.PolicyIdentifier)
+}
+
+// Metrics http listener for prometheus support. The metrics of the latest completed scan of the metrics scope are served
+// from memory, a scan is triggered in the background when they are missing or older than the refresh interval
 func (handler *HTTPHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	if handler.metrics.startRefresh(time.Now()) {
+		go handler.refreshMetrics(trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(r.Context())))
+	}
+
+	metrics, err := handler.metrics.get()
+	if metrics == nil {
+		response := &utilsmetav1.Response{}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			response.Type = utilsapisv1.ErrorScanResponseType
+			response.Response = err.Error()
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			response.Type = utilsapisv1.BusyScanResponseType
+			response.Response = "metrics are not ready yet, scanning in progress"
+		}
+		w.Write(responseToBytes(response))
+		return
+	}
 
+	w.WriteHeader(http.StatusOK)
+	w.Write(metrics)
+}
+
+// refreshMetrics scans with the default prometheus scan command, the metrics are cached when the scan completes
+func (handler *HTTPHandler) refreshMetrics(ctx context.Context) {
+	err := handler.scanMetrics(ctx)
+	if err != nil {
+		logger.L().Ctx(ctx).Error("failed to refresh metrics", helpers.Error(err))
+	}
+	handler.metrics.endRefresh(err, time.Now())
+}
+
+func (handler *HTTPHandler) scanMetrics(ctx context.Context) error {
 	scanID := uuid.NewString()
 	handler.state.setBusy(scanID)
 	defer handler.state.setNotBusy(scanID)
@@ -35,7 +150,7 @@ func (handler *HTTPHandler) Metrics(w http.ResponseWriter, r *http.Request) {
 		},
 		scanInfo: scanInfo,
 		scanID:   scanID,
-		ctx:      trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(r.Context())),
+		ctx:      ctx,
 		resp:     make(chan *utilsmetav1.Response, 1),
 	}
 
@@ -48,25 +163,20 @@ func (handler *HTTPHandler) Metrics(w http.ResponseWriter, r *http.Request) {
 	defer removeResultsFile(scanID) // remove json format results file
 	defer os.Remove(resultsFile)    // remove prometheus format results file
 
-	// handle response
 	if results.Type == utilsapisv1.ErrorScanResponseType {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(responseToBytes(results))
-		return
+		return fmt.Errorf("%v", results.Response)
 	}
+	return nil
+}
 
-	// read prometheus format results file
-	f, err := os.ReadFile(resultsFile)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		results.Type = utilsapisv1.ErrorScanResponseType
-		results.Response = fmt.Sprintf("failed read results from file. reason: %s", err.Error())
-		w.Write(responseToBytes(results))
-		return
+// getPrometheusBreakdown returns the configuration of the namespaces and workloads metrics
+func getPrometheusBreakdown() printerv2.PrometheusBreakdown {
+	return printerv2.PrometheusBreakdown{
+		Namespaces:        envToBool("KS_METRICS_NAMESPACES", false),     // metrics of each namespace
+		Workloads:         envToBool("KS_METRICS_WORKLOADS", false),      // metrics of each workload
+		MaxSeries:         envToInt("KS_METRICS_MAX_SERIES", 10000),      // cardinality cap of the namespaces and workloads metrics
+		AllowedNamespaces: splitEnv("KS_METRICS_ALLOWED_NAMESPACES", ""), // namespaces of the namespaces and workloads metrics
 	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Write(f)
 }
 
 func getPrometheusDefaultScanCommand(scanID, resultsFile string) *cautils.ScanInfo {
@@ -82,6 +192,11 @@ func getPrometheusDefaultScanCommand(scanID, resultsFile string) *cautils.ScanIn
 	scanInfo.ComplianceThreshold = 0                         // Do not fail scanning
 	scanInfo.Output = resultsFile                            // results output
 	scanInfo.Format = envToString("KS_FORMAT", "prometheus") // default output should be json
+	breakdown := getPrometheusBreakdown()
+	scanInfo.PrometheusNamespaces = breakdown.Namespaces
+	scanInfo.PrometheusWorkloads = breakdown.Workloads
+	scanInfo.PrometheusMaxSeries = breakdown.MaxSeries
+	scanInfo.PrometheusAllowList = breakdown.AllowedNamespaces
 	scanInfo.SetPolicyIdentifiers(getter.NativeFrameworks, apisv1.KindFramework)
 	return scanInfo
 }
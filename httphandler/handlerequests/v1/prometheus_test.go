@@ -1,11 +1,22 @@
 package v1
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/cautils/getter"
+	apisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
+	utilsmetav1 "github.com/kubescape/opa-utils/httpserver/meta/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetPrometheusDefaultScanCommand(t *testing.T) {
@@ -23,3 +34,169 @@ func TestGetPrometheusDefaultScanCommand(t *testing.T) {
 	assert.False(t, scanInfo.HostSensorEnabled.GetBool())
 	assert.Equal(t, getter.DefaultLocalStore, scanInfo.UseArtifactsFrom)
 }
+
+func TestMetricsCache(t *testing.T) {
+	now := time.Now()
+	cache := newMetricsCache(10 * time.Minute)
+
+	metrics, err := cache.get()
+	assert.Nil(t, metrics)
+	assert.NoError(t, err)
+
+	// a single scan at a time
+	assert.True(t, cache.startRefresh(now))
+	assert.False(t, cache.startRefresh(now))
+
+	// a failed scan is retried later
+	cache.endRefresh(errors.New("failed"), now)
+	metrics, err = cache.get()
+	assert.Nil(t, metrics)
+	assert.EqualError(t, err, "failed")
+	assert.False(t, cache.startRefresh(now.Add(30*time.Second)))
+	assert.True(t, cache.startRefresh(now.Add(metricsRetryInterval)))
+
+	// the metrics are served until they are older than the refresh interval
+	cache.set([]byte("kubescape_cluster_complianceScore{} 80"), now.Add(metricsRetryInterval))
+	cache.endRefresh(nil, now.Add(metricsRetryInterval))
+	metrics, err = cache.get()
+	assert.Equal(t, []byte("kubescape_cluster_complianceScore{} 80"), metrics)
+	assert.NoError(t, err)
+	assert.False(t, cache.startRefresh(now.Add(5*time.Minute)))
+	assert.True(t, cache.startRefresh(now.Add(time.Hour)))
+
+	// the previous metrics are kept when the refresh fails
+	cache.endRefresh(errors.New("failed"), now.Add(time.Hour))
+	metrics, _ = cache.get()
+	assert.Equal(t, []byte("kubescape_cluster_complianceScore{} 80"), metrics)
+
+	// the metrics of the latest completed scan are kept
+	cache.set([]byte("kubescape_cluster_complianceScore{} 90"), now.Add(time.Hour))
+	metrics, err = cache.get()
+	assert.Equal(t, []byte("kubescape_cluster_complianceScore{} 90"), metrics)
+	assert.NoError(t, err)
+	assert.False(t, cache.startRefresh(now.Add(time.Hour+time.Minute)))
+}
+
+func TestInMetricsScope(t *testing.T) {
+	assert.True(t, inMetricsScope(getPrometheusDefaultScanCommand("1234", "")))
+
+	frameworks := ToScanInfo(&utilsmetav1.PostScanRequest{TargetType: apisv1.KindFramework, TargetNames: getter.NativeFrameworks})
+	assert.True(t, inMetricsScope(frameworks))
+
+	control := ToScanInfo(&utilsmetav1.PostScanRequest{TargetType: apisv1.KindControl, TargetNames: []string{"C-0016"}})
+	assert.False(t, inMetricsScope(control))
+
+	framework := ToScanInfo(&utilsmetav1.PostScanRequest{TargetType: apisv1.KindFramework, TargetNames: []string{"nsa"}})
+	assert.False(t, inMetricsScope(framework))
+
+	all := ToScanInfo(&utilsmetav1.PostScanRequest{})
+	assert.False(t, inMetricsScope(all))
+
+	namespaces := ToScanInfo(&utilsmetav1.PostScanRequest{TargetType: apisv1.KindFramework, TargetNames: getter.NativeFrameworks, IncludeNamespaces: []string{"prod"}})
+	assert.False(t, inMetricsScope(namespaces))
+}
+
+func TestMetrics(t *testing.T) {
+	defer func(o string) { OutputDir = o }(OutputDir)
+	OutputDir = t.TempDir()
+
+	var scans atomic.Int32
+	release := make(chan struct{})
+	defer func(o scanner) { scanImpl = o }(scanImpl)
+	scanImpl = func(context.Context, *cautils.ScanInfo, string) ([]byte, error) {
+		scans.Add(1)
+		<-release
+		return []byte("kubescape_cluster_complianceScore{} 80\n"), nil
+	}
+
+	h := NewHTTPHandler(false)
+	getMetrics := func() (int, string) {
+		w := httptest.NewRecorder()
+		h.Metrics(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+		body, err := io.ReadAll(w.Result().Body)
+		require.NoError(t, err)
+		return w.Result().StatusCode, string(body)
+	}
+
+	// the requests do not wait for the scan
+	code, body := getMetrics()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, `"type":"busy"`)
+	code, _ = getMetrics()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		metrics, _ := h.metrics.get()
+		return metrics != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the metrics are served from memory
+	for i := 0; i < 2; i++ {
+		code, body = getMetrics()
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "kubescape_cluster_complianceScore{} 80\n", body)
+	}
+	assert.Equal(t, int32(1), scans.Load())
+}
+
+func TestMetricsScanFailure(t *testing.T) {
+	defer func(o string) { OutputDir = o }(OutputDir)
+	OutputDir = t.TempDir()
+
+	defer func(o scanner) { scanImpl = o }(scanImpl)
+	scanImpl = func(context.Context, *cautils.ScanInfo, string) ([]byte, error) {
+		return nil, errors.New("no cluster")
+	}
+
+	h := NewHTTPHandler(false)
+	require.True(t, h.metrics.startRefresh(time.Now()))
+	h.refreshMetrics(context.Background())
+
+	// the scan is not retried right away
+	w := httptest.NewRecorder()
+	h.Metrics(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+	assert.False(t, h.metrics.refreshing)
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "no cluster")
+}
+
+func TestMetricsOfCompletedScan(t *testing.T) {
+	defer func(o scanner) { scanImpl = o }(scanImpl)
+	scanImpl = func(_ context.Context, scanInfo *cautils.ScanInfo, _ string) ([]byte, error) {
+		if !inMetricsScope(scanInfo) {
+			return nil, nil
+		}
+		return []byte("kubescape_cluster_complianceScore{} 80\n"), nil
+	}
+
+	h := NewHTTPHandler(false)
+	executeScan := func(scanInfo *cautils.ScanInfo) {
+		scanParams := &scanRequestParams{
+			scanQueryParams: &ScanQueryParams{},
+			scanInfo:        scanInfo,
+			scanID:          "1234",
+			ctx:             context.Background(),
+			resp:            make(chan *utilsmetav1.Response, 1),
+		}
+		h.state.setBusy(scanParams.scanID)
+		h.executeScan(scanParams)
+	}
+
+	// a scan of another scope does not replace the metrics
+	executeScan(ToScanInfo(&utilsmetav1.PostScanRequest{TargetType: apisv1.KindControl, TargetNames: []string{"C-0016"}}))
+	metrics, _ := h.metrics.get()
+	assert.Nil(t, metrics)
+
+	// the metrics of a completed scan of the metrics scope are served without scanning again
+	executeScan(ToScanInfo(&utilsmetav1.PostScanRequest{TargetType: apisv1.KindFramework, TargetNames: getter.NativeFrameworks}))
+	assert.False(t, h.metrics.startRefresh(time.Now()))
+	w := httptest.NewRecorder()
+	h.Metrics(w, httptest.NewRequest(http.MethodGet, "/v1/metrics", nil))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+	assert.Equal(t, "kubescape_cluster_complianceScore{} 80\n", string(body))
+}
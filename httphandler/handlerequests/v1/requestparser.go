@@ -57,6 +57,27 @@ type StatusQueryParams struct {
 	ScanID string `schema:"id" json:"id"`
 }
 
+// swagger:parameters getHistory
+type HistoryQueryParams struct {
+	// Cluster name, repository URL or path of the scans. If empty or not provided, defaults to all the targets.
+	//
+	// in: query
+	Target string `schema:"target" json:"target"`
+	// Framework of the scores. If empty or not provided, defaults to the compliance scores of the scans.
+	//
+	// in: query
+	Framework string `schema:"framework" json:"framework"`
+	// Scans of the last period only, as a duration, e.g. 168h. If empty or not provided, defaults to all the scans.
+	//
+	// in: query
+	Since string `schema:"since" json:"since"`
+	// Latest scans of each target only. If 0 or not provided, defaults to all the scans.
+	//
+	// in: query
+	// default: 0
+	Limit int `schema:"limit" json:"limit"`
+}
+
 // scanRequestParams params passed to channel
 type scanRequestParams struct {
 	scanInfo        *cautils.ScanInfo // request as received from api
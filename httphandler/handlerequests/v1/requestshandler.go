@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	_ "net/http/pprof"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/schema"
@@ -28,9 +29,12 @@ type ScanResponse struct {
 }
 
 type HTTPHandler struct {
-	offline         bool
-	state           *serverState
-	scanRequestChan chan *scanRequestParams
+	offline            bool
+	state              *serverState
+	scanRequestChan    chan *scanRequestParams
+	metrics            *metricsCache
+	admissionMu        sync.Mutex // guards the admission validator
+	admissionValidator IAdmissionValidator
 }
 
 func NewHTTPHandler(offline bool) *HTTPHandler {
@@ -38,6 +42,7 @@ func NewHTTPHandler(offline bool) *HTTPHandler {
 		offline:         offline,
 		state:           newServerState(),
 		scanRequestChan: make(chan *scanRequestParams),
+		metrics:         newMetricsCache(envToDuration("KS_METRICS_REFRESH_INTERVAL", defaultMetricsRefreshInterval)),
 	}
 	go handler.watchForScan()
 	return handler
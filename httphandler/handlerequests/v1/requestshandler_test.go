@@ -10,7 +10,6 @@ import (
 
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	utilsmetav1 "github.com/kubescape/opa-utils/httpserver/meta/v1"
-	reporthandlingv2 "github.com/kubescape/opa-utils/reporthandling/v2"
 )
 
 func testBody(t *testing.T) io.Reader {
@@ -22,7 +21,7 @@ func testBody(t *testing.T) io.Reader {
 	return bytes.NewReader(b)
 }
 
-type scanner func(_ context.Context, _ *cautils.ScanInfo, _ string) (*reporthandlingv2.PostureReport, error)
+type scanner func(_ context.Context, _ *cautils.ScanInfo, _ string) ([]byte, error)
 
 // TestScan tests that the scan handler passes the scan requests correctly to the underlying scan engine.
 func TestScan(t *testing.T) {
@@ -30,7 +29,7 @@ func TestScan(t *testing.T) {
 	// Our scanner is not setting up the k8s connection; the test is covering the rest of the wiring
 	// that the signaling from the http handler goes all the way to the scanner implementation.
 	defer func(o scanner) { scanImpl = o }(scanImpl)
-	scanImpl = func(context.Context, *cautils.ScanInfo, string) (*reporthandlingv2.PostureReport, error) {
+	scanImpl = func(context.Context, *cautils.ScanInfo, string) ([]byte, error) {
 		return nil, nil
 	}
 
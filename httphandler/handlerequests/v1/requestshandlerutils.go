@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/armosec/utils-go/boolutils"
 	"github.com/kubescape/backend/pkg/versioncheck"
@@ -15,6 +17,7 @@ import (
 	"github.com/kubescape/kubescape/v3/core/cautils"
 	"github.com/kubescape/kubescape/v3/core/cautils/getter"
 	"github.com/kubescape/kubescape/v3/core/core"
+	printerv2 "github.com/kubescape/kubescape/v3/core/pkg/resultshandling/printer/v2"
 	"github.com/kubescape/kubescape/v3/httphandler/config"
 	"github.com/kubescape/kubescape/v3/httphandler/storage"
 	utilsapisv1 "github.com/kubescape/opa-utils/httpserver/apis/v1"
@@ -30,7 +33,7 @@ func (handler *HTTPHandler) executeScan(scanReq *scanRequestParams) {
 	response := &utilsmetav1.Response{}
 
 	logger.L().Info("scan triggered", helpers.String("ID", scanReq.scanID))
-	_, err := scanImpl(scanReq.ctx, scanReq.scanInfo, scanReq.scanID)
+	metrics, err := scanImpl(scanReq.ctx, scanReq.scanInfo, scanReq.scanID)
 	if err != nil {
 		logger.L().Ctx(scanReq.ctx).Error("scanning failed", helpers.String("ID", scanReq.scanID), helpers.Error(err))
 		if scanReq.scanQueryParams.ReturnResults {
@@ -39,6 +42,9 @@ func (handler *HTTPHandler) executeScan(scanReq *scanRequestParams) {
 		}
 	} else {
 		logger.L().Ctx(scanReq.ctx).Success("done scanning", helpers.String("ID", scanReq.scanID))
+		if metrics != nil {
+			handler.metrics.set(metrics, time.Now()) // the metrics endpoint serves the latest scan of the metrics scope
+		}
 		if scanReq.scanQueryParams.ReturnResults {
 			//TODO(ttimonen) should we actually pass the PostureReport here somehow?
 			response.Type = utilsapisv1.ResultsV1ScanResponseType
@@ -62,7 +68,10 @@ func (handler *HTTPHandler) watchForScan() {
 		handler.executeScan(scanReq)
 	}
 }
-func scan(ctx context.Context, scanInfo *cautils.ScanInfo, scanID string) (*reporthandlingv2.PostureReport, error) {
+
+// scan runs the scan and handles its results, it returns the results in the Prometheus text format when the scan has the
+// scope of the metrics scan
+func scan(ctx context.Context, scanInfo *cautils.ScanInfo, scanID string) ([]byte, error) {
 	ctx, spanScan := otel.Tracer("").Start(ctx, "kubescape.scan")
 	defer spanScan.End()
 
@@ -83,9 +92,14 @@ func scan(ctx context.Context, scanInfo *cautils.ScanInfo, scanID string) (*repo
 	if err != nil {
 		return nil, writeScanErrorToFile(err, scanID)
 	}
+	defer result.Close()
 	if err := result.HandleResults(ctx); err != nil {
 		return nil, err
 	}
+	var metrics []byte
+	if inMetricsScope(scanInfo) {
+		metrics = printerv2.NewPrometheusPrinter(false, getPrometheusBreakdown()).Metrics(result.GetData())
+	}
 	storage := storage.GetStorage()
 	if storage != nil {
 		pr := result.GetResults()
@@ -97,7 +111,7 @@ func scan(ctx context.Context, scanInfo *cautils.ScanInfo, scanID string) (*repo
 		logger.L().Debug("storage is not initialized - skipping storing results")
 	}
 
-	return nil, nil
+	return metrics, nil
 }
 
 func readResultsFile(fileID string) (*reporthandlingv2.PostureReport, error) {
@@ -186,6 +200,8 @@ func defaultScanInfo() *cautils.ScanInfo {
 	scanInfo.Local = envToBool("KS_KEEP_LOCAL", false)                             // do not publish results to Kubescape SaaS
 	scanInfo.EnableRegoPrint = envToBool("KS_REGO_PRINT", false)                   // print rego rules
 	scanInfo.HostSensorEnabled.SetBool(envToBool("KS_ENABLE_HOST_SCANNER", false)) // enable host scanner
+	scanInfo.KeepHistory = envToBool("KS_KEEP_HISTORY", false)                     // record the scans in the history store
+	scanInfo.HistoryFile = envToString("KS_HISTORY_FILE", "")                      // path to the history store
 	if !envToBool("KS_DOWNLOAD_ARTIFACTS", false) {
 		scanInfo.UseArtifactsFrom = getter.DefaultLocalStore // Load files from cache (this will prevent kubescape fom downloading the artifacts every time)
 	}
@@ -207,6 +223,26 @@ func envToString(env string, defaultValue string) string {
 	return defaultValue
 }
 
+func envToInt(env string, defaultValue int) int {
+	if d, ok := os.LookupEnv(env); ok {
+		if i, err := strconv.Atoi(d); err == nil {
+			return i
+		}
+		logger.L().Warning("invalid integer, using the default value", helpers.String("env", env), helpers.String("value", d))
+	}
+	return defaultValue
+}
+
+func envToDuration(env string, defaultValue time.Duration) time.Duration {
+	if d, ok := os.LookupEnv(env); ok {
+		if duration, err := time.ParseDuration(d); err == nil {
+			return duration
+		}
+		logger.L().Warning("invalid duration, using the default value", helpers.String("env", env), helpers.String("value", d))
+	}
+	return defaultValue
+}
+
 func writeScanErrorToFile(err error, scanID string) error {
 	if e := os.MkdirAll(filepath.Dir(FailedOutputDir), os.ModePerm); e != nil {
 		return fmt.Errorf("failed to scan. reason: '%s'. failed to save error in file - failed to create directory. reason: %s", err.Error(), e.Error())
@@ -23,6 +23,8 @@ const (
 	v1StatusPath            = "/status"
 	v1ResultsPath           = "/results"
 	v1PrometheusMetricsPath = "/metrics"
+	v1AdmissionPath         = "/admission"
+	v1HistoryPath           = "/history"
 
 	// healtcheck paths
 	livePath  = "/livez"
@@ -31,7 +33,7 @@ const (
 
 // SetupHTTPListener set up listening http servers
 func SetupHTTPListener() error {
-	keyPair, err := loadTLSKey("", "") // TODO - support key and crt files
+	keyPair, err := loadTLSKey(os.Getenv("KS_TLS_CERT_FILE"), os.Getenv("KS_TLS_KEY_FILE")) // admission webhooks are served over TLS
 	if err != nil {
 		return err
 	}
@@ -62,6 +64,8 @@ func SetupHTTPListener() error {
 	v1SubRouter.HandleFunc(v1ScanPath, httpHandler.Scan)
 	v1SubRouter.HandleFunc(v1StatusPath, httpHandler.Status)
 	v1SubRouter.HandleFunc(v1ResultsPath, httpHandler.Results)
+	v1SubRouter.HandleFunc(v1AdmissionPath, httpHandler.Admission)
+	v1SubRouter.HandleFunc(v1HistoryPath, httpHandler.History)
 
 	// OpenTelemetry metrics initialization
 	metrics.Init()